@@ -0,0 +1,238 @@
+// glightning is a small command line client for c-lightning, built on
+// the glightning library. It's a lightning-cli alternative, and an
+// example of using the RPC client.
+//
+// Usage:
+//
+//	glightning [flags] <method> [key=value ...]
+//
+// Parameters are given by name. Values which parse as JSON are sent
+// as-is (numbers, booleans, arrays, objects); anything else is sent
+// as a string. Results are printed as indented JSON.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/elementsproject/glightning/glightning"
+)
+
+func main() {
+	home, _ := os.UserHomeDir()
+	lightningDir := flag.String("lightning-dir", filepath.Join(home, ".lightning", "bitcoin"), "lightningd's network directory")
+	rpcFile := flag.String("rpc-file", "lightning-rpc", "name of the RPC socket in lightning-dir")
+	timeout := flag.Uint("timeout", 60, "seconds to wait for a response")
+	raw := flag.Bool("raw", false, "always use the untyped generic call")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	method := flag.Arg(0)
+	params, err := parseParams(flag.Args()[1:])
+	if err != nil {
+		fail(err)
+	}
+
+	ln := glightning.NewLightning()
+	ln.SetTimeout(*timeout)
+	if err := ln.StartUp(*rpcFile, *lightningDir); err != nil {
+		fail(err)
+	}
+	defer ln.Shutdown()
+
+	result, err := call(ln, method, params, *raw)
+	if err != nil {
+		fail(err)
+	}
+
+	out, err := json.MarshalIndent(result, "", "   ")
+	if err != nil {
+		fail(err)
+	}
+	fmt.Println(string(out))
+}
+
+// Use the typed wrapper for {method} if there is one which takes
+// exactly these params, otherwise fall back to a generic call
+func call(ln glightning.LightningClient, method string, params map[string]interface{}, raw bool) (interface{}, error) {
+	if cmd, ok := typedCommands[method]; ok && !raw && cmd.accepts(params) {
+		return cmd.run(ln, params)
+	}
+	return ln.Call(method, params)
+}
+
+type typedCommand struct {
+	// param names this wrapper understands; all are optional
+	params []string
+	run    func(ln glightning.LightningClient, params map[string]interface{}) (interface{}, error)
+}
+
+func (c *typedCommand) accepts(params map[string]interface{}) bool {
+	for name, val := range params {
+		if _, ok := val.(string); !ok {
+			return false
+		}
+		found := false
+		for _, p := range c.params {
+			if p == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func str(params map[string]interface{}, name string) string {
+	s, _ := params[name].(string)
+	return s
+}
+
+var typedCommands = map[string]*typedCommand{
+	"getinfo": {
+		run: func(ln glightning.LightningClient, _ map[string]interface{}) (interface{}, error) {
+			return ln.GetInfo()
+		},
+	},
+	"listpeers": {
+		params: []string{"id"},
+		run: func(ln glightning.LightningClient, params map[string]interface{}) (interface{}, error) {
+			if id := str(params, "id"); id != "" {
+				return ln.GetPeer(id)
+			}
+			return ln.ListPeers()
+		},
+	},
+	"listnodes": {
+		params: []string{"id"},
+		run: func(ln glightning.LightningClient, params map[string]interface{}) (interface{}, error) {
+			if id := str(params, "id"); id != "" {
+				return ln.GetNode(id)
+			}
+			return ln.ListNodes()
+		},
+	},
+	"listchannels": {
+		params: []string{"short_channel_id", "source", "destination"},
+		run: func(ln glightning.LightningClient, params map[string]interface{}) (interface{}, error) {
+			if len(params) > 1 {
+				return nil, fmt.Errorf("Can only filter listchannels by one of short_channel_id, source or destination")
+			}
+			switch {
+			case str(params, "short_channel_id") != "":
+				return ln.GetChannel(str(params, "short_channel_id"))
+			case str(params, "source") != "":
+				return ln.ListChannelsBySource(str(params, "source"))
+			case str(params, "destination") != "":
+				return ln.ListChannelsByDestination(str(params, "destination"))
+			}
+			return ln.ListChannels()
+		},
+	},
+	"listinvoices": {
+		params: []string{"label", "payment_hash"},
+		run: func(ln glightning.LightningClient, params map[string]interface{}) (interface{}, error) {
+			switch {
+			case str(params, "label") != "":
+				return ln.GetInvoice(str(params, "label"))
+			case str(params, "payment_hash") != "":
+				return ln.GetInvoiceByHash(str(params, "payment_hash"))
+			}
+			return ln.ListInvoices()
+		},
+	},
+	"listfunds": {
+		run: func(ln glightning.LightningClient, _ map[string]interface{}) (interface{}, error) {
+			return ln.ListFunds()
+		},
+	},
+	"listforwards": {
+		run: func(ln glightning.LightningClient, _ map[string]interface{}) (interface{}, error) {
+			return ln.ListForwards()
+		},
+	},
+	"listpays": {
+		params: []string{"bolt11"},
+		run: func(ln glightning.LightningClient, params map[string]interface{}) (interface{}, error) {
+			if bolt11 := str(params, "bolt11"); bolt11 != "" {
+				return ln.ListPaysToBolt11(bolt11)
+			}
+			return ln.ListPays()
+		},
+	},
+	"decodepay": {
+		params: []string{"bolt11", "description"},
+		run: func(ln glightning.LightningClient, params map[string]interface{}) (interface{}, error) {
+			return ln.DecodePay(str(params, "bolt11"), str(params, "description"))
+		},
+	},
+	"help": {
+		params: []string{"command"},
+		run: func(ln glightning.LightningClient, params map[string]interface{}) (interface{}, error) {
+			if command := str(params, "command"); command != "" {
+				return ln.HelpFor(command)
+			}
+			return ln.Help()
+		},
+	},
+	"feerates": {
+		params: []string{"style"},
+		run: func(ln glightning.LightningClient, params map[string]interface{}) (interface{}, error) {
+			if str(params, "style") == "perkb" {
+				return ln.FeeRates(glightning.PerKb)
+			}
+			return ln.FeeRates(glightning.PerKw)
+		},
+	},
+}
+
+// Parse key=value arguments into RPC params
+func parseParams(args []string) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	for _, arg := range args {
+		i := strings.IndexByte(arg, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("Parameter %q must be of the form key=value", arg)
+		}
+		key, val := arg[:i], arg[i+1:]
+		var parsed interface{}
+		// strings that happen to be valid JSON numbers (e.g. a label
+		// of "12") are sent as numbers; lightningd accepts either
+		if err := json.Unmarshal([]byte(val), &parsed); err == nil {
+			params[key] = parsed
+		} else {
+			params[key] = val
+		}
+	}
+	return params, nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s [flags] <method> [key=value ...]\n\n", filepath.Base(os.Args[0]))
+	flag.PrintDefaults()
+
+	names := make([]string, 0, len(typedCommands))
+	for name := range typedCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "\nTyped commands: %s\nAny other method is passed through as-is.\n", strings.Join(names, ", "))
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
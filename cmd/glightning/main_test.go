@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseParams(t *testing.T) {
+	params, err := parseParams([]string{"label=inv", "msatoshi=1000", "exposeprivatechannels=true", `fallbacks=["bcrt1q"]`})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"label":                 "inv",
+		"msatoshi":              float64(1000),
+		"exposeprivatechannels": true,
+		"fallbacks":             []interface{}{"bcrt1q"},
+	}, params)
+
+	_, err = parseParams([]string{"nokey"})
+	assert.Error(t, err)
+}
+
+func TestCallTyped(t *testing.T) {
+	ln := mock.New()
+	ln.GetInvoiceFunc = func(label string) (*glightning.Invoice, error) {
+		return &glightning.Invoice{Label: label}, nil
+	}
+	result, err := call(ln, "listinvoices", map[string]interface{}{"label": "inv"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, &glightning.Invoice{Label: "inv"}, result)
+}
+
+func TestCallGenericFallback(t *testing.T) {
+	ln := mock.New()
+	ln.CallFunc = func(method string, params map[string]interface{}) (json.RawMessage, error) {
+		return json.RawMessage(`{"ok":true}`), nil
+	}
+
+	// no typed wrapper
+	_, err := call(ln, "listoffers", nil, false)
+	assert.NoError(t, err)
+	// typed wrapper, but it doesn't take this param
+	_, err = call(ln, "listinvoices", map[string]interface{}{"offer_id": "aa"}, false)
+	assert.NoError(t, err)
+	// forced
+	_, err = call(ln, "getinfo", nil, true)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"Call", "Call", "Call"}, ln.Calls())
+}
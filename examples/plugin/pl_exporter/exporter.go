@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/exporter"
+)
+
+// Serves node metrics for Prometheus; set where with
+// --exporter-listen=host:port
+func main() {
+	e := exporter.New()
+	plugin := glightning.NewPlugin(e.Init)
+	if err := e.Register(plugin); err != nil {
+		log.Fatal(err)
+	}
+
+	err := plugin.Start(os.Stdin, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
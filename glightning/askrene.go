@@ -0,0 +1,350 @@
+package glightning
+
+import (
+	"fmt"
+)
+
+// The askrene plugin offers route finding over the gossip graph, modified
+// by any number of named 'layers'. Layers can add channels, disable nodes,
+// bias channels or record what we've learned about a channel's liquidity.
+
+type GetRoutesRequest struct {
+	Source      string   `json:"source"`
+	Destination string   `json:"destination"`
+	AmountMsat  string   `json:"amount_msat"`
+	Layers      []string `json:"layers"`
+	MaxFeeMsat  string   `json:"maxfee_msat"`
+	FinalCltv   uint32   `json:"final_cltv"`
+	MaxDelay    uint32   `json:"maxdelay,omitempty"`
+}
+
+func (r *GetRoutesRequest) Name() string {
+	return "getroutes"
+}
+
+type GetRoutesResult struct {
+	ProbabilityPpm uint64          `json:"probability_ppm"`
+	Routes         []*AskReneRoute `json:"routes"`
+}
+
+type AskReneRoute struct {
+	ProbabilityPpm uint64        `json:"probability_ppm"`
+	AmountMsat     string        `json:"amount_msat"`
+	FinalCltv      uint32        `json:"final_cltv"`
+	Path           []*AskReneHop `json:"path"`
+}
+
+type AskReneHop struct {
+	ShortChannelIdDir string `json:"short_channel_id_dir"`
+	NextNodeId        string `json:"next_node_id"`
+	AmountMsat        string `json:"amount_msat"`
+	Delay             uint32 `json:"delay"`
+}
+
+// Find routes from {source} to {destination} for {amount}, using the
+// gossip graph as modified by the given {layers} (applied in order).
+// Routes will pay no more than {maxFee} in fees.
+//
+// The returned routes together deliver the full {amount}; each route's
+// 'ProbabilityPpm' is the estimated chance of success, in parts-per-million.
+func (l *Lightning) GetRoutes(source, destination string, amount *MSat, layers []string, maxFee *MSat, finalCltv uint32) (*GetRoutesResult, error) {
+	if source == "" || destination == "" {
+		return nil, fmt.Errorf("Must provide a source and destination")
+	}
+	if amount == nil || amount.Value == 0 {
+		return nil, fmt.Errorf("Must set an amount to route")
+	}
+	if maxFee == nil {
+		return nil, fmt.Errorf("Must set a maximum fee")
+	}
+	if layers == nil {
+		layers = []string{}
+	}
+
+	var result GetRoutesResult
+	err := l.rpc.Request(&GetRoutesRequest{
+		Source:      source,
+		Destination: destination,
+		AmountMsat:  amount.String(),
+		Layers:      layers,
+		MaxFeeMsat:  maxFee.String(),
+		FinalCltv:   finalCltv,
+	}, &result)
+	return &result, err
+}
+
+type AskReneLayer struct {
+	Layer           string                   `json:"layer"`
+	Persistent      bool                     `json:"persistent"`
+	DisabledNodes   []string                 `json:"disabled_nodes"`
+	CreatedChannels []*AskReneCreatedChannel `json:"created_channels"`
+	Constraints     []*AskReneConstraint     `json:"constraints"`
+	Biases          []*AskReneBias           `json:"biases"`
+}
+
+type AskReneCreatedChannel struct {
+	Source         string `json:"source"`
+	Destination    string `json:"destination"`
+	ShortChannelId string `json:"short_channel_id"`
+	CapacityMsat   string `json:"capacity_msat"`
+}
+
+type AskReneConstraint struct {
+	ShortChannelIdDir string `json:"short_channel_id_dir"`
+	Layer             string `json:"layer,omitempty"`
+	Timestamp         uint64 `json:"timestamp"`
+	MinimumMsat       string `json:"minimum_msat,omitempty"`
+	MaximumMsat       string `json:"maximum_msat,omitempty"`
+}
+
+type AskReneBias struct {
+	Layer             string `json:"layer,omitempty"`
+	ShortChannelIdDir string `json:"short_channel_id_dir"`
+	Bias              int    `json:"bias"`
+	Description       string `json:"description,omitempty"`
+}
+
+type askReneLayersResult struct {
+	Layers []*AskReneLayer `json:"layers"`
+}
+
+type AskReneCreateLayerRequest struct {
+	Layer      string `json:"layer"`
+	Persistent bool   `json:"persistent,omitempty"`
+}
+
+func (r *AskReneCreateLayerRequest) Name() string {
+	return "askrene-create-layer"
+}
+
+// Create a new, empty layer named {layer}. Persistent layers are
+// saved to the datastore and survive restarts.
+func (l *Lightning) AskReneCreateLayer(layer string, persistent bool) (*AskReneLayer, error) {
+	if layer == "" {
+		return nil, fmt.Errorf("Must provide a layer name")
+	}
+
+	var result askReneLayersResult
+	err := l.rpc.Request(&AskReneCreateLayerRequest{layer, persistent}, &result)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Layers) == 0 {
+		return nil, fmt.Errorf("Layer %s not returned on creation", layer)
+	}
+	return result.Layers[0], nil
+}
+
+type AskReneRemoveLayerRequest struct {
+	Layer string `json:"layer"`
+}
+
+func (r *AskReneRemoveLayerRequest) Name() string {
+	return "askrene-remove-layer"
+}
+
+func (l *Lightning) AskReneRemoveLayer(layer string) error {
+	var result struct{}
+	return l.rpc.Request(&AskReneRemoveLayerRequest{layer}, &result)
+}
+
+type AskReneListLayersRequest struct {
+	Layer string `json:"layer,omitempty"`
+}
+
+func (r *AskReneListLayersRequest) Name() string {
+	return "askrene-listlayers"
+}
+
+// List all the layers askrene knows about
+func (l *Lightning) AskReneListLayers() ([]*AskReneLayer, error) {
+	var result askReneLayersResult
+	err := l.rpc.Request(&AskReneListLayersRequest{}, &result)
+	return result.Layers, err
+}
+
+// Show layer {layer}
+func (l *Lightning) AskReneGetLayer(layer string) (*AskReneLayer, error) {
+	var result askReneLayersResult
+	err := l.rpc.Request(&AskReneListLayersRequest{layer}, &result)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Layers) == 0 {
+		return nil, fmt.Errorf("Layer %s not found", layer)
+	}
+	return result.Layers[0], nil
+}
+
+type AskReneInform string
+
+const (
+	// The amount couldn't pass through the channel
+	InformConstrained AskReneInform = "constrained"
+	// The amount could pass through the channel, but failed further along
+	InformUnconstrained AskReneInform = "unconstrained"
+	// The amount passed through the channel and the payment succeeded
+	InformSucceeded AskReneInform = "succeeded"
+)
+
+type AskReneInformChannelRequest struct {
+	Layer             string        `json:"layer"`
+	ShortChannelIdDir string        `json:"short_channel_id_dir"`
+	AmountMsat        string        `json:"amount_msat"`
+	Inform            AskReneInform `json:"inform"`
+}
+
+func (r *AskReneInformChannelRequest) Name() string {
+	return "askrene-inform-channel"
+}
+
+// Record in {layer} what we learned about the liquidity of channel
+// {scidDir} (scid/direction) when trying to send {amount} through it.
+// Returns the resulting constraints on the channel.
+func (l *Lightning) AskReneInformChannel(layer, scidDir string, amount *MSat, inform AskReneInform) ([]*AskReneConstraint, error) {
+	if amount == nil {
+		return nil, fmt.Errorf("Must provide the amount that was attempted")
+	}
+
+	var result struct {
+		Constraints []*AskReneConstraint `json:"constraints"`
+	}
+	err := l.rpc.Request(&AskReneInformChannelRequest{
+		Layer:             layer,
+		ShortChannelIdDir: scidDir,
+		AmountMsat:        amount.String(),
+		Inform:            inform,
+	}, &result)
+	return result.Constraints, err
+}
+
+type AskReneDisableNodeRequest struct {
+	Layer string `json:"layer"`
+	Node  string `json:"node"`
+}
+
+func (r *AskReneDisableNodeRequest) Name() string {
+	return "askrene-disable-node"
+}
+
+// Don't route through {node} when using {layer}
+func (l *Lightning) AskReneDisableNode(layer, node string) error {
+	var result struct{}
+	return l.rpc.Request(&AskReneDisableNodeRequest{layer, node}, &result)
+}
+
+type AskReneBiasChannelRequest struct {
+	Layer             string `json:"layer"`
+	ShortChannelIdDir string `json:"short_channel_id_dir"`
+	Bias              int    `json:"bias"`
+	Description       string `json:"description,omitempty"`
+	Relative          bool   `json:"relative,omitempty"`
+}
+
+func (r *AskReneBiasChannelRequest) Name() string {
+	return "askrene-bias-channel"
+}
+
+// Bias channel {scidDir} in {layer}, from -100 (avoid) to +100 (prefer).
+// If {relative}, the bias is added to any existing bias.
+func (l *Lightning) AskReneBiasChannel(layer, scidDir string, bias int, description string, relative bool) ([]*AskReneBias, error) {
+	if bias < -100 || bias > 100 {
+		return nil, fmt.Errorf("Bias must be between -100 and 100, not %d", bias)
+	}
+
+	var result struct {
+		Biases []*AskReneBias `json:"biases"`
+	}
+	err := l.rpc.Request(&AskReneBiasChannelRequest{
+		Layer:             layer,
+		ShortChannelIdDir: scidDir,
+		Bias:              bias,
+		Description:       description,
+		Relative:          relative,
+	}, &result)
+	return result.Biases, err
+}
+
+type AskReneCreateChannelRequest struct {
+	Layer          string `json:"layer"`
+	Source         string `json:"source"`
+	Destination    string `json:"destination"`
+	ShortChannelId string `json:"short_channel_id"`
+	CapacityMsat   string `json:"capacity_msat"`
+}
+
+func (r *AskReneCreateChannelRequest) Name() string {
+	return "askrene-create-channel"
+}
+
+// Add a channel which isn't in gossip (e.g. a private channel, or
+// one from a route hint) to {layer}
+func (l *Lightning) AskReneCreateChannel(layer, source, destination, shortChannelId string, capacity *MSat) error {
+	if capacity == nil {
+		return fmt.Errorf("Must provide the channel's capacity")
+	}
+
+	var result struct{}
+	return l.rpc.Request(&AskReneCreateChannelRequest{
+		Layer:          layer,
+		Source:         source,
+		Destination:    destination,
+		ShortChannelId: shortChannelId,
+		CapacityMsat:   capacity.String(),
+	}, &result)
+}
+
+type AskReneReservation struct {
+	ShortChannelIdDir string `json:"short_channel_id_dir"`
+	AmountMsat        string `json:"amount_msat"`
+}
+
+type AskReneReserveRequest struct {
+	Path []*AskReneReservation `json:"path"`
+}
+
+func (r *AskReneReserveRequest) Name() string {
+	return "askrene-reserve"
+}
+
+type AskReneUnreserveRequest struct {
+	Path []*AskReneReservation `json:"path"`
+}
+
+func (r *AskReneUnreserveRequest) Name() string {
+	return "askrene-unreserve"
+}
+
+// Tell askrene that we're using the capacity along {path}, so
+// it won't be offered to other route queries until unreserved.
+func (l *Lightning) AskReneReserve(path []*AskReneReservation) error {
+	var result struct{}
+	return l.rpc.Request(&AskReneReserveRequest{path}, &result)
+}
+
+// Release the capacity along {path} reserved by AskReneReserve
+func (l *Lightning) AskReneUnreserve(path []*AskReneReservation) error {
+	var result struct{}
+	return l.rpc.Request(&AskReneUnreserveRequest{path}, &result)
+}
+
+type AskReneAgeRequest struct {
+	Layer  string `json:"layer"`
+	Cutoff uint64 `json:"cutoff"`
+}
+
+func (r *AskReneAgeRequest) Name() string {
+	return "askrene-age"
+}
+
+type AskReneAgeResult struct {
+	Layer      string `json:"layer"`
+	NumRemoved uint64 `json:"num_removed"`
+}
+
+// Remove constraints in {layer} older than the unix timestamp {cutoff}
+func (l *Lightning) AskReneAge(layer string, cutoff uint64) (*AskReneAgeResult, error) {
+	var result AskReneAgeResult
+	err := l.rpc.Request(&AskReneAgeRequest{layer, cutoff}, &result)
+	return &result, err
+}
@@ -0,0 +1,146 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRoutes(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"getroutes","params":{"amount_msat":"1000000msat","destination":"035d2b1192dfba134e10e540875d366ebc8bc353d5aa766b80c090b39c3a5d885d","final_cltv":18,"layers":["auto.localchans"],"maxfee_msat":"5000msat","source":"0266e4598d1d3c415f572a8488830b60f7e744ed9235eb0b1ba93283b315c03518"},"id":1}`
+	resp := wrapResult(1, `{
+   "probability_ppm": 998000,
+   "routes": [
+      {
+         "probability_ppm": 998000,
+         "amount_msat": "1000000msat",
+         "final_cltv": 18,
+         "path": [
+            {
+               "short_channel_id_dir": "109x1x1/1",
+               "next_node_id": "022d223620a359a47ff7f7ac447c85c46c923da53389221a0054c11c1e3ca31d59",
+               "amount_msat": "1000011msat",
+               "delay": 24
+            },
+            {
+               "short_channel_id_dir": "123x1x1/0",
+               "next_node_id": "035d2b1192dfba134e10e540875d366ebc8bc353d5aa766b80c090b39c3a5d885d",
+               "amount_msat": "1000000msat",
+               "delay": 18
+            }
+         ]
+      }
+   ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	result, err := lightning.GetRoutes(
+		"0266e4598d1d3c415f572a8488830b60f7e744ed9235eb0b1ba93283b315c03518",
+		"035d2b1192dfba134e10e540875d366ebc8bc353d5aa766b80c090b39c3a5d885d",
+		glightning.NewMsat(1000000),
+		[]string{"auto.localchans"},
+		glightning.NewMsat(5000),
+		18)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.GetRoutesResult{
+		ProbabilityPpm: 998000,
+		Routes: []*glightning.AskReneRoute{
+			&glightning.AskReneRoute{
+				ProbabilityPpm: 998000,
+				AmountMsat:     "1000000msat",
+				FinalCltv:      18,
+				Path: []*glightning.AskReneHop{
+					&glightning.AskReneHop{
+						ShortChannelIdDir: "109x1x1/1",
+						NextNodeId:        "022d223620a359a47ff7f7ac447c85c46c923da53389221a0054c11c1e3ca31d59",
+						AmountMsat:        "1000011msat",
+						Delay:             24,
+					},
+					&glightning.AskReneHop{
+						ShortChannelIdDir: "123x1x1/0",
+						NextNodeId:        "035d2b1192dfba134e10e540875d366ebc8bc353d5aa766b80c090b39c3a5d885d",
+						AmountMsat:        "1000000msat",
+						Delay:             18,
+					},
+				},
+			},
+		},
+	}, result)
+}
+
+func TestAskReneCreateLayer(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"askrene-create-layer","params":{"layer":"test_layer"},"id":1}`
+	resp := wrapResult(1, `{
+   "layers": [
+      {
+         "layer": "test_layer",
+         "persistent": false,
+         "disabled_nodes": [],
+         "created_channels": [],
+         "constraints": [],
+         "biases": []
+      }
+   ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	layer, err := lightning.AskReneCreateLayer("test_layer", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.AskReneLayer{
+		Layer:           "test_layer",
+		DisabledNodes:   []string{},
+		CreatedChannels: []*glightning.AskReneCreatedChannel{},
+		Constraints:     []*glightning.AskReneConstraint{},
+		Biases:          []*glightning.AskReneBias{},
+	}, layer)
+}
+
+func TestAskReneInformChannel(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"askrene-inform-channel","params":{"amount_msat":"100000msat","inform":"constrained","layer":"test_layer","short_channel_id_dir":"0x0x1/1"},"id":1}`
+	resp := wrapResult(1, `{
+   "constraints": [
+      {
+         "short_channel_id_dir": "0x0x1/1",
+         "layer": "test_layer",
+         "timestamp": 1738000000,
+         "maximum_msat": "99999msat"
+      }
+   ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	constraints, err := lightning.AskReneInformChannel("test_layer", "0x0x1/1", glightning.NewMsat(100000), glightning.InformConstrained)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*glightning.AskReneConstraint{
+		&glightning.AskReneConstraint{
+			ShortChannelIdDir: "0x0x1/1",
+			Layer:             "test_layer",
+			Timestamp:         1738000000,
+			MaximumMsat:       "99999msat",
+		},
+	}, constraints)
+}
+
+func TestAskReneDisableNode(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"askrene-disable-node","params":{"layer":"test_layer","node":"022d223620a359a47ff7f7ac447c85c46c923da53389221a0054c11c1e3ca31d59"},"id":1}`
+	resp := wrapResult(1, `{}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	err := lightning.AskReneDisableNode("test_layer", "022d223620a359a47ff7f7ac447c85c46c923da53389221a0054c11c1e3ca31d59")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAskReneBiasChannelRange(t *testing.T) {
+	lightning := glightning.NewLightning()
+	_, err := lightning.AskReneBiasChannel("test_layer", "0x0x1/1", 101, "", false)
+	assert.Error(t, err)
+}
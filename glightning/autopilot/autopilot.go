@@ -0,0 +1,279 @@
+// Package autopilot picks nodes from the channel graph to open
+// channels to, and opens them in a single funding transaction.
+//
+// It's opt-in and does nothing on its own: build an Autopilot, set
+// its budget and call Run whenever you'd like it to top up the
+// node's channels, e.g. from a timer or after a deposit.
+//
+//	g, err := graph.Load(ln)
+//	pilot := autopilot.New(ln, g)
+//	pilot.Budget = 5000000
+//	pilot.ChannelSize = 1000000
+//	result, err := pilot.Run()
+//
+// Candidates are ranked by a Scorer; the default weighs capacity,
+// centrality and uptime equally.
+package autopilot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/graph"
+)
+
+// A node which could be opened to, with what the graph says about
+// it. The normalised figures are relative to the best candidate, so
+// each is between 0 and 1.
+type Candidate struct {
+	Id    string
+	Alias string
+	// Active public channels, and their total capacity
+	Channels     int
+	CapacityMsat uint64
+	// The newest channel_update from the node, as a unix timestamp
+	LastUpdate uint
+
+	// CapacityMsat against the largest candidate's
+	Capacity float64
+	// Channels against the best connected candidate's
+	Centrality float64
+	// How recently the node gossiped, from 1 for the newest update in
+	// the graph down to 0 for one MaxAge older. Nodes which keep
+	// sending channel_updates are online to send them.
+	Uptime float64
+
+	// As given by the Autopilot's Scorer
+	Score float64
+}
+
+// Ranks candidates; higher is better. Candidates scored 0 or less
+// are never opened to.
+type Scorer interface {
+	Score(c *Candidate) float64
+}
+
+type ScorerFunc func(c *Candidate) float64
+
+func (f ScorerFunc) Score(c *Candidate) float64 {
+	return f(c)
+}
+
+// Scores candidates by a weighted sum of their normalised figures
+type WeightedScorer struct {
+	CapacityWeight   float64
+	CentralityWeight float64
+	UptimeWeight     float64
+}
+
+func (s *WeightedScorer) Score(c *Candidate) float64 {
+	return s.CapacityWeight*c.Capacity + s.CentralityWeight*c.Centrality + s.UptimeWeight*c.Uptime
+}
+
+// Weighs capacity, centrality and uptime equally
+var DefaultScorer Scorer = &WeightedScorer{1, 1, 1}
+
+type Autopilot struct {
+	Scorer Scorer
+	// The most, in satoshis, the autopilot may put into channels over
+	// all its runs
+	Budget uint64
+	// The size, in satoshis, of each channel opened
+	ChannelSize uint64
+	// The most channels to open in one run. Defaults to 5.
+	MaxChannels int
+	// Skip nodes with fewer active channels than this. Defaults to 2.
+	MinNodeChannels int
+	// How old a node's gossip can be before it's taken to be offline.
+	// Defaults to two weeks.
+	MaxAge time.Duration
+	// Whether to announce the channels opened. Defaults to true.
+	Announce bool
+	// Optional
+	FeeRate *glightning.FeeRate
+
+	client glightning.LightningClient
+	graph  *graph.Graph
+
+	mu      sync.Mutex
+	spent   uint64
+	exclude map[string]bool
+}
+
+func New(client glightning.LightningClient, g *graph.Graph) *Autopilot {
+	return &Autopilot{
+		Scorer:          DefaultScorer,
+		MaxChannels:     5,
+		MinNodeChannels: 2,
+		MaxAge:          14 * 24 * time.Hour,
+		Announce:        true,
+		client:          client,
+		graph:           g,
+		exclude:         make(map[string]bool),
+	}
+}
+
+// Never open channels to the nodes {ids}
+func (a *Autopilot) Exclude(ids ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, id := range ids {
+		a.exclude[id] = true
+	}
+}
+
+// Satoshis put into channels so far
+func (a *Autopilot) Spent() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.spent
+}
+
+// What's left of the budget
+func (a *Autopilot) Remaining() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.spent >= a.Budget {
+		return 0
+	}
+	return a.Budget - a.spent
+}
+
+// The nodes which could be opened to, best first. Leaves out this
+// node, nodes it already has channels with, excluded nodes, and
+// those scored 0 or less.
+func (a *Autopilot) Candidates() ([]*Candidate, error) {
+	info, err := a.client.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	peers, err := a.client.ListPeers()
+	if err != nil {
+		return nil, err
+	}
+	skip := map[string]bool{info.Id: true}
+	for _, peer := range peers {
+		if len(peer.Channels) > 0 {
+			skip[peer.Id] = true
+		}
+	}
+	a.mu.Lock()
+	for id := range a.exclude {
+		skip[id] = true
+	}
+	a.mu.Unlock()
+
+	var candidates []*Candidate
+	var maxCapacity uint64
+	var maxChannels int
+	var newest uint
+	for _, node := range a.graph.Nodes() {
+		c := &Candidate{Id: node.Id, Alias: node.Alias}
+		for _, edge := range node.Out {
+			if edge.LastUpdate > c.LastUpdate {
+				c.LastUpdate = edge.LastUpdate
+			}
+			if !edge.Active || !edge.Public {
+				continue
+			}
+			c.Channels++
+			c.CapacityMsat += edge.CapacityMsat
+		}
+		if c.LastUpdate > newest {
+			newest = c.LastUpdate
+		}
+		if skip[c.Id] || c.Channels < a.MinNodeChannels || c.Channels == 0 {
+			continue
+		}
+		if c.CapacityMsat > maxCapacity {
+			maxCapacity = c.CapacityMsat
+		}
+		if c.Channels > maxChannels {
+			maxChannels = c.Channels
+		}
+		candidates = append(candidates, c)
+	}
+
+	scored := candidates[:0]
+	for _, c := range candidates {
+		if maxCapacity > 0 {
+			c.Capacity = float64(c.CapacityMsat) / float64(maxCapacity)
+		}
+		c.Centrality = float64(c.Channels) / float64(maxChannels)
+		c.Uptime = uptime(newest-c.LastUpdate, a.MaxAge)
+		c.Score = a.Scorer.Score(c)
+		if c.Score > 0 {
+			scored = append(scored, c)
+		}
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Id < scored[j].Id
+	})
+	return scored, nil
+}
+
+func uptime(age uint, maxAge time.Duration) float64 {
+	seconds := maxAge.Seconds()
+	if seconds <= 0 || float64(age) >= seconds {
+		return 0
+	}
+	return 1 - float64(age)/seconds
+}
+
+// The channels Run would open: the best candidates, as many as the
+// remaining budget and MaxChannels allow
+func (a *Autopilot) Plan() ([]*glightning.FundDestination, error) {
+	if a.ChannelSize == 0 {
+		return nil, fmt.Errorf("Must set a channel size")
+	}
+	count := int(a.Remaining() / a.ChannelSize)
+	if count > a.MaxChannels {
+		count = a.MaxChannels
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	candidates, err := a.Candidates()
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) < count {
+		count = len(candidates)
+	}
+	amount := glightning.NewSat64(a.ChannelSize).RawString()
+	destinations := make([]*glightning.FundDestination, count)
+	for i, c := range candidates[:count] {
+		destinations[i] = &glightning.FundDestination{
+			Id:       c.Id,
+			Amount:   amount,
+			Announce: a.Announce,
+		}
+	}
+	return destinations, nil
+}
+
+// Open the planned channels in one transaction. Candidates which
+// can't be opened to (they're offline, say) are dropped from it,
+// and listed in the result's Failed. Returns nil if there's nothing
+// to open, as the budget is spent or there are no candidates.
+func (a *Autopilot) Run() (*glightning.MultiFundChannelResult, error) {
+	destinations, err := a.Plan()
+	if err != nil || len(destinations) == 0 {
+		return nil, err
+	}
+	result, err := a.client.MultiFundChannel(destinations, a.FeeRate, nil, nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	a.spent += uint64(len(result.ChannelIds)) * a.ChannelSize
+	a.mu.Unlock()
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package autopilot_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/autopilot"
+	"github.com/elementsproject/glightning/glightning/graph"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const day = 24 * 60 * 60
+
+// A has the most capacity, B the most channels, C too few, D is
+// already a peer and F hasn't gossiped in weeks
+func testGraph() *graph.Graph {
+	g := graph.New()
+	n := 0
+	half := func(src, dst string, sats uint64, updated uint) {
+		n++
+		g.UpdateChannel(&glightning.Channel{
+			Source:         src,
+			Destination:    dst,
+			ShortChannelId: glightning.ShortChannelId(string(rune('0'+n)) + "x1x0"),
+			Satoshis:       sats,
+			IsActive:       true,
+			IsPublic:       true,
+			LastUpdate:     updated,
+		})
+	}
+	now := uint(100 * day)
+	half("A", "X", 8000000, now)
+	half("A", "Y", 8000000, now)
+	half("B", "X", 1000000, now)
+	half("B", "Y", 1000000, now-7*day)
+	half("B", "Z", 1000000, now)
+	half("B", "W", 1000000, now)
+	half("C", "X", 9000000, now)
+	half("D", "X", 9000000, now)
+	half("D", "Y", 9000000, now)
+	half("F", "X", 8000000, now-30*day)
+	half("F", "Y", 8000000, now-30*day)
+	half("S", "X", 1000000, now)
+	half("S", "Y", 1000000, now)
+	return g
+}
+
+func testNode() *mock.Lightning {
+	ln := mock.New()
+	ln.GetInfoFunc = func() (*glightning.NodeInfo, error) {
+		return &glightning.NodeInfo{Id: "S"}, nil
+	}
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return []*glightning.Peer{
+			{Id: "D", Channels: []*glightning.PeerChannel{{State: "CHANNELD_NORMAL"}}},
+			{Id: "X"},
+		}, nil
+	}
+	return ln
+}
+
+func ids(candidates []*autopilot.Candidate) []string {
+	var ids []string
+	for _, c := range candidates {
+		ids = append(ids, c.Id)
+	}
+	return ids
+}
+
+func TestCandidates(t *testing.T) {
+	pilot := autopilot.New(testNode(), testGraph())
+	candidates, err := pilot.Candidates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"A", "B", "F"}, ids(candidates))
+	a := candidates[0]
+	assert.Equal(t, 2, a.Channels)
+	assert.Equal(t, uint64(16000000000), a.CapacityMsat)
+	assert.Equal(t, 1.0, a.Capacity)
+	assert.Equal(t, 0.5, a.Centrality)
+	assert.Equal(t, 1.0, a.Uptime)
+	assert.Equal(t, 2.5, a.Score)
+	f := candidates[2]
+	assert.Equal(t, 0.0, f.Uptime)
+
+	// a scorer can rule candidates out
+	pilot.Exclude("B")
+	pilot.Scorer = autopilot.ScorerFunc(func(c *autopilot.Candidate) float64 {
+		return c.Uptime
+	})
+	candidates, err = pilot.Candidates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"A"}, ids(candidates))
+}
+
+func TestRun(t *testing.T) {
+	ln := testNode()
+	var calls [][]*glightning.FundDestination
+	ln.MultiFundChannelFunc = func(destinations []*glightning.FundDestination, feerate *glightning.FeeRate, minConf *uint16, utxos []*glightning.Utxo, minChannels uint) (*glightning.MultiFundChannelResult, error) {
+		calls = append(calls, destinations)
+		assert.Equal(t, uint(1), minChannels)
+		result := &glightning.MultiFundChannelResult{FundingTxId: "aa"}
+		for _, dest := range destinations {
+			result.ChannelIds = append(result.ChannelIds, &glightning.MultiFundChannelId{Id: dest.Id})
+		}
+		return result, nil
+	}
+	pilot := autopilot.New(ln, testGraph())
+	_, err := pilot.Run()
+	assert.EqualError(t, err, "Must set a channel size")
+
+	pilot.Budget = 2500000
+	pilot.ChannelSize = 1000000
+	result, err := pilot.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "aa", result.FundingTxId)
+	assert.Equal(t, []*glightning.FundDestination{
+		{Id: "A", Amount: "1000000", Announce: true},
+		{Id: "B", Amount: "1000000", Announce: true},
+	}, calls[0])
+	assert.Equal(t, uint64(2000000), pilot.Spent())
+	assert.Equal(t, uint64(500000), pilot.Remaining())
+
+	// what's left of the budget won't buy another channel
+	result, err = pilot.Run()
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Len(t, calls, 1)
+
+	ln.MultiFundChannelFunc = func(destinations []*glightning.FundDestination, feerate *glightning.FeeRate, minConf *uint16, utxos []*glightning.Utxo, minChannels uint) (*glightning.MultiFundChannelResult, error) {
+		return nil, errors.New("Insufficient funds")
+	}
+	pilot.Budget = 10000000
+	_, err = pilot.Run()
+	assert.EqualError(t, err, "Insufficient funds")
+	assert.Equal(t, uint64(2000000), pilot.Spent())
+}
@@ -0,0 +1,181 @@
+// Package backup keeps a continuous backup of lightningd's database by
+// recording every batch of writes it's about to commit, via the
+// db_write hook.
+//
+// lightningd waits for the hook to return before committing each
+// batch, so once a batch is stored by a Target it's safe; if storing
+// it fails, lightningd is told to fail (and stop) rather than get
+// ahead of its backup.
+//
+//	target, err := backup.OpenFile("/mnt/backup/lightningd.bak")
+//	...
+//	b, err := backup.New(target)
+//	...
+//	b.Register(plugin)
+//
+// Batches are replayed onto a copy of the database, taken when the
+// backup was started, with Restore.
+//
+// The package also reads and writes static channel backups (see
+// SaveStaticBackups), the last resort when the database is lost.
+package backup
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/elementsproject/glightning/glightning"
+)
+
+// The SQL statements of one database transaction, and the
+// data_version lightningd's database will have once it's committed
+type Batch struct {
+	DataVersion uint64
+	Writes      []string
+}
+
+// Somewhere to keep batches: a local file (FileTarget), or a remote
+// object store (RemoteTarget)
+type Target interface {
+	// Store {batch} durably before returning. A batch with the same
+	// DataVersion as the last one replaces it: lightningd failed to
+	// commit the last batch, and is writing it again.
+	Append(batch *Batch) error
+	// Call {fn} with each batch stored, in the order they were
+	// appended, stopping at the first error
+	ReadAll(fn func(*Batch) error) error
+	// The DataVersion of the last batch stored, or 0 if none
+	LastVersion() (uint64, error)
+}
+
+// Backup appends each batch lightningd is about to commit to its
+// Target, checking they follow on from the last one.
+type Backup struct {
+	// Called with the reason whenever a write is refused. Refusing a
+	// write stops lightningd, so this is the place to alert someone.
+	OnError func(error)
+
+	target Target
+	mu     sync.Mutex
+	last   uint64
+}
+
+func New(target Target) (*Backup, error) {
+	last, err := target.LastVersion()
+	if err != nil {
+		return nil, err
+	}
+	return &Backup{target: target, last: last}, nil
+}
+
+// Register the db_write hook with {plugin}. A plugin registering
+// db_write can't register any other hooks, and must be started with
+// lightningd (i.e. be in its config, not started later).
+func (b *Backup) Register(plugin *glightning.Plugin) error {
+	return plugin.RegisterHooks(&glightning.Hooks{
+		DbWrite: b.OnDbWrite,
+	})
+}
+
+// The data_version of the last batch backed up
+func (b *Backup) LastVersion() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.last
+}
+
+// The db_write hook
+func (b *Backup) OnDbWrite(event *glightning.DbWriteEvent) (*glightning.DbWriteResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.append(&Batch{event.DataVersion, event.Writes}); err != nil {
+		if b.OnError != nil {
+			b.OnError(err)
+		}
+		return event.Fail(), nil
+	}
+	return event.Continue(), nil
+}
+
+func (b *Backup) append(batch *Batch) error {
+	// an empty backup can start anywhere
+	if b.last != 0 && !follows(b.last, batch.DataVersion) {
+		return fmt.Errorf("data_version %d doesn't follow on from the backup's %d", batch.DataVersion, b.last)
+	}
+	if err := b.target.Append(batch); err != nil {
+		return fmt.Errorf("Unable to store data_version %d: %s", batch.DataVersion, err)
+	}
+	b.last = batch.DataVersion
+	return nil
+}
+
+// Whether a batch of {next} may come after one of {last}
+func follows(last, next uint64) bool {
+	return next == last+1 || next == last
+}
+
+type Summary struct {
+	// Batches stored, and those of them which replaced the one before
+	Batches  int
+	Replaced int
+	// The statements in batches which weren't replaced
+	Writes       int
+	FirstVersion uint64
+	LastVersion  uint64
+}
+
+// Read the whole of {target}, checking every batch is intact and
+// follows on from the one before
+func Verify(target Target) (*Summary, error) {
+	summary := &Summary{}
+	var lastWrites int
+	err := target.ReadAll(func(batch *Batch) error {
+		if summary.Batches > 0 {
+			if !follows(summary.LastVersion, batch.DataVersion) {
+				return fmt.Errorf("Batch %d has data_version %d, following %d", summary.Batches, batch.DataVersion, summary.LastVersion)
+			}
+			if batch.DataVersion == summary.LastVersion {
+				summary.Replaced++
+				summary.Writes -= lastWrites
+			}
+		} else {
+			summary.FirstVersion = batch.DataVersion
+		}
+		summary.Batches++
+		summary.Writes += len(batch.Writes)
+		summary.LastVersion = batch.DataVersion
+		lastWrites = len(batch.Writes)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// Call {apply} with each batch in {target} which lightningd went on
+// to commit (and the last, which it may have), in order, skipping
+// those which were replaced. Applying them to a copy of the database
+// taken when the backup began brings it up to date.
+func Restore(target Target, apply func(*Batch) error) error {
+	var pending *Batch
+	err := target.ReadAll(func(batch *Batch) error {
+		if pending != nil {
+			if !follows(pending.DataVersion, batch.DataVersion) {
+				return fmt.Errorf("Batch with data_version %d follows %d", batch.DataVersion, pending.DataVersion)
+			}
+			if batch.DataVersion != pending.DataVersion {
+				if err := apply(pending); err != nil {
+					return err
+				}
+			}
+		}
+		pending = batch
+		return nil
+	})
+	if err != nil || pending == nil {
+		return err
+	}
+	return apply(pending)
+}
@@ -0,0 +1,185 @@
+package backup_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/backup"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryStore struct {
+	objects map[string][]byte
+}
+
+func (s *memoryStore) Put(name string, data []byte) error {
+	s.objects[name] = append([]byte{}, data...)
+	return nil
+}
+
+func (s *memoryStore) Get(name string) ([]byte, error) {
+	data, ok := s.objects[name]
+	if !ok {
+		return nil, fmt.Errorf("no such object %s", name)
+	}
+	return data, nil
+}
+
+func (s *memoryStore) List(prefix string) ([]string, error) {
+	var names []string
+	for name := range s.objects {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func write(b *backup.Backup, version uint64, writes ...string) string {
+	resp, _ := b.OnDbWrite(&glightning.DbWriteEvent{DataVersion: version, Writes: writes})
+	return string(resp.Result)
+}
+
+func restored(t *testing.T, target backup.Target) []uint64 {
+	var versions []uint64
+	err := backup.Restore(target, func(batch *backup.Batch) error {
+		versions = append(versions, batch.DataVersion)
+		return nil
+	})
+	assert.NoError(t, err)
+	return versions
+}
+
+// Returns the target's summary, once written to
+func testTarget(t *testing.T, target backup.Target, reopen func() backup.Target) *backup.Summary {
+	b, err := backup.New(target)
+	assert.NoError(t, err)
+	var errs []error
+	b.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+
+	assert.Equal(t, "continue", write(b, 5, "INSERT INTO a VALUES (1);"))
+	assert.Equal(t, "continue", write(b, 6, "UPDATE a SET x=2;", "DELETE FROM b;"))
+	// lightningd failed to commit 6, and is writing it again
+	assert.Equal(t, "continue", write(b, 6, "UPDATE a SET x=3;"))
+	assert.Equal(t, "continue", write(b, 7, "UPDATE vars SET val='2';"))
+	// a gap is refused
+	assert.Equal(t, "fail", write(b, 9, "UPDATE a SET x=4;"))
+	assert.Len(t, errs, 1)
+	assert.Equal(t, uint64(7), b.LastVersion())
+
+	target = reopen()
+	b, err = backup.New(target)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), b.LastVersion())
+	assert.Equal(t, "fail", write(b, 5))
+	assert.Equal(t, "continue", write(b, 8, "UPDATE a SET x=5;"))
+
+	summary, err := backup.Verify(target)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, summary.Writes)
+	assert.Equal(t, uint64(5), summary.FirstVersion)
+	assert.Equal(t, uint64(8), summary.LastVersion)
+
+	var writes []string
+	err = backup.Restore(target, func(batch *backup.Batch) error {
+		writes = append(writes, batch.Writes...)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"INSERT INTO a VALUES (1);",
+		"UPDATE a SET x=3;",
+		"UPDATE vars SET val='2';",
+		"UPDATE a SET x=5;",
+	}, writes)
+	return summary
+}
+
+func TestFileTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lightningd.bak")
+	target, err := backup.OpenFile(path)
+	assert.NoError(t, err)
+
+	summary := testTarget(t, target, func() backup.Target {
+		target.Close()
+		target, err = backup.OpenFile(path)
+		assert.NoError(t, err)
+		return target
+	})
+	target.Close()
+	// the replaced batch is still in the file
+	assert.Equal(t, 5, summary.Batches)
+	assert.Equal(t, 1, summary.Replaced)
+}
+
+func TestFileTargetTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lightningd.bak")
+	target, err := backup.OpenFile(path)
+	assert.NoError(t, err)
+	target.Append(&backup.Batch{DataVersion: 1, Writes: []string{"a"}})
+	target.Append(&backup.Batch{DataVersion: 2, Writes: []string{"b"}})
+	target.Close()
+
+	// lose the end of the last record
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Truncate(path, info.Size()-3))
+
+	target, err = backup.OpenFile(path)
+	assert.NoError(t, err)
+	defer target.Close()
+	last, _ := target.LastVersion()
+	assert.Equal(t, uint64(1), last)
+	assert.NoError(t, target.Append(&backup.Batch{DataVersion: 2, Writes: []string{"c"}}))
+	assert.Equal(t, []uint64{1, 2}, restored(t, target))
+}
+
+func TestFileTargetCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lightningd.bak")
+	target, err := backup.OpenFile(path)
+	assert.NoError(t, err)
+	target.Append(&backup.Batch{DataVersion: 1, Writes: []string{"INSERT INTO a VALUES (1);"}})
+	target.Close()
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	data[len(data)-3] ^= 0xff
+	assert.NoError(t, os.WriteFile(path, data, 0600))
+
+	_, err = backup.OpenFile(path)
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(path, []byte("not a backup"), 0600))
+	_, err = backup.OpenFile(path)
+	assert.Error(t, err)
+}
+
+func TestRemoteTarget(t *testing.T) {
+	store := &memoryStore{objects: make(map[string][]byte)}
+	store.Put("node1/README", []byte("not a batch"))
+	summary := testTarget(t, backup.NewRemoteTarget(store, "node1/"), func() backup.Target {
+		return backup.NewRemoteTarget(store, "node1/")
+	})
+	// the replaced batch was overwritten
+	assert.Equal(t, 4, summary.Batches)
+	assert.Equal(t, 0, summary.Replaced)
+	assert.Len(t, store.objects, 5)
+	assert.Contains(t, store.objects, "node1/00000000000000000006")
+}
+
+func TestVerifyGap(t *testing.T) {
+	store := &memoryStore{objects: make(map[string][]byte)}
+	target := backup.NewRemoteTarget(store, "")
+	target.Append(&backup.Batch{DataVersion: 1})
+	target.Append(&backup.Batch{DataVersion: 3})
+
+	_, err := backup.Verify(target)
+	assert.Error(t, err)
+	assert.Error(t, backup.Restore(target, func(*backup.Batch) error { return nil }))
+}
@@ -0,0 +1,204 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Backup files start with this magic, then a u16 format version
+const fileMagic = "GLBACKUP"
+
+const FileFormatVersion uint16 = 1
+
+const headerLen = len(fileMagic) + 2
+
+// FileTarget appends batches to a local file. Each batch is written
+// as a record, checksummed so a damaged one is caught, and synced to
+// disk before Append returns.
+//
+// Records are a u32 length, the u32 CRC-32 of the payload, and the
+// payload: a u64 data_version, u32 count of statements, then each
+// statement as a u32 length and its bytes. All big-endian.
+type FileTarget struct {
+	path string
+	file *os.File
+	last uint64
+}
+
+// Open the backup file at {path}, creating it if it doesn't exist. A
+// record left half written by a crash is dropped.
+func OpenFile(path string) (*FileTarget, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	t := &FileTarget{path: path, file: file}
+	if err := t.open(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *FileTarget) open() error {
+	info, err := t.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		header := make([]byte, headerLen)
+		copy(header, fileMagic)
+		binary.BigEndian.PutUint16(header[len(fileMagic):], FileFormatVersion)
+		if _, err := t.file.Write(header); err != nil {
+			return err
+		}
+		return t.file.Sync()
+	}
+
+	// find the end of the last whole record
+	end := int64(headerLen)
+	err = readFile(t.file, func(batch *Batch, offset int64) error {
+		t.last = batch.DataVersion
+		end = offset
+		return nil
+	})
+	if err != nil && err != errTruncated {
+		return err
+	}
+	if err == errTruncated {
+		if err := t.file.Truncate(end); err != nil {
+			return err
+		}
+	}
+	_, err = t.file.Seek(end, io.SeekStart)
+	return err
+}
+
+func (t *FileTarget) Append(batch *Batch) error {
+	if _, err := t.file.Write(encodeRecord(batch)); err != nil {
+		return err
+	}
+	if err := t.file.Sync(); err != nil {
+		return err
+	}
+	t.last = batch.DataVersion
+	return nil
+}
+
+func (t *FileTarget) ReadAll(fn func(*Batch) error) error {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	err = readFile(file, func(batch *Batch, _ int64) error {
+		return fn(batch)
+	})
+	if err == errTruncated {
+		return nil
+	}
+	return err
+}
+
+func (t *FileTarget) LastVersion() (uint64, error) {
+	return t.last, nil
+}
+
+func (t *FileTarget) Close() error {
+	return t.file.Close()
+}
+
+var errTruncated = errors.New("backup file truncated")
+
+// Read each record of {file} from the start, calling {fn} with the
+// batch and the offset just past it. Returns errTruncated if the
+// file ends part way through a record.
+func readFile(file *os.File, fn func(*Batch, int64) error) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(file)
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("Not a backup file: %s", err)
+	}
+	if string(header[:len(fileMagic)]) != fileMagic {
+		return fmt.Errorf("Not a backup file")
+	}
+	if version := binary.BigEndian.Uint16(header[len(fileMagic):]); version != FileFormatVersion {
+		return fmt.Errorf("Unsupported backup file version %d", version)
+	}
+
+	offset := int64(headerLen)
+	for {
+		prefix := make([]byte, 8)
+		if _, err := io.ReadFull(r, prefix); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errTruncated
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(prefix))
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return errTruncated
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(prefix[4:]) {
+			return fmt.Errorf("Bad checksum for record at offset %d", offset)
+		}
+		batch, err := decodeBatch(payload)
+		if err != nil {
+			return fmt.Errorf("Bad record at offset %d: %s", offset, err)
+		}
+		offset += int64(len(prefix) + len(payload))
+		if err := fn(batch, offset); err != nil {
+			return err
+		}
+	}
+}
+
+func encodeBatch(batch *Batch) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, batch.DataVersion)
+	binary.Write(&buf, binary.BigEndian, uint32(len(batch.Writes)))
+	for _, write := range batch.Writes {
+		binary.Write(&buf, binary.BigEndian, uint32(len(write)))
+		buf.WriteString(write)
+	}
+	return buf.Bytes()
+}
+
+func encodeRecord(batch *Batch) []byte {
+	payload := encodeBatch(batch)
+	record := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(record, uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(payload))
+	return append(record, payload...)
+}
+
+// Decode the payload of a record
+func decodeBatch(payload []byte) (*Batch, error) {
+	if len(payload) < 12 {
+		return nil, fmt.Errorf("too short")
+	}
+	batch := &Batch{DataVersion: binary.BigEndian.Uint64(payload)}
+	count := binary.BigEndian.Uint32(payload[8:])
+	payload = payload[12:]
+	for i := uint32(0); i < count; i++ {
+		if len(payload) < 4 {
+			return nil, fmt.Errorf("statement %d truncated", i)
+		}
+		n := binary.BigEndian.Uint32(payload)
+		if uint32(len(payload)-4) < n {
+			return nil, fmt.Errorf("statement %d truncated", i)
+		}
+		batch.Writes = append(batch.Writes, string(payload[4:4+n]))
+		payload = payload[4+n:]
+	}
+	return batch, nil
+}
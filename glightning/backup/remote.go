@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ObjectStore is the little RemoteTarget needs of a remote store, such
+// as an S3 bucket or a directory over SFTP; wrap the client library of
+// your choice to provide it.
+type ObjectStore interface {
+	// Store {data} as {name}, replacing any object already there. The
+	// object must be durable once Put returns.
+	Put(name string, data []byte) error
+	Get(name string) ([]byte, error)
+	// The names of the objects beginning with {prefix}, in any order
+	List(prefix string) ([]string, error)
+}
+
+// RemoteTarget stores each batch as an object in an ObjectStore, named
+// {prefix} then its zero-padded data_version, so a replaced batch
+// simply overwrites its object. Objects are encoded as FileTarget
+// records.
+type RemoteTarget struct {
+	store  ObjectStore
+	prefix string
+	last   uint64
+	loaded bool
+}
+
+func NewRemoteTarget(store ObjectStore, prefix string) *RemoteTarget {
+	return &RemoteTarget{store: store, prefix: prefix}
+}
+
+func (t *RemoteTarget) objectName(version uint64) string {
+	return fmt.Sprintf("%s%020d", t.prefix, version)
+}
+
+// The data_versions stored, in order
+func (t *RemoteTarget) versions() ([]uint64, error) {
+	names, err := t.store.List(t.prefix)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]uint64, 0, len(names))
+	for _, name := range names {
+		version, err := strconv.ParseUint(strings.TrimPrefix(name, t.prefix), 10, 64)
+		if err != nil {
+			// not one of ours
+			continue
+		}
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+func (t *RemoteTarget) Append(batch *Batch) error {
+	if err := t.store.Put(t.objectName(batch.DataVersion), encodeRecord(batch)); err != nil {
+		return err
+	}
+	t.last = batch.DataVersion
+	t.loaded = true
+	return nil
+}
+
+func (t *RemoteTarget) ReadAll(fn func(*Batch) error) error {
+	versions, err := t.versions()
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		name := t.objectName(version)
+		data, err := t.store.Get(name)
+		if err != nil {
+			return err
+		}
+		batch, err := decodeRecord(data)
+		if err != nil {
+			return fmt.Errorf("Bad object %s: %s", name, err)
+		}
+		if batch.DataVersion != version {
+			return fmt.Errorf("Object %s holds data_version %d", name, batch.DataVersion)
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *RemoteTarget) LastVersion() (uint64, error) {
+	if !t.loaded {
+		versions, err := t.versions()
+		if err != nil {
+			return 0, err
+		}
+		if len(versions) > 0 {
+			t.last = versions[len(versions)-1]
+		}
+		t.loaded = true
+	}
+	return t.last, nil
+}
+
+func decodeRecord(record []byte) (*Batch, error) {
+	if len(record) < 8 || uint32(len(record)-8) != binary.BigEndian.Uint32(record) {
+		return nil, fmt.Errorf("bad length")
+	}
+	payload := record[8:]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(record[4:]) {
+		return nil, fmt.Errorf("bad checksum")
+	}
+	return decodeBatch(payload)
+}
@@ -0,0 +1,340 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/tlv"
+)
+
+// Types of the TLV records which end a static channel backup, besides
+// the shachain (1) and basepoints (3) lightningd restores with
+const (
+	scbOpener            = 5
+	scbRemoteToSelfDelay = 7
+)
+
+// wireaddr types
+const (
+	wireIPv4      = 1
+	wireIPv6      = 2
+	wireTorV2     = 3
+	wireTorV3     = 4
+	wireDNS       = 5
+	wireWebsocket = 6
+)
+
+// One channel's static channel backup (SCB), as staticbackup returns
+// it and emergency.recover holds it: enough for the peer to be found
+// and asked to close the channel, returning our funds.
+type StaticChannel struct {
+	// lightningd's database id for the channel
+	Id        uint64
+	ChannelId string
+	NodeId    string
+	// Where the peer was last reached
+	Address       *glightning.Address
+	FundingTxId   string
+	FundingOutnum uint32
+	FundingSats   uint64
+	// Whether the peer opened the channel. Set from the TLV records,
+	// which backups from older versions of lightningd lack.
+	OpenedByPeer      bool
+	RemoteToSelfDelay uint16
+	// All the TLV records, including those decoded above
+	Tlvs []*tlv.Record
+}
+
+// Decode {scb}, one of the hex strings staticbackup returns
+func DecodeStaticChannel(scb string) (*StaticChannel, error) {
+	data, err := hex.DecodeString(scb)
+	if err != nil {
+		return nil, err
+	}
+	r := bytes.NewReader(data)
+	c := &StaticChannel{}
+
+	var fixed struct {
+		Id        uint64
+		ChannelId [32]byte
+		NodeId    [33]byte
+		Unused    uint8
+	}
+	if err := binary.Read(r, binary.BigEndian, &fixed); err != nil {
+		return nil, fmt.Errorf("Static channel backup too short: %s", err)
+	}
+	c.Id = fixed.Id
+	c.ChannelId = hex.EncodeToString(fixed.ChannelId[:])
+	c.NodeId = hex.EncodeToString(fixed.NodeId[:])
+
+	if c.Address, err = readWireAddr(r); err != nil {
+		return nil, err
+	}
+
+	var funding struct {
+		TxId   [32]byte
+		Outnum uint32
+		Sats   uint64
+	}
+	if err := binary.Read(r, binary.BigEndian, &funding); err != nil {
+		return nil, fmt.Errorf("Static channel backup too short: %s", err)
+	}
+	c.FundingTxId = hex.EncodeToString(reverse(funding.TxId[:]))
+	c.FundingOutnum = funding.Outnum
+	c.FundingSats = funding.Sats
+
+	rest := make([]byte, r.Len())
+	r.Read(rest)
+	if c.Tlvs, err = tlv.Decode(rest); err != nil {
+		return nil, err
+	}
+	for _, record := range c.Tlvs {
+		switch record.Type {
+		case scbOpener:
+			// a side: 0 for us, 1 for the peer
+			c.OpenedByPeer = len(record.Value) == 1 && record.Value[0] == 1
+		case scbRemoteToSelfDelay:
+			if len(record.Value) == 2 {
+				c.RemoteToSelfDelay = binary.BigEndian.Uint16(record.Value)
+			}
+		}
+	}
+	return c, nil
+}
+
+// Encode the backup back into the hex form recoverchannel takes
+func (c *StaticChannel) Encode() (string, error) {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, c.Id)
+	for _, field := range []struct {
+		hex    string
+		length int
+	}{{c.ChannelId, 32}, {c.NodeId, 33}} {
+		b, err := hex.DecodeString(field.hex)
+		if err != nil || len(b) != field.length {
+			return "", fmt.Errorf("Invalid id %q", field.hex)
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte(0)
+	if err := writeWireAddr(&buf, c.Address); err != nil {
+		return "", err
+	}
+	txid, err := hex.DecodeString(c.FundingTxId)
+	if err != nil || len(txid) != 32 {
+		return "", fmt.Errorf("Invalid funding txid %q", c.FundingTxId)
+	}
+	buf.Write(reverse(txid))
+	binary.Write(&buf, binary.BigEndian, c.FundingOutnum)
+	binary.Write(&buf, binary.BigEndian, c.FundingSats)
+	tlvs, err := tlv.Encode(c.Tlvs)
+	if err != nil {
+		return "", err
+	}
+	buf.Write(tlvs)
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
+
+var onionEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+var wireAddrLen = map[uint8]int{
+	wireIPv4:  4,
+	wireIPv6:  16,
+	wireTorV2: 10,
+	wireTorV3: 35,
+}
+
+var wireAddrType = map[uint8]glightning.NetAddressType{
+	wireIPv4:      glightning.AddrIPv4,
+	wireIPv6:      glightning.AddrIPv6,
+	wireTorV2:     glightning.AddrTorV2,
+	wireTorV3:     glightning.AddrTorV3,
+	wireDNS:       glightning.AddrDNS,
+	wireWebsocket: glightning.AddrWebsocket,
+}
+
+// A wireaddr: a type byte, the address, and a u16 port
+func readWireAddr(r *bytes.Reader) (*glightning.Address, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("Static channel backup too short: %s", err)
+	}
+	netType, ok := wireAddrType[typ]
+	if !ok {
+		return nil, fmt.Errorf("Unknown address type %d", typ)
+	}
+
+	length := wireAddrLen[typ]
+	if typ == wireDNS {
+		l, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("Static channel backup too short: %s", err)
+		}
+		length = int(l)
+	}
+	raw := make([]byte, length)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, fmt.Errorf("Static channel backup too short: %s", err)
+	}
+	var port uint16
+	if err := binary.Read(r, binary.BigEndian, &port); err != nil {
+		return nil, fmt.Errorf("Static channel backup too short: %s", err)
+	}
+
+	addr := &glightning.Address{Type: netType, Port: int(port)}
+	switch typ {
+	case wireIPv4, wireIPv6:
+		addr.Addr = net.IP(raw).String()
+	case wireTorV2, wireTorV3:
+		addr.Addr = strings.ToLower(onionEncoding.EncodeToString(raw)) + ".onion"
+	case wireDNS:
+		addr.Addr = string(raw)
+	}
+	return addr, nil
+}
+
+func writeWireAddr(buf *bytes.Buffer, addr *glightning.Address) error {
+	if addr == nil {
+		return fmt.Errorf("Must have the peer's address")
+	}
+	var typ uint8
+	for t, netType := range wireAddrType {
+		if netType == addr.Type {
+			typ = t
+		}
+	}
+	var raw []byte
+	switch typ {
+	case wireIPv4:
+		raw = net.ParseIP(addr.Addr).To4()
+	case wireIPv6:
+		raw = net.ParseIP(addr.Addr).To16()
+	case wireTorV2, wireTorV3:
+		raw, _ = onionEncoding.DecodeString(strings.ToUpper(strings.TrimSuffix(addr.Addr, ".onion")))
+	case wireDNS:
+		raw = append([]byte{byte(len(addr.Addr))}, addr.Addr...)
+	case wireWebsocket:
+	default:
+		return fmt.Errorf("Unknown address type %s", addr.Type)
+	}
+	if n, ok := wireAddrLen[typ]; ok && len(raw) != n {
+		return fmt.Errorf("Invalid %s address %q", addr.Type, addr.Addr)
+	}
+	buf.WriteByte(typ)
+	buf.Write(raw)
+	return binary.Write(buf, binary.BigEndian, uint16(addr.Port))
+}
+
+// The version of the static backup file format
+const StaticBackupVersion = 1
+
+// A set of static channel backups as stored by WriteStaticBackups: a
+// JSON object, so it's easily inspected and the backups can be passed
+// straight back to recoverchannel.
+type StaticBackupFile struct {
+	Version int `json:"version"`
+	// When the backups were taken, as a unix timestamp
+	Timestamp int64    `json:"timestamp"`
+	Scb       []string `json:"scb"`
+}
+
+// Write {scbs}, after checking each decodes
+func WriteStaticBackups(w io.Writer, scbs []string) error {
+	for i, scb := range scbs {
+		if _, err := DecodeStaticChannel(scb); err != nil {
+			return fmt.Errorf("Static channel backup %d: %s", i, err)
+		}
+	}
+	return json.NewEncoder(w).Encode(&StaticBackupFile{
+		Version:   StaticBackupVersion,
+		Timestamp: time.Now().Unix(),
+		Scb:       scbs,
+	})
+}
+
+// Read backups written by WriteStaticBackups, checking each decodes
+func ReadStaticBackups(r io.Reader) (*StaticBackupFile, []*StaticChannel, error) {
+	var file StaticBackupFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, nil, err
+	}
+	if file.Version != StaticBackupVersion {
+		return nil, nil, fmt.Errorf("Unsupported static backup version %d", file.Version)
+	}
+	channels := make([]*StaticChannel, len(file.Scb))
+	for i, scb := range file.Scb {
+		c, err := DecodeStaticChannel(scb)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Static channel backup %d: %s", i, err)
+		}
+		channels[i] = c
+	}
+	return &file, channels, nil
+}
+
+// Fetch the node's static channel backups and write them to {path},
+// replacing it only once the new backups are safely on disk
+func SaveStaticBackups(client glightning.LightningClient, path string) ([]*StaticChannel, error) {
+	scbs, err := client.StaticBackup()
+	if err != nil {
+		return nil, err
+	}
+	channels := make([]*StaticChannel, len(scbs))
+	for i, scb := range scbs {
+		if channels[i], err = DecodeStaticChannel(scb); err != nil {
+			return nil, fmt.Errorf("Static channel backup %d: %s", i, err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if err := WriteStaticBackups(tmp, scbs); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	return channels, os.Rename(tmp.Name(), path)
+}
+
+// Restore the channels backed up at {path} with recoverchannel.
+// Returns the ids of the channels restored.
+func RecoverStaticBackups(client glightning.LightningClient, path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	backups, _, err := ReadStaticBackups(file)
+	if err != nil {
+		return nil, err
+	}
+	return client.RecoverChannel(backups.Scb)
+}
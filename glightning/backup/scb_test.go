@@ -0,0 +1,140 @@
+package backup_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/backup"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/elementsproject/glightning/glightning/tlv"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	scbChannelId = "1c6b4e3fd1e8f8cb4a4e4a7dce2d3fc9f9b31e0c4a6dea1e0f55ae23f1e7bc10"
+	scbNodeId    = "022d223620a359a47ff7f7ac447c85c46c923da53389221a0054c11c1e3ca31d59"
+	scbTxId      = "ff1e5b5c54c0e1b5ef2e9b3d7dbba1b46e1ab2b0e9fd3c08f3bc0f2a48d2e1f0"
+	scbTorV3     = "vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd.onion"
+)
+
+// An SCB as lightningd writes it, with the peer at 203.0.113.5:9735
+var scbHex = strings.Join([]string{
+	"0000000000000007", // id
+	scbChannelId,
+	scbNodeId,
+	"00",             // unused
+	"01cb0071052607", // ipv4 203.0.113.5, port 9735
+	"f0e1d2482a0fbcf3083cfde9b0b21a6eb4a1bb7d3d9b2eefb5e1c0545c5b1eff", // funding txid, reversed
+	"00000001",         // outnum
+	"00000000000f4240", // 1000000 sat
+	"05010107020090",   // opener: peer, remote_to_self_delay: 144
+}, "")
+
+func TestDecodeStaticChannel(t *testing.T) {
+	c, err := backup.DecodeStaticChannel(scbHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(7), c.Id)
+	assert.Equal(t, scbChannelId, c.ChannelId)
+	assert.Equal(t, scbNodeId, c.NodeId)
+	assert.Equal(t, &glightning.Address{Type: glightning.AddrIPv4, Addr: "203.0.113.5", Port: 9735}, c.Address)
+	assert.Equal(t, scbTxId, c.FundingTxId)
+	assert.Equal(t, uint32(1), c.FundingOutnum)
+	assert.Equal(t, uint64(1000000), c.FundingSats)
+	assert.True(t, c.OpenedByPeer)
+	assert.Equal(t, uint16(144), c.RemoteToSelfDelay)
+	assert.Len(t, c.Tlvs, 2)
+
+	encoded, err := c.Encode()
+	assert.NoError(t, err)
+	assert.Equal(t, scbHex, encoded)
+
+	_, err = backup.DecodeStaticChannel(scbHex[:100])
+	assert.Error(t, err)
+	_, err = backup.DecodeStaticChannel("zz")
+	assert.Error(t, err)
+}
+
+func TestStaticChannelAddresses(t *testing.T) {
+	for _, addr := range []*glightning.Address{
+		{Type: glightning.AddrIPv6, Addr: "2001:db8::1", Port: 9735},
+		{Type: glightning.AddrTorV3, Addr: scbTorV3, Port: 9735},
+		{Type: glightning.AddrDNS, Addr: "node.example.com", Port: 9736},
+	} {
+		c := &backup.StaticChannel{
+			Id:          1,
+			ChannelId:   scbChannelId,
+			NodeId:      scbNodeId,
+			Address:     addr,
+			FundingTxId: scbTxId,
+			FundingSats: 50000,
+			Tlvs:        []*tlv.Record{{Type: 1, Value: []byte{0xaa}}},
+		}
+		encoded, err := c.Encode()
+		if !assert.NoError(t, err, addr.Addr) {
+			continue
+		}
+		decoded, err := backup.DecodeStaticChannel(encoded)
+		assert.NoError(t, err, addr.Addr)
+		assert.Equal(t, c, decoded, addr.Addr)
+	}
+
+	c := &backup.StaticChannel{
+		ChannelId:   scbChannelId,
+		NodeId:      scbNodeId,
+		Address:     &glightning.Address{Type: glightning.AddrIPv4, Addr: "not an ip"},
+		FundingTxId: scbTxId,
+	}
+	_, err := c.Encode()
+	assert.EqualError(t, err, `Invalid ipv4 address "not an ip"`)
+}
+
+func TestStaticBackupFile(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, backup.WriteStaticBackups(&buf, []string{scbHex}))
+	file, channels, err := backup.ReadStaticBackups(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, backup.StaticBackupVersion, file.Version)
+	assert.NotZero(t, file.Timestamp)
+	assert.Equal(t, []string{scbHex}, file.Scb)
+	assert.Equal(t, scbChannelId, channels[0].ChannelId)
+
+	err = backup.WriteStaticBackups(&buf, []string{scbHex, "00"})
+	assert.EqualError(t, err, "Static channel backup 1: Static channel backup too short: unexpected EOF")
+
+	_, _, err = backup.ReadStaticBackups(strings.NewReader(`{"version": 2, "scb": []}`))
+	assert.EqualError(t, err, "Unsupported static backup version 2")
+}
+
+func TestSaveRecoverStaticBackups(t *testing.T) {
+	ln := mock.New()
+	ln.StaticBackupFunc = func() ([]string, error) {
+		return []string{scbHex}, nil
+	}
+	var recovered []string
+	ln.RecoverChannelFunc = func(scbs []string) ([]string, error) {
+		recovered = scbs
+		return []string{scbChannelId}, nil
+	}
+
+	path := filepath.Join(t.TempDir(), "channels.scb")
+	channels, err := backup.SaveStaticBackups(ln, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, channels, 1)
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Len(t, entries, 1)
+
+	stubs, err := backup.RecoverStaticBackups(ln, path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{scbChannelId}, stubs)
+	assert.Equal(t, []string{scbHex}, recovered)
+}
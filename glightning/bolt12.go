@@ -0,0 +1,540 @@
+package glightning
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/elementsproject/glightning/glightning/tlv"
+)
+
+// Offline decoding of BOLT#12 offers ('lno'), invoice requests
+// ('lnr') and invoices ('lni'). These are bech32 strings, without a
+// checksum, of a TLV stream. Each message type includes all the
+// fields of the one before it: an invoice request echoes the offer
+// and an invoice echoes the invoice request.
+
+type Bolt12Record = tlv.Record
+
+type Bolt12BlindedHop struct {
+	BlindedNodeId string
+	EncryptedData []byte
+}
+
+type Bolt12BlindedPath struct {
+	// Either a node id, or a "{scid}/{direction}" if the path
+	// starts at a channel
+	FirstNodeId  string
+	FirstPathKey string
+	Hops         []*Bolt12BlindedHop
+}
+
+type Bolt12BlindedPayInfo struct {
+	FeeBaseMsat               uint32
+	FeeProportionalMillionths uint32
+	CltvExpiryDelta           uint16
+	HtlcMinimumMsat           uint64
+	HtlcMaximumMsat           uint64
+	Features                  Features
+}
+
+type Bolt12Fallback struct {
+	Version uint8
+	Address []byte
+}
+
+type Bolt12Offer struct {
+	Chains []string
+	// Unset if the offer is for a chain-native amount
+	Currency       string
+	Metadata       []byte
+	Amount         uint64
+	Description    string
+	Features       Features
+	AbsoluteExpiry uint64
+	Paths          []*Bolt12BlindedPath
+	Issuer         string
+	QuantityMax    uint64
+	IssuerId       string
+
+	// Every TLV record, including ones not decoded above
+	Records []*Bolt12Record
+}
+
+type Bolt12InvoiceRequest struct {
+	Bolt12Offer
+	InvreqMetadata []byte
+	InvreqChain    string
+	InvreqAmount   uint64
+	InvreqFeatures Features
+	Quantity       uint64
+	PayerId        string
+	PayerNote      string
+	InvreqPaths    []*Bolt12BlindedPath
+	Signature      []byte
+}
+
+type Bolt12Invoice struct {
+	Bolt12InvoiceRequest
+	InvoicePaths    []*Bolt12BlindedPath
+	BlindedPay      []*Bolt12BlindedPayInfo
+	CreatedAt       uint64
+	RelativeExpiry  uint32
+	PaymentHash     string
+	InvoiceAmount   uint64
+	Fallbacks       []*Bolt12Fallback
+	InvoiceFeatures Features
+	NodeId          string
+}
+
+const (
+	bolt12SignatureStart = 240
+	bolt12SignatureEnd   = 1000
+	bolt12Signature      = 240
+)
+
+// Decode an 'lno' offer string
+func DecodeOffer(offer string) (*Bolt12Offer, error) {
+	records, err := decodeBolt12String("lno", offer)
+	if err != nil {
+		return nil, err
+	}
+	var o Bolt12Offer
+	if err := o.fill(records, true); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Decode an 'lnr' invoice request string
+func DecodeInvoiceRequest(invreq string) (*Bolt12InvoiceRequest, error) {
+	records, err := decodeBolt12String("lnr", invreq)
+	if err != nil {
+		return nil, err
+	}
+	var r Bolt12InvoiceRequest
+	if err := r.fill(records, true); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Decode an 'lni' invoice string
+func DecodeBolt12Invoice(invoice string) (*Bolt12Invoice, error) {
+	records, err := decodeBolt12String("lni", invoice)
+	if err != nil {
+		return nil, err
+	}
+	var i Bolt12Invoice
+	if err := i.fill(records); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// The offer id: the merkle root of the offer's TLV stream
+func (o *Bolt12Offer) OfferId() string {
+	return hex.EncodeToString(bolt12MerkleRoot(o.Records))
+}
+
+// Check the invoice request was signed by its PayerId
+func (r *Bolt12InvoiceRequest) VerifySignature() error {
+	return verifyBolt12Signature("invoice_request", r.Records, r.PayerId, r.Signature)
+}
+
+// Check the invoice was signed by its NodeId
+func (i *Bolt12Invoice) VerifySignature() error {
+	return verifyBolt12Signature("invoice", i.Records, i.NodeId, i.Signature)
+}
+
+func (o *Bolt12Offer) fill(records []*Bolt12Record, strict bool) error {
+	o.Records = records
+	for _, rec := range records {
+		var err error
+		v := rec.Value
+		switch rec.Type {
+		case 2:
+			if len(v)%32 != 0 {
+				return fmt.Errorf("offer_chains: bad length %d", len(v))
+			}
+			for ; len(v) > 0; v = v[32:] {
+				o.Chains = append(o.Chains, hex.EncodeToString(v[:32]))
+			}
+		case 4:
+			o.Metadata = v
+		case 6:
+			o.Currency = string(v)
+		case 8:
+			o.Amount, err = tlv.ReadTu64(v)
+		case 10:
+			o.Description = string(v)
+		case 12:
+			o.Features = Features(v)
+		case 14:
+			o.AbsoluteExpiry, err = tlv.ReadTu64(v)
+		case 16:
+			o.Paths, err = bolt12BlindedPaths(v)
+		case 18:
+			o.Issuer = string(v)
+		case 20:
+			o.QuantityMax, err = tlv.ReadTu64(v)
+		case 22:
+			o.IssuerId, err = bolt12Point(v)
+		default:
+			if strict && rec.Type%2 == 0 {
+				return fmt.Errorf("Unknown even field %d", rec.Type)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid field %d: %s", rec.Type, err)
+		}
+	}
+	return nil
+}
+
+func (r *Bolt12InvoiceRequest) fill(records []*Bolt12Record, strict bool) error {
+	if err := r.Bolt12Offer.fill(records, false); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		var err error
+		v := rec.Value
+		switch rec.Type {
+		case 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22:
+			// offer fields
+		case 0:
+			r.InvreqMetadata = v
+		case 80:
+			if len(v) != 32 {
+				return fmt.Errorf("invreq_chain: bad length %d", len(v))
+			}
+			r.InvreqChain = hex.EncodeToString(v)
+		case 82:
+			r.InvreqAmount, err = tlv.ReadTu64(v)
+		case 84:
+			r.InvreqFeatures = Features(v)
+		case 86:
+			r.Quantity, err = tlv.ReadTu64(v)
+		case 88:
+			r.PayerId, err = bolt12Point(v)
+		case 89:
+			r.PayerNote = string(v)
+		case 90:
+			r.InvreqPaths, err = bolt12BlindedPaths(v)
+		case bolt12Signature:
+			if len(v) != 64 {
+				return fmt.Errorf("signature: bad length %d", len(v))
+			}
+			r.Signature = v
+		default:
+			if strict && rec.Type%2 == 0 {
+				return fmt.Errorf("Unknown even field %d", rec.Type)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid field %d: %s", rec.Type, err)
+		}
+	}
+	return nil
+}
+
+func (i *Bolt12Invoice) fill(records []*Bolt12Record) error {
+	if err := i.Bolt12InvoiceRequest.fill(records, false); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		var err error
+		v := rec.Value
+		switch rec.Type {
+		case 0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22,
+			80, 82, 84, 86, 88, 89, 90, bolt12Signature:
+			// offer and invoice request fields
+		case 160:
+			i.InvoicePaths, err = bolt12BlindedPaths(v)
+		case 162:
+			i.BlindedPay, err = bolt12BlindedPayInfos(v)
+		case 164:
+			i.CreatedAt, err = tlv.ReadTu64(v)
+		case 166:
+			var expiry uint64
+			expiry, err = tlv.ReadTu64(v)
+			if err == nil && expiry > 0xFFFFFFFF {
+				err = fmt.Errorf("too large")
+			}
+			i.RelativeExpiry = uint32(expiry)
+		case 168:
+			if len(v) != 32 {
+				return fmt.Errorf("invoice_payment_hash: bad length %d", len(v))
+			}
+			i.PaymentHash = hex.EncodeToString(v)
+		case 170:
+			i.InvoiceAmount, err = tlv.ReadTu64(v)
+		case 172:
+			i.Fallbacks, err = bolt12Fallbacks(v)
+		case 174:
+			i.InvoiceFeatures = Features(v)
+		case 176:
+			i.NodeId, err = bolt12Point(v)
+		default:
+			if rec.Type%2 == 0 {
+				return fmt.Errorf("Unknown even field %d", rec.Type)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid field %d: %s", rec.Type, err)
+		}
+	}
+	return nil
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Strip '+' joins, check the hrp and convert the bech32 data
+// (which has no checksum) to bytes, then split it into records
+func decodeBolt12String(hrp, s string) ([]*Bolt12Record, error) {
+	// long strings may be split with '+' and whitespace
+	var b strings.Builder
+	for _, part := range strings.Split(s, "+") {
+		b.WriteString(strings.TrimFunc(part, unicode.IsSpace))
+	}
+	s = b.String()
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return nil, fmt.Errorf("Mixed case in bolt12 string")
+	}
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 0 || s[:sep] != hrp {
+		return nil, fmt.Errorf("Expected a bolt12 string starting with %s1", hrp)
+	}
+
+	var data []byte
+	var acc uint
+	var bits uint
+	for _, c := range s[sep+1:] {
+		v := strings.IndexRune(bech32Charset, c)
+		if v < 0 {
+			return nil, fmt.Errorf("Invalid bech32 character %q", c)
+		}
+		acc = acc<<5 | uint(v)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			data = append(data, byte(acc>>bits))
+		}
+	}
+	// leftover bits are padding, and must be zero
+	if bits >= 5 || acc&(1<<bits-1) != 0 {
+		return nil, fmt.Errorf("Invalid bech32 padding")
+	}
+
+	return bolt12Records(data)
+}
+
+func bolt12Records(data []byte) ([]*Bolt12Record, error) {
+	records, err := tlv.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("Empty bolt12 string")
+	}
+	return records, nil
+}
+
+func bolt12Point(v []byte) (string, error) {
+	if len(v) != 33 || (v[0] != 2 && v[0] != 3) {
+		return "", fmt.Errorf("not a compressed pubkey")
+	}
+	return hex.EncodeToString(v), nil
+}
+
+func bolt12BlindedPaths(v []byte) ([]*Bolt12BlindedPath, error) {
+	var paths []*Bolt12BlindedPath
+	r := bytes.NewReader(v)
+	for r.Len() > 0 {
+		var path Bolt12BlindedPath
+		first, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		r.UnreadByte()
+		if first == 0 || first == 1 {
+			// sciddir: direction byte then short channel id
+			buf := make([]byte, 9)
+			if n, _ := r.Read(buf); n != 9 {
+				return nil, fmt.Errorf("truncated blinded path")
+			}
+			scid := ShortChannelIdFromUint64(binary.BigEndian.Uint64(buf[1:]))
+			path.FirstNodeId = fmt.Sprintf("%s/%d", scid, buf[0])
+		} else {
+			if path.FirstNodeId, err = readBolt12Point(r); err != nil {
+				return nil, err
+			}
+		}
+		if path.FirstPathKey, err = readBolt12Point(r); err != nil {
+			return nil, err
+		}
+		numHops, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("truncated blinded path")
+		}
+		for j := 0; j < int(numHops); j++ {
+			var hop Bolt12BlindedHop
+			if hop.BlindedNodeId, err = readBolt12Point(r); err != nil {
+				return nil, err
+			}
+			var encLen uint16
+			if err := binary.Read(r, binary.BigEndian, &encLen); err != nil {
+				return nil, fmt.Errorf("truncated blinded path")
+			}
+			hop.EncryptedData = make([]byte, encLen)
+			if n, _ := r.Read(hop.EncryptedData); n != int(encLen) {
+				return nil, fmt.Errorf("truncated blinded path")
+			}
+			path.Hops = append(path.Hops, &hop)
+		}
+		paths = append(paths, &path)
+	}
+	return paths, nil
+}
+
+func readBolt12Point(r *bytes.Reader) (string, error) {
+	buf := make([]byte, 33)
+	if n, _ := r.Read(buf); n != 33 {
+		return "", fmt.Errorf("truncated point")
+	}
+	return bolt12Point(buf)
+}
+
+func bolt12BlindedPayInfos(v []byte) ([]*Bolt12BlindedPayInfo, error) {
+	var infos []*Bolt12BlindedPayInfo
+	r := bytes.NewReader(v)
+	for r.Len() > 0 {
+		var info Bolt12BlindedPayInfo
+		var flen uint16
+		for _, field := range []interface{}{
+			&info.FeeBaseMsat,
+			&info.FeeProportionalMillionths,
+			&info.CltvExpiryDelta,
+			&info.HtlcMinimumMsat,
+			&info.HtlcMaximumMsat,
+			&flen,
+		} {
+			if err := binary.Read(r, binary.BigEndian, field); err != nil {
+				return nil, fmt.Errorf("truncated blinded payinfo")
+			}
+		}
+		info.Features = make(Features, flen)
+		if n, _ := r.Read(info.Features); n != int(flen) {
+			return nil, fmt.Errorf("truncated blinded payinfo")
+		}
+		infos = append(infos, &info)
+	}
+	return infos, nil
+}
+
+func bolt12Fallbacks(v []byte) ([]*Bolt12Fallback, error) {
+	var fallbacks []*Bolt12Fallback
+	r := bytes.NewReader(v)
+	for r.Len() > 0 {
+		var fb Bolt12Fallback
+		var alen uint16
+		if err := binary.Read(r, binary.BigEndian, &fb.Version); err != nil {
+			return nil, fmt.Errorf("truncated fallback")
+		}
+		if err := binary.Read(r, binary.BigEndian, &alen); err != nil {
+			return nil, fmt.Errorf("truncated fallback")
+		}
+		fb.Address = make([]byte, alen)
+		if n, _ := r.Read(fb.Address); n != int(alen) {
+			return nil, fmt.Errorf("truncated fallback")
+		}
+		fallbacks = append(fallbacks, &fb)
+	}
+	return fallbacks, nil
+}
+
+// SHA256(SHA256(tag) || SHA256(tag) || msg)
+func taggedHash(tag []byte, msg ...[]byte) []byte {
+	t := sha256.Sum256(tag)
+	h := sha256.New()
+	h.Write(t[:])
+	h.Write(t[:])
+	for _, m := range msg {
+		h.Write(m)
+	}
+	return h.Sum(nil)
+}
+
+func bolt12Branch(a, b []byte) []byte {
+	if bytes.Compare(a, b) > 0 {
+		a, b = b, a
+	}
+	return taggedHash([]byte("LnBranch"), a, b)
+}
+
+// The merkle root of a TLV stream, as used for offer ids and
+// signatures. Signature fields are not included.
+func bolt12MerkleRoot(records []*Bolt12Record) []byte {
+	var encoded [][]byte
+	var types [][]byte
+	for _, rec := range records {
+		if rec.Type >= bolt12SignatureStart && rec.Type <= bolt12SignatureEnd {
+			continue
+		}
+		var typ, full bytes.Buffer
+		tlv.WriteBigSize(&typ, rec.Type)
+		full.Write(typ.Bytes())
+		tlv.WriteBigSize(&full, uint64(len(rec.Value)))
+		full.Write(rec.Value)
+		types = append(types, typ.Bytes())
+		encoded = append(encoded, full.Bytes())
+	}
+	if len(encoded) == 0 {
+		return nil
+	}
+
+	nonceTag := append([]byte("LnNonce"), encoded[0]...)
+	leaves := make([][]byte, len(encoded))
+	for i := range encoded {
+		leaves[i] = bolt12Branch(
+			taggedHash([]byte("LnLeaf"), encoded[i]),
+			taggedHash(nonceTag, types[i]))
+	}
+
+	// pair up neighbours; an odd one out is carried up a level
+	for len(leaves) > 1 {
+		var next [][]byte
+		for i := 0; i < len(leaves); i += 2 {
+			if i+1 < len(leaves) {
+				next = append(next, bolt12Branch(leaves[i], leaves[i+1]))
+			} else {
+				next = append(next, leaves[i])
+			}
+		}
+		leaves = next
+	}
+	return leaves[0]
+}
+
+func verifyBolt12Signature(messageName string, records []*Bolt12Record, pubkey string, sig []byte) error {
+	if len(sig) == 0 {
+		return fmt.Errorf("No signature")
+	}
+	key, err := hex.DecodeString(pubkey)
+	if err != nil || len(key) != 33 {
+		return fmt.Errorf("No valid signing key")
+	}
+	tag := []byte("lightning" + messageName + "signature")
+	msg := taggedHash(tag, bolt12MerkleRoot(records))
+	// BOLT#12 signatures are BIP-340, over the x-only key
+	if !schnorrVerify(key[1:], msg, sig) {
+		return fmt.Errorf("Invalid signature")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package glightning_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testOffer   = "lno1qgsqvgnwgcg35z6ee2h3yczraddm72xrfua9uve2rlrm9deu7xyfzrcgqgn3qzsxvdhkven9v5fqgcmpvej3gqg9zcssxday4mclss3u5pmwfd7en2x2hl6qmkuzx8e2nuqss8c46laxtsd6"
+	testInvreq  = "lnr1qqz24w7vm5pzqp3zderpzxstt8927ynqg044h0egcd8n5h3n9g0u0v4h8ncc3yg0pqpzwyq2qe3k7enxv4j3yprrv9nx29qpq5tzzqeh5jh0r7zz8js8de9hmxdge2llgrwmsgcl920szzqlzhtl5ewphfgzqp3zderpzxstt8927ynqg044h0egcd8n5h3n9g0u0v4h8ncc3yg02gpyugzkqyp9sggrpjnjfstl8q5ppktk305ej7t7fuwqeqcpcvjzmhscc9cyh2c2e4d4jpn5dpsku6mn7pq8llxfa5q8luh9knry02dsakk743kamy0a7tm78yxghlqjhhsw85kgysfjgtgg4lc9el089ddlt4e26adg94stgsvmxfza5nqyzhyhuy"
+	testInvoice = "lni1qqz24w7vm5pzqp3zderpzxstt8927ynqg044h0egcd8n5h3n9g0u0v4h8ncc3yg0pqpzwyq2qe3k7enxv4j3yprrv9nx29qpq5tzzqeh5jh0r7zz8js8de9hmxdge2llgrwmsgcl920szzqlzhtl5ewphfgzqp3zderpzxstt8927ynqg044h0egcd8n5h3n9g0u0v4h8ncc3yg02gpyugzkqyp9sggrpjnjfstl8q5ppktk305ej7t7fuwqeqcpcvjzmhscc9cyh2c2e4d4jpn5dpsku6mn5p5syh9a7pjxuhd5a23e3um97t485r3agxdhuqesuwwwj27aah9vf7duqghsre0ptn9r28d07wzrldc08shs5x7aqhj6lzy2vauyaulppg4qzqgr4n2gfchsclm9xzddz79f74v6hhsf095hf3t7w9xrtugsmlp8ejlqqqcpqgp6y8qqqqp7sqqqqpjqpyqqqqqqqqqqqqqsqqqqqqae4jsqqqq2gpr920cspfszrss2sgqqqypqxpq9qcrsszg2pvxq6rs0zqg3yyc5z5tpwxqergd3c8g7r74qyn3qkqssxday4mclss3u5pmwfd7en2x2hl6qmkuzx8e2nuqss8c46laxtsd67pqv7al4w8t09taf0j8wehmuxn0nnlwpdxhwcn8h3ex95lwjyewt2geehhz6e4qt500f99yk48ftsrdypr3trfkqgcnwjwyjksqnk43zxs"
+
+	regtestChain = "06226e46111a0b59caaf126043eb5bbf28c34f3a5e332a1fc7b2b73cf188910f"
+	issuerId     = "0337a4aef1f8423ca076e4b7d99a8cabff40ddb8231f2a9f01081f15d7fa65c1ba"
+	payerId      = "030ca724c17f382810d9768be999797e4f1c0c8301c3242dde18c1704bab0acd5b"
+)
+
+func TestDecodeOffer(t *testing.T) {
+	offer, err := glightning.DecodeOffer(testOffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{regtestChain}, offer.Chains)
+	assert.Equal(t, uint64(10000), offer.Amount)
+	assert.Equal(t, "coffee", offer.Description)
+	assert.Equal(t, "cafe", offer.Issuer)
+	assert.Equal(t, uint64(5), offer.QuantityMax)
+	assert.Equal(t, issuerId, offer.IssuerId)
+	assert.Equal(t, "8d145090b3802d3c23cb7b536058815b0b1ad21905bb5f02d38444f22beb7d06", offer.OfferId())
+	assert.Len(t, offer.Records, 6)
+}
+
+func TestDecodeOfferSplit(t *testing.T) {
+	split := testOffer[:20] + "+\n  " + testOffer[20:]
+	offer, err := glightning.DecodeOffer(strings.ToUpper(split))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "coffee", offer.Description)
+}
+
+func TestDecodeOfferErrors(t *testing.T) {
+	_, err := glightning.DecodeOffer(testInvreq)
+	assert.Error(t, err)
+	_, err = glightning.DecodeOffer("lno1")
+	assert.Error(t, err)
+	_, err = glightning.DecodeOffer("lno1qgsqvgnwgcg35z6ee2h3yczraddm72xrfua9uve2rlrm9deu7xyfzrcgqgn3qzsxvdhkven9v5fqgcmpvej3gqg9zcssxday4mclss3u5pmwfd7en2x2hl6qmkuzx8e2nuqss8c46laxtsd")
+	assert.Error(t, err, "truncated")
+	_, err = glightning.DecodeOffer("lno1QGSqvgnwgcg35z6ee2h3yczraddm72xrfua9uve2rlrm9deu7xyfzrcgqgn3qzsxvdhkven9v5fqgcmpvej3gqg9zcssxday4mclss3u5pmwfd7en2x2hl6qmkuzx8e2nuqss8c46laxtsd6")
+	assert.Error(t, err, "mixed case")
+}
+
+func TestDecodeInvoiceRequest(t *testing.T) {
+	invreq, err := glightning.DecodeInvoiceRequest(testInvreq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "aabbccdd", hex.EncodeToString(invreq.InvreqMetadata))
+	assert.Equal(t, "coffee", invreq.Description)
+	assert.Equal(t, regtestChain, invreq.InvreqChain)
+	assert.Equal(t, uint64(20000), invreq.InvreqAmount)
+	assert.Equal(t, uint64(2), invreq.Quantity)
+	assert.Equal(t, payerId, invreq.PayerId)
+	assert.Equal(t, "thanks", invreq.PayerNote)
+	assert.NoError(t, invreq.VerifySignature())
+
+	invreq.PayerNote = "tampered"
+	for _, rec := range invreq.Records {
+		if rec.Type == 89 {
+			rec.Value = []byte("tampered")
+		}
+	}
+	assert.Error(t, invreq.VerifySignature())
+}
+
+func TestDecodeBolt12Invoice(t *testing.T) {
+	invoice, err := glightning.DecodeBolt12Invoice(testInvoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "coffee", invoice.Description)
+	assert.Equal(t, payerId, invoice.PayerId)
+	assert.Equal(t, uint64(1700000000), invoice.CreatedAt)
+	assert.Equal(t, uint32(7200), invoice.RelativeExpiry)
+	assert.Equal(t, "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", invoice.PaymentHash)
+	assert.Equal(t, uint64(20000), invoice.InvoiceAmount)
+	assert.Equal(t, issuerId, invoice.NodeId)
+
+	assert.Equal(t, []*glightning.Bolt12BlindedPath{
+		&glightning.Bolt12BlindedPath{
+			FirstNodeId:  "025cbdf0646e5db4eaa398f365f2ea7a0e3d419b7e0330e39ce92bddedcac4f9bc",
+			FirstPathKey: "022f01e5e15cca351daff3843fb70f3c2f0a1bdd05e5af888a67784ef3e10a2a01",
+			Hops: []*glightning.Bolt12BlindedHop{
+				&glightning.Bolt12BlindedHop{
+					BlindedNodeId: "03acd484e2f0c7f65309ad178a9f559abde09796974c57e714c35f110dfc27ccbe",
+					EncryptedData: []byte{1, 2, 3},
+				},
+			},
+		},
+	}, invoice.InvoicePaths)
+	assert.Equal(t, []*glightning.Bolt12BlindedPayInfo{
+		&glightning.Bolt12BlindedPayInfo{
+			FeeBaseMsat:               1000,
+			FeeProportionalMillionths: 100,
+			CltvExpiryDelta:           144,
+			HtlcMinimumMsat:           1,
+			HtlcMaximumMsat:           1000000000,
+			Features:                  glightning.Features{},
+		},
+	}, invoice.BlindedPay)
+
+	assert.NoError(t, invoice.VerifySignature())
+
+	// an invoice isn't an invoice request
+	_, err = glightning.DecodeInvoiceRequest(strings.Replace(testInvoice, "lni1", "lnr1", 1))
+	assert.Error(t, err)
+}
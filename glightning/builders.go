@@ -0,0 +1,326 @@
+package glightning
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Builds an InvoiceRequest, for CreateInvoiceWithOptions, instead of
+// threading every option through CreateInvoice's parameters:
+//
+//	req, err := NewInvoiceBuilder().
+//		Msat(5000).
+//		Label("order-7").
+//		Description("coffee").
+//		Expiry(10 * time.Minute).
+//		PrivateHints(true).
+//		Build()
+type InvoiceBuilder struct {
+	req InvoiceRequest
+	err error
+}
+
+func NewInvoiceBuilder() *InvoiceBuilder {
+	return &InvoiceBuilder{}
+}
+
+func (b *InvoiceBuilder) fail(err error) *InvoiceBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+func (b *InvoiceBuilder) Msat(msat uint64) *InvoiceBuilder {
+	if msat == 0 {
+		return b.fail(fmt.Errorf("No value set for invoice. (`msat` is less than or equal to zero)."))
+	}
+	b.req.MilliSatoshis = fmt.Sprint(msat)
+	return b
+}
+
+// An invoice which can be paid with any amount
+func (b *InvoiceBuilder) AnyAmount() *InvoiceBuilder {
+	b.req.MilliSatoshis = "any"
+	return b
+}
+
+func (b *InvoiceBuilder) Label(label string) *InvoiceBuilder {
+	b.req.Label = label
+	return b
+}
+
+func (b *InvoiceBuilder) Description(description string) *InvoiceBuilder {
+	b.req.Description = description
+	return b
+}
+
+// Commit to the description's hash rather than including it; see
+// CreateInvoiceDescHashOnly
+func (b *InvoiceBuilder) DescHashOnly() *InvoiceBuilder {
+	b.req.DescHashOnly = true
+	return b
+}
+
+// Rounded down to whole seconds
+func (b *InvoiceBuilder) Expiry(expiry time.Duration) *InvoiceBuilder {
+	if expiry < time.Second {
+		return b.fail(fmt.Errorf("Expiry must be at least a second, not %s", expiry))
+	}
+	b.req.ExpirySeconds = uint32(expiry / time.Second)
+	return b
+}
+
+func (b *InvoiceBuilder) Fallbacks(addresses ...string) *InvoiceBuilder {
+	b.req.Fallbacks = append(b.req.Fallbacks, addresses...)
+	return b
+}
+
+// The payment preimage, as 64 hex digits. Only set this if you know
+// you need to; lightningd generates one otherwise.
+func (b *InvoiceBuilder) Preimage(preimage string) *InvoiceBuilder {
+	if raw, err := hex.DecodeString(preimage); err != nil || len(raw) != 32 {
+		return b.fail(fmt.Errorf("Preimage must be 32 bytes of hex"))
+	}
+	b.req.PreImage = preimage
+	return b
+}
+
+func (b *InvoiceBuilder) Cltv(cltv uint32) *InvoiceBuilder {
+	b.req.Cltv = cltv
+	return b
+}
+
+// Whether to include route hints for private channels
+func (b *InvoiceBuilder) PrivateHints(expose bool) *InvoiceBuilder {
+	b.req.ExposePrivChansFlag = &expose
+	return b
+}
+
+// Only include route hints for these private channels
+func (b *InvoiceBuilder) ExposeChannels(shortChannelIds ...string) *InvoiceBuilder {
+	b.req.ExposeTheseChannels = append(b.req.ExposeTheseChannels, shortChannelIds...)
+	return b
+}
+
+func (b *InvoiceBuilder) Build() (*InvoiceRequest, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	req := b.req
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Builds a PayRequest, for Pay:
+//
+//	req, err := NewPayBuilder().
+//		Bolt11(bolt11).
+//		Label("rent").
+//		MaxFee(NewMsat(1000)).
+//		RetryFor(2 * time.Minute).
+//		Build()
+type PayBuilder struct {
+	req PayRequest
+	err error
+}
+
+func NewPayBuilder() *PayBuilder {
+	return &PayBuilder{}
+}
+
+func (b *PayBuilder) fail(err error) *PayBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+func (b *PayBuilder) Bolt11(bolt11 string) *PayBuilder {
+	b.req.Bolt11 = bolt11
+	return b
+}
+
+// The amount to pay, for invoices which don't specify one
+func (b *PayBuilder) Msat(msat uint64) *PayBuilder {
+	b.req.MilliSatoshi = msat
+	return b
+}
+
+// Required if the invoice only has a description hash
+func (b *PayBuilder) Description(description string) *PayBuilder {
+	b.req.Desc = description
+	return b
+}
+
+func (b *PayBuilder) Label(label string) *PayBuilder {
+	b.req.Label = label
+	return b
+}
+
+func (b *PayBuilder) RiskFactor(riskFactor float32) *PayBuilder {
+	b.req.RiskFactor = riskFactor
+	return b
+}
+
+func (b *PayBuilder) MaxFeePercent(percent float32) *PayBuilder {
+	b.req.MaxFeePercent = percent
+	return b
+}
+
+func (b *PayBuilder) ExemptFee(fee *MSat) *PayBuilder {
+	b.req.ExemptFee = fee.String()
+	return b
+}
+
+// An absolute cap on the fee; can't be combined with MaxFeePercent
+// or ExemptFee
+func (b *PayBuilder) MaxFee(fee *MSat) *PayBuilder {
+	b.req.MaxFee = fee.String()
+	return b
+}
+
+// Rounded down to whole seconds
+func (b *PayBuilder) RetryFor(retryFor time.Duration) *PayBuilder {
+	if retryFor < time.Second {
+		return b.fail(fmt.Errorf("RetryFor must be at least a second, not %s", retryFor))
+	}
+	b.req.RetryFor = uint(retryFor / time.Second)
+	return b
+}
+
+// In blocks
+func (b *PayBuilder) MaxDelay(blocks uint) *PayBuilder {
+	b.req.MaxDelay = blocks
+	return b
+}
+
+// Channels (scid/direction) or node ids to route around
+func (b *PayBuilder) Exclude(excluded ...string) *PayBuilder {
+	b.req.Exclude = append(b.req.Exclude, excluded...)
+	return b
+}
+
+// Pay only part of the invoice; see PayPartial
+func (b *PayBuilder) Partial(partial *MSat) *PayBuilder {
+	if partial == nil || partial.Value == 0 {
+		return b.fail(fmt.Errorf("Must set a partial amount to pay"))
+	}
+	b.req.PartialMsat = partial.String()
+	return b
+}
+
+func (b *PayBuilder) Build() (*PayRequest, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	req := b.req
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Builds a FundChannelRequest, for FundChannelWithOptions. Channels
+// are public unless Private is set.
+//
+//	req, err := NewFundChannelBuilder().
+//		NodeId(peer).
+//		Amount(NewSat(100000)).
+//		FeeRate(NewFeeRateByDirective(PerKb, Urgent)).
+//		Private().
+//		Build()
+type FundChannelBuilder struct {
+	req FundChannelRequest
+	err error
+}
+
+func NewFundChannelBuilder() *FundChannelBuilder {
+	return &FundChannelBuilder{req: FundChannelRequest{Announce: true}}
+}
+
+func (b *FundChannelBuilder) fail(err error) *FundChannelBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+func (b *FundChannelBuilder) NodeId(id string) *FundChannelBuilder {
+	b.req.Id = id
+	return b
+}
+
+// See AllSats to fund with the whole wallet
+func (b *FundChannelBuilder) Amount(amount *Sat) *FundChannelBuilder {
+	if amount == nil || (amount.Value == 0 && !amount.SendAll) {
+		return b.fail(fmt.Errorf("Must set satoshi amount to send"))
+	}
+	b.req.Amount = amount.RawString()
+	return b
+}
+
+func (b *FundChannelBuilder) FeeRate(feerate *FeeRate) *FundChannelBuilder {
+	b.req.FeeRate = feerate.String()
+	return b
+}
+
+// Don't announce the channel
+func (b *FundChannelBuilder) Private() *FundChannelBuilder {
+	b.req.Announce = false
+	return b
+}
+
+func (b *FundChannelBuilder) MinConf(minConf uint16) *FundChannelBuilder {
+	b.req.MinConf = &minConf
+	return b
+}
+
+// Gift {push} of the funding amount to the peer
+func (b *FundChannelBuilder) PushMsat(push *MSat) *FundChannelBuilder {
+	b.req.PushMsat = push.String()
+	return b
+}
+
+// Lease {amount} of liquidity from the peer, at the rates of the
+// liquidity ad {compactLease} (WillFund.CompactLease)
+func (b *FundChannelBuilder) Lease(amount *Sat, compactLease string) *FundChannelBuilder {
+	if amount == nil || amount.Value == 0 || compactLease == "" {
+		return b.fail(fmt.Errorf("Must set both the amount to lease and the lease rates"))
+	}
+	b.req.RequestAmt = amount.RawString()
+	b.req.CompactLease = compactLease
+	return b
+}
+
+// Make the channel usable once its funding has {depth} confirmations
+func (b *FundChannelBuilder) MinDepth(depth uint32) *FundChannelBuilder {
+	b.req.MinDepth = &depth
+	return b
+}
+
+// Open a zero-conf channel, usable before its funding confirms. The
+// peer must accept it as such, e.g. from its openchannel hook.
+func (b *FundChannelBuilder) ZeroConf() *FundChannelBuilder {
+	return b.MinDepth(0)
+}
+
+// Propose a channel type of {bits}
+func (b *FundChannelBuilder) ChannelType(bits ...FeatureBit) *FundChannelBuilder {
+	b.req.ChannelType = bits
+	return b
+}
+
+func (b *FundChannelBuilder) Build() (*FundChannelRequest, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	req := b.req
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
@@ -0,0 +1,156 @@
+package glightning_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvoiceBuilder(t *testing.T) {
+	req, err := glightning.NewInvoiceBuilder().
+		Msat(5000).
+		Label("order-7").
+		Description("coffee").
+		Expiry(10 * time.Minute).
+		PrivateHints(true).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "5000", req.MilliSatoshis)
+	assert.Equal(t, uint32(600), req.ExpirySeconds)
+	assert.True(t, *req.ExposePrivChansFlag)
+
+	_, err = glightning.NewInvoiceBuilder().AnyAmount().Description("tip").Build()
+	assert.EqualError(t, err, "Must set a label on an invoice")
+
+	_, err = glightning.NewInvoiceBuilder().Msat(1).Label("a").Description("b").Preimage("00").Build()
+	assert.EqualError(t, err, "Preimage must be 32 bytes of hex")
+
+	_, err = glightning.NewInvoiceBuilder().Msat(1).Label("a").Description("b").PrivateHints(false).ExposeChannels("1x2x3").Build()
+	assert.EqualError(t, err, "Cannot both flag to expose private and provide list of short channel ids")
+
+	// the first error is the one reported
+	_, err = glightning.NewInvoiceBuilder().Msat(0).Expiry(0).Build()
+	assert.EqualError(t, err, "No value set for invoice. (`msat` is less than or equal to zero).")
+}
+
+func TestCreateInvoiceWithOptions(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"invoice","params":{"cltv":18,"description":"coffee","exposeprivatechannels":["1x2x3"],"label":"order-7","msatoshi":"any"},"id":1}`
+	resp := wrapResult(1, `{"payment_hash": "aa", "expires_at": 1546475890, "bolt11": "lnbcrt1"}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	invoiceReq, err := glightning.NewInvoiceBuilder().
+		AnyAmount().
+		Label("order-7").
+		Description("coffee").
+		Cltv(18).
+		ExposeChannels("1x2x3").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoice, err := lightning.CreateInvoiceWithOptions(invoiceReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "lnbcrt1", invoice.Bolt11)
+
+	_, err = lightning.CreateInvoiceWithOptions(&glightning.InvoiceRequest{MilliSatoshis: "1"})
+	assert.Error(t, err)
+}
+
+func TestPayBuilder(t *testing.T) {
+	req, err := glightning.NewPayBuilder().
+		Bolt11("lnbcrt1").
+		Label("rent").
+		MaxFee(glightning.NewMsat(1000)).
+		RetryFor(2 * time.Minute).
+		Exclude("233x1x0/0").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.PayRequest{
+		Bolt11:   "lnbcrt1",
+		Label:    "rent",
+		MaxFee:   "1000msat",
+		RetryFor: 120,
+		Exclude:  []string{"233x1x0/0"},
+	}, req)
+
+	_, err = glightning.NewPayBuilder().Bolt11("lnbcrt1").MaxFee(glightning.NewMsat(1000)).MaxFeePercent(1).Build()
+	assert.EqualError(t, err, "MaxFee can't be combined with MaxFeePercent or ExemptFee")
+
+	_, err = glightning.NewPayBuilder().Msat(1000).Build()
+	assert.EqualError(t, err, "Must supply a Bolt11 to pay")
+}
+
+func TestFundChannelWithOptions(t *testing.T) {
+	peer := "02e3cd7849f177a46f137ae3bfc1a08fc6a90bf4026c74f83c1ecc8430c282fe96"
+	req := `{"jsonrpc":"2.0","method":"fundchannel","params":{"amount":"100000","announce":false,"feerate":"urgent","id":"` + peer + `","minconf":3,"push_msat":"1000msat"},"id":1}`
+	resp := wrapResult(1, `{"tx": "0200", "txid": "cc", "channel_id": "dd"}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	fundReq, err := glightning.NewFundChannelBuilder().
+		NodeId(peer).
+		Amount(glightning.NewSat(100000)).
+		FeeRate(glightning.NewFeeRateByDirective(glightning.PerKb, glightning.Urgent)).
+		Private().
+		MinConf(3).
+		PushMsat(glightning.NewMsat(1000)).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := lightning.FundChannelWithOptions(fundReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "cc", result.FundingTxId)
+
+	_, err = glightning.NewFundChannelBuilder().NodeId(peer).Build()
+	assert.EqualError(t, err, "Must set satoshi amount to send")
+
+	fundReq, err = glightning.NewFundChannelBuilder().NodeId(peer).Amount(glightning.AllSats()).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, fundReq.Announce)
+	assert.Equal(t, "all", fundReq.Amount)
+
+	_, err = glightning.NewFundChannelBuilder().NodeId(peer).Amount(glightning.NewSat(100000)).Lease(glightning.NewSat(500000), "").Build()
+	assert.EqualError(t, err, "Must set both the amount to lease and the lease rates")
+}
+
+func TestFundChannelZeroConf(t *testing.T) {
+	peer := "02befaace6e8970aaca34eafe85f30f988e374628ec279d94e7eca8b574b738eb4"
+	lightning, requestQ, replyQ := startupServer(t)
+	req := `{"jsonrpc":"2.0","method":"fundchannel","params":{"amount":"100000","announce":false,"channel_type":[12,46,50],"id":"` + peer + `","mindepth":0},"id":1}`
+	resp := wrapResult(1, `{"tx": "0200", "txid": "cc", "channel_id": "dd", "channel_type": {"bits": [12, 46, 50], "names": ["static_remotekey/even", "scid_alias/even", "zeroconf/even"]}, "mindepth": 0}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+
+	fundReq, err := glightning.NewFundChannelBuilder().
+		NodeId(peer).
+		Amount(glightning.NewSat(100000)).
+		Private().
+		ZeroConf().
+		ChannelType(glightning.FeatureStaticRemoteKey, glightning.FeatureScidAlias, glightning.FeatureZeroConf).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := lightning.FundChannelWithOptions(fundReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, result.ChannelType.Has(glightning.FeatureZeroConf))
+	assert.Equal(t, uint32(0), *result.MinDepth)
+
+	// and the peer's side
+	event := &glightning.OpenChannelEvent{}
+	zero := uint32(0)
+	assert.Equal(t, &glightning.OpenChannelResponse{Result: "continue", MinDepth: &zero}, event.ContinueZeroConf())
+}
@@ -0,0 +1,219 @@
+package glightning
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type BulkPaymentStatus string
+
+const (
+	BulkPending  BulkPaymentStatus = "pending"
+	BulkPaying   BulkPaymentStatus = "paying"
+	BulkComplete BulkPaymentStatus = "complete"
+	BulkFailed   BulkPaymentStatus = "failed"
+	// Not attempted, as the batch's fee limit was reached first
+	BulkSkipped BulkPaymentStatus = "skipped"
+)
+
+// One invoice of a batch, and how paying it went
+type BulkPayment struct {
+	// The invoice's position in the batch
+	Index  int
+	Bolt11 string
+	Status BulkPaymentStatus
+	// Delivered to the payee, and sent including fees, in msat
+	AmountMsat uint64
+	SentMsat   uint64
+	FeeMsat    uint64
+	Preimage   string
+	Err        error
+	Started    time.Time
+	Finished   time.Time
+}
+
+type BulkPayReport struct {
+	// Every payment in the batch, in the order the invoices were given
+	Payments  []*BulkPayment
+	Succeeded int
+	Failed    int
+	Skipped   int
+	// Totals over the successful payments, in msat
+	AmountMsat uint64
+	SentMsat   uint64
+	FeeMsat    uint64
+	Elapsed    time.Duration
+}
+
+// The payments which failed or were skipped
+func (r *BulkPayReport) Failures() []*BulkPayment {
+	var failures []*BulkPayment
+	for _, p := range r.Payments {
+		if p.Status != BulkComplete {
+			failures = append(failures, p)
+		}
+	}
+	return failures
+}
+
+// BulkPayer pays a batch of invoices, e.g. a round of withdrawals,
+// with up to Concurrency payments in flight at once.
+//
+//	payer := glightning.NewBulkPayer(ln)
+//	payer.Options = &glightning.PayRequest{MaxFee: "5000msat"}
+//	report := payer.Pay(invoices)
+//	for _, failed := range report.Failures() {
+//		...
+//	}
+//
+// A failed payment doesn't stop the others; each payment's outcome
+// is in the report.
+type BulkPayer struct {
+	// Payments in flight at once; defaults to 4
+	Concurrency int
+	// Settings for each pay call, e.g. MaxFee or RetryFor. Its Bolt11
+	// is replaced with each invoice's.
+	Options *PayRequest
+	// Once the batch has paid this much in fees, in msat, payments not
+	// yet started are skipped. Zero means no limit.
+	MaxTotalFeeMsat uint64
+	// Called as each payment concludes, from the goroutine which paid it
+	OnPayment func(*BulkPayment)
+
+	client LightningClient
+
+	mu       sync.Mutex
+	payments []*BulkPayment
+}
+
+func NewBulkPayer(client LightningClient) *BulkPayer {
+	return &BulkPayer{
+		Concurrency: 4,
+		client:      client,
+	}
+}
+
+// Pay every one of {bolt11s}, returning once all have concluded
+func (b *BulkPayer) Pay(bolt11s []string) *BulkPayReport {
+	start := time.Now()
+	payments := make([]*BulkPayment, len(bolt11s))
+	for i, bolt11 := range bolt11s {
+		payments[i] = &BulkPayment{
+			Index:  i,
+			Bolt11: bolt11,
+			Status: BulkPending,
+		}
+	}
+	b.mu.Lock()
+	b.payments = payments
+	b.mu.Unlock()
+
+	concurrency := b.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	queue := make(chan *BulkPayment)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency && i < len(payments); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for payment := range queue {
+				b.pay(payment)
+			}
+		}()
+	}
+	for _, payment := range payments {
+		queue <- payment
+	}
+	close(queue)
+	wg.Wait()
+
+	report := &BulkPayReport{
+		Payments: payments,
+		Elapsed:  time.Since(start),
+	}
+	for _, p := range payments {
+		switch p.Status {
+		case BulkComplete:
+			report.Succeeded++
+			report.AmountMsat += p.AmountMsat
+			report.SentMsat += p.SentMsat
+			report.FeeMsat += p.FeeMsat
+		case BulkSkipped:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+	}
+	return report
+}
+
+func (b *BulkPayer) pay(payment *BulkPayment) {
+	b.mu.Lock()
+	if b.MaxTotalFeeMsat != 0 && b.feesPaid() >= b.MaxTotalFeeMsat {
+		payment.Status = BulkSkipped
+		payment.Err = fmt.Errorf("Fee limit of %dmsat reached", b.MaxTotalFeeMsat)
+		b.mu.Unlock()
+		b.concluded(payment)
+		return
+	}
+	payment.Status = BulkPaying
+	payment.Started = time.Now()
+	b.mu.Unlock()
+
+	req := &PayRequest{}
+	if b.Options != nil {
+		*req = *b.Options
+	}
+	req.Bolt11 = payment.Bolt11
+	result, err := b.client.Pay(req)
+
+	b.mu.Lock()
+	payment.Finished = time.Now()
+	if err != nil {
+		payment.Status = BulkFailed
+		payment.Err = err
+	} else {
+		payment.Status = BulkComplete
+		payment.AmountMsat = msatOr(result.AmountMilliSatoshi, result.AmountMilliSatoshiRaw)
+		payment.SentMsat = msatOr(result.MilliSatoshiSent, result.MilliSatoshiSentRaw)
+		if payment.SentMsat > payment.AmountMsat {
+			payment.FeeMsat = payment.SentMsat - payment.AmountMsat
+		}
+		payment.Preimage = result.PaymentPreimage
+	}
+	b.mu.Unlock()
+	b.concluded(payment)
+}
+
+func (b *BulkPayer) concluded(payment *BulkPayment) {
+	if b.OnPayment != nil {
+		b.mu.Lock()
+		p := *payment
+		b.mu.Unlock()
+		b.OnPayment(&p)
+	}
+}
+
+// Must be called with mu held
+func (b *BulkPayer) feesPaid() uint64 {
+	var fees uint64
+	for _, p := range b.payments {
+		fees += p.FeeMsat
+	}
+	return fees
+}
+
+// A snapshot of the current batch's payments, for following its
+// progress from another goroutine while Pay runs
+func (b *BulkPayer) Status() []BulkPayment {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := make([]BulkPayment, len(b.payments))
+	for i, p := range b.payments {
+		status[i] = *p
+	}
+	return status
+}
@@ -0,0 +1,105 @@
+package glightning_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkPayer(t *testing.T) {
+	ln := mock.New()
+	var inflight, maxInflight int32
+	var mu sync.Mutex
+	var maxFees []string
+	ln.PayFunc = func(req *glightning.PayRequest) (*glightning.PaymentSuccess, error) {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInflight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInflight, max, n) {
+				break
+			}
+		}
+		mu.Lock()
+		maxFees = append(maxFees, req.MaxFee)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+
+		if req.Bolt11 == "lnbc-bad" {
+			return nil, fmt.Errorf("no route")
+		}
+		return &glightning.PaymentSuccess{
+			SendPayFields: glightning.SendPayFields{
+				Status:             "complete",
+				AmountMilliSatoshi: "1000msat",
+				MilliSatoshiSent:   "1002msat",
+				PaymentPreimage:    "pre-" + req.Bolt11,
+			},
+		}, nil
+	}
+
+	payer := glightning.NewBulkPayer(ln)
+	payer.Concurrency = 2
+	payer.Options = &glightning.PayRequest{MaxFee: "10msat"}
+	var concluded int32
+	payer.OnPayment = func(p *glightning.BulkPayment) {
+		atomic.AddInt32(&concluded, 1)
+	}
+	report := payer.Pay([]string{"lnbc-a", "lnbc-bad", "lnbc-b", "lnbc-c", "lnbc-d"})
+
+	assert.Equal(t, int32(2), maxInflight)
+	assert.Equal(t, int32(5), concluded)
+	assert.Equal(t, []string{"10msat", "10msat", "10msat", "10msat", "10msat"}, maxFees)
+	assert.Equal(t, 4, report.Succeeded)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, uint64(4000), report.AmountMsat)
+	assert.Equal(t, uint64(4008), report.SentMsat)
+	assert.Equal(t, uint64(8), report.FeeMsat)
+
+	assert.Len(t, report.Payments, 5)
+	for i, p := range report.Payments {
+		assert.Equal(t, i, p.Index)
+	}
+	assert.Equal(t, "pre-lnbc-a", report.Payments[0].Preimage)
+	assert.Equal(t, uint64(2), report.Payments[0].FeeMsat)
+	failures := report.Failures()
+	if assert.Len(t, failures, 1) {
+		assert.Equal(t, "lnbc-bad", failures[0].Bolt11)
+		assert.Equal(t, glightning.BulkFailed, failures[0].Status)
+		assert.EqualError(t, failures[0].Err, "no route")
+	}
+
+	status := payer.Status()
+	assert.Len(t, status, 5)
+	assert.Equal(t, glightning.BulkComplete, status[4].Status)
+}
+
+func TestBulkPayerFeeLimit(t *testing.T) {
+	ln := mock.New()
+	ln.PayFunc = func(req *glightning.PayRequest) (*glightning.PaymentSuccess, error) {
+		return &glightning.PaymentSuccess{
+			SendPayFields: glightning.SendPayFields{
+				Status:             "complete",
+				AmountMilliSatoshi: "1000msat",
+				MilliSatoshiSent:   "1100msat",
+			},
+		}, nil
+	}
+
+	payer := glightning.NewBulkPayer(ln)
+	payer.Concurrency = 1
+	payer.MaxTotalFeeMsat = 200
+	report := payer.Pay([]string{"lnbc-a", "lnbc-b", "lnbc-c"})
+
+	assert.Equal(t, 2, report.Succeeded)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, uint64(200), report.FeeMsat)
+	assert.Equal(t, glightning.BulkSkipped, report.Payments[2].Status)
+	assert.EqualError(t, report.Payments[2].Err, "Fee limit of 200msat reached")
+}
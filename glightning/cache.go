@@ -0,0 +1,149 @@
+package glightning
+
+import (
+	"sync"
+	"time"
+)
+
+// Methods CachingClient can cache, by RPC name
+const (
+	CacheGetInfo      = "getinfo"
+	CacheListNodes    = "listnodes"
+	CacheListChannels = "listchannels"
+	CacheListPeers    = "listpeers"
+	CacheListFunds    = "listfunds"
+)
+
+// CachingClient wraps a LightningClient, caching the results of
+// read-only calls which take no arguments (getinfo, listnodes,
+// listchannels, listpeers and listfunds) for a time. Every other
+// call goes straight through.
+//
+// Cached results are shared between callers; don't modify them.
+// Errors aren't cached.
+type CachingClient struct {
+	LightningClient
+
+	mu      sync.Mutex
+	ttls    map[string]time.Duration
+	entries map[string]*cacheEntry
+	// bumped each time a method's results are dropped, so fetches
+	// started before then don't store stale results
+	generations map[string]uint64
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// Cache each of the cacheable methods for {ttl}. Use SetTTL to
+// change it per method.
+func NewCachingClient(client LightningClient, ttl time.Duration) *CachingClient {
+	c := &CachingClient{
+		LightningClient: client,
+		ttls:            make(map[string]time.Duration),
+		entries:         make(map[string]*cacheEntry),
+		generations:     make(map[string]uint64),
+	}
+	for _, method := range []string{CacheGetInfo, CacheListNodes, CacheListChannels, CacheListPeers, CacheListFunds} {
+		c.ttls[method] = ttl
+	}
+	return c
+}
+
+// Cache results of {method} (e.g. CacheListChannels) for {ttl}. A zero
+// {ttl} turns caching off for the method.
+func (c *CachingClient) SetTTL(method string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttls[method] = ttl
+	c.drop(method)
+}
+
+// Drop the cached results of the given {methods}, or of every
+// method if none are given, so the next call refetches them.
+func (c *CachingClient) Invalidate(methods ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(methods) == 0 {
+		for method := range c.ttls {
+			c.drop(method)
+		}
+		return
+	}
+	for _, method := range methods {
+		c.drop(method)
+	}
+}
+
+// Callers must hold c.mu
+func (c *CachingClient) drop(method string) {
+	delete(c.entries, method)
+	c.generations[method]++
+}
+
+func (c *CachingClient) cached(method string, fetch func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	ttl := c.ttls[method]
+	generation := c.generations[method]
+	if entry, ok := c.entries[method]; ok && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.value, nil
+	}
+	c.mu.Unlock()
+
+	value, err := fetch()
+	if err != nil || ttl <= 0 {
+		return value, err
+	}
+
+	c.mu.Lock()
+	if c.generations[method] == generation {
+		c.entries[method] = &cacheEntry{value: value, expires: time.Now().Add(ttl)}
+	}
+	c.mu.Unlock()
+	return value, nil
+}
+
+func (c *CachingClient) GetInfo() (*NodeInfo, error) {
+	value, err := c.cached(CacheGetInfo, func() (interface{}, error) {
+		return c.LightningClient.GetInfo()
+	})
+	info, _ := value.(*NodeInfo)
+	return info, err
+}
+
+func (c *CachingClient) ListNodes() ([]*Node, error) {
+	value, err := c.cached(CacheListNodes, func() (interface{}, error) {
+		return c.LightningClient.ListNodes()
+	})
+	nodes, _ := value.([]*Node)
+	return nodes, err
+}
+
+func (c *CachingClient) ListChannels() ([]*Channel, error) {
+	value, err := c.cached(CacheListChannels, func() (interface{}, error) {
+		return c.LightningClient.ListChannels()
+	})
+	channels, _ := value.([]*Channel)
+	return channels, err
+}
+
+func (c *CachingClient) ListPeers() ([]*Peer, error) {
+	value, err := c.cached(CacheListPeers, func() (interface{}, error) {
+		return c.LightningClient.ListPeers()
+	})
+	peers, _ := value.([]*Peer)
+	return peers, err
+}
+
+func (c *CachingClient) ListFunds() (*FundsResult, error) {
+	value, err := c.cached(CacheListFunds, func() (interface{}, error) {
+		return c.LightningClient.ListFunds()
+	})
+	funds, _ := value.(*FundsResult)
+	return funds, err
+}
+
+var _ LightningClient = (*CachingClient)(nil)
@@ -0,0 +1,107 @@
+package glightning_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachingClient(t *testing.T) {
+	ln := mock.New()
+	ln.GetInfoFunc = func() (*glightning.NodeInfo, error) {
+		return &glightning.NodeInfo{Id: "02aa"}, nil
+	}
+	ln.ListChannelsFunc = func() ([]*glightning.Channel, error) {
+		return []*glightning.Channel{{ShortChannelId: "1x1x1"}}, nil
+	}
+	ln.PingFunc = func(peerId string) (*glightning.Pong, error) {
+		return &glightning.Pong{}, nil
+	}
+
+	var client glightning.LightningClient
+	cache := glightning.NewCachingClient(ln, time.Hour)
+	client = cache
+
+	for i := 0; i < 3; i++ {
+		info, err := client.GetInfo()
+		assert.NoError(t, err)
+		assert.Equal(t, "02aa", info.Id)
+		channels, err := client.ListChannels()
+		assert.NoError(t, err)
+		assert.Len(t, channels, 1)
+		client.Ping("02bb")
+	}
+	assert.Equal(t, 1, ln.CallCount("GetInfo"))
+	assert.Equal(t, 1, ln.CallCount("ListChannels"))
+	// other calls aren't cached
+	assert.Equal(t, 3, ln.CallCount("Ping"))
+
+	cache.Invalidate(glightning.CacheGetInfo)
+	client.GetInfo()
+	client.ListChannels()
+	assert.Equal(t, 2, ln.CallCount("GetInfo"))
+	assert.Equal(t, 1, ln.CallCount("ListChannels"))
+
+	cache.Invalidate()
+	client.ListChannels()
+	assert.Equal(t, 2, ln.CallCount("ListChannels"))
+
+	// turned off
+	cache.SetTTL(glightning.CacheListChannels, 0)
+	client.ListChannels()
+	client.ListChannels()
+	assert.Equal(t, 4, ln.CallCount("ListChannels"))
+}
+
+func TestCachingClientExpiry(t *testing.T) {
+	ln := mock.New()
+	fail := true
+	ln.ListNodesFunc = func() ([]*glightning.Node, error) {
+		if fail {
+			return nil, errors.New("boom")
+		}
+		return []*glightning.Node{{Id: "02aa"}}, nil
+	}
+
+	cache := glightning.NewCachingClient(ln, 20*time.Millisecond)
+	_, err := cache.ListNodes()
+	assert.Error(t, err)
+
+	// errors aren't cached
+	fail = false
+	nodes, err := cache.ListNodes()
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 1)
+	cache.ListNodes()
+	assert.Equal(t, 2, ln.CallCount("ListNodes"))
+
+	time.Sleep(30 * time.Millisecond)
+	cache.ListNodes()
+	assert.Equal(t, 3, ln.CallCount("ListNodes"))
+}
+
+func TestCachingClientInvalidateDuringFetch(t *testing.T) {
+	ln := mock.New()
+	cache := glightning.NewCachingClient(ln, time.Hour)
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		// a peer connects while listpeers is in flight
+		if ln.CallCount("ListPeers") == 1 {
+			cache.Invalidate(glightning.CacheListPeers)
+			return nil, nil
+		}
+		return []*glightning.Peer{{Id: "02aa"}}, nil
+	}
+
+	peers, err := cache.ListPeers()
+	assert.NoError(t, err)
+	assert.Len(t, peers, 0)
+	// the stale result wasn't stored
+	peers, _ = cache.ListPeers()
+	assert.Len(t, peers, 1)
+	cache.ListPeers()
+	assert.Equal(t, 2, ln.CallCount("ListPeers"))
+}
@@ -0,0 +1,315 @@
+package glightning
+
+import (
+	"fmt"
+	"sync"
+)
+
+type ChainAlertKind string
+
+const (
+	// A channel went to chain without us asking for it: the peer
+	// broadcast its commitment, or lightningd dropped to chain
+	// after a protocol error
+	AlertUnilateralClose ChainAlertKind = "unilateral_close"
+	// An HTLC is within HtlcDeadline blocks of its expiry
+	AlertHtlcDeadline ChainAlertKind = "htlc_deadline"
+	// A wallet output which appeared while channels were being
+	// resolved on chain has SweepConfirmations confirmations
+	AlertSweepConfirmed ChainAlertKind = "sweep_confirmed"
+)
+
+type ChainAlert struct {
+	Kind ChainAlertKind
+	// The block height the alert was raised at; 0 for closes seen
+	// before the first block_added
+	Height    uint32
+	PeerId    string
+	ChannelId string
+	// For AlertUnilateralClose
+	Change *ChannelStateChanged
+	// For AlertHtlcDeadline
+	Htlc *Htlc
+	// Blocks left until the HTLC expires
+	BlocksLeft uint32
+	// For AlertSweepConfirmed
+	Output        *FundOutput
+	Confirmations uint32
+}
+
+func (a *ChainAlert) String() string {
+	switch a.Kind {
+	case AlertUnilateralClose:
+		return fmt.Sprintf("channel %s with %s closed unilaterally (%s, cause %s)", a.ChannelId, a.PeerId, a.Change.NewState, a.Change.Cause)
+	case AlertHtlcDeadline:
+		return fmt.Sprintf("%s htlc %d on channel %s expires in %d blocks", a.Htlc.Direction, a.Htlc.Id, a.ChannelId, a.BlocksLeft)
+	case AlertSweepConfirmed:
+		return fmt.Sprintf("sweep %s:%d confirmed %d times", a.Output.TxId, a.Output.Output, a.Confirmations)
+	}
+	return string(a.Kind)
+}
+
+// States a channel is in once its funding output has been spent, or
+// is about to be by us
+var onchainStates = map[string]bool{
+	"AWAITING_UNILATERAL": true,
+	"FUNDING_SPEND_SEEN":  true,
+	"ONCHAIN":             true,
+}
+
+// ChainWatch is a lightweight watch service for node operators. It
+// follows a plugin's channel_state_changed and block_added
+// notifications and raises alerts for unilateral closes nobody asked
+// for, HTLCs nearing their expiry, and sweeps of closed channels'
+// funds confirming.
+//
+//	events := glightning.NewEvents(plugin,
+//		glightning.EventChannelStateChanged, glightning.EventBlockAdded)
+//	watch := glightning.NewChainWatch(ln, events)
+//	watch.OnAlert = func(alert *glightning.ChainAlert) { log.Print(alert) }
+//	err := watch.Start()
+//
+// Closes made through the client can be marked with ExpectClose so
+// they aren't alerted on.
+type ChainWatch struct {
+	// Blocks before an HTLC's expiry to raise AlertHtlcDeadline.
+	// Defaults to 6.
+	HtlcDeadline uint32
+	// Confirmations at which to report a sweep. Defaults to 3.
+	SweepConfirmations uint32
+	// Called with each alert, from the watch's goroutine
+	OnAlert func(*ChainAlert)
+	// Called with any error from lightningd while handling a block
+	OnError func(error)
+
+	client LightningClient
+	events *Events
+	sub    *EventSubscription
+	exited chan struct{}
+
+	mu       sync.Mutex
+	height   uint32
+	expected map[string]bool
+	// channels being resolved on chain, by channel id or, for those
+	// found by Start, short channel id
+	onchain map[string]bool
+	// outputs already in the wallet, or already reported
+	outputs map[string]bool
+	// HTLCs already alerted on
+	htlcs map[string]bool
+}
+
+// A watch over {events}, which must carry EventChannelStateChanged
+// and EventBlockAdded
+func NewChainWatch(client LightningClient, events *Events) *ChainWatch {
+	return &ChainWatch{
+		HtlcDeadline:       6,
+		SweepConfirmations: 3,
+		client:             client,
+		events:             events,
+		expected:           make(map[string]bool),
+		onchain:            make(map[string]bool),
+		outputs:            make(map[string]bool),
+		htlcs:              make(map[string]bool),
+	}
+}
+
+// Note the wallet's current outputs, so only new ones are reported
+// as sweeps, and start watching
+func (w *ChainWatch) Start() error {
+	funds, err := w.client.ListFunds()
+	if err != nil {
+		return err
+	}
+	for _, output := range funds.Outputs {
+		w.outputs[outputKey(output)] = true
+	}
+	for _, channel := range funds.Channels {
+		if onchainStates[channel.State] {
+			w.onchain[channel.ShortChannelId] = true
+		}
+	}
+
+	w.sub = w.events.Subscribe(16, OnlyKinds(EventChannelStateChanged, EventBlockAdded))
+	w.exited = make(chan struct{})
+	go w.run()
+	return nil
+}
+
+// Stop watching, waiting for the event being handled to finish
+func (w *ChainWatch) Stop() {
+	if w.sub == nil {
+		return
+	}
+	w.sub.Close()
+	<-w.exited
+}
+
+// Mark the channel {id} (a channel id or short channel id) as being
+// closed on purpose, so its going to chain isn't alerted on
+func (w *ChainWatch) ExpectClose(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expected[id] = true
+}
+
+// The height of the last block seen
+func (w *ChainWatch) Height() uint32 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.height
+}
+
+func (w *ChainWatch) run() {
+	defer close(w.exited)
+	for event := range w.sub.C {
+		switch payload := event.Payload.(type) {
+		case *ChannelStateChanged:
+			w.stateChanged(payload)
+		case *BlockAdded:
+			w.blockAdded(payload)
+		}
+	}
+}
+
+func (w *ChainWatch) stateChanged(change *ChannelStateChanged) {
+	scid := change.ShortChannelId.String()
+	if change.NewState == "CLOSED" {
+		w.mu.Lock()
+		delete(w.onchain, change.ChannelId)
+		delete(w.onchain, scid)
+		w.mu.Unlock()
+		return
+	}
+	if !onchainStates[change.NewState] {
+		return
+	}
+	w.mu.Lock()
+	alreadyOnchain := w.onchain[change.ChannelId] || w.onchain[scid]
+	w.onchain[change.ChannelId] = true
+	expected := w.expected[change.ChannelId] || w.expected[scid]
+	height := w.height
+	w.mu.Unlock()
+
+	// a mutual close, or one we asked for, is no surprise; nor is a
+	// channel moving on through the onchain states
+	if alreadyOnchain || expected || change.Cause == "user" {
+		return
+	}
+	if change.OldState == "CLOSINGD_COMPLETE" || change.OldState == "CLOSINGD_SIGEXCHANGE" {
+		return
+	}
+	w.alert(&ChainAlert{
+		Kind:      AlertUnilateralClose,
+		Height:    height,
+		PeerId:    change.PeerId,
+		ChannelId: change.ChannelId,
+		Change:    change,
+	})
+}
+
+func (w *ChainWatch) blockAdded(block *BlockAdded) {
+	w.mu.Lock()
+	w.height = block.Height
+	w.mu.Unlock()
+
+	if err := w.checkHtlcs(block.Height); err != nil {
+		w.error(err)
+	}
+	if err := w.checkSweeps(block.Height); err != nil {
+		w.error(err)
+	}
+}
+
+func (w *ChainWatch) checkHtlcs(height uint32) error {
+	peers, err := w.client.ListPeers()
+	if err != nil {
+		return err
+	}
+	for _, peer := range peers {
+		for _, channel := range peer.Channels {
+			for _, htlc := range channel.Htlcs {
+				if htlc.Expiry > uint64(height)+uint64(w.HtlcDeadline) {
+					continue
+				}
+				key := fmt.Sprintf("%s/%s/%d", channel.ChannelId, htlc.Direction, htlc.Id)
+				w.mu.Lock()
+				seen := w.htlcs[key]
+				w.htlcs[key] = true
+				w.mu.Unlock()
+				if seen {
+					continue
+				}
+				var left uint32
+				if htlc.Expiry > uint64(height) {
+					left = uint32(htlc.Expiry - uint64(height))
+				}
+				w.alert(&ChainAlert{
+					Kind:       AlertHtlcDeadline,
+					Height:     height,
+					PeerId:     peer.Id,
+					ChannelId:  channel.ChannelId,
+					Htlc:       htlc,
+					BlocksLeft: left,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// Sweeps aren't labelled as such in listfunds, so any output which
+// appears while a channel is being resolved on chain is taken to be
+// one
+func (w *ChainWatch) checkSweeps(height uint32) error {
+	w.mu.Lock()
+	pending := len(w.onchain) > 0
+	w.mu.Unlock()
+	if !pending {
+		return nil
+	}
+
+	funds, err := w.client.ListFunds()
+	if err != nil {
+		return err
+	}
+	for _, output := range funds.Outputs {
+		key := outputKey(output)
+		w.mu.Lock()
+		seen := w.outputs[key]
+		w.mu.Unlock()
+		if seen || output.Status != "confirmed" || output.Blockheight <= 0 || uint32(output.Blockheight) > height {
+			continue
+		}
+		confirmations := height - uint32(output.Blockheight) + 1
+		if confirmations < w.SweepConfirmations {
+			continue
+		}
+		w.mu.Lock()
+		w.outputs[key] = true
+		w.mu.Unlock()
+		w.alert(&ChainAlert{
+			Kind:          AlertSweepConfirmed,
+			Height:        height,
+			Output:        output,
+			Confirmations: confirmations,
+		})
+	}
+	return nil
+}
+
+func (w *ChainWatch) alert(alert *ChainAlert) {
+	if w.OnAlert != nil {
+		w.OnAlert(alert)
+	}
+}
+
+func (w *ChainWatch) error(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
+
+func outputKey(output *FundOutput) string {
+	return fmt.Sprintf("%s:%d", output.TxId, output.Output)
+}
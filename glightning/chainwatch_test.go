@@ -0,0 +1,112 @@
+package glightning_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func nextAlert(t *testing.T, alerts chan *glightning.ChainAlert) *glightning.ChainAlert {
+	select {
+	case alert := <-alerts:
+		return alert
+	case <-time.After(time.Second):
+		t.Fatal("no alert")
+		return nil
+	}
+}
+
+func TestChainWatch(t *testing.T) {
+	ln := mock.New()
+	sweeping := false
+	ln.ListFundsFunc = func() (*glightning.FundsResult, error) {
+		outputs := []*glightning.FundOutput{
+			{TxId: "aa", Output: 0, Status: "confirmed", Blockheight: 50},
+		}
+		if sweeping {
+			outputs = append(outputs, &glightning.FundOutput{TxId: "bb", Output: 1, Status: "confirmed", Blockheight: 99})
+		}
+		return &glightning.FundsResult{Outputs: outputs}, nil
+	}
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return []*glightning.Peer{{Id: closePeer, Channels: []*glightning.PeerChannel{{
+			ChannelId: "a3b1",
+			Htlcs: []*glightning.Htlc{
+				{Direction: "out", Id: 7, Expiry: 104},
+				{Direction: "in", Id: 8, Expiry: 200},
+			},
+		}}}}, nil
+	}
+
+	events := glightning.NewEvents(glightning.NewPlugin(nullInitFunc))
+	watch := glightning.NewChainWatch(ln, events)
+	alerts := make(chan *glightning.ChainAlert, 8)
+	watch.OnAlert = func(alert *glightning.ChainAlert) { alerts <- alert }
+	assert.NoError(t, watch.Start())
+	defer watch.Stop()
+
+	state := func(channelId, old, new, cause string) {
+		events.Publish(glightning.EventChannelStateChanged, &glightning.ChannelStateChanged{
+			PeerId: closePeer, ChannelId: channelId, OldState: old, NewState: new, Cause: cause,
+		})
+	}
+	state("a3b1", "CHANNELD_NORMAL", "AWAITING_UNILATERAL", "protocol")
+	alert := nextAlert(t, alerts)
+	assert.Equal(t, glightning.AlertUnilateralClose, alert.Kind)
+	assert.Equal(t, "a3b1", alert.ChannelId)
+	assert.Equal(t, "channel a3b1 with "+closePeer+" closed unilaterally (AWAITING_UNILATERAL, cause protocol)", alert.String())
+
+	// none of these are alerted on
+	state("a3b1", "AWAITING_UNILATERAL", "FUNDING_SPEND_SEEN", "protocol")
+	watch.ExpectClose("b2")
+	state("b2", "CHANNELD_NORMAL", "AWAITING_UNILATERAL", "protocol")
+	state("c3", "CLOSINGD_COMPLETE", "FUNDING_SPEND_SEEN", "onchain")
+	state("d4", "CHANNELD_NORMAL", "AWAITING_UNILATERAL", "user")
+
+	sweeping = true
+	events.Publish(glightning.EventBlockAdded, &glightning.BlockAdded{Height: 100})
+	alert = nextAlert(t, alerts)
+	assert.Equal(t, glightning.AlertHtlcDeadline, alert.Kind)
+	assert.Equal(t, uint64(7), alert.Htlc.Id)
+	assert.Equal(t, uint32(4), alert.BlocksLeft)
+	assert.Equal(t, uint32(100), watch.Height())
+
+	// the htlc isn't alerted on twice, and the sweep now has three
+	// confirmations
+	events.Publish(glightning.EventBlockAdded, &glightning.BlockAdded{Height: 101})
+	alert = nextAlert(t, alerts)
+	assert.Equal(t, glightning.AlertSweepConfirmed, alert.Kind)
+	assert.Equal(t, "bb", alert.Output.TxId)
+	assert.Equal(t, uint32(3), alert.Confirmations)
+	assert.Equal(t, "sweep bb:1 confirmed 3 times", alert.String())
+
+	events.Publish(glightning.EventBlockAdded, &glightning.BlockAdded{Height: 102})
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, alerts, 0)
+}
+
+func TestChainWatchErrors(t *testing.T) {
+	ln := mock.New()
+	ln.ListFundsFunc = func() (*glightning.FundsResult, error) {
+		return &glightning.FundsResult{Channels: []*glightning.FundingChannel{
+			{ShortChannelId: "103x1x0", State: "ONCHAIN"},
+		}}, nil
+	}
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return nil, errors.New("lightningd is gone")
+	}
+	events := glightning.NewEvents(glightning.NewPlugin(nullInitFunc))
+	watch := glightning.NewChainWatch(ln, events)
+	errs := make(chan error, 2)
+	watch.OnError = func(err error) { errs <- err }
+	assert.NoError(t, watch.Start())
+
+	events.Publish(glightning.EventBlockAdded, &glightning.BlockAdded{Height: 100})
+	assert.EqualError(t, <-errs, "lightningd is gone")
+	watch.Stop()
+	assert.Equal(t, 0, events.Len())
+}
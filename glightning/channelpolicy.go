@@ -0,0 +1,271 @@
+package glightning
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A peer's proposal to open a channel to us, from either the
+// openchannel or the openchannel2 hook
+type ChannelProposal struct {
+	PeerId string
+	// What the peer is putting into the channel, in satoshi
+	FundingSat uint64
+	// Pushed to us on opening, in msat
+	PushMsat uint64
+	// Opened with the openchannel2 hook
+	DualFunded  bool
+	ChannelType *ChannelType
+	// The hook's event: *OpenChannelEvent or *OpenChannel2Event
+	Event interface{}
+}
+
+// What a ChannelPolicy made of a ChannelProposal
+type ChannelDecision struct {
+	Proposal *ChannelProposal
+	Accept   bool
+	// The rule which rejected the channel: "denied", "min_size",
+	// "max_size", "max_channels", "unconfirmed" or "error"; or
+	// "override" if the Override callback changed the decision
+	Rule string
+	// Why the channel was rejected; sent to the peer
+	Message string
+	// Where our funds go when the channel is closed, if accepted
+	CloseTo string
+}
+
+type ChannelPolicyStats struct {
+	Accepted uint64
+	Rejected uint64
+	// Rejections by rule
+	ByRule map[string]uint64
+}
+
+// ChannelPolicy decides which channels peers may open to us, on the
+// openchannel and openchannel2 hooks, from a set of limits.
+//
+//	policy := glightning.NewChannelPolicy(ln)
+//	policy.MinChannelSat = 1000000
+//	policy.MaxChannelsPerPeer = 2
+//	policy.Deny("02befaace6e8970aaca34eafe85f30f988e374628ec279d94e7eca8b574b738eb4")
+//	err := policy.Register(plugin)
+//
+// The limits are checked in the order of the fields below. Each
+// decision is logged through the plugin it's registered with, and
+// can be changed by Override. Dual-funded channels are accepted
+// without any contribution of ours.
+type ChannelPolicy struct {
+	// Reject channels smaller or larger than these, in satoshi. Zero
+	// means no limit.
+	MinChannelSat uint64
+	MaxChannelSat uint64
+	// Reject channels from peers which already have this many open or
+	// opening with us. Zero means no limit.
+	MaxChannelsPerPeer int
+	// Reject zero-conf channels, whose funding transaction the peer
+	// wants used before it confirms
+	RequireConfirmed bool
+	// Called with each decision before it's returned to lightningd;
+	// return a different decision to override it, or nil to keep it
+	Override func(decision *ChannelDecision) *ChannelDecision
+	// Called with each final decision
+	OnDecision func(decision *ChannelDecision)
+
+	client LightningClient
+	plugin *Plugin
+
+	mu     sync.RWMutex
+	denied map[string]bool
+	stats  ChannelPolicyStats
+}
+
+func NewChannelPolicy(client LightningClient) *ChannelPolicy {
+	return &ChannelPolicy{
+		client: client,
+		denied: make(map[string]bool),
+		stats:  ChannelPolicyStats{ByRule: make(map[string]uint64)},
+	}
+}
+
+// Reject all channels from {nodeIds}
+func (c *ChannelPolicy) Deny(nodeIds ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range nodeIds {
+		c.denied[id] = true
+	}
+}
+
+// Take {nodeIds} off the deny list
+func (c *ChannelPolicy) Allow(nodeIds ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range nodeIds {
+		delete(c.denied, id)
+	}
+}
+
+func (c *ChannelPolicy) isDenied(nodeId string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.denied[nodeId]
+}
+
+// Register the policy as {plugin}'s openchannel and openchannel2
+// hooks, and log its decisions there. Like RegisterHooks, this must
+// be called before the plugin is started.
+func (c *ChannelPolicy) Register(plugin *Plugin) error {
+	c.plugin = plugin
+	return plugin.RegisterHooks(&Hooks{
+		OpenChannel:  c.OpenChannel,
+		OpenChannel2: c.OpenChannel2,
+	})
+}
+
+// The openchannel hook, for use with RegisterHooks alongside other
+// hooks
+func (c *ChannelPolicy) OpenChannel(event *OpenChannelEvent) (*OpenChannelResponse, error) {
+	oc := &event.OpenChannel
+	proposal := &ChannelProposal{
+		PeerId:      oc.PeerId,
+		FundingSat:  msatOr(oc.FundingSatoshis, 0) / 1000,
+		PushMsat:    msatOr(oc.PushMilliSatoshis, 0),
+		ChannelType: oc.ChannelType,
+		Event:       event,
+	}
+	decision := c.Decide(proposal)
+	if !decision.Accept {
+		return event.Reject(decision.Message), nil
+	}
+	if decision.CloseTo != "" {
+		return event.ContinueWithCloseTo(decision.CloseTo), nil
+	}
+	return event.Continue(), nil
+}
+
+// The openchannel2 hook, for use with RegisterHooks alongside other
+// hooks
+func (c *ChannelPolicy) OpenChannel2(event *OpenChannel2Event) (*OpenChannel2Response, error) {
+	oc := &event.OpenChannel2
+	proposal := &ChannelProposal{
+		PeerId:      oc.PeerId,
+		FundingSat:  msatOr(oc.TheirFundingMilliSatoshis, 0) / 1000,
+		DualFunded:  true,
+		ChannelType: oc.ChannelType,
+		Event:       event,
+	}
+	decision := c.Decide(proposal)
+	if !decision.Accept {
+		return event.Reject(decision.Message), nil
+	}
+	response := event.Continue()
+	response.CloseToAddress = decision.CloseTo
+	return response, nil
+}
+
+// Decide on {proposal}, as the hooks do
+func (c *ChannelPolicy) Decide(proposal *ChannelProposal) *ChannelDecision {
+	decision := c.check(proposal)
+	if c.Override != nil {
+		if override := c.Override(decision); override != nil && override != decision {
+			override.Proposal = proposal
+			if override.Rule == "" {
+				override.Rule = "override"
+			}
+			decision = override
+		}
+	}
+
+	c.mu.Lock()
+	if decision.Accept {
+		c.stats.Accepted++
+	} else {
+		c.stats.Rejected++
+		c.stats.ByRule[decision.Rule]++
+	}
+	c.mu.Unlock()
+
+	if c.plugin != nil {
+		c.plugin.Log(decision.String(), Info)
+	}
+	if c.OnDecision != nil {
+		c.OnDecision(decision)
+	}
+	return decision
+}
+
+func (c *ChannelPolicy) check(p *ChannelProposal) *ChannelDecision {
+	reject := func(rule, format string, args ...interface{}) *ChannelDecision {
+		return &ChannelDecision{Proposal: p, Rule: rule, Message: fmt.Sprintf(format, args...)}
+	}
+	if c.isDenied(p.PeerId) {
+		return reject("denied", "Not accepting channels from this node")
+	}
+	if c.MinChannelSat != 0 && p.FundingSat < c.MinChannelSat {
+		return reject("min_size", "Channel too small: %dsat is less than the minimum of %dsat", p.FundingSat, c.MinChannelSat)
+	}
+	if c.MaxChannelSat != 0 && p.FundingSat > c.MaxChannelSat {
+		return reject("max_size", "Channel too large: %dsat is more than the maximum of %dsat", p.FundingSat, c.MaxChannelSat)
+	}
+	if c.MaxChannelsPerPeer != 0 {
+		count, err := c.channelsWith(p.PeerId)
+		if err != nil {
+			// better to turn a channel away than to accept one the
+			// policy would have rejected
+			return reject("error", "Unable to check existing channels")
+		}
+		if count >= c.MaxChannelsPerPeer {
+			return reject("max_channels", "Too many channels: already have %d", count)
+		}
+	}
+	if c.RequireConfirmed && p.ChannelType.Has(FeatureZeroConf) {
+		return reject("unconfirmed", "Not accepting zero-conf channels")
+	}
+	return &ChannelDecision{Proposal: p, Accept: true}
+}
+
+// The channels open or opening with {peerId}; the one being proposed
+// isn't listed yet
+func (c *ChannelPolicy) channelsWith(peerId string) (int, error) {
+	peers, err := c.client.ListPeers()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, peer := range peers {
+		if peer.Id != peerId {
+			continue
+		}
+		for _, ch := range peer.Channels {
+			switch ch.State {
+			case "ONCHAIN", "CLOSED", "FUNDING_SPEND_SEEN", "CLOSINGD_COMPLETE":
+			default:
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (c *ChannelPolicy) Stats() ChannelPolicyStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	stats := c.stats
+	stats.ByRule = make(map[string]uint64, len(c.stats.ByRule))
+	for rule, count := range c.stats.ByRule {
+		stats.ByRule[rule] = count
+	}
+	return stats
+}
+
+func (d *ChannelDecision) String() string {
+	p := d.Proposal
+	kind := "channel"
+	if p.DualFunded {
+		kind = "dual-funded channel"
+	}
+	if d.Accept {
+		return fmt.Sprintf("Accepted %dsat %s from %s", p.FundingSat, kind, p.PeerId)
+	}
+	return fmt.Sprintf("Rejected %dsat %s from %s (%s): %s", p.FundingSat, kind, p.PeerId, d.Rule, d.Message)
+}
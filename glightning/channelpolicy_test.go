@@ -0,0 +1,125 @@
+package glightning_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func proposeChannel(peer, funding string) *glightning.OpenChannelEvent {
+	return &glightning.OpenChannelEvent{
+		OpenChannel: glightning.OpenChannel{PeerId: peer, FundingSatoshis: funding, PushMilliSatoshis: "0msat"},
+	}
+}
+
+func TestChannelPolicy(t *testing.T) {
+	ln := mock.New()
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return []*glightning.Peer{{
+			Id: "02bb",
+			Channels: []*glightning.PeerChannel{
+				{State: "CHANNELD_NORMAL"},
+				{State: "ONCHAIN"},
+				{State: "CHANNELD_AWAITING_LOCKIN"},
+			},
+		}}, nil
+	}
+	policy := glightning.NewChannelPolicy(ln)
+	policy.MinChannelSat = 100000
+	policy.MaxChannelSat = 10000000
+	policy.MaxChannelsPerPeer = 2
+	policy.RequireConfirmed = true
+	policy.Deny("02dd", "02ee")
+	policy.Allow("02ee")
+	var decisions []string
+	policy.OnDecision = func(d *glightning.ChannelDecision) {
+		decisions = append(decisions, d.String())
+	}
+
+	decide := func(event *glightning.OpenChannelEvent) *glightning.OpenChannelResponse {
+		resp, err := policy.OpenChannel(event)
+		assert.NoError(t, err)
+		return resp
+	}
+	assert.Equal(t, &glightning.OpenChannelResponse{Result: "continue"}, decide(proposeChannel("02aa", "500000000msat")))
+	assert.Equal(t, &glightning.OpenChannelResponse{
+		Result:  "reject",
+		Message: "Channel too small: 50000sat is less than the minimum of 100000sat",
+	}, decide(proposeChannel("02aa", "50000sat")))
+	assert.Equal(t, "reject", string(decide(proposeChannel("02aa", "20000000sat")).Result))
+	assert.Equal(t, &glightning.OpenChannelResponse{
+		Result:  "reject",
+		Message: "Too many channels: already have 2",
+	}, decide(proposeChannel("02bb", "500000sat")))
+	assert.Equal(t, "reject", string(decide(proposeChannel("02dd", "500000sat")).Result))
+	assert.Equal(t, "continue", string(decide(proposeChannel("02ee", "500000sat")).Result))
+
+	zeroConf := proposeChannel("02aa", "500000sat")
+	zeroConf.OpenChannel.ChannelType = &glightning.ChannelType{Bits: []glightning.FeatureBit{12, 22, 50}}
+	assert.Equal(t, &glightning.OpenChannelResponse{
+		Result:  "reject",
+		Message: "Not accepting zero-conf channels",
+	}, decide(zeroConf))
+
+	assert.Equal(t, []string{
+		"Accepted 500000sat channel from 02aa",
+		"Rejected 50000sat channel from 02aa (min_size): Channel too small: 50000sat is less than the minimum of 100000sat",
+		"Rejected 20000000sat channel from 02aa (max_size): Channel too large: 20000000sat is more than the maximum of 10000000sat",
+		"Rejected 500000sat channel from 02bb (max_channels): Too many channels: already have 2",
+		"Rejected 500000sat channel from 02dd (denied): Not accepting channels from this node",
+		"Accepted 500000sat channel from 02ee",
+		"Rejected 500000sat channel from 02aa (unconfirmed): Not accepting zero-conf channels",
+	}, decisions)
+	assert.Equal(t, glightning.ChannelPolicyStats{
+		Accepted: 2,
+		Rejected: 5,
+		ByRule:   map[string]uint64{"min_size": 1, "max_size": 1, "max_channels": 1, "denied": 1, "unconfirmed": 1},
+	}, policy.Stats())
+
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return nil, errors.New("lightningd is busy")
+	}
+	assert.Equal(t, "reject", string(decide(proposeChannel("02aa", "500000sat")).Result))
+}
+
+func TestChannelPolicyOverride(t *testing.T) {
+	policy := glightning.NewChannelPolicy(mock.New())
+	policy.MinChannelSat = 1000000
+	policy.Override = func(d *glightning.ChannelDecision) *glightning.ChannelDecision {
+		// a trusted peer may open small channels, which close to cold storage
+		if d.Proposal.PeerId == "02aa" {
+			return &glightning.ChannelDecision{Accept: true, CloseTo: "bc1qcold"}
+		}
+		return nil
+	}
+
+	var event glightning.OpenChannel2Event
+	err := json.Unmarshal([]byte(`{"openchannel2": {
+		"id": "02aa",
+		"channel_id": "252d1b0a1e57895e84137f28cf19ab2c35847e284c112fefdecc7afeaa5c1de7",
+		"their_funding_msat": "100000000msat",
+		"require_confirmed_inputs": true,
+		"channel_type": {"bits": [12, 22], "names": ["static_remotekey/even", "anchors_zero_fee_htlc_tx/even"]}
+	}}`), &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, event.OpenChannel2.ChannelType.Has(glightning.FeatureAnchorsZeroFeeHtlc))
+
+	resp, err := policy.OpenChannel2(&event)
+	assert.NoError(t, err)
+	assert.Equal(t, &glightning.OpenChannel2Response{Result: "continue", CloseToAddress: "bc1qcold"}, resp)
+
+	event.OpenChannel2.PeerId = "02bb"
+	resp, _ = policy.OpenChannel2(&event)
+	assert.Equal(t, "reject", string(resp.Result))
+	assert.Equal(t, glightning.ChannelPolicyStats{
+		Accepted: 1,
+		Rejected: 1,
+		ByRule:   map[string]uint64{"min_size": 1},
+	}, policy.Stats())
+}
@@ -0,0 +1,101 @@
+package glightning
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+)
+
+// Offline verification of signmessage signatures, as checkmessage
+// does, for services which need to check a node signed something but
+// have no access to a node.
+
+const zbase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+
+// Encode {data} in z-base-32, as signmessage does
+func ZBase32Encode(data []byte) string {
+	var out strings.Builder
+	var acc uint
+	var bits uint
+	for _, b := range data {
+		acc = acc<<8 | uint(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out.WriteByte(zbase32Alphabet[(acc>>bits)&31])
+		}
+	}
+	if bits > 0 {
+		out.WriteByte(zbase32Alphabet[(acc<<(5-bits))&31])
+	}
+	return out.String()
+}
+
+// Decode z-base-32 {s}. Trailing bits which don't make up a whole
+// byte are dropped.
+func ZBase32Decode(s string) ([]byte, error) {
+	var out []byte
+	var acc uint
+	var bits uint
+	for i := 0; i < len(s); i++ {
+		v := strings.IndexByte(zbase32Alphabet, s[i])
+		if v < 0 {
+			return nil, fmt.Errorf("Invalid z-base-32 character %q", s[i])
+		}
+		acc = acc<<5 | uint(v)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+		}
+	}
+	return out, nil
+}
+
+// The hash signmessage signs: SHA256d of the prefixed message
+func signedMessageHash(message string) []byte {
+	first := sha256.Sum256([]byte("Lightning Signed Message:" + message))
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// Recover the public key, as compressed hex, which made signmessage
+// signature {zbase} of {message}. Any valid signature recovers some
+// key: check it's the one expected, or use VerifyMessageBy.
+//
+// Like checkmessage without a pubkey, this can't tell whether the key
+// belongs to a node; checkmessage only reports such a signature
+// verified if the key is of a node in its gossip.
+func RecoverMessageKey(message, zbase string) (string, error) {
+	sig, err := ZBase32Decode(zbase)
+	if err != nil {
+		return "", err
+	}
+	if len(sig) != 65 {
+		return "", fmt.Errorf("Signature is %d bytes, expected 65", len(sig))
+	}
+	// lightningd's recovery ids are offset by 31, for compressed keys,
+	recid := int(sig[0]) - 31
+	if recid < 0 || recid > 3 {
+		return "", fmt.Errorf("Invalid recovery id %d", sig[0])
+	}
+	// which makes it a compact signature, as btcec recovers from
+	key, _, err := ecdsa.RecoverCompact(sig, signedMessageHash(message))
+	if err != nil {
+		return "", fmt.Errorf("Invalid signature")
+	}
+	return hex.EncodeToString(key.SerializeCompressed()), nil
+}
+
+// Whether {zbase} is {pubkey}'s signmessage signature of {message},
+// as checkmessage with a pubkey
+func VerifyMessageBy(message, zbase, pubkey string) (bool, error) {
+	recovered, err := RecoverMessageKey(message, zbase)
+	if err != nil {
+		return false, err
+	}
+	return recovered == strings.ToLower(pubkey), nil
+}
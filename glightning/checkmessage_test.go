@@ -0,0 +1,74 @@
+package glightning_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+var signedMessages = []struct {
+	message, zbase, pubkey string
+}{
+	{
+		"hello world",
+		"ryyhxyd1hht4rnd1k7uquj4quunqr1nuba1cq9p3fcesm37449mxwwb6em4xooisur6jdxjst9yyzgto7k375wspt4fy5nd9zt9ptrj4",
+		"02286bda929ce4252df5bd5b1bbccb97a2ed690c51827812044a16597a3a7eb6f8",
+	},
+	{
+		"",
+		"dhtxfk588q5o9tg6r6km3fi1dkpbn361h9sgo6g9ajh7fxph8rb9oyqj5u4iqb3b65uoepw7xpbd5gsobwjft459xr6gw93trxcpngqd",
+		"028cd027db64a65f8fd7464493a3d992dc31b5eb97edb3edae2481890fa5770eb1",
+	},
+	{
+		"glightning ✓",
+		"dhe9cgqe7htjdffjb8huaacxjriuddggtak371isgt5f8hy9xoyjhyotkssenb7nqzkwxpssro4fzwifx93jp4cg8dspkw8wynawzmm1",
+		"033a8a21e2fe11e8c2080aaa74a7f90c2ed8f4f2d8cb4d43a27af4f430618efbd1",
+	},
+}
+
+func TestZBase32(t *testing.T) {
+	data := []byte{0xf0, 0xbf, 0xc7}
+	assert.Equal(t, "6n9hq", glightning.ZBase32Encode(data))
+	decoded, err := glightning.ZBase32Decode("6n9hq")
+	assert.NoError(t, err)
+	assert.Equal(t, data, decoded)
+
+	_, err = glightning.ZBase32Decode("0l")
+	assert.EqualError(t, err, `Invalid z-base-32 character '0'`)
+}
+
+func TestRecoverMessageKey(t *testing.T) {
+	for _, m := range signedMessages {
+		pubkey, err := glightning.RecoverMessageKey(m.message, m.zbase)
+		assert.NoError(t, err)
+		assert.Equal(t, m.pubkey, pubkey)
+
+		ok, err := glightning.VerifyMessageBy(m.message, m.zbase, m.pubkey)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	// another message recovers some other key
+	ok, err := glightning.VerifyMessageBy("hello world!", signedMessages[0].zbase, signedMessages[0].pubkey)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = glightning.RecoverMessageKey("hello world", "ryyhxyd1")
+	assert.EqualError(t, err, "Signature is 5 bytes, expected 65")
+
+	sig, _ := glightning.ZBase32Decode(signedMessages[0].zbase)
+	bad := append([]byte{27}, sig[1:]...)
+	_, err = glightning.RecoverMessageKey("hello world", glightning.ZBase32Encode(bad))
+	assert.EqualError(t, err, "Invalid recovery id 27")
+
+	// r of zero, and s of the curve order, are out of range
+	zeroR := append(append([]byte{sig[0]}, make([]byte, 32)...), sig[33:]...)
+	_, err = glightning.RecoverMessageKey("hello world", glightning.ZBase32Encode(zeroR))
+	assert.EqualError(t, err, "Invalid signature")
+	order, _ := hex.DecodeString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141")
+	bigS := append(append([]byte{sig[0]}, sig[1:33]...), order...)
+	_, err = glightning.RecoverMessageKey("hello world", glightning.ZBase32Encode(bigS))
+	assert.EqualError(t, err, "Invalid signature")
+}
@@ -0,0 +1,205 @@
+package glightning
+
+import (
+	"encoding/json"
+
+	"github.com/elementsproject/glightning/glightning/network"
+	"github.com/elementsproject/glightning/glightning/tlv"
+	"github.com/elementsproject/glightning/jrpc2"
+)
+
+// LightningClient is the set of RPC wrappers offered by Lightning.
+// Depend on it instead of *Lightning to be able to swap in a fake
+// (see the mock package) when testing code that talks to lightningd.
+type LightningClient interface {
+	IsUp() bool
+	Request(m jrpc2.Method, resp interface{}) error
+	RequestRaw(m jrpc2.Method, resp interface{}) (json.RawMessage, error)
+	Call(method string, params map[string]interface{}) (json.RawMessage, error)
+	CallInto(method string, params interface{}, result interface{}) error
+	ListConfigs() (map[string]interface{}, error)
+	GetConfig(config string) (interface{}, error)
+	GetPeer(peerId string) (*Peer, error)
+	GetPeerWithLogs(peerId string, level LogLevel) (*Peer, error)
+	ListPeersWithLogs(level LogLevel) ([]*Peer, error)
+	ListPeers() ([]*Peer, error)
+	GetNode(nodeId string) (*Node, error)
+	ListNodes() ([]*Node, error)
+	GetRouteSimple(peerId string, msats uint64, riskfactor float32) ([]RouteHop, error)
+	GetRoute(peerId string, msats uint64, riskfactor float32, cltv uint, fromId string, fuzzpercent float32, exclude []string, maxHops int32) ([]RouteHop, error)
+	SendOnion(onion string, hop FirstHop, paymentHash string) (*SendPayFields, error)
+	SendOnionWithDetails(onion string, hop FirstHop, paymentHash string, label string, secrets []string, partId *uint64) (*SendPayFields, error)
+	CreateOnion(hops []Hop, paymentHash, sessionKey string) (*CreateOnionResponse, error)
+	SendOnionMessage(firstId, blinding string, hops []*OnionMessageHop) error
+	InjectOnionMessage(pathKey, message string) error
+	GetChannel(shortChanId string) ([]*Channel, error)
+	ListChannelsBySource(nodeId string) ([]*Channel, error)
+	ListChannelsByDestination(nodeId string) ([]*Channel, error)
+	ListChannels() ([]*Channel, error)
+	CreateInvoiceAny(label, description string, expirySeconds uint32, fallbacks []string, preimage string, exposePrivateChans bool) (*Invoice, error)
+	CreateInvoice(msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, willExposePrivateChans bool) (*Invoice, error)
+	CreateInvoiceExposing(msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, exposePrivChans []string) (*Invoice, error)
+	CreateInvoiceWithCltvExpiry(msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, willExposePrivateChans bool, cltv uint32) (*Invoice, error)
+	Invoice(msat uint64, label, description string) (*Invoice, error)
+	CreateInvoiceDescHashOnly(msat uint64, label, description string, expirySeconds uint32) (*Invoice, error)
+	CreateInvoiceWithOptions(req *InvoiceRequest) (*Invoice, error)
+	ListInvoices() ([]*Invoice, error)
+	GetInvoice(label string) (*Invoice, error)
+	GetInvoiceByHash(paymentHash string) (*Invoice, error)
+	ListInvoicesFiltered(req *ListInvoiceRequest) ([]*Invoice, error)
+	DeleteInvoice(label, status string) (*Invoice, error)
+	DeleteInvoiceDescription(label, status string) (*Invoice, error)
+	WaitAnyInvoice(lastPayIndex uint) (*Invoice, error)
+	WaitAnyInvoiceTimeout(lastPayIndex uint, timeout uint) (*Invoice, error)
+	WaitInvoice(label string) (*Invoice, error)
+	DeleteExpiredInvoicesSince(unixTime uint64) error
+	DisableInvoiceAutoclean() error
+	SetInvoiceAutoclean(intervalSeconds, expiredBySeconds uint32) error
+	DecodeBolt11(bolt11 string) (*DecodedBolt11, error)
+	DecodePay(bolt11, desc string) (*DecodedBolt11, error)
+	ListPayStatuses() ([]PayStatus, error)
+	GetPayStatus(bolt11 string) (*PayStatus, error)
+	Help() ([]*Command, error)
+	HelpFor(command string) (*Command, error)
+	Stop() (string, error)
+	Deprecations(enable bool) error
+	GetLog(level LogLevel) (*LogResponse, error)
+	DevHash(secret string) (string, error)
+	DevCrash() (interface{}, error)
+	DevQueryShortChanIds(peerId string, shortChanIds []string) (*QueryShortChannelIdsResponse, error)
+	GetInfo() (*NodeInfo, error)
+	SignMessage(message string) (*SignedMessage, error)
+	CheckMessage(message, zbase string) (bool, string, error)
+	CheckMessageVerify(message, zbase, pubkey string) (bool, error)
+	SendPayLite(route []RouteHop, paymentHash string) (*SendPayResult, error)
+	SendPay(route []RouteHop, paymentHash, label string, msat *uint64, bolt11 string, paymentSecret string, partId uint64) (*SendPayResult, error)
+	SendPayPart(req *SendPayRequest) (*SendPayResult, error)
+	WaitSendPay(paymentHash string, timeout uint) (*SendPayFields, error)
+	WaitSendPayPart(paymentHash string, timeout uint, partId uint64) (*SendPayFields, error)
+	WaitSendPayPartInGroup(paymentHash string, timeout uint, partId, groupId uint64) (*SendPayFields, error)
+	PayBolt(bolt11 string) (*PaymentSuccess, error)
+	PayPartial(bolt11 string, partial *MSat) (*PaymentSuccess, error)
+	Pay(req *PayRequest) (*PaymentSuccess, error)
+	KeySend(destination string, msat uint64, label string, extraTlvs []*tlv.Record) (*PaymentSuccess, error)
+	ListPays() ([]PaymentFields, error)
+	ListPaysToBolt11(bolt11 string) ([]PaymentFields, error)
+	ListSendPaysAll() ([]SendPayFields, error)
+	ListSendPays(bolt11 string) ([]SendPayFields, error)
+	ListSendPaysByHash(paymentHash string) ([]SendPayFields, error)
+	ListTransactions() ([]Transaction, error)
+	BkprListAccountEvents(account string) ([]*BkprAccountEvent, error)
+	StaticBackup() ([]string, error)
+	EmergencyRecover() ([]string, error)
+	RecoverChannel(scbs []string) ([]string, error)
+	ConnectPeer(peerId, host string, port uint) (*ConnectResult, error)
+	Connect(peerId, host string, port uint) (string, error)
+	FundChannel(id string, amount *Sat) (*FundChannelResult, error)
+	FundPrivateChannel(id string, amount *Sat) (*FundChannelResult, error)
+	FundChannelAtFee(id string, amount *Sat, feerate *FeeRate) (*FundChannelResult, error)
+	FundPrivateChannelAtFee(id string, amount *Sat, feerate *FeeRate) (*FundChannelResult, error)
+	FundChannelExt(id string, amount *Sat, feerate *FeeRate, announce bool, minConf *uint16, pushMSat *MSat) (*FundChannelResult, error)
+	FundChannelWithOptions(req *FundChannelRequest) (*FundChannelResult, error)
+	MultiFundChannel(destinations []*FundDestination, feerate *FeeRate, minConf *uint16, utxos []*Utxo, minChannels uint) (*MultiFundChannelResult, error)
+	StartFundChannel(id string, amount uint64, announce bool, feerate *FeeRate, closeTo string) (*StartResponse, error)
+	CompleteFundChannel(peerId, txId string, txout uint32) (string, error)
+	CompleteFundChannelPsbt(peerId, psbt string) (string, error)
+	CancelFundChannel(peerId string) (bool, error)
+	OpenChannelBump(channelId string, amount *Sat, initialPsbt string, feerate *FeeRate) (*OpenChannelV2Result, error)
+	OpenChannelUpdate(channelId, psbt string) (*OpenChannelV2Result, error)
+	OpenChannelSigned(channelId, signedPsbt string) (*OpenChannelSignedResult, error)
+	OpenChannelAbort(channelId string) (*OpenChannelAbortResult, error)
+	SpliceInit(channelId string, relativeAmount int64, initialPsbt string, feeRatePerKw uint32) (*SpliceUpdateResult, error)
+	SpliceUpdate(channelId, psbt string) (*SpliceUpdateResult, error)
+	SpliceSigned(channelId, psbt string) (*SpliceSignedResult, error)
+	CloseNormal(id string) (*CloseResult, error)
+	CloseTo(id, destination string) (*CloseResult, error)
+	CloseWithStep(id, step string) (*CloseResult, error)
+	CloseToWithStep(id, destination, step string) (*CloseResult, error)
+	CloseToTimeoutWithStep(id string, timeout uint, destination, step string) (*CloseResult, error)
+	Close(id string, timeout uint, destination string) (*CloseResult, error)
+	DevSignLastTx(peerId string) (string, error)
+	DevFail(peerId string) error
+	DevReenableCommit(id string) error
+	Ping(peerId string) (*Pong, error)
+	PingWithLen(peerId string, pingLen, pongByteLen uint) (*Pong, error)
+	DevMemDump() ([]*MemDumpEntry, error)
+	DevMemLeak() ([]*MemLeak, error)
+	Withdraw(destination string, amount *Sat, feerate *FeeRate, minConf *uint16) (*WithdrawResult, error)
+	WithdrawWithUtxos(destination string, amount *Sat, feerate *FeeRate, minConf *uint16, utxos []*Utxo) (*WithdrawResult, error)
+	NewAddr() (string, error)
+	NewAddress(addrType AddressType) (*NewAddrResult, error)
+	PrepareTx(outputs []*Outputs, feerate *FeeRate, minConf *uint16) (*TxResult, error)
+	PrepareTxWithUtxos(outputs []*Outputs, feerate *FeeRate, minConf *uint16, utxos []*Utxo) (*TxResult, error)
+	DiscardTx(txid string) (*TxResult, error)
+	SendTx(txid string) (*TxResult, error)
+	SetPsbtVersion(psbt string, version uint8) (string, error)
+	FundPsbt(amount *Sat, feerate *FeeRate, startWeight uint, reserve *uint32) (*FundPsbtResult, error)
+	FundPsbtWithOptions(req *FundPsbtRequest) (*FundPsbtResult, error)
+	SignPsbt(psbt string, signOnly []uint32) (string, error)
+	UnreserveInputs(psbt string) ([]*PsbtReservation, error)
+	AddPsbtOutput(amount *Sat, initialPsbt, destination string) (*AddPsbtOutputResult, error)
+	SendPsbt(psbt string) (*WithdrawResult, error)
+	ListFunds() (*FundsResult, error)
+	ListForwards() ([]Forwarding, error)
+	ListForwardsFiltered(req *ListForwardsRequest) ([]Forwarding, error)
+	DevRescanOutputs() ([]Output, error)
+	DevForgetChannel(peerId string, force bool) (*ForgetChannelResult, error)
+	DevForgetChannelByShortChannelId(peerId, shortChannelId string, force bool) (*ForgetChannelResult, error)
+	DevForgetChannelByChannelId(peerId, channelId string, force bool) (*ForgetChannelResult, error)
+	SendCustomMessage(nodeId, message string) (*CustomMessageResult, error)
+	SendCustomTlvMessage(nodeId string, msgType uint16, records []*tlv.Record) (*CustomMessageResult, error)
+	Disconnect(peerId string, force bool) error
+	FeeRates(style FeeRateStyle) (*FeeRateEstimate, error)
+	SetChannelFee(id string, baseMsat string, ppm uint32) (*ChannelFeeResult, error)
+	SetChannel(id string, baseMsat uint64, ppm uint32) (*SetChannelResult, error)
+	SetChannelWithOptions(req *SetChannelRequest) (*SetChannelResult, error)
+	ListPlugins() ([]PluginInfo, error)
+	RescanPlugins() ([]PluginInfo, error)
+	SetPluginStartDir(directory string) ([]PluginInfo, error)
+	StartPlugin(pluginName string) ([]PluginInfo, error)
+	StopPlugin(pluginName string) (string, error)
+	GetSharedSecret(point string) (string, error)
+	GetFunderPolicy() (*FunderPolicyResult, error)
+	FunderUpdate(req *FunderUpdateRequest) (*FunderPolicyResult, error)
+	Sql(query string) ([][]json.RawMessage, error)
+
+	GetRoutes(source, destination string, amount *MSat, layers []string, maxFee *MSat, finalCltv uint32) (*GetRoutesResult, error)
+	AskReneCreateLayer(layer string, persistent bool) (*AskReneLayer, error)
+	AskReneRemoveLayer(layer string) error
+	AskReneListLayers() ([]*AskReneLayer, error)
+	AskReneGetLayer(layer string) (*AskReneLayer, error)
+	AskReneInformChannel(layer, scidDir string, amount *MSat, inform AskReneInform) ([]*AskReneConstraint, error)
+	AskReneDisableNode(layer, node string) error
+	AskReneBiasChannel(layer, scidDir string, bias int, description string, relative bool) ([]*AskReneBias, error)
+	AskReneCreateChannel(layer, source, destination, shortChannelId string, capacity *MSat) error
+	AskReneReserve(path []*AskReneReservation) error
+	AskReneUnreserve(path []*AskReneReservation) error
+	AskReneAge(layer string, cutoff uint64) (*AskReneAgeResult, error)
+
+	Commando(peerId, rune, method string, params map[string]interface{}, resp interface{}) error
+	CreateRune(restrictions [][]string) (*Rune, error)
+	CheckRune(rune, nodeId, method string, params map[string]interface{}) (bool, error)
+
+	DetectVersion() (*Version, error)
+	Version() *Version
+
+	ConnectBestEffort(nodeId string) (*ConnectBestEffortResult, error)
+	ConnectAddresses(nodeId string, addresses []Address) (*ConnectBestEffortResult, error)
+
+	SetDatastore(key []string, value string, mode DatastoreMode) (*DatastoreEntry, error)
+	ListDatastore(key []string) ([]*DatastoreEntry, error)
+	GetDatastore(key []string) (*DatastoreEntry, error)
+	DelDatastore(key []string) (*DatastoreEntry, error)
+
+	WithdrawWithStrategy(destination string, amount *Sat, strategy FeeStrategy, minConf *uint16) (*WithdrawResult, error)
+	FundChannelWithStrategy(id string, amount *Sat, strategy FeeStrategy, announce bool, minConf *uint16) (*FundChannelResult, error)
+	PrepareTxWithStrategy(outputs []*Outputs, strategy FeeStrategy, minConf *uint16) (*TxResult, error)
+
+	ListChannelsIter(batchSize int) *ChannelIterator
+	ListForwardsIter(batchSize int) *ForwardIterator
+	ListInvoicesIter(batchSize int) *InvoiceIterator
+
+	DetectNetwork() (network.Network, error)
+}
+
+var _ LightningClient = (*Lightning)(nil)
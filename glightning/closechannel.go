@@ -0,0 +1,171 @@
+package glightning
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// How far along closing each state is; a CloseFlow waits for its
+// Until state, or one after it
+var closeStateOrder = map[string]int{
+	"CHANNELD_SHUTTING_DOWN": 1,
+	"CLOSINGD_SIGEXCHANGE":   2,
+	"CLOSINGD_COMPLETE":      3,
+	"AWAITING_UNILATERAL":    4,
+	"FUNDING_SPEND_SEEN":     5,
+	"ONCHAIN":                6,
+	"CLOSED":                 7,
+}
+
+type CloseFlowResult struct {
+	PeerId    string
+	ChannelId string
+	// "mutual", or "unilateral" if the close was escalated
+	Type string
+	Tx   string
+	TxId string
+	// Our balance when the close began, in msat; what we get back,
+	// less our share of the closing fees
+	BalanceMsat uint64
+	// The last state the channel was seen in
+	State string
+}
+
+// Closes a channel cooperatively, following it through its
+// channel_state_changed notifications, and escalates to a unilateral
+// close if the peer hasn't agreed by the Deadline.
+//
+//	flow := glightning.NewCloseFlow(ln, events, "103x1x0")
+//	flow.Deadline = 10 * time.Minute
+//	flow.Until = "ONCHAIN"
+//	result, err := flow.Run(ctx)
+//
+// The close call blocks until the channel is closed (or escalated),
+// so the client's timeout (see SetTimeout) must be longer than the
+// Deadline.
+type CloseFlow struct {
+	client LightningClient
+	events *Events
+	// A peer id, channel id or short channel id
+	Id string
+	// Where to send our funds, if not to lightningd's wallet
+	Destination string
+	// See CloseWithStep
+	FeeNegotiationStep string
+	// How long to wait for the peer to agree before closing
+	// unilaterally; 0 to use lightningd's default (48 hours)
+	Deadline time.Duration
+	// The state Run returns at (or after); CLOSINGD_COMPLETE by
+	// default. "ONCHAIN" waits for the closing transaction to be seen
+	// on chain.
+	Until string
+	// Called with each state change of the channel
+	OnState func(*ChannelStateChanged)
+}
+
+// A flow closing the channel {id}. The channel's state changes are
+// followed through {events}, which must carry EventChannelStateChanged;
+// with no events, Run returns once the close call does.
+func NewCloseFlow(client LightningClient, events *Events, id string) *CloseFlow {
+	return &CloseFlow{
+		client: client,
+		events: events,
+		Id:     id,
+		Until:  "CLOSINGD_COMPLETE",
+	}
+}
+
+func (f *CloseFlow) Run(ctx context.Context) (*CloseFlowResult, error) {
+	if _, ok := closeStateOrder[f.Until]; !ok {
+		return nil, fmt.Errorf("Unknown closing state %q", f.Until)
+	}
+	peer, channel, err := f.findChannel()
+	if err != nil {
+		return nil, err
+	}
+	result := &CloseFlowResult{
+		PeerId:      peer.Id,
+		ChannelId:   channel.ChannelId,
+		BalanceMsat: msatOr(channel.ToUsMsat, channel.MilliSatoshiToUs),
+		State:       channel.State,
+	}
+
+	var states <-chan *Event
+	if f.events != nil {
+		sub := f.events.Subscribe(16, func(e *Event) bool {
+			change, ok := e.Payload.(*ChannelStateChanged)
+			return ok && change.ChannelId == channel.ChannelId
+		})
+		defer sub.Close()
+		states = sub.C
+	}
+
+	type closed struct {
+		result *CloseResult
+		err    error
+	}
+	closeDone := make(chan closed, 1)
+	go func() {
+		res, err := f.client.CloseToTimeoutWithStep(f.Id, uint(f.Deadline/time.Second), f.Destination, f.FeeNegotiationStep)
+		closeDone <- closed{res, err}
+	}()
+
+	reached := false
+	for {
+		select {
+		case c := <-closeDone:
+			if c.err != nil {
+				return nil, c.err
+			}
+			result.Type = c.result.Type
+			result.Tx = c.result.Tx
+			result.TxId = c.result.TxId
+			closeDone = nil
+			if states == nil {
+				return result, nil
+			}
+		case e := <-states:
+			change := e.Payload.(*ChannelStateChanged)
+			result.State = change.NewState
+			if f.OnState != nil {
+				f.OnState(change)
+			}
+			if closeStateOrder[change.NewState] >= closeStateOrder[f.Until] {
+				reached = true
+			}
+		case <-ctx.Done():
+			return result, ctx.Err()
+		}
+		if reached && closeDone == nil {
+			return result, nil
+		}
+	}
+}
+
+func (f *CloseFlow) findChannel() (*Peer, *PeerChannel, error) {
+	peers, err := f.client.ListPeers()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, peer := range peers {
+		for _, channel := range peer.Channels {
+			if _, closing := closeStateOrder[channel.State]; closing {
+				continue
+			}
+			if peer.Id == f.Id || channel.ChannelId == f.Id || string(channel.ShortChannelId) == f.Id {
+				return peer, channel, nil
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("No open channel %s", f.Id)
+}
+
+// lightningd gives amounts as "1000msat" strings, with a plain
+// number in older fields
+func msatOr(amount string, raw uint64) uint64 {
+	if m, err := ParseMSat(amount); err == nil {
+		return m.Value
+	}
+	return raw
+}
@@ -0,0 +1,122 @@
+package glightning_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const closePeer = "02c0114aac5ea2bce7759eb48d5aa75129700c1eb7fe6cc8743968a202f26505d6"
+
+func closeMock() *mock.Lightning {
+	ln := mock.New()
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return []*glightning.Peer{{Id: closePeer, Channels: []*glightning.PeerChannel{
+			{State: "ONCHAIN", ChannelId: "old", ShortChannelId: "101x1x0"},
+			{State: "CHANNELD_NORMAL", ChannelId: "a3b1", ShortChannelId: "103x1x0", ToUsMsat: "400000msat"},
+		}}}, nil
+	}
+	return ln
+}
+
+func publishState(events *glightning.Events, channelId, old, new string) {
+	events.Publish(glightning.EventChannelStateChanged, &glightning.ChannelStateChanged{
+		PeerId:    closePeer,
+		ChannelId: channelId,
+		OldState:  old,
+		NewState:  new,
+	})
+}
+
+func TestCloseFlow(t *testing.T) {
+	ln := closeMock()
+	events := glightning.NewEvents(glightning.NewPlugin(nullInitFunc))
+	ln.CloseToTimeoutWithStepFunc = func(id string, timeout uint, destination, step string) (*glightning.CloseResult, error) {
+		assert.Equal(t, "103x1x0", id)
+		assert.Equal(t, uint(600), timeout)
+		publishState(events, "other", "CHANNELD_NORMAL", "CLOSINGD_COMPLETE")
+		publishState(events, "a3b1", "CHANNELD_NORMAL", "CHANNELD_SHUTTING_DOWN")
+		return &glightning.CloseResult{Tx: "0200", TxId: "cc", Type: "mutual"}, nil
+	}
+	flow := glightning.NewCloseFlow(ln, events, "103x1x0")
+	flow.Deadline = 10 * time.Minute
+	var seen []string
+	flow.OnState = func(change *glightning.ChannelStateChanged) {
+		seen = append(seen, change.NewState)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		publishState(events, "a3b1", "CLOSINGD_SIGEXCHANGE", "CLOSINGD_COMPLETE")
+	}()
+	result, err := flow.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.CloseFlowResult{
+		PeerId:      closePeer,
+		ChannelId:   "a3b1",
+		Type:        "mutual",
+		Tx:          "0200",
+		TxId:        "cc",
+		BalanceMsat: 400000,
+		State:       "CLOSINGD_COMPLETE",
+	}, result)
+	assert.Equal(t, []string{"CHANNELD_SHUTTING_DOWN", "CLOSINGD_COMPLETE"}, seen)
+	assert.Equal(t, 0, events.Len())
+}
+
+func TestCloseFlowEscalated(t *testing.T) {
+	ln := closeMock()
+	events := glightning.NewEvents(glightning.NewPlugin(nullInitFunc))
+	ln.CloseToTimeoutWithStepFunc = func(id string, timeout uint, destination, step string) (*glightning.CloseResult, error) {
+		publishState(events, "a3b1", "CHANNELD_SHUTTING_DOWN", "AWAITING_UNILATERAL")
+		return &glightning.CloseResult{TxId: "dd", Type: "unilateral"}, nil
+	}
+	flow := glightning.NewCloseFlow(ln, events, closePeer)
+	flow.Until = "ONCHAIN"
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		publishState(events, "a3b1", "AWAITING_UNILATERAL", "FUNDING_SPEND_SEEN")
+		publishState(events, "a3b1", "FUNDING_SPEND_SEEN", "ONCHAIN")
+	}()
+	result, err := flow.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "unilateral", result.Type)
+	assert.Equal(t, "ONCHAIN", result.State)
+}
+
+func TestCloseFlowErrors(t *testing.T) {
+	ln := closeMock()
+	_, err := glightning.NewCloseFlow(ln, nil, "101x1x0").Run(context.Background())
+	assert.EqualError(t, err, "No open channel 101x1x0")
+
+	flow := glightning.NewCloseFlow(ln, nil, "a3b1")
+	flow.Until = "DONE"
+	_, err = flow.Run(context.Background())
+	assert.EqualError(t, err, `Unknown closing state "DONE"`)
+
+	// without events, Run returns with the close
+	ln.CloseToTimeoutWithStepFunc = func(id string, timeout uint, destination, step string) (*glightning.CloseResult, error) {
+		return &glightning.CloseResult{TxId: "cc", Type: "mutual"}, nil
+	}
+	result, err := glightning.NewCloseFlow(ln, nil, "a3b1").Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "cc", result.TxId)
+	assert.Equal(t, "CHANNELD_NORMAL", result.State)
+
+	// nor does it wait past its context
+	events := glightning.NewEvents(glightning.NewPlugin(nullInitFunc))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result, err = glightning.NewCloseFlow(ln, events, "a3b1").Run(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, "cc", result.TxId)
+}
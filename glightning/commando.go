@@ -0,0 +1,209 @@
+package glightning
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/elementsproject/glightning/jrpc2"
+)
+
+type CommandoRequest struct {
+	PeerId string                 `json:"peer_id"`
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params,omitempty"`
+	Rune   string                 `json:"rune,omitempty"`
+}
+
+func (r *CommandoRequest) Name() string {
+	return "commando"
+}
+
+// Run {method} with {params} on the node {peerId}, which must be
+// connected, authorised by {rune}. The result is decoded into {resp}.
+func (l *Lightning) Commando(peerId, rune, method string, params map[string]interface{}, resp interface{}) error {
+	if err := checkNodeId(peerId); err != nil {
+		return err
+	}
+	if method == "" {
+		return fmt.Errorf("Must provide a method to call")
+	}
+	return l.rpc.Request(&CommandoRequest{peerId, method, params, rune}, resp)
+}
+
+type CreateRuneRequest struct {
+	Rune         string     `json:"rune,omitempty"`
+	Restrictions [][]string `json:"restrictions,omitempty"`
+}
+
+func (r *CreateRuneRequest) Name() string {
+	return "createrune"
+}
+
+type Rune struct {
+	Rune     string `json:"rune"`
+	UniqueId string `json:"unique_id"`
+	Warning  string `json:"warning_unrestricted_rune,omitempty"`
+}
+
+// Create a rune limited by {restrictions}: each is a list of
+// alternatives, e.g. []string{"method=getinfo", "method=listpeers"},
+// all of which must be met. With no restrictions the rune allows
+// everything.
+func (l *Lightning) CreateRune(restrictions [][]string) (*Rune, error) {
+	var result Rune
+	err := l.rpc.Request(&CreateRuneRequest{Restrictions: restrictions}, &result)
+	return &result, err
+}
+
+type CheckRuneRequest struct {
+	Rune   string                 `json:"rune"`
+	NodeId string                 `json:"nodeid,omitempty"`
+	Method string                 `json:"method,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+func (r *CheckRuneRequest) Name() string {
+	return "checkrune"
+}
+
+// Check whether {rune} allows {nodeId} (which may be empty, if the
+// rune doesn't restrict it) to call {method} with {params}. A rune
+// which doesn't is an error, explaining why.
+func (l *Lightning) CheckRune(rune, nodeId, method string, params map[string]interface{}) (bool, error) {
+	if rune == "" {
+		return false, fmt.Errorf("Must provide a rune")
+	}
+
+	var result struct {
+		Valid bool `json:"valid"`
+	}
+	err := l.rpc.Request(&CheckRuneRequest{rune, nodeId, method, params}, &result)
+	return result.Valid, err
+}
+
+// RemoteLightning has all of Lightning's methods, but runs them on
+// another node, relayed by commando through a local node it's
+// connected to. One local node can so control many others, each
+// through a rune it has been given for them.
+//
+// Calls fail unless the remote node is connected, and the rune
+// allows the method. They go over the local node's connection, so
+// time out as its calls do; the remote's own settings, such as
+// SetCompat and SetStrict, only change how its results are decoded,
+// and it can't be started or shut down itself.
+type RemoteLightning struct {
+	*Lightning
+	PeerId string
+	local  *Lightning
+}
+
+func NewRemoteLightning(local *Lightning, peerId, rune string) *RemoteLightning {
+	// the remote's client is never started: its settings are kept
+	// apart from the local node's, whose connection it uses
+	remote := &Lightning{client: jrpc2.NewClient()}
+	remote.rpc = &commandoRequester{local, remote, peerId, rune}
+	return &RemoteLightning{
+		Lightning: remote,
+		PeerId:    peerId,
+		local:     local,
+	}
+}
+
+// The local node's connection is started, and shut down, on its own
+func (r *RemoteLightning) StartUp(rpcfile, lightningDir string) error {
+	return r.notStarted()
+}
+
+func (r *RemoteLightning) StartUpTCP(addr string, tlsConfig *tls.Config) error {
+	return r.notStarted()
+}
+
+func (r *RemoteLightning) StartUpHTTP(endpoint string, httpClient *http.Client) error {
+	return r.notStarted()
+}
+
+// Closes {conn}, which the remote node can't be reached over
+func (r *RemoteLightning) StartConn(conn io.ReadWriteCloser) {
+	conn.Close()
+}
+
+func (r *RemoteLightning) notStarted() error {
+	return fmt.Errorf("Remote node %s is reached through the local node; start that instead", r.PeerId)
+}
+
+func (r *RemoteLightning) Shutdown() {}
+
+// Whether the local node is up; not whether the remote one is
+// connected to it
+func (r *RemoteLightning) IsUp() bool {
+	return r.local.IsUp()
+}
+
+// Relays each request through commando, decoding its result as the
+// remote is set up to
+type commandoRequester struct {
+	local  *Lightning
+	remote *Lightning
+	peerId string
+	rune   string
+}
+
+func (c *commandoRequester) wrap(m jrpc2.Method) *CommandoRequest {
+	params := jrpc2.GetNamedParams(m)
+	if len(params) == 0 {
+		params = nil
+	}
+	return &CommandoRequest{
+		PeerId: c.peerId,
+		Method: m.Name(),
+		Params: params,
+		Rune:   c.rune,
+	}
+}
+
+// Decode the {raw} result of a call into {resp}, unless it failed
+func (c *commandoRequester) decode(raw json.RawMessage, err error, resp interface{}) error {
+	if err != nil || resp == nil {
+		return err
+	}
+	if unmarshal := c.remote.unmarshaler(); unmarshal != nil {
+		return unmarshal(raw, resp)
+	}
+	return json.Unmarshal(raw, resp)
+}
+
+func (c *commandoRequester) Request(m jrpc2.Method, resp interface{}) error {
+	raw, err := c.local.rpc.RequestRaw(c.wrap(m), nil)
+	return c.decode(raw, err, resp)
+}
+
+func (c *commandoRequester) RequestNoTimeout(m jrpc2.Method, resp interface{}) error {
+	raw, err := c.local.rpc.RequestRawNoTimeout(c.wrap(m), nil)
+	return c.decode(raw, err, resp)
+}
+
+func (c *commandoRequester) RequestRaw(m jrpc2.Method, resp interface{}) (json.RawMessage, error) {
+	raw, err := c.local.rpc.RequestRaw(c.wrap(m), nil)
+	return raw, c.decode(raw, err, resp)
+}
+
+func (c *commandoRequester) RequestRawNoTimeout(m jrpc2.Method, resp interface{}) (json.RawMessage, error) {
+	raw, err := c.local.rpc.RequestRawNoTimeout(c.wrap(m), nil)
+	return raw, c.decode(raw, err, resp)
+}
+
+func (c *commandoRequester) RequestCtx(ctx context.Context, m jrpc2.Method, resp interface{}) error {
+	raw, err := c.local.rpc.RequestRawCtx(ctx, c.wrap(m), nil)
+	return c.decode(raw, err, resp)
+}
+
+func (c *commandoRequester) RequestRawCtx(ctx context.Context, m jrpc2.Method, resp interface{}) (json.RawMessage, error) {
+	raw, err := c.local.rpc.RequestRawCtx(ctx, c.wrap(m), nil)
+	return raw, c.decode(raw, err, resp)
+}
+
+var _ LightningClient = (*RemoteLightning)(nil)
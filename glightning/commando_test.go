@@ -0,0 +1,100 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	remotePeer = "02e9c0f1d5c44d4fe7a3c2d5b3e1a9f0c8d7b6a5948372615049382716a5b4c3d2"
+	remoteRune = "zFMd1fjhrAYxUeFA54TjloZqOt8JrA_i_nYwIgXkag49MA=="
+)
+
+func TestRemoteLightning(t *testing.T) {
+	lightning, requestQ, replyQ := startupServer(t)
+	remote := glightning.NewRemoteLightning(lightning, remotePeer, remoteRune)
+
+	req := `{"jsonrpc":"2.0","method":"commando","params":{"method":"getinfo","peer_id":"` + remotePeer + `","rune":"` + remoteRune + `"},"id":1}`
+	resp := wrapResult(1, `{"id": "`+remotePeer+`", "alias": "fleet-3"}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	info, err := remote.GetInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "fleet-3", info.Alias)
+
+	// params are relayed as given
+	req = `{"jsonrpc":"2.0","method":"commando","params":{"method":"setchannelfee","params":{"base":"1000msat","id":"1x2x3","ppm":10},"peer_id":"` + remotePeer + `","rune":"` + remoteRune + `"},"id":2}`
+	resp = wrapResult(2, `{"channels": []}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err = remote.SetChannelFee("1x2x3", "1000msat", 10)
+	assert.NoError(t, err)
+
+	// a refused rune comes back as the remote's error
+	req = `{"jsonrpc":"2.0","method":"commando","params":{"method":"stop","peer_id":"` + remotePeer + `","rune":"` + remoteRune + `"},"id":3}`
+	resp = `{"jsonrpc":"2.0","id":3,"error":{"code":19537,"message":"Not authorized: method is not getinfo"}}`
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err = remote.Stop()
+	assert.EqualError(t, err, "19537:Not authorized: method is not getinfo")
+
+	assert.Error(t, remote.StartUp("lightning-rpc", "/tmp"))
+	assert.Error(t, remote.StartUpTCP("127.0.0.1:9835", nil))
+	assert.Error(t, remote.StartUpHTTP("http://127.0.0.1:9835", nil))
+	remote.Shutdown()
+	assert.True(t, lightning.IsUp())
+}
+
+// The remote's settings are its own; the local node's are left alone
+func TestRemoteLightningSettings(t *testing.T) {
+	lightning, requestQ, replyQ := startupServer(t)
+	remote := glightning.NewRemoteLightning(lightning, remotePeer, remoteRune)
+	remote.SetCompat(true)
+	remote.SetTimeout(1)
+
+	// a new-style result, only decoded by the remote's compat layer
+	req := `{"jsonrpc":"2.0","method":"commando","params":{"method":"listinvoices","params":{"label":"uniq"},"peer_id":"` + remotePeer + `","rune":"` + remoteRune + `"},"id":1}`
+	resp := wrapResult(1, `{"invoices":[{"label":"uniq","amount_msat":1000,"status":"unpaid"}]}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	invoice, err := remote.GetInvoice("uniq")
+	assert.NoError(t, err)
+	assert.Equal(t, "1000msat", invoice.AmountMilliSatoshi)
+
+	req = `{"jsonrpc":"2.0","method":"listinvoices","params":{"label":"uniq"},"id":2}`
+	resp = wrapResult(2, `{"invoices":[{"label":"uniq","amount_msat":1000,"status":"unpaid"}]}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err = lightning.GetInvoice("uniq")
+	assert.Error(t, err)
+	assert.True(t, lightning.IsUp())
+}
+
+func TestCreateRune(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"createrune","params":{"restrictions":[["method=getinfo","method=listpeers"]]},"id":1}`
+	resp := wrapResult(1, `{"rune": "zFMd1fjhrAYxUeFA54TjloZqOt8JrA_i_nYwIgXkag49MA==", "unique_id": "0"}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	rune, err := lightning.CreateRune([][]string{{"method=getinfo", "method=listpeers"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.Rune{
+		Rune:     "zFMd1fjhrAYxUeFA54TjloZqOt8JrA_i_nYwIgXkag49MA==",
+		UniqueId: "0",
+	}, rune)
+}
+
+func TestCheckRune(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"checkrune","params":{"method":"pay","params":{"bolt11":"lnbcrt1"},"rune":"` + remoteRune + `"},"id":1}`
+	resp := wrapResult(1, `{"valid": true}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	valid, err := lightning.CheckRune(remoteRune, "", "pay", map[string]interface{}{"bolt11": "lnbcrt1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, valid)
+
+	_, err = lightning.CheckRune("", "", "pay", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,291 @@
+package glightning
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A lightningd release version, e.g. "v0.7.3" or "v24.08.1"
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	// The version string, as reported by getinfo
+	Raw string
+}
+
+// Parse a version as reported by `lightningd --version` or getinfo.
+// Accepts a leading 'v' and ignores any suffix after the numeric
+// part, e.g. "v23.11rc1" or "v0.10.2-modded".
+func ParseVersion(version string) (*Version, error) {
+	v := &Version{Raw: version}
+	s := strings.TrimPrefix(strings.TrimSpace(version), "v")
+
+	parts := strings.SplitN(s, ".", 3)
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		end := 0
+		for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			if i < 2 {
+				return nil, fmt.Errorf("Unable to parse version %q", version)
+			}
+			break
+		}
+		n, err := strconv.Atoi(part[:end])
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse version %q: %s", version, err)
+		}
+		*nums[i] = n
+		// anything after the digits is a suffix; stop here
+		if end < len(part) {
+			break
+		}
+	}
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("Unable to parse version %q", version)
+	}
+	return v, nil
+}
+
+func (v *Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Returns -1, 0 or 1 if v is older than, the same as,
+// or newer than {other}
+func (v *Version) Compare(other *Version) int {
+	a := []int{v.Major, v.Minor, v.Patch}
+	b := []int{other.Major, other.Minor, other.Patch}
+	for i := range a {
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (v *Version) AtLeast(major, minor, patch int) bool {
+	return v.Compare(&Version{Major: major, Minor: minor, Patch: patch}) >= 0
+}
+
+// Ask lightningd for its version (via getinfo). The detected version
+// is cached; see Version. To have responses from both old and new
+// releases fill the same structs, turn on SetCompat as well.
+func (l *Lightning) DetectVersion() (*Version, error) {
+	// only the version is wanted: the rest of getinfo changed across
+	// releases, and may not decode without the compat layer
+	var info struct {
+		Version string `json:"version"`
+	}
+	err := l.rpc.Request(&GetInfoRequest{}, &info)
+	if err != nil {
+		return nil, err
+	}
+	version, err := ParseVersion(info.Version)
+	if err != nil {
+		return nil, err
+	}
+	l.version = version
+	return version, nil
+}
+
+// The version found by DetectVersion, nil if it hasn't been run
+func (l *Lightning) Version() *Version {
+	return l.version
+}
+
+// Turn the field-compat layer on or off. When on, results are
+// normalized before being decoded:
+//   - fields renamed between releases (e.g. 'msatoshi' and
+//     'amount_msat') are filled in from whichever one is present
+//   - msat amounts are converted between the old "123msat" strings
+//     and the newer plain integers, as the Go field requires; only
+//     for msat fields (named '*msat' or 'msatoshi*'), so no other
+//     amount is taken to be in msat
+func (l *Lightning) SetCompat(on bool) {
+	l.compat = on
+	l.setUnmarshaler()
+}
+
+// Results are decoded by the compat layer and/or strictly (see
+// SetStrict), as turned on
+func (l *Lightning) setUnmarshaler() {
+	l.client.SetUnmarshaler(l.unmarshaler())
+}
+
+// How results are decoded, as set up; nil for json.Unmarshal
+func (l *Lightning) unmarshaler() func(data []byte, v interface{}) error {
+	var unmarshal func(data []byte, v interface{}) error
+	if l.strict {
+		unmarshal = strictUnmarshal
+	}
+	if l.compat {
+		decode := unmarshal
+		if decode == nil {
+			decode = json.Unmarshal
+		}
+		unmarshal = func(data []byte, v interface{}) error {
+			return compatDecode(data, v, decode)
+		}
+	}
+	return unmarshal
+}
+
+// Pairs of field names which hold the same value, in the
+// old (msatoshi) and new (msat) styles
+var compatFieldPairs = [][2]string{
+	{"msatoshi", "amount_msat"},
+	{"msatoshi_sent", "amount_sent_msat"},
+	{"msatoshi_received", "amount_received_msat"},
+	{"msatoshi_to_us", "to_us_msat"},
+	{"msatoshi_to_us_min", "min_to_us_msat"},
+	{"msatoshi_to_us_max", "max_to_us_msat"},
+	{"msatoshi_total", "total_msat"},
+	{"msatoshi_fees_collected", "fees_collected_msat"},
+	{"in_msatoshi", "in_msat"},
+	{"out_msatoshi", "out_msat"},
+	{"our_amount_msat", "our_msat"},
+}
+
+var compatAliases = func() map[string][]string {
+	aliases := make(map[string][]string)
+	for _, pair := range compatFieldPairs {
+		aliases[pair[0]] = append(aliases[pair[0]], pair[1])
+		aliases[pair[1]] = append(aliases[pair[1]], pair[0])
+	}
+	return aliases
+}()
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// Normalize {data} for {v}, then {decode} it
+func compatDecode(data []byte, v interface{}, decode func([]byte, interface{}) error) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return decode(data, v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	normalized, err := json.Marshal(compatNormalize(raw, rv.Type().Elem(), ""))
+	if err != nil {
+		return err
+	}
+	return decode(normalized, v)
+}
+
+// Rewrite {val} so that it decodes into a Go value of type {t}.
+// {key} is the JSON field name {val} was found under, if any.
+func compatNormalize(val interface{}, t reflect.Type, key string) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+		// the type knows how to decode itself
+		return val
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			return val
+		}
+		compatNormalizeStruct(obj, t)
+		return obj
+	case reflect.Slice, reflect.Array:
+		list, ok := val.([]interface{})
+		if !ok {
+			return val
+		}
+		for i := range list {
+			list[i] = compatNormalize(list[i], t.Elem(), "")
+		}
+		return list
+	case reflect.Map:
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			return val
+		}
+		for k := range obj {
+			obj[k] = compatNormalize(obj[k], t.Elem(), k)
+		}
+		return obj
+	case reflect.String:
+		if num, ok := val.(json.Number); ok {
+			if strings.HasSuffix(key, "msat") {
+				return num.String() + "msat"
+			}
+			return num.String()
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if str, ok := val.(string); ok && isMsatField(key) {
+			if msat, err := ParseMSat(str); err == nil {
+				return json.Number(strconv.FormatUint(msat.Value, 10))
+			}
+		}
+	}
+	return val
+}
+
+// Whether JSON field {key} holds an msat amount
+func isMsatField(key string) bool {
+	return strings.HasSuffix(key, "msat") || strings.Contains(key, "msatoshi")
+}
+
+func compatNormalizeStruct(obj map[string]interface{}, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name := field.Name
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if tag != "" {
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && tag == "" && ft.Kind() == reflect.Struct {
+			// embedded struct fields are promoted into this object
+			compatNormalizeStruct(obj, ft)
+			continue
+		}
+
+		if val, ok := obj[name]; ok {
+			obj[name] = compatNormalize(val, field.Type, name)
+			continue
+		}
+		for _, alias := range compatAliases[name] {
+			if val, ok := obj[alias]; ok {
+				obj[name] = compatNormalize(val, field.Type, name)
+				break
+			}
+		}
+	}
+}
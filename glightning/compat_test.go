@@ -0,0 +1,166 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	cases := map[string][3]int{
+		"v0.7.3":               {0, 7, 3},
+		"v0.10.2-modded":       {0, 10, 2},
+		"v23.11rc1":            {23, 11, 0},
+		"v24.08.1":             {24, 8, 1},
+		"24.02-123-gabcdef":    {24, 2, 0},
+		"v0.7.3-111-g19d9fdd0": {0, 7, 3},
+	}
+	for in, expected := range cases {
+		v, err := glightning.ParseVersion(in)
+		if assert.NoError(t, err, in) {
+			assert.Equal(t, expected, [3]int{v.Major, v.Minor, v.Patch}, in)
+			assert.Equal(t, in, v.Raw)
+		}
+	}
+
+	for _, bad := range []string{"", "v", "vX.1", "24"} {
+		_, err := glightning.ParseVersion(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	old, _ := glightning.ParseVersion("v0.10.2")
+	recent, _ := glightning.ParseVersion("v23.08")
+	assert.Equal(t, -1, old.Compare(recent))
+	assert.Equal(t, 1, recent.Compare(old))
+	assert.Equal(t, 0, old.Compare(old))
+	assert.True(t, recent.AtLeast(23, 5, 0))
+	assert.False(t, old.AtLeast(0, 11, 0))
+	assert.Equal(t, "v23.8.0", recent.String())
+}
+
+func TestDetectVersion(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"getinfo","params":{},"id":1}`
+	resp := wrapResult(1, `{
+   "id": "02cca6c5c966fcf61d121e3a70e03a1cd9eeeea024b26ea666ce974d43b242e636",
+   "version": "v24.08.1",
+   "blockheight": 110,
+   "network": "regtest",
+   "fees_collected_msat": 0
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	assert.Nil(t, lightning.Version())
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	version, err := lightning.DetectVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, version.AtLeast(24, 8, 0))
+	assert.Equal(t, version, lightning.Version())
+}
+
+// Newer releases dropped 'msatoshi' and return msat amounts as integers
+func TestCompatNewStyleInvoice(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"listinvoices","params":{"label":"uniq"},"id":1}`
+	resp := wrapResult(1, `{
+  "invoices": [
+    {
+      "label": "uniq",
+      "payment_hash": "0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a",
+      "amount_msat": 1000,
+      "amount_received_msat": 1001,
+      "status": "paid",
+      "description": "desc",
+      "expires_at": 1546475890
+    }
+  ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	lightning.SetCompat(true)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	invoice, err := lightning.GetInvoice("uniq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.Invoice{
+		Label:                   "uniq",
+		PaymentHash:             "0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a",
+		AmountMilliSatoshi:      "1000msat",
+		AmountMilliSatoshiRaw:   1000,
+		MilliSatoshiReceived:    "1001msat",
+		MilliSatoshiReceivedRaw: 1001,
+		Status:                  "paid",
+		Description:             "desc",
+		ExpiresAt:               1546475890,
+	}, invoice)
+}
+
+// Old releases only sent 'msatoshi' with a "msat"-suffixed string alongside
+func TestCompatOldStyleInvoice(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"listinvoices","params":{"label":"uniq"},"id":1}`
+	resp := wrapResult(1, `{
+  "invoices": [
+    {
+      "label": "uniq",
+      "payment_hash": "0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a",
+      "msatoshi": 1000,
+      "status": "unpaid",
+      "description": "desc",
+      "expires_at": 1546475890
+    }
+  ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	lightning.SetCompat(true)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	invoice, err := lightning.GetInvoice("uniq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "1000msat", invoice.AmountMilliSatoshi)
+	assert.Equal(t, uint64(1000), invoice.AmountMilliSatoshiRaw)
+}
+
+// Only msat fields take "sat"/"msat" strings as msat amounts
+func TestCompatOnlyMsatFields(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"amounts","params":{},"id":1}`
+	lightning, requestQ, replyQ := startupServer(t)
+	lightning.SetCompat(true)
+
+	var msat struct {
+		FeeMsat   uint64 `json:"fee_msat"`
+		Msatoshi  uint64 `json:"msatoshi"`
+		AmountSat uint64 `json:"amount_sat"`
+	}
+	go runServerSide(t, req, wrapResult(1, `{"fee_msat":"2sat","msatoshi":"1000msat","amount_sat":1000}`), replyQ, requestQ)
+	err := lightning.CallInto("amounts", nil, &msat)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2000), msat.FeeMsat)
+	assert.Equal(t, uint64(1000), msat.Msatoshi)
+	assert.Equal(t, uint64(1000), msat.AmountSat)
+
+	// a sat field isn't multiplied up, as though it were msat
+	req = `{"jsonrpc":"2.0","method":"amounts","params":{},"id":2}`
+	go runServerSide(t, req, wrapResult(2, `{"amount_sat":"1000sat"}`), replyQ, requestQ)
+	err = lightning.CallInto("amounts", nil, &msat)
+	assert.Error(t, err)
+	assert.Equal(t, uint64(1000), msat.AmountSat)
+}
+
+// DetectVersion leaves the compat layer as it was
+func TestDetectVersionLeavesCompatOff(t *testing.T) {
+	lightning, requestQ, replyQ := startupServer(t)
+	req := `{"jsonrpc":"2.0","method":"getinfo","params":{},"id":1}`
+	go runServerSide(t, req, wrapResult(1, `{"id":"02aa","version":"v0.10.2"}`), replyQ, requestQ)
+	_, err := lightning.DetectVersion()
+	assert.NoError(t, err)
+
+	req = `{"jsonrpc":"2.0","method":"listinvoices","params":{"label":"uniq"},"id":2}`
+	resp := wrapResult(2, `{"invoices":[{"label":"uniq","amount_msat":1000,"status":"unpaid"}]}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	// a new-style integer amount doesn't fit the string field as-is
+	_, err = lightning.GetInvoice("uniq")
+	assert.Error(t, err)
+}
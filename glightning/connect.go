@@ -0,0 +1,84 @@
+package glightning
+
+import (
+	"fmt"
+	"sort"
+)
+
+// One address ConnectBestEffort tried
+type ConnectAttempt struct {
+	Address Address
+	// nil if the connection succeeded
+	Err error
+}
+
+type ConnectBestEffortResult struct {
+	ConnectResult
+	// The address the connection was made on
+	Address Address
+	// Every address tried, in order, ending with the one which worked
+	Attempts []*ConnectAttempt
+}
+
+// Connect to {nodeId} on one of the addresses it gossips: clearnet
+// addresses first, then Tor, trying each in turn until one works.
+// lightningd does much the same when connect is given only an id,
+// but this reports which address worked and why the others didn't.
+//
+// If every address fails, the error is the last attempt's and the
+// result has all the attempts.
+func (l *Lightning) ConnectBestEffort(nodeId string) (*ConnectBestEffortResult, error) {
+	if err := checkNodeId(nodeId); err != nil {
+		return nil, err
+	}
+	node, err := l.GetNode(nodeId)
+	if err != nil {
+		return nil, err
+	}
+	return l.ConnectAddresses(nodeId, SortConnectAddresses(node.Addresses))
+}
+
+// Connect to {nodeId} on the first of {addresses} which works, as
+// ConnectBestEffort, in the order given
+func (l *Lightning) ConnectAddresses(nodeId string, addresses []Address) (*ConnectBestEffortResult, error) {
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("No addresses to connect to %s on", nodeId)
+	}
+	result := &ConnectBestEffortResult{}
+	for _, addr := range addresses {
+		attempt := &ConnectAttempt{Address: addr}
+		result.Attempts = append(result.Attempts, attempt)
+		connected, err := l.ConnectPeer(nodeId, addr.Addr, uint(addr.Port))
+		if err != nil {
+			attempt.Err = err
+			continue
+		}
+		result.ConnectResult = *connected
+		result.Address = addr
+		return result, nil
+	}
+	return result, result.Attempts[len(result.Attempts)-1].Err
+}
+
+// The addresses connect can use, clearnet (IPv4, IPv6 then DNS)
+// before Tor (v3 before v2), keeping the gossiped order otherwise.
+// Websocket addresses, which are for browsers, are dropped.
+func SortConnectAddresses(addresses []Address) []Address {
+	rank := map[NetAddressType]int{
+		AddrIPv4:  0,
+		AddrIPv6:  1,
+		AddrDNS:   2,
+		AddrTorV3: 3,
+		AddrTorV2: 4,
+	}
+	var sorted []Address
+	for _, addr := range addresses {
+		if _, ok := rank[addr.Type]; ok {
+			sorted = append(sorted, addr)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rank[sorted[i].Type] < rank[sorted[j].Type]
+	})
+	return sorted
+}
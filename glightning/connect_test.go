@@ -0,0 +1,61 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortConnectAddresses(t *testing.T) {
+	addrs := []glightning.Address{
+		{Type: glightning.AddrTorV2, Addr: "v2.onion", Port: 9735},
+		{Type: glightning.AddrWebsocket, Addr: "", Port: 8080},
+		{Type: glightning.AddrTorV3, Addr: "v3.onion", Port: 9735},
+		{Type: glightning.AddrDNS, Addr: "node.example.com", Port: 9735},
+		{Type: glightning.AddrIPv6, Addr: "2001:db8::1", Port: 9735},
+		{Type: glightning.AddrIPv4, Addr: "203.0.113.1", Port: 9735},
+		{Type: glightning.AddrIPv4, Addr: "203.0.113.2", Port: 9736},
+	}
+	var sorted []string
+	for _, addr := range glightning.SortConnectAddresses(addrs) {
+		sorted = append(sorted, addr.Addr)
+	}
+	assert.Equal(t, []string{"203.0.113.1", "203.0.113.2", "2001:db8::1", "node.example.com", "v3.onion", "v2.onion"}, sorted)
+}
+
+func TestConnectBestEffort(t *testing.T) {
+	id := "02befaace6e8970aaca34eafe85f30f988e374628ec279d94e7eca8b574b738eb4"
+	lightning, requestQ, replyQ := startupServer(t)
+
+	req := `{"jsonrpc":"2.0","method":"listnodes","params":{"id":"` + id + `"},"id":1}`
+	resp := wrapResult(1, `{"nodes": [{"nodeid": "`+id+`", "addresses": [
+		{"type": "torv3", "address": "abcdefgh.onion", "port": 9735},
+		{"type": "ipv4", "address": "203.0.113.1", "port": 9735}]}]}`)
+	go func() {
+		runServerSide(t, req, resp, replyQ, requestQ)
+		runServerSide(t,
+			`{"jsonrpc":"2.0","method":"connect","params":{"host":"203.0.113.1","id":"`+id+`","port":9735},"id":2}`,
+			wrapError(2, 401, "Connection refused", `null`),
+			replyQ, requestQ)
+		runServerSide(t,
+			`{"jsonrpc":"2.0","method":"connect","params":{"host":"abcdefgh.onion","id":"`+id+`","port":9735},"id":3}`,
+			wrapResult(3, `{"id": "`+id+`", "features": "08a0"}`),
+			replyQ, requestQ)
+	}()
+
+	result, err := lightning.ConnectBestEffort(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, id, result.Id)
+	assert.Equal(t, "abcdefgh.onion", result.Address.Addr)
+	if assert.Len(t, result.Attempts, 2) {
+		assert.Equal(t, "203.0.113.1", result.Attempts[0].Address.Addr)
+		assert.Error(t, result.Attempts[0].Err)
+		assert.NoError(t, result.Attempts[1].Err)
+	}
+
+	_, err = lightning.ConnectAddresses(id, nil)
+	assert.EqualError(t, err, "No addresses to connect to "+id+" on")
+}
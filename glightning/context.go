@@ -0,0 +1,67 @@
+package glightning
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/elementsproject/glightning/jrpc2"
+)
+
+// A Lightning whose calls are scoped to {ctx}: each waits for as long
+// as {ctx} allows (rather than the timeout set with SetTimeout), and
+// fails with ctx.Err() once it's cancelled. The connection is shared
+// with {l}.
+//
+//	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+//	defer cancel()
+//	info, err := lightning.WithContext(ctx).GetInfo()
+//
+// Each wrapper also has a Ctx variant doing the same, e.g. GetInfoCtx.
+func (l *Lightning) WithContext(ctx context.Context) *Lightning {
+	scoped := *l
+	scoped.rpc = &ctxRequester{l.rpc, ctx}
+	return &scoped
+}
+
+// {client}, with its calls scoped to {ctx} (see Lightning.WithContext)
+// if it's a client which can be; others, such as fakes, are returned
+// as they are.
+func ClientWithContext(ctx context.Context, client LightningClient) LightningClient {
+	if scoper, ok := client.(interface {
+		WithContext(ctx context.Context) *Lightning
+	}); ok {
+		return scoper.WithContext(ctx)
+	}
+	return client
+}
+
+// Sends every request with its context
+type ctxRequester struct {
+	rpc requester
+	ctx context.Context
+}
+
+func (c *ctxRequester) Request(m jrpc2.Method, resp interface{}) error {
+	return c.rpc.RequestCtx(c.ctx, m, resp)
+}
+
+// The context is all that limits how long a request can take
+func (c *ctxRequester) RequestNoTimeout(m jrpc2.Method, resp interface{}) error {
+	return c.rpc.RequestCtx(c.ctx, m, resp)
+}
+
+func (c *ctxRequester) RequestRaw(m jrpc2.Method, resp interface{}) (json.RawMessage, error) {
+	return c.rpc.RequestRawCtx(c.ctx, m, resp)
+}
+
+func (c *ctxRequester) RequestRawNoTimeout(m jrpc2.Method, resp interface{}) (json.RawMessage, error) {
+	return c.rpc.RequestRawCtx(c.ctx, m, resp)
+}
+
+func (c *ctxRequester) RequestCtx(ctx context.Context, m jrpc2.Method, resp interface{}) error {
+	return c.rpc.RequestCtx(ctx, m, resp)
+}
+
+func (c *ctxRequester) RequestRawCtx(ctx context.Context, m jrpc2.Method, resp interface{}) (json.RawMessage, error) {
+	return c.rpc.RequestRawCtx(ctx, m, resp)
+}
@@ -0,0 +1,58 @@
+package glightning_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetInfoCtx(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"getinfo","params":{},"id":1}`
+	resp := wrapResult(1, `{"id": "02aa", "alias": "scoped"}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	// the context, not the client's timeout, limits the call
+	lightning.SetTimeout(0)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	info, err := lightning.GetInfoCtx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "scoped", info.Alias)
+}
+
+func TestWaitInvoiceCtxCancelled(t *testing.T) {
+	lightning, requestQ, _ := startupServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// lightningd never answers; give up once the request is sent
+		<-requestQ
+		cancel()
+	}()
+	_, err := lightning.WaitInvoiceCtx(ctx, "order-7")
+	assert.Equal(t, context.Canceled, err)
+
+	// the scoped Lightning stays cancelled
+	scoped := lightning.WithContext(ctx)
+	_, err = scoped.GetInfo()
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestClientWithContext(t *testing.T) {
+	lightning, requestQ, _ := startupServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var client glightning.LightningClient = lightning
+	_, err := glightning.ClientWithContext(ctx, client).GetInfo()
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, requestQ, 0)
+
+	// fakes can't be scoped
+	fake := mock.New()
+	assert.Equal(t, fake, glightning.ClientWithContext(ctx, fake))
+}
@@ -0,0 +1,125 @@
+package glightning
+
+import (
+	"fmt"
+)
+
+// lightningd's datastore is a simple key/value store, persisted in its
+// database. Keys are paths of strings, e.g. ["myplugin", "state"];
+// by convention the first element is the name of the owning plugin.
+
+type DatastoreMode string
+
+const (
+	DatastoreMustCreate      DatastoreMode = "must-create"
+	DatastoreMustReplace     DatastoreMode = "must-replace"
+	DatastoreCreateOrReplace DatastoreMode = "create-or-replace"
+	DatastoreMustAppend      DatastoreMode = "must-append"
+	DatastoreCreateOrAppend  DatastoreMode = "create-or-append"
+)
+
+type DatastoreRequest struct {
+	Key        []string      `json:"key"`
+	String     string        `json:"string,omitempty"`
+	Hex        string        `json:"hex,omitempty"`
+	Mode       DatastoreMode `json:"mode,omitempty"`
+	Generation *uint64       `json:"generation,omitempty"`
+}
+
+func (r *DatastoreRequest) Name() string {
+	return "datastore"
+}
+
+type DatastoreEntry struct {
+	Key []string `json:"key"`
+	// Incremented each time the entry is changed
+	Generation uint64 `json:"generation"`
+	Hex        string `json:"hex"`
+	// Only set if the value is valid UTF-8
+	String string `json:"string,omitempty"`
+}
+
+type ListDatastoreRequest struct {
+	Key []string `json:"key,omitempty"`
+}
+
+func (r *ListDatastoreRequest) Name() string {
+	return "listdatastore"
+}
+
+type DelDatastoreRequest struct {
+	Key        []string `json:"key"`
+	Generation *uint64  `json:"generation,omitempty"`
+}
+
+func (r *DelDatastoreRequest) Name() string {
+	return "deldatastore"
+}
+
+// Store the string {value} under {key}. {mode} decides what happens
+// if there's already a value there; the default (empty) mode is
+// DatastoreMustCreate.
+func (l *Lightning) SetDatastore(key []string, value string, mode DatastoreMode) (*DatastoreEntry, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("Must provide a datastore key")
+	}
+
+	var result DatastoreEntry
+	err := l.rpc.Request(&DatastoreRequest{
+		Key:    key,
+		String: value,
+		Mode:   mode,
+	}, &result)
+	return &result, err
+}
+
+// List the entries at or beneath {key}; every entry if {key} is empty.
+// Entries beneath {key} which have children of their own are listed
+// without a value.
+func (l *Lightning) ListDatastore(key []string) ([]*DatastoreEntry, error) {
+	var result struct {
+		Datastore []*DatastoreEntry `json:"datastore"`
+	}
+	err := l.rpc.Request(&ListDatastoreRequest{Key: key}, &result)
+	return result.Datastore, err
+}
+
+// Fetch the entry stored at exactly {key}, or nil if there isn't one
+func (l *Lightning) GetDatastore(key []string) (*DatastoreEntry, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("Must provide a datastore key")
+	}
+	entries, err := l.ListDatastore(key)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if sameKey(entry.Key, key) {
+			return entry, nil
+		}
+	}
+	return nil, nil
+}
+
+func sameKey(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove the entry at {key}, returning what was stored there
+func (l *Lightning) DelDatastore(key []string) (*DatastoreEntry, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("Must provide a datastore key")
+	}
+
+	var result DatastoreEntry
+	err := l.rpc.Request(&DelDatastoreRequest{Key: key}, &result)
+	return &result, err
+}
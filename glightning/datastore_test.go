@@ -0,0 +1,79 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDatastore(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"datastore","params":{"key":["myplugin","state"],"mode":"create-or-replace","string":"hello"},"id":1}`
+	resp := wrapResult(1, `{
+   "key": ["myplugin", "state"],
+   "generation": 1,
+   "hex": "68656c6c6f",
+   "string": "hello"
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	entry, err := lightning.SetDatastore([]string{"myplugin", "state"}, "hello", glightning.DatastoreCreateOrReplace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.DatastoreEntry{
+		Key:        []string{"myplugin", "state"},
+		Generation: 1,
+		Hex:        "68656c6c6f",
+		String:     "hello",
+	}, entry)
+
+	_, err = lightning.SetDatastore(nil, "hello", "")
+	assert.EqualError(t, err, "Must provide a datastore key")
+}
+
+func TestGetDatastore(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"listdatastore","params":{"key":["myplugin"]},"id":1}`
+	resp := wrapResult(1, `{
+   "datastore": [
+      {
+         "key": ["myplugin"]
+      },
+      {
+         "key": ["myplugin", "state"],
+         "generation": 3,
+         "hex": "0102"
+      }
+   ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	entry, err := lightning.GetDatastore([]string{"myplugin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.DatastoreEntry{Key: []string{"myplugin"}}, entry)
+
+	req = `{"jsonrpc":"2.0","method":"listdatastore","params":{"key":["other"]},"id":2}`
+	go runServerSide(t, req, wrapResult(2, `{"datastore":[]}`), replyQ, requestQ)
+	entry, err = lightning.GetDatastore([]string{"other"})
+	assert.NoError(t, err)
+	assert.Nil(t, entry)
+}
+
+func TestDelDatastore(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"deldatastore","params":{"key":["myplugin","state"]},"id":1}`
+	resp := wrapResult(1, `{
+   "key": ["myplugin", "state"],
+   "generation": 0,
+   "hex": "68656c6c6f",
+   "string": "hello"
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	entry, err := lightning.DelDatastore([]string{"myplugin", "state"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "hello", entry.String)
+}
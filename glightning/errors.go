@@ -0,0 +1,140 @@
+package glightning
+
+import (
+	"fmt"
+)
+
+// A lightningd JSON-RPC error code, usable as a sentinel error:
+//
+//	_, err := ln.PayBolt(bolt11)
+//	if errors.Is(err, glightning.ErrPayInvoiceExpired) {
+//		...
+//	}
+//
+// errors.Is matches any *jrpc2.RpcError (or error wrapping one, such
+// as a PaymentError) with the same code. Use errors.As to get at the
+// RpcError itself, for its message and data.
+type ErrorCode int
+
+const (
+	// The command doesn't exist, e.g. on an older lightningd; useful
+	// for detecting what a node supports
+	ErrUnknownCommand ErrorCode = -32601
+	ErrInvalidParams  ErrorCode = -32602
+	// lightningd's catch-all
+	ErrLightningd ErrorCode = -1
+
+	ErrPayInProgress            ErrorCode = 200
+	ErrPayRhashAlreadyUsed      ErrorCode = 201
+	ErrPayUnparseableOnion      ErrorCode = 202
+	ErrPayDestinationPermFail   ErrorCode = 203
+	ErrPayTryOtherRoute         ErrorCode = 204
+	ErrPayRouteNotFound         ErrorCode = 205
+	ErrPayRouteTooExpensive     ErrorCode = 206
+	ErrPayInvoiceExpired        ErrorCode = 207
+	ErrPayNoSuchPayment         ErrorCode = 208
+	ErrPayUnspecifiedError      ErrorCode = 209
+	ErrPayStoppedRetrying       ErrorCode = 210
+	ErrPayStatusUnexpected      ErrorCode = 211
+	ErrPayInvoiceRequestInvalid ErrorCode = 212
+	ErrPayOfferInvalid          ErrorCode = 214
+
+	ErrFundingMaxExceeded      ErrorCode = 300
+	ErrFundingCannotAfford     ErrorCode = 301
+	ErrFundingOutputIsDust     ErrorCode = 302
+	ErrFundingBroadcastFail    ErrorCode = 303
+	ErrFundingStillSyncing     ErrorCode = 304
+	ErrFundingPeerNotConnected ErrorCode = 305
+	ErrFundingUnknownPeer      ErrorCode = 306
+	ErrFundingNothingToCancel  ErrorCode = 307
+	ErrFundingCancelNotSafe    ErrorCode = 308
+	ErrFundingPsbtInvalid      ErrorCode = 309
+	ErrFundingV2NotSupported   ErrorCode = 310
+	ErrFundingUnknownChannel   ErrorCode = 311
+	ErrFundingStateInvalid     ErrorCode = 312
+	ErrFundingFeeTooLow        ErrorCode = 313
+
+	ErrConnectNoKnownAddress     ErrorCode = 400
+	ErrConnectAllAddressesFailed ErrorCode = 401
+
+	ErrInvoiceLabelExists       ErrorCode = 900
+	ErrInvoicePreimageExists    ErrorCode = 901
+	ErrInvoiceExpiredDuringWait ErrorCode = 903
+	ErrInvoiceWaitTimedOut      ErrorCode = 904
+	ErrInvoiceNotFound          ErrorCode = 905
+
+	ErrDatastoreDelDoesNotExist    ErrorCode = 1200
+	ErrDatastoreDelWrongGeneration ErrorCode = 1201
+	ErrDatastoreAlreadyExists      ErrorCode = 1202
+	ErrDatastoreDoesNotExist       ErrorCode = 1203
+	// The entry changed since it was read: its generation no longer
+	// matches the one given
+	ErrDatastoreWrongGeneration ErrorCode = 1204
+	ErrDatastoreHasChildren     ErrorCode = 1205
+	ErrDatastoreNoChildren      ErrorCode = 1206
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	ErrUnknownCommand: "unknown command",
+	ErrInvalidParams:  "invalid parameters",
+	ErrLightningd:     "lightningd error",
+
+	ErrPayInProgress:            "payment in progress",
+	ErrPayRhashAlreadyUsed:      "payment hash already used",
+	ErrPayUnparseableOnion:      "unparseable onion reply",
+	ErrPayDestinationPermFail:   "permanent failure at destination",
+	ErrPayTryOtherRoute:         "try another route",
+	ErrPayRouteNotFound:         "route not found",
+	ErrPayRouteTooExpensive:     "route too expensive",
+	ErrPayInvoiceExpired:        "invoice expired",
+	ErrPayNoSuchPayment:         "no such payment",
+	ErrPayUnspecifiedError:      "unspecified payment error",
+	ErrPayStoppedRetrying:       "stopped retrying payment",
+	ErrPayStatusUnexpected:      "unexpected payment status",
+	ErrPayInvoiceRequestInvalid: "invalid invoice request",
+	ErrPayOfferInvalid:          "invalid offer",
+
+	ErrFundingMaxExceeded:      "funding maximum exceeded",
+	ErrFundingCannotAfford:     "cannot afford funding",
+	ErrFundingOutputIsDust:     "funding output is dust",
+	ErrFundingBroadcastFail:    "funding broadcast failed",
+	ErrFundingStillSyncing:     "still syncing with bitcoin",
+	ErrFundingPeerNotConnected: "peer not connected",
+	ErrFundingUnknownPeer:      "unknown peer",
+	ErrFundingNothingToCancel:  "nothing to cancel",
+	ErrFundingCancelNotSafe:    "cancel not safe",
+	ErrFundingPsbtInvalid:      "invalid funding psbt",
+	ErrFundingV2NotSupported:   "dual funding not supported",
+	ErrFundingUnknownChannel:   "unknown channel",
+	ErrFundingStateInvalid:     "invalid channel state",
+	ErrFundingFeeTooLow:        "funding fee too low",
+
+	ErrConnectNoKnownAddress:     "no known address",
+	ErrConnectAllAddressesFailed: "all addresses failed",
+
+	ErrInvoiceLabelExists:       "invoice label already exists",
+	ErrInvoicePreimageExists:    "invoice preimage already exists",
+	ErrInvoiceExpiredDuringWait: "invoice expired while waiting",
+	ErrInvoiceWaitTimedOut:      "timed out waiting for invoice",
+	ErrInvoiceNotFound:          "invoice not found",
+
+	ErrDatastoreDelDoesNotExist:    "datastore entry to delete does not exist",
+	ErrDatastoreDelWrongGeneration: "datastore entry to delete has changed",
+	ErrDatastoreAlreadyExists:      "datastore entry already exists",
+	ErrDatastoreDoesNotExist:       "datastore entry does not exist",
+	ErrDatastoreWrongGeneration:    "datastore entry has changed",
+	ErrDatastoreHasChildren:        "datastore entry has children",
+	ErrDatastoreNoChildren:         "datastore entry has no children",
+}
+
+func (c ErrorCode) Error() string {
+	if name, ok := errorCodeNames[c]; ok {
+		return fmt.Sprintf("%d: %s", int(c), name)
+	}
+	return fmt.Sprintf("lightningd error %d", int(c))
+}
+
+// The code errors.Is matches a *jrpc2.RpcError on
+func (c ErrorCode) RpcErrorCode() int {
+	return int(c)
+}
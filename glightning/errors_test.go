@@ -0,0 +1,35 @@
+package glightning_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCodes(t *testing.T) {
+	lightning, requestQ, replyQ := startupServer(t)
+	req := `{"jsonrpc":"2.0","method":"pay","params":{"bolt11":"lnbcrt1"},"id":1}`
+	resp := wrapError(1, 207, "Invoice expired", `{}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err := lightning.PayBolt("lnbcrt1")
+	assert.True(t, errors.Is(err, glightning.ErrPayInvoiceExpired))
+	assert.False(t, errors.Is(err, glightning.ErrPayRouteNotFound))
+
+	wrapped := fmt.Errorf("paying: %w", err)
+	assert.True(t, errors.Is(wrapped, glightning.ErrPayInvoiceExpired))
+
+	req = `{"jsonrpc":"2.0","method":"getinfo","params":{},"id":2}`
+	resp = wrapError(2, -32601, "Unknown command 'getinfo'", `null`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err = lightning.GetInfo()
+	assert.True(t, errors.Is(err, glightning.ErrUnknownCommand))
+
+	assert.True(t, errors.Is(&jrpc2.RpcError{Code: 1204}, glightning.ErrDatastoreWrongGeneration))
+	assert.False(t, errors.Is(errors.New("1204"), glightning.ErrDatastoreWrongGeneration))
+	assert.EqualError(t, glightning.ErrInvoiceWaitTimedOut, "904: timed out waiting for invoice")
+	assert.EqualError(t, glightning.ErrorCode(999), "lightningd error 999")
+}
@@ -0,0 +1,206 @@
+package glightning
+
+import (
+	"sync"
+)
+
+// The kinds of notification an Events bus can carry. Each matches
+// the name of the lightningd notification it's built from.
+type EventKind string
+
+const (
+	EventConnect             EventKind = EventKind(_Connect)
+	EventDisconnect          EventKind = EventKind(_Disconnect)
+	EventInvoicePaid         EventKind = EventKind(_InvoicePaid)
+	EventChannelOpened       EventKind = EventKind(_ChannelOpened)
+	EventChannelStateChanged EventKind = EventKind(_ChannelState)
+	EventWarning             EventKind = EventKind(_Warning)
+	EventForward             EventKind = EventKind(_Forward)
+	EventSendPaySuccess      EventKind = EventKind(_SendPaySuccess)
+	EventSendPayFailure      EventKind = EventKind(_SendPayFailure)
+	EventBlockAdded          EventKind = EventKind(_BlockAdded)
+)
+
+// Every kind of event, in the order they're subscribed to
+var AllEventKinds = []EventKind{
+	EventConnect,
+	EventDisconnect,
+	EventInvoicePaid,
+	EventChannelOpened,
+	EventChannelStateChanged,
+	EventWarning,
+	EventForward,
+	EventSendPaySuccess,
+	EventSendPayFailure,
+	EventBlockAdded,
+}
+
+// A single notification, as delivered on an EventSubscription.
+// Payload's type depends on Kind:
+//
+//	EventConnect             *ConnectEvent
+//	EventDisconnect          *DisconnectEvent
+//	EventInvoicePaid         *Payment
+//	EventChannelOpened       *ChannelOpened
+//	EventChannelStateChanged *ChannelStateChanged
+//	EventWarning             *Warning
+//	EventForward             *Forwarding
+//	EventSendPaySuccess      *SendPaySuccess
+//	EventSendPayFailure      *SendPayFailure
+//	EventBlockAdded          *BlockAdded
+type Event struct {
+	Kind    EventKind
+	Payload interface{}
+}
+
+// Decides whether an event is delivered to a subscription
+type EventFilter func(*Event) bool
+
+// A filter which passes only events of the given kinds
+func OnlyKinds(kinds ...EventKind) EventFilter {
+	set := make(map[EventKind]bool, len(kinds))
+	for _, kind := range kinds {
+		set[kind] = true
+	}
+	return func(e *Event) bool {
+		return set[e.Kind]
+	}
+}
+
+// Events multiplexes a plugin's notifications onto Go channels.
+// Rather than registering a callback per notification, create an
+// Events bus for the plugin once and Subscribe to it wherever
+// notifications are needed, filtering for the ones you care about.
+type Events struct {
+	mu   sync.Mutex
+	subs map[*EventSubscription]bool
+}
+
+// Subscribe {plugin} to the given notification kinds (all of them
+// if none are given) and return a bus which fans them out.
+// Like the other Subscribe* methods, this must be called before
+// the plugin is started, and at most once per plugin.
+func NewEvents(plugin *Plugin, kinds ...EventKind) *Events {
+	events := &Events{
+		subs: make(map[*EventSubscription]bool),
+	}
+	if len(kinds) == 0 {
+		kinds = AllEventKinds
+	}
+	for _, kind := range kinds {
+		events.subscribeTo(plugin, kind)
+	}
+	return events
+}
+
+func (e *Events) subscribeTo(p *Plugin, kind EventKind) {
+	switch kind {
+	case EventConnect:
+		p.SubscribeConnect(func(c *ConnectEvent) { e.Publish(kind, c) })
+	case EventDisconnect:
+		p.SubscribeDisconnect(func(d *DisconnectEvent) { e.Publish(kind, d) })
+	case EventInvoicePaid:
+		p.SubscribeInvoicePaid(func(pay *Payment) { e.Publish(kind, pay) })
+	case EventChannelOpened:
+		p.SubscribeChannelOpened(func(c *ChannelOpened) { e.Publish(kind, c) })
+	case EventChannelStateChanged:
+		p.SubscribeChannelStateChanged(func(c *ChannelStateChanged) { e.Publish(kind, c) })
+	case EventWarning:
+		p.SubscribeWarnings(func(w *Warning) { e.Publish(kind, w) })
+	case EventForward:
+		p.SubscribeForwardings(func(f *Forwarding) { e.Publish(kind, f) })
+	case EventSendPaySuccess:
+		p.SubscribeSendPaySuccess(func(s *SendPaySuccess) { e.Publish(kind, s) })
+	case EventSendPayFailure:
+		p.SubscribeSendPayFailure(func(f *SendPayFailure) { e.Publish(kind, f) })
+	case EventBlockAdded:
+		p.SubscribeBlockAdded(func(b *BlockAdded) { e.Publish(kind, b) })
+	}
+}
+
+// Register a new subscription. Events passing {filter} (all
+// events, if it's nil) are sent on the subscription's channel,
+// which holds up to {buffer} undelivered events. Delivery blocks
+// while the buffer is full, so keep reading until you Close it.
+func (e *Events) Subscribe(buffer int, filter EventFilter) *EventSubscription {
+	c := make(chan *Event, buffer)
+	sub := &EventSubscription{
+		C:      c,
+		c:      c,
+		done:   make(chan struct{}),
+		filter: filter,
+		events: e,
+	}
+	e.mu.Lock()
+	e.subs[sub] = true
+	e.mu.Unlock()
+	return sub
+}
+
+// Send an event to every matching subscription. Called for each
+// notification the plugin receives; may also be used to inject
+// events of your own.
+func (e *Events) Publish(kind EventKind, payload interface{}) {
+	event := &Event{Kind: kind, Payload: payload}
+
+	e.mu.Lock()
+	subs := make([]*EventSubscription, 0, len(e.subs))
+	for sub := range e.subs {
+		subs = append(subs, sub)
+	}
+	e.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.deliver(event)
+	}
+}
+
+// Number of open subscriptions
+func (e *Events) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.subs)
+}
+
+type EventSubscription struct {
+	// Matching events are delivered here. Closed by Close.
+	C <-chan *Event
+
+	c      chan *Event
+	done   chan struct{}
+	once   sync.Once
+	lock   sync.RWMutex
+	filter EventFilter
+	events *Events
+}
+
+func (s *EventSubscription) deliver(event *Event) {
+	if s.filter != nil && !s.filter(event) {
+		return
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	select {
+	case <-s.done:
+	default:
+		select {
+		case s.c <- event:
+		case <-s.done:
+		}
+	}
+}
+
+// Stop delivering events and close C. Safe to call more than once.
+func (s *EventSubscription) Close() {
+	s.once.Do(func() {
+		s.events.mu.Lock()
+		delete(s.events.subs, s)
+		s.events.mu.Unlock()
+
+		close(s.done)
+		// wait out any deliveries in progress before closing
+		s.lock.Lock()
+		close(s.c)
+		s.lock.Unlock()
+	})
+}
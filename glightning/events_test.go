@@ -0,0 +1,104 @@
+package glightning_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func receiveEvent(t *testing.T, sub *glightning.EventSubscription) *glightning.Event {
+	select {
+	case event := <-sub.C:
+		return event
+	case <-time.After(1 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return nil
+}
+
+func TestEvents_Notifications(t *testing.T) {
+	plugin := glightning.NewPlugin(nullInitFunc)
+	events := glightning.NewEvents(plugin)
+	sub := events.Subscribe(2, nil)
+	defer sub.Close()
+
+	blocks := `{"jsonrpc":"2.0","method":"block_added","params":{"block_added":{"hash":"0000000000000000000b34f1f1e1d7c0c3a4a27f08fc1c83a2bc4e7f0a3f4c11","height":850000}}}`
+	states := `{"jsonrpc":"2.0","method":"channel_state_changed","params":{"channel_state_changed":{"peer_id":"02c0114aac5ea2bce7759eb48d5aa75129700c1eb7fe6cc8743968a202f26505d6","channel_id":"a3b1c9d2e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1","short_channel_id":"103x1x0","timestamp":"2024-05-01T12:00:00.000Z","old_state":"CHANNELD_AWAITING_LOCKIN","new_state":"CHANNELD_NORMAL","cause":"user","message":"Lockin complete"}}}`
+
+	runTest(t, plugin, blocks+"\n\n"+states+"\n\n", "")
+
+	got := map[glightning.EventKind]interface{}{}
+	for i := 0; i < 2; i++ {
+		event := receiveEvent(t, sub)
+		got[event.Kind] = event.Payload
+	}
+
+	assert.Equal(t, &glightning.BlockAdded{
+		Hash:   "0000000000000000000b34f1f1e1d7c0c3a4a27f08fc1c83a2bc4e7f0a3f4c11",
+		Height: 850000,
+	}, got[glightning.EventBlockAdded])
+	assert.Equal(t, &glightning.ChannelStateChanged{
+		PeerId:         "02c0114aac5ea2bce7759eb48d5aa75129700c1eb7fe6cc8743968a202f26505d6",
+		ChannelId:      "a3b1c9d2e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1",
+		ShortChannelId: "103x1x0",
+		Timestamp:      "2024-05-01T12:00:00.000Z",
+		OldState:       "CHANNELD_AWAITING_LOCKIN",
+		NewState:       "CHANNELD_NORMAL",
+		Cause:          "user",
+		Message:        "Lockin complete",
+	}, got[glightning.EventChannelStateChanged])
+}
+
+func TestEvents_Manifest(t *testing.T) {
+	plugin := glightning.NewPlugin(nullInitFunc)
+	glightning.NewEvents(plugin, glightning.EventForward, glightning.EventBlockAdded)
+
+	msg := "{\"jsonrpc\":\"2.0\",\"method\":\"getmanifest\",\"id\":\"aloha\"}\n\n"
+	resp := "{\"jsonrpc\":\"2.0\",\"result\":{\"options\":[],\"rpcmethods\":[],\"dynamic\":true,\"subscriptions\":[\"forward_event\",\"block_added\"],\"featurebits\":{}},\"id\":\"aloha\"}"
+	runTest(t, plugin, msg, resp)
+}
+
+func TestEvents_Filter(t *testing.T) {
+	events := glightning.NewEvents(glightning.NewPlugin(nullInitFunc))
+	blocks := events.Subscribe(1, glightning.OnlyKinds(glightning.EventBlockAdded))
+	defer blocks.Close()
+	all := events.Subscribe(2, nil)
+	defer all.Close()
+
+	events.Publish(glightning.EventWarning, &glightning.Warning{Log: "oops"})
+	events.Publish(glightning.EventBlockAdded, &glightning.BlockAdded{Height: 1})
+
+	event := receiveEvent(t, blocks)
+	assert.Equal(t, glightning.EventBlockAdded, event.Kind)
+	assert.Equal(t, uint32(1), event.Payload.(*glightning.BlockAdded).Height)
+
+	assert.Equal(t, glightning.EventWarning, receiveEvent(t, all).Kind)
+	assert.Equal(t, glightning.EventBlockAdded, receiveEvent(t, all).Kind)
+}
+
+func TestEvents_Close(t *testing.T) {
+	events := glightning.NewEvents(glightning.NewPlugin(nullInitFunc))
+	sub := events.Subscribe(0, nil)
+	assert.Equal(t, 1, events.Len())
+
+	// an unbuffered, unread subscription blocks delivery until closed
+	published := make(chan struct{})
+	go func() {
+		events.Publish(glightning.EventBlockAdded, &glightning.BlockAdded{Height: 1})
+		close(published)
+	}()
+
+	sub.Close()
+	sub.Close()
+	select {
+	case <-published:
+	case <-time.After(1 * time.Second):
+		t.Fatal("publish still blocked after close")
+	}
+	assert.Equal(t, 0, events.Len())
+
+	_, open := <-sub.C
+	assert.False(t, open)
+}
@@ -0,0 +1,285 @@
+// Package exporter is a plugin which publishes node metrics for
+// Prometheus to scrape: balances, channel counts by state, peer
+// connectivity, forwards and the fees earned from them.
+//
+// It can be run as a plugin on its own:
+//
+//	func main() {
+//		e := exporter.New()
+//		plugin := glightning.NewPlugin(e.Init)
+//		e.Register(plugin)
+//		log.Fatal(plugin.Start(os.Stdin, os.Stdout))
+//	}
+//
+// and serves metrics at http://<exporter-listen>/metrics.
+//
+// Balances and counts are read from lightningd on each scrape; the
+// forward, connection and channel state counters are kept from
+// notifications, and so count from when the plugin started.
+package exporter
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+)
+
+// The plugin option naming the address to serve metrics on
+const ListenOption = "exporter-listen"
+
+const DefaultListen = "127.0.0.1:9750"
+
+type Exporter struct {
+	client   glightning.LightningClient
+	server   *http.Server
+	listener net.Listener
+
+	mu              sync.Mutex
+	forwards        map[string]uint64
+	forwardedMsat   uint64
+	forwardFeesMsat uint64
+	connects        uint64
+	disconnects     uint64
+	stateChanges    map[string]uint64
+}
+
+func New() *Exporter {
+	return &Exporter{
+		forwards:     make(map[string]uint64),
+		stateChanges: make(map[string]uint64),
+	}
+}
+
+// Register the listen option, and subscribe to the notifications the
+// counters are kept from: connect, disconnect, forward_event and
+// channel_state_changed.
+//
+// A plugin can only subscribe to each notification once, so if
+// yours subscribes to any of these itself, don't call Register;
+// register the option and pass the notifications on to OnConnect etc
+// from your own handlers instead.
+func (e *Exporter) Register(plugin *glightning.Plugin) error {
+	err := plugin.RegisterNewOption(ListenOption, "Address to serve Prometheus metrics on", DefaultListen)
+	if err != nil {
+		return err
+	}
+	plugin.SubscribeConnect(e.OnConnect)
+	plugin.SubscribeDisconnect(e.OnDisconnect)
+	plugin.SubscribeForwardings(e.OnForward)
+	plugin.SubscribeChannelStateChanged(e.OnChannelStateChanged)
+	return nil
+}
+
+// A plugin init handler, which connects to lightningd and starts
+// serving on the address set by the listen option
+func (e *Exporter) Init(plugin *glightning.Plugin, options map[string]glightning.Option, config *glightning.Config) {
+	lightning := glightning.NewLightning()
+	if err := lightning.StartUp(config.RpcFile, config.LightningDir); err != nil {
+		plugin.Log("exporter: unable to connect to lightningd: "+err.Error(), glightning.Unusual)
+		return
+	}
+	addr, err := plugin.GetOption(ListenOption)
+	if err != nil || addr == "" {
+		addr = DefaultListen
+	}
+	if err := e.Start(lightning, addr); err != nil {
+		plugin.Log("exporter: "+err.Error(), glightning.Unusual)
+		return
+	}
+	plugin.Log("exporter: serving metrics on http://"+addr+"/metrics", glightning.Info)
+}
+
+// Serve metrics read from {client} on {addr}
+func (e *Exporter) Start(client glightning.LightningClient, addr string) error {
+	e.client = client
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	e.listener = listener
+	e.server = &http.Server{Handler: mux}
+	go e.server.Serve(listener)
+	return nil
+}
+
+// The address being served on, once started
+func (e *Exporter) Addr() string {
+	if e.listener == nil {
+		return ""
+	}
+	return e.listener.Addr().String()
+}
+
+func (e *Exporter) Stop() error {
+	if e.server == nil {
+		return nil
+	}
+	return e.server.Close()
+}
+
+// Set the client metrics are read from, for use with ServeHTTP
+// without Start
+func (e *Exporter) SetClient(client glightning.LightningClient) {
+	e.client = client
+}
+
+func (e *Exporter) OnConnect(event *glightning.ConnectEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.connects++
+}
+
+func (e *Exporter) OnDisconnect(event *glightning.DisconnectEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.disconnects++
+}
+
+// Forwards are notified when offered, then again when settled or
+// failed; amounts and fees are counted once settled
+func (e *Exporter) OnForward(forward *glightning.Forwarding) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.forwards[forward.Status]++
+	if forward.Status != "settled" {
+		return
+	}
+	e.forwardedMsat += msat(forward.MilliSatoshiOut, forward.OutMsat)
+	e.forwardFeesMsat += msat(forward.Fee, forward.FeeMsat)
+}
+
+func (e *Exporter) OnChannelStateChanged(event *glightning.ChannelStateChanged) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stateChanges[event.NewState]++
+}
+
+func msat(raw uint64, amount string) uint64 {
+	if raw != 0 || amount == "" {
+		return raw
+	}
+	parsed, err := glightning.ParseMSat(amount)
+	if err != nil {
+		return 0
+	}
+	return parsed.Value
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := e.WriteMetrics(&buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(buf.Bytes())
+}
+
+// Write every metric, in the Prometheus text format. If lightningd
+// can't be reached only lightning_up (as 0) and the counters are
+// written.
+func (e *Exporter) WriteMetrics(w io.Writer) error {
+	m := &metricWriter{w: w}
+	start := time.Now()
+	up := 0.0
+	if e.writeNodeMetrics(m) {
+		up = 1
+	}
+	m.single("lightning_up", "Whether lightningd answered the scrape", "gauge", up)
+	e.writeCounters(m)
+	m.single("lightning_scrape_duration_seconds", "How long reading metrics from lightningd took", "gauge", time.Since(start).Seconds())
+	return m.err
+}
+
+// Returns false if lightningd couldn't be reached. Everything is read
+// before anything is written, so a failed scrape writes nothing.
+func (e *Exporter) writeNodeMetrics(m *metricWriter) bool {
+	if e.client == nil {
+		return false
+	}
+	info, err := e.client.GetInfo()
+	if err != nil {
+		return false
+	}
+	peers, err := e.client.ListPeers()
+	if err != nil {
+		return false
+	}
+	funds, err := e.client.ListFunds()
+	if err != nil {
+		return false
+	}
+
+	m.family("lightning_node_info", "The node's id, alias and version", "gauge")
+	m.sample("lightning_node_info", map[string]string{
+		"id":      info.Id,
+		"alias":   info.Alias,
+		"version": info.Version,
+		"network": info.Network,
+	}, 1)
+	m.single("lightning_block_height", "The block height lightningd has processed", "gauge", float64(info.Blockheight))
+	m.single("lightning_fees_collected_msat", "Fees earned forwarding, over the node's lifetime", "counter",
+		float64(msat(info.FeesCollectedMilliSatoshis, info.FeesCollected)))
+
+	connected := 0
+	for _, peer := range peers {
+		if peer.Connected {
+			connected++
+		}
+	}
+	m.family("lightning_peers", "Peers, by whether they're connected", "gauge")
+	m.sample("lightning_peers", map[string]string{"connected": "true"}, float64(connected))
+	m.sample("lightning_peers", map[string]string{"connected": "false"}, float64(len(peers)-connected))
+
+	states := make(map[string]uint64)
+	var ourMsat, capacitySat uint64
+	for _, channel := range funds.Channels {
+		states[channel.State]++
+		if channel.State != "CHANNELD_NORMAL" {
+			continue
+		}
+		ourMsat += msat(channel.ChannelSatoshi*1000, channel.OurAmountMilliSatoshi)
+		capacitySat += msat(channel.ChannelTotalSatoshi*1000, channel.AmountMilliSatoshi) / 1000
+	}
+	m.family("lightning_channels", "Channels, by state", "gauge")
+	for _, state := range sortedKeys(states) {
+		m.sample("lightning_channels", map[string]string{"state": state}, float64(states[state]))
+	}
+	m.single("lightning_channel_balance_msat", "Our balance in normal channels", "gauge", float64(ourMsat))
+	m.single("lightning_channel_capacity_sat", "The total capacity of normal channels", "gauge", float64(capacitySat))
+
+	wallet := make(map[string]uint64)
+	for _, output := range funds.Outputs {
+		wallet[output.Status] += msat(output.Value*1000, output.AmountMilliSatoshi) / 1000
+	}
+	m.family("lightning_wallet_balance_sat", "Onchain wallet funds, by status", "gauge")
+	for _, status := range []string{"confirmed", "unconfirmed"} {
+		m.sample("lightning_wallet_balance_sat", map[string]string{"status": status}, float64(wallet[status]))
+	}
+	return true
+}
+
+func (e *Exporter) writeCounters(m *metricWriter) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	m.family("lightning_forwards_total", "Forward notifications, by status", "counter")
+	for _, status := range sortedKeys(e.forwards) {
+		m.sample("lightning_forwards_total", map[string]string{"status": status}, float64(e.forwards[status]))
+	}
+	m.single("lightning_forwarded_msat_total", "The amount forwarded by settled forwards", "counter", float64(e.forwardedMsat))
+	m.single("lightning_forward_fees_msat_total", "Fees earned by settled forwards", "counter", float64(e.forwardFeesMsat))
+	m.single("lightning_peer_connects_total", "Peer connections", "counter", float64(e.connects))
+	m.single("lightning_peer_disconnects_total", "Peer disconnections", "counter", float64(e.disconnects))
+	m.family("lightning_channel_state_changes_total", "Channel state changes, by the new state", "counter")
+	for _, state := range sortedKeys(e.stateChanges) {
+		m.sample("lightning_channel_state_changes_total", map[string]string{"state": state}, float64(e.stateChanges[state]))
+	}
+}
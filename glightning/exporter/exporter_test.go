@@ -0,0 +1,118 @@
+package exporter_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/exporter"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func nodeMock() *mock.Lightning {
+	ln := mock.New()
+	ln.GetInfoFunc = func() (*glightning.NodeInfo, error) {
+		return &glightning.NodeInfo{
+			Id:            "02aa",
+			Alias:         `"quoted"`,
+			Version:       "v24.08",
+			Network:       "regtest",
+			Blockheight:   812,
+			FeesCollected: "5400msat",
+		}, nil
+	}
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return []*glightning.Peer{{Connected: true}, {Connected: true}, {}}, nil
+	}
+	ln.ListFundsFunc = func() (*glightning.FundsResult, error) {
+		return &glightning.FundsResult{
+			Outputs: []*glightning.FundOutput{
+				{AmountMilliSatoshi: "150000000msat", Status: "confirmed"},
+				{AmountMilliSatoshi: "20000000msat", Status: "confirmed"},
+				{AmountMilliSatoshi: "1000000msat", Status: "unconfirmed"},
+			},
+			Channels: []*glightning.FundingChannel{
+				{State: "CHANNELD_NORMAL", OurAmountMilliSatoshi: "400000000msat", AmountMilliSatoshi: "1000000000msat"},
+				{State: "CHANNELD_NORMAL", OurAmountMilliSatoshi: "100500msat", AmountMilliSatoshi: "500000000msat"},
+				{State: "ONCHAIN", OurAmountMilliSatoshi: "0msat", AmountMilliSatoshi: "200000000msat"},
+			},
+		}, nil
+	}
+	return ln
+}
+
+func TestWriteMetrics(t *testing.T) {
+	e := exporter.New()
+	e.SetClient(nodeMock())
+
+	e.OnConnect(&glightning.ConnectEvent{PeerId: "02bb"})
+	e.OnDisconnect(&glightning.DisconnectEvent{PeerId: "02bb"})
+	e.OnForward(&glightning.Forwarding{Status: "offered", OutMsat: "100000msat", FeeMsat: "1001msat"})
+	e.OnForward(&glightning.Forwarding{Status: "settled", OutMsat: "100000msat", FeeMsat: "1001msat"})
+	e.OnForward(&glightning.Forwarding{Status: "local_failed", OutMsat: "5000msat", FeeMsat: "1msat"})
+	e.OnChannelStateChanged(&glightning.ChannelStateChanged{NewState: "CHANNELD_NORMAL"})
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.WriteMetrics(&buf))
+	out := buf.String()
+
+	for _, line := range []string{
+		`lightning_node_info{alias="\"quoted\"",id="02aa",network="regtest",version="v24.08"} 1`,
+		`lightning_block_height 812`,
+		`lightning_fees_collected_msat 5400`,
+		`lightning_peers{connected="true"} 2`,
+		`lightning_peers{connected="false"} 1`,
+		`lightning_channels{state="CHANNELD_NORMAL"} 2`,
+		`lightning_channels{state="ONCHAIN"} 1`,
+		`lightning_channel_balance_msat 400100500`,
+		`lightning_channel_capacity_sat 1500000`,
+		`lightning_wallet_balance_sat{status="confirmed"} 170000`,
+		`lightning_wallet_balance_sat{status="unconfirmed"} 1000`,
+		`lightning_up 1`,
+		`lightning_forwards_total{status="local_failed"} 1`,
+		`lightning_forwards_total{status="offered"} 1`,
+		`lightning_forwards_total{status="settled"} 1`,
+		`lightning_forwarded_msat_total 100000`,
+		`lightning_forward_fees_msat_total 1001`,
+		`lightning_peer_connects_total 1`,
+		`lightning_peer_disconnects_total 1`,
+		`lightning_channel_state_changes_total{state="CHANNELD_NORMAL"} 1`,
+		`# TYPE lightning_forwards_total counter`,
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+}
+
+func TestWriteMetricsDown(t *testing.T) {
+	ln := nodeMock()
+	ln.ListFundsFunc = func() (*glightning.FundsResult, error) {
+		return nil, errors.New("connection refused")
+	}
+	e := exporter.New()
+	e.SetClient(ln)
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.WriteMetrics(&buf))
+	out := buf.String()
+	assert.Contains(t, out, "lightning_up 0\n")
+	assert.Contains(t, out, "lightning_peer_connects_total 0\n")
+	assert.False(t, strings.Contains(out, "lightning_node_info"))
+}
+
+func TestServe(t *testing.T) {
+	e := exporter.New()
+	assert.NoError(t, e.Start(nodeMock(), "127.0.0.1:0"))
+	defer e.Stop()
+
+	resp, err := http.Get("http://" + e.Addr() + "/metrics")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "lightning_up 1\n")
+}
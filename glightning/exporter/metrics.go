@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Writes metrics in the Prometheus text exposition format
+type metricWriter struct {
+	w   io.Writer
+	err error
+}
+
+// Start the metric family {name}; its samples follow
+func (m *metricWriter) family(name, help, kind string) {
+	m.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (m *metricWriter) sample(name string, labels map[string]string, value float64) {
+	m.printf("%s%s %s\n", name, formatLabels(labels), strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// A family with a single, unlabelled sample
+func (m *metricWriter) single(name, help, kind string, value float64) {
+	m.family(name, help, kind)
+	m.sample(name, nil, value)
+}
+
+func (m *metricWriter) printf(format string, args ...interface{}) {
+	if m.err != nil {
+		return
+	}
+	_, m.err = fmt.Fprintf(m.w, format, args...)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(labels[name]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// The keys of {counts}, sorted, so output is stable
+func sortedKeys(counts map[string]uint64) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
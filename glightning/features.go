@@ -0,0 +1,182 @@
+package glightning
+
+import (
+	"encoding/hex"
+	"sort"
+)
+
+// A BOLT#9 feature bit. Features come in pairs: the even bit means
+// the feature is required ("it's OK to be odd"), the odd bit means
+// it's optional.
+type FeatureBit int
+
+const (
+	FeatureDataLossProtect    FeatureBit = 0
+	FeatureUpfrontShutdown    FeatureBit = 4
+	FeatureGossipQueries      FeatureBit = 6
+	FeatureVarOnion           FeatureBit = 8
+	FeatureGossipQueriesEx    FeatureBit = 10
+	FeatureStaticRemoteKey    FeatureBit = 12
+	FeaturePaymentSecret      FeatureBit = 14
+	FeatureBasicMpp           FeatureBit = 16
+	FeatureLargeChannels      FeatureBit = 18
+	FeatureAnchorOutputs      FeatureBit = 20
+	FeatureAnchorsZeroFeeHtlc FeatureBit = 22
+	FeatureRouteBlinding      FeatureBit = 24
+	FeatureShutdownAnySegwit  FeatureBit = 26
+	FeatureDualFund           FeatureBit = 28
+	FeatureQuiesce            FeatureBit = 34
+	FeatureOnionMessages      FeatureBit = 38
+	FeatureChannelType        FeatureBit = 44
+	FeatureScidAlias          FeatureBit = 46
+	FeaturePaymentMetadata    FeatureBit = 48
+	FeatureZeroConf           FeatureBit = 50
+)
+
+func (b FeatureBit) IsOdd() bool {
+	return b%2 == 1
+}
+
+func (b FeatureBit) IsEven() bool {
+	return b%2 == 0
+}
+
+// The even (required) bit of this bit's pair
+func (b FeatureBit) Required() FeatureBit {
+	return b &^ 1
+}
+
+// The odd (optional) bit of this bit's pair
+func (b FeatureBit) Optional() FeatureBit {
+	return b | 1
+}
+
+// A feature bitfield, as returned hex-encoded by listpeers, listnodes,
+// connect and decodepay. Big-endian: bit 0 is the lowest bit of the
+// last byte.
+type Features []byte
+
+func ParseFeatures(hexstr string) (Features, error) {
+	raw, err := hex.DecodeString(hexstr)
+	if err != nil {
+		return nil, err
+	}
+	return Features(raw), nil
+}
+
+// Interpret the hex blob as a feature bitfield. Safe to call on nil.
+func (h *Hexed) Features() Features {
+	if h == nil {
+		return nil
+	}
+	return Features(h.Raw)
+}
+
+func (f Features) String() string {
+	return hex.EncodeToString(f)
+}
+
+// Whether exactly bit {bit} is set
+func (f Features) IsSet(bit FeatureBit) bool {
+	if bit < 0 {
+		return false
+	}
+	idx := len(f) - 1 - int(bit)/8
+	if idx < 0 {
+		return false
+	}
+	return f[idx]&(1<<(uint(bit)%8)) != 0
+}
+
+// Whether the feature is offered at all, i.e. either
+// the required or the optional bit is set
+func (f Features) Supports(bit FeatureBit) bool {
+	return f.IsSet(bit.Required()) || f.IsSet(bit.Optional())
+}
+
+// Whether the feature's required (even) bit is set
+func (f Features) Requires(bit FeatureBit) bool {
+	return f.IsSet(bit.Required())
+}
+
+// All set bits, in ascending order
+func (f Features) Bits() []FeatureBit {
+	var bits []FeatureBit
+	for i := range f {
+		b := f[len(f)-1-i]
+		for j := 0; j < 8; j++ {
+			if b&(1<<uint(j)) != 0 {
+				bits = append(bits, FeatureBit(i*8+j))
+			}
+		}
+	}
+	sort.Slice(bits, func(i, j int) bool { return bits[i] < bits[j] })
+	return bits
+}
+
+// Any even bits set that aren't in {known}. A peer requiring one of
+// these is one we can't talk to.
+func (f Features) UnknownRequired(known []FeatureBit) []FeatureBit {
+	var unknown []FeatureBit
+	for _, bit := range f.Bits() {
+		if bit.IsOdd() {
+			continue
+		}
+		found := false
+		for _, k := range known {
+			if k.Required() == bit {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, bit)
+		}
+	}
+	return unknown
+}
+
+func (f Features) SupportsMPP() bool {
+	return f.Supports(FeatureBasicMpp)
+}
+
+func (f Features) SupportsVarOnion() bool {
+	return f.Supports(FeatureVarOnion)
+}
+
+func (f Features) SupportsPaymentSecret() bool {
+	return f.Supports(FeaturePaymentSecret)
+}
+
+func (f Features) SupportsStaticRemoteKey() bool {
+	return f.Supports(FeatureStaticRemoteKey)
+}
+
+// Either flavor of anchor outputs
+func (f Features) SupportsAnchors() bool {
+	return f.Supports(FeatureAnchorsZeroFeeHtlc) || f.Supports(FeatureAnchorOutputs)
+}
+
+func (f Features) SupportsDualFund() bool {
+	return f.Supports(FeatureDualFund)
+}
+
+func (f Features) SupportsLargeChannels() bool {
+	return f.Supports(FeatureLargeChannels)
+}
+
+func (f Features) SupportsOnionMessages() bool {
+	return f.Supports(FeatureOnionMessages)
+}
+
+func (f Features) SupportsRouteBlinding() bool {
+	return f.Supports(FeatureRouteBlinding)
+}
+
+func (f Features) SupportsScidAlias() bool {
+	return f.Supports(FeatureScidAlias)
+}
+
+func (f Features) SupportsZeroConf() bool {
+	return f.Supports(FeatureZeroConf)
+}
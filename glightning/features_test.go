@@ -0,0 +1,55 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFeatures(t *testing.T) {
+	// a typical c-lightning node
+	features, err := glightning.ParseFeatures("08a0000a8a5961")
+	assert.NoError(t, err)
+	assert.Equal(t, "08a0000a8a5961", features.String())
+
+	assert.Equal(t, []glightning.FeatureBit{0, 5, 6, 8, 11, 12, 14, 17, 19, 23, 25, 27, 45, 47, 51}, features.Bits())
+	assert.True(t, features.IsSet(14))
+	assert.False(t, features.IsSet(15))
+	assert.True(t, features.SupportsMPP())
+	assert.True(t, features.SupportsPaymentSecret())
+	assert.True(t, features.Requires(glightning.FeaturePaymentSecret))
+	assert.False(t, features.Requires(glightning.FeatureBasicMpp))
+	assert.True(t, features.SupportsAnchors())
+	assert.True(t, features.SupportsScidAlias())
+	assert.True(t, features.SupportsZeroConf())
+	assert.False(t, features.SupportsDualFund())
+	assert.False(t, features.IsSet(400))
+
+	_, err = glightning.ParseFeatures("zz")
+	assert.Error(t, err)
+}
+
+func TestFeatureBitParity(t *testing.T) {
+	assert.True(t, glightning.FeatureBit(17).IsOdd())
+	assert.False(t, glightning.FeatureBit(17).IsEven())
+	assert.Equal(t, glightning.FeatureBit(16), glightning.FeatureBit(17).Required())
+	assert.Equal(t, glightning.FeatureBit(29), glightning.FeatureDualFund.Optional())
+}
+
+func TestUnknownRequiredFeatures(t *testing.T) {
+	// bits 14 (known) and 100 (unknown, required)
+	features := make(glightning.Features, 13)
+	features[0] = 0x10
+	features[11] = 0x40
+	assert.Equal(t, []glightning.FeatureBit{100}, features.UnknownRequired([]glightning.FeatureBit{glightning.FeaturePaymentSecret}))
+}
+
+func TestHexedFeatures(t *testing.T) {
+	var nilHex *glightning.Hexed
+	assert.Nil(t, nilHex.Features())
+
+	h, err := glightning.NewHex("0200")
+	assert.NoError(t, err)
+	assert.True(t, h.Features().SupportsVarOnion())
+}
@@ -0,0 +1,281 @@
+package glightning
+
+import (
+	"sync"
+	"time"
+)
+
+// What a FeePolicy knows about a channel when setting its fees
+type ChannelFees struct {
+	PeerId         string
+	ChannelId      string
+	ShortChannelId string
+	CapacityMsat   uint64
+	LocalMsat      uint64
+	// LocalMsat over CapacityMsat: 0 when the channel is depleted,
+	// 1 when all the funds are on our side
+	LocalRatio float64
+	// The channel's current fees
+	BaseMsat uint64
+	PPM      uint32
+	// Forwards out over the channel within the FeeManager's History;
+	// empty if there were none
+	Revenue *ChannelRevenue
+}
+
+// Decides the fees a channel should have. The FeeManager limits how
+// far the fees move towards them in each adjustment.
+type FeePolicy interface {
+	Fees(c *ChannelFees) (baseMsat uint64, ppm uint32)
+}
+
+type FeePolicyFunc func(c *ChannelFees) (baseMsat uint64, ppm uint32)
+
+func (f FeePolicyFunc) Fees(c *ChannelFees) (uint64, uint32) {
+	return f(c)
+}
+
+// Charges more the more depleted a channel is: MinPPM when all its
+// funds are on our side, rising linearly to MaxPPM when none are.
+// Discourages routing out of channels which have little left to
+// route, and encourages routing out of those with plenty.
+type BalanceFeePolicy struct {
+	BaseMsat uint64
+	MinPPM   uint32
+	MaxPPM   uint32
+}
+
+func (p *BalanceFeePolicy) Fees(c *ChannelFees) (uint64, uint32) {
+	spread := float64(p.MaxPPM) - float64(p.MinPPM)
+	return p.BaseMsat, uint32(float64(p.MinPPM) + spread*(1-c.LocalRatio) + 0.5)
+}
+
+// A fee adjustment made, or on a dry run which would have been
+type FeeChange struct {
+	Channel  *ChannelFees
+	BaseMsat uint64
+	PPM      uint32
+	// Whether setchannel was called; false on a dry run
+	Applied bool
+}
+
+// FeeManager periodically inspects each channel's balance and recent
+// forwards and adjusts its fees with setchannel, as its FeePolicy
+// decides.
+//
+// So as not to flood the network with channel_updates, or swing fees
+// wildly on a policy's say-so, changes are rate limited: each
+// adjustment moves a channel's ppm by at most MaxStep of its current
+// value, changes smaller than MinStepPPM are skipped, and a channel
+// is changed at most once per MinInterval.
+type FeeManager struct {
+	Policy FeePolicy
+	// How often to adjust fees once started. Defaults to an hour.
+	Interval time.Duration
+	// How far back to look at forwards. Defaults to a week; 0 looks
+	// at all of them.
+	History time.Duration
+	// Work out and report changes, but don't make them
+	DryRun bool
+	// The most a channel's ppm may move in one adjustment, as a
+	// fraction of its current ppm. Defaults to 0.25. Channels with
+	// fees lower than MinStepPPM may move by MinStepPPM.
+	MaxStep float64
+	// Skip ppm changes smaller than this. Defaults to 5.
+	MinStepPPM uint32
+	// The least time between changes to a channel. Defaults to 6
+	// hours.
+	MinInterval time.Duration
+	// Called with each change made (or, on a dry run, proposed)
+	OnChange func(*FeeChange)
+	// Called with any error from an adjustment run by Start
+	OnError func(error)
+
+	client LightningClient
+
+	mu      sync.Mutex
+	changed map[string]time.Time
+
+	done     chan struct{}
+	exited   chan struct{}
+	stopOnce sync.Once
+}
+
+func NewFeeManager(client LightningClient, policy FeePolicy) *FeeManager {
+	return &FeeManager{
+		Policy:      policy,
+		Interval:    time.Hour,
+		History:     7 * 24 * time.Hour,
+		MaxStep:     0.25,
+		MinStepPPM:  5,
+		MinInterval: 6 * time.Hour,
+		client:      client,
+		changed:     make(map[string]time.Time),
+	}
+}
+
+// Adjust fees every Interval in the background
+func (m *FeeManager) Start() {
+	m.done = make(chan struct{})
+	m.exited = make(chan struct{})
+	go m.run()
+}
+
+// Stop adjusting, waiting for any adjustment in progress to finish
+func (m *FeeManager) Stop() {
+	if m.done == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+	<-m.exited
+}
+
+func (m *FeeManager) run() {
+	defer close(m.exited)
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := m.Adjust(); err != nil && m.OnError != nil {
+				m.OnError(err)
+			}
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// Inspect every normal channel and adjust the fees of those whose
+// policy says they should change. Returns the changes made; if a
+// setchannel call fails the others are still made, and the first
+// error is returned with them.
+func (m *FeeManager) Adjust() ([]*FeeChange, error) {
+	channels, err := m.Channels()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*FeeChange
+	var firstErr error
+	for _, channel := range channels {
+		change := m.propose(channel)
+		if change == nil {
+			continue
+		}
+		if !m.DryRun {
+			if _, err := m.client.SetChannel(channel.ShortChannelId, change.BaseMsat, change.PPM); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			change.Applied = true
+			m.mu.Lock()
+			m.changed[channel.ShortChannelId] = time.Now()
+			m.mu.Unlock()
+		}
+		changes = append(changes, change)
+		if m.OnChange != nil {
+			m.OnChange(change)
+		}
+	}
+	return changes, firstErr
+}
+
+// The change the policy wants for {channel}, within the rate
+// limits; nil if there's to be none
+func (m *FeeManager) propose(channel *ChannelFees) *FeeChange {
+	m.mu.Lock()
+	last, ok := m.changed[channel.ShortChannelId]
+	m.mu.Unlock()
+	if ok && time.Since(last) < m.MinInterval {
+		return nil
+	}
+
+	base, ppm := m.Policy.Fees(channel)
+	current := channel.PPM
+	step := uint32(float64(current) * m.MaxStep)
+	if step < m.MinStepPPM {
+		step = m.MinStepPPM
+	}
+	if ppm > current && ppm-current > step {
+		ppm = current + step
+	}
+	if ppm < current && current-ppm > step {
+		ppm = current - step
+	}
+
+	var diff uint32
+	if ppm > current {
+		diff = ppm - current
+	} else {
+		diff = current - ppm
+	}
+	if base == channel.BaseMsat && diff < m.MinStepPPM {
+		return nil
+	}
+	return &FeeChange{
+		Channel:  channel,
+		BaseMsat: base,
+		PPM:      ppm,
+	}
+}
+
+// The node's normal channels, with their balances, fees and recent
+// forwards
+func (m *FeeManager) Channels() ([]*ChannelFees, error) {
+	peers, err := m.client.ListPeers()
+	if err != nil {
+		return nil, err
+	}
+	forwards, err := m.client.ListForwards()
+	if err != nil {
+		return nil, err
+	}
+	if m.History > 0 {
+		since := float64(time.Now().Add(-m.History).Unix())
+		recent := forwards[:0]
+		for _, f := range forwards {
+			if f.ReceivedTime >= since {
+				recent = append(recent, f)
+			}
+		}
+		forwards = recent
+	}
+	revenue := make(map[string]*ChannelRevenue)
+	for _, r := range NewForwardReport(forwards, ChannelPeers(peers)).Channels() {
+		revenue[r.ShortChannelId] = r
+	}
+
+	var channels []*ChannelFees
+	for _, peer := range peers {
+		for _, channel := range peer.Channels {
+			if channel.State != "CHANNELD_NORMAL" || channel.ShortChannelId == "" {
+				continue
+			}
+			scid := string(channel.ShortChannelId)
+			fees := &ChannelFees{
+				PeerId:         peer.Id,
+				ChannelId:      channel.ChannelId,
+				ShortChannelId: scid,
+				CapacityMsat:   msatOr(channel.TotalMsat, channel.MilliSatoshiTotal),
+				LocalMsat:      msatOr(channel.ToUsMsat, channel.MilliSatoshiToUs),
+				BaseMsat:       msatOr(channel.FeeBaseMsat, 0),
+				PPM:            channel.FeeProportionalMillionths,
+				Revenue:        revenue[scid],
+			}
+			if fees.CapacityMsat > 0 {
+				fees.LocalRatio = float64(fees.LocalMsat) / float64(fees.CapacityMsat)
+			}
+			if fees.Revenue == nil {
+				fees.Revenue = &ChannelRevenue{ShortChannelId: scid, PeerId: peer.Id}
+			}
+			channels = append(channels, fees)
+		}
+	}
+	return channels, nil
+}
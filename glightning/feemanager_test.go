@@ -0,0 +1,125 @@
+package glightning_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func feeManagerMock() *mock.Lightning {
+	ln := mock.New()
+	channel := func(scid string, toUs string, ppm uint32) *glightning.PeerChannel {
+		return &glightning.PeerChannel{
+			State:                     "CHANNELD_NORMAL",
+			ShortChannelId:            glightning.ShortChannelId(scid),
+			ChannelId:                 "cid" + scid,
+			TotalMsat:                 "1000000msat",
+			ToUsMsat:                  toUs,
+			FeeBaseMsat:               "1000msat",
+			FeeProportionalMillionths: ppm,
+		}
+	}
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return []*glightning.Peer{{Id: closePeer, Channels: []*glightning.PeerChannel{
+			// depleted, so its fees go up, but only by a quarter
+			channel("1x1x0", "100000msat", 100),
+			channel("2x1x0", "500000msat", 540),
+			// too small a change to bother with
+			channel("3x1x0", "500000msat", 548),
+			{State: "CHANNELD_AWAITING_LOCKIN", ShortChannelId: "4x1x0"},
+		}}}, nil
+	}
+	now := float64(time.Now().Unix())
+	ln.ListForwardsFunc = func() ([]glightning.Forwarding, error) {
+		return []glightning.Forwarding{
+			{InChannel: "2x1x0", OutChannel: "1x1x0", FeeMsat: "50msat", OutMsat: "5000msat", Status: "settled", ReceivedTime: now - 60},
+			{InChannel: "2x1x0", OutChannel: "1x1x0", FeeMsat: "70msat", OutMsat: "7000msat", Status: "settled", ReceivedTime: now - 30*24*60*60},
+		}, nil
+	}
+	return ln
+}
+
+func TestFeeManager(t *testing.T) {
+	ln := feeManagerMock()
+	type set struct {
+		scid string
+		base uint64
+		ppm  uint32
+	}
+	var sets []set
+	ln.SetChannelFunc = func(id string, baseMsat uint64, ppm uint32) (*glightning.SetChannelResult, error) {
+		sets = append(sets, set{id, baseMsat, ppm})
+		return &glightning.SetChannelResult{}, nil
+	}
+
+	policy := &glightning.BalanceFeePolicy{BaseMsat: 1000, MinPPM: 100, MaxPPM: 1000}
+	var fees []uint64
+	manager := glightning.NewFeeManager(ln, glightning.FeePolicyFunc(func(c *glightning.ChannelFees) (uint64, uint32) {
+		fees = append(fees, c.Revenue.FeesMsat)
+		return policy.Fees(c)
+	}))
+	manager.DryRun = true
+	changes, err := manager.Adjust()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the recent forward counts
+	assert.Equal(t, []uint64{50, 0, 0}, fees)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, "1x1x0", changes[0].Channel.ShortChannelId)
+	assert.Equal(t, 0.1, changes[0].Channel.LocalRatio)
+	assert.Equal(t, uint32(125), changes[0].PPM)
+	assert.Equal(t, uint32(550), changes[1].PPM)
+	assert.False(t, changes[0].Applied)
+	assert.Empty(t, sets)
+
+	manager.DryRun = false
+	var reported int
+	manager.OnChange = func(*glightning.FeeChange) { reported++ }
+	changes, err = manager.Adjust()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, changes[0].Applied)
+	assert.Equal(t, []set{{"1x1x0", 1000, 125}, {"2x1x0", 1000, 550}}, sets)
+	assert.Equal(t, 2, reported)
+
+	// changed too recently to change again
+	changes, err = manager.Adjust()
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestFeeManagerErrors(t *testing.T) {
+	ln := feeManagerMock()
+	ln.SetChannelFunc = func(id string, baseMsat uint64, ppm uint32) (*glightning.SetChannelResult, error) {
+		if id == "1x1x0" {
+			return nil, errors.New("Channel is not in normal state")
+		}
+		return &glightning.SetChannelResult{}, nil
+	}
+	manager := glightning.NewFeeManager(ln, &glightning.BalanceFeePolicy{BaseMsat: 1000, MinPPM: 100, MaxPPM: 1000})
+	changes, err := manager.Adjust()
+	assert.EqualError(t, err, "Channel is not in normal state")
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "2x1x0", changes[0].Channel.ShortChannelId)
+
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return nil, errors.New("lightningd is gone")
+	}
+	errs := make(chan error, 1)
+	manager.Interval = time.Millisecond
+	manager.OnError = func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+	manager.Start()
+	assert.EqualError(t, <-errs, "lightningd is gone")
+	manager.Stop()
+}
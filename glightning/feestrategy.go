@@ -0,0 +1,145 @@
+package glightning
+
+import (
+	"fmt"
+)
+
+// FeeStrategy picks the feerate for an onchain transaction, e.g. from
+// lightningd's current estimates and the caller's policy. A *FeeRate
+// is itself a strategy which always picks that rate.
+type FeeStrategy interface {
+	ChooseFeeRate(client LightningClient) (*FeeRate, error)
+}
+
+// Always {f}
+func (f *FeeRate) ChooseFeeRate(client LightningClient) (*FeeRate, error) {
+	return f, nil
+}
+
+// TargetFeeStrategy picks the cheapest of lightningd's estimates which
+// should confirm within TargetBlocks, clamped between MinSatPerVByte
+// and MaxSatPerVByte, and never below what lightningd will accept.
+type TargetFeeStrategy struct {
+	// Defaults to 6 if zero
+	TargetBlocks uint32
+	// No limit if zero
+	MaxSatPerVByte uint
+	MinSatPerVByte uint
+	// Use the smoothed estimates, which move less suddenly
+	Smoothed bool
+}
+
+// Aim to confirm within {blocks} blocks, paying at most {maxSatPerVByte}
+// (unlimited if zero)
+func NewTargetFeeStrategy(blocks uint32, maxSatPerVByte uint) *TargetFeeStrategy {
+	return &TargetFeeStrategy{
+		TargetBlocks:   blocks,
+		MaxSatPerVByte: maxSatPerVByte,
+	}
+}
+
+func (s *TargetFeeStrategy) ChooseFeeRate(client LightningClient) (*FeeRate, error) {
+	estimate, err := client.FeeRates(PerKb)
+	if err != nil {
+		return nil, err
+	}
+	details := estimate.Details
+	if details == nil {
+		return nil, fmt.Errorf("No feerate estimates available: %s", estimate.Warning)
+	}
+
+	target := s.TargetBlocks
+	if target == 0 {
+		target = 6
+	}
+	rate := s.targetRate(details, target)
+
+	// sat/vB is sat per thousand vbytes, i.e. perkb, over 1000
+	if s.MinSatPerVByte > 0 && rate < s.MinSatPerVByte*1000 {
+		rate = s.MinSatPerVByte * 1000
+	}
+	if s.MaxSatPerVByte > 0 && rate > s.MaxSatPerVByte*1000 {
+		rate = s.MaxSatPerVByte * 1000
+	}
+	if floor := minAcceptable(details); rate < floor {
+		if s.MaxSatPerVByte > 0 && floor > s.MaxSatPerVByte*1000 {
+			return nil, fmt.Errorf("Minimum acceptable feerate of %dperkb exceeds the limit of %dsat/vB", floor, s.MaxSatPerVByte)
+		}
+		rate = floor
+	}
+	return NewFeeRate(PerKb, rate), nil
+}
+
+// The rate of the estimate with the largest blockcount within
+// {target}, or the most urgent estimate if there's none. Falls back
+// to the urgent/normal/slow rates from older lightningd.
+func (s *TargetFeeStrategy) targetRate(details *FeeRateDetails, target uint32) uint {
+	var best *FeeRateBlockEstimate
+	for _, est := range details.Estimates {
+		if est.BlockCount <= target && (best == nil || est.BlockCount > best.BlockCount) {
+			best = est
+		}
+	}
+	if best == nil {
+		for _, est := range details.Estimates {
+			if best == nil || est.BlockCount < best.BlockCount {
+				best = est
+			}
+		}
+	}
+	if best != nil {
+		if s.Smoothed && best.SmoothedFeeRate > 0 {
+			return best.SmoothedFeeRate
+		}
+		return best.FeeRate
+	}
+
+	var rate int
+	switch {
+	case target <= 2:
+		rate = details.Urgent
+	case target <= 6:
+		rate = details.Normal
+	default:
+		rate = details.Slow
+	}
+	if rate < 0 {
+		return 0
+	}
+	return uint(rate)
+}
+
+func minAcceptable(details *FeeRateDetails) uint {
+	floor := details.Floor
+	if details.MinAcceptable > 0 && uint(details.MinAcceptable) > floor {
+		floor = uint(details.MinAcceptable)
+	}
+	return floor
+}
+
+// Withdraw, at the feerate {strategy} chooses
+func (l *Lightning) WithdrawWithStrategy(destination string, amount *Sat, strategy FeeStrategy, minConf *uint16) (*WithdrawResult, error) {
+	feerate, err := strategy.ChooseFeeRate(l)
+	if err != nil {
+		return nil, err
+	}
+	return l.Withdraw(destination, amount, feerate, minConf)
+}
+
+// Fund a channel, at the feerate {strategy} chooses
+func (l *Lightning) FundChannelWithStrategy(id string, amount *Sat, strategy FeeStrategy, announce bool, minConf *uint16) (*FundChannelResult, error) {
+	feerate, err := strategy.ChooseFeeRate(l)
+	if err != nil {
+		return nil, err
+	}
+	return l.FundChannelExt(id, amount, feerate, announce, minConf, nil)
+}
+
+// Prepare a transaction, at the feerate {strategy} chooses
+func (l *Lightning) PrepareTxWithStrategy(outputs []*Outputs, strategy FeeStrategy, minConf *uint16) (*TxResult, error) {
+	feerate, err := strategy.ChooseFeeRate(l)
+	if err != nil {
+		return nil, err
+	}
+	return l.PrepareTx(outputs, feerate, minConf)
+}
@@ -0,0 +1,77 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func feeRatesMock(details *glightning.FeeRateDetails) *mock.Lightning {
+	ln := mock.New()
+	ln.FeeRatesFunc = func(style glightning.FeeRateStyle) (*glightning.FeeRateEstimate, error) {
+		return &glightning.FeeRateEstimate{Style: style, Details: details}, nil
+	}
+	return ln
+}
+
+func TestTargetFeeStrategy(t *testing.T) {
+	ln := feeRatesMock(&glightning.FeeRateDetails{
+		MinAcceptable: 1012,
+		Floor:         1012,
+		Estimates: []*glightning.FeeRateBlockEstimate{
+			{BlockCount: 2, FeeRate: 30000, SmoothedFeeRate: 28000},
+			{BlockCount: 6, FeeRate: 12000, SmoothedFeeRate: 13000},
+			{BlockCount: 12, FeeRate: 5000, SmoothedFeeRate: 5000},
+			{BlockCount: 100, FeeRate: 1000, SmoothedFeeRate: 1000},
+		},
+	})
+
+	check := func(s *glightning.TargetFeeStrategy, expected string) {
+		feerate, err := s.ChooseFeeRate(ln)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, feerate.String())
+	}
+	check(&glightning.TargetFeeStrategy{}, "12000perkb")
+	check(glightning.NewTargetFeeStrategy(1, 0), "30000perkb")
+	check(glightning.NewTargetFeeStrategy(10, 0), "12000perkb")
+	check(glightning.NewTargetFeeStrategy(2, 20), "20000perkb")
+	check(&glightning.TargetFeeStrategy{TargetBlocks: 6, Smoothed: true}, "13000perkb")
+	check(&glightning.TargetFeeStrategy{TargetBlocks: 12, MinSatPerVByte: 8}, "8000perkb")
+	// never below what lightningd accepts
+	check(glightning.NewTargetFeeStrategy(1000, 0), "1012perkb")
+
+	_, err := glightning.NewTargetFeeStrategy(1000, 1).ChooseFeeRate(ln)
+	assert.Error(t, err)
+}
+
+func TestTargetFeeStrategyFallback(t *testing.T) {
+	ln := feeRatesMock(&glightning.FeeRateDetails{
+		Urgent: 20000,
+		Normal: 8000,
+		Slow:   2000,
+	})
+	for blocks, expected := range map[uint32]string{
+		1:   "20000perkb",
+		6:   "8000perkb",
+		144: "2000perkb",
+	} {
+		feerate, err := glightning.NewTargetFeeStrategy(blocks, 0).ChooseFeeRate(ln)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, feerate.String())
+	}
+}
+
+func TestWithdrawWithStrategy(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"withdraw","params":{"destination":"bcrt1qqgpqyqszqgpqyqszqgpqyqszqgpqyqszazmwwa","feerate":"2000perkb","satoshi":"50000"},"id":1}`
+	resp := wrapResult(1, `{"tx": "0200", "txid": "aa"}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+
+	result, err := lightning.WithdrawWithStrategy("bcrt1qqgpqyqszqgpqyqszqgpqyqszqgpqyqszazmwwa", glightning.NewSat(50000), glightning.NewFeeRate(glightning.PerKb, 2000), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "aa", result.TxId)
+}
@@ -0,0 +1,290 @@
+package glightning
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Forwarding totals for a single channel. Fees and outgoing volume
+// are credited to the channel a payment left by, since that's the
+// channel whose fee was charged.
+type ChannelRevenue struct {
+	ShortChannelId string
+	// The channel's peer, if known
+	PeerId        string
+	FeesMsat      uint64
+	VolumeInMsat  uint64
+	VolumeOutMsat uint64
+	// Outgoing forwards which settled or failed
+	Settled uint64
+	Failed  uint64
+}
+
+// Fraction of the outgoing forwards over the channel which settled
+func (r *ChannelRevenue) SuccessRate() float64 {
+	return successRate(r.Settled, r.Failed)
+}
+
+// Forwarding totals for a single (UTC) day
+type DailyRevenue struct {
+	// As "2006-01-02"
+	Day        string
+	FeesMsat   uint64
+	VolumeMsat uint64
+	Settled    uint64
+	Failed     uint64
+}
+
+func (r *DailyRevenue) SuccessRate() float64 {
+	return successRate(r.Settled, r.Failed)
+}
+
+// Forwarding totals across all of a peer's channels
+type PeerRevenue struct {
+	PeerId     string
+	FeesMsat   uint64
+	VolumeMsat uint64
+	Settled    uint64
+	Failed     uint64
+}
+
+func (r *PeerRevenue) SuccessRate() float64 {
+	return successRate(r.Settled, r.Failed)
+}
+
+func successRate(settled, failed uint64) float64 {
+	if settled+failed == 0 {
+		return 0
+	}
+	return float64(settled) / float64(settled+failed)
+}
+
+// ForwardReport aggregates forwards into revenue by channel, by
+// day and by peer. Fill it from listforwards with NewForwardReport,
+// or keep it current by calling Add from a forward_event
+// subscription. Forwards still in flight ('offered') are ignored.
+type ForwardReport struct {
+	FeesMsat   uint64
+	VolumeMsat uint64
+	Settled    uint64
+	Failed     uint64
+
+	peers    map[string]string
+	channels map[string]*ChannelRevenue
+	days     map[string]*DailyRevenue
+}
+
+// Build a report from {forwards}. {peers} maps short channel ids to
+// the peer on the other end (see ChannelPeers); it may be nil, in
+// which case the report has no per-peer breakdown.
+func NewForwardReport(forwards []Forwarding, peers map[string]string) *ForwardReport {
+	report := &ForwardReport{
+		peers:    peers,
+		channels: make(map[string]*ChannelRevenue),
+		days:     make(map[string]*DailyRevenue),
+	}
+	for i := range forwards {
+		report.Add(&forwards[i])
+	}
+	return report
+}
+
+// Map each of the {peers}' channels' short channel id to the peer's id
+func ChannelPeers(peers []*Peer) map[string]string {
+	channelPeers := make(map[string]string)
+	for _, peer := range peers {
+		for _, channel := range peer.Channels {
+			if channel.ShortChannelId != "" {
+				channelPeers[string(channel.ShortChannelId)] = peer.Id
+			}
+		}
+	}
+	return channelPeers
+}
+
+func (r *ForwardReport) Add(f *Forwarding) {
+	var settled bool
+	switch f.Status {
+	case "settled":
+		settled = true
+	case "failed", "local_failed":
+	default:
+		return
+	}
+
+	out := r.channel(f.OutChannel)
+	in := r.channel(f.InChannel)
+	day := r.day(f)
+	if !settled {
+		r.Failed++
+		day.Failed++
+		if out != nil {
+			out.Failed++
+		}
+		return
+	}
+
+	fee := forwardAmount(f.Fee, f.FeeMsat)
+	amountIn := forwardAmount(f.MilliSatoshiIn, f.InMsat)
+	amountOut := forwardAmount(f.MilliSatoshiOut, f.OutMsat)
+
+	r.Settled++
+	r.FeesMsat += fee
+	r.VolumeMsat += amountOut
+	day.Settled++
+	day.FeesMsat += fee
+	day.VolumeMsat += amountOut
+	if out != nil {
+		out.Settled++
+		out.FeesMsat += fee
+		out.VolumeOutMsat += amountOut
+	}
+	if in != nil {
+		in.VolumeInMsat += amountIn
+	}
+}
+
+func (r *ForwardReport) channel(scid string) *ChannelRevenue {
+	if scid == "" {
+		return nil
+	}
+	channel, ok := r.channels[scid]
+	if !ok {
+		channel = &ChannelRevenue{
+			ShortChannelId: scid,
+			PeerId:         r.peers[scid],
+		}
+		r.channels[scid] = channel
+	}
+	return channel
+}
+
+func (r *ForwardReport) day(f *Forwarding) *DailyRevenue {
+	at := f.ResolvedTime
+	if at == 0 {
+		at = f.ReceivedTime
+	}
+	name := time.Unix(int64(at), 0).UTC().Format("2006-01-02")
+	day, ok := r.days[name]
+	if !ok {
+		day = &DailyRevenue{Day: name}
+		r.days[name] = day
+	}
+	return day
+}
+
+// The old msatoshi fields are plain numbers; their replacements
+// are "123msat" strings, or numbers again with the compat layer on
+func forwardAmount(raw uint64, msat string) uint64 {
+	if raw != 0 || msat == "" {
+		return raw
+	}
+	amount, err := ParseMSat(msat)
+	if err != nil {
+		return 0
+	}
+	return amount.Value
+}
+
+// Per-channel totals, highest earning first
+func (r *ForwardReport) Channels() []*ChannelRevenue {
+	channels := make([]*ChannelRevenue, 0, len(r.channels))
+	for _, channel := range r.channels {
+		channels = append(channels, channel)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].FeesMsat != channels[j].FeesMsat {
+			return channels[i].FeesMsat > channels[j].FeesMsat
+		}
+		return channels[i].ShortChannelId < channels[j].ShortChannelId
+	})
+	return channels
+}
+
+// Per-day totals, oldest first
+func (r *ForwardReport) Days() []*DailyRevenue {
+	days := make([]*DailyRevenue, 0, len(r.days))
+	for _, day := range r.days {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Day < days[j].Day
+	})
+	return days
+}
+
+// The {n} highest earning peers (all of them, if n is 0). Empty
+// unless the report was built with a channel to peer mapping.
+func (r *ForwardReport) TopPeers(n int) []*PeerRevenue {
+	byPeer := make(map[string]*PeerRevenue)
+	for _, channel := range r.channels {
+		if channel.PeerId == "" {
+			continue
+		}
+		peer, ok := byPeer[channel.PeerId]
+		if !ok {
+			peer = &PeerRevenue{PeerId: channel.PeerId}
+			byPeer[channel.PeerId] = peer
+		}
+		peer.FeesMsat += channel.FeesMsat
+		peer.VolumeMsat += channel.VolumeOutMsat
+		peer.Settled += channel.Settled
+		peer.Failed += channel.Failed
+	}
+
+	peers := make([]*PeerRevenue, 0, len(byPeer))
+	for _, peer := range byPeer {
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].FeesMsat != peers[j].FeesMsat {
+			return peers[i].FeesMsat > peers[j].FeesMsat
+		}
+		return peers[i].PeerId < peers[j].PeerId
+	})
+	if n > 0 && n < len(peers) {
+		peers = peers[:n]
+	}
+	return peers
+}
+
+// Write the per-channel totals as CSV, with a header row
+func (r *ForwardReport) WriteChannelsCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"short_channel_id", "peer_id", "fees_msat", "volume_in_msat", "volume_out_msat", "settled", "failed", "success_rate"})
+	for _, c := range r.Channels() {
+		out.Write([]string{
+			c.ShortChannelId,
+			c.PeerId,
+			strconv.FormatUint(c.FeesMsat, 10),
+			strconv.FormatUint(c.VolumeInMsat, 10),
+			strconv.FormatUint(c.VolumeOutMsat, 10),
+			strconv.FormatUint(c.Settled, 10),
+			strconv.FormatUint(c.Failed, 10),
+			strconv.FormatFloat(c.SuccessRate(), 'f', 4, 64),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// Write the per-day totals as CSV, with a header row
+func (r *ForwardReport) WriteDaysCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"day", "fees_msat", "volume_msat", "settled", "failed", "success_rate"})
+	for _, d := range r.Days() {
+		out.Write([]string{
+			d.Day,
+			strconv.FormatUint(d.FeesMsat, 10),
+			strconv.FormatUint(d.VolumeMsat, 10),
+			strconv.FormatUint(d.Settled, 10),
+			strconv.FormatUint(d.Failed, 10),
+			strconv.FormatFloat(d.SuccessRate(), 'f', 4, 64),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,76 @@
+package glightning_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForwardReport(t *testing.T) {
+	// 2019-06-16 and 2019-06-17, UTC
+	const day1, day2 = 1560696343.052, 1560782743.5
+	forwards := []glightning.Forwarding{
+		{InChannel: "103x2x1", OutChannel: "110x1x0", MilliSatoshiIn: 100001001, MilliSatoshiOut: 100000000, Fee: 1001, Status: "settled", ReceivedTime: day1, ResolvedTime: day1 + 1},
+		{InChannel: "103x2x1", OutChannel: "110x1x0", InMsat: "50000500msat", OutMsat: "50000000msat", FeeMsat: "500msat", Status: "settled", ReceivedTime: day2},
+		{InChannel: "110x1x0", OutChannel: "103x2x1", MilliSatoshiIn: 2002, MilliSatoshiOut: 2000, Fee: 2, Status: "settled", ReceivedTime: day2},
+		{InChannel: "103x2x1", OutChannel: "110x1x0", MilliSatoshiIn: 1001, MilliSatoshiOut: 1000, Fee: 1, Status: "local_failed", ReceivedTime: day2},
+		{InChannel: "103x2x1", OutChannel: "120x1x0", MilliSatoshiIn: 1001, MilliSatoshiOut: 1000, Fee: 1, Status: "offered", ReceivedTime: day2},
+	}
+	peers := glightning.ChannelPeers([]*glightning.Peer{
+		{Id: "02aa", Channels: []*glightning.PeerChannel{{ShortChannelId: "103x2x1"}}},
+		{Id: "02bb", Channels: []*glightning.PeerChannel{{ShortChannelId: "110x1x0"}, {}}},
+	})
+	assert.Equal(t, map[string]string{"103x2x1": "02aa", "110x1x0": "02bb"}, peers)
+
+	report := glightning.NewForwardReport(forwards, peers)
+	assert.Equal(t, uint64(1503), report.FeesMsat)
+	assert.Equal(t, uint64(150002000), report.VolumeMsat)
+	assert.Equal(t, uint64(3), report.Settled)
+	assert.Equal(t, uint64(1), report.Failed)
+
+	channels := report.Channels()
+	assert.Len(t, channels, 2)
+	assert.Equal(t, &glightning.ChannelRevenue{
+		ShortChannelId: "110x1x0",
+		PeerId:         "02bb",
+		FeesMsat:       1501,
+		VolumeInMsat:   2002,
+		VolumeOutMsat:  150000000,
+		Settled:        2,
+		Failed:         1,
+	}, channels[0])
+	assert.InDelta(t, 2.0/3.0, channels[0].SuccessRate(), 0.0001)
+	assert.Equal(t, "103x2x1", channels[1].ShortChannelId)
+	assert.Equal(t, uint64(150001501), channels[1].VolumeInMsat)
+
+	days := report.Days()
+	assert.Len(t, days, 2)
+	assert.Equal(t, &glightning.DailyRevenue{Day: "2019-06-16", FeesMsat: 1001, VolumeMsat: 100000000, Settled: 1}, days[0])
+	assert.Equal(t, &glightning.DailyRevenue{Day: "2019-06-17", FeesMsat: 502, VolumeMsat: 50002000, Settled: 2, Failed: 1}, days[1])
+
+	top := report.TopPeers(1)
+	assert.Equal(t, []*glightning.PeerRevenue{{PeerId: "02bb", FeesMsat: 1501, VolumeMsat: 150000000, Settled: 2, Failed: 1}}, top)
+	assert.Len(t, report.TopPeers(0), 2)
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.WriteDaysCSV(&buf))
+	assert.Equal(t, "day,fees_msat,volume_msat,settled,failed,success_rate\n"+
+		"2019-06-16,1001,100000000,1,0,1.0000\n"+
+		"2019-06-17,502,50002000,2,1,0.6667\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, report.WriteChannelsCSV(&buf))
+	assert.Equal(t, "short_channel_id,peer_id,fees_msat,volume_in_msat,volume_out_msat,settled,failed,success_rate\n"+
+		"110x1x0,02bb,1501,2002,150000000,2,1,0.6667\n"+
+		"103x2x1,02aa,2,150001501,2000,1,0,1.0000\n", buf.String())
+}
+
+func TestForwardReportStream(t *testing.T) {
+	report := glightning.NewForwardReport(nil, nil)
+	report.Add(&glightning.Forwarding{InChannel: "1x1x1", OutChannel: "2x2x2", Fee: 5, MilliSatoshiOut: 1000, Status: "settled"})
+	assert.Equal(t, uint64(5), report.FeesMsat)
+	assert.Empty(t, report.TopPeers(0))
+	assert.Equal(t, "", report.Channels()[0].PeerId)
+}
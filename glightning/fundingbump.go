@@ -0,0 +1,224 @@
+package glightning
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// What a FundingBumper's FundBumpFunc must fund
+type FundBumpRequest struct {
+	ChannelId string
+	// Our contribution to the channel, in satoshi
+	Amount uint64
+	// The feerate of the replacement transaction
+	FeeRatePerKw uint
+	// Which replacement this is, from 1
+	Round int
+}
+
+// Builds the PSBT contributing our inputs to a replacement funding
+// transaction, e.g. with fundpsbt or utxopsbt. It must spend at least
+// one of the inputs of the transaction it replaces.
+type FundBumpFunc func(ctx context.Context, req *FundBumpRequest) (psbt string, err error)
+
+// A replacement funding transaction, signed and sent to the peer
+type FundingBump struct {
+	Round        int
+	FeeRatePerKw uint
+	TxId         string
+	Tx           string
+}
+
+// The channel states in which a dual-funded open is still unconfirmed,
+// and so can be bumped
+var bumpableStates = map[string]bool{
+	"DUALOPEND_OPEN_COMMITTED":  true,
+	"DUALOPEND_AWAITING_LOCKIN": true,
+}
+
+// FundingBumper keeps an unconfirmed dual-funded channel open's
+// funding transaction competitive as the mempool fills: each
+// Interval it compares the feerate it was sent at with lightningd's
+// current opening estimate, and when the estimate has risen by
+// MinIncrease replaces the transaction (openchannel_bump, _update and
+// _signed) at the new rate, up to MaxFeeRatePerKw.
+//
+//	bumper := glightning.NewFundingBumper(ln, channelId, 500000, 2500)
+//	bumper.MaxFeeRatePerKw = 20000
+//	bumps, err := bumper.Run(ctx, func(ctx context.Context, req *glightning.FundBumpRequest) (string, error) {
+//		...build a PSBT spending the previous inputs at req.FeeRatePerKw...
+//	})
+type FundingBumper struct {
+	ChannelId string
+	// Our contribution to the channel, in satoshi
+	Amount uint64
+	// The feerate the funding transaction was last sent at
+	FeeRatePerKw uint
+	// Never bump above this feerate. Required.
+	MaxFeeRatePerKw uint
+	// Bump once the estimate is this fraction above the current
+	// feerate. Defaults to 0.25; lightningd refuses less than 1/24.
+	MinIncrease float64
+	// Stop after this many bumps; 0 means no limit
+	MaxBumps int
+	// How often to check the estimate. Defaults to a minute.
+	Interval time.Duration
+	// Signs our inputs of the funding PSBT. Defaults to signpsbt, for
+	// inputs from lightningd's wallet.
+	Sign func(ctx context.Context, psbt string) (string, error)
+	// Called after each bump
+	OnBump func(*FundingBump)
+
+	client LightningClient
+	bumps  []*FundingBump
+}
+
+func NewFundingBumper(client LightningClient, channelId string, amount uint64, feeRatePerKw uint) *FundingBumper {
+	return &FundingBumper{
+		ChannelId:    channelId,
+		Amount:       amount,
+		FeeRatePerKw: feeRatePerKw,
+		MinIncrease:  0.25,
+		Interval:     time.Minute,
+		client:       client,
+	}
+}
+
+// Watch the channel, bumping its funding as the estimate rises, until
+// it confirms, MaxBumps is reached, or {ctx} is done. Returns the
+// bumps made, with any error that stopped it.
+func (b *FundingBumper) Run(ctx context.Context, fund FundBumpFunc) ([]*FundingBump, error) {
+	if fund == nil {
+		return nil, fmt.Errorf("Must provide a FundBumpFunc")
+	}
+	if b.MaxFeeRatePerKw == 0 {
+		return nil, fmt.Errorf("Must set a maximum feerate")
+	}
+
+	for {
+		pending, err := b.unconfirmed()
+		if err != nil || !pending {
+			return b.bumps, err
+		}
+		if b.MaxBumps > 0 && len(b.bumps) >= b.MaxBumps {
+			return b.bumps, nil
+		}
+
+		if target, ok, err := b.target(); err != nil {
+			return b.bumps, err
+		} else if ok {
+			if _, err := b.Bump(ctx, target, fund); err != nil {
+				return b.bumps, err
+			}
+		}
+
+		select {
+		case <-time.After(b.Interval):
+		case <-ctx.Done():
+			return b.bumps, ctx.Err()
+		}
+	}
+}
+
+// Whether the channel's funding is yet to confirm
+func (b *FundingBumper) unconfirmed() (bool, error) {
+	peers, err := b.client.ListPeers()
+	if err != nil {
+		return false, err
+	}
+	for _, peer := range peers {
+		for _, channel := range peer.Channels {
+			if channel.ChannelId == b.ChannelId {
+				return bumpableStates[channel.State], nil
+			}
+		}
+	}
+	return false, fmt.Errorf("Channel %s not found", b.ChannelId)
+}
+
+// The feerate to bump to now, if a bump is due
+func (b *FundingBumper) target() (uint, bool, error) {
+	estimate, err := b.client.FeeRates(PerKw)
+	if err != nil {
+		return 0, false, err
+	}
+	if estimate.Details == nil {
+		return 0, false, fmt.Errorf("No feerate estimate available")
+	}
+	target := estimate.Details.Opening
+	if target > b.MaxFeeRatePerKw {
+		target = b.MaxFeeRatePerKw
+	}
+	minimum := float64(b.FeeRatePerKw) * (1 + b.MinIncrease)
+	if float64(target) < minimum || target <= b.FeeRatePerKw {
+		return 0, false, nil
+	}
+	return target, true, nil
+}
+
+// Replace the funding transaction at {feeRatePerKw} now. If the
+// negotiation fails part way the round is aborted, leaving the
+// previous transaction in place.
+func (b *FundingBumper) Bump(ctx context.Context, feeRatePerKw uint, fund FundBumpFunc) (*FundingBump, error) {
+	round := len(b.bumps) + 1
+	psbt, err := fund(ctx, &FundBumpRequest{
+		ChannelId:    b.ChannelId,
+		Amount:       b.Amount,
+		FeeRatePerKw: feeRatePerKw,
+		Round:        round,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := b.client.OpenChannelBump(b.ChannelId, NewSat64(b.Amount), psbt, NewFeeRate(PerKw, feeRatePerKw))
+	if err != nil {
+		return nil, err
+	}
+	signed, err := b.negotiate(ctx, result)
+	if err != nil {
+		b.client.OpenChannelAbort(b.ChannelId)
+		return nil, err
+	}
+
+	bump := &FundingBump{
+		Round:        round,
+		FeeRatePerKw: feeRatePerKw,
+		TxId:         signed.TxId,
+		Tx:           signed.Tx,
+	}
+	b.FeeRatePerKw = feeRatePerKw
+	b.bumps = append(b.bumps, bump)
+	if b.OnBump != nil {
+		b.OnBump(bump)
+	}
+	return bump, nil
+}
+
+// The most openchannel_update rounds to go before giving up on the
+// peer securing the commitments
+const maxUpdateRounds = 10
+
+func (b *FundingBumper) negotiate(ctx context.Context, result *OpenChannelV2Result) (*OpenChannelSignedResult, error) {
+	var err error
+	for i := 0; !result.CommitmentsSecured; i++ {
+		if i == maxUpdateRounds {
+			return nil, fmt.Errorf("Commitments not secured after %d updates", maxUpdateRounds)
+		}
+		if result, err = b.client.OpenChannelUpdate(b.ChannelId, result.Psbt); err != nil {
+			return nil, err
+		}
+	}
+
+	var signed string
+	if b.Sign != nil {
+		signed, err = b.Sign(ctx, result.Psbt)
+	} else {
+		signed, err = b.client.SignPsbt(result.Psbt, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b.client.OpenChannelSigned(b.ChannelId, signed)
+}
@@ -0,0 +1,112 @@
+package glightning_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const bumpChannelId = "1c6b4e3fd1e8f8cb4a4e4a7dce2d3fc9f9b31e0c4a6dea1e0f55ae23f1e7bc10"
+
+// a node whose channel confirms after {checks} checks, with the
+// opening feerate estimate following {estimates}
+func bumpMock(checks int, estimates ...uint) *mock.Lightning {
+	ln := mock.New()
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		state := "DUALOPEND_AWAITING_LOCKIN"
+		if checks == 0 {
+			state = "CHANNELD_NORMAL"
+		} else {
+			checks--
+		}
+		return []*glightning.Peer{{
+			Id:       "02aa",
+			Channels: []*glightning.PeerChannel{{ChannelId: bumpChannelId, State: state}},
+		}}, nil
+	}
+	ln.FeeRatesFunc = func(style glightning.FeeRateStyle) (*glightning.FeeRateEstimate, error) {
+		estimate := estimates[0]
+		if len(estimates) > 1 {
+			estimates = estimates[1:]
+		}
+		return &glightning.FeeRateEstimate{
+			Style:   style,
+			Details: &glightning.FeeRateDetails{Opening: estimate},
+		}, nil
+	}
+	return ln
+}
+
+func TestFundingBumper(t *testing.T) {
+	ln := bumpMock(3, 2600, 5000, 30000)
+	var bumped []string
+	ln.OpenChannelBumpFunc = func(channelId string, amount *glightning.Sat, initialPsbt string, feerate *glightning.FeeRate) (*glightning.OpenChannelV2Result, error) {
+		assert.Equal(t, bumpChannelId, channelId)
+		assert.Equal(t, uint64(500000), amount.Value)
+		bumped = append(bumped, initialPsbt+"@"+feerate.String())
+		return &glightning.OpenChannelV2Result{ChannelId: channelId, Psbt: "update"}, nil
+	}
+	ln.OpenChannelUpdateFunc = func(channelId, psbt string) (*glightning.OpenChannelV2Result, error) {
+		return &glightning.OpenChannelV2Result{ChannelId: channelId, Psbt: "secured", CommitmentsSecured: true}, nil
+	}
+	ln.SignPsbtFunc = func(psbt string, signOnly []uint32) (string, error) {
+		assert.Equal(t, "secured", psbt)
+		return "signed", nil
+	}
+	txids := 0
+	ln.OpenChannelSignedFunc = func(channelId, signedPsbt string) (*glightning.OpenChannelSignedResult, error) {
+		txids++
+		return &glightning.OpenChannelSignedResult{ChannelId: channelId, TxId: fmt.Sprintf("tx%d", txids)}, nil
+	}
+
+	bumper := glightning.NewFundingBumper(ln, bumpChannelId, 500000, 2500)
+	bumper.MaxFeeRatePerKw = 8000
+	bumper.Interval = time.Millisecond
+	var notified int
+	bumper.OnBump = func(*glightning.FundingBump) { notified++ }
+	bumps, err := bumper.Run(context.Background(), func(ctx context.Context, req *glightning.FundBumpRequest) (string, error) {
+		return fmt.Sprintf("psbt%d", req.Round), nil
+	})
+	assert.NoError(t, err)
+
+	// 2600 is too small a rise, and 30000 is capped
+	assert.Equal(t, []string{"psbt1@5000perkw", "psbt2@8000perkw"}, bumped)
+	if assert.Len(t, bumps, 2) {
+		assert.Equal(t, &glightning.FundingBump{Round: 2, FeeRatePerKw: 8000, TxId: "tx2"}, bumps[1])
+	}
+	assert.Equal(t, 2, notified)
+	assert.Equal(t, uint(8000), bumper.FeeRatePerKw)
+}
+
+func TestFundingBumperAborts(t *testing.T) {
+	ln := bumpMock(5, 10000)
+	ln.OpenChannelBumpFunc = func(channelId string, amount *glightning.Sat, initialPsbt string, feerate *glightning.FeeRate) (*glightning.OpenChannelV2Result, error) {
+		return &glightning.OpenChannelV2Result{ChannelId: channelId, Psbt: "update"}, nil
+	}
+	ln.OpenChannelUpdateFunc = func(channelId, psbt string) (*glightning.OpenChannelV2Result, error) {
+		return nil, fmt.Errorf("peer disconnected")
+	}
+	var aborted bool
+	ln.OpenChannelAbortFunc = func(channelId string) (*glightning.OpenChannelAbortResult, error) {
+		aborted = true
+		return &glightning.OpenChannelAbortResult{ChannelId: channelId}, nil
+	}
+
+	bumper := glightning.NewFundingBumper(ln, bumpChannelId, 500000, 2500)
+	bumper.MaxFeeRatePerKw = 20000
+	bumps, err := bumper.Run(context.Background(), func(ctx context.Context, req *glightning.FundBumpRequest) (string, error) {
+		return "psbt", nil
+	})
+	assert.EqualError(t, err, "peer disconnected")
+	assert.Empty(t, bumps)
+	assert.True(t, aborted)
+	assert.Equal(t, uint(2500), bumper.FeeRatePerKw)
+
+	_, err = bumper.Run(context.Background(), nil)
+	assert.EqualError(t, err, "Must provide a FundBumpFunc")
+}
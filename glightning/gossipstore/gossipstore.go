@@ -0,0 +1,158 @@
+// Package gossipstore reads lightningd's gossip_store file, which
+// holds every gossip message lightningd knows of, so the gossip can
+// be consumed without going through the RPC.
+//
+// The file is a version byte followed by records, each a header
+//
+//	[u16 flags][u16 len][u32 crc][u32 timestamp]
+//
+// and {len} bytes of message. Messages are either BOLT#7 gossip
+// (decoded to ChannelAnnouncement, NodeAnnouncement and
+// ChannelUpdate) or lightningd's own bookkeeping (ChannelAmount,
+// DeleteChannel, ChannelDying, StoreEnded).
+package gossipstore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Record header flags
+const (
+	FlagDeleted uint16 = 0x8000
+	FlagZombie  uint16 = 0x1000
+	FlagDying   uint16 = 0x0800
+)
+
+// The oldest and newest minor versions of the format this reads.
+// Older stores used a different record header.
+const (
+	MinVersion = 10
+	MaxVersion = 31
+)
+
+const headerLen = 12
+
+type Record struct {
+	// Byte offset of the record's header within the file
+	Offset    int64
+	Flags     uint16
+	CRC       uint32
+	Timestamp uint32
+	// BOLT#7 or gossip_store message type
+	Type uint16
+	// The full message, including its type
+	Raw []byte
+	// The decoded message, one of the message types in this package,
+	// or nil if the type isn't one this package knows
+	Msg interface{}
+}
+
+// The record has been superseded, e.g. by a newer channel_update, or
+// its channel closed. lightningd skips deleted records.
+func (r *Record) Deleted() bool {
+	return r.Flags&FlagDeleted != 0
+}
+
+// The channel hasn't been updated in two weeks
+func (r *Record) Zombie() bool {
+	return r.Flags&FlagZombie != 0
+}
+
+// The channel's funding output has been spent, and it will be
+// forgotten after a few blocks
+func (r *Record) Dying() bool {
+	return r.Flags&FlagDying != 0
+}
+
+type Reader struct {
+	r *bufio.Reader
+	// The format's major and minor versions, from the version byte
+	Major  uint8
+	Minor  uint8
+	offset int64
+}
+
+// Start reading a gossip_store from {r}, checking its version.
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: bufio.NewReader(r)}
+	version, err := reader.r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read gossip_store version: %s", err)
+	}
+	reader.offset = 1
+	reader.Major = version >> 5
+	reader.Minor = version & 0x1f
+	if reader.Major != 0 || reader.Minor < MinVersion || reader.Minor > MaxVersion {
+		return nil, fmt.Errorf("Unsupported gossip_store version %d.%d", reader.Major, reader.Minor)
+	}
+	return reader, nil
+}
+
+// Read every record in the gossip_store file at {path}, calling
+// {fn} for each. Stops at the first error, from reading or
+// returned by {fn}.
+func ReadFile(path string, fn func(*Record) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader, err := NewReader(f)
+	if err != nil {
+		return err
+	}
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// The next record, or io.EOF at the end of the store. A record
+// cut short (e.g. one lightningd is still writing) is reported as
+// io.ErrUnexpectedEOF.
+func (r *Reader) Next() (*Record, error) {
+	var header [headerLen]byte
+	n, err := io.ReadFull(r.r, header[:])
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	record := &Record{
+		Offset:    r.offset,
+		Flags:     binary.BigEndian.Uint16(header[0:2]),
+		CRC:       binary.BigEndian.Uint32(header[4:8]),
+		Timestamp: binary.BigEndian.Uint32(header[8:12]),
+	}
+	length := binary.BigEndian.Uint16(header[2:4])
+	record.Raw = make([]byte, length)
+	if _, err := io.ReadFull(r.r, record.Raw); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	r.offset += int64(n) + int64(length)
+
+	if len(record.Raw) < 2 {
+		return nil, fmt.Errorf("gossip_store record at %d is too short", record.Offset)
+	}
+	record.Type = binary.BigEndian.Uint16(record.Raw)
+	record.Msg, err = decode(record.Type, record.Raw[2:])
+	if err != nil {
+		return nil, fmt.Errorf("gossip_store record at %d: %s", record.Offset, err)
+	}
+	return record, nil
+}
@@ -0,0 +1,216 @@
+package gossipstore_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/gossipstore"
+	"github.com/stretchr/testify/assert"
+)
+
+type msgBuilder struct {
+	bytes.Buffer
+}
+
+func (b *msgBuilder) u8(v uint8)   { b.WriteByte(v) }
+func (b *msgBuilder) u16(v uint16) { binary.Write(b, binary.BigEndian, v) }
+func (b *msgBuilder) u32(v uint32) { binary.Write(b, binary.BigEndian, v) }
+func (b *msgBuilder) u64(v uint64) { binary.Write(b, binary.BigEndian, v) }
+func (b *msgBuilder) fill(c byte, n int) {
+	b.Write(bytes.Repeat([]byte{c}, n))
+}
+
+func record(flags uint16, timestamp uint32, msg []byte) []byte {
+	var b msgBuilder
+	b.u16(flags)
+	b.u16(uint16(len(msg)))
+	b.u32(0xdeadbeef)
+	b.u32(timestamp)
+	b.Write(msg)
+	return b.Bytes()
+}
+
+const scid = uint64(103)<<40 | uint64(1)<<16 | 0
+
+func channelAnnouncement() []byte {
+	var b msgBuilder
+	b.u16(256)
+	b.fill(0x01, 64*4)
+	b.u16(1)
+	b.u8(0x02)
+	b.fill(0x06, 32)
+	b.u64(scid)
+	b.fill(0x02, 33)
+	b.fill(0x03, 33)
+	b.fill(0x04, 33)
+	b.fill(0x05, 33)
+	return b.Bytes()
+}
+
+func channelUpdate() []byte {
+	var b msgBuilder
+	b.u16(258)
+	b.fill(0x01, 64)
+	b.fill(0x06, 32)
+	b.u64(scid)
+	b.u32(1700000000)
+	b.u8(1)
+	b.u8(3)
+	b.u16(144)
+	b.u64(1000)
+	b.u32(1)
+	b.u32(10)
+	b.u64(990000000)
+	return b.Bytes()
+}
+
+func nodeAnnouncement() []byte {
+	var addrs msgBuilder
+	addrs.u8(1)
+	addrs.Write([]byte{127, 0, 0, 1})
+	addrs.u16(9735)
+	addrs.u8(4)
+	addrs.fill(0, 35)
+	addrs.u16(9736)
+	addrs.u8(5)
+	addrs.u8(11)
+	addrs.WriteString("example.com")
+	addrs.u16(9737)
+
+	var b msgBuilder
+	b.u16(257)
+	b.fill(0x01, 64)
+	b.u16(0)
+	b.u32(1700000001)
+	b.fill(0x02, 33)
+	b.Write([]byte{0xff, 0x00, 0x80})
+	b.WriteString("alice")
+	b.fill(0, 27)
+	b.u16(uint16(addrs.Len()))
+	b.Write(addrs.Bytes())
+	return b.Bytes()
+}
+
+func store() []byte {
+	var b msgBuilder
+	b.u8(13)
+	b.Write(record(0, 1700000000, channelAnnouncement()))
+	var amount msgBuilder
+	amount.u16(4101)
+	amount.u64(1000000)
+	b.Write(record(0, 0, amount.Bytes()))
+	b.Write(record(gossipstore.FlagDeleted, 1700000000, channelUpdate()))
+	b.Write(record(0, 1700000001, nodeAnnouncement()))
+	var dying msgBuilder
+	dying.u16(4106)
+	dying.u64(scid)
+	dying.u32(850012)
+	b.Write(record(gossipstore.FlagDying, 0, dying.Bytes()))
+	b.Write(record(0, 0, []byte{0x12, 0x34, 0xaa}))
+	return b.Bytes()
+}
+
+func readAll(t *testing.T, data []byte) []*gossipstore.Record {
+	reader, err := gossipstore.NewReader(bytes.NewReader(data))
+	assert.NoError(t, err)
+	var records []*gossipstore.Record
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return records
+		}
+		if !assert.NoError(t, err) {
+			return records
+		}
+		records = append(records, record)
+	}
+}
+
+func TestReader(t *testing.T) {
+	records := readAll(t, store())
+	assert.Len(t, records, 6)
+
+	ann := records[0].Msg.(*gossipstore.ChannelAnnouncement)
+	assert.Equal(t, int64(1), records[0].Offset)
+	assert.Equal(t, uint32(0xdeadbeef), records[0].CRC)
+	assert.Equal(t, glightning.ShortChannelId("103x1x0"), ann.ShortChannelId)
+	assert.Equal(t, strings.Repeat("02", 33), ann.NodeId1)
+	assert.Equal(t, strings.Repeat("05", 33), ann.BitcoinKey2)
+	assert.True(t, ann.Features.IsSet(glightning.FeatureBit(1)))
+
+	assert.Equal(t, &gossipstore.ChannelAmount{Satoshis: 1000000}, records[1].Msg)
+
+	update := records[2].Msg.(*gossipstore.ChannelUpdate)
+	assert.True(t, records[2].Deleted())
+	assert.Equal(t, uint16(gossipstore.TypeChannelUpdate), records[2].Type)
+	assert.Equal(t, uint8(1), update.Direction())
+	assert.True(t, update.Disabled())
+	assert.Equal(t, uint16(144), update.CltvExpiryDelta)
+	assert.Equal(t, uint64(990000000), update.HtlcMaximumMsat)
+	assert.Equal(t, uint32(10), update.FeePPM)
+
+	node := records[3].Msg.(*gossipstore.NodeAnnouncement)
+	assert.Equal(t, "alice", node.Alias)
+	assert.Equal(t, "ff0080", node.Color)
+	assert.Equal(t, uint32(1700000001), records[3].Timestamp)
+	assert.Equal(t, []glightning.Address{
+		{Type: glightning.AddrIPv4, Addr: "127.0.0.1", Port: 9735},
+		{Type: glightning.AddrTorV3, Addr: strings.Repeat("a", 56) + ".onion", Port: 9736},
+		{Type: glightning.AddrDNS, Addr: "example.com", Port: 9737},
+	}, node.Addresses)
+
+	assert.True(t, records[4].Dying())
+	assert.Equal(t, &gossipstore.ChannelDying{ShortChannelId: "103x1x0", BlockHeight: 850012}, records[4].Msg)
+
+	// unknown types are passed through undecoded
+	assert.Nil(t, records[5].Msg)
+	assert.Equal(t, uint16(0x1234), records[5].Type)
+	assert.Equal(t, "1234aa", hex.EncodeToString(records[5].Raw))
+}
+
+func TestReaderErrors(t *testing.T) {
+	_, err := gossipstore.NewReader(bytes.NewReader([]byte{0x29}))
+	assert.EqualError(t, err, "Unsupported gossip_store version 1.9")
+	_, err = gossipstore.NewReader(bytes.NewReader(nil))
+	assert.Error(t, err)
+
+	// a record cut off partway
+	data := store()
+	reader, _ := gossipstore.NewReader(bytes.NewReader(data[:40]))
+	_, err = reader.Next()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	// a message too short for its type
+	var b msgBuilder
+	b.u8(13)
+	b.Write(record(0, 0, []byte{0x01, 0x02, 0x00}))
+	reader, _ = gossipstore.NewReader(bytes.NewReader(b.Bytes()))
+	_, err = reader.Next()
+	assert.EqualError(t, err, "gossip_store record at 1: type 258: message truncated")
+}
+
+func TestReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gossipstore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "gossip_store")
+	assert.NoError(t, ioutil.WriteFile(path, store(), 0600))
+
+	var updates int
+	err = gossipstore.ReadFile(path, func(r *gossipstore.Record) error {
+		if _, ok := r.Msg.(*gossipstore.ChannelUpdate); ok {
+			updates++
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, updates)
+}
@@ -0,0 +1,219 @@
+package gossipstore
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/elementsproject/glightning/glightning"
+)
+
+// Message types
+const (
+	TypeChannelAnnouncement uint16 = 256
+	TypeNodeAnnouncement    uint16 = 257
+	TypeChannelUpdate       uint16 = 258
+
+	TypeChannelAmount uint16 = 4101
+	TypeDeleteChannel uint16 = 4103
+	TypeStoreEnded    uint16 = 4105
+	TypeChannelDying  uint16 = 4106
+)
+
+type ChannelAnnouncement struct {
+	NodeSignature1    string
+	NodeSignature2    string
+	BitcoinSignature1 string
+	BitcoinSignature2 string
+	Features          glightning.Features
+	ChainHash         string
+	ShortChannelId    glightning.ShortChannelId
+	NodeId1           string
+	NodeId2           string
+	BitcoinKey1       string
+	BitcoinKey2       string
+}
+
+type NodeAnnouncement struct {
+	Signature string
+	Features  glightning.Features
+	Timestamp uint32
+	NodeId    string
+	// As "rrggbb"
+	Color     string
+	Alias     string
+	Addresses []glightning.Address
+}
+
+type ChannelUpdate = glightning.ChannelUpdate
+
+// The capacity of the channel announced by the preceding record
+type ChannelAmount struct {
+	Satoshis uint64
+}
+
+// A channel has been closed and its records deleted
+type DeleteChannel struct {
+	ShortChannelId glightning.ShortChannelId
+}
+
+// A channel's funding output has been spent
+type ChannelDying struct {
+	ShortChannelId glightning.ShortChannelId
+	// The block height at which it will be forgotten
+	BlockHeight uint32
+}
+
+// The store has been compacted into a new file; this one is done.
+// Continue reading the new file from {EquivalentOffset}.
+type StoreEnded struct {
+	EquivalentOffset uint64
+}
+
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	if len(d.data) < n {
+		d.err = fmt.Errorf("message truncated")
+		return make([]byte, n)
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) hex(n int) string {
+	return hex.EncodeToString(d.take(n))
+}
+
+func (d *decoder) u8() uint8 {
+	return d.take(1)[0]
+}
+
+func (d *decoder) u16() uint16 {
+	return binary.BigEndian.Uint16(d.take(2))
+}
+
+func (d *decoder) u32() uint32 {
+	return binary.BigEndian.Uint32(d.take(4))
+}
+
+func (d *decoder) u64() uint64 {
+	return binary.BigEndian.Uint64(d.take(8))
+}
+
+func (d *decoder) scid() glightning.ShortChannelId {
+	return glightning.ShortChannelIdFromUint64(d.u64())
+}
+
+// BOLT#7 byte arrays are a u16 length then the bytes
+func (d *decoder) lenBytes() []byte {
+	return append([]byte{}, d.take(int(d.u16()))...)
+}
+
+func decode(msgType uint16, payload []byte) (interface{}, error) {
+	d := &decoder{data: payload}
+	var msg interface{}
+	switch msgType {
+	case TypeChannelAnnouncement:
+		msg = &ChannelAnnouncement{
+			NodeSignature1:    d.hex(64),
+			NodeSignature2:    d.hex(64),
+			BitcoinSignature1: d.hex(64),
+			BitcoinSignature2: d.hex(64),
+			Features:          glightning.Features(d.lenBytes()),
+			ChainHash:         d.hex(32),
+			ShortChannelId:    d.scid(),
+			NodeId1:           d.hex(33),
+			NodeId2:           d.hex(33),
+			BitcoinKey1:       d.hex(33),
+			BitcoinKey2:       d.hex(33),
+		}
+	case TypeNodeAnnouncement:
+		ann := &NodeAnnouncement{
+			Signature: d.hex(64),
+			Features:  glightning.Features(d.lenBytes()),
+			Timestamp: d.u32(),
+			NodeId:    d.hex(33),
+			Color:     d.hex(3),
+			Alias:     strings.TrimRight(string(d.take(32)), "\x00"),
+		}
+		addrs := d.lenBytes()
+		if d.err == nil {
+			var err error
+			ann.Addresses, err = decodeAddresses(addrs)
+			if err != nil {
+				return nil, err
+			}
+		}
+		msg = ann
+	case TypeChannelUpdate:
+		update, err := glightning.DecodeChannelUpdate(payload)
+		if err != nil {
+			return nil, fmt.Errorf("type %d: %s", msgType, err)
+		}
+		msg = update
+	case TypeChannelAmount:
+		msg = &ChannelAmount{Satoshis: d.u64()}
+	case TypeDeleteChannel:
+		msg = &DeleteChannel{ShortChannelId: d.scid()}
+	case TypeChannelDying:
+		msg = &ChannelDying{ShortChannelId: d.scid(), BlockHeight: d.u32()}
+	case TypeStoreEnded:
+		msg = &StoreEnded{EquivalentOffset: d.u64()}
+	default:
+		return nil, nil
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("type %d: %s", msgType, d.err)
+	}
+	return msg, nil
+}
+
+var onionEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func decodeAddresses(data []byte) ([]glightning.Address, error) {
+	d := &decoder{data: data}
+	var addrs []glightning.Address
+	for len(d.data) > 0 && d.err == nil {
+		var addr glightning.Address
+		switch desc := d.u8(); desc {
+		case 1:
+			addr.Type = glightning.AddrIPv4
+			addr.Addr = net.IP(d.take(4)).String()
+		case 2:
+			addr.Type = glightning.AddrIPv6
+			addr.Addr = net.IP(d.take(16)).String()
+		case 3:
+			addr.Type = glightning.AddrTorV2
+			addr.Addr = strings.ToLower(onionEncoding.EncodeToString(d.take(10))) + ".onion"
+		case 4:
+			addr.Type = glightning.AddrTorV3
+			addr.Addr = strings.ToLower(onionEncoding.EncodeToString(d.take(35))) + ".onion"
+		case 5:
+			addr.Type = glightning.AddrDNS
+			addr.Addr = string(d.take(int(d.u8())))
+		default:
+			// addresses are in ascending order of type, and we
+			// can't know the length of an unknown one
+			return addrs, nil
+		}
+		addr.Port = int(d.u16())
+		if d.err == nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("bad address %d: %s", len(addrs), d.err)
+	}
+	return addrs, nil
+}
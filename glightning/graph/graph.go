@@ -0,0 +1,272 @@
+// Package graph keeps an indexed, in-memory copy of the lightning
+// network's channel graph, as seen by lightningd's listchannels and
+// listnodes, and runs path queries over it.
+//
+// It's meant for client-side routing experiments; getroute remains
+// the way to get routes lightningd itself would use.
+package graph
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/elementsproject/glightning/glightning"
+)
+
+// One direction of a channel
+type Edge struct {
+	Source         string
+	Destination    string
+	ShortChannelId glightning.ShortChannelId
+	// 0 or 1, as used in "scid/direction" exclusions
+	Direction    uint8
+	CapacityMsat uint64
+	BaseFeeMsat  uint64
+	FeePPM       uint64
+	Delay        uint
+	HtlcMinMsat  uint64
+	// Zero if the channel doesn't advertise a maximum
+	HtlcMaxMsat uint64
+	Active      bool
+	Public      bool
+	LastUpdate  uint
+}
+
+// Fee charged to forward {amount} msat over this edge
+func (e *Edge) Fee(amount uint64) uint64 {
+	return e.BaseFeeMsat + amount*e.FeePPM/1000000
+}
+
+// Whether {amount} msat fits between the edge's htlc limits and
+// capacity
+func (e *Edge) CanCarry(amount uint64) bool {
+	if amount < e.HtlcMinMsat {
+		return false
+	}
+	if e.HtlcMaxMsat != 0 && amount > e.HtlcMaxMsat {
+		return false
+	}
+	return e.CapacityMsat == 0 || amount <= e.CapacityMsat
+}
+
+// The edge in "scid/direction" form
+func (e *Edge) String() string {
+	return fmt.Sprintf("%s/%d", e.ShortChannelId, e.Direction)
+}
+
+type Node struct {
+	Id    string
+	Alias string
+	// Edges leaving this node
+	Out []*Edge
+}
+
+type edgeKey struct {
+	scid      glightning.ShortChannelId
+	direction uint8
+}
+
+// Graph is safe for concurrent use.
+type Graph struct {
+	mu    sync.RWMutex
+	nodes map[string]*Node
+	edges map[edgeKey]*Edge
+}
+
+func New() *Graph {
+	return &Graph{
+		nodes: make(map[string]*Node),
+		edges: make(map[edgeKey]*Edge),
+	}
+}
+
+// Build a graph from lightningd's view of the network
+func Load(client glightning.LightningClient) (*Graph, error) {
+	g := New()
+	if err := g.Refresh(client); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Replace the graph's contents with a fresh listchannels/listnodes
+func (g *Graph) Refresh(client glightning.LightningClient) error {
+	channels, err := client.ListChannels()
+	if err != nil {
+		return err
+	}
+	nodes, err := client.ListNodes()
+	if err != nil {
+		return err
+	}
+
+	fresh := New()
+	for _, node := range nodes {
+		fresh.node(node.Id).Alias = node.Alias
+	}
+	for _, channel := range channels {
+		fresh.addChannel(channel)
+	}
+
+	g.mu.Lock()
+	g.nodes, g.edges = fresh.nodes, fresh.edges
+	g.mu.Unlock()
+	return nil
+}
+
+// Add or update one direction of a channel, e.g. after a
+// channel_update. Updates older than the edge already held are
+// ignored.
+func (g *Graph) UpdateChannel(channel *glightning.Channel) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.addChannel(channel)
+}
+
+// Refetch channel {scid} from lightningd, dropping it from the
+// graph if it's gone
+func (g *Graph) RefreshChannel(client glightning.LightningClient, scid string) error {
+	halves, err := client.GetChannel(scid)
+	if err != nil {
+		// GetChannel errors if there's no such channel
+		g.RemoveChannel(glightning.ShortChannelId(scid))
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, half := range halves {
+		g.addChannel(half)
+	}
+	return nil
+}
+
+// Remove both directions of channel {scid}
+func (g *Graph) RemoveChannel(scid glightning.ShortChannelId) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for direction := uint8(0); direction < 2; direction++ {
+		key := edgeKey{scid, direction}
+		edge, ok := g.edges[key]
+		if !ok {
+			continue
+		}
+		delete(g.edges, key)
+		node := g.nodes[edge.Source]
+		for i, out := range node.Out {
+			if out == edge {
+				node.Out = append(node.Out[:i], node.Out[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Keep the graph updated from a plugin's notifications. lightningd
+// doesn't notify plugins of gossip, so this refetches the channels
+// named in channel_state_changed notifications;
+// call Refresh periodically to pick up the rest of the network.
+// Close the returned subscription to stop following.
+func (g *Graph) Follow(client glightning.LightningClient, events *glightning.Events) *glightning.EventSubscription {
+	sub := events.Subscribe(16, glightning.OnlyKinds(glightning.EventChannelStateChanged))
+	go func() {
+		for event := range sub.C {
+			changed := event.Payload.(*glightning.ChannelStateChanged)
+			if changed.ShortChannelId != "" {
+				g.RefreshChannel(client, string(changed.ShortChannelId))
+			}
+		}
+	}()
+	return sub
+}
+
+func (g *Graph) node(id string) *Node {
+	node, ok := g.nodes[id]
+	if !ok {
+		node = &Node{Id: id}
+		g.nodes[id] = node
+	}
+	return node
+}
+
+func (g *Graph) addChannel(c *glightning.Channel) {
+	edge := &Edge{
+		Source:         c.Source,
+		Destination:    c.Destination,
+		ShortChannelId: c.ShortChannelId,
+		Direction:      uint8(c.ChannelFlags & 1),
+		CapacityMsat:   c.Satoshis * 1000,
+		BaseFeeMsat:    c.BaseFeeMillisatoshi,
+		FeePPM:         c.FeePerMillionth,
+		Delay:          c.Delay,
+		HtlcMinMsat:    parseMsat(c.HtlcMinimumMilliSatoshis),
+		HtlcMaxMsat:    parseMsat(c.HtlcMaximumMilliSatoshis),
+		Active:         c.IsActive,
+		Public:         c.IsPublic,
+		LastUpdate:     c.LastUpdate,
+	}
+	if edge.CapacityMsat == 0 {
+		edge.CapacityMsat = parseMsat(c.AmountMsat)
+	}
+
+	key := edgeKey{edge.ShortChannelId, edge.Direction}
+	if old, ok := g.edges[key]; ok {
+		if old.LastUpdate > edge.LastUpdate {
+			return
+		}
+		*old = *edge
+		return
+	}
+	g.edges[key] = edge
+	source := g.node(edge.Source)
+	source.Out = append(source.Out, edge)
+	g.node(edge.Destination)
+}
+
+func parseMsat(amount string) uint64 {
+	if amount == "" {
+		return 0
+	}
+	msat, err := glightning.ParseMSat(amount)
+	if err != nil {
+		return 0
+	}
+	return msat.Value
+}
+
+// Look up a node; nil if it isn't in the graph
+func (g *Graph) Node(id string) *Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.nodes[id]
+}
+
+// Look up one direction of a channel; nil if it isn't in the graph
+func (g *Graph) Edge(scid glightning.ShortChannelId, direction uint8) *Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.edges[edgeKey{scid, direction}]
+}
+
+// All the nodes in the graph, in no particular order
+func (g *Graph) Nodes() []*Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	nodes := make([]*Node, 0, len(g.nodes))
+	for _, node := range g.nodes {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func (g *Graph) NodeCount() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.nodes)
+}
+
+// Number of channel directions held
+func (g *Graph) EdgeCount() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.edges)
+}
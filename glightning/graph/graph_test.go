@@ -0,0 +1,165 @@
+package graph_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/graph"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// A -> B -> D costs 1000 + 1000; A -> C -> D costs 10 + 10;
+// A -> C -> B -> D exists too, and A -> D is too small
+func testChannels() []*glightning.Channel {
+	half := func(src, dst, scid string, flags uint, base uint64, sats uint64) *glightning.Channel {
+		return &glightning.Channel{
+			Source:              src,
+			Destination:         dst,
+			ShortChannelId:      glightning.ShortChannelId(scid),
+			ChannelFlags:        flags,
+			Satoshis:            sats,
+			IsActive:            true,
+			BaseFeeMillisatoshi: base,
+			FeePerMillionth:     0,
+			Delay:               6,
+			LastUpdate:          100,
+		}
+	}
+	return []*glightning.Channel{
+		half("A", "B", "1x1x0", 0, 1000, 1000000),
+		half("B", "A", "1x1x0", 1, 1000, 1000000),
+		half("B", "D", "2x1x0", 0, 1000, 1000000),
+		half("A", "C", "3x1x0", 0, 10, 1000000),
+		half("C", "D", "4x1x0", 0, 10, 1000000),
+		half("C", "B", "5x1x0", 0, 1, 1000000),
+		half("A", "D", "6x1x0", 0, 0, 1),
+	}
+}
+
+func loadGraph(t *testing.T) (*graph.Graph, *mock.Lightning) {
+	ln := mock.New()
+	ln.ListChannelsFunc = func() ([]*glightning.Channel, error) {
+		return testChannels(), nil
+	}
+	ln.ListNodesFunc = func() ([]*glightning.Node, error) {
+		return []*glightning.Node{{Id: "A", Alias: "alice"}, {Id: "E", Alias: "lonely"}}, nil
+	}
+	g, err := graph.Load(ln)
+	assert.NoError(t, err)
+	return g, ln
+}
+
+func scids(p *graph.Path) []string {
+	var ids []string
+	for _, e := range p.Edges {
+		ids = append(ids, e.String())
+	}
+	return ids
+}
+
+func TestLoad(t *testing.T) {
+	g, _ := loadGraph(t)
+	assert.Equal(t, 5, g.NodeCount())
+	assert.Len(t, g.Nodes(), 5)
+	assert.Equal(t, 7, g.EdgeCount())
+	assert.Equal(t, "alice", g.Node("A").Alias)
+	assert.Len(t, g.Node("A").Out, 3)
+	assert.Empty(t, g.Node("E").Out)
+
+	edge := g.Edge("1x1x0", 1)
+	assert.Equal(t, "B", edge.Source)
+	assert.Equal(t, uint64(1000000000), edge.CapacityMsat)
+}
+
+func TestShortestPath(t *testing.T) {
+	g, _ := loadGraph(t)
+
+	path, err := g.ShortestPath("A", "D", 5000, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3x1x0/0", "4x1x0/0"}, scids(path))
+	assert.Equal(t, uint64(10), path.Fee(5000))
+
+	// the direct channel is too small for 5000msat, but fine for 500
+	path, err = g.ShortestPath("A", "D", 500, graph.HopCost)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"6x1x0/0"}, scids(path))
+
+	_, err = g.ShortestPath("A", "E", 500, nil)
+	assert.EqualError(t, err, "No path from A to E for 500msat")
+	_, err = g.ShortestPath("A", "Z", 500, nil)
+	assert.EqualError(t, err, "Node Z is not in the graph")
+}
+
+func TestKShortestPaths(t *testing.T) {
+	g, _ := loadGraph(t)
+	paths, err := g.KShortestPaths("A", "D", 5000, 5, nil)
+	assert.NoError(t, err)
+	assert.Len(t, paths, 3)
+	assert.Equal(t, []string{"3x1x0/0", "4x1x0/0"}, scids(paths[0]))
+	assert.Equal(t, []string{"3x1x0/0", "5x1x0/0", "2x1x0/0"}, scids(paths[1]))
+	assert.Equal(t, []string{"1x1x0/0", "2x1x0/0"}, scids(paths[2]))
+	assert.Equal(t, float64(20), paths[0].Cost)
+	assert.Equal(t, float64(1011), paths[1].Cost)
+	assert.Equal(t, float64(2000), paths[2].Cost)
+}
+
+func TestPathRoute(t *testing.T) {
+	g, _ := loadGraph(t)
+	paths, _ := g.KShortestPaths("A", "D", 5000, 2, nil)
+	route := paths[1].Route(5000, 9)
+	assert.Equal(t, []glightning.RouteHop{
+		{Id: "C", ShortChannelId: "3x1x0", MilliSatoshi: 6001, Delay: 21},
+		{Id: "B", ShortChannelId: "5x1x0", MilliSatoshi: 6000, Delay: 15},
+		{Id: "D", ShortChannelId: "2x1x0", MilliSatoshi: 5000, Delay: 9},
+	}, route)
+	assert.Equal(t, uint64(1001), paths[1].Fee(5000))
+}
+
+func TestUpdates(t *testing.T) {
+	g, ln := loadGraph(t)
+
+	// older updates are ignored
+	stale := testChannels()[3]
+	stale.BaseFeeMillisatoshi = 5000
+	stale.LastUpdate = 50
+	g.UpdateChannel(stale)
+	assert.Equal(t, uint64(10), g.Edge("3x1x0", 0).BaseFeeMsat)
+
+	// a disabled channel is routed around
+	update := testChannels()[3]
+	update.IsActive = false
+	update.LastUpdate = 200
+	g.UpdateChannel(update)
+	path, err := g.ShortestPath("A", "D", 5000, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1x1x0/0", "2x1x0/0"}, scids(path))
+
+	g.RemoveChannel("1x1x0")
+	assert.Nil(t, g.Edge("1x1x0", 0))
+	assert.Len(t, g.Node("A").Out, 2)
+	assert.Len(t, g.Node("B").Out, 1)
+
+	ln.GetChannelFunc = func(shortChanId string) ([]*glightning.Channel, error) {
+		assert.Equal(t, "1x1x0", shortChanId)
+		return testChannels()[:2], nil
+	}
+	assert.NoError(t, g.RefreshChannel(ln, "1x1x0"))
+	assert.NotNil(t, g.Edge("1x1x0", 0))
+	assert.Equal(t, 7, g.EdgeCount())
+}
+
+func TestFollow(t *testing.T) {
+	g, ln := loadGraph(t)
+	refreshed := make(chan string, 1)
+	ln.GetChannelFunc = func(shortChanId string) ([]*glightning.Channel, error) {
+		refreshed <- shortChanId
+		return testChannels()[2:3], nil
+	}
+
+	events := glightning.NewEvents(glightning.NewPlugin(func(*glightning.Plugin, map[string]glightning.Option, *glightning.Config) {}))
+	sub := g.Follow(ln, events)
+	defer sub.Close()
+	events.Publish(glightning.EventChannelStateChanged, &glightning.ChannelStateChanged{ShortChannelId: "2x1x0", NewState: "CHANNELD_NORMAL"})
+	assert.Equal(t, "2x1x0", <-refreshed)
+}
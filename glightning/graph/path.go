@@ -0,0 +1,257 @@
+package graph
+
+import (
+	"container/heap"
+	"fmt"
+
+	"github.com/elementsproject/glightning/glightning"
+)
+
+// The cost of sending {amount} msat over edge {e}; lower is better.
+// Return false to rule the edge out entirely. Costs must not be
+// negative.
+//
+// Queries only consider active edges which can carry the amount, so
+// cost functions needn't check for that.
+type CostFunc func(e *Edge, amount uint64) (float64, bool)
+
+// Prefer the cheapest routes, by fee
+func FeeCost(e *Edge, amount uint64) (float64, bool) {
+	return float64(e.Fee(amount)), true
+}
+
+// Prefer the shortest routes, by hop count
+func HopCost(e *Edge, amount uint64) (float64, bool) {
+	return 1, true
+}
+
+// Trade fees off against the time funds could be locked up for,
+// as lightningd does: {riskFactor} is the annual percentage cost
+// assumed for locked funds.
+func RiskCost(riskFactor float64) CostFunc {
+	return func(e *Edge, amount uint64) (float64, bool) {
+		// blocks per year, roughly
+		risk := float64(amount) * float64(e.Delay) * riskFactor / 100 / 52560
+		return float64(e.Fee(amount)) + risk + 1, true
+	}
+}
+
+type Path struct {
+	Edges []*Edge
+	Cost  float64
+}
+
+// The lightningd route for delivering {amount} msat along the path,
+// with {finalCltv} as the last hop's delay. Fees and delays are
+// added back from the destination, as sendpay expects.
+func (p *Path) Route(amount uint64, finalCltv uint) []glightning.RouteHop {
+	route := make([]glightning.RouteHop, len(p.Edges))
+	delay := finalCltv
+	for i := len(p.Edges) - 1; i >= 0; i-- {
+		edge := p.Edges[i]
+		route[i] = glightning.RouteHop{
+			Id:             edge.Destination,
+			ShortChannelId: edge.ShortChannelId,
+			MilliSatoshi:   amount,
+			Delay:          delay,
+			Direction:      edge.Direction,
+		}
+		// the hop's source charges for forwarding over it
+		if i > 0 {
+			amount += edge.Fee(amount)
+			delay += edge.Delay
+		}
+	}
+	return route
+}
+
+// Total fee paid to intermediate nodes when delivering {amount}
+func (p *Path) Fee(amount uint64) uint64 {
+	route := p.Route(amount, 0)
+	if len(route) == 0 {
+		return 0
+	}
+	return route[0].MilliSatoshi - amount
+}
+
+// The cheapest path from {from} to {to} able to carry {amount} msat,
+// according to {cost} (FeeCost if nil). Edges are costed with the
+// amount delivered, ignoring the fees added along the way.
+func (g *Graph) ShortestPath(from, to string, amount uint64, cost CostFunc) (*Path, error) {
+	paths, err := g.KShortestPaths(from, to, amount, 1, cost)
+	if err != nil {
+		return nil, err
+	}
+	return paths[0], nil
+}
+
+// Up to {k} cheapest loop-free paths from {from} to {to}, cheapest
+// first (Yen's algorithm). See ShortestPath.
+func (g *Graph) KShortestPaths(from, to string, amount uint64, k int, cost CostFunc) ([]*Path, error) {
+	if cost == nil {
+		cost = FeeCost
+	}
+	if from == to {
+		return nil, fmt.Errorf("Must find a path between two different nodes")
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if _, ok := g.nodes[from]; !ok {
+		return nil, fmt.Errorf("Node %s is not in the graph", from)
+	}
+	if _, ok := g.nodes[to]; !ok {
+		return nil, fmt.Errorf("Node %s is not in the graph", to)
+	}
+
+	first := g.dijkstra(from, to, amount, cost, nil, nil)
+	if first == nil {
+		return nil, fmt.Errorf("No path from %s to %s for %dmsat", from, to, amount)
+	}
+
+	found := []*Path{first}
+	var candidates []*Path
+	for len(found) < k {
+		prev := found[len(found)-1]
+		for i := range prev.Edges {
+			spur := from
+			if i > 0 {
+				spur = prev.Edges[i-1].Destination
+			}
+			root := prev.Edges[:i]
+
+			excludedEdges := make(map[*Edge]bool)
+			for _, p := range found {
+				if len(p.Edges) > i && sameEdges(p.Edges[:i], root) {
+					excludedEdges[p.Edges[i]] = true
+				}
+			}
+			excludedNodes := map[string]bool{from: true}
+			for _, edge := range root {
+				excludedNodes[edge.Destination] = true
+			}
+			delete(excludedNodes, spur)
+
+			spurPath := g.dijkstra(spur, to, amount, cost, excludedEdges, excludedNodes)
+			if spurPath == nil {
+				continue
+			}
+			path := &Path{
+				Edges: append(append([]*Edge{}, root...), spurPath.Edges...),
+				Cost:  spurPath.Cost,
+			}
+			for _, edge := range root {
+				c, _ := cost(edge, amount)
+				path.Cost += c
+			}
+			if !containsPath(candidates, path) && !containsPath(found, path) {
+				candidates = append(candidates, path)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+
+		best := 0
+		for i, c := range candidates {
+			if c.Cost < candidates[best].Cost {
+				best = i
+			}
+		}
+		found = append(found, candidates[best])
+		candidates = append(candidates[:best], candidates[best+1:]...)
+	}
+	return found, nil
+}
+
+func sameEdges(a, b []*Edge) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPath(paths []*Path, path *Path) bool {
+	for _, p := range paths {
+		if sameEdges(p.Edges, path.Edges) {
+			return true
+		}
+	}
+	return false
+}
+
+type queueItem struct {
+	node string
+	cost float64
+}
+
+type queue []*queueItem
+
+func (q queue) Len() int            { return len(q) }
+func (q queue) Less(i, j int) bool  { return q[i].cost < q[j].cost }
+func (q queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x interface{}) { *q = append(*q, x.(*queueItem)) }
+func (q *queue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// Caller holds g.mu
+func (g *Graph) dijkstra(from, to string, amount uint64, cost CostFunc, excludedEdges map[*Edge]bool, excludedNodes map[string]bool) *Path {
+	dist := map[string]float64{from: 0}
+	via := make(map[string]*Edge)
+	done := make(map[string]bool)
+
+	q := &queue{{node: from}}
+	for q.Len() > 0 {
+		item := heap.Pop(q).(*queueItem)
+		if done[item.node] {
+			continue
+		}
+		done[item.node] = true
+		if item.node == to {
+			break
+		}
+
+		node := g.nodes[item.node]
+		if node == nil {
+			continue
+		}
+		for _, edge := range node.Out {
+			if !edge.Active || !edge.CanCarry(amount) ||
+				excludedEdges[edge] || excludedNodes[edge.Destination] || done[edge.Destination] {
+				continue
+			}
+			c, ok := cost(edge, amount)
+			if !ok {
+				continue
+			}
+			d := item.cost + c
+			if old, seen := dist[edge.Destination]; seen && old <= d {
+				continue
+			}
+			dist[edge.Destination] = d
+			via[edge.Destination] = edge
+			heap.Push(q, &queueItem{node: edge.Destination, cost: d})
+		}
+	}
+
+	if !done[to] {
+		return nil
+	}
+	var edges []*Edge
+	for node := to; node != from; {
+		edge := via[node]
+		edges = append([]*Edge{edge}, edges...)
+		node = edge.Source
+	}
+	return &Path{Edges: edges, Cost: dist[to]}
+}
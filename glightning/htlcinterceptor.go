@@ -0,0 +1,202 @@
+package glightning
+
+import (
+	"sync"
+)
+
+// Whether an interceptor rule applies to an HTLC
+type HtlcMatcher func(event *HtlcAcceptedEvent) bool
+
+// What to do with an HTLC a rule matched. Return a nil response to
+// pass it on to the next matching rule.
+type HtlcDecision func(event *HtlcAcceptedEvent) (*HtlcAcceptedResponse, error)
+
+// HTLCs being forwarded out over any of {scids}
+func MatchScid(scids ...string) HtlcMatcher {
+	set := make(map[string]bool, len(scids))
+	for _, scid := range scids {
+		set[scid] = true
+	}
+	return func(event *HtlcAcceptedEvent) bool {
+		return set[event.Onion.ShortChannelId]
+	}
+}
+
+// HTLCs for {min} to {max} msat, inclusive. A {max} of 0 means no
+// upper limit.
+func MatchAmount(min, max uint64) HtlcMatcher {
+	return func(event *HtlcAcceptedEvent) bool {
+		amount := msatOr(event.Htlc.AmountMilliSatoshi, 0)
+		return amount >= min && (max == 0 || amount <= max)
+	}
+}
+
+// HTLCs paying any of {hashes}
+func MatchPaymentHash(hashes ...string) HtlcMatcher {
+	set := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		set[hash] = true
+	}
+	return func(event *HtlcAcceptedEvent) bool {
+		return set[event.Htlc.PaymentHash]
+	}
+}
+
+// HTLCs which are to be forwarded, rather than paying this node
+func MatchForward() HtlcMatcher {
+	return func(event *HtlcAcceptedEvent) bool {
+		return event.Onion.ShortChannelId != ""
+	}
+}
+
+// HTLCs matched by all of {matchers}
+func MatchAll(matchers ...HtlcMatcher) HtlcMatcher {
+	return func(event *HtlcAcceptedEvent) bool {
+		for _, match := range matchers {
+			if !match(event) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Fail matched HTLCs with {failCode}
+func FailHtlc(failCode uint16) HtlcDecision {
+	return func(event *HtlcAcceptedEvent) (*HtlcAcceptedResponse, error) {
+		return event.Fail(failCode), nil
+	}
+}
+
+// Let matched HTLCs through, skipping any later rules
+func ContinueHtlc() HtlcDecision {
+	return func(event *HtlcAcceptedEvent) (*HtlcAcceptedResponse, error) {
+		return event.Continue(), nil
+	}
+}
+
+type HtlcInterceptorStats struct {
+	Total     uint64
+	Continued uint64
+	Failed    uint64
+	Resolved  uint64
+	// Decisions which returned an error; the HTLC is continued
+	Errors uint64
+	// HTLCs decided by each rule, by name. Those no rule decided
+	// aren't counted here.
+	ByRule map[string]uint64
+}
+
+type htlcRule struct {
+	name   string
+	match  HtlcMatcher
+	decide HtlcDecision
+}
+
+// HtlcInterceptor runs the htlc_accepted hook as a list of rules,
+// each a matcher and a decision, so policies can be written without
+// each reimplementing the hook's plumbing.
+//
+//	interceptor := glightning.NewHtlcInterceptor()
+//	interceptor.Handle("no-dust", glightning.MatchAmount(0, 1000), glightning.FailHtlc(0x400f))
+//	interceptor.Handle("watch", glightning.MatchScid("103x1x0"), watchHtlc)
+//	err := interceptor.Register(plugin)
+//
+// Rules are tried in the order they were added; the first whose
+// decision returns a response decides the HTLC. HTLCs no rule
+// decides are continued.
+//
+// lightningd replays undecided HTLCs on startup, so decisions may
+// see the same HTLC more than once.
+type HtlcInterceptor struct {
+	// Called with any error a decision returns. The HTLC is continued
+	// rather than passing the error back to lightningd, which treats
+	// a failed htlc_accepted hook as fatal.
+	OnError func(event *HtlcAcceptedEvent, err error)
+
+	mu    sync.RWMutex
+	rules []*htlcRule
+	stats HtlcInterceptorStats
+}
+
+func NewHtlcInterceptor() *HtlcInterceptor {
+	return &HtlcInterceptor{
+		stats: HtlcInterceptorStats{ByRule: make(map[string]uint64)},
+	}
+}
+
+// Add a rule, after those already added. A nil {match} matches every
+// HTLC.
+func (i *HtlcInterceptor) Handle(name string, match HtlcMatcher, decide HtlcDecision) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rules = append(i.rules, &htlcRule{name, match, decide})
+}
+
+// Register the interceptor as {plugin}'s htlc_accepted hook. Like
+// RegisterHooks, this must be called before the plugin is started.
+func (i *HtlcInterceptor) Register(plugin *Plugin) error {
+	return plugin.RegisterHooks(&Hooks{
+		HtlcAccepted: i.HtlcAccepted,
+	})
+}
+
+// The hook itself, for use with RegisterHooks alongside other hooks
+func (i *HtlcInterceptor) HtlcAccepted(event *HtlcAcceptedEvent) (*HtlcAcceptedResponse, error) {
+	i.mu.RLock()
+	rules := i.rules
+	i.mu.RUnlock()
+
+	var response *HtlcAcceptedResponse
+	var decidedBy string
+	var failed bool
+	for _, rule := range rules {
+		if rule.match != nil && !rule.match(event) {
+			continue
+		}
+		resp, err := rule.decide(event)
+		if err != nil {
+			failed = true
+			if i.OnError != nil {
+				i.OnError(event, err)
+			}
+			break
+		}
+		if resp != nil {
+			response, decidedBy = resp, rule.name
+			break
+		}
+	}
+	if response == nil {
+		response = event.Continue()
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.stats.Total++
+	if failed {
+		i.stats.Errors++
+	} else if decidedBy != "" {
+		i.stats.ByRule[decidedBy]++
+	}
+	switch response.Result {
+	case _HcContinue:
+		i.stats.Continued++
+	case _HcFail:
+		i.stats.Failed++
+	case _HcResolve:
+		i.stats.Resolved++
+	}
+	return response, nil
+}
+
+func (i *HtlcInterceptor) Stats() HtlcInterceptorStats {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	stats := i.stats
+	stats.ByRule = make(map[string]uint64, len(i.stats.ByRule))
+	for name, count := range i.stats.ByRule {
+		stats.ByRule[name] = count
+	}
+	return stats
+}
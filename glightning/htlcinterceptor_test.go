@@ -0,0 +1,73 @@
+package glightning_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func htlc(scid, amount, hash string) *glightning.HtlcAcceptedEvent {
+	return &glightning.HtlcAcceptedEvent{
+		Onion: glightning.Onion{ShortChannelId: scid},
+		Htlc:  glightning.HtlcOffer{AmountMilliSatoshi: amount, PaymentHash: hash},
+	}
+}
+
+func TestHtlcInterceptor(t *testing.T) {
+	interceptor := glightning.NewHtlcInterceptor()
+	var watched []string
+	interceptor.Handle("watch", glightning.MatchScid("103x1x0"), func(event *glightning.HtlcAcceptedEvent) (*glightning.HtlcAcceptedResponse, error) {
+		watched = append(watched, event.Htlc.PaymentHash)
+		return nil, nil
+	})
+	interceptor.Handle("no-dust", glightning.MatchAll(glightning.MatchForward(), glightning.MatchAmount(0, 1000)), glightning.FailHtlc(0x400f))
+	interceptor.Handle("allow", glightning.MatchPaymentHash("bb"), glightning.ContinueHtlc())
+	interceptor.Handle("resolve", glightning.MatchPaymentHash("bb", "cc"), func(event *glightning.HtlcAcceptedEvent) (*glightning.HtlcAcceptedResponse, error) {
+		return event.Resolve("00ff"), nil
+	})
+	interceptor.Handle("broken", glightning.MatchPaymentHash("dd"), func(event *glightning.HtlcAcceptedEvent) (*glightning.HtlcAcceptedResponse, error) {
+		return nil, errors.New("policy engine is down")
+	})
+	var errs []error
+	interceptor.OnError = func(event *glightning.HtlcAcceptedEvent, err error) {
+		errs = append(errs, err)
+	}
+
+	decide := func(event *glightning.HtlcAcceptedEvent) *glightning.HtlcAcceptedResponse {
+		resp, err := interceptor.HtlcAccepted(event)
+		assert.NoError(t, err)
+		return resp
+	}
+	code := uint16(0x400f)
+	assert.Equal(t, &glightning.HtlcAcceptedResponse{Result: "fail", FailureCode: &code}, decide(htlc("103x1x0", "999msat", "aa")))
+	assert.Equal(t, "continue", string(decide(htlc("103x1x0", "5000msat", "aa")).Result))
+	// paying us, so not dust
+	assert.Equal(t, "continue", string(decide(htlc("", "999msat", "bb")).Result))
+	assert.Equal(t, &glightning.HtlcAcceptedResponse{Result: "resolve", PaymentKey: "00ff"}, decide(htlc("", "5000msat", "cc")))
+	assert.Equal(t, "continue", string(decide(htlc("", "5000msat", "dd")).Result))
+
+	assert.Equal(t, []string{"aa", "aa"}, watched)
+	assert.EqualError(t, errs[0], "policy engine is down")
+	assert.Equal(t, glightning.HtlcInterceptorStats{
+		Total:     5,
+		Continued: 3,
+		Failed:    1,
+		Resolved:  1,
+		Errors:    1,
+		ByRule:    map[string]uint64{"no-dust": 1, "allow": 1, "resolve": 1},
+	}, interceptor.Stats())
+}
+
+func TestHtlcInterceptorHook(t *testing.T) {
+	plugin := glightning.NewPlugin(nullInitFunc)
+	interceptor := glightning.NewHtlcInterceptor()
+	interceptor.Handle("fail-all", nil, glightning.FailHtlc(0x2002))
+	assert.NoError(t, interceptor.Register(plugin))
+
+	msg := `{"jsonrpc":"2.0","id":"aloha","method":"htlc_accepted","params":{"onion":{"payload":"","type":"tlv","short_channel_id":"104x1x0","forward_amount":"100002msat","outgoing_cltv_value":132},"htlc":{"amount":"100004msat","cltv_expiry":138,"cltv_expiry_relative":23,"payment_hash":"b929d8ae3fa7a61c1e3dc6eff5dbfc201e242e6c7286442380520e0c5e6d0e0c"}}}`
+	resp := `{"jsonrpc":"2.0","result":{"result":"fail","failure_code":8194},"id":"aloha"}`
+	runTest(t, plugin, msg+"\n\n", resp)
+	assert.Equal(t, uint64(1), interceptor.Stats().Failed)
+}
@@ -0,0 +1,147 @@
+package glightning
+
+import (
+	"encoding/json"
+)
+
+// InvoiceMetadata attaches application data, e.g. an order id and
+// the customer's details, to invoices by label. The data is stored
+// as JSON in lightningd's datastore under
+// ["glightning", "invoicemeta", {name}, {label}], so it lives and is
+// backed up alongside the invoices themselves, with no external
+// database to keep in step.
+//
+//	meta := glightning.NewInvoiceMetadata(ln, "shop")
+//	invoice, err := meta.CreateInvoice(req, &Order{Id: 42})
+//	...
+//	var order Order
+//	invoice, found, err := meta.GetInvoice(label, &order)
+type InvoiceMetadata struct {
+	client LightningClient
+	prefix []string
+}
+
+func NewInvoiceMetadata(client LightningClient, name string) *InvoiceMetadata {
+	return &InvoiceMetadata{
+		client: client,
+		prefix: []string{"glightning", "invoicemeta", name},
+	}
+}
+
+func (m *InvoiceMetadata) key(label string) []string {
+	return append(append([]string{}, m.prefix...), label)
+}
+
+// Store {meta}, marshalled as JSON, for the invoice {label},
+// replacing anything stored for it before
+func (m *InvoiceMetadata) Set(label string, meta interface{}) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	_, err = m.client.SetDatastore(m.key(label), string(data), DatastoreCreateOrReplace)
+	return err
+}
+
+// Unmarshal the metadata for invoice {label} into {into}. Returns
+// false if there's none.
+func (m *InvoiceMetadata) Get(label string, into interface{}) (bool, error) {
+	entry, err := m.client.GetDatastore(m.key(label))
+	if err != nil || entry == nil {
+		return false, err
+	}
+	return true, json.Unmarshal([]byte(entry.String), into)
+}
+
+// Remove the metadata for invoice {label}, if there is any
+func (m *InvoiceMetadata) Delete(label string) error {
+	entry, err := m.client.GetDatastore(m.key(label))
+	if err != nil || entry == nil {
+		return err
+	}
+	_, err = m.client.DelDatastore(m.key(label))
+	return err
+}
+
+// The labels of the invoices with metadata
+func (m *InvoiceMetadata) Labels() ([]string, error) {
+	entries, err := m.client.ListDatastore(m.prefix)
+	if err != nil {
+		return nil, err
+	}
+	var labels []string
+	for _, entry := range entries {
+		if len(entry.Key) == len(m.prefix)+1 {
+			labels = append(labels, entry.Key[len(m.prefix)])
+		}
+	}
+	return labels, nil
+}
+
+// Create the invoice {req} with {meta} attached. The metadata is
+// stored first, so a paid invoice always has it; it's removed again
+// if the invoice can't be created.
+func (m *InvoiceMetadata) CreateInvoice(req *InvoiceRequest, meta interface{}) (*Invoice, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	if err := m.Set(req.Label, meta); err != nil {
+		return nil, err
+	}
+	invoice, err := m.client.CreateInvoiceWithOptions(req)
+	if err != nil {
+		m.Delete(req.Label)
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// Look up invoice {label}, unmarshalling its metadata into {into}.
+// The bool is false if the invoice has no metadata.
+func (m *InvoiceMetadata) GetInvoice(label string, into interface{}) (*Invoice, bool, error) {
+	invoice, err := m.client.GetInvoice(label)
+	if err != nil {
+		return nil, false, err
+	}
+	found, err := m.Get(label, into)
+	return invoice, found, err
+}
+
+// As GetInvoice, by payment hash
+func (m *InvoiceMetadata) GetInvoiceByHash(paymentHash string, into interface{}) (*Invoice, bool, error) {
+	invoice, err := m.client.GetInvoiceByHash(paymentHash)
+	if err != nil {
+		return nil, false, err
+	}
+	found, err := m.Get(invoice.Label, into)
+	return invoice, found, err
+}
+
+// Remove the metadata of invoices which no longer exist, e.g. after
+// delinvoice or autoclean. Returns the labels removed.
+func (m *InvoiceMetadata) Prune() ([]string, error) {
+	labels, err := m.Labels()
+	if err != nil || len(labels) == 0 {
+		return nil, err
+	}
+	invoices, err := m.client.ListInvoices()
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(invoices))
+	for _, invoice := range invoices {
+		exists[invoice.Label] = true
+	}
+
+	var pruned []string
+	for _, label := range labels {
+		if exists[label] {
+			continue
+		}
+		if _, err := m.client.DelDatastore(m.key(label)); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, label)
+	}
+	return pruned, nil
+}
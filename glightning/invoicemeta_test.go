@@ -0,0 +1,128 @@
+package glightning_test
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	Id       int    `json:"id"`
+	Customer string `json:"customer"`
+}
+
+// a fake node with a datastore and invoices
+func invoiceMetaMock() (*mock.Lightning, map[string]string, map[string]*glightning.Invoice) {
+	ln := mock.New()
+	store := make(map[string]string)
+	invoices := make(map[string]*glightning.Invoice)
+	join := func(key []string) string { return strings.Join(key, "/") }
+	ln.SetDatastoreFunc = func(key []string, value string, mode glightning.DatastoreMode) (*glightning.DatastoreEntry, error) {
+		store[join(key)] = value
+		return &glightning.DatastoreEntry{Key: key, String: value}, nil
+	}
+	ln.GetDatastoreFunc = func(key []string) (*glightning.DatastoreEntry, error) {
+		value, ok := store[join(key)]
+		if !ok {
+			return nil, nil
+		}
+		return &glightning.DatastoreEntry{Key: key, String: value}, nil
+	}
+	ln.DelDatastoreFunc = func(key []string) (*glightning.DatastoreEntry, error) {
+		delete(store, join(key))
+		return &glightning.DatastoreEntry{Key: key}, nil
+	}
+	ln.ListDatastoreFunc = func(key []string) ([]*glightning.DatastoreEntry, error) {
+		var entries []*glightning.DatastoreEntry
+		for k, v := range store {
+			if strings.HasPrefix(k, join(key)+"/") {
+				entries = append(entries, &glightning.DatastoreEntry{Key: strings.Split(k, "/"), String: v})
+			}
+		}
+		return entries, nil
+	}
+	ln.CreateInvoiceWithOptionsFunc = func(req *glightning.InvoiceRequest) (*glightning.Invoice, error) {
+		if _, ok := invoices[req.Label]; ok {
+			return nil, errors.New("Duplicate label")
+		}
+		invoice := &glightning.Invoice{Label: req.Label, PaymentHash: "hash-" + req.Label}
+		invoices[req.Label] = invoice
+		return invoice, nil
+	}
+	ln.GetInvoiceFunc = func(label string) (*glightning.Invoice, error) {
+		if invoice, ok := invoices[label]; ok {
+			return invoice, nil
+		}
+		return nil, errors.New("Invoice " + label + " not found")
+	}
+	ln.GetInvoiceByHashFunc = func(hash string) (*glightning.Invoice, error) {
+		return invoices[strings.TrimPrefix(hash, "hash-")], nil
+	}
+	ln.ListInvoicesFunc = func() ([]*glightning.Invoice, error) {
+		var list []*glightning.Invoice
+		for _, invoice := range invoices {
+			list = append(list, invoice)
+		}
+		return list, nil
+	}
+	return ln, store, invoices
+}
+
+func TestInvoiceMetadata(t *testing.T) {
+	ln, store, invoices := invoiceMetaMock()
+	meta := glightning.NewInvoiceMetadata(ln, "shop")
+
+	req, err := glightning.NewInvoiceBuilder().Msat(1000).Label("order-42").Description("a hat").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoice, err := meta.CreateInvoice(req, &order{Id: 42, Customer: "kim"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "order-42", invoice.Label)
+	assert.Equal(t, `{"id":42,"customer":"kim"}`, store["glightning/invoicemeta/shop/order-42"])
+
+	var o order
+	invoice, found, err := meta.GetInvoice("order-42", &o)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "order-42", invoice.Label)
+	assert.Equal(t, order{Id: 42, Customer: "kim"}, o)
+
+	o = order{}
+	_, found, err = meta.GetInvoiceByHash("hash-order-42", &o)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 42, o.Id)
+
+	// a failed invoice leaves no metadata behind...
+	_, err = meta.CreateInvoice(req, &order{Id: 43})
+	assert.EqualError(t, err, "Duplicate label")
+	_, found, err = meta.GetInvoice("order-42", &o)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	// ...and nor does a deleted one, once pruned
+	assert.NoError(t, meta.Set("order-42", &order{Id: 42}))
+	assert.NoError(t, meta.Set("order-7", &order{Id: 7}))
+	labels, err := meta.Labels()
+	assert.NoError(t, err)
+	sort.Strings(labels)
+	assert.Equal(t, []string{"order-42", "order-7"}, labels)
+	pruned, err := meta.Prune()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"order-7"}, pruned)
+	delete(invoices, "order-42")
+	pruned, err = meta.Prune()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"order-42"}, pruned)
+	assert.Empty(t, store)
+
+	assert.NoError(t, meta.Delete("order-1"))
+}
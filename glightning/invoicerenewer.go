@@ -0,0 +1,247 @@
+package glightning
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// InvoiceRenewer keeps invoices payable, e.g. for a payment page left
+// open for days: when an invoice it created expires unpaid, it issues
+// a fresh one for the same amount and description, moves the
+// invoice's InvoiceMetadata across, and hands both to OnRenew so the
+// application can show the replacement bolt11.
+//
+// The invoices being renewed are kept in lightningd's datastore under
+// ["glightning", "invoicerenewer", {name}, {label}], and a renewer
+// started later under the same name carries on with them. Metadata is
+// kept by an InvoiceMetadata of the same name.
+type InvoiceRenewer struct {
+	// How often to look for expired invoices. Defaults to 10 seconds.
+	CheckInterval time.Duration
+	// Renew each invoice at most this many times, then forget it.
+	// Zero means no limit.
+	MaxRenewals uint
+	// Called with the expired invoice and its replacement
+	OnRenew func(expired, renewed *Invoice)
+	// Called with any error from lightningd while checking in the
+	// background
+	OnError func(error)
+
+	client LightningClient
+	meta   *InvoiceMetadata
+	prefix []string
+	done   chan struct{}
+	exited chan struct{}
+
+	mu       sync.Mutex
+	stopOnce sync.Once
+}
+
+// What's kept for each invoice being renewed
+type renewal struct {
+	Request *InvoiceRequest `json:"request"`
+	// The invoice's original label; renewals are labelled after it
+	Base     string `json:"base"`
+	Renewals uint   `json:"renewals"`
+}
+
+func NewInvoiceRenewer(client LightningClient, name string) *InvoiceRenewer {
+	return &InvoiceRenewer{
+		CheckInterval: 10 * time.Second,
+		client:        client,
+		meta:          NewInvoiceMetadata(client, name),
+		prefix:        []string{"glightning", "invoicerenewer", name},
+		done:          make(chan struct{}),
+		exited:        make(chan struct{}),
+	}
+}
+
+// The metadata store the renewer moves metadata within
+func (r *InvoiceRenewer) Metadata() *InvoiceMetadata {
+	return r.meta
+}
+
+func (r *InvoiceRenewer) key(label string) []string {
+	return append(append([]string{}, r.prefix...), label)
+}
+
+func (r *InvoiceRenewer) save(label string, rn *renewal) error {
+	data, err := json.Marshal(rn)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.SetDatastore(r.key(label), string(data), DatastoreCreateOrReplace)
+	return err
+}
+
+// Create the invoice {req} with {meta} attached, as
+// InvoiceMetadata.CreateInvoice does, and renew it whenever it
+// expires unpaid. {meta} may be nil.
+func (r *InvoiceRenewer) CreateInvoice(req *InvoiceRequest, meta interface{}) (*Invoice, error) {
+	if req.PreImage != "" {
+		return nil, fmt.Errorf("Must not set a preimage on an invoice to be renewed")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invoice, err := r.meta.CreateInvoice(req, meta)
+	if err != nil {
+		return nil, err
+	}
+	return invoice, r.save(req.Label, &renewal{Request: req, Base: req.Label})
+}
+
+// Stop renewing invoice {label}. The invoice itself is left as it is.
+func (r *InvoiceRenewer) Cancel(label string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, err := r.client.GetDatastore(r.key(label))
+	if err != nil || entry == nil {
+		return err
+	}
+	_, err = r.client.DelDatastore(r.key(label))
+	return err
+}
+
+// The labels of the invoices being renewed, as of their latest renewal
+func (r *InvoiceRenewer) Labels() ([]string, error) {
+	entries, err := r.client.ListDatastore(r.prefix)
+	if err != nil {
+		return nil, err
+	}
+	var labels []string
+	for _, entry := range entries {
+		if len(entry.Key) == len(r.prefix)+1 {
+			labels = append(labels, entry.Key[len(r.prefix)])
+		}
+	}
+	return labels, nil
+}
+
+// Renew every invoice being renewed which has expired unpaid, and
+// forget those which have been paid or deleted. Returns the
+// replacement invoices. Start calls this every CheckInterval.
+func (r *InvoiceRenewer) Check() ([]*Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, err := r.client.ListDatastore(r.prefix)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	invoices, err := r.client.ListInvoices()
+	if err != nil {
+		return nil, err
+	}
+	byLabel := make(map[string]*Invoice, len(invoices))
+	for _, invoice := range invoices {
+		byLabel[invoice.Label] = invoice
+	}
+
+	now := uint64(time.Now().Unix())
+	var renewed []*Invoice
+	for _, entry := range entries {
+		if len(entry.Key) != len(r.prefix)+1 {
+			continue
+		}
+		label := entry.Key[len(r.prefix)]
+		invoice := byLabel[label]
+		var rn renewal
+		if invoice == nil || invoice.Status == "paid" || json.Unmarshal([]byte(entry.String), &rn) != nil {
+			if _, err := r.client.DelDatastore(entry.Key); err != nil {
+				return renewed, err
+			}
+			continue
+		}
+		if invoice.Status != "expired" && (invoice.ExpiresAt == 0 || invoice.ExpiresAt > now) {
+			continue
+		}
+
+		fresh, err := r.renew(label, invoice, &rn)
+		if err != nil {
+			return renewed, err
+		}
+		if fresh == nil {
+			continue
+		}
+		renewed = append(renewed, fresh)
+		if r.OnRenew != nil {
+			r.OnRenew(invoice, fresh)
+		}
+	}
+	return renewed, nil
+}
+
+// Replace expired invoice {label}, returning nil if it has been
+// renewed MaxRenewals times already
+func (r *InvoiceRenewer) renew(label string, expired *Invoice, rn *renewal) (*Invoice, error) {
+	if r.MaxRenewals != 0 && rn.Renewals >= r.MaxRenewals {
+		_, err := r.client.DelDatastore(r.key(label))
+		return nil, err
+	}
+
+	var meta json.RawMessage
+	found, err := r.meta.Get(label, &meta)
+	if err != nil {
+		return nil, err
+	}
+
+	req := *rn.Request
+	next := *rn
+	next.Request = &req
+	next.Renewals++
+	req.Label = fmt.Sprintf("%s-renewal-%d", rn.Base, next.Renewals)
+
+	var fresh *Invoice
+	if found {
+		fresh, err = r.meta.CreateInvoice(&req, meta)
+	} else {
+		fresh, err = r.client.CreateInvoiceWithOptions(&req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.save(req.Label, &next); err != nil {
+		return nil, err
+	}
+	if _, err := r.client.DelDatastore(r.key(label)); err != nil {
+		return nil, err
+	}
+	if found {
+		if err := r.meta.Delete(label); err != nil {
+			return nil, err
+		}
+	}
+	return fresh, nil
+}
+
+// Check for expired invoices every CheckInterval until Stop
+func (r *InvoiceRenewer) Start() {
+	go r.run()
+}
+
+// Stop checking, waiting for any check in progress to finish
+func (r *InvoiceRenewer) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.done)
+	})
+	<-r.exited
+}
+
+func (r *InvoiceRenewer) run() {
+	defer close(r.exited)
+	ticker := time.NewTicker(r.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Check(); err != nil && r.OnError != nil {
+			r.OnError(err)
+		}
+		select {
+		case <-ticker.C:
+		case <-r.done:
+			return
+		}
+	}
+}
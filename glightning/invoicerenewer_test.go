@@ -0,0 +1,100 @@
+package glightning_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvoiceRenewer(t *testing.T) {
+	ln, store, invoices := invoiceMetaMock()
+	renewer := glightning.NewInvoiceRenewer(ln, "shop")
+	var notified [][2]string
+	renewer.OnRenew = func(expired, renewed *glightning.Invoice) {
+		notified = append(notified, [2]string{expired.Label, renewed.Label})
+	}
+
+	req, err := glightning.NewInvoiceBuilder().Msat(1000).Label("order-42").Description("a hat").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = renewer.CreateInvoice(req, &order{Id: 42, Customer: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, store, "glightning/invoicerenewer/shop/order-42")
+
+	// nothing has expired yet
+	invoices["order-42"].Status = "unpaid"
+	invoices["order-42"].ExpiresAt = uint64(time.Now().Add(time.Hour).Unix())
+	renewed, err := renewer.Check()
+	assert.NoError(t, err)
+	assert.Empty(t, renewed)
+
+	invoices["order-42"].Status = "expired"
+	renewed, err = renewer.Check()
+	assert.NoError(t, err)
+	if assert.Len(t, renewed, 1) {
+		assert.Equal(t, "order-42-renewal-1", renewed[0].Label)
+	}
+	assert.Equal(t, [][2]string{{"order-42", "order-42-renewal-1"}}, notified)
+
+	var o order
+	found, err := renewer.Metadata().Get("order-42-renewal-1", &o)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, order{Id: 42, Customer: "alice"}, o)
+	assert.NotContains(t, store, "glightning/invoicemeta/shop/order-42")
+	labels, _ := renewer.Labels()
+	assert.Equal(t, []string{"order-42-renewal-1"}, labels)
+
+	// an unpaid invoice past its expiry is renewed too
+	invoices["order-42-renewal-1"].Status = "unpaid"
+	invoices["order-42-renewal-1"].ExpiresAt = uint64(time.Now().Add(-time.Second).Unix())
+	renewed, _ = renewer.Check()
+	if assert.Len(t, renewed, 1) {
+		assert.Equal(t, "order-42-renewal-2", renewed[0].Label)
+	}
+
+	// paid invoices are forgotten
+	invoices["order-42-renewal-2"].Status = "paid"
+	renewed, _ = renewer.Check()
+	assert.Empty(t, renewed)
+	labels, _ = renewer.Labels()
+	assert.Empty(t, labels)
+
+	req.PreImage = "00"
+	_, err = renewer.CreateInvoice(req, nil)
+	assert.EqualError(t, err, "Must not set a preimage on an invoice to be renewed")
+}
+
+func TestInvoiceRenewerLimits(t *testing.T) {
+	ln, _, invoices := invoiceMetaMock()
+	renewer := glightning.NewInvoiceRenewer(ln, "shop")
+	renewer.MaxRenewals = 1
+	renewer.CheckInterval = time.Millisecond
+	renewed := make(chan string, 2)
+	renewer.OnRenew = func(expired, fresh *glightning.Invoice) {
+		fresh.Status = "expired"
+		renewed <- fresh.Label
+	}
+
+	req, _ := glightning.NewInvoiceBuilder().Msat(1000).Label("page").Description("donate").Build()
+	if _, err := renewer.CreateInvoice(req, nil); err != nil {
+		t.Fatal(err)
+	}
+	invoices["page"].Status = "expired"
+
+	renewer.Start()
+	assert.Equal(t, "page-renewal-1", <-renewed)
+	// the renewal expires too, and isn't renewed again
+	time.Sleep(20 * time.Millisecond)
+	renewer.Stop()
+	assert.Empty(t, renewed)
+	labels, _ := renewer.Labels()
+	assert.Empty(t, labels)
+
+	assert.NoError(t, renewer.Cancel("page"))
+}
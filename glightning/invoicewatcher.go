@@ -0,0 +1,142 @@
+package glightning
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// InvoiceWatcher waits on paid invoices in the background and
+// delivers them, in pay_index order, on C.
+//
+// Delivery is at-least-once: the pay_index of the last invoice
+// handed to Ack is kept in lightningd's datastore, and a watcher
+// started later under the same name resumes from there. Invoices
+// which were delivered but not acked before a restart are delivered
+// again, so handle them idempotently, and Ack them in the order
+// they arrive.
+type InvoiceWatcher struct {
+	// Paid invoices are delivered here. Closed once the watcher stops.
+	C <-chan *Invoice
+
+	// How long each waitanyinvoice call may wait, and so roughly how
+	// long Stop may take. Defaults to 30 seconds.
+	PollTimeout uint
+	// How long to wait before retrying after an error. Defaults to
+	// 5 seconds.
+	RetryDelay time.Duration
+	// Called with any error from lightningd, before retrying
+	OnError func(error)
+
+	client LightningClient
+	key    []string
+	c      chan *Invoice
+	done   chan struct{}
+	exited chan struct{}
+
+	mu       sync.Mutex
+	acked    uint64
+	stopOnce sync.Once
+}
+
+// Create a watcher whose progress is stored in the datastore under
+// ["glightning", "invoicewatcher", {name}].
+func NewInvoiceWatcher(client LightningClient, name string) *InvoiceWatcher {
+	c := make(chan *Invoice)
+	return &InvoiceWatcher{
+		C:           c,
+		PollTimeout: 30,
+		RetryDelay:  5 * time.Second,
+		client:      client,
+		key:         []string{"glightning", "invoicewatcher", name},
+		c:           c,
+		done:        make(chan struct{}),
+		exited:      make(chan struct{}),
+	}
+}
+
+// Load the last acked pay_index and start watching
+func (w *InvoiceWatcher) Start() error {
+	entry, err := w.client.GetDatastore(w.key)
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		w.acked, err = strconv.ParseUint(entry.String, 10, 64)
+		if err != nil {
+			return err
+		}
+	}
+	go w.run(w.acked)
+	return nil
+}
+
+// The pay_index of the last invoice acked
+func (w *InvoiceWatcher) LastPayIndex() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.acked
+}
+
+// Mark {invoice} as handled, so it won't be delivered again after
+// a restart
+func (w *InvoiceWatcher) Ack(invoice *Invoice) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if invoice.PayIndex <= w.acked {
+		return nil
+	}
+	_, err := w.client.SetDatastore(w.key, strconv.FormatUint(invoice.PayIndex, 10), DatastoreCreateOrReplace)
+	if err != nil {
+		return err
+	}
+	w.acked = invoice.PayIndex
+	return nil
+}
+
+// Stop watching and close C. Waits for any call to waitanyinvoice
+// in progress to return.
+func (w *InvoiceWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.done)
+	})
+	<-w.exited
+}
+
+func (w *InvoiceWatcher) stopped() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *InvoiceWatcher) run(lastPayIndex uint64) {
+	defer close(w.exited)
+	defer close(w.c)
+
+	for !w.stopped() {
+		invoice, err := w.client.WaitAnyInvoiceTimeout(uint(lastPayIndex), w.PollTimeout)
+		if err != nil {
+			if errors.Is(err, ErrInvoiceWaitTimedOut) {
+				continue
+			}
+			if w.OnError != nil {
+				w.OnError(err)
+			}
+			select {
+			case <-time.After(w.RetryDelay):
+			case <-w.done:
+			}
+			continue
+		}
+
+		select {
+		case w.c <- invoice:
+			lastPayIndex = invoice.PayIndex
+		case <-w.done:
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package glightning_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+// a fake node with invoices paid at pay_index 1..{paid}, and a
+// datastore
+func invoiceWatcherMock(paid uint64) (*mock.Lightning, map[string]string) {
+	ln := mock.New()
+	var mu sync.Mutex
+	store := make(map[string]string)
+	ln.GetDatastoreFunc = func(key []string) (*glightning.DatastoreEntry, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		value, ok := store[key[2]]
+		if !ok {
+			return nil, nil
+		}
+		return &glightning.DatastoreEntry{Key: key, String: value}, nil
+	}
+	ln.SetDatastoreFunc = func(key []string, value string, mode glightning.DatastoreMode) (*glightning.DatastoreEntry, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		store[key[2]] = value
+		return &glightning.DatastoreEntry{Key: key, String: value}, nil
+	}
+	ln.WaitAnyInvoiceTimeoutFunc = func(lastPayIndex uint, timeout uint) (*glightning.Invoice, error) {
+		if uint64(lastPayIndex) >= paid {
+			time.Sleep(time.Millisecond)
+			return nil, &jrpc2.RpcError{Code: 904, Message: "Timed out"}
+		}
+		index := uint64(lastPayIndex) + 1
+		return &glightning.Invoice{Label: "inv", PayIndex: index, Status: "paid"}, nil
+	}
+	return ln, store
+}
+
+func TestInvoiceWatcher(t *testing.T) {
+	ln, store := invoiceWatcherMock(3)
+	watcher := glightning.NewInvoiceWatcher(ln, "test")
+	assert.NoError(t, watcher.Start())
+
+	first := <-watcher.C
+	assert.Equal(t, uint64(1), first.PayIndex)
+	assert.NoError(t, watcher.Ack(first))
+	second := <-watcher.C
+	assert.Equal(t, uint64(2), second.PayIndex)
+	watcher.Stop()
+
+	// the closed channel drains
+	for range watcher.C {
+	}
+	assert.Equal(t, uint64(1), watcher.LastPayIndex())
+	assert.Equal(t, "1", store["test"])
+
+	// a restart redelivers the unacked invoice
+	watcher = glightning.NewInvoiceWatcher(ln, "test")
+	assert.NoError(t, watcher.Start())
+	assert.Equal(t, uint64(2), (<-watcher.C).PayIndex)
+	third := <-watcher.C
+	assert.Equal(t, uint64(3), third.PayIndex)
+	assert.NoError(t, watcher.Ack(third))
+	// older acks don't move the index back
+	assert.NoError(t, watcher.Ack(first))
+	watcher.Stop()
+	assert.Equal(t, "3", store["test"])
+}
+
+func TestInvoiceWatcherRetries(t *testing.T) {
+	ln := mock.New()
+	ln.GetDatastoreFunc = func(key []string) (*glightning.DatastoreEntry, error) {
+		return nil, nil
+	}
+	var calls int
+	ln.WaitAnyInvoiceTimeoutFunc = func(lastPayIndex uint, timeout uint) (*glightning.Invoice, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return &glightning.Invoice{PayIndex: 1}, nil
+	}
+
+	watcher := glightning.NewInvoiceWatcher(ln, "retry")
+	watcher.RetryDelay = time.Millisecond
+	var errs []error
+	watcher.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+	assert.NoError(t, watcher.Start())
+	assert.Equal(t, uint64(1), (<-watcher.C).PayIndex)
+	watcher.Stop()
+	assert.Equal(t, []error{errors.New("connection refused")}, errs)
+}
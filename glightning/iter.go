@@ -0,0 +1,240 @@
+package glightning
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Iterators walk very large list results a batch at a time, so the
+// whole result never has to be held in memory at once:
+//
+//	it := NewChannelIterator(ln, 1000)
+//	for it.Next() {
+//		for _, channel := range it.Batch() {
+//			...
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+
+// The default number of results fetched per batch
+const DefaultBatchSize = 1000
+
+// ChannelIterator pages through the whole gossip graph, as listchannels
+// would return it, using the sql plugin's LIMIT/OFFSET. Channels which
+// change while iterating may be skipped or seen twice.
+type ChannelIterator struct {
+	client LightningClient
+	size   int
+	offset int
+	batch  []*Channel
+	done   bool
+	err    error
+}
+
+func NewChannelIterator(client LightningClient, batchSize int) *ChannelIterator {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &ChannelIterator{client: client, size: batchSize}
+}
+
+// The columns selected, in the order channelFromRow reads them
+const channelColumns = "source, destination, short_channel_id, public, amount_msat, " +
+	"message_flags, channel_flags, active, last_update, base_fee_millisatoshi, " +
+	"fee_per_millionth, delay, htlc_minimum_msat, htlc_maximum_msat"
+
+// Fetch the next batch; false once there are no more, or on error
+func (it *ChannelIterator) Next() bool {
+	if it.done || it.err != nil {
+		return false
+	}
+	query := fmt.Sprintf("SELECT %s FROM channels ORDER BY short_channel_id, source LIMIT %d OFFSET %d", channelColumns, it.size, it.offset)
+	rows, err := it.client.Sql(query)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.batch = make([]*Channel, 0, len(rows))
+	for _, row := range rows {
+		channel, err := channelFromRow(row)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.batch = append(it.batch, channel)
+	}
+	it.offset += len(rows)
+	if len(rows) < it.size {
+		it.done = true
+	}
+	return len(rows) > 0
+}
+
+// The current batch
+func (it *ChannelIterator) Batch() []*Channel {
+	return it.batch
+}
+
+// The error which stopped iteration, if any
+func (it *ChannelIterator) Err() error {
+	return it.err
+}
+
+func channelFromRow(row []json.RawMessage) (*Channel, error) {
+	c := &Channel{}
+	var amountMsat, htlcMin, htlcMax uint64
+	columns := []interface{}{
+		&c.Source, &c.Destination, &c.ShortChannelId, &c.IsPublic, &amountMsat,
+		&c.MessageFlags, &c.ChannelFlags, &c.IsActive, &c.LastUpdate, &c.BaseFeeMillisatoshi,
+		&c.FeePerMillionth, &c.Delay, &htlcMin, &htlcMax,
+	}
+	if len(row) != len(columns) {
+		return nil, fmt.Errorf("Expected %d columns from sql, got %d", len(columns), len(row))
+	}
+	for i, column := range columns {
+		if err := decodeColumn(row[i], column); err != nil {
+			return nil, fmt.Errorf("Unable to decode column %d of sql row: %s", i, err)
+		}
+	}
+	c.Satoshis = amountMsat / 1000
+	c.AmountMsat = NewMsat(amountMsat).String()
+	c.HtlcMinimumMilliSatoshis = NewMsat(htlcMin).String()
+	c.HtlcMaximumMilliSatoshis = NewMsat(htlcMax).String()
+	return c, nil
+}
+
+// SQLite has no booleans, so sql returns them as 0 or 1
+func decodeColumn(raw json.RawMessage, v interface{}) error {
+	if string(raw) == "null" {
+		return nil
+	}
+	if b, ok := v.(*bool); ok {
+		n, err := strconv.Atoi(string(raw))
+		if err != nil {
+			return json.Unmarshal(raw, b)
+		}
+		*b = n != 0
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// ForwardIterator pages through listforwards by 'created' index
+type ForwardIterator struct {
+	client LightningClient
+	req    ListForwardsRequest
+	size   uint32
+	next   uint64
+	batch  []Forwarding
+	done   bool
+	err    error
+}
+
+// Page through the forwards matching {filter} (its Index, Start and
+// Limit are ignored), {batchSize} at a time
+func NewForwardIterator(client LightningClient, filter *ListForwardsRequest, batchSize int) *ForwardIterator {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	it := &ForwardIterator{client: client, size: uint32(batchSize)}
+	if filter != nil {
+		it.req = *filter
+	}
+	return it
+}
+
+func (it *ForwardIterator) Next() bool {
+	if it.done || it.err != nil {
+		return false
+	}
+	start, limit := it.next, it.size
+	req := it.req
+	req.Index = "created"
+	req.Start = &start
+	req.Limit = &limit
+	it.batch, it.err = it.client.ListForwardsFiltered(&req)
+	if it.err != nil {
+		return false
+	}
+	if len(it.batch) > 0 {
+		it.next = it.batch[len(it.batch)-1].CreatedIndex + 1
+	}
+	if uint32(len(it.batch)) < it.size {
+		it.done = true
+	}
+	return len(it.batch) > 0
+}
+
+func (it *ForwardIterator) Batch() []Forwarding {
+	return it.batch
+}
+
+func (it *ForwardIterator) Err() error {
+	return it.err
+}
+
+// InvoiceIterator pages through listinvoices by 'created' index
+type InvoiceIterator struct {
+	client LightningClient
+	size   uint32
+	next   uint64
+	batch  []*Invoice
+	done   bool
+	err    error
+}
+
+func NewInvoiceIterator(client LightningClient, batchSize int) *InvoiceIterator {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &InvoiceIterator{client: client, size: uint32(batchSize)}
+}
+
+func (it *InvoiceIterator) Next() bool {
+	if it.done || it.err != nil {
+		return false
+	}
+	start, limit := it.next, it.size
+	it.batch, it.err = it.client.ListInvoicesFiltered(&ListInvoiceRequest{
+		Index: "created",
+		Start: &start,
+		Limit: &limit,
+	})
+	if it.err != nil {
+		return false
+	}
+	if len(it.batch) > 0 {
+		it.next = it.batch[len(it.batch)-1].CreatedIndex + 1
+	}
+	if uint32(len(it.batch)) < it.size {
+		it.done = true
+	}
+	return len(it.batch) > 0
+}
+
+func (it *InvoiceIterator) Batch() []*Invoice {
+	return it.batch
+}
+
+func (it *InvoiceIterator) Err() error {
+	return it.err
+}
+
+// Iterate over every channel in the gossip graph; see ChannelIterator
+func (l *Lightning) ListChannelsIter(batchSize int) *ChannelIterator {
+	return NewChannelIterator(l, batchSize)
+}
+
+// Iterate over every forward; see ForwardIterator
+func (l *Lightning) ListForwardsIter(batchSize int) *ForwardIterator {
+	return NewForwardIterator(l, nil, batchSize)
+}
+
+// Iterate over every invoice; see InvoiceIterator
+func (l *Lightning) ListInvoicesIter(batchSize int) *InvoiceIterator {
+	return NewInvoiceIterator(l, batchSize)
+}
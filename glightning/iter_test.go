@@ -0,0 +1,158 @@
+package glightning_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSql(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"sql","params":{"query":"SELECT id, alias FROM nodes"},"id":1}`
+	resp := wrapResult(1, `{
+   "rows": [
+      ["02aa", "alice"],
+      ["03bb", null]
+   ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	rows, err := lightning.Sql("SELECT id, alias FROM nodes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, [][]json.RawMessage{
+		{json.RawMessage(`"02aa"`), json.RawMessage(`"alice"`)},
+		{json.RawMessage(`"03bb"`), json.RawMessage(`null`)},
+	}, rows)
+}
+
+func channelRow(i int) []json.RawMessage {
+	row := fmt.Sprintf(`["02aa","03bb","%dx1x0",1,1000000000,1,%d,0,1700000000,1000,10,6,1,990000000]`, 100+i, i%2)
+	var raw []json.RawMessage
+	json.Unmarshal([]byte(row), &raw)
+	return raw
+}
+
+func TestChannelIterator(t *testing.T) {
+	ln := mock.New()
+	var queries []string
+	ln.SqlFunc = func(query string) ([][]json.RawMessage, error) {
+		queries = append(queries, query)
+		var offset, limit int
+		fmt.Sscanf(query[strings.Index(query, "LIMIT"):], "LIMIT %d OFFSET %d", &limit, &offset)
+		var rows [][]json.RawMessage
+		for i := offset; i < offset+limit && i < 5; i++ {
+			rows = append(rows, channelRow(i))
+		}
+		return rows, nil
+	}
+
+	var channels []*glightning.Channel
+	it := glightning.NewChannelIterator(ln, 2)
+	for it.Next() {
+		assert.LessOrEqual(t, len(it.Batch()), 2)
+		channels = append(channels, it.Batch()...)
+	}
+	assert.NoError(t, it.Err())
+	assert.Len(t, channels, 5)
+	assert.Len(t, queries, 3)
+	assert.Contains(t, queries[2], "FROM channels ORDER BY short_channel_id, source LIMIT 2 OFFSET 4")
+
+	assert.Equal(t, &glightning.Channel{
+		Source:                   "02aa",
+		Destination:              "03bb",
+		ShortChannelId:           "101x1x0",
+		IsPublic:                 true,
+		Satoshis:                 1000000,
+		AmountMsat:               "1000000000msat",
+		MessageFlags:             1,
+		ChannelFlags:             1,
+		IsActive:                 false,
+		LastUpdate:               1700000000,
+		BaseFeeMillisatoshi:      1000,
+		FeePerMillionth:          10,
+		Delay:                    6,
+		HtlcMinimumMilliSatoshis: "1msat",
+		HtlcMaximumMilliSatoshis: "990000000msat",
+	}, channels[1])
+}
+
+func TestChannelIteratorError(t *testing.T) {
+	ln := mock.New()
+	it := glightning.NewChannelIterator(ln, 0)
+	assert.False(t, it.Next())
+	assert.True(t, errors.Is(it.Err(), mock.ErrNotMocked))
+
+	ln.SqlFunc = func(query string) ([][]json.RawMessage, error) {
+		return [][]json.RawMessage{{json.RawMessage(`"02aa"`)}}, nil
+	}
+	it = glightning.NewChannelIterator(ln, 0)
+	assert.False(t, it.Next())
+	assert.EqualError(t, it.Err(), "Expected 14 columns from sql, got 1")
+}
+
+func TestForwardIterator(t *testing.T) {
+	ln := mock.New()
+	var starts []uint64
+	ln.ListForwardsFilteredFunc = func(req *glightning.ListForwardsRequest) ([]glightning.Forwarding, error) {
+		assert.Equal(t, "created", req.Index)
+		assert.Equal(t, "settled", req.Status)
+		starts = append(starts, *req.Start)
+		var page []glightning.Forwarding
+		for i := *req.Start; i < *req.Start+uint64(*req.Limit) && i < 7; i++ {
+			page = append(page, glightning.Forwarding{CreatedIndex: i, Status: "settled"})
+		}
+		return page, nil
+	}
+
+	var total int
+	it := glightning.NewForwardIterator(ln, &glightning.ListForwardsRequest{Status: "settled"}, 3)
+	for it.Next() {
+		total += len(it.Batch())
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 7, total)
+	assert.Equal(t, []uint64{0, 3, 6}, starts)
+}
+
+func TestInvoiceIterator(t *testing.T) {
+	ln := mock.New()
+	ln.ListInvoicesFilteredFunc = func(req *glightning.ListInvoiceRequest) ([]*glightning.Invoice, error) {
+		if *req.Start > 2 {
+			return nil, nil
+		}
+		return []*glightning.Invoice{{CreatedIndex: 1}, {CreatedIndex: 2}}, nil
+	}
+
+	var batches int
+	it := glightning.NewInvoiceIterator(ln, 2)
+	for it.Next() {
+		batches++
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 1, batches)
+	assert.Equal(t, 2, ln.CallCount("ListInvoicesFiltered"))
+}
+
+func TestListInvoicesFiltered(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"listinvoices","params":{"index":"created","limit":2,"start":5},"id":1}`
+	resp := wrapResult(1, `{"invoices":[{"label":"a","created_index":5},{"label":"b","created_index":6}]}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	start, limit := uint64(5), uint32(2)
+	invoices, err := lightning.ListInvoicesFiltered(&glightning.ListInvoiceRequest{Index: "created", Start: &start, Limit: &limit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, invoices, 2)
+	assert.Equal(t, uint64(6), invoices[1].CreatedIndex)
+
+	_, err = lightning.ListInvoicesFiltered(&glightning.ListInvoiceRequest{Limit: &limit})
+	assert.EqualError(t, err, "Must set an index ('created' or 'updated') to use start or limit")
+}
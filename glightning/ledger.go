@@ -0,0 +1,355 @@
+package glightning
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The accounts a Ledger posts to, besides one per channel
+// ("assets:channel:<channel id>")
+const (
+	LedgerWallet = "assets:wallet"
+	// A clearing account for funds moving on chain between the
+	// wallet, channels and the outside world. Each movement's sides
+	// cancel out here once the bookkeeper has recorded all of them.
+	LedgerTransit = "assets:in-transit"
+	// Funds deposited from, or sent to, outside the node
+	LedgerExternal       = "equity:external"
+	LedgerInvoiceIncome  = "income:invoices"
+	LedgerRoutingIncome  = "income:routing"
+	LedgerPayments       = "expenses:payments"
+	LedgerRoutingFees    = "expenses:fees:routing"
+	LedgerOnchainFees    = "expenses:fees:onchain"
+	ledgerChannelAccount = "assets:channel:"
+)
+
+// One side of a journal transaction. Amounts are in msat; debits
+// increase asset and expense accounts, credits increase income and
+// equity accounts.
+type LedgerEntry struct {
+	// The entries making up one transaction share a TxnId, and their
+	// debits and credits balance
+	TxnId      uint64    `json:"txn_id"`
+	Time       time.Time `json:"time"`
+	Account    string    `json:"account"`
+	DebitMsat  uint64    `json:"debit_msat"`
+	CreditMsat uint64    `json:"credit_msat"`
+	// The bookkeeper's tag, or "routed" for forwards
+	Tag string `json:"tag"`
+	// The outpoint, txid or payment hash the entry came from
+	Reference   string `json:"reference,omitempty"`
+	Blockheight uint32 `json:"blockheight,omitempty"`
+	// "bookkeeper" or "listforwards"
+	Source      string `json:"source"`
+	Description string `json:"description,omitempty"`
+}
+
+// Ledger merges the bookkeeper plugin's account events, listforwards
+// and listtransactions into a double-entry journal, for tax and
+// audit exports.
+//
+// Routing income is taken from listforwards, with each forward's fee
+// credited to income:routing; the bookkeeper's own "routed" events
+// are skipped so it isn't counted twice. Payments we make are split
+// into what reached the payee and the routing fees paid on the way.
+// listtransactions fills in the block heights and types of on-chain
+// entries which the bookkeeper left without.
+type Ledger struct {
+	// Short channel ids to channel ids, so forwards post to the same
+	// channel accounts as the bookkeeper's events. Channels missing
+	// from it are posted under their short channel id.
+	ChannelIds map[string]string
+
+	entries []*LedgerEntry
+	nextTxn uint64
+	// txids of transactions spending wallet outputs; deposits from
+	// them are change, not funds from outside
+	spending map[string]bool
+}
+
+func NewLedger() *Ledger {
+	return &Ledger{
+		ChannelIds: make(map[string]string),
+		spending:   make(map[string]bool),
+	}
+}
+
+// Build a ledger from everything lightningd knows. Requires the
+// bookkeeper plugin.
+func BuildLedger(client LightningClient) (*Ledger, error) {
+	events, err := client.BkprListAccountEvents("")
+	if err != nil {
+		return nil, err
+	}
+	forwards, err := client.ListForwards()
+	if err != nil {
+		return nil, err
+	}
+	txs, err := client.ListTransactions()
+	if err != nil {
+		return nil, err
+	}
+	peers, err := client.ListPeers()
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := NewLedger()
+	for _, peer := range peers {
+		for _, channel := range peer.Channels {
+			if channel.ShortChannelId != "" {
+				ledger.ChannelIds[string(channel.ShortChannelId)] = channel.ChannelId
+			}
+		}
+	}
+	ledger.AddAccountEvents(events)
+	for i := range forwards {
+		ledger.AddForward(&forwards[i])
+	}
+	for i := range txs {
+		ledger.AddTransaction(&txs[i])
+	}
+	return ledger, nil
+}
+
+func channelAccount(id string) string {
+	return ledgerChannelAccount + id
+}
+
+// The ledger account for a bookkeeper account
+func bkprAccount(account string) string {
+	switch account {
+	case "wallet":
+		return LedgerWallet
+	case "external":
+		return LedgerExternal
+	}
+	return channelAccount(account)
+}
+
+type ledgerLeg struct {
+	account string
+	debit   uint64
+	credit  uint64
+}
+
+func (l *Ledger) post(template LedgerEntry, legs ...ledgerLeg) {
+	l.nextTxn++
+	for _, leg := range legs {
+		if leg.debit == 0 && leg.credit == 0 {
+			continue
+		}
+		entry := template
+		entry.TxnId = l.nextTxn
+		entry.Account = leg.account
+		entry.DebitMsat = leg.debit
+		entry.CreditMsat = leg.credit
+		l.entries = append(l.entries, &entry)
+	}
+}
+
+// Post a batch of bookkeeper events. A deposit to the wallet is
+// taken to be from outside unless it's change from a withdrawal,
+// so pass withdrawals in the same batch as, or an earlier batch
+// than, their change.
+func (l *Ledger) AddAccountEvents(events []*BkprAccountEvent) {
+	for _, e := range events {
+		if e.Account == "wallet" && e.SpendingTxId != "" {
+			l.spending[e.SpendingTxId] = true
+		}
+	}
+	for _, e := range events {
+		l.addAccountEvent(e)
+	}
+}
+
+func (l *Ledger) addAccountEvent(e *BkprAccountEvent) {
+	if e.Tag == "routed" {
+		return
+	}
+	template := LedgerEntry{
+		Time:        time.Unix(int64(e.Timestamp), 0).UTC(),
+		Tag:         e.Tag,
+		Reference:   e.Outpoint,
+		Blockheight: e.Blockheight,
+		Source:      "bookkeeper",
+		Description: e.Description,
+	}
+	account := bkprAccount(e.Account)
+
+	switch e.Type {
+	case "onchain_fee":
+		template.Reference = e.TxId
+		if e.DebitMsat >= e.CreditMsat {
+			fee := e.DebitMsat - e.CreditMsat
+			l.post(template, ledgerLeg{LedgerOnchainFees, fee, 0}, ledgerLeg{LedgerTransit, 0, fee})
+		} else {
+			refund := e.CreditMsat - e.DebitMsat
+			l.post(template, ledgerLeg{LedgerTransit, refund, 0}, ledgerLeg{LedgerOnchainFees, 0, refund})
+		}
+	case "channel":
+		template.Reference = e.PaymentId
+		l.addChannelEvent(template, account, e)
+	default:
+		// chain events move funds between accounts by way of transit,
+		// except deposits into the wallet from outside
+		contra := LedgerTransit
+		if e.Account == "wallet" && e.Tag == "deposit" && !l.spending[strings.SplitN(e.Outpoint, ":", 2)[0]] {
+			contra = LedgerExternal
+		}
+		if e.CreditMsat > 0 {
+			l.post(template, ledgerLeg{account, e.CreditMsat, 0}, ledgerLeg{contra, 0, e.CreditMsat})
+		}
+		if e.DebitMsat > 0 {
+			l.post(template, ledgerLeg{contra, e.DebitMsat, 0}, ledgerLeg{account, 0, e.DebitMsat})
+		}
+	}
+}
+
+func (l *Ledger) addChannelEvent(template LedgerEntry, account string, e *BkprAccountEvent) {
+	income := LedgerInvoiceIncome
+	expense := LedgerPayments
+	if e.Tag != "invoice" {
+		income = "income:" + e.Tag
+		expense = "expenses:" + e.Tag
+	}
+	if e.IsRebalance {
+		income, expense = LedgerTransit, LedgerTransit
+	}
+
+	if e.CreditMsat > 0 {
+		l.post(template, ledgerLeg{account, e.CreditMsat, 0}, ledgerLeg{income, 0, e.CreditMsat})
+	}
+	if e.DebitMsat > 0 {
+		// the debit includes the fees paid to route the payment
+		fees := e.FeesMsat
+		if fees > e.DebitMsat {
+			fees = e.DebitMsat
+		}
+		l.post(template,
+			ledgerLeg{expense, e.DebitMsat - fees, 0},
+			ledgerLeg{LedgerRoutingFees, fees, 0},
+			ledgerLeg{account, 0, e.DebitMsat})
+	}
+}
+
+func (l *Ledger) forwardAccount(scid string) string {
+	if id, ok := l.ChannelIds[scid]; ok {
+		return channelAccount(id)
+	}
+	return channelAccount(scid)
+}
+
+// Post a forward. Only settled forwards move funds.
+func (l *Ledger) AddForward(f *Forwarding) {
+	if f.Status != "settled" {
+		return
+	}
+	in := forwardAmount(f.MilliSatoshiIn, f.InMsat)
+	out := forwardAmount(f.MilliSatoshiOut, f.OutMsat)
+	fee := forwardAmount(f.Fee, f.FeeMsat)
+	if in == 0 {
+		in = out + fee
+	}
+	resolved := f.ResolvedTime
+	if resolved == 0 {
+		resolved = f.ReceivedTime
+	}
+	seconds := int64(resolved)
+	l.post(LedgerEntry{
+		Time:      time.Unix(seconds, int64((resolved-float64(seconds))*1e9)).UTC(),
+		Tag:       "routed",
+		Reference: f.PaymentHash,
+		Source:    "listforwards",
+	},
+		ledgerLeg{l.forwardAccount(f.InChannel), in, 0},
+		ledgerLeg{l.forwardAccount(f.OutChannel), 0, out},
+		ledgerLeg{LedgerRoutingIncome, 0, in - out})
+}
+
+// Fill in the block height and type of entries from {tx} which
+// don't have them
+func (l *Ledger) AddTransaction(tx *Transaction) {
+	for _, entry := range l.entries {
+		if entry.Source != "bookkeeper" || !sameTx(entry.Reference, tx.Hash) {
+			continue
+		}
+		if entry.Blockheight == 0 {
+			entry.Blockheight = uint32(tx.Blockheight)
+		}
+		if entry.Description == "" && len(tx.Type) > 0 {
+			entry.Description = strings.Join(tx.Type, ",")
+		}
+	}
+}
+
+// Whether {reference}, a txid or outpoint, is of {txid}
+func sameTx(reference, txid string) bool {
+	return reference == txid || strings.HasPrefix(reference, txid+":")
+}
+
+// The ledger's entries, oldest first
+func (l *Ledger) Entries() []*LedgerEntry {
+	entries := append([]*LedgerEntry{}, l.entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Time.Equal(entries[j].Time) {
+			return entries[i].Time.Before(entries[j].Time)
+		}
+		return entries[i].TxnId < entries[j].TxnId
+	})
+	return entries
+}
+
+// Each account's debits less its credits, in msat
+func (l *Ledger) Balances() map[string]int64 {
+	balances := make(map[string]int64)
+	for _, entry := range l.entries {
+		balances[entry.Account] += int64(entry.DebitMsat) - int64(entry.CreditMsat)
+	}
+	return balances
+}
+
+// The ledger's total debits and credits, which are always equal
+func (l *Ledger) Totals() (debitMsat, creditMsat uint64) {
+	for _, entry := range l.entries {
+		debitMsat += entry.DebitMsat
+		creditMsat += entry.CreditMsat
+	}
+	return debitMsat, creditMsat
+}
+
+// Write the entries as CSV, with a header row
+func (l *Ledger) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"txn_id", "time", "account", "debit_msat", "credit_msat", "tag", "reference", "blockheight", "source", "description"})
+	for _, e := range l.Entries() {
+		blockheight := ""
+		if e.Blockheight != 0 {
+			blockheight = strconv.FormatUint(uint64(e.Blockheight), 10)
+		}
+		out.Write([]string{
+			strconv.FormatUint(e.TxnId, 10),
+			e.Time.Format(time.RFC3339),
+			e.Account,
+			strconv.FormatUint(e.DebitMsat, 10),
+			strconv.FormatUint(e.CreditMsat, 10),
+			e.Tag,
+			e.Reference,
+			blockheight,
+			e.Source,
+			e.Description,
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// Write the entries as a JSON array
+func (l *Ledger) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(l.Entries())
+}
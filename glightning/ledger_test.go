@@ -0,0 +1,94 @@
+package glightning_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func ledgerMock() *mock.Lightning {
+	ln := mock.New()
+	ln.BkprListAccountEventsFunc = func(account string) ([]*glightning.BkprAccountEvent, error) {
+		return []*glightning.BkprAccountEvent{
+			{Account: "wallet", Type: "chain", Tag: "deposit", CreditMsat: 1000000000, Outpoint: "aa:0", Timestamp: 1000, Blockheight: 100},
+			// spent opening a channel, with change and a fee
+			{Account: "wallet", Type: "chain", Tag: "withdrawal", DebitMsat: 1000000000, Outpoint: "aa:0", SpendingTxId: "bb", Timestamp: 2000},
+			{Account: "cid1", Type: "chain", Tag: "channel_open", CreditMsat: 600000000, Outpoint: "bb:0", Timestamp: 2000},
+			{Account: "wallet", Type: "chain", Tag: "deposit", CreditMsat: 399800000, Outpoint: "bb:1", Timestamp: 2000},
+			{Account: "wallet", Type: "onchain_fee", Tag: "onchain_fee", DebitMsat: 200000, TxId: "bb", Timestamp: 2000},
+			// paid, and paying
+			{Account: "cid1", Type: "channel", Tag: "invoice", CreditMsat: 50000, PaymentId: "h1", Timestamp: 3000},
+			{Account: "cid1", Type: "channel", Tag: "invoice", DebitMsat: 10100, FeesMsat: 100, PaymentId: "h2", Timestamp: 3500},
+			// counted from listforwards instead
+			{Account: "cid1", Type: "channel", Tag: "routed", CreditMsat: 1001000, FeesMsat: 1000, PaymentId: "h3", Timestamp: 4000},
+		}, nil
+	}
+	ln.ListForwardsFunc = func() ([]glightning.Forwarding, error) {
+		return []glightning.Forwarding{
+			{InChannel: "1x1x0", OutChannel: "2x1x0", InMsat: "1001000msat", OutMsat: "1000000msat", FeeMsat: "1000msat", Status: "settled", PaymentHash: "h3", ResolvedTime: 4000.5},
+			{InChannel: "1x1x0", OutChannel: "2x1x0", InMsat: "5000msat", OutMsat: "4000msat", Status: "failed"},
+		}, nil
+	}
+	ln.ListTransactionsFunc = func() ([]glightning.Transaction, error) {
+		return []glightning.Transaction{{Hash: "bb", Blockheight: 101, Type: []string{"channel_funding"}}}, nil
+	}
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return []*glightning.Peer{{Id: closePeer, Channels: []*glightning.PeerChannel{
+			{ChannelId: "cid1", ShortChannelId: "1x1x0"},
+		}}}, nil
+	}
+	return ln
+}
+
+func TestLedger(t *testing.T) {
+	ledger, err := glightning.BuildLedger(ledgerMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	debits, credits := ledger.Totals()
+	assert.Equal(t, debits, credits)
+	assert.Equal(t, map[string]int64{
+		glightning.LedgerWallet:        1000000000 - 1000000000 + 399800000,
+		glightning.LedgerTransit:       0,
+		glightning.LedgerExternal:      -1000000000,
+		"assets:channel:cid1":          600000000 + 50000 - 10100 + 1001000,
+		"assets:channel:2x1x0":         -1000000,
+		glightning.LedgerOnchainFees:   200000,
+		glightning.LedgerInvoiceIncome: -50000,
+		glightning.LedgerPayments:      10000,
+		glightning.LedgerRoutingFees:   100,
+		glightning.LedgerRoutingIncome: -1000,
+	}, ledger.Balances())
+
+	entries := ledger.Entries()
+	assert.Equal(t, "assets:wallet", entries[0].Account)
+	assert.Equal(t, uint64(1000000000), entries[0].DebitMsat)
+	assert.Equal(t, uint32(100), entries[0].Blockheight)
+	// filled in from listtransactions
+	open := entries[4]
+	assert.Equal(t, "bb:0", open.Reference)
+	assert.Equal(t, uint32(101), open.Blockheight)
+	assert.Equal(t, "channel_funding", open.Description)
+	last := entries[len(entries)-1]
+	assert.Equal(t, "listforwards", last.Source)
+	assert.Equal(t, int64(4000), last.Time.Unix())
+
+	var csv bytes.Buffer
+	assert.NoError(t, ledger.WriteCSV(&csv))
+	lines := strings.Split(csv.String(), "\n")
+	assert.Equal(t, "txn_id,time,account,debit_msat,credit_msat,tag,reference,blockheight,source,description", lines[0])
+	assert.Equal(t, "1,1970-01-01T00:16:40Z,assets:wallet,1000000000,0,deposit,aa:0,100,bookkeeper,", lines[1])
+	assert.Equal(t, "1,1970-01-01T00:16:40Z,equity:external,0,1000000000,deposit,aa:0,100,bookkeeper,", lines[2])
+
+	var buf bytes.Buffer
+	assert.NoError(t, ledger.WriteJSON(&buf))
+	var decoded []*glightning.LedgerEntry
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, entries, decoded)
+}
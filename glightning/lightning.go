@@ -1,12 +1,23 @@
 package glightning
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"net"
+	"net/http"
 	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/elementsproject/glightning/glightning/network"
+	"github.com/elementsproject/glightning/glightning/tlv"
 	"github.com/elementsproject/glightning/jrpc2"
 )
 
@@ -14,12 +25,30 @@ import (
 // c-lightning RPC commands
 type Lightning struct {
 	client *jrpc2.Client
-	isUp   bool
+	// Where requests go; the client, unless they're being relayed
+	// to another node (see RemoteLightning)
+	rpc     requester
+	isUp    bool
+	version *Version
+	compat  bool
+	strict  bool
+	// Checked against destinations, if set
+	network *network.Network
+}
+
+type requester interface {
+	Request(m jrpc2.Method, resp interface{}) error
+	RequestNoTimeout(m jrpc2.Method, resp interface{}) error
+	RequestRaw(m jrpc2.Method, resp interface{}) (json.RawMessage, error)
+	RequestRawNoTimeout(m jrpc2.Method, resp interface{}) (json.RawMessage, error)
+	RequestCtx(ctx context.Context, m jrpc2.Method, resp interface{}) error
+	RequestRawCtx(ctx context.Context, m jrpc2.Method, resp interface{}) (json.RawMessage, error)
 }
 
 func NewLightning() *Lightning {
 	ln := &Lightning{}
 	ln.client = jrpc2.NewClient()
+	ln.rpc = ln.client
 	return ln
 }
 
@@ -27,24 +56,65 @@ func (l *Lightning) SetTimeout(secs uint) {
 	l.client.SetTimeout(secs)
 }
 
+// Send what the rpc client logs to {logger}; see
+// jrpc2.Client.SetLogger
+func (l *Lightning) SetLogger(logger jrpc2.Logger) {
+	l.client.SetLogger(logger)
+}
+
+// Report every call to lightningd to {metrics}; see
+// jrpc2.Client.SetMetrics
+func (l *Lightning) SetMetrics(metrics jrpc2.Metrics) {
+	l.client.SetMetrics(metrics)
+}
+
+// Trace every call to lightningd with {tracer}; the *Ctx methods
+// start their spans from the context they're given. See
+// jrpc2.Client.SetTracer
+func (l *Lightning) SetTracer(tracer jrpc2.Tracer) {
+	l.client.SetTracer(tracer)
+}
+
+// Run {hook} on every request before it goes out; see
+// jrpc2.Client.OnRequest
+func (l *Lightning) OnRequest(hook func(req *jrpc2.Request)) {
+	l.client.OnRequest(hook)
+}
+
+// Run {hook} on every response before it's decoded; see
+// jrpc2.Client.OnResponse
+func (l *Lightning) OnResponse(hook func(resp *jrpc2.RawResponse)) {
+	l.client.OnResponse(hook)
+}
+
 func (l *Lightning) StartUp(rpcfile, lightningDir string) error {
+	err := l.client.StartUpSocket(filepath.Join(lightningDir, rpcfile))
+	l.isUp = err == nil
+	return err
+}
+
+// Talk to lightningd's JSON-RPC over TCP, optionally with TLS, e.g.
+// where its rpc socket is forwarded over the network
+func (l *Lightning) StartUpTCP(addr string, tlsConfig *tls.Config) error {
+	err := l.client.StartUpTCP(addr, tlsConfig)
+	l.isUp = err == nil
+	return err
+}
+
+// Talk to lightningd's JSON-RPC through an HTTP gateway at
+// {endpoint}, POSTing each request; {httpClient} may be nil
+func (l *Lightning) StartUpHTTP(endpoint string, httpClient *http.Client) error {
+	err := l.client.StartUpHTTP(endpoint, httpClient)
+	l.isUp = err == nil
+	return err
+}
+
+// Talk to lightningd over an already open connection instead of its
+// rpc socket; e.g. a jrpc2.ReplayServer's Pipe, in tests
+func (l *Lightning) StartConn(conn io.ReadWriteCloser) {
 	up := make(chan bool)
-	errChan := make(chan error)
-	go func(l *Lightning, rpcfile, lightningDir string, up chan bool, errChan chan error) {
-		err := l.client.SocketStart(filepath.Join(lightningDir, rpcfile), up)
-		if err != nil {
-			errChan <- err
-		}
-	}(l, rpcfile, lightningDir, up, errChan)
-
-	for {
-		select {
-		case l.isUp = <-up:
-			return nil
-		case err := <-errChan:
-			return err
-		}
-	}
+	go l.client.ConnStart(conn, up)
+	l.isUp = <-up
 }
 
 func (l *Lightning) Shutdown() {
@@ -56,7 +126,90 @@ func (l *Lightning) IsUp() bool {
 }
 
 func (l *Lightning) Request(m jrpc2.Method, resp interface{}) error {
-	return l.client.Request(m, resp)
+	return l.rpc.Request(m, resp)
+}
+
+// Like Request, but also returns the result exactly as lightningd
+// sent it (e.g. to keep for an audit log). {resp} may be nil.
+func (l *Lightning) RequestRaw(m jrpc2.Method, resp interface{}) (json.RawMessage, error) {
+	return l.rpc.RequestRaw(m, resp)
+}
+
+// A request for any RPC method, with params given as a map.
+// Useful for calling methods glightning has no typed wrapper for.
+type GenericRequest struct {
+	Method string
+	Params map[string]interface{}
+}
+
+func (r *GenericRequest) Name() string {
+	return r.Method
+}
+
+func (r *GenericRequest) NamedParams() map[string]interface{} {
+	return r.Params
+}
+
+// Call RPC {method} with {params}, returning the raw JSON result
+func (l *Lightning) Call(method string, params map[string]interface{}) (json.RawMessage, error) {
+	if method == "" {
+		return nil, fmt.Errorf("Must provide a method to call")
+	}
+	var result json.RawMessage
+	err := l.rpc.Request(&GenericRequest{method, params}, &result)
+	return result, err
+}
+
+// Call RPC {method}, decoding its result into {result}, so commands
+// without a wrapper (e.g. a plugin's) can still give typed results:
+//
+//	var summary SummaryResult
+//	err := lightning.CallInto("summary", nil, &summary)
+//
+// {params} is a map, or a struct whose json tags give the
+// param names. See Call for the same, returning the result.
+func (l *Lightning) CallInto(method string, params interface{}, result interface{}) error {
+	if method == "" {
+		return fmt.Errorf("Must provide a method to call")
+	}
+	named, err := toNamedParams(params)
+	if err != nil {
+		return err
+	}
+	return l.rpc.Request(&GenericRequest{method, named}, result)
+}
+
+// Call RPC {method} on {l}, returning its result as a T, so commands
+// without a wrapper (e.g. a plugin's) can still give typed results:
+//
+//	summary, err := glightning.Call[SummaryResult](lightning, "summary", nil)
+//
+// {params} is a map, or a struct whose json tags give the
+// param names.
+func Call[T any](l *Lightning, method string, params any) (*T, error) {
+	var result T
+	if err := l.CallInto(method, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func toNamedParams(params interface{}) (map[string]interface{}, error) {
+	switch p := params.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return p, nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var named map[string]interface{}
+	if err := json.Unmarshal(data, &named); err != nil {
+		return nil, fmt.Errorf("Params must be a map or struct: %s", err)
+	}
+	return named, nil
 }
 
 type ListConfigsRequest struct {
@@ -73,13 +226,13 @@ func (r *ListConfigsRequest) New() interface{} {
 
 func (l *Lightning) ListConfigs() (map[string]interface{}, error) {
 	var result map[string]interface{}
-	err := l.client.Request(&ListConfigsRequest{}, &result)
+	err := l.rpc.Request(&ListConfigsRequest{}, &result)
 	return result, err
 }
 
 func (l *Lightning) GetConfig(config string) (interface{}, error) {
 	var result map[string]interface{}
-	err := l.client.Request(&ListConfigsRequest{config}, &result)
+	err := l.rpc.Request(&ListConfigsRequest{config}, &result)
 	return result[config], err
 }
 
@@ -105,7 +258,7 @@ type PeerChannel struct {
 	State                            string            `json:"state"`
 	ScratchTxId                      string            `json:"scratch_txid"`
 	Owner                            string            `json:"owner"`
-	ShortChannelId                   string            `json:"short_channel_id"`
+	ShortChannelId                   ShortChannelId    `json:"short_channel_id"`
 	ChannelDirection                 int               `json:"direction"`
 	ChannelId                        string            `json:"channel_id"`
 	FundingTxId                      string            `json:"funding_txid"`
@@ -153,6 +306,48 @@ type PeerChannel struct {
 	OutMilliSatoshiFulfilled         uint64            `json:"out_msatoshi_fulfilled"`
 	OutgoingFulfilledMsat            string            `json:"out_fulfilled_msat"`
 	Htlcs                            []*Htlc           `json:"htlcs"`
+	Features                         []string          `json:"features,omitempty"`
+	FundingOutnum                    uint32            `json:"funding_outnum"`
+	MinimumHtlcOutMsat               string            `json:"minimum_htlc_out_msat,omitempty"`
+	MaximumHtlcOutMsat               string            `json:"maximum_htlc_out_msat,omitempty"`
+	FeeBaseMsat                      string            `json:"fee_base_msat,omitempty"`
+	FeeProportionalMillionths        uint32            `json:"fee_proportional_millionths,omitempty"`
+	Alias                            *ChannelAlias     `json:"alias,omitempty"`
+	Updates                          *ChannelUpdates   `json:"updates,omitempty"`
+	Opener                           string            `json:"opener,omitempty"`
+	Closer                           string            `json:"closer,omitempty"`
+	StateChanges                     []*StateChange    `json:"state_changes,omitempty"`
+}
+
+// Short channel id aliases for a channel, our 'local' alias
+// and the one our peer told us to use ('remote')
+type ChannelAlias struct {
+	Local  string `json:"local,omitempty"`
+	Remote string `json:"remote,omitempty"`
+}
+
+// The forwarding policy of each side of a channel: 'local' for
+// payments we send over it, 'remote' for those the peer sends us
+type ChannelUpdates struct {
+	Local  *ChannelForwardingPolicy `json:"local,omitempty"`
+	Remote *ChannelForwardingPolicy `json:"remote,omitempty"`
+}
+
+type ChannelForwardingPolicy struct {
+	HtlcMinimumMsat           string `json:"htlc_minimum_msat"`
+	HtlcMaximumMsat           string `json:"htlc_maximum_msat"`
+	CltvExpiryDelta           uint   `json:"cltv_expiry_delta"`
+	FeeBaseMsat               string `json:"fee_base_msat"`
+	FeeProportionalMillionths uint64 `json:"fee_proportional_millionths"`
+}
+
+type StateChange struct {
+	Timestamp string `json:"timestamp"`
+	OldState  string `json:"old_state"`
+	NewState  string `json:"new_state"`
+	// todo: enum (unknown, local, user, remote, protocol, onchain)
+	Cause   string `json:"cause"`
+	Message string `json:"message"`
 }
 
 type Htlc struct {
@@ -202,7 +397,7 @@ func (l *Lightning) getPeers(peerId string, level LogLevel) ([]*Peer, error) {
 		request.Level = level.String()
 	}
 
-	err := l.client.Request(request, &result)
+	err := l.rpc.Request(request, &result)
 	return result.Peers, err
 }
 
@@ -221,13 +416,69 @@ type Node struct {
 	LastTimestamp uint      `json:"last_timestamp"`
 	Features      *Hexed    `json:"features"`
 	Addresses     []Address `json:"addresses"`
+	// Only present if the node advertises liquidity ads
+	WillFund *WillFund `json:"option_will_fund,omitempty"`
+}
+
+// The lease rates a node advertises for `option_will_fund`
+// (liquidity ads).
+type WillFund struct {
+	LeaseFeeBaseMsat                     string `json:"lease_fee_base_msat"`
+	LeaseFeeBasis                        uint32 `json:"lease_fee_basis"`
+	FundingWeight                        uint32 `json:"funding_weight"`
+	ChannelFeeMaxBaseMsat                string `json:"channel_fee_max_base_msat"`
+	ChannelFeeMaxProportionalThousandths uint32 `json:"channel_fee_max_proportional_thousandths"`
+	CompactLease                         string `json:"compact_lease"`
+}
+
+type NetAddressType string
+
+const (
+	AddrIPv4      NetAddressType = "ipv4"
+	AddrIPv6      NetAddressType = "ipv6"
+	AddrTorV2     NetAddressType = "torv2"
+	AddrTorV3     NetAddressType = "torv3"
+	AddrDNS       NetAddressType = "dns"
+	AddrWebsocket NetAddressType = "websocket"
+)
+
+func (t NetAddressType) String() string {
+	return string(t)
+}
+
+func ParseNetAddressType(s string) (NetAddressType, error) {
+	switch t := NetAddressType(strings.ToLower(s)); t {
+	case AddrIPv4, AddrIPv6, AddrTorV2, AddrTorV3, AddrDNS, AddrWebsocket:
+		return t, nil
+	}
+	return "", fmt.Errorf("Unknown address type %s", s)
+}
+
+func (t NetAddressType) IsTor() bool {
+	return t == AddrTorV2 || t == AddrTorV3
+}
+
+func (t NetAddressType) IsClearnet() bool {
+	return t == AddrIPv4 || t == AddrIPv6 || t == AddrDNS || t == AddrWebsocket
 }
 
 type Address struct {
-	// todo: map to enum (ipv4, ipv6, torv2, torv3)
-	Type string `json:"type"`
-	Addr string `json:"address"`
-	Port int    `json:"port"`
+	Type NetAddressType `json:"type"`
+	Addr string         `json:"address"`
+	Port int            `json:"port"`
+}
+
+func (a *Address) IsTor() bool {
+	return a.Type.IsTor()
+}
+
+func (a *Address) IsClearnet() bool {
+	return a.Type.IsClearnet()
+}
+
+// Formats the address as host:port, bracketing IPv6 hosts
+func (a *Address) String() string {
+	return net.JoinHostPort(a.Addr, strconv.Itoa(a.Port))
 }
 
 // Get all nodes in our local network view, filter on node {id},
@@ -249,7 +500,7 @@ func (l *Lightning) getNodes(nodeId string) ([]*Node, error) {
 	var result struct {
 		Nodes []*Node `json:"nodes"`
 	}
-	err := l.client.Request(&ListNodeRequest{nodeId}, &result)
+	err := l.rpc.Request(&ListNodeRequest{nodeId}, &result)
 	return result.Nodes, err
 }
 
@@ -270,12 +521,12 @@ type Route struct {
 }
 
 type RouteHop struct {
-	Id             string `json:"id"`
-	ShortChannelId string `json:"channel"`
-	MilliSatoshi   uint64 `json:"msatoshi"`
-	AmountMsat     string `json:"amount_msat,omitempty"`
-	Delay          uint   `json:"delay"`
-	Direction      uint8  `json:"direction,omitempty"`
+	Id             string         `json:"id"`
+	ShortChannelId ShortChannelId `json:"channel"`
+	MilliSatoshi   uint64         `json:"msatoshi"`
+	AmountMsat     string         `json:"amount_msat,omitempty"`
+	Delay          uint           `json:"delay"`
+	Direction      uint8          `json:"direction,omitempty"`
 }
 
 func (rr RouteRequest) Name() string {
@@ -297,6 +548,14 @@ func (l *Lightning) GetRoute(peerId string, msats uint64, riskfactor float32, cl
 	if peerId == "" {
 		return nil, fmt.Errorf("Must provide a peerId to route to")
 	}
+	if err := checkNodeId(peerId); err != nil {
+		return nil, err
+	}
+	if fromId != "" {
+		if err := checkNodeId(fromId); err != nil {
+			return nil, err
+		}
+	}
 
 	if msats == 0 {
 		return nil, fmt.Errorf("No value set for payment. (`msatoshis` is equal to zero).")
@@ -317,7 +576,7 @@ func (l *Lightning) GetRoute(peerId string, msats uint64, riskfactor float32, cl
 	}
 
 	var result Route
-	err := l.client.Request(&RouteRequest{
+	err := l.rpc.Request(&RouteRequest{
 		PeerId:        peerId,
 		MilliSatoshis: msats,
 		RiskFactor:    riskfactor,
@@ -374,8 +633,8 @@ func (l *Lightning) SendOnionWithDetails(onion string, hop FirstHop, paymentHash
 		req.PartId = *partId
 	}
 
-	err := l.client.Request(&req, &response)
-	return &response, err
+	err := l.rpc.Request(&req, &response)
+	return &response, toPaymentError(err)
 }
 
 type CreateOnionRequest struct {
@@ -395,6 +654,58 @@ type Hop struct {
 	Payload string `json:"payload"`
 }
 
+// The BOLT#4 TLV payload for a hop of an onion built with CreateOnion
+type OnionPayload struct {
+	AmountMsat   uint64
+	OutgoingCltv uint32
+	// The channel to forward over; unset for the final hop
+	ShortChannelId ShortChannelId
+	// The final hop's payment secret (hex), and the total being paid
+	// if this is one part of it
+	PaymentSecret string
+	TotalMsat     uint64
+	// Any other records, e.g. keysend's preimage
+	Extra []*tlv.Record
+}
+
+// Encode the payload, with its length prefix, as CreateOnion's
+// Hop.Payload expects
+func (p *OnionPayload) Encode() (string, error) {
+	records := []*tlv.Record{
+		tlv.NewTu64Record(2, p.AmountMsat),
+		tlv.NewTu64Record(4, uint64(p.OutgoingCltv)),
+	}
+	if p.ShortChannelId != "" {
+		if !p.ShortChannelId.Valid() {
+			return "", fmt.Errorf("Invalid short channel id %s", p.ShortChannelId)
+		}
+		scid := make([]byte, 8)
+		binary.BigEndian.PutUint64(scid, p.ShortChannelId.Uint64())
+		records = append(records, &tlv.Record{Type: 6, Value: scid})
+	}
+	if p.PaymentSecret != "" {
+		secret, err := hex.DecodeString(p.PaymentSecret)
+		if err != nil || len(secret) != 32 {
+			return "", fmt.Errorf("Payment secret must be 32 bytes of hex")
+		}
+		total := p.TotalMsat
+		if total == 0 {
+			total = p.AmountMsat
+		}
+		records = append(records, &tlv.Record{Type: 8, Value: append(secret, tlv.Tu64(total)...)})
+	}
+	records = append(records, p.Extra...)
+
+	stream, err := tlv.Encode(records)
+	if err != nil {
+		return "", err
+	}
+	var payload bytes.Buffer
+	tlv.WriteBigSize(&payload, uint64(len(stream)))
+	payload.Write(stream)
+	return hex.EncodeToString(payload.Bytes()), nil
+}
+
 type CreateOnionResponse struct {
 	Onion         string   `json:"onion"`
 	SharedSecrets []string `json:"shared_secrets"`
@@ -408,13 +719,74 @@ func (l *Lightning) CreateOnion(hops []Hop, paymentHash, sessionKey string) (*Cr
 		SessionKey:     sessionKey,
 	}
 
-	err := l.client.Request(&req, &response)
+	err := l.rpc.Request(&req, &response)
 	return &response, err
 }
 
+// An onion message hop: the node to send through, plus the
+// hex-encoded (blinded) TLV payload for that node
+type OnionMessageHop struct {
+	Node string `json:"node"`
+	Tlv  string `json:"tlv"`
+}
+
+type SendOnionMessageRequest struct {
+	FirstId  string             `json:"first_id"`
+	Blinding string             `json:"blinding"`
+	Hops     []*OnionMessageHop `json:"hops"`
+}
+
+func (r SendOnionMessageRequest) Name() string {
+	return "sendonionmessage"
+}
+
+// Send an onion message to {firstId} along {hops}. {blinding} is the
+// blinding point for the first hop.
+func (l *Lightning) SendOnionMessage(firstId, blinding string, hops []*OnionMessageHop) error {
+	if firstId == "" || blinding == "" {
+		return fmt.Errorf("Must provide a first_id and blinding")
+	}
+	if len(hops) == 0 {
+		return fmt.Errorf("Must provide at least one hop")
+	}
+	for i, hop := range hops {
+		if hop == nil || hop.Node == "" || hop.Tlv == "" {
+			return fmt.Errorf("Hop %d must have a node and tlv", i)
+		}
+	}
+
+	var result struct{}
+	return l.rpc.Request(&SendOnionMessageRequest{
+		FirstId:  firstId,
+		Blinding: blinding,
+		Hops:     hops,
+	}, &result)
+}
+
+type InjectOnionMessageRequest struct {
+	PathKey string `json:"path_key"`
+	Message string `json:"message"`
+}
+
+func (r InjectOnionMessageRequest) Name() string {
+	return "injectonionmessage"
+}
+
+// Process the hex-encoded onion {message} as if it had been sent to us
+// by a peer, using {pathKey} as the blinding point
+func (l *Lightning) InjectOnionMessage(pathKey, message string) error {
+	if pathKey == "" || message == "" {
+		return fmt.Errorf("Must provide a path_key and message")
+	}
+
+	var result struct{}
+	return l.rpc.Request(&InjectOnionMessageRequest{pathKey, message}, &result)
+}
+
 type ListChannelRequest struct {
 	ShortChannelId string `json:"short_channel_id,omitempty"`
 	Source         string `json:"source,omitempty"`
+	Destination    string `json:"destination,omitempty"`
 }
 
 func (lc ListChannelRequest) Name() string {
@@ -422,21 +794,21 @@ func (lc ListChannelRequest) Name() string {
 }
 
 type Channel struct {
-	Source                   string `json:"source"`
-	Destination              string `json:"destination"`
-	ShortChannelId           string `json:"short_channel_id"`
-	IsPublic                 bool   `json:"public"`
-	Satoshis                 uint64 `json:"satoshis"`
-	AmountMsat               string `json:"amount_msat"`
-	MessageFlags             uint   `json:"message_flags"`
-	ChannelFlags             uint   `json:"channel_flags"`
-	IsActive                 bool   `json:"active"`
-	LastUpdate               uint   `json:"last_update"`
-	BaseFeeMillisatoshi      uint64 `json:"base_fee_millisatoshi"`
-	FeePerMillionth          uint64 `json:"fee_per_millionth"`
-	Delay                    uint   `json:"delay"`
-	HtlcMinimumMilliSatoshis string `json:"htlc_minimum_msat"`
-	HtlcMaximumMilliSatoshis string `json:"htlc_maximum_msat"`
+	Source                   string         `json:"source"`
+	Destination              string         `json:"destination"`
+	ShortChannelId           ShortChannelId `json:"short_channel_id"`
+	IsPublic                 bool           `json:"public"`
+	Satoshis                 uint64         `json:"satoshis"`
+	AmountMsat               string         `json:"amount_msat"`
+	MessageFlags             uint           `json:"message_flags"`
+	ChannelFlags             uint           `json:"channel_flags"`
+	IsActive                 bool           `json:"active"`
+	LastUpdate               uint           `json:"last_update"`
+	BaseFeeMillisatoshi      uint64         `json:"base_fee_millisatoshi"`
+	FeePerMillionth          uint64         `json:"fee_per_millionth"`
+	Delay                    uint           `json:"delay"`
+	HtlcMinimumMilliSatoshis string         `json:"htlc_minimum_msat"`
+	HtlcMaximumMilliSatoshis string         `json:"htlc_maximum_msat"`
 }
 
 // Get channel by {shortChanId}
@@ -444,18 +816,29 @@ func (l *Lightning) GetChannel(shortChanId string) ([]*Channel, error) {
 	var result struct {
 		Channels []*Channel `json:"channels"`
 	}
-	err := l.client.Request(&ListChannelRequest{shortChanId, ""}, &result)
+	err := l.rpc.Request(&ListChannelRequest{ShortChannelId: shortChanId}, &result)
 	if len(result.Channels) == 0 {
 		return nil, errors.New(fmt.Sprintf("No channel found for short channel id %s", shortChanId))
 	}
 	return result.Channels, err
 }
 
+// List channels where {nodeId} is the source, i.e. the
+// channels that node announced an update for
 func (l *Lightning) ListChannelsBySource(nodeId string) ([]*Channel, error) {
+	return l.listChannels(&ListChannelRequest{Source: nodeId})
+}
+
+// List channels where {nodeId} is the destination
+func (l *Lightning) ListChannelsByDestination(nodeId string) ([]*Channel, error) {
+	return l.listChannels(&ListChannelRequest{Destination: nodeId})
+}
+
+func (l *Lightning) listChannels(req *ListChannelRequest) ([]*Channel, error) {
 	var result struct {
 		Channels []*Channel `json:"channels"`
 	}
-	err := l.client.Request(&ListChannelRequest{"", nodeId}, &result)
+	err := l.rpc.Request(req, &result)
 	return result.Channels, err
 }
 
@@ -471,6 +854,7 @@ type InvoiceRequest struct {
 	Fallbacks     []string `json:"fallbacks,omitempty"`
 	PreImage      string   `json:"preimage,omitempty"`
 	Cltv          uint32   `json:"cltv,omitempty"`
+	DescHashOnly  bool     `json:"deschashonly,omitempty"`
 	// Note that these both have the same json key. we use checks
 	// to make sure that only one of them is filled in
 	ExposePrivChansFlag *bool    `json:"exposeprivatechannels,omitempty"`
@@ -485,6 +869,7 @@ type Invoice struct {
 	Label                   string `json:"label"`
 	Bolt11                  string `json:"bolt11"`
 	PaymentHash             string `json:"payment_hash"`
+	PaymentSecret           string `json:"payment_secret,omitempty"`
 	AmountMilliSatoshi      string `json:"amount_msat,omitempty"`
 	AmountMilliSatoshiRaw   uint64 `json:"msatoshi,omitempty"`
 	Status                  string `json:"status"`
@@ -495,8 +880,13 @@ type Invoice struct {
 	PaymentPreImage         string `json:"payment_preimage,omitempty"`
 	WarningOffline          string `json:"warning_offline,omitempty"`
 	WarningCapacity         string `json:"warning_capacity,omitempty"`
+	WarningDeadends         string `json:"warning_deadends,omitempty"`
+	WarningPrivateUnused    string `json:"warning_private_unused,omitempty"`
+	WarningMpp              string `json:"warning_mpp,omitempty"`
 	Description             string `json:"description"`
 	ExpiresAt               uint64 `json:"expires_at"`
+	CreatedIndex            uint64 `json:"created_index,omitempty"`
+	UpdatedIndex            uint64 `json:"updated_index,omitempty"`
 }
 
 // Creates an invoice with a value of "any", that can be paid with any amount
@@ -557,6 +947,58 @@ func (l *Lightning) Invoice(msat uint64, label, description string) (*Invoice, e
 	return createInvoice(l, fmt.Sprint(msat), label, description, 0, nil, "", false, nil, 0)
 }
 
+// Creates an invoice for `msat` which commits to the sha256 of
+// 'description' (as a description_hash) instead of including it, for
+// descriptions too long for an invoice, such as LNURL-pay metadata.
+// The payer must be given the description out of band.
+func (l *Lightning) CreateInvoiceDescHashOnly(msat uint64, label, description string, expirySeconds uint32) (*Invoice, error) {
+	if msat <= 0 {
+		return nil, fmt.Errorf("No value set for invoice. (`msat` is less than or equal to zero).")
+	}
+	if label == "" {
+		return nil, fmt.Errorf("Must set a label on an invoice")
+	}
+	if description == "" {
+		return nil, fmt.Errorf("Must set a description on an invoice")
+	}
+
+	var result Invoice
+	err := l.rpc.Request(&InvoiceRequest{
+		MilliSatoshis: fmt.Sprint(msat),
+		Label:         label,
+		Description:   description,
+		ExpirySeconds: expirySeconds,
+		DescHashOnly:  true,
+	}, &result)
+	return &result, err
+}
+
+// Create the invoice {req}, as built by an InvoiceBuilder
+func (l *Lightning) CreateInvoiceWithOptions(req *InvoiceRequest) (*Invoice, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	var result Invoice
+	err := l.rpc.Request(req, &result)
+	return &result, err
+}
+
+func (r *InvoiceRequest) validate() error {
+	if r.MilliSatoshis == "" {
+		return fmt.Errorf("No value set for invoice. (`msat` is less than or equal to zero).")
+	}
+	if r.Label == "" {
+		return fmt.Errorf("Must set a label on an invoice")
+	}
+	if r.Description == "" {
+		return fmt.Errorf("Must set a description on an invoice")
+	}
+	if r.ExposePrivChansFlag != nil && len(r.ExposeTheseChannels) > 0 {
+		return fmt.Errorf("Cannot both flag to expose private and provide list of short channel ids")
+	}
+	return nil
+}
+
 func createInvoice(l *Lightning, msat, label, description string, expirySeconds uint32, fallbacks []string, preimage string, flagExposePrivate bool, exposeShortChannelIds []string, cltv uint32) (*Invoice, error) {
 
 	if label == "" {
@@ -581,7 +1023,7 @@ func createInvoice(l *Lightning, msat, label, description string, expirySeconds
 	}
 
 	var result Invoice
-	err := l.client.Request(&InvoiceRequest{
+	err := l.rpc.Request(&InvoiceRequest{
 		MilliSatoshis:       msat,
 		Label:               label,
 		Description:         description,
@@ -596,7 +1038,12 @@ func createInvoice(l *Lightning, msat, label, description string, expirySeconds
 }
 
 type ListInvoiceRequest struct {
-	Label string `json:"label,omitempty"`
+	Label       string `json:"label,omitempty"`
+	PaymentHash string `json:"payment_hash,omitempty"`
+	// Either 'created' or 'updated', required to use Start/Limit
+	Index string  `json:"index,omitempty"`
+	Start *uint64 `json:"start,omitempty"`
+	Limit *uint32 `json:"limit,omitempty"`
 }
 
 func (r ListInvoiceRequest) Name() string {
@@ -605,12 +1052,12 @@ func (r ListInvoiceRequest) Name() string {
 
 // List all invoices
 func (l *Lightning) ListInvoices() ([]*Invoice, error) {
-	return l.getInvoices("")
+	return l.getInvoices(&ListInvoiceRequest{})
 }
 
 // Show invoice {label}.
 func (l *Lightning) GetInvoice(label string) (*Invoice, error) {
-	list, err := l.getInvoices(label)
+	list, err := l.getInvoices(&ListInvoiceRequest{Label: label})
 	if err != nil {
 		return nil, err
 	}
@@ -620,27 +1067,84 @@ func (l *Lightning) GetInvoice(label string) (*Invoice, error) {
 	return list[0], err
 }
 
-func (l *Lightning) getInvoices(label string) ([]*Invoice, error) {
+// Show the invoice with payment hash {paymentHash}.
+func (l *Lightning) GetInvoiceByHash(paymentHash string) (*Invoice, error) {
+	if paymentHash == "" {
+		return nil, fmt.Errorf("Must provide a payment_hash")
+	}
+	list, err := l.getInvoices(&ListInvoiceRequest{PaymentHash: paymentHash})
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, errors.New(fmt.Sprintf("Invoice with payment_hash %s not found", paymentHash))
+	}
+	return list[0], err
+}
+
+// List invoices matching the filters set on the request.
+//
+// To page through invoices incrementally, set the 'Index' to either
+// 'created' or 'updated' and pass a 'Start' of one past the last
+// 'CreatedIndex' (or 'UpdatedIndex') you've seen, along with a 'Limit'.
+func (l *Lightning) ListInvoicesFiltered(req *ListInvoiceRequest) ([]*Invoice, error) {
+	if (req.Start != nil || req.Limit != nil) && req.Index == "" {
+		return nil, fmt.Errorf("Must set an index ('created' or 'updated') to use start or limit")
+	}
+	if req.Index != "" && req.Index != "created" && req.Index != "updated" {
+		return nil, fmt.Errorf("Index must be either 'created' or 'updated', not %s", req.Index)
+	}
+	return l.getInvoices(req)
+}
+
+func (l *Lightning) getInvoices(req *ListInvoiceRequest) ([]*Invoice, error) {
 	var result struct {
 		List []*Invoice `json:"invoices"`
 	}
-	err := l.client.Request(&ListInvoiceRequest{label}, &result)
+	err := l.rpc.Request(req, &result)
 	return result.List, err
 }
 
 type DeleteInvoiceRequest struct {
-	Label  string `json:"label"`
-	Status string `json:"status"`
+	Label    string `json:"label"`
+	Status   string `json:"status"`
+	DescOnly bool   `json:"desconly,omitempty"`
 }
 
 func (r DeleteInvoiceRequest) Name() string {
 	return "delinvoice"
 }
 
-// Delete unpaid invoice {label} with {status}
+// Delete unpaid invoice {label} with {status}. Returns the invoice
+// as it was before deletion.
 func (l *Lightning) DeleteInvoice(label, status string) (*Invoice, error) {
+	return l.deleteInvoice(&DeleteInvoiceRequest{
+		Label:  label,
+		Status: status,
+	})
+}
+
+// Remove only the description from invoice {label} with {status},
+// leaving the rest of the invoice in place. Useful for dropping
+// large or private descriptions from paid invoices.
+func (l *Lightning) DeleteInvoiceDescription(label, status string) (*Invoice, error) {
+	return l.deleteInvoice(&DeleteInvoiceRequest{
+		Label:    label,
+		Status:   status,
+		DescOnly: true,
+	})
+}
+
+func (l *Lightning) deleteInvoice(req *DeleteInvoiceRequest) (*Invoice, error) {
+	if req.Label == "" {
+		return nil, fmt.Errorf("Must provide the label of the invoice to delete")
+	}
+	if req.Status == "" {
+		return nil, fmt.Errorf("Must provide the status of the invoice to delete")
+	}
+
 	var result Invoice
-	err := l.client.Request(&DeleteInvoiceRequest{label, status}, &result)
+	err := l.rpc.Request(req, &result)
 	return &result, err
 }
 
@@ -667,7 +1171,7 @@ func (l *Lightning) WaitAnyInvoice(lastPayIndex uint) (*Invoice, error) {
 		LastPayIndex: lastPayIndex,
 		Timeout:      nil,
 	}
-	err := l.client.RequestNoTimeout(req, &result)
+	err := l.rpc.RequestNoTimeout(req, &result)
 	return &result, err
 }
 
@@ -679,7 +1183,7 @@ func (l *Lightning) WaitAnyInvoiceTimeout(lastPayIndex uint, timeout uint) (*Inv
 		LastPayIndex: lastPayIndex,
 		Timeout:      &timeout,
 	}
-	err := l.client.RequestNoTimeout(req, &result)
+	err := l.rpc.RequestNoTimeout(req, &result)
 	return &result, err
 }
 
@@ -700,7 +1204,7 @@ func (l *Lightning) WaitInvoice(label string) (*Invoice, error) {
 	}
 
 	var result Invoice
-	err := l.client.RequestNoTimeout(&WaitInvoiceRequest{label}, &result)
+	err := l.rpc.RequestNoTimeout(&WaitInvoiceRequest{label}, &result)
 	return &result, err
 }
 
@@ -714,7 +1218,7 @@ func (r DeleteExpiredInvoiceReq) Name() string {
 
 func (l *Lightning) DeleteExpiredInvoicesSince(unixTime uint64) error {
 	var result interface{}
-	return l.client.Request(&DeleteExpiredInvoiceReq{unixTime}, &result)
+	return l.rpc.Request(&DeleteExpiredInvoiceReq{unixTime}, &result)
 }
 
 type AutoCleanInvoiceRequest struct {
@@ -736,7 +1240,7 @@ func (l *Lightning) DisableInvoiceAutoclean() error {
 // Clean up expired invoices that have expired for {expired_by} seconds (default 86400).
 func (l *Lightning) SetInvoiceAutoclean(intervalSeconds, expiredBySeconds uint32) error {
 	var result string
-	err := l.client.Request(&AutoCleanInvoiceRequest{intervalSeconds, expiredBySeconds}, &result)
+	err := l.rpc.Request(&AutoCleanInvoiceRequest{intervalSeconds, expiredBySeconds}, &result)
 	return err
 }
 
@@ -802,7 +1306,7 @@ func (l *Lightning) DecodePay(bolt11, desc string) (*DecodedBolt11, error) {
 	}
 
 	var result DecodedBolt11
-	err := l.client.Request(&DecodePayRequest{bolt11, desc}, &result)
+	err := l.rpc.Request(&DecodePayRequest{bolt11, desc}, &result)
 	return &result, err
 }
 
@@ -860,7 +1364,7 @@ func (l *Lightning) paystatus(bolt11 string) ([]PayStatus, error) {
 	var result struct {
 		Pays []PayStatus `json:"pay"`
 	}
-	err := l.client.Request(&PayStatusRequest{bolt11}, &result)
+	err := l.rpc.Request(&PayStatusRequest{bolt11}, &result)
 	if err != nil {
 		return nil, err
 	}
@@ -882,20 +1386,67 @@ type Command struct {
 	Category     string `json:"category"`
 }
 
+type CommandParam struct {
+	Name     string
+	Optional bool
+}
+
+// The name of the RPC command, e.g. 'invoice'
+func (c *Command) Name() string {
+	fields := strings.Fields(c.NameAndUsage)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// The parameter list for the RPC command, without the command name.
+// e.g. 'id [host] [port]'
+func (c *Command) Usage() string {
+	fields := strings.Fields(c.NameAndUsage)
+	if len(fields) <= 1 {
+		return ""
+	}
+	return strings.Join(fields[1:], " ")
+}
+
+// Parses the command's usage string into its parameters. Parameters
+// wrapped in brackets are marked as optional.
+func (c *Command) Params() []*CommandParam {
+	fields := strings.Fields(c.Usage())
+	params := make([]*CommandParam, 0, len(fields))
+	// brackets can nest, e.g. '[host [port]]'
+	depth := 0
+	for _, field := range fields {
+		depth += strings.Count(field, "[")
+		name := strings.Trim(field, "[]")
+		if name != "" {
+			params = append(params, &CommandParam{
+				Name:     name,
+				Optional: depth > 0,
+			})
+		}
+		depth -= strings.Count(field, "]")
+	}
+	return params
+}
+
 // Show available c-lightning RPC commands
 func (l *Lightning) Help() ([]*Command, error) {
 	var result struct {
 		Commands []*Command `json:"help"`
 	}
-	err := l.client.Request(&HelpRequest{}, &result)
+	err := l.rpc.Request(&HelpRequest{}, &result)
 	return result.Commands, err
 }
 
+// Show the help entry for a single {command}. The parsed usage
+// is available via the returned Command's Params
 func (l *Lightning) HelpFor(command string) (*Command, error) {
 	var result struct {
 		Commands []*Command `json:"help"`
 	}
-	err := l.client.Request(&HelpRequest{command}, &result)
+	err := l.rpc.Request(&HelpRequest{command}, &result)
 	if err != nil {
 		return nil, err
 	}
@@ -915,10 +1466,25 @@ func (r StopRequest) Name() string {
 // of "Shutting down" on success.
 func (l *Lightning) Stop() (string, error) {
 	var result string
-	err := l.client.Request(&StopRequest{}, &result)
+	err := l.rpc.Request(&StopRequest{}, &result)
 	return result, err
 }
 
+type DeprecationsRequest struct {
+	Enable bool `json:"enable"`
+}
+
+func (r DeprecationsRequest) Name() string {
+	return "deprecations"
+}
+
+// Enable or disable deprecated APIs for this RPC connection only,
+// overriding the node's `allow-deprecated-apis` setting.
+func (l *Lightning) Deprecations(enable bool) error {
+	var result struct{}
+	return l.rpc.Request(&DeprecationsRequest{enable}, &result)
+}
+
 type LogLevel int
 
 const (
@@ -965,7 +1531,7 @@ type Log struct {
 // Show logs, with optional log {level} (info|unusual|debug|io)
 func (l *Lightning) GetLog(level LogLevel) (*LogResponse, error) {
 	var result LogResponse
-	err := l.client.Request(&LogRequest{level.String()}, &result)
+	err := l.rpc.Request(&LogRequest{level.String()}, &result)
 	return &result, err
 }
 
@@ -988,7 +1554,7 @@ func (l *Lightning) DevHash(secret string) (string, error) {
 	}
 
 	var result DevHashResult
-	err := l.client.Request(&DevRHashRequest{secret}, &result)
+	err := l.rpc.Request(&DevRHashRequest{secret}, &result)
 	return result.RHash, err
 }
 
@@ -1000,7 +1566,7 @@ func (r DevCrashRequest) Name() string {
 
 // Crash lightningd by calling fatal(). Returns nothing.
 func (l *Lightning) DevCrash() (interface{}, error) {
-	err := l.client.Request(&DevCrashRequest{}, nil)
+	err := l.rpc.Request(&DevCrashRequest{}, nil)
 	return nil, err
 }
 
@@ -1028,7 +1594,7 @@ func (l *Lightning) DevQueryShortChanIds(peerId string, shortChanIds []string) (
 	}
 
 	var result QueryShortChannelIdsResponse
-	err := l.client.Request(&DevQueryShortChanIdsRequest{peerId, shortChanIds}, &result)
+	err := l.rpc.Request(&DevQueryShortChanIdsRequest{peerId, shortChanIds}, &result)
 	return &result, err
 }
 
@@ -1077,7 +1643,7 @@ type AddressInternal struct {
 
 func (l *Lightning) GetInfo() (*NodeInfo, error) {
 	var result NodeInfo
-	err := l.client.Request(&GetInfoRequest{}, &result)
+	err := l.rpc.Request(&GetInfoRequest{}, &result)
 	return &result, err
 }
 
@@ -1097,7 +1663,7 @@ func (r SignMessageRequest) Name() string {
 
 func (l *Lightning) SignMessage(message string) (*SignedMessage, error) {
 	var result SignedMessage
-	err := l.client.Request(&SignMessageRequest{message}, &result)
+	err := l.rpc.Request(&SignMessageRequest{message}, &result)
 	return &result, err
 }
 
@@ -1123,14 +1689,14 @@ func (l *Lightning) CheckMessage(message, zbase string) (bool, string, error) {
 		Message: message,
 		ZBase:   zbase,
 	}
-	err := l.client.Request(request, &result)
+	err := l.rpc.Request(request, &result)
 	return result.Verified, result.Pubkey, err
 }
 
 // Pubkey provided, so we return whether or not is verified
 func (l *Lightning) CheckMessageVerify(message, zbase, pubkey string) (bool, error) {
 	var result CheckedMessage
-	err := l.client.Request(&CheckMessageRequest{message, zbase, pubkey}, &result)
+	err := l.rpc.Request(&CheckMessageRequest{message, zbase, pubkey}, &result)
 	return result.Verified, err
 }
 
@@ -1142,6 +1708,12 @@ type SendPayRequest struct {
 	Bolt11        string     `json:"bolt11,omitempty"`
 	PaymentSecret string     `json:"payment_secret,omitempty"`
 	PartId        uint64     `json:"partid,omitempty"`
+	// The amount (for MPP payments, the total across all parts), as
+	// lightningd takes it from v0.12 on; before then, it's named
+	// 'msatoshi' and set with MilliSatoshis. Set just one of the two.
+	TotalMsat     string `json:"amount_msat,omitempty"`
+	LocalInvReqId string `json:"localinvreqid,omitempty"`
+	GroupId       uint64 `json:"groupid,omitempty"`
 }
 
 func (r SendPayRequest) Name() string {
@@ -1162,6 +1734,7 @@ type SendPayFields struct {
 	Label                 string  `json:"label,omitempty"`
 	Bolt11                string  `json:"bolt11,omitempty"`
 	PartId                uint64  `json:"partid,omitempty"`
+	GroupId               uint64  `json:"groupid,omitempty"`
 	ErrorOnion            string  `json:"erroronion,omitempty"`
 }
 
@@ -1210,7 +1783,7 @@ func (l *Lightning) SendPay(route []RouteHop, paymentHash, label string, msat *u
 	}
 
 	var result SendPayResult
-	err := l.client.Request(&SendPayRequest{
+	err := l.rpc.Request(&SendPayRequest{
 		Route:         route,
 		PaymentHash:   paymentHash,
 		Label:         label,
@@ -1219,26 +1792,62 @@ func (l *Lightning) SendPay(route []RouteHop, paymentHash, label string, msat *u
 		PaymentSecret: paymentSecret,
 		PartId:        partId,
 	}, &result)
-	return &result, err
+	return &result, toPaymentError(err)
+}
+
+// Send a single part of a multi-part payment, or a payment with
+// any of the less common options set (see SendPayRequest).
+//
+// When a 'PartId' is set, the 'TotalMsat' (the amount of the entire
+// payment, across all parts; 'MilliSatoshis' before v0.12) must also
+// be provided, as well as the 'PaymentSecret' from the invoice. All
+// parts of a payment should share the same 'GroupId'.
+func (l *Lightning) SendPayPart(req *SendPayRequest) (*SendPayResult, error) {
+	if req.PaymentHash == "" {
+		return nil, fmt.Errorf("Must specify a paymentHash to pay")
+	}
+	if len(req.Route) == 0 {
+		return nil, fmt.Errorf("Must specify a route to send payment along")
+	}
+	if req.PartId != 0 && req.TotalMsat == "" && req.MilliSatoshis == nil {
+		return nil, fmt.Errorf("Must specify the total amount when sending a payment part")
+	}
+	if req.TotalMsat != "" && req.MilliSatoshis != nil {
+		return nil, fmt.Errorf("Must specify only one of TotalMsat and MilliSatoshis")
+	}
+
+	var result SendPayResult
+	err := l.rpc.Request(req, &result)
+	return &result, toPaymentError(err)
 }
 
 type WaitSendPayRequest struct {
 	PaymentHash string `json:"payment_hash"`
 	Timeout     uint   `json:"timeout,omitempty"`
 	PartId      uint64 `json:"partid,omitempty"`
+	GroupId     uint64 `json:"groupid,omitempty"`
 }
 
 func (r WaitSendPayRequest) Name() string {
 	return "waitsendpay"
 }
 
+// A failed payment (pay, keysend, sendpay, sendonion or waitsendpay):
+// lightningd's error, plus the details of the failure it sent with it
 type PaymentError struct {
 	*jrpc2.RpcError
 	Data *PaymentErrorData
 }
 
+func (e *PaymentError) Unwrap() error {
+	return e.RpcError
+}
+
 type PaymentErrorData struct {
 	*PaymentFields
+	PaymentHash     string `json:"payment_hash,omitempty"`
+	Destination     string `json:"destination,omitempty"`
+	AmountMsat      string `json:"amount_msat,omitempty"`
 	OnionReply      string `json:"onionreply,omitempty"`
 	ErringIndex     uint64 `json:"erring_index"`
 	FailCode        int    `json:"failcode"`
@@ -1247,6 +1856,8 @@ type PaymentErrorData struct {
 	ErringDirection int    `json:"erring_direction,omitempty"`
 	FailCodeName    string `json:"failcodename,omitempty"`
 	RawMessage      string `json:"raw_message,omitempty"`
+	// RawMessage decoded, if lightningd could decrypt the failure
+	Failure *OnionFailure `json:"-"`
 }
 
 // Polls or waits for the status of an outgoing payment that was
@@ -1263,38 +1874,59 @@ func (l *Lightning) WaitSendPay(paymentHash string, timeout uint) (*SendPayField
 }
 
 func (l *Lightning) WaitSendPayPart(paymentHash string, timeout uint, partId uint64) (*SendPayFields, error) {
+	return l.WaitSendPayPartInGroup(paymentHash, timeout, partId, 0)
+}
+
+// Wait on a single part {partId} of a multi-part payment, sent as part of
+// the payment attempt {groupId}. A zero {groupId} will match the latest
+// payment attempt for the {paymentHash}.
+func (l *Lightning) WaitSendPayPartInGroup(paymentHash string, timeout uint, partId, groupId uint64) (*SendPayFields, error) {
 	if paymentHash == "" {
 		return nil, fmt.Errorf("Must provide a payment hash to pay")
 	}
 
 	var result SendPayFields
-	err := l.client.RequestNoTimeout(&WaitSendPayRequest{
+	err := l.rpc.RequestNoTimeout(&WaitSendPayRequest{
 		PaymentHash: paymentHash,
 		Timeout:     timeout,
 		PartId:      partId,
+		GroupId:     groupId,
 	}, &result)
-	if err, ok := err.(*jrpc2.RpcError); ok {
-		var paymentErrData PaymentErrorData
-		parseErr := err.ParseData(&paymentErrData)
-		if parseErr != nil {
-			log.Printf(parseErr.Error())
-			return &result, err
-		}
-		return &result, &PaymentError{err, &paymentErrData}
-	}
+	return &result, toPaymentError(err)
+}
 
-	return &result, err
+// Turn an RPC error from a payment command into a PaymentError, with
+// the failure details from its data. Errors without any are returned
+// as they are.
+func toPaymentError(err error) error {
+	rpcErr, ok := err.(*jrpc2.RpcError)
+	if !ok || !rpcErr.HasData() {
+		return err
+	}
+	var data PaymentErrorData
+	if rpcErr.UnmarshalData(&data) != nil {
+		return err
+	}
+	if raw, hexErr := hex.DecodeString(data.RawMessage); hexErr == nil && len(raw) > 0 {
+		data.Failure, _ = DecodeOnionFailure(raw)
+	}
+	return &PaymentError{rpcErr, &data}
 }
 
 type PayRequest struct {
-	Bolt11        string  `json:"bolt11"`
-	MilliSatoshi  uint64  `json:"msatoshi,omitempty"`
-	Desc          string  `json:"description,omitempty"`
-	RiskFactor    float32 `json:"riskfactor,omitempty"`
-	MaxFeePercent float32 `json:"maxfeeprecent,omitempty"`
-	RetryFor      uint    `json:"retry_for,omitempty"`
-	MaxDelay      uint    `json:"maxdelay,omitempty"`
-	ExemptFee     bool    `json:"exemptfee,omitempty"`
+	Bolt11        string   `json:"bolt11"`
+	MilliSatoshi  uint64   `json:"msatoshi,omitempty"`
+	Desc          string   `json:"description,omitempty"`
+	RiskFactor    float32  `json:"riskfactor,omitempty"`
+	MaxFeePercent float32  `json:"maxfeepercent,omitempty"`
+	RetryFor      uint     `json:"retry_for,omitempty"`
+	MaxDelay      uint     `json:"maxdelay,omitempty"`
+	ExemptFee     string   `json:"exemptfee,omitempty"`
+	Label         string   `json:"label,omitempty"`
+	Exclude       []string `json:"exclude,omitempty"`
+	MaxFee        string   `json:"maxfee,omitempty"`
+	LocalInvReqId string   `json:"localinvreqid,omitempty"`
+	PartialMsat   string   `json:"partial_msat,omitempty"`
 }
 
 func (r PayRequest) Name() string {
@@ -1309,6 +1941,9 @@ type PaymentSuccess struct {
 	SendPayTries  int          `json:"sendpay_tries"`
 	Route         []RouteHop   `json:"route"`
 	Failures      []PayFailure `json:"failures"`
+	Parts         uint32       `json:"parts,omitempty"`
+	// Set if only some parts of the payment have completed
+	WarningPartialCompletion string `json:"warning_partial_completion,omitempty"`
 }
 
 type PayFailure struct {
@@ -1329,6 +1964,22 @@ func (l *Lightning) PayBolt(bolt11 string) (*PaymentSuccess, error) {
 	})
 }
 
+// Pay only {partial} of the amount of {bolt11}, the remainder being paid
+// by other nodes (e.g. splitting a bill). The payment only completes once
+// the recipient has received the invoice's full amount.
+//
+// The returned result's 'AmountMilliSatoshi' is the amount this node
+// paid, not the amount of the invoice.
+func (l *Lightning) PayPartial(bolt11 string, partial *MSat) (*PaymentSuccess, error) {
+	if partial == nil || partial.Value == 0 {
+		return nil, fmt.Errorf("Must set a partial amount to pay")
+	}
+	return l.Pay(&PayRequest{
+		Bolt11:      bolt11,
+		PartialMsat: partial.String(),
+	})
+}
+
 // Send payment as specified by 'Bolt11' with 'MilliSatoshi'
 // (Millisatoshis amount is ignored if the 'Bolt11' includes an amount).
 //
@@ -1346,7 +1997,16 @@ func (l *Lightning) PayBolt(bolt11 string) (*PaymentSuccess, error) {
 // 'ExemptFee' can be used for tiny paymetns which would otherwise be
 // dominated by the fee leveraged by forwarding nodes. Setting 'ExemptFee'
 // allows 'MaxFeePercent' check to be skipped on fees that are smaller than
-// 'ExemptFee'. c-lightning default is 5000 millisatoshi.
+// 'ExemptFee'. c-lightning default is 5000 millisatoshi. (e.g. "5000msat")
+//
+// 'MaxFee' is an absolute cap on the fee paid (e.g. "1000msat"), and
+// can't be combined with 'MaxFeePercent' or 'ExemptFee'.
+//
+// 'Exclude' is a list of short channel ids with direction (scid/direction)
+// or node ids that won't be used when routing the payment.
+//
+// 'PartialMsat' lets this node pay only part of the invoice's amount,
+// with the remainder expected to come from other nodes.
 //
 // c-lightning will keep finding routes and retrying payment until it succeeds
 // or the given 'RetryFor' seconds have elapsed.  Note that the command may
@@ -1359,18 +2019,75 @@ func (l *Lightning) PayBolt(bolt11 string) (*PaymentSuccess, error) {
 // Defaults to the configured locktime max (--max-locktime-blocks)
 // Units is in blocks.
 func (l *Lightning) Pay(req *PayRequest) (*PaymentSuccess, error) {
-	if req.Bolt11 == "" {
-		return nil, fmt.Errorf("Must supply a Bolt11 to pay")
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	var result PaymentSuccess
+	err := l.rpc.RequestNoTimeout(req, &result)
+	return &result, toPaymentError(err)
+}
+
+func (r *PayRequest) validate() error {
+	if r.Bolt11 == "" {
+		return fmt.Errorf("Must supply a Bolt11 to pay")
+	}
+	if r.RiskFactor < 0 {
+		return fmt.Errorf("Risk factor must be postiive %f", r.RiskFactor)
+	}
+	if r.MaxFeePercent < 0 || r.MaxFeePercent > 100 {
+		return fmt.Errorf("MaxFeePercent must be a percentage. %f", r.MaxFeePercent)
+	}
+	if r.MaxFee != "" && (r.MaxFeePercent != 0 || r.ExemptFee != "") {
+		return fmt.Errorf("MaxFee can't be combined with MaxFeePercent or ExemptFee")
+	}
+	return nil
+}
+
+type KeySendRequest struct {
+	Destination   string            `json:"destination"`
+	AmountMsat    uint64            `json:"amount_msat"`
+	Label         string            `json:"label,omitempty"`
+	MaxFeePercent float32           `json:"maxfeepercent,omitempty"`
+	RetryFor      uint              `json:"retry_for,omitempty"`
+	MaxDelay      uint              `json:"maxdelay,omitempty"`
+	ExemptFee     string            `json:"exemptfee,omitempty"`
+	ExtraTlvs     map[string]string `json:"extratlvs,omitempty"`
+}
+
+func (r KeySendRequest) Name() string {
+	return "keysend"
+}
+
+// Pay {msat} to {destination} without an invoice (keysend). Each of
+// {extraTlvs} is added to the final hop's onion payload; use odd
+// types (conventionally above 65536), which the recipient may ignore.
+func (l *Lightning) KeySend(destination string, msat uint64, label string, extraTlvs []*tlv.Record) (*PaymentSuccess, error) {
+	if err := checkNodeId(destination); err != nil {
+		return nil, err
+	}
+	if msat == 0 {
+		return nil, fmt.Errorf("Must send a non-zero amount")
 	}
-	if req.RiskFactor < 0 {
-		return nil, fmt.Errorf("Risk factor must be postiive %f", req.RiskFactor)
+
+	req := &KeySendRequest{
+		Destination: destination,
+		AmountMsat:  msat,
+		Label:       label,
 	}
-	if req.MaxFeePercent < 0 || req.MaxFeePercent > 100 {
-		return nil, fmt.Errorf("MaxFeePercent must be a percentage. %f", req.MaxFeePercent)
+	if len(extraTlvs) > 0 {
+		req.ExtraTlvs = make(map[string]string, len(extraTlvs))
+		for _, rec := range extraTlvs {
+			typ := strconv.FormatUint(rec.Type, 10)
+			if _, ok := req.ExtraTlvs[typ]; ok {
+				return nil, fmt.Errorf("Duplicate extra tlv type %s", typ)
+			}
+			req.ExtraTlvs[typ] = hex.EncodeToString(rec.Value)
+		}
 	}
+
 	var result PaymentSuccess
-	err := l.client.RequestNoTimeout(req, &result)
-	return &result, err
+	err := l.rpc.RequestNoTimeout(req, &result)
+	return &result, toPaymentError(err)
 }
 
 type PaymentFields struct {
@@ -1393,7 +2110,7 @@ func (l *Lightning) ListPays() ([]PaymentFields, error) {
 	var result struct {
 		Payments []PaymentFields `json:"pays"`
 	}
-	err := l.client.Request(&ListPaysRequest{}, &result)
+	err := l.rpc.Request(&ListPaysRequest{}, &result)
 	return result.Payments, err
 }
 
@@ -1401,7 +2118,7 @@ func (l *Lightning) ListPaysToBolt11(bolt11 string) ([]PaymentFields, error) {
 	var result struct {
 		Payments []PaymentFields `json:"payments"`
 	}
-	err := l.client.Request(&ListPaysRequest{bolt11}, &result)
+	err := l.rpc.Request(&ListPaysRequest{bolt11}, &result)
 	return result.Payments, err
 }
 
@@ -1436,7 +2153,7 @@ func (l *Lightning) listSendPays(req *ListSendPaysRequest) ([]SendPayFields, err
 	var result struct {
 		Payments []SendPayFields `json:"payments"`
 	}
-	err := l.client.Request(req, &result)
+	err := l.rpc.Request(req, &result)
 	return result.Payments, err
 }
 
@@ -1477,17 +2194,119 @@ func (l *Lightning) ListTransactions() ([]Transaction, error) {
 	var result struct {
 		Transactions []Transaction `json:"transactions"`
 	}
-	err := l.client.Request(&TransactionsRequest{}, &result)
+	err := l.rpc.Request(&TransactionsRequest{}, &result)
 	return result.Transactions, err
 }
 
-type ConnectRequest struct {
-	PeerId string `json:"id"`
-	Host   string `json:"host"`
-	Port   uint   `json:"port"`
+type BkprListAccountEventsRequest struct {
+	Account   string `json:"account,omitempty"`
+	PaymentId string `json:"payment_id,omitempty"`
 }
 
-func (r ConnectRequest) Name() string {
+func (r BkprListAccountEventsRequest) Name() string {
+	return "bkpr-listaccountevents"
+}
+
+// A movement of funds recorded by the bookkeeper plugin
+type BkprAccountEvent struct {
+	// "wallet", a channel id, or "external"
+	Account string `json:"account"`
+	// "chain", "channel" or "onchain_fee"
+	Type       string `json:"type"`
+	Tag        string `json:"tag"`
+	CreditMsat uint64 `json:"credit_msat"`
+	DebitMsat  uint64 `json:"debit_msat"`
+	Currency   string `json:"currency"`
+	Timestamp  uint64 `json:"timestamp"`
+	// For chain events
+	Outpoint    string `json:"outpoint,omitempty"`
+	Blockheight uint32 `json:"blockheight,omitempty"`
+	Origin      string `json:"origin,omitempty"`
+	// For withdrawals, the transaction which spent the outpoint
+	SpendingTxId string `json:"spending_txid,omitempty"`
+	// For channel events; a payment hash
+	PaymentId   string `json:"payment_id,omitempty"`
+	PartId      uint64 `json:"part_id,omitempty"`
+	FeesMsat    uint64 `json:"fees_msat,omitempty"`
+	IsRebalance bool   `json:"is_rebalance,omitempty"`
+	// For onchain_fee events
+	TxId        string `json:"txid,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// List the bookkeeper's events for {account}, or for every account if
+// it's empty. Requires the bookkeeper plugin, which ships with
+// lightningd from v23.02.
+func (l *Lightning) BkprListAccountEvents(account string) ([]*BkprAccountEvent, error) {
+	var result struct {
+		Events []*BkprAccountEvent `json:"events"`
+	}
+	err := l.rpc.Request(&BkprListAccountEventsRequest{Account: account}, &result)
+	return result.Events, err
+}
+
+type StaticBackupRequest struct{}
+
+func (r StaticBackupRequest) Name() string {
+	return "staticbackup"
+}
+
+// The static channel backup (SCB) of each of the node's channels, as
+// hex. See the backup package for decoding and storing them.
+func (l *Lightning) StaticBackup() ([]string, error) {
+	var result struct {
+		Scb []string `json:"scb"`
+	}
+	err := l.rpc.Request(&StaticBackupRequest{}, &result)
+	return result.Scb, err
+}
+
+type EmergencyRecoverRequest struct{}
+
+func (r EmergencyRecoverRequest) Name() string {
+	return "emergencyrecover"
+}
+
+// Restore the channels in the node's emergency.recover file as
+// stubs, whose peers are then asked to close them. Returns the ids
+// of the channels restored; those the node already has are skipped.
+func (l *Lightning) EmergencyRecover() ([]string, error) {
+	var result struct {
+		Stubs []string `json:"stubs"`
+	}
+	err := l.rpc.Request(&EmergencyRecoverRequest{}, &result)
+	return result.Stubs, err
+}
+
+type RecoverChannelRequest struct {
+	Scb []string `json:"scb"`
+}
+
+func (r RecoverChannelRequest) Name() string {
+	return "recoverchannel"
+}
+
+// Restore channels from static channel backups {scbs}, as returned
+// by StaticBackup, as with EmergencyRecover. Returns the ids of the
+// channels restored.
+func (l *Lightning) RecoverChannel(scbs []string) ([]string, error) {
+	if len(scbs) == 0 {
+		return nil, fmt.Errorf("Must provide static channel backups to recover")
+	}
+	var result struct {
+		Stubs []string `json:"stubs"`
+	}
+	err := l.rpc.Request(&RecoverChannelRequest{scbs}, &result)
+	return result.Stubs, err
+}
+
+type ConnectRequest struct {
+	PeerId string `json:"id"`
+	Host   string `json:"host"`
+	Port   uint   `json:"port"`
+}
+
+func (r ConnectRequest) Name() string {
 	return "connect"
 }
 
@@ -1501,9 +2320,18 @@ type ConnectResult struct {
 }
 
 // Connect to {peerId} at {host}:{port}. Returns result with peer id and peer's features
+// {peerId} may also be given in the form 'id@host[:port]'.
 func (l *Lightning) ConnectPeer(peerId, host string, port uint) (*ConnectResult, error) {
+	id := peerId
+	if i := strings.IndexByte(id, '@'); i >= 0 {
+		id = id[:i]
+	}
+	if err := checkNodeId(id); err != nil {
+		return nil, err
+	}
+
 	var result ConnectResult
-	err := l.client.Request(&ConnectRequest{peerId, host, port}, &result)
+	err := l.rpc.Request(&ConnectRequest{peerId, host, port}, &result)
 	return &result, err
 }
 
@@ -1511,7 +2339,10 @@ func (l *Lightning) ConnectPeer(peerId, host string, port uint) (*ConnectResult,
 // Sort of deprecated, use ConnectPeer, as it gives you back the peer's init features as well
 func (l *Lightning) Connect(peerId, host string, port uint) (string, error) {
 	result, err := l.ConnectPeer(peerId, host, port)
-	return result.Id, err
+	if err != nil {
+		return "", err
+	}
+	return result.Id, nil
 }
 
 type FundChannelRequest struct {
@@ -1521,6 +2352,15 @@ type FundChannelRequest struct {
 	Announce bool    `json:"announce"`
 	MinConf  *uint16 `json:"minconf,omitempty"`
 	PushMsat string  `json:"push_msat,omitempty"`
+	// Liquidity to lease from the peer, at the rates it advertises
+	// in CompactLease (see WillFund)
+	RequestAmt   string `json:"request_amt,omitempty"`
+	CompactLease string `json:"compact_lease,omitempty"`
+	// Confirmations before the channel is usable; 0 opens a zero-conf
+	// channel, which the peer must agree to
+	MinDepth *uint32 `json:"mindepth,omitempty"`
+	// The channel type to propose, as feature bits
+	ChannelType []FeatureBit `json:"channel_type,omitempty"`
 }
 
 func (r FundChannelRequest) Name() string {
@@ -1528,9 +2368,11 @@ func (r FundChannelRequest) Name() string {
 }
 
 type FundChannelResult struct {
-	FundingTx   string `json:"tx"`
-	FundingTxId string `json:"txid"`
-	ChannelId   string `json:"channel_id"`
+	FundingTx   string       `json:"tx"`
+	FundingTxId string       `json:"txid"`
+	ChannelId   string       `json:"channel_id"`
+	ChannelType *ChannelType `json:"channel_type,omitempty"`
+	MinDepth    *uint32      `json:"mindepth,omitempty"`
 }
 
 // Fund channel, defaults to public channel and default feerate.
@@ -1556,6 +2398,9 @@ func (l *Lightning) FundPrivateChannelAtFee(id string, amount *Sat, feerate *Fee
 // can send an optional 'pushMsat', of millisatoshis to push to peer (from your funding amount)
 // Any pushed msats are irrevocably gifted to the peer. (use only if you enjoy being a sats santa!)
 func (l *Lightning) FundChannelExt(id string, amount *Sat, feerate *FeeRate, announce bool, minConf *uint16, pushMSat *MSat) (*FundChannelResult, error) {
+	if err := checkNodeId(id); err != nil {
+		return nil, err
+	}
 	if amount == nil || (amount.Value == 0 && !amount.SendAll) {
 		return nil, fmt.Errorf("Must set satoshi amount to send")
 	}
@@ -1574,7 +2419,108 @@ func (l *Lightning) FundChannelExt(id string, amount *Sat, feerate *FeeRate, ann
 	req.MinConf = minConf
 
 	var result FundChannelResult
-	err := l.client.Request(req, &result)
+	err := l.rpc.Request(req, &result)
+	return &result, err
+}
+
+// Fund the channel {req}, as built by a FundChannelBuilder
+func (l *Lightning) FundChannelWithOptions(req *FundChannelRequest) (*FundChannelResult, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	var result FundChannelResult
+	err := l.rpc.Request(req, &result)
+	return &result, err
+}
+
+func (r *FundChannelRequest) validate() error {
+	if err := checkNodeId(r.Id); err != nil {
+		return err
+	}
+	if r.Amount == "" {
+		return fmt.Errorf("Must set satoshi amount to send")
+	}
+	if (r.RequestAmt == "") != (r.CompactLease == "") {
+		return fmt.Errorf("Must set both the amount to lease and the lease rates")
+	}
+	return nil
+}
+
+type MultiFundChannelRequest struct {
+	Destinations []*FundDestination `json:"destinations"`
+	FeeRate      string             `json:"feerate,omitempty"`
+	MinConf      *uint16            `json:"minconf,omitempty"`
+	Utxos        []string           `json:"utxos,omitempty"`
+	// Go ahead with the channels which could be opened, so long as
+	// there are at least this many
+	MinChannels uint `json:"minchannels,omitempty"`
+}
+
+func (r MultiFundChannelRequest) Name() string {
+	return "multifundchannel"
+}
+
+type FundDestination struct {
+	// A node id, optionally with "@host:port"
+	Id       string `json:"id"`
+	Amount   string `json:"amount"`
+	Announce bool   `json:"announce"`
+	PushMsat string `json:"push_msat,omitempty"`
+	CloseTo  string `json:"close_to,omitempty"`
+}
+
+type MultiFundChannelResult struct {
+	FundingTx   string                   `json:"tx"`
+	FundingTxId string                   `json:"txid"`
+	ChannelIds  []*MultiFundChannelId    `json:"channel_ids"`
+	Failed      []*MultiFundChannelError `json:"failed,omitempty"`
+}
+
+type MultiFundChannelId struct {
+	Id        string `json:"id"`
+	ChannelId string `json:"channel_id"`
+	Outnum    uint32 `json:"outnum"`
+	CloseTo   string `json:"close_to,omitempty"`
+}
+
+// A destination dropped from the funding transaction
+type MultiFundChannelError struct {
+	Id     string          `json:"id"`
+	Method string          `json:"method"`
+	Error  *jrpc2.RpcError `json:"error"`
+}
+
+// Open channels to all of {destinations} in a single funding
+// transaction. With {minChannels} set, destinations which fail are
+// dropped (and listed in the result's Failed) so long as that many
+// channels are left.
+func (l *Lightning) MultiFundChannel(destinations []*FundDestination, feerate *FeeRate, minConf *uint16, utxos []*Utxo, minChannels uint) (*MultiFundChannelResult, error) {
+	if len(destinations) == 0 {
+		return nil, fmt.Errorf("Must specify at least one destination")
+	}
+	for _, dest := range destinations {
+		if err := checkNodeId(strings.SplitN(dest.Id, "@", 2)[0]); err != nil {
+			return nil, err
+		}
+		if dest.Amount == "" {
+			return nil, fmt.Errorf("Must set satoshi amount to send")
+		}
+	}
+
+	req := &MultiFundChannelRequest{
+		Destinations: destinations,
+		MinConf:      minConf,
+		MinChannels:  minChannels,
+	}
+	if feerate != nil {
+		req.FeeRate = feerate.String()
+	}
+	for _, utxo := range utxos {
+		req.Utxos = append(req.Utxos, utxo.String())
+	}
+
+	var result MultiFundChannelResult
+	err := l.rpc.Request(req, &result)
 	return &result, err
 }
 
@@ -1596,6 +2542,9 @@ func (r FundChannelStart) Name() string {
 
 // Returns a string that's a bech32 address. this address is the funding output address.
 func (l *Lightning) StartFundChannel(id string, amount uint64, announce bool, feerate *FeeRate, closeTo string) (*StartResponse, error) {
+	if err := checkNodeId(id); err != nil {
+		return nil, err
+	}
 	var result StartResponse
 
 	req := &FundChannelStart{
@@ -1609,7 +2558,7 @@ func (l *Lightning) StartFundChannel(id string, amount uint64, announce bool, fe
 		req.FeeRate = feerate.String()
 	}
 
-	err := l.client.Request(req, &result)
+	err := l.rpc.Request(req, &result)
 	return &result, err
 }
 
@@ -1629,7 +2578,32 @@ func (l *Lightning) CompleteFundChannel(peerId, txId string, txout uint32) (chan
 		CommitmentsSecured bool   `json:"commitments_secured"`
 	}
 
-	err = l.client.Request(&FundChannelComplete{peerId, txId, txout}, &result)
+	err = l.rpc.Request(&FundChannelComplete{peerId, txId, txout}, &result)
+	return result.ChannelId, err
+}
+
+type FundChannelCompletePsbt struct {
+	PeerId string `json:"id"`
+	Psbt   string `json:"psbt"`
+}
+
+func (r FundChannelCompletePsbt) Name() string {
+	return "fundchannel_complete"
+}
+
+// Complete the channel opening begun with StartFundChannel, given
+// {psbt}, which pays the funding address. Don't broadcast the funding
+// transaction until this succeeds, or the funds could be stuck.
+func (l *Lightning) CompleteFundChannelPsbt(peerId, psbt string) (channelId string, err error) {
+	if psbt == "" {
+		return "", fmt.Errorf("Must provide a psbt funding the channel")
+	}
+	var result struct {
+		ChannelId          string `json:"channel_id"`
+		CommitmentsSecured bool   `json:"commitments_secured"`
+	}
+
+	err = l.rpc.Request(&FundChannelCompletePsbt{peerId, psbt}, &result)
 	return result.ChannelId, err
 }
 
@@ -1646,10 +2620,224 @@ func (l *Lightning) CancelFundChannel(peerId string) (bool, error) {
 		Cancelled string `json:"cancelled"`
 	}
 
-	err := l.client.Request(&FundChannelCancel{peerId}, &result)
+	err := l.rpc.Request(&FundChannelCancel{peerId}, &result)
 	return err == nil, err
 }
 
+// The result of openchannel_bump and openchannel_update, part of
+// negotiating a dual-funded (v2) open with the peer
+type OpenChannelV2Result struct {
+	ChannelId string `json:"channel_id"`
+	// The funding PSBT, with the peer's contributions so far
+	Psbt string `json:"psbt"`
+	// Once true, sign the PSBT and pass it to OpenChannelSigned;
+	// otherwise pass it back to OpenChannelUpdate
+	CommitmentsSecured      bool   `json:"commitments_secured"`
+	FundingSerial           uint64 `json:"funding_serial,omitempty"`
+	FundingOutnum           uint32 `json:"funding_outnum,omitempty"`
+	CloseTo                 string `json:"close_to,omitempty"`
+	RequiresConfirmedInputs bool   `json:"requires_confirmed_inputs,omitempty"`
+}
+
+type OpenChannelBumpRequest struct {
+	ChannelId      string `json:"channel_id"`
+	Amount         string `json:"amount"`
+	InitialPsbt    string `json:"initialpsbt"`
+	FundingFeeRate string `json:"funding_feerate,omitempty"`
+}
+
+func (r OpenChannelBumpRequest) Name() string {
+	return "openchannel_bump"
+}
+
+// Start replacing the funding transaction of the unconfirmed
+// dual-funded channel {channelId} by fee (RBF), contributing {amount}
+// from {initialPsbt}, which must spend at least one of the inputs of
+// the transaction being replaced. Continue with OpenChannelUpdate.
+func (l *Lightning) OpenChannelBump(channelId string, amount *Sat, initialPsbt string, feerate *FeeRate) (*OpenChannelV2Result, error) {
+	if channelId == "" {
+		return nil, fmt.Errorf("Must provide a channel id to bump")
+	}
+	if amount == nil {
+		return nil, fmt.Errorf("Must set satoshi amount to fund")
+	}
+	if initialPsbt == "" {
+		return nil, fmt.Errorf("Must provide an initial psbt")
+	}
+	req := &OpenChannelBumpRequest{
+		ChannelId:   channelId,
+		Amount:      amount.RawString(),
+		InitialPsbt: initialPsbt,
+	}
+	if feerate != nil {
+		req.FundingFeeRate = feerate.String()
+	}
+	var result OpenChannelV2Result
+	err := l.rpc.Request(req, &result)
+	return &result, err
+}
+
+type OpenChannelUpdateRequest struct {
+	ChannelId string `json:"channel_id"`
+	Psbt      string `json:"psbt"`
+}
+
+func (r OpenChannelUpdateRequest) Name() string {
+	return "openchannel_update"
+}
+
+// Pass the latest funding {psbt} for {channelId} to the peer, until
+// the result's CommitmentsSecured is set
+func (l *Lightning) OpenChannelUpdate(channelId, psbt string) (*OpenChannelV2Result, error) {
+	if channelId == "" || psbt == "" {
+		return nil, fmt.Errorf("Must provide a channel id and psbt to update")
+	}
+	var result OpenChannelV2Result
+	err := l.rpc.Request(&OpenChannelUpdateRequest{channelId, psbt}, &result)
+	return &result, err
+}
+
+type OpenChannelSignedRequest struct {
+	ChannelId  string `json:"channel_id"`
+	SignedPsbt string `json:"signed_psbt"`
+}
+
+func (r OpenChannelSignedRequest) Name() string {
+	return "openchannel_signed"
+}
+
+type OpenChannelSignedResult struct {
+	ChannelId string `json:"channel_id"`
+	Tx        string `json:"tx"`
+	TxId      string `json:"txid"`
+}
+
+// Send our signatures for the funding transaction of {channelId}. Once
+// the peer's arrive lightningd broadcasts it.
+func (l *Lightning) OpenChannelSigned(channelId, signedPsbt string) (*OpenChannelSignedResult, error) {
+	if channelId == "" || signedPsbt == "" {
+		return nil, fmt.Errorf("Must provide a channel id and signed psbt")
+	}
+	var result OpenChannelSignedResult
+	err := l.rpc.Request(&OpenChannelSignedRequest{channelId, signedPsbt}, &result)
+	return &result, err
+}
+
+type OpenChannelAbortRequest struct {
+	ChannelId string `json:"channel_id"`
+}
+
+func (r OpenChannelAbortRequest) Name() string {
+	return "openchannel_abort"
+}
+
+type OpenChannelAbortResult struct {
+	ChannelId string `json:"channel_id"`
+	// Whether the channel was forgotten, rather than only the round
+	// of negotiation in progress being abandoned
+	ChannelCanceled bool   `json:"channel_canceled"`
+	Reason          string `json:"reason"`
+}
+
+// Abandon the open, or RBF round, of {channelId} being negotiated
+func (l *Lightning) OpenChannelAbort(channelId string) (*OpenChannelAbortResult, error) {
+	if channelId == "" {
+		return nil, fmt.Errorf("Must provide a channel id to abort")
+	}
+	var result OpenChannelAbortResult
+	err := l.rpc.Request(&OpenChannelAbortRequest{channelId}, &result)
+	return &result, err
+}
+
+type SpliceInitRequest struct {
+	ChannelId      string `json:"channel_id"`
+	RelativeAmount int64  `json:"relative_amount"`
+	InitialPsbt    string `json:"initialpsbt,omitempty"`
+	FeeRatePerKw   uint32 `json:"feerate_per_kw,omitempty"`
+	ForceFeeRate   bool   `json:"force_feerate,omitempty"`
+}
+
+func (r SpliceInitRequest) Name() string {
+	return "splice_init"
+}
+
+// The result of splice_init and splice_update
+type SpliceUpdateResult struct {
+	// The splice PSBT, with the peer's contributions so far
+	Psbt string `json:"psbt"`
+	// Once true, sign our inputs of the PSBT and pass it to
+	// SpliceSigned; otherwise pass it back to SpliceUpdate
+	CommitmentsSecured bool `json:"commitments_secured"`
+	SignaturesSecured  bool `json:"signatures_secured,omitempty"`
+}
+
+// Start splicing {relativeAmount} satoshi into channel {channelId}, or
+// out of it if negative. {initialPsbt} has the inputs funding a splice
+// in, or the outputs paid by a splice out. A {feeRatePerKw} of 0 uses
+// lightningd's estimate. Continue with SpliceUpdate.
+func (l *Lightning) SpliceInit(channelId string, relativeAmount int64, initialPsbt string, feeRatePerKw uint32) (*SpliceUpdateResult, error) {
+	if channelId == "" {
+		return nil, fmt.Errorf("Must provide a channel id to splice")
+	}
+	var result SpliceUpdateResult
+	err := l.rpc.Request(&SpliceInitRequest{
+		ChannelId:      channelId,
+		RelativeAmount: relativeAmount,
+		InitialPsbt:    initialPsbt,
+		FeeRatePerKw:   feeRatePerKw,
+	}, &result)
+	return &result, err
+}
+
+type SpliceUpdateRequest struct {
+	ChannelId string `json:"channel_id"`
+	Psbt      string `json:"psbt"`
+}
+
+func (r SpliceUpdateRequest) Name() string {
+	return "splice_update"
+}
+
+// Pass the latest splice {psbt} for {channelId} to the peer, until the
+// result's CommitmentsSecured is set
+func (l *Lightning) SpliceUpdate(channelId, psbt string) (*SpliceUpdateResult, error) {
+	if channelId == "" || psbt == "" {
+		return nil, fmt.Errorf("Must provide a channel id and psbt to update")
+	}
+	var result SpliceUpdateResult
+	err := l.rpc.Request(&SpliceUpdateRequest{channelId, psbt}, &result)
+	return &result, err
+}
+
+type SpliceSignedRequest struct {
+	ChannelId string `json:"channel_id"`
+	Psbt      string `json:"psbt"`
+	SignFirst bool   `json:"sign_first,omitempty"`
+}
+
+func (r SpliceSignedRequest) Name() string {
+	return "splice_signed"
+}
+
+type SpliceSignedResult struct {
+	Tx   string `json:"tx"`
+	TxId string `json:"txid"`
+	Psbt string `json:"psbt,omitempty"`
+	// The channel's new funding output
+	Outnum uint32 `json:"outnum,omitempty"`
+}
+
+// Send our signatures for the splice of {channelId}, with our inputs
+// of {psbt} signed. Once the peer's arrive lightningd broadcasts it.
+func (l *Lightning) SpliceSigned(channelId, psbt string) (*SpliceSignedResult, error) {
+	if channelId == "" || psbt == "" {
+		return nil, fmt.Errorf("Must provide a channel id and signed psbt")
+	}
+	var result SpliceSignedResult
+	err := l.rpc.Request(&SpliceSignedRequest{ChannelId: channelId, Psbt: psbt}, &result)
+	return &result, err
+}
+
 type CloseRequest struct {
 	PeerId             string `json:"id"`
 	Timeout            uint   `json:"unilateraltimeout,omitempty"`
@@ -1702,7 +2890,7 @@ func (l *Lightning) Close(id string, timeout uint, destination string) (*CloseRe
 
 func (l *Lightning) close_internal(id string, timeout uint, destination string, step string) (*CloseResult, error) {
 	var result CloseResult
-	err := l.client.Request(&CloseRequest{id, timeout, destination, step}, &result)
+	err := l.rpc.Request(&CloseRequest{id, timeout, destination, step}, &result)
 	return &result, err
 }
 
@@ -1720,7 +2908,7 @@ func (l *Lightning) DevSignLastTx(peerId string) (string, error) {
 	var result struct {
 		Tx string `json:"tx"`
 	}
-	err := l.client.Request(&DevSignLastTxRequest{peerId}, &result)
+	err := l.rpc.Request(&DevSignLastTxRequest{peerId}, &result)
 	return result.Tx, err
 }
 
@@ -1734,8 +2922,8 @@ func (r DevFailRequest) Name() string {
 
 // Fail with peer {id}
 func (l *Lightning) DevFail(peerId string) error {
-	var result interface{}
-	err := l.client.Request(&DevFailRequest{peerId}, result)
+	var result struct{}
+	err := l.rpc.Request(&DevFailRequest{peerId}, &result)
 	return err
 }
 
@@ -1749,8 +2937,8 @@ func (r DevReenableCommitRequest) Name() string {
 
 // Re-enable the commit timer on peer {id}
 func (l *Lightning) DevReenableCommit(id string) error {
-	var result interface{}
-	err := l.client.Request(&DevReenableCommitRequest{id}, result)
+	var result struct{}
+	err := l.rpc.Request(&DevReenableCommitRequest{id}, &result)
 	return err
 }
 
@@ -1775,8 +2963,11 @@ func (l *Lightning) Ping(peerId string) (*Pong, error) {
 
 // Send {peerId} a ping of length {pingLen} asking for bytes {pongByteLen}
 func (l *Lightning) PingWithLen(peerId string, pingLen, pongByteLen uint) (*Pong, error) {
+	if err := checkNodeId(peerId); err != nil {
+		return nil, err
+	}
 	var result Pong
-	err := l.client.Request(&PingRequest{peerId, pingLen, pongByteLen}, &result)
+	err := l.rpc.Request(&PingRequest{peerId, pingLen, pongByteLen}, &result)
 	return &result, err
 }
 
@@ -1796,7 +2987,7 @@ type MemDumpEntry struct {
 // Show memory objects currently in use
 func (l *Lightning) DevMemDump() ([]*MemDumpEntry, error) {
 	var result []*MemDumpEntry
-	err := l.client.Request(&DevMemDumpRequest{}, &result)
+	err := l.rpc.Request(&DevMemDumpRequest{}, &result)
 	return result, err
 }
 
@@ -1820,7 +3011,7 @@ type MemLeak struct {
 // Show unreferenced memory objects
 func (l *Lightning) DevMemLeak() ([]*MemLeak, error) {
 	var result MemLeakResult
-	err := l.client.Request(&DevMemLeakRequest{}, &result)
+	err := l.rpc.Request(&DevMemLeakRequest{}, &result)
 	return result.Leaks, err
 }
 
@@ -1867,7 +3058,7 @@ func (r FeeRateStyle) String() string {
 
 func (f *FeeRate) String() string {
 	if f.Rate > 0 {
-		return fmt.Sprint(f.Rate) + f.Style.String()
+		return strconv.FormatUint(uint64(f.Rate), 10) + f.Style.String()
 	}
 	// defaults to 'normal'
 	return f.Directive.String()
@@ -1880,6 +3071,30 @@ func NewFeeRate(style FeeRateStyle, rate uint) *FeeRate {
 	}
 }
 
+// Parse a feerate as accepted by lightningd: a directive ("normal",
+// "urgent", "slow") or a number with an optional 'perkb'/'perkw' suffix.
+// Omitting the suffix is equivalent to 'perkb'.
+func ParseFeeRate(feerate string) (*FeeRate, error) {
+	for i, d := range []string{"normal", "urgent", "slow"} {
+		if feerate == d {
+			return NewFeeRateByDirective(PerKb, FeeDirective(i)), nil
+		}
+	}
+
+	style := PerKb
+	if strings.HasSuffix(feerate, PerKw.String()) {
+		style = PerKw
+		feerate = strings.TrimSuffix(feerate, PerKw.String())
+	} else {
+		feerate = strings.TrimSuffix(feerate, PerKb.String())
+	}
+	rate, err := strconv.ParseUint(feerate, 10, 0)
+	if err != nil || rate == 0 {
+		return nil, fmt.Errorf("Invalid feerate %q", feerate)
+	}
+	return NewFeeRate(style, uint(rate)), nil
+}
+
 func NewFeeRateByDirective(style FeeRateStyle, directive FeeDirective) *FeeRate {
 	return &FeeRate{
 		Style:     style,
@@ -1919,6 +3134,9 @@ func (l *Lightning) WithdrawWithUtxos(destination string, amount *Sat, feerate *
 	if destination == "" {
 		return nil, fmt.Errorf("Must supply a destination for withdrawal")
 	}
+	if err := l.checkAddress(destination); err != nil {
+		return nil, err
+	}
 
 	request := &WithdrawRequest{
 		Destination: destination,
@@ -1936,7 +3154,7 @@ func (l *Lightning) WithdrawWithUtxos(destination string, amount *Sat, feerate *
 	}
 
 	var result WithdrawResult
-	err := l.client.Request(request, &result)
+	err := l.rpc.Request(request, &result)
 	return &result, err
 }
 
@@ -1974,7 +3192,7 @@ func (l *Lightning) NewAddr() (string, error) {
 // Get new address of type {addrType} from the internal wallet.
 func (l *Lightning) NewAddress(addrType AddressType) (*NewAddrResult, error) {
 	var result NewAddrResult
-	err := l.client.Request(&NewAddrRequest{addrType.String()}, &result)
+	err := l.rpc.Request(&NewAddrRequest{addrType.String()}, &result)
 
 	return &result, err
 }
@@ -2046,6 +3264,11 @@ func (l *Lightning) PrepareTxWithUtxos(outputs []*Outputs, feerate *FeeRate, min
 	if len(outputs) < 0 {
 		return nil, fmt.Errorf("Must supply at least one output")
 	}
+	for _, output := range outputs {
+		if err := l.checkAddress(output.Address); err != nil {
+			return nil, err
+		}
+	}
 
 	request := &TxPrepare{
 		Outputs: stringifyOutputs(outputs),
@@ -2064,7 +3287,7 @@ func (l *Lightning) PrepareTxWithUtxos(outputs []*Outputs, feerate *FeeRate, min
 	}
 
 	var result TxResult
-	err := l.client.Request(request, &result)
+	err := l.rpc.Request(request, &result)
 	return &result, err
 }
 
@@ -2079,7 +3302,7 @@ func (r *TxDiscard) Name() string {
 // Abandon a transaction created by PrepareTx
 func (l *Lightning) DiscardTx(txid string) (*TxResult, error) {
 	var result TxResult
-	err := l.client.Request(&TxDiscard{txid}, &result)
+	err := l.rpc.Request(&TxDiscard{txid}, &result)
 	return &result, err
 }
 
@@ -2094,7 +3317,208 @@ func (r *TxSend) Name() string {
 // Sign and broadcast a transaction created by PrepareTx
 func (l *Lightning) SendTx(txid string) (*TxResult, error) {
 	var result TxResult
-	err := l.client.Request(&TxSend{txid}, &result)
+	err := l.rpc.Request(&TxSend{txid}, &result)
+	return &result, err
+}
+
+type SetPsbtVersionRequest struct {
+	Psbt    string `json:"psbt"`
+	Version uint8  `json:"version"`
+}
+
+func (r *SetPsbtVersionRequest) Name() string {
+	return "setpsbtversion"
+}
+
+// Convert {psbt} to PSBT version {version} (0 or 2)
+func (l *Lightning) SetPsbtVersion(psbt string, version uint8) (string, error) {
+	if psbt == "" {
+		return "", fmt.Errorf("Must provide a psbt")
+	}
+	if version != 0 && version != 2 {
+		return "", fmt.Errorf("Psbt version must be 0 or 2, not %d", version)
+	}
+
+	var result struct {
+		Psbt string `json:"psbt"`
+	}
+	err := l.rpc.Request(&SetPsbtVersionRequest{psbt, version}, &result)
+	return result.Psbt, err
+}
+
+type FundPsbtRequest struct {
+	Satoshi     string  `json:"satoshi"`
+	FeeRate     string  `json:"feerate"`
+	StartWeight uint    `json:"startweight"`
+	MinConf     *uint16 `json:"minconf,omitempty"`
+	Reserve     *uint32 `json:"reserve,omitempty"`
+	Locktime    *uint32 `json:"locktime,omitempty"`
+	// Add a change output for any excess, rather than leaving it
+	// for the caller to spend
+	ExcessAsChange bool `json:"excess_as_change,omitempty"`
+}
+
+func (r *FundPsbtRequest) Name() string {
+	return "fundpsbt"
+}
+
+type PsbtReservation struct {
+	TxId        string `json:"txid"`
+	Vout        uint32 `json:"vout"`
+	WasReserved bool   `json:"was_reserved"`
+	Reserved    bool   `json:"reserved"`
+	ReservedTo  uint32 `json:"reserved_to_block"`
+}
+
+type FundPsbtResult struct {
+	Psbt                 string `json:"psbt"`
+	FeeRatePerKw         uint32 `json:"feerate_per_kw"`
+	EstimatedFinalWeight uint32 `json:"estimated_final_weight"`
+	ExcessMsat           string `json:"excess_msat"`
+	// Only set if ExcessAsChange was requested and a change output added
+	ChangeOutnum *uint32            `json:"change_outnum,omitempty"`
+	Reservations []*PsbtReservation `json:"reservations"`
+}
+
+// Create a psbt spending enough of the wallet's outputs to pay {amount}
+// (or everything, with AllSats) plus fees at {feerate} for a
+// transaction of {startWeight}, plus the inputs. The psbt has no
+// outputs, except change if ExcessAsChange is set; the caller adds
+// them, then signs with SignPsbt and broadcasts with SendPsbt.
+//
+// The inputs used are reserved for {reserve} blocks (72 if nil; 0 to
+// not reserve them).
+func (l *Lightning) FundPsbt(amount *Sat, feerate *FeeRate, startWeight uint, reserve *uint32) (*FundPsbtResult, error) {
+	return l.FundPsbtWithOptions(&FundPsbtRequest{
+		Satoshi:     amountString(amount),
+		FeeRate:     feeRateString(feerate),
+		StartWeight: startWeight,
+		Reserve:     reserve,
+	})
+}
+
+func (l *Lightning) FundPsbtWithOptions(req *FundPsbtRequest) (*FundPsbtResult, error) {
+	if req.Satoshi == "" {
+		return nil, fmt.Errorf("Must set satoshi amount to fund")
+	}
+	if req.FeeRate == "" {
+		req.FeeRate = NewFeeRateByDirective(PerKw, Normal).String()
+	}
+
+	var result FundPsbtResult
+	err := l.rpc.Request(req, &result)
+	return &result, err
+}
+
+func amountString(amount *Sat) string {
+	if amount == nil || (amount.Value == 0 && !amount.SendAll) {
+		return ""
+	}
+	return amount.RawString()
+}
+
+func feeRateString(feerate *FeeRate) string {
+	if feerate == nil {
+		return ""
+	}
+	return feerate.String()
+}
+
+type SignPsbtRequest struct {
+	Psbt     string   `json:"psbt"`
+	SignOnly []uint32 `json:"signonly,omitempty"`
+}
+
+func (r *SignPsbtRequest) Name() string {
+	return "signpsbt"
+}
+
+// Sign the inputs of {psbt} which spend the wallet's outputs; only
+// those numbered in {signOnly}, if given. Returns the signed psbt.
+func (l *Lightning) SignPsbt(psbt string, signOnly []uint32) (string, error) {
+	if psbt == "" {
+		return "", fmt.Errorf("Must provide a psbt")
+	}
+
+	var result struct {
+		SignedPsbt string `json:"signed_psbt"`
+	}
+	err := l.rpc.Request(&SignPsbtRequest{psbt, signOnly}, &result)
+	return result.SignedPsbt, err
+}
+
+type UnreserveInputsRequest struct {
+	Psbt    string  `json:"psbt"`
+	Reserve *uint32 `json:"reserve,omitempty"`
+}
+
+func (r *UnreserveInputsRequest) Name() string {
+	return "unreserveinputs"
+}
+
+// Release the wallet's inputs to {psbt} reserved by fundpsbt or
+// utxopsbt, so they can be spent elsewhere
+func (l *Lightning) UnreserveInputs(psbt string) ([]*PsbtReservation, error) {
+	if psbt == "" {
+		return nil, fmt.Errorf("Must provide a psbt")
+	}
+	var result struct {
+		Reservations []*PsbtReservation `json:"reservations"`
+	}
+	err := l.rpc.Request(&UnreserveInputsRequest{Psbt: psbt}, &result)
+	return result.Reservations, err
+}
+
+type AddPsbtOutputRequest struct {
+	Satoshi     string  `json:"satoshi"`
+	InitialPsbt string  `json:"initialpsbt,omitempty"`
+	Locktime    *uint32 `json:"locktime,omitempty"`
+	Destination string  `json:"destination,omitempty"`
+}
+
+func (r *AddPsbtOutputRequest) Name() string {
+	return "addpsbtoutput"
+}
+
+type AddPsbtOutputResult struct {
+	Psbt                 string `json:"psbt"`
+	EstimatedAddedWeight uint32 `json:"estimated_added_weight"`
+	Outnum               uint32 `json:"outnum"`
+}
+
+// Add an output paying {amount} to {destination} to {initialPsbt},
+// or to a new psbt if it's empty. An empty {destination} pays a new
+// address of the wallet's.
+func (l *Lightning) AddPsbtOutput(amount *Sat, initialPsbt, destination string) (*AddPsbtOutputResult, error) {
+	if amountString(amount) == "" || amount.SendAll {
+		return nil, fmt.Errorf("Must set satoshi amount of output")
+	}
+	var result AddPsbtOutputResult
+	err := l.rpc.Request(&AddPsbtOutputRequest{
+		Satoshi:     amount.RawString(),
+		InitialPsbt: initialPsbt,
+		Destination: destination,
+	}, &result)
+	return &result, err
+}
+
+type SendPsbtRequest struct {
+	Psbt    string  `json:"psbt"`
+	Reserve *uint32 `json:"reserve,omitempty"`
+}
+
+func (r *SendPsbtRequest) Name() string {
+	return "sendpsbt"
+}
+
+// Finalize and broadcast the fully signed {psbt}
+func (l *Lightning) SendPsbt(psbt string) (*WithdrawResult, error) {
+	if psbt == "" {
+		return nil, fmt.Errorf("Must provide a psbt")
+	}
+
+	var result WithdrawResult
+	err := l.rpc.Request(&SendPsbtRequest{Psbt: psbt}, &result)
 	return &result, err
 }
 
@@ -2135,11 +3559,20 @@ type FundingChannel struct {
 // Funds in wallet.
 func (l *Lightning) ListFunds() (*FundsResult, error) {
 	var result FundsResult
-	err := l.client.Request(&ListFundsRequest{}, &result)
+	err := l.rpc.Request(&ListFundsRequest{}, &result)
 	return &result, err
 }
 
-type ListForwardsRequest struct{}
+type ListForwardsRequest struct {
+	// todo: enum (offered, settled, local_failed, failed)
+	Status     string `json:"status,omitempty"`
+	InChannel  string `json:"in_channel,omitempty"`
+	OutChannel string `json:"out_channel,omitempty"`
+	// Either 'created' or 'updated', required to use Start/Limit
+	Index string  `json:"index,omitempty"`
+	Start *uint64 `json:"start,omitempty"`
+	Limit *uint32 `json:"limit,omitempty"`
+}
 
 func (r *ListForwardsRequest) Name() string {
 	return "listforwards"
@@ -2160,14 +3593,33 @@ type Forwarding struct {
 	FailReason      string  `json:"failreason"`
 	ReceivedTime    float64 `json:"received_time"`
 	ResolvedTime    float64 `json:"resolved_time"`
+	CreatedIndex    uint64  `json:"created_index,omitempty"`
+	UpdatedIndex    uint64  `json:"updated_index,omitempty"`
 }
 
 // List all forwarded payments and their information
 func (l *Lightning) ListForwards() ([]Forwarding, error) {
+	return l.ListForwardsFiltered(&ListForwardsRequest{})
+}
+
+// List forwarded payments matching the {status}, {in_channel} and
+// {out_channel} filters set on the request.
+//
+// To page through forwards incrementally, set the 'Index' to either
+// 'created' or 'updated' and pass a 'Start' of one past the last
+// 'CreatedIndex' (or 'UpdatedIndex') you've seen, along with a 'Limit'.
+func (l *Lightning) ListForwardsFiltered(req *ListForwardsRequest) ([]Forwarding, error) {
+	if (req.Start != nil || req.Limit != nil) && req.Index == "" {
+		return nil, fmt.Errorf("Must set an index ('created' or 'updated') to use start or limit")
+	}
+	if req.Index != "" && req.Index != "created" && req.Index != "updated" {
+		return nil, fmt.Errorf("Index must be either 'created' or 'updated', not %s", req.Index)
+	}
+
 	var result struct {
 		Forwards []Forwarding `json:"forwards"`
 	}
-	err := l.client.Request(&ListForwardsRequest{}, &result)
+	err := l.rpc.Request(req, &result)
 	return result.Forwards, err
 }
 
@@ -2177,11 +3629,31 @@ func (r *DevRescanOutputsRequest) Name() string {
 	return "dev-rescan-outputs"
 }
 
+type OutputState uint
+
+const (
+	OutputAvailable OutputState = iota
+	OutputReserved
+	OutputSpent
+)
+
+func (s OutputState) String() string {
+	switch s {
+	case OutputAvailable:
+		return "available"
+	case OutputReserved:
+		return "reserved"
+	case OutputSpent:
+		return "spent"
+	}
+	return fmt.Sprintf("unknown(%d)", uint(s))
+}
+
 type Output struct {
-	TxId     string `json:"txid"`
-	Output   uint   `json:"output"`
-	OldState uint   `json:"oldstate"`
-	NewState uint   `json:"newstate"`
+	TxId     string      `json:"txid"`
+	Output   uint        `json:"output"`
+	OldState OutputState `json:"oldstate"`
+	NewState OutputState `json:"newstate"`
 }
 
 // Synchronize the state of our funds with bitcoind
@@ -2189,13 +3661,15 @@ func (l *Lightning) DevRescanOutputs() ([]Output, error) {
 	var result struct {
 		Outputs []Output `json:"outputs"`
 	}
-	err := l.client.Request(&DevRescanOutputsRequest{}, &result)
+	err := l.rpc.Request(&DevRescanOutputsRequest{}, &result)
 	return result.Outputs, err
 }
 
 type DevForgetChannelRequest struct {
-	PeerId string `json:"id"`
-	Force  bool   `json:"force"`
+	PeerId         string `json:"id"`
+	ShortChannelId string `json:"short_channel_id,omitempty"`
+	ChannelId      string `json:"channel_id,omitempty"`
+	Force          bool   `json:"force"`
 }
 
 func (r *DevForgetChannelRequest) Name() string {
@@ -2211,8 +3685,47 @@ type ForgetChannelResult struct {
 // Forget channel with id {peerId}. Optionally {force} if has active channel.
 // Caution, this might lose you funds.
 func (l *Lightning) DevForgetChannel(peerId string, force bool) (*ForgetChannelResult, error) {
+	return l.devForgetChannel(&DevForgetChannelRequest{
+		PeerId: peerId,
+		Force:  force,
+	})
+}
+
+// Forget the channel with {shortChannelId} with peer {peerId}. Required
+// if there's more than one channel with the peer.
+// Caution, this might lose you funds.
+func (l *Lightning) DevForgetChannelByShortChannelId(peerId, shortChannelId string, force bool) (*ForgetChannelResult, error) {
+	if shortChannelId == "" {
+		return nil, fmt.Errorf("Must provide a short channel id")
+	}
+	return l.devForgetChannel(&DevForgetChannelRequest{
+		PeerId:         peerId,
+		ShortChannelId: shortChannelId,
+		Force:          force,
+	})
+}
+
+// Forget the channel with {channelId} with peer {peerId}. Useful for
+// channels which were never locked in, and so don't have a short channel id.
+// Caution, this might lose you funds.
+func (l *Lightning) DevForgetChannelByChannelId(peerId, channelId string, force bool) (*ForgetChannelResult, error) {
+	if channelId == "" {
+		return nil, fmt.Errorf("Must provide a channel id")
+	}
+	return l.devForgetChannel(&DevForgetChannelRequest{
+		PeerId:    peerId,
+		ChannelId: channelId,
+		Force:     force,
+	})
+}
+
+func (l *Lightning) devForgetChannel(req *DevForgetChannelRequest) (*ForgetChannelResult, error) {
+	if req.PeerId == "" {
+		return nil, fmt.Errorf("Must provide a peer id")
+	}
+
 	var result ForgetChannelResult
-	err := l.client.Request(&DevForgetChannelRequest{peerId, force}, &result)
+	err := l.rpc.Request(req, &result)
 	return &result, err
 }
 
@@ -2233,10 +3746,24 @@ type CustomMessageResult struct {
 
 func (l *Lightning) SendCustomMessage(nodeId, message string) (*CustomMessageResult, error) {
 	var result *CustomMessageResult
-	err := l.client.Request(&CustomMessageRequest{NodeId: nodeId, Message: message}, &result)
+	err := l.rpc.Request(&CustomMessageRequest{NodeId: nodeId, Message: message}, &result)
 	return result, err
 }
 
+// Send {nodeId} a custom message of {msgType} whose body is the TLV
+// stream of {records}. Types must be odd, as peers which don't know
+// them will disconnect on even ones.
+func (l *Lightning) SendCustomTlvMessage(nodeId string, msgType uint16, records []*tlv.Record) (*CustomMessageResult, error) {
+	if msgType%2 == 0 {
+		return nil, fmt.Errorf("Custom message type %d must be odd", msgType)
+	}
+	msg, err := tlv.EncodeMessage(msgType, records)
+	if err != nil {
+		return nil, err
+	}
+	return l.SendCustomMessage(nodeId, hex.EncodeToString(msg))
+}
+
 type DisconnectRequest struct {
 	PeerId string `json:"id"`
 	Force  bool   `json:"force"`
@@ -2249,8 +3776,11 @@ func (r *DisconnectRequest) Name() string {
 // Disconnect from peer with {peerId}. Optionally {force} if has active channel.
 // Returns a nil response on success
 func (l *Lightning) Disconnect(peerId string, force bool) error {
+	if err := checkNodeId(peerId); err != nil {
+		return err
+	}
 	var result interface{}
-	err := l.client.Request(&DisconnectRequest{peerId, force}, &result)
+	err := l.rpc.Request(&DisconnectRequest{peerId, force}, &result)
 	return err
 }
 
@@ -2289,6 +3819,16 @@ type FeeRateDetails struct {
 	DelayedToUs     uint `json:"delayed_to_us"`
 	HtlcResolution  uint `json:"htlc_resolution"`
 	Penalty         uint `json:"penalty"`
+	// The lowest feerate which will propagate
+	Floor uint `json:"floor,omitempty"`
+	// Estimates by confirmation target, from most to least urgent
+	Estimates []*FeeRateBlockEstimate `json:"estimates,omitempty"`
+}
+
+type FeeRateBlockEstimate struct {
+	BlockCount      uint32 `json:"blockcount"`
+	FeeRate         uint   `json:"feerate"`
+	SmoothedFeeRate uint   `json:"smoothed_feerate"`
 }
 
 // Return feerate estimates, either satoshi-per-kw or satoshi-per-kb {style}
@@ -2299,7 +3839,7 @@ func (l *Lightning) FeeRates(style FeeRateStyle) (*FeeRateEstimate, error) {
 		OnchainEstimate *OnchainEstimate `json:"onchain_fee_estimates"`
 		Warning         string           `json:"warning"`
 	}
-	err := l.client.Request(&FeeRatesRequest{style.String()}, &result)
+	err := l.rpc.Request(&FeeRatesRequest{style.String()}, &result)
 	if err != nil {
 		return nil, err
 	}
@@ -2346,7 +3886,62 @@ func (r *SetChannelFeeRequest) Name() string {
 // a short channel id, or all, for all channels.
 func (l *Lightning) SetChannelFee(id string, baseMsat string, ppm uint32) (*ChannelFeeResult, error) {
 	var result ChannelFeeResult
-	err := l.client.Request(&SetChannelFeeRequest{id, baseMsat, ppm}, &result)
+	err := l.rpc.Request(&SetChannelFeeRequest{id, baseMsat, ppm}, &result)
+	return &result, err
+}
+
+type SetChannelRequest struct {
+	Id string `json:"id"`
+	// Fields left nil are left as they are
+	FeeBaseMsat *uint64 `json:"feebase,omitempty"`
+	FeePPM      *uint32 `json:"feeppm,omitempty"`
+	HtlcMinMsat string  `json:"htlcmin,omitempty"`
+	HtlcMaxMsat string  `json:"htlcmax,omitempty"`
+	// Seconds to keep accepting the old fees for, so payments routed
+	// before the update has propagated don't fail. Defaults to 600.
+	EnforceDelay    *uint32 `json:"enforcedelay,omitempty"`
+	IgnoreFeeLimits *bool   `json:"ignorefeelimits,omitempty"`
+}
+
+func (r *SetChannelRequest) Name() string {
+	return "setchannel"
+}
+
+type SetChannelResult struct {
+	Channels []*ChannelSettings `json:"channels"`
+}
+
+type ChannelSettings struct {
+	PeerId                    string `json:"peer_id"`
+	ChannelId                 string `json:"channel_id"`
+	ShortChannelId            string `json:"short_channel_id,omitempty"`
+	FeeBaseMsat               string `json:"fee_base_msat"`
+	FeeProportionalMillionths uint32 `json:"fee_proportional_millionths"`
+	MinimumHtlcOutMsat        string `json:"minimum_htlc_out_msat"`
+	MaximumHtlcOutMsat        string `json:"maximum_htlc_out_msat"`
+	IgnoreFeeLimits           bool   `json:"ignore_fee_limits"`
+	WarningHtlcMinTooLow      string `json:"warning_htlcmin_too_low,omitempty"`
+	WarningHtlcMaxTooHigh     string `json:"warning_htlcmax_too_high,omitempty"`
+}
+
+// Set the fees of channel {id} to {baseMsat} plus {ppm} parts per
+// million. Like SetChannelFee, 'id' can be a peer id, a channel id,
+// a short channel id, or all, for all channels.
+func (l *Lightning) SetChannel(id string, baseMsat uint64, ppm uint32) (*SetChannelResult, error) {
+	return l.SetChannelWithOptions(&SetChannelRequest{
+		Id:          id,
+		FeeBaseMsat: &baseMsat,
+		FeePPM:      &ppm,
+	})
+}
+
+// Update any of channel {req.Id}'s fees and htlc limits
+func (l *Lightning) SetChannelWithOptions(req *SetChannelRequest) (*SetChannelResult, error) {
+	if req.Id == "" {
+		return nil, fmt.Errorf("Must specify a channel to set")
+	}
+	var result SetChannelResult
+	err := l.rpc.Request(req, &result)
 	return &result, err
 }
 
@@ -2373,13 +3968,13 @@ func (r *PluginRequest) Name() string {
 
 func (l *Lightning) ListPlugins() ([]PluginInfo, error) {
 	var result pluginResponse
-	err := l.client.Request(&PluginRequest{"list"}, &result)
+	err := l.rpc.Request(&PluginRequest{"list"}, &result)
 	return result.Plugins, err
 }
 
 func (l *Lightning) RescanPlugins() ([]PluginInfo, error) {
 	var result pluginResponse
-	err := l.client.Request(&PluginRequest{"rescan"}, &result)
+	err := l.rpc.Request(&PluginRequest{"rescan"}, &result)
 	return result.Plugins, err
 }
 
@@ -2394,7 +3989,7 @@ func (r *PluginRequestDir) Name() string {
 
 func (l *Lightning) SetPluginStartDir(directory string) ([]PluginInfo, error) {
 	var result pluginResponse
-	err := l.client.Request(&PluginRequestDir{"start-dir", directory}, &result)
+	err := l.rpc.Request(&PluginRequestDir{"start-dir", directory}, &result)
 	return result.Plugins, err
 }
 
@@ -2409,13 +4004,13 @@ func (r *PluginRequestPlugin) Name() string {
 
 func (l *Lightning) StartPlugin(pluginName string) ([]PluginInfo, error) {
 	var result pluginResponse
-	err := l.client.Request(&PluginRequestPlugin{"start", pluginName}, &result)
+	err := l.rpc.Request(&PluginRequestPlugin{"start", pluginName}, &result)
 	return result.Plugins, err
 }
 
 func (l *Lightning) StopPlugin(pluginName string) (string, error) {
 	var result stopPluginResponse
-	err := l.client.Request(&PluginRequestPlugin{"stop", pluginName}, &result)
+	err := l.rpc.Request(&PluginRequestPlugin{"stop", pluginName}, &result)
 	return result.Result, err
 }
 
@@ -2437,10 +4032,122 @@ type SharedSecretResp struct {
    This field is 32 bytes (64 hexadecimal characters in a string). */
 func (l *Lightning) GetSharedSecret(point string) (string, error) {
 	var result SharedSecretResp
-	err := l.client.Request(&SharedSecretRequest{point}, &result)
+	err := l.rpc.Request(&SharedSecretRequest{point}, &result)
 	return result.SharedSecret, err
 }
 
+type FunderPolicy int
+
+const (
+	FunderMatch FunderPolicy = iota
+	FunderAvailable
+	FunderFixed
+)
+
+func (p FunderPolicy) String() string {
+	return []string{"match", "available", "fixed"}[p]
+}
+
+// All fields are optional; any left unset keep their current
+// value on the node.
+type FunderUpdateRequest struct {
+	Policy                               string  `json:"policy,omitempty"`
+	PolicyMod                            *uint64 `json:"policy_mod,omitempty"`
+	LeasesOnly                           *bool   `json:"leases_only,omitempty"`
+	MinTheirFundingMsat                  string  `json:"min_their_funding_msat,omitempty"`
+	MaxTheirFundingMsat                  string  `json:"max_their_funding_msat,omitempty"`
+	PerChannelMinMsat                    string  `json:"per_channel_min_msat,omitempty"`
+	PerChannelMaxMsat                    string  `json:"per_channel_max_msat,omitempty"`
+	ReserveTankMsat                      string  `json:"reserve_tank_msat,omitempty"`
+	FuzzPercent                          *uint32 `json:"fuzz_percent,omitempty"`
+	FundProbability                      *uint32 `json:"fund_probability,omitempty"`
+	LeaseFeeBaseMsat                     string  `json:"lease_fee_base_msat,omitempty"`
+	LeaseFeeBasis                        *uint32 `json:"lease_fee_basis,omitempty"`
+	FundingWeight                        *uint32 `json:"funding_weight,omitempty"`
+	ChannelFeeMaxBaseMsat                string  `json:"channel_fee_max_base_msat,omitempty"`
+	ChannelFeeMaxProportionalThousandths *uint32 `json:"channel_fee_max_proportional_thousandths,omitempty"`
+	CompactLease                         string  `json:"compact_lease,omitempty"`
+}
+
+func (r *FunderUpdateRequest) Name() string {
+	return "funderupdate"
+}
+
+type FunderPolicyResult struct {
+	Summary                              string `json:"summary"`
+	Policy                               string `json:"policy"`
+	PolicyMod                            uint64 `json:"policy_mod"`
+	LeasesOnly                           bool   `json:"leases_only"`
+	MinTheirFundingMsat                  string `json:"min_their_funding_msat"`
+	MaxTheirFundingMsat                  string `json:"max_their_funding_msat"`
+	PerChannelMinMsat                    string `json:"per_channel_min_msat"`
+	PerChannelMaxMsat                    string `json:"per_channel_max_msat"`
+	ReserveTankMsat                      string `json:"reserve_tank_msat"`
+	FuzzPercent                          uint32 `json:"fuzz_percent"`
+	FundProbability                      uint32 `json:"fund_probability"`
+	LeaseFeeBaseMsat                     string `json:"lease_fee_base_msat,omitempty"`
+	LeaseFeeBasis                        uint32 `json:"lease_fee_basis,omitempty"`
+	FundingWeight                        uint32 `json:"funding_weight,omitempty"`
+	ChannelFeeMaxBaseMsat                string `json:"channel_fee_max_base_msat,omitempty"`
+	ChannelFeeMaxProportionalThousandths uint32 `json:"channel_fee_max_proportional_thousandths,omitempty"`
+	CompactLease                         string `json:"compact_lease,omitempty"`
+}
+
+// Show the current funder policy, i.e. how this node contributes
+// to channel opens initiated by peers (dual-funding)
+func (l *Lightning) GetFunderPolicy() (*FunderPolicyResult, error) {
+	return l.FunderUpdate(&FunderUpdateRequest{})
+}
+
+// Update the funder policy. Fields left unset on the request are
+// not changed.
+//
+// 'Policy' is one of 'match', 'available' or 'fixed' (see FunderPolicy),
+// with 'PolicyMod' being the percentage (match, available) or amount
+// in satoshis (fixed) to contribute.
+//
+// The 'Lease*', 'FundingWeight' and 'ChannelFeeMax*' fields set the
+// rates advertised via liquidity ads (option_will_fund).
+func (l *Lightning) FunderUpdate(req *FunderUpdateRequest) (*FunderPolicyResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("Must provide a funder update request")
+	}
+	if req.FuzzPercent != nil && *req.FuzzPercent > 100 {
+		return nil, fmt.Errorf("FuzzPercent must be a percentage. %d", *req.FuzzPercent)
+	}
+	if req.FundProbability != nil && *req.FundProbability > 100 {
+		return nil, fmt.Errorf("FundProbability must be a percentage. %d", *req.FundProbability)
+	}
+
+	var result FunderPolicyResult
+	err := l.rpc.Request(req, &result)
+	return &result, err
+}
+
+type SqlRequest struct {
+	Query string `json:"query"`
+}
+
+func (r SqlRequest) Name() string {
+	return "sql"
+}
+
+// Run a read-only SQLite {query} over lightningd's list* commands,
+// via the sql plugin, e.g. "SELECT short_channel_id FROM channels".
+//
+// Each row is returned as its raw JSON columns, in the order they
+// were selected, to be decoded as whatever type the column holds.
+func (l *Lightning) Sql(query string) ([][]json.RawMessage, error) {
+	if query == "" {
+		return nil, fmt.Errorf("Must provide a query")
+	}
+	var result struct {
+		Rows [][]json.RawMessage `json:"rows"`
+	}
+	err := l.rpc.Request(&SqlRequest{query}, &result)
+	return result.Rows, err
+}
+
 // List of all non-dev RPC methods
 var Lightning_RpcMethods map[string](func() jrpc2.Method)
 
@@ -2457,6 +4164,8 @@ func init() {
 	Lightning_RpcMethods[(&RouteRequest{}).Name()] = func() jrpc2.Method { return new(RouteRequest) }
 	Lightning_RpcMethods[(&SendOnionRequest{}).Name()] = func() jrpc2.Method { return new(SendOnionRequest) }
 	Lightning_RpcMethods[(&CreateOnionRequest{}).Name()] = func() jrpc2.Method { return new(CreateOnionRequest) }
+	Lightning_RpcMethods[(&SendOnionMessageRequest{}).Name()] = func() jrpc2.Method { return new(SendOnionMessageRequest) }
+	Lightning_RpcMethods[(&InjectOnionMessageRequest{}).Name()] = func() jrpc2.Method { return new(InjectOnionMessageRequest) }
 	Lightning_RpcMethods[(&ListChannelRequest{}).Name()] = func() jrpc2.Method { return new(ListChannelRequest) }
 	Lightning_RpcMethods[(&InvoiceRequest{}).Name()] = func() jrpc2.Method { return new(InvoiceRequest) }
 	Lightning_RpcMethods[(&ListInvoiceRequest{}).Name()] = func() jrpc2.Method { return new(ListInvoiceRequest) }
@@ -2469,6 +4178,7 @@ func init() {
 	Lightning_RpcMethods[(&PayStatusRequest{}).Name()] = func() jrpc2.Method { return new(PayStatusRequest) }
 	Lightning_RpcMethods[(&HelpRequest{}).Name()] = func() jrpc2.Method { return new(HelpRequest) }
 	Lightning_RpcMethods[(&StopRequest{}).Name()] = func() jrpc2.Method { return new(StopRequest) }
+	Lightning_RpcMethods[(&DeprecationsRequest{}).Name()] = func() jrpc2.Method { return new(DeprecationsRequest) }
 	Lightning_RpcMethods[(&LogRequest{}).Name()] = func() jrpc2.Method { return new(LogRequest) }
 
 	// we skip all the Dev-commands
@@ -2479,14 +4189,27 @@ func init() {
 	Lightning_RpcMethods[(&SendPayRequest{}).Name()] = func() jrpc2.Method { return new(SendPayRequest) }
 	Lightning_RpcMethods[(&WaitSendPayRequest{}).Name()] = func() jrpc2.Method { return new(WaitSendPayRequest) }
 	Lightning_RpcMethods[(&PayRequest{}).Name()] = func() jrpc2.Method { return new(PayRequest) }
+	Lightning_RpcMethods[(&KeySendRequest{}).Name()] = func() jrpc2.Method { return new(KeySendRequest) }
 	Lightning_RpcMethods[(&ListPaysRequest{}).Name()] = func() jrpc2.Method { return new(ListPaysRequest) }
 	Lightning_RpcMethods[(&ListSendPaysRequest{}).Name()] = func() jrpc2.Method { return new(ListSendPaysRequest) }
 	Lightning_RpcMethods[(&TransactionsRequest{}).Name()] = func() jrpc2.Method { return new(TransactionsRequest) }
+	Lightning_RpcMethods[(&BkprListAccountEventsRequest{}).Name()] = func() jrpc2.Method { return new(BkprListAccountEventsRequest) }
+	Lightning_RpcMethods[(&StaticBackupRequest{}).Name()] = func() jrpc2.Method { return new(StaticBackupRequest) }
+	Lightning_RpcMethods[(&EmergencyRecoverRequest{}).Name()] = func() jrpc2.Method { return new(EmergencyRecoverRequest) }
+	Lightning_RpcMethods[(&RecoverChannelRequest{}).Name()] = func() jrpc2.Method { return new(RecoverChannelRequest) }
 	Lightning_RpcMethods[(&ConnectRequest{}).Name()] = func() jrpc2.Method { return new(ConnectRequest) }
 	Lightning_RpcMethods[(&FundChannelRequest{}).Name()] = func() jrpc2.Method { return new(FundChannelRequest) }
+	Lightning_RpcMethods[(&MultiFundChannelRequest{}).Name()] = func() jrpc2.Method { return new(MultiFundChannelRequest) }
 	Lightning_RpcMethods[(&FundChannelStart{}).Name()] = func() jrpc2.Method { return new(FundChannelStart) }
 	Lightning_RpcMethods[(&FundChannelComplete{}).Name()] = func() jrpc2.Method { return new(FundChannelComplete) }
 	Lightning_RpcMethods[(&FundChannelCancel{}).Name()] = func() jrpc2.Method { return new(FundChannelCancel) }
+	Lightning_RpcMethods[(&OpenChannelBumpRequest{}).Name()] = func() jrpc2.Method { return new(OpenChannelBumpRequest) }
+	Lightning_RpcMethods[(&OpenChannelUpdateRequest{}).Name()] = func() jrpc2.Method { return new(OpenChannelUpdateRequest) }
+	Lightning_RpcMethods[(&OpenChannelSignedRequest{}).Name()] = func() jrpc2.Method { return new(OpenChannelSignedRequest) }
+	Lightning_RpcMethods[(&OpenChannelAbortRequest{}).Name()] = func() jrpc2.Method { return new(OpenChannelAbortRequest) }
+	Lightning_RpcMethods[(&SpliceInitRequest{}).Name()] = func() jrpc2.Method { return new(SpliceInitRequest) }
+	Lightning_RpcMethods[(&SpliceUpdateRequest{}).Name()] = func() jrpc2.Method { return new(SpliceUpdateRequest) }
+	Lightning_RpcMethods[(&SpliceSignedRequest{}).Name()] = func() jrpc2.Method { return new(SpliceSignedRequest) }
 	Lightning_RpcMethods[(&CloseRequest{}).Name()] = func() jrpc2.Method { return new(CloseRequest) }
 	Lightning_RpcMethods[(&PingRequest{}).Name()] = func() jrpc2.Method { return new(PingRequest) }
 	Lightning_RpcMethods[(&WithdrawRequest{}).Name()] = func() jrpc2.Method { return new(WithdrawRequest) }
@@ -2494,12 +4217,38 @@ func init() {
 	Lightning_RpcMethods[(&TxPrepare{}).Name()] = func() jrpc2.Method { return new(TxPrepare) }
 	Lightning_RpcMethods[(&TxDiscard{}).Name()] = func() jrpc2.Method { return new(TxDiscard) }
 	Lightning_RpcMethods[(&TxSend{}).Name()] = func() jrpc2.Method { return new(TxSend) }
+	Lightning_RpcMethods[(&SetPsbtVersionRequest{}).Name()] = func() jrpc2.Method { return new(SetPsbtVersionRequest) }
+	Lightning_RpcMethods[(&FundPsbtRequest{}).Name()] = func() jrpc2.Method { return new(FundPsbtRequest) }
+	Lightning_RpcMethods[(&SignPsbtRequest{}).Name()] = func() jrpc2.Method { return new(SignPsbtRequest) }
+	Lightning_RpcMethods[(&UnreserveInputsRequest{}).Name()] = func() jrpc2.Method { return new(UnreserveInputsRequest) }
+	Lightning_RpcMethods[(&AddPsbtOutputRequest{}).Name()] = func() jrpc2.Method { return new(AddPsbtOutputRequest) }
+	Lightning_RpcMethods[(&SendPsbtRequest{}).Name()] = func() jrpc2.Method { return new(SendPsbtRequest) }
 	Lightning_RpcMethods[(&ListFundsRequest{}).Name()] = func() jrpc2.Method { return new(ListFundsRequest) }
 	Lightning_RpcMethods[(&ListForwardsRequest{}).Name()] = func() jrpc2.Method { return new(ListForwardsRequest) }
 	Lightning_RpcMethods[(&DisconnectRequest{}).Name()] = func() jrpc2.Method { return new(DisconnectRequest) }
 	Lightning_RpcMethods[(&FeeRatesRequest{}).Name()] = func() jrpc2.Method { return new(FeeRatesRequest) }
 	Lightning_RpcMethods[(&SetChannelFeeRequest{}).Name()] = func() jrpc2.Method { return new(SetChannelFeeRequest) }
+	Lightning_RpcMethods[(&SetChannelRequest{}).Name()] = func() jrpc2.Method { return new(SetChannelRequest) }
 	Lightning_RpcMethods[(&PluginRequest{}).Name()] = func() jrpc2.Method { return new(PluginRequest) }
 	Lightning_RpcMethods[(&SharedSecretRequest{}).Name()] = func() jrpc2.Method { return new(SharedSecretRequest) }
 	Lightning_RpcMethods[(&CustomMessageRequest{}).Name()] = func() jrpc2.Method { return new(CustomMessageRequest) }
+	Lightning_RpcMethods[(&FunderUpdateRequest{}).Name()] = func() jrpc2.Method { return new(FunderUpdateRequest) }
+	Lightning_RpcMethods[(&GetRoutesRequest{}).Name()] = func() jrpc2.Method { return new(GetRoutesRequest) }
+	Lightning_RpcMethods[(&AskReneCreateLayerRequest{}).Name()] = func() jrpc2.Method { return new(AskReneCreateLayerRequest) }
+	Lightning_RpcMethods[(&AskReneRemoveLayerRequest{}).Name()] = func() jrpc2.Method { return new(AskReneRemoveLayerRequest) }
+	Lightning_RpcMethods[(&AskReneListLayersRequest{}).Name()] = func() jrpc2.Method { return new(AskReneListLayersRequest) }
+	Lightning_RpcMethods[(&AskReneInformChannelRequest{}).Name()] = func() jrpc2.Method { return new(AskReneInformChannelRequest) }
+	Lightning_RpcMethods[(&AskReneDisableNodeRequest{}).Name()] = func() jrpc2.Method { return new(AskReneDisableNodeRequest) }
+	Lightning_RpcMethods[(&AskReneBiasChannelRequest{}).Name()] = func() jrpc2.Method { return new(AskReneBiasChannelRequest) }
+	Lightning_RpcMethods[(&AskReneCreateChannelRequest{}).Name()] = func() jrpc2.Method { return new(AskReneCreateChannelRequest) }
+	Lightning_RpcMethods[(&AskReneReserveRequest{}).Name()] = func() jrpc2.Method { return new(AskReneReserveRequest) }
+	Lightning_RpcMethods[(&AskReneUnreserveRequest{}).Name()] = func() jrpc2.Method { return new(AskReneUnreserveRequest) }
+	Lightning_RpcMethods[(&AskReneAgeRequest{}).Name()] = func() jrpc2.Method { return new(AskReneAgeRequest) }
+	Lightning_RpcMethods[(&DatastoreRequest{}).Name()] = func() jrpc2.Method { return new(DatastoreRequest) }
+	Lightning_RpcMethods[(&ListDatastoreRequest{}).Name()] = func() jrpc2.Method { return new(ListDatastoreRequest) }
+	Lightning_RpcMethods[(&DelDatastoreRequest{}).Name()] = func() jrpc2.Method { return new(DelDatastoreRequest) }
+	Lightning_RpcMethods[(&SqlRequest{}).Name()] = func() jrpc2.Method { return new(SqlRequest) }
+	Lightning_RpcMethods[(&CommandoRequest{}).Name()] = func() jrpc2.Method { return new(CommandoRequest) }
+	Lightning_RpcMethods[(&CreateRuneRequest{}).Name()] = func() jrpc2.Method { return new(CreateRuneRequest) }
+	Lightning_RpcMethods[(&CheckRuneRequest{}).Name()] = func() jrpc2.Method { return new(CheckRuneRequest) }
 }
@@ -0,0 +1,713 @@
+package glightning
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/elementsproject/glightning/glightning/network"
+	"github.com/elementsproject/glightning/glightning/tlv"
+	"github.com/elementsproject/glightning/jrpc2"
+)
+
+// Variants of each of Lightning's calls which are scoped to a context;
+// see WithContext. XxxCtx(ctx, ...) is l.WithContext(ctx).Xxx(...).
+
+func (l *Lightning) RequestCtx(ctx context.Context, m jrpc2.Method, resp interface{}) error {
+	return l.WithContext(ctx).Request(m, resp)
+}
+
+func (l *Lightning) RequestRawCtx(ctx context.Context, m jrpc2.Method, resp interface{}) (json.RawMessage, error) {
+	return l.WithContext(ctx).RequestRaw(m, resp)
+}
+
+func (l *Lightning) CallCtx(ctx context.Context, method string, params map[string]interface{}) (json.RawMessage, error) {
+	return l.WithContext(ctx).Call(method, params)
+}
+
+func (l *Lightning) CallIntoCtx(ctx context.Context, method string, params interface{}, result interface{}) error {
+	return l.WithContext(ctx).CallInto(method, params, result)
+}
+
+func (l *Lightning) ListConfigsCtx(ctx context.Context) (map[string]interface{}, error) {
+	return l.WithContext(ctx).ListConfigs()
+}
+
+func (l *Lightning) GetConfigCtx(ctx context.Context, config string) (interface{}, error) {
+	return l.WithContext(ctx).GetConfig(config)
+}
+
+func (l *Lightning) GetPeerCtx(ctx context.Context, peerId string) (*Peer, error) {
+	return l.WithContext(ctx).GetPeer(peerId)
+}
+
+func (l *Lightning) GetPeerWithLogsCtx(ctx context.Context, peerId string, level LogLevel) (*Peer, error) {
+	return l.WithContext(ctx).GetPeerWithLogs(peerId, level)
+}
+
+func (l *Lightning) ListPeersWithLogsCtx(ctx context.Context, level LogLevel) ([]*Peer, error) {
+	return l.WithContext(ctx).ListPeersWithLogs(level)
+}
+
+func (l *Lightning) ListPeersCtx(ctx context.Context) ([]*Peer, error) {
+	return l.WithContext(ctx).ListPeers()
+}
+
+func (l *Lightning) GetNodeCtx(ctx context.Context, nodeId string) (*Node, error) {
+	return l.WithContext(ctx).GetNode(nodeId)
+}
+
+func (l *Lightning) ListNodesCtx(ctx context.Context) ([]*Node, error) {
+	return l.WithContext(ctx).ListNodes()
+}
+
+func (l *Lightning) GetRouteSimpleCtx(ctx context.Context, peerId string, msats uint64, riskfactor float32) ([]RouteHop, error) {
+	return l.WithContext(ctx).GetRouteSimple(peerId, msats, riskfactor)
+}
+
+func (l *Lightning) GetRouteCtx(ctx context.Context, peerId string, msats uint64, riskfactor float32, cltv uint, fromId string, fuzzpercent float32, exclude []string, maxHops int32) ([]RouteHop, error) {
+	return l.WithContext(ctx).GetRoute(peerId, msats, riskfactor, cltv, fromId, fuzzpercent, exclude, maxHops)
+}
+
+func (l *Lightning) SendOnionCtx(ctx context.Context, onion string, hop FirstHop, paymentHash string) (*SendPayFields, error) {
+	return l.WithContext(ctx).SendOnion(onion, hop, paymentHash)
+}
+
+func (l *Lightning) SendOnionWithDetailsCtx(ctx context.Context, onion string, hop FirstHop, paymentHash string, label string, secrets []string, partId *uint64) (*SendPayFields, error) {
+	return l.WithContext(ctx).SendOnionWithDetails(onion, hop, paymentHash, label, secrets, partId)
+}
+
+func (l *Lightning) CreateOnionCtx(ctx context.Context, hops []Hop, paymentHash, sessionKey string) (*CreateOnionResponse, error) {
+	return l.WithContext(ctx).CreateOnion(hops, paymentHash, sessionKey)
+}
+
+func (l *Lightning) SendOnionMessageCtx(ctx context.Context, firstId, blinding string, hops []*OnionMessageHop) error {
+	return l.WithContext(ctx).SendOnionMessage(firstId, blinding, hops)
+}
+
+func (l *Lightning) InjectOnionMessageCtx(ctx context.Context, pathKey, message string) error {
+	return l.WithContext(ctx).InjectOnionMessage(pathKey, message)
+}
+
+func (l *Lightning) GetChannelCtx(ctx context.Context, shortChanId string) ([]*Channel, error) {
+	return l.WithContext(ctx).GetChannel(shortChanId)
+}
+
+func (l *Lightning) ListChannelsBySourceCtx(ctx context.Context, nodeId string) ([]*Channel, error) {
+	return l.WithContext(ctx).ListChannelsBySource(nodeId)
+}
+
+func (l *Lightning) ListChannelsByDestinationCtx(ctx context.Context, nodeId string) ([]*Channel, error) {
+	return l.WithContext(ctx).ListChannelsByDestination(nodeId)
+}
+
+func (l *Lightning) ListChannelsCtx(ctx context.Context) ([]*Channel, error) {
+	return l.WithContext(ctx).ListChannels()
+}
+
+func (l *Lightning) CreateInvoiceAnyCtx(ctx context.Context, label, description string, expirySeconds uint32, fallbacks []string, preimage string, exposePrivateChans bool) (*Invoice, error) {
+	return l.WithContext(ctx).CreateInvoiceAny(label, description, expirySeconds, fallbacks, preimage, exposePrivateChans)
+}
+
+func (l *Lightning) CreateInvoiceCtx(ctx context.Context, msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, willExposePrivateChans bool) (*Invoice, error) {
+	return l.WithContext(ctx).CreateInvoice(msat, label, description, expirySeconds, fallbacks, preimage, willExposePrivateChans)
+}
+
+func (l *Lightning) CreateInvoiceExposingCtx(ctx context.Context, msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, exposePrivChans []string) (*Invoice, error) {
+	return l.WithContext(ctx).CreateInvoiceExposing(msat, label, description, expirySeconds, fallbacks, preimage, exposePrivChans)
+}
+
+func (l *Lightning) CreateInvoiceWithCltvExpiryCtx(ctx context.Context, msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, willExposePrivateChans bool, cltv uint32) (*Invoice, error) {
+	return l.WithContext(ctx).CreateInvoiceWithCltvExpiry(msat, label, description, expirySeconds, fallbacks, preimage, willExposePrivateChans, cltv)
+}
+
+func (l *Lightning) InvoiceCtx(ctx context.Context, msat uint64, label, description string) (*Invoice, error) {
+	return l.WithContext(ctx).Invoice(msat, label, description)
+}
+
+func (l *Lightning) CreateInvoiceDescHashOnlyCtx(ctx context.Context, msat uint64, label, description string, expirySeconds uint32) (*Invoice, error) {
+	return l.WithContext(ctx).CreateInvoiceDescHashOnly(msat, label, description, expirySeconds)
+}
+
+func (l *Lightning) CreateInvoiceWithOptionsCtx(ctx context.Context, req *InvoiceRequest) (*Invoice, error) {
+	return l.WithContext(ctx).CreateInvoiceWithOptions(req)
+}
+
+func (l *Lightning) ListInvoicesCtx(ctx context.Context) ([]*Invoice, error) {
+	return l.WithContext(ctx).ListInvoices()
+}
+
+func (l *Lightning) GetInvoiceCtx(ctx context.Context, label string) (*Invoice, error) {
+	return l.WithContext(ctx).GetInvoice(label)
+}
+
+func (l *Lightning) GetInvoiceByHashCtx(ctx context.Context, paymentHash string) (*Invoice, error) {
+	return l.WithContext(ctx).GetInvoiceByHash(paymentHash)
+}
+
+func (l *Lightning) ListInvoicesFilteredCtx(ctx context.Context, req *ListInvoiceRequest) ([]*Invoice, error) {
+	return l.WithContext(ctx).ListInvoicesFiltered(req)
+}
+
+func (l *Lightning) DeleteInvoiceCtx(ctx context.Context, label, status string) (*Invoice, error) {
+	return l.WithContext(ctx).DeleteInvoice(label, status)
+}
+
+func (l *Lightning) DeleteInvoiceDescriptionCtx(ctx context.Context, label, status string) (*Invoice, error) {
+	return l.WithContext(ctx).DeleteInvoiceDescription(label, status)
+}
+
+func (l *Lightning) WaitAnyInvoiceCtx(ctx context.Context, lastPayIndex uint) (*Invoice, error) {
+	return l.WithContext(ctx).WaitAnyInvoice(lastPayIndex)
+}
+
+func (l *Lightning) WaitAnyInvoiceTimeoutCtx(ctx context.Context, lastPayIndex uint, timeout uint) (*Invoice, error) {
+	return l.WithContext(ctx).WaitAnyInvoiceTimeout(lastPayIndex, timeout)
+}
+
+func (l *Lightning) WaitInvoiceCtx(ctx context.Context, label string) (*Invoice, error) {
+	return l.WithContext(ctx).WaitInvoice(label)
+}
+
+func (l *Lightning) DeleteExpiredInvoicesSinceCtx(ctx context.Context, unixTime uint64) error {
+	return l.WithContext(ctx).DeleteExpiredInvoicesSince(unixTime)
+}
+
+func (l *Lightning) DisableInvoiceAutocleanCtx(ctx context.Context) error {
+	return l.WithContext(ctx).DisableInvoiceAutoclean()
+}
+
+func (l *Lightning) SetInvoiceAutocleanCtx(ctx context.Context, intervalSeconds, expiredBySeconds uint32) error {
+	return l.WithContext(ctx).SetInvoiceAutoclean(intervalSeconds, expiredBySeconds)
+}
+
+func (l *Lightning) DecodeBolt11Ctx(ctx context.Context, bolt11 string) (*DecodedBolt11, error) {
+	return l.WithContext(ctx).DecodeBolt11(bolt11)
+}
+
+func (l *Lightning) DecodePayCtx(ctx context.Context, bolt11, desc string) (*DecodedBolt11, error) {
+	return l.WithContext(ctx).DecodePay(bolt11, desc)
+}
+
+func (l *Lightning) ListPayStatusesCtx(ctx context.Context) ([]PayStatus, error) {
+	return l.WithContext(ctx).ListPayStatuses()
+}
+
+func (l *Lightning) GetPayStatusCtx(ctx context.Context, bolt11 string) (*PayStatus, error) {
+	return l.WithContext(ctx).GetPayStatus(bolt11)
+}
+
+func (l *Lightning) HelpCtx(ctx context.Context) ([]*Command, error) {
+	return l.WithContext(ctx).Help()
+}
+
+func (l *Lightning) HelpForCtx(ctx context.Context, command string) (*Command, error) {
+	return l.WithContext(ctx).HelpFor(command)
+}
+
+func (l *Lightning) StopCtx(ctx context.Context) (string, error) {
+	return l.WithContext(ctx).Stop()
+}
+
+func (l *Lightning) DeprecationsCtx(ctx context.Context, enable bool) error {
+	return l.WithContext(ctx).Deprecations(enable)
+}
+
+func (l *Lightning) GetLogCtx(ctx context.Context, level LogLevel) (*LogResponse, error) {
+	return l.WithContext(ctx).GetLog(level)
+}
+
+func (l *Lightning) DevHashCtx(ctx context.Context, secret string) (string, error) {
+	return l.WithContext(ctx).DevHash(secret)
+}
+
+func (l *Lightning) DevCrashCtx(ctx context.Context) (interface{}, error) {
+	return l.WithContext(ctx).DevCrash()
+}
+
+func (l *Lightning) DevQueryShortChanIdsCtx(ctx context.Context, peerId string, shortChanIds []string) (*QueryShortChannelIdsResponse, error) {
+	return l.WithContext(ctx).DevQueryShortChanIds(peerId, shortChanIds)
+}
+
+func (l *Lightning) GetInfoCtx(ctx context.Context) (*NodeInfo, error) {
+	return l.WithContext(ctx).GetInfo()
+}
+
+func (l *Lightning) SignMessageCtx(ctx context.Context, message string) (*SignedMessage, error) {
+	return l.WithContext(ctx).SignMessage(message)
+}
+
+func (l *Lightning) CheckMessageCtx(ctx context.Context, message, zbase string) (bool, string, error) {
+	return l.WithContext(ctx).CheckMessage(message, zbase)
+}
+
+func (l *Lightning) CheckMessageVerifyCtx(ctx context.Context, message, zbase, pubkey string) (bool, error) {
+	return l.WithContext(ctx).CheckMessageVerify(message, zbase, pubkey)
+}
+
+func (l *Lightning) SendPayLiteCtx(ctx context.Context, route []RouteHop, paymentHash string) (*SendPayResult, error) {
+	return l.WithContext(ctx).SendPayLite(route, paymentHash)
+}
+
+func (l *Lightning) SendPayCtx(ctx context.Context, route []RouteHop, paymentHash, label string, msat *uint64, bolt11 string, paymentSecret string, partId uint64) (*SendPayResult, error) {
+	return l.WithContext(ctx).SendPay(route, paymentHash, label, msat, bolt11, paymentSecret, partId)
+}
+
+func (l *Lightning) SendPayPartCtx(ctx context.Context, req *SendPayRequest) (*SendPayResult, error) {
+	return l.WithContext(ctx).SendPayPart(req)
+}
+
+func (l *Lightning) WaitSendPayCtx(ctx context.Context, paymentHash string, timeout uint) (*SendPayFields, error) {
+	return l.WithContext(ctx).WaitSendPay(paymentHash, timeout)
+}
+
+func (l *Lightning) WaitSendPayPartCtx(ctx context.Context, paymentHash string, timeout uint, partId uint64) (*SendPayFields, error) {
+	return l.WithContext(ctx).WaitSendPayPart(paymentHash, timeout, partId)
+}
+
+func (l *Lightning) WaitSendPayPartInGroupCtx(ctx context.Context, paymentHash string, timeout uint, partId, groupId uint64) (*SendPayFields, error) {
+	return l.WithContext(ctx).WaitSendPayPartInGroup(paymentHash, timeout, partId, groupId)
+}
+
+func (l *Lightning) PayBoltCtx(ctx context.Context, bolt11 string) (*PaymentSuccess, error) {
+	return l.WithContext(ctx).PayBolt(bolt11)
+}
+
+func (l *Lightning) PayPartialCtx(ctx context.Context, bolt11 string, partial *MSat) (*PaymentSuccess, error) {
+	return l.WithContext(ctx).PayPartial(bolt11, partial)
+}
+
+func (l *Lightning) PayCtx(ctx context.Context, req *PayRequest) (*PaymentSuccess, error) {
+	return l.WithContext(ctx).Pay(req)
+}
+
+func (l *Lightning) KeySendCtx(ctx context.Context, destination string, msat uint64, label string, extraTlvs []*tlv.Record) (*PaymentSuccess, error) {
+	return l.WithContext(ctx).KeySend(destination, msat, label, extraTlvs)
+}
+
+func (l *Lightning) ListPaysCtx(ctx context.Context) ([]PaymentFields, error) {
+	return l.WithContext(ctx).ListPays()
+}
+
+func (l *Lightning) ListPaysToBolt11Ctx(ctx context.Context, bolt11 string) ([]PaymentFields, error) {
+	return l.WithContext(ctx).ListPaysToBolt11(bolt11)
+}
+
+func (l *Lightning) ListSendPaysAllCtx(ctx context.Context) ([]SendPayFields, error) {
+	return l.WithContext(ctx).ListSendPaysAll()
+}
+
+func (l *Lightning) ListSendPaysCtx(ctx context.Context, bolt11 string) ([]SendPayFields, error) {
+	return l.WithContext(ctx).ListSendPays(bolt11)
+}
+
+func (l *Lightning) ListSendPaysByHashCtx(ctx context.Context, paymentHash string) ([]SendPayFields, error) {
+	return l.WithContext(ctx).ListSendPaysByHash(paymentHash)
+}
+
+func (l *Lightning) ListTransactionsCtx(ctx context.Context) ([]Transaction, error) {
+	return l.WithContext(ctx).ListTransactions()
+}
+
+func (l *Lightning) BkprListAccountEventsCtx(ctx context.Context, account string) ([]*BkprAccountEvent, error) {
+	return l.WithContext(ctx).BkprListAccountEvents(account)
+}
+
+func (l *Lightning) StaticBackupCtx(ctx context.Context) ([]string, error) {
+	return l.WithContext(ctx).StaticBackup()
+}
+
+func (l *Lightning) EmergencyRecoverCtx(ctx context.Context) ([]string, error) {
+	return l.WithContext(ctx).EmergencyRecover()
+}
+
+func (l *Lightning) RecoverChannelCtx(ctx context.Context, scbs []string) ([]string, error) {
+	return l.WithContext(ctx).RecoverChannel(scbs)
+}
+
+func (l *Lightning) ConnectPeerCtx(ctx context.Context, peerId, host string, port uint) (*ConnectResult, error) {
+	return l.WithContext(ctx).ConnectPeer(peerId, host, port)
+}
+
+func (l *Lightning) ConnectCtx(ctx context.Context, peerId, host string, port uint) (string, error) {
+	return l.WithContext(ctx).Connect(peerId, host, port)
+}
+
+func (l *Lightning) FundChannelCtx(ctx context.Context, id string, amount *Sat) (*FundChannelResult, error) {
+	return l.WithContext(ctx).FundChannel(id, amount)
+}
+
+func (l *Lightning) FundPrivateChannelCtx(ctx context.Context, id string, amount *Sat) (*FundChannelResult, error) {
+	return l.WithContext(ctx).FundPrivateChannel(id, amount)
+}
+
+func (l *Lightning) FundChannelAtFeeCtx(ctx context.Context, id string, amount *Sat, feerate *FeeRate) (*FundChannelResult, error) {
+	return l.WithContext(ctx).FundChannelAtFee(id, amount, feerate)
+}
+
+func (l *Lightning) FundPrivateChannelAtFeeCtx(ctx context.Context, id string, amount *Sat, feerate *FeeRate) (*FundChannelResult, error) {
+	return l.WithContext(ctx).FundPrivateChannelAtFee(id, amount, feerate)
+}
+
+func (l *Lightning) FundChannelExtCtx(ctx context.Context, id string, amount *Sat, feerate *FeeRate, announce bool, minConf *uint16, pushMSat *MSat) (*FundChannelResult, error) {
+	return l.WithContext(ctx).FundChannelExt(id, amount, feerate, announce, minConf, pushMSat)
+}
+
+func (l *Lightning) FundChannelWithOptionsCtx(ctx context.Context, req *FundChannelRequest) (*FundChannelResult, error) {
+	return l.WithContext(ctx).FundChannelWithOptions(req)
+}
+
+func (l *Lightning) MultiFundChannelCtx(ctx context.Context, destinations []*FundDestination, feerate *FeeRate, minConf *uint16, utxos []*Utxo, minChannels uint) (*MultiFundChannelResult, error) {
+	return l.WithContext(ctx).MultiFundChannel(destinations, feerate, minConf, utxos, minChannels)
+}
+
+func (l *Lightning) StartFundChannelCtx(ctx context.Context, id string, amount uint64, announce bool, feerate *FeeRate, closeTo string) (*StartResponse, error) {
+	return l.WithContext(ctx).StartFundChannel(id, amount, announce, feerate, closeTo)
+}
+
+func (l *Lightning) CompleteFundChannelCtx(ctx context.Context, peerId, txId string, txout uint32) (string, error) {
+	return l.WithContext(ctx).CompleteFundChannel(peerId, txId, txout)
+}
+
+func (l *Lightning) CompleteFundChannelPsbtCtx(ctx context.Context, peerId, psbt string) (string, error) {
+	return l.WithContext(ctx).CompleteFundChannelPsbt(peerId, psbt)
+}
+
+func (l *Lightning) CancelFundChannelCtx(ctx context.Context, peerId string) (bool, error) {
+	return l.WithContext(ctx).CancelFundChannel(peerId)
+}
+
+func (l *Lightning) OpenChannelBumpCtx(ctx context.Context, channelId string, amount *Sat, initialPsbt string, feerate *FeeRate) (*OpenChannelV2Result, error) {
+	return l.WithContext(ctx).OpenChannelBump(channelId, amount, initialPsbt, feerate)
+}
+
+func (l *Lightning) OpenChannelUpdateCtx(ctx context.Context, channelId, psbt string) (*OpenChannelV2Result, error) {
+	return l.WithContext(ctx).OpenChannelUpdate(channelId, psbt)
+}
+
+func (l *Lightning) OpenChannelSignedCtx(ctx context.Context, channelId, signedPsbt string) (*OpenChannelSignedResult, error) {
+	return l.WithContext(ctx).OpenChannelSigned(channelId, signedPsbt)
+}
+
+func (l *Lightning) OpenChannelAbortCtx(ctx context.Context, channelId string) (*OpenChannelAbortResult, error) {
+	return l.WithContext(ctx).OpenChannelAbort(channelId)
+}
+
+func (l *Lightning) SpliceInitCtx(ctx context.Context, channelId string, relativeAmount int64, initialPsbt string, feeRatePerKw uint32) (*SpliceUpdateResult, error) {
+	return l.WithContext(ctx).SpliceInit(channelId, relativeAmount, initialPsbt, feeRatePerKw)
+}
+
+func (l *Lightning) SpliceUpdateCtx(ctx context.Context, channelId, psbt string) (*SpliceUpdateResult, error) {
+	return l.WithContext(ctx).SpliceUpdate(channelId, psbt)
+}
+
+func (l *Lightning) SpliceSignedCtx(ctx context.Context, channelId, psbt string) (*SpliceSignedResult, error) {
+	return l.WithContext(ctx).SpliceSigned(channelId, psbt)
+}
+
+func (l *Lightning) CloseNormalCtx(ctx context.Context, id string) (*CloseResult, error) {
+	return l.WithContext(ctx).CloseNormal(id)
+}
+
+func (l *Lightning) CloseToCtx(ctx context.Context, id, destination string) (*CloseResult, error) {
+	return l.WithContext(ctx).CloseTo(id, destination)
+}
+
+func (l *Lightning) CloseWithStepCtx(ctx context.Context, id, step string) (*CloseResult, error) {
+	return l.WithContext(ctx).CloseWithStep(id, step)
+}
+
+func (l *Lightning) CloseToWithStepCtx(ctx context.Context, id, destination, step string) (*CloseResult, error) {
+	return l.WithContext(ctx).CloseToWithStep(id, destination, step)
+}
+
+func (l *Lightning) CloseToTimeoutWithStepCtx(ctx context.Context, id string, timeout uint, destination, step string) (*CloseResult, error) {
+	return l.WithContext(ctx).CloseToTimeoutWithStep(id, timeout, destination, step)
+}
+
+func (l *Lightning) CloseCtx(ctx context.Context, id string, timeout uint, destination string) (*CloseResult, error) {
+	return l.WithContext(ctx).Close(id, timeout, destination)
+}
+
+func (l *Lightning) DevSignLastTxCtx(ctx context.Context, peerId string) (string, error) {
+	return l.WithContext(ctx).DevSignLastTx(peerId)
+}
+
+func (l *Lightning) DevFailCtx(ctx context.Context, peerId string) error {
+	return l.WithContext(ctx).DevFail(peerId)
+}
+
+func (l *Lightning) DevReenableCommitCtx(ctx context.Context, id string) error {
+	return l.WithContext(ctx).DevReenableCommit(id)
+}
+
+func (l *Lightning) PingCtx(ctx context.Context, peerId string) (*Pong, error) {
+	return l.WithContext(ctx).Ping(peerId)
+}
+
+func (l *Lightning) PingWithLenCtx(ctx context.Context, peerId string, pingLen, pongByteLen uint) (*Pong, error) {
+	return l.WithContext(ctx).PingWithLen(peerId, pingLen, pongByteLen)
+}
+
+func (l *Lightning) DevMemDumpCtx(ctx context.Context) ([]*MemDumpEntry, error) {
+	return l.WithContext(ctx).DevMemDump()
+}
+
+func (l *Lightning) DevMemLeakCtx(ctx context.Context) ([]*MemLeak, error) {
+	return l.WithContext(ctx).DevMemLeak()
+}
+
+func (l *Lightning) WithdrawCtx(ctx context.Context, destination string, amount *Sat, feerate *FeeRate, minConf *uint16) (*WithdrawResult, error) {
+	return l.WithContext(ctx).Withdraw(destination, amount, feerate, minConf)
+}
+
+func (l *Lightning) WithdrawWithUtxosCtx(ctx context.Context, destination string, amount *Sat, feerate *FeeRate, minConf *uint16, utxos []*Utxo) (*WithdrawResult, error) {
+	return l.WithContext(ctx).WithdrawWithUtxos(destination, amount, feerate, minConf, utxos)
+}
+
+func (l *Lightning) NewAddrCtx(ctx context.Context) (string, error) {
+	return l.WithContext(ctx).NewAddr()
+}
+
+func (l *Lightning) NewAddressCtx(ctx context.Context, addrType AddressType) (*NewAddrResult, error) {
+	return l.WithContext(ctx).NewAddress(addrType)
+}
+
+func (l *Lightning) PrepareTxCtx(ctx context.Context, outputs []*Outputs, feerate *FeeRate, minConf *uint16) (*TxResult, error) {
+	return l.WithContext(ctx).PrepareTx(outputs, feerate, minConf)
+}
+
+func (l *Lightning) PrepareTxWithUtxosCtx(ctx context.Context, outputs []*Outputs, feerate *FeeRate, minConf *uint16, utxos []*Utxo) (*TxResult, error) {
+	return l.WithContext(ctx).PrepareTxWithUtxos(outputs, feerate, minConf, utxos)
+}
+
+func (l *Lightning) DiscardTxCtx(ctx context.Context, txid string) (*TxResult, error) {
+	return l.WithContext(ctx).DiscardTx(txid)
+}
+
+func (l *Lightning) SendTxCtx(ctx context.Context, txid string) (*TxResult, error) {
+	return l.WithContext(ctx).SendTx(txid)
+}
+
+func (l *Lightning) SetPsbtVersionCtx(ctx context.Context, psbt string, version uint8) (string, error) {
+	return l.WithContext(ctx).SetPsbtVersion(psbt, version)
+}
+
+func (l *Lightning) FundPsbtCtx(ctx context.Context, amount *Sat, feerate *FeeRate, startWeight uint, reserve *uint32) (*FundPsbtResult, error) {
+	return l.WithContext(ctx).FundPsbt(amount, feerate, startWeight, reserve)
+}
+
+func (l *Lightning) FundPsbtWithOptionsCtx(ctx context.Context, req *FundPsbtRequest) (*FundPsbtResult, error) {
+	return l.WithContext(ctx).FundPsbtWithOptions(req)
+}
+
+func (l *Lightning) SignPsbtCtx(ctx context.Context, psbt string, signOnly []uint32) (string, error) {
+	return l.WithContext(ctx).SignPsbt(psbt, signOnly)
+}
+
+func (l *Lightning) UnreserveInputsCtx(ctx context.Context, psbt string) ([]*PsbtReservation, error) {
+	return l.WithContext(ctx).UnreserveInputs(psbt)
+}
+
+func (l *Lightning) AddPsbtOutputCtx(ctx context.Context, amount *Sat, initialPsbt, destination string) (*AddPsbtOutputResult, error) {
+	return l.WithContext(ctx).AddPsbtOutput(amount, initialPsbt, destination)
+}
+
+func (l *Lightning) SendPsbtCtx(ctx context.Context, psbt string) (*WithdrawResult, error) {
+	return l.WithContext(ctx).SendPsbt(psbt)
+}
+
+func (l *Lightning) ListFundsCtx(ctx context.Context) (*FundsResult, error) {
+	return l.WithContext(ctx).ListFunds()
+}
+
+func (l *Lightning) ListForwardsCtx(ctx context.Context) ([]Forwarding, error) {
+	return l.WithContext(ctx).ListForwards()
+}
+
+func (l *Lightning) ListForwardsFilteredCtx(ctx context.Context, req *ListForwardsRequest) ([]Forwarding, error) {
+	return l.WithContext(ctx).ListForwardsFiltered(req)
+}
+
+func (l *Lightning) DevRescanOutputsCtx(ctx context.Context) ([]Output, error) {
+	return l.WithContext(ctx).DevRescanOutputs()
+}
+
+func (l *Lightning) DevForgetChannelCtx(ctx context.Context, peerId string, force bool) (*ForgetChannelResult, error) {
+	return l.WithContext(ctx).DevForgetChannel(peerId, force)
+}
+
+func (l *Lightning) DevForgetChannelByShortChannelIdCtx(ctx context.Context, peerId, shortChannelId string, force bool) (*ForgetChannelResult, error) {
+	return l.WithContext(ctx).DevForgetChannelByShortChannelId(peerId, shortChannelId, force)
+}
+
+func (l *Lightning) DevForgetChannelByChannelIdCtx(ctx context.Context, peerId, channelId string, force bool) (*ForgetChannelResult, error) {
+	return l.WithContext(ctx).DevForgetChannelByChannelId(peerId, channelId, force)
+}
+
+func (l *Lightning) SendCustomMessageCtx(ctx context.Context, nodeId, message string) (*CustomMessageResult, error) {
+	return l.WithContext(ctx).SendCustomMessage(nodeId, message)
+}
+
+func (l *Lightning) SendCustomTlvMessageCtx(ctx context.Context, nodeId string, msgType uint16, records []*tlv.Record) (*CustomMessageResult, error) {
+	return l.WithContext(ctx).SendCustomTlvMessage(nodeId, msgType, records)
+}
+
+func (l *Lightning) DisconnectCtx(ctx context.Context, peerId string, force bool) error {
+	return l.WithContext(ctx).Disconnect(peerId, force)
+}
+
+func (l *Lightning) FeeRatesCtx(ctx context.Context, style FeeRateStyle) (*FeeRateEstimate, error) {
+	return l.WithContext(ctx).FeeRates(style)
+}
+
+func (l *Lightning) SetChannelFeeCtx(ctx context.Context, id string, baseMsat string, ppm uint32) (*ChannelFeeResult, error) {
+	return l.WithContext(ctx).SetChannelFee(id, baseMsat, ppm)
+}
+
+func (l *Lightning) SetChannelCtx(ctx context.Context, id string, baseMsat uint64, ppm uint32) (*SetChannelResult, error) {
+	return l.WithContext(ctx).SetChannel(id, baseMsat, ppm)
+}
+
+func (l *Lightning) SetChannelWithOptionsCtx(ctx context.Context, req *SetChannelRequest) (*SetChannelResult, error) {
+	return l.WithContext(ctx).SetChannelWithOptions(req)
+}
+
+func (l *Lightning) ListPluginsCtx(ctx context.Context) ([]PluginInfo, error) {
+	return l.WithContext(ctx).ListPlugins()
+}
+
+func (l *Lightning) RescanPluginsCtx(ctx context.Context) ([]PluginInfo, error) {
+	return l.WithContext(ctx).RescanPlugins()
+}
+
+func (l *Lightning) SetPluginStartDirCtx(ctx context.Context, directory string) ([]PluginInfo, error) {
+	return l.WithContext(ctx).SetPluginStartDir(directory)
+}
+
+func (l *Lightning) StartPluginCtx(ctx context.Context, pluginName string) ([]PluginInfo, error) {
+	return l.WithContext(ctx).StartPlugin(pluginName)
+}
+
+func (l *Lightning) StopPluginCtx(ctx context.Context, pluginName string) (string, error) {
+	return l.WithContext(ctx).StopPlugin(pluginName)
+}
+
+func (l *Lightning) GetSharedSecretCtx(ctx context.Context, point string) (string, error) {
+	return l.WithContext(ctx).GetSharedSecret(point)
+}
+
+func (l *Lightning) GetFunderPolicyCtx(ctx context.Context) (*FunderPolicyResult, error) {
+	return l.WithContext(ctx).GetFunderPolicy()
+}
+
+func (l *Lightning) FunderUpdateCtx(ctx context.Context, req *FunderUpdateRequest) (*FunderPolicyResult, error) {
+	return l.WithContext(ctx).FunderUpdate(req)
+}
+
+func (l *Lightning) SqlCtx(ctx context.Context, query string) ([][]json.RawMessage, error) {
+	return l.WithContext(ctx).Sql(query)
+}
+
+func (l *Lightning) GetRoutesCtx(ctx context.Context, source, destination string, amount *MSat, layers []string, maxFee *MSat, finalCltv uint32) (*GetRoutesResult, error) {
+	return l.WithContext(ctx).GetRoutes(source, destination, amount, layers, maxFee, finalCltv)
+}
+
+func (l *Lightning) AskReneCreateLayerCtx(ctx context.Context, layer string, persistent bool) (*AskReneLayer, error) {
+	return l.WithContext(ctx).AskReneCreateLayer(layer, persistent)
+}
+
+func (l *Lightning) AskReneRemoveLayerCtx(ctx context.Context, layer string) error {
+	return l.WithContext(ctx).AskReneRemoveLayer(layer)
+}
+
+func (l *Lightning) AskReneListLayersCtx(ctx context.Context) ([]*AskReneLayer, error) {
+	return l.WithContext(ctx).AskReneListLayers()
+}
+
+func (l *Lightning) AskReneGetLayerCtx(ctx context.Context, layer string) (*AskReneLayer, error) {
+	return l.WithContext(ctx).AskReneGetLayer(layer)
+}
+
+func (l *Lightning) AskReneInformChannelCtx(ctx context.Context, layer, scidDir string, amount *MSat, inform AskReneInform) ([]*AskReneConstraint, error) {
+	return l.WithContext(ctx).AskReneInformChannel(layer, scidDir, amount, inform)
+}
+
+func (l *Lightning) AskReneDisableNodeCtx(ctx context.Context, layer, node string) error {
+	return l.WithContext(ctx).AskReneDisableNode(layer, node)
+}
+
+func (l *Lightning) AskReneBiasChannelCtx(ctx context.Context, layer, scidDir string, bias int, description string, relative bool) ([]*AskReneBias, error) {
+	return l.WithContext(ctx).AskReneBiasChannel(layer, scidDir, bias, description, relative)
+}
+
+func (l *Lightning) AskReneCreateChannelCtx(ctx context.Context, layer, source, destination, shortChannelId string, capacity *MSat) error {
+	return l.WithContext(ctx).AskReneCreateChannel(layer, source, destination, shortChannelId, capacity)
+}
+
+func (l *Lightning) AskReneReserveCtx(ctx context.Context, path []*AskReneReservation) error {
+	return l.WithContext(ctx).AskReneReserve(path)
+}
+
+func (l *Lightning) AskReneUnreserveCtx(ctx context.Context, path []*AskReneReservation) error {
+	return l.WithContext(ctx).AskReneUnreserve(path)
+}
+
+func (l *Lightning) AskReneAgeCtx(ctx context.Context, layer string, cutoff uint64) (*AskReneAgeResult, error) {
+	return l.WithContext(ctx).AskReneAge(layer, cutoff)
+}
+
+func (l *Lightning) CommandoCtx(ctx context.Context, peerId, rune, method string, params map[string]interface{}, resp interface{}) error {
+	return l.WithContext(ctx).Commando(peerId, rune, method, params, resp)
+}
+
+func (l *Lightning) CreateRuneCtx(ctx context.Context, restrictions [][]string) (*Rune, error) {
+	return l.WithContext(ctx).CreateRune(restrictions)
+}
+
+func (l *Lightning) CheckRuneCtx(ctx context.Context, rune, nodeId, method string, params map[string]interface{}) (bool, error) {
+	return l.WithContext(ctx).CheckRune(rune, nodeId, method, params)
+}
+
+func (l *Lightning) DetectVersionCtx(ctx context.Context) (*Version, error) {
+	return l.WithContext(ctx).DetectVersion()
+}
+
+func (l *Lightning) ConnectBestEffortCtx(ctx context.Context, nodeId string) (*ConnectBestEffortResult, error) {
+	return l.WithContext(ctx).ConnectBestEffort(nodeId)
+}
+
+func (l *Lightning) ConnectAddressesCtx(ctx context.Context, nodeId string, addresses []Address) (*ConnectBestEffortResult, error) {
+	return l.WithContext(ctx).ConnectAddresses(nodeId, addresses)
+}
+
+func (l *Lightning) SetDatastoreCtx(ctx context.Context, key []string, value string, mode DatastoreMode) (*DatastoreEntry, error) {
+	return l.WithContext(ctx).SetDatastore(key, value, mode)
+}
+
+func (l *Lightning) ListDatastoreCtx(ctx context.Context, key []string) ([]*DatastoreEntry, error) {
+	return l.WithContext(ctx).ListDatastore(key)
+}
+
+func (l *Lightning) GetDatastoreCtx(ctx context.Context, key []string) (*DatastoreEntry, error) {
+	return l.WithContext(ctx).GetDatastore(key)
+}
+
+func (l *Lightning) DelDatastoreCtx(ctx context.Context, key []string) (*DatastoreEntry, error) {
+	return l.WithContext(ctx).DelDatastore(key)
+}
+
+func (l *Lightning) WithdrawWithStrategyCtx(ctx context.Context, destination string, amount *Sat, strategy FeeStrategy, minConf *uint16) (*WithdrawResult, error) {
+	return l.WithContext(ctx).WithdrawWithStrategy(destination, amount, strategy, minConf)
+}
+
+func (l *Lightning) FundChannelWithStrategyCtx(ctx context.Context, id string, amount *Sat, strategy FeeStrategy, announce bool, minConf *uint16) (*FundChannelResult, error) {
+	return l.WithContext(ctx).FundChannelWithStrategy(id, amount, strategy, announce, minConf)
+}
+
+func (l *Lightning) PrepareTxWithStrategyCtx(ctx context.Context, outputs []*Outputs, strategy FeeStrategy, minConf *uint16) (*TxResult, error) {
+	return l.WithContext(ctx).PrepareTxWithStrategy(outputs, strategy, minConf)
+}
+
+func (l *Lightning) DetectNetworkCtx(ctx context.Context) (network.Network, error) {
+	return l.WithContext(ctx).DetectNetwork()
+}
@@ -8,9 +8,12 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/tlv"
+	"github.com/elementsproject/glightning/jrpc2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -96,6 +99,93 @@ func TestListTransactions(t *testing.T) {
 	assert.Equal(t, expected, txs)
 }
 
+func TestBkprListAccountEvents(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"bkpr-listaccountevents","params":{"account":"wallet"},"id":1}`
+	resp := wrapResult(1, `{
+  "events": [
+    {
+      "account": "wallet",
+      "type": "chain",
+      "tag": "deposit",
+      "credit_msat": 200000000000,
+      "debit_msat": 0,
+      "currency": "bcrt",
+      "outpoint": "7d4dd3a5ad3f3d7a8b5bf2fa3e6da3fd8c2c7a2c4a7cb3e5c1b0c2f6e3d4a5b6:1",
+      "timestamp": 1686954481,
+      "blockheight": 110
+    },
+    {
+      "account": "wallet",
+      "type": "onchain_fee",
+      "tag": "onchain_fee",
+      "credit_msat": 0,
+      "debit_msat": 153000,
+      "currency": "bcrt",
+      "timestamp": 1686954502,
+      "txid": "1b0c2f6e3d4a5b67d4dd3a5ad3f3d7a8b5bf2fa3e6da3fd8c2c7a2c4a7cb3e5c"
+    }
+  ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	events, err := lightning.BkprListAccountEvents("wallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*glightning.BkprAccountEvent{
+		{
+			Account:     "wallet",
+			Type:        "chain",
+			Tag:         "deposit",
+			CreditMsat:  200000000000,
+			Currency:    "bcrt",
+			Outpoint:    "7d4dd3a5ad3f3d7a8b5bf2fa3e6da3fd8c2c7a2c4a7cb3e5c1b0c2f6e3d4a5b6:1",
+			Timestamp:   1686954481,
+			Blockheight: 110,
+		},
+		{
+			Account:   "wallet",
+			Type:      "onchain_fee",
+			Tag:       "onchain_fee",
+			DebitMsat: 153000,
+			Currency:  "bcrt",
+			Timestamp: 1686954502,
+			TxId:      "1b0c2f6e3d4a5b67d4dd3a5ad3f3d7a8b5bf2fa3e6da3fd8c2c7a2c4a7cb3e5c",
+		},
+	}, events)
+}
+
+func TestStaticBackup(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"staticbackup","params":{},"id":1}`
+	resp := wrapResult(1, `{"scb": ["0000000000000001aa", "0000000000000002bb"]}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	scbs, err := lightning.StaticBackup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"0000000000000001aa", "0000000000000002bb"}, scbs)
+
+	req = `{"jsonrpc":"2.0","method":"recoverchannel","params":{"scb":["0000000000000001aa"]},"id":2}`
+	resp = wrapResult(2, `{"stubs": ["1c6b"]}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	stubs, err := lightning.RecoverChannel([]string{"0000000000000001aa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"1c6b"}, stubs)
+
+	req = `{"jsonrpc":"2.0","method":"emergencyrecover","params":{},"id":3}`
+	resp = wrapResult(3, `{"stubs": []}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	stubs, err = lightning.EmergencyRecover()
+	assert.NoError(t, err)
+	assert.Empty(t, stubs)
+
+	_, err = lightning.RecoverChannel(nil)
+	assert.EqualError(t, err, "Must provide static channel backups to recover")
+}
+
 func TestListPeers(t *testing.T) {
 	req := `{"jsonrpc":"2.0","method":"listpeers","params":{},"id":1}`
 	resp := wrapResult(1, `{                                                                                                                                                         
@@ -271,6 +361,70 @@ func TestListPeers(t *testing.T) {
 	assert.Equal(t, expected, peers)
 }
 
+func TestListPeersModernFields(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"listpeers","params":{},"id":1}`
+	resp := wrapResult(1, `{"peers": [
+    {
+      "id": "02e3cd7849f177a46f137ae3bfc1a08fc6a90bf4026c74f83c1ecc8430c282fe96",
+      "connected": true,
+      "netaddr": [ "127.0.0.1:6677" ],
+      "features": "08a0a69a2a",
+      "channels": [
+        {
+          "state": "CHANNELD_NORMAL",
+          "short_channel_id": "103x1x0",
+          "channel_id": "5415f1347cf12f30222c5968c59a4744e78ee39f0361e19b6ce2996cce4e1538",
+          "funding_txid": "38154ece6c99e26c9be161039fe38ee744479ac568592c22302ff17c34f11554",
+          "funding_outnum": 1,
+          "features": [ "option_static_remotekey", "option_anchors_zero_fee_htlc_tx" ],
+          "minimum_htlc_out_msat": "0msat",
+          "maximum_htlc_out_msat": "990000000msat",
+          "fee_base_msat": "1msat",
+          "fee_proportional_millionths": 10,
+          "alias": { "local": "5800000x2x2", "remote": "11000000x3x0" },
+          "opener": "local",
+          "state_changes": [
+            {
+              "timestamp": "2022-03-28T01:26:38.392Z",
+              "old_state": "CHANNELD_AWAITING_LOCKIN",
+              "new_state": "CHANNELD_NORMAL",
+              "cause": "user",
+              "message": "Lockin complete"
+            }
+          ],
+          "htlcs": []
+        }
+      ]
+    }
+  ]}`)
+
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	peers, err := lightning.ListPeers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	channel := peers[0].Channels[0]
+	assert.Equal(t, uint32(1), channel.FundingOutnum)
+	assert.Equal(t, []string{"option_static_remotekey", "option_anchors_zero_fee_htlc_tx"}, channel.Features)
+	assert.Equal(t, "0msat", channel.MinimumHtlcOutMsat)
+	assert.Equal(t, "990000000msat", channel.MaximumHtlcOutMsat)
+	assert.Equal(t, "1msat", channel.FeeBaseMsat)
+	assert.Equal(t, uint32(10), channel.FeeProportionalMillionths)
+	assert.Equal(t, &glightning.ChannelAlias{Local: "5800000x2x2", Remote: "11000000x3x0"}, channel.Alias)
+	assert.Equal(t, "local", channel.Opener)
+	assert.Equal(t, "", channel.Closer)
+	assert.Equal(t, []*glightning.StateChange{
+		&glightning.StateChange{
+			Timestamp: "2022-03-28T01:26:38.392Z",
+			OldState:  "CHANNELD_AWAITING_LOCKIN",
+			NewState:  "CHANNELD_NORMAL",
+			Cause:     "user",
+			Message:   "Lockin complete",
+		},
+	}, channel.StateChanges)
+}
+
 func TestListForwards(t *testing.T) {
 	req := `{"jsonrpc":"2.0","method":"listforwards","params":{},"id":1}`
 	resp := wrapResult(1, `{
@@ -345,6 +499,57 @@ func TestListForwards(t *testing.T) {
 	}, forwards)
 }
 
+func TestListForwardsFiltered(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"listforwards","params":{"in_channel":"103x2x1","index":"created","limit":1,"start":5,"status":"settled"},"id":1}`
+	resp := wrapResult(1, `{
+   "forwards": [
+      {
+         "in_channel": "103x2x1",
+         "out_channel": "110x1x0",
+         "in_msat": "100001001msat",
+         "out_msat": "100000000msat",
+         "fee_msat": "1001msat",
+         "status": "settled",
+         "received_time": 1560696342.368,
+         "resolved_time": 1560696342.556,
+         "created_index": 5,
+         "updated_index": 9
+      }
+   ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	start := uint64(5)
+	limit := uint32(1)
+	forwards, err := lightning.ListForwardsFiltered(&glightning.ListForwardsRequest{
+		Status:    "settled",
+		InChannel: "103x2x1",
+		Index:     "created",
+		Start:     &start,
+		Limit:     &limit,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []glightning.Forwarding{
+		glightning.Forwarding{
+			InChannel:    "103x2x1",
+			OutChannel:   "110x1x0",
+			InMsat:       "100001001msat",
+			OutMsat:      "100000000msat",
+			FeeMsat:      "1001msat",
+			Status:       "settled",
+			ReceivedTime: 1560696342.368,
+			ResolvedTime: 1560696342.556,
+			CreatedIndex: 5,
+			UpdatedIndex: 9,
+		},
+	}, forwards)
+
+	_, err = lightning.ListForwardsFiltered(&glightning.ListForwardsRequest{Limit: &limit})
+	assert.Error(t, err)
+}
+
 func TestListPays(t *testing.T) {
 	req := `{"jsonrpc":"2.0","method":"listpays","params":{},"id":1}`
 	resp := wrapResult(1, `
@@ -545,10 +750,119 @@ func TestPay(t *testing.T) {
 			Route:         failroute,
 		},
 	}
-	expect := &glightning.PaymentSuccess{*paymentFields, 1, 1, route, failures}
+	expect := &glightning.PaymentSuccess{
+		SendPayFields: *paymentFields,
+		GetRouteTries: 1,
+		SendPayTries:  1,
+		Route:         route,
+		Failures:      failures,
+	}
 	assert.Equal(t, expect, payment)
 }
 
+func TestPayWithOptions(t *testing.T) {
+	bolt11 := "lnbcrt3u1pwz67h2pp5h694gdd2suutuv2cpscucarmcgmarjpla9rd5vuwu8rtlzkgtgfqdpzvehhygr8dahkgueqv9hxggrnv4e8v6trv5cqp2rzjq0ashz3etfsqsj2xatuce766s84qzrsrql40x696y8nad08sunwyzqqpquqqqqgqqqqqqqqpqqqqqzsqqcvwxa6a3uu2ue80wflztg9ed27vtwu9k6ymtl03yxswnej5qzdw99ndmhwueuckg2ua2g8hfqf0l3mxvn9azs2u6qx0ag3hxye9x6e9qqv29cq5"
+	req := fmt.Sprintf(`{"jsonrpc":"2.0","method":"pay","params":{"bolt11":"%s","exclude":["233x1x0/0","03fb0b8a395a60084946eaf98cfb5a81ea010e0307eaf368ba21e7d6bcf0e4dc41"],"label":"coffee","maxfee":"1000msat"},"id":1}`, bolt11)
+	resp := wrapResult(1, `{
+  "payment_hash": "be8b5435aa8738be31580c31cc747bc237d1c83fe946da338ee1c6bf8ac85a12",
+  "destination": "023d0e0719af06baa4aac6a1fc8d291b66e00b0a79c6282ed584ce27742f542a82",
+  "amount_msat": "300000msat",
+  "amount_sent_msat": "300010msat",
+  "created_at": 1546484611,
+  "status": "complete",
+  "payment_preimage": "b368340fc5fb5839beaaf59885efa6636557715746be26601cddf876a2bc489b"
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	payment, err := lightning.Pay(&glightning.PayRequest{
+		Bolt11: bolt11,
+		Label:  "coffee",
+		MaxFee: "1000msat",
+		Exclude: []string{
+			"233x1x0/0",
+			"03fb0b8a395a60084946eaf98cfb5a81ea010e0307eaf368ba21e7d6bcf0e4dc41",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "complete", payment.Status)
+	assert.Equal(t, "300010msat", payment.MilliSatoshiSent)
+
+	_, err = lightning.Pay(&glightning.PayRequest{
+		Bolt11:        bolt11,
+		MaxFee:        "1000msat",
+		MaxFeePercent: 0.5,
+	})
+	assert.Error(t, err)
+}
+
+func TestPayError(t *testing.T) {
+	bolt11 := "lnbcrt3u1pwz67h2pp5h694gdd2suutuv2cpscucarmcgmarjpla9rd5vuwu8rtlzkgtgfqdpzvehhygr8dahkgueqv9hxggrnv4e8v6trv5cqp2rzjq0ashz3etfsqsj2xatuce766s84qzrsrql40x696y8nad08sunwyzqqpquqqqqgqqqqqqqqpqqqqqzsqqcvwxa6a3uu2ue80wflztg9ed27vtwu9k6ymtl03yxswnej5qzdw99ndmhwueuckg2ua2g8hfqf0l3mxvn9azs2u6qx0ag3hxye9x6e9qqv29cq5"
+	req := fmt.Sprintf(`{"jsonrpc":"2.0","method":"pay","params":{"bolt11":"%s"},"id":1}`, bolt11)
+	resp := wrapError(1, 204, "failed: WIRE_INCORRECT_OR_UNKNOWN_PAYMENT_DETAILS", `{"erring_index": 1, "failcode": 16399,
+  "erring_node": "023d0e0719af06baa4aac6a1fc8d291b66e00b0a79c6282ed584ce27742f542a82",
+  "erring_channel": "263x1x0",
+  "erring_direction": 0,
+  "payment_hash": "be8b5435aa8738be31580c31cc747bc237d1c83fe946da338ee1c6bf8ac85a12",
+  "destination": "023d0e0719af06baa4aac6a1fc8d291b66e00b0a79c6282ed584ce27742f542a82",
+  "amount_msat": "300000msat",
+  "status": "failed",
+  "raw_message": "400f00000000000493e000000107"}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err := lightning.PayBolt(bolt11)
+	payErr, ok := err.(*glightning.PaymentError)
+	if !ok {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 204, payErr.Code)
+	assert.Equal(t, uint64(1), payErr.Data.ErringIndex)
+	assert.Equal(t, "263x1x0", payErr.Data.ErringChannel)
+	assert.Equal(t, "be8b5435aa8738be31580c31cc747bc237d1c83fe946da338ee1c6bf8ac85a12", payErr.Data.PaymentHash)
+	assert.Equal(t, "300000msat", payErr.Data.AmountMsat)
+	assert.Equal(t, "failed", payErr.Data.Status)
+	assert.Equal(t, glightning.FailCodeIncorrectOrUnknownPaymentDetails, payErr.Data.Failure.Code)
+	assert.True(t, payErr.Data.Failure.IsPermanent())
+
+	var rpcErr *jrpc2.RpcError
+	assert.True(t, errors.As(err, &rpcErr))
+
+	// errors without data are left as they are
+	req = fmt.Sprintf(`{"jsonrpc":"2.0","method":"pay","params":{"bolt11":"%s"},"id":2}`, bolt11)
+	resp = `{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"Invalid bolt11"}}`
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err = lightning.PayBolt(bolt11)
+	_, ok = err.(*jrpc2.RpcError)
+	assert.True(t, ok)
+}
+
+func TestPayPartial(t *testing.T) {
+	bolt11 := "lnbcrt3u1pwz67h2pp5h694gdd2suutuv2cpscucarmcgmarjpla9rd5vuwu8rtlzkgtgfqdpzvehhygr8dahkgueqv9hxggrnv4e8v6trv5cqp2rzjq0ashz3etfsqsj2xatuce766s84qzrsrql40x696y8nad08sunwyzqqpquqqqqgqqqqqqqqpqqqqqzsqqcvwxa6a3uu2ue80wflztg9ed27vtwu9k6ymtl03yxswnej5qzdw99ndmhwueuckg2ua2g8hfqf0l3mxvn9azs2u6qx0ag3hxye9x6e9qqv29cq5"
+	req := fmt.Sprintf(`{"jsonrpc":"2.0","method":"pay","params":{"bolt11":"%s","partial_msat":"100000msat"},"id":1}`, bolt11)
+	resp := wrapResult(1, `{
+  "payment_hash": "be8b5435aa8738be31580c31cc747bc237d1c83fe946da338ee1c6bf8ac85a12",
+  "destination": "023d0e0719af06baa4aac6a1fc8d291b66e00b0a79c6282ed584ce27742f542a82",
+  "amount_msat": "100000msat",
+  "amount_sent_msat": "100010msat",
+  "created_at": 1546484611,
+  "parts": 1,
+  "status": "complete",
+  "payment_preimage": "b368340fc5fb5839beaaf59885efa6636557715746be26601cddf876a2bc489b"
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	payment, err := lightning.PayPartial(bolt11, glightning.NewMsat(100000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "100000msat", payment.AmountMilliSatoshi)
+	assert.Equal(t, uint32(1), payment.Parts)
+
+	_, err = lightning.PayPartial(bolt11, glightning.NewMsat(0))
+	assert.Error(t, err)
+}
+
 func TestWaitSendPay(t *testing.T) {
 	req := `{"jsonrpc":"2.0","method":"waitsendpay","params":{"payment_hash":"37ef7c6ff62d5a2fbce1940ab2f4de2785045b922f93944b73f7bc5123ed698f"},"id":1}`
 	resp := wrapResult(1, `{
@@ -590,6 +904,39 @@ func TestWaitSendPay(t *testing.T) {
 
 }
 
+func TestWaitSendPayPartInGroup(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"waitsendpay","params":{"groupid":3,"partid":2,"payment_hash":"37ef7c6ff62d5a2fbce1940ab2f4de2785045b922f93944b73f7bc5123ed698f","timeout":60},"id":1}`
+	resp := wrapResult(1, `{
+  "id": 5,
+  "payment_hash": "37ef7c6ff62d5a2fbce1940ab2f4de2785045b922f93944b73f7bc5123ed698f",
+  "amount_msat": "20000msat",
+  "msatoshi_sent": 10000,
+  "amount_sent_msat": "10000msat",
+  "created_at": 1546483736,
+  "status": "complete",
+  "partid": 2,
+  "groupid": 3
+}`)
+	paymentHash := "37ef7c6ff62d5a2fbce1940ab2f4de2785045b922f93944b73f7bc5123ed698f"
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	payment, err := lightning.WaitSendPayPartInGroup(paymentHash, 60, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.SendPayFields{
+		Id:                  5,
+		PaymentHash:         paymentHash,
+		AmountMilliSatoshi:  "20000msat",
+		MilliSatoshiSentRaw: 10000,
+		MilliSatoshiSent:    "10000msat",
+		CreatedAt:           1546483736,
+		Status:              "complete",
+		PartId:              2,
+		GroupId:             3,
+	}, payment)
+}
+
 func TestWaitSendPayError(t *testing.T) {
 	req := `{"jsonrpc":"2.0","method":"waitsendpay","params":{"payment_hash":"37ef7c6ff62d5a2fbce1940ab2f4de2785045b922f93944b73f7bc5123ed698f"},"id":1}`
 	resp := wrapError(1, 204, "failed: WIRE_TEMPORARY_CHANNEL_FAILURE", `{"erring_index": 2, "failcode": 4107,
@@ -686,6 +1033,66 @@ func TestSendPay(t *testing.T) {
 	assert.Equal(t, result, invoice)
 }
 
+func TestSendPayPart(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"sendpay","params":{"amount_msat":"20000msat","groupid":3,"partid":2,"payment_hash":"3d8705ad509bb52ee01047a4ced0cd4099da92507674e5452d19271f29df2993","payment_secret":"2ef3fd1fe2cbe4ac3f5ff6c0b8d4a8cb10cb0ae9d6f1d7bb5f6e2fa9a1a8fbcc","route":[{"id":"023d0e0719af06baa4aac6a1fc8d291b66e00b0a79c6282ed584ce27742f542a82","channel":"263x1x0","msatoshi":10000,"delay":9}]},"id":1}`
+	resp := wrapResult(1, `{
+  "message": "Monitor status with listsendpays or waitsendpay",
+  "id": 4,
+  "payment_hash": "3d8705ad509bb52ee01047a4ced0cd4099da92507674e5452d19271f29df2993",
+  "destination": "023d0e0719af06baa4aac6a1fc8d291b66e00b0a79c6282ed584ce27742f542a82",
+  "amount_msat": "20000msat",
+  "msatoshi_sent": 10000,
+  "amount_sent_msat": "10000msat",
+  "created_at": 1546480001,
+  "status": "pending",
+  "partid": 2,
+  "groupid": 3
+}`)
+
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	route := []glightning.RouteHop{
+		glightning.RouteHop{
+			Id:             "023d0e0719af06baa4aac6a1fc8d291b66e00b0a79c6282ed584ce27742f542a82",
+			ShortChannelId: "263x1x0",
+			MilliSatoshi:   uint64(10000),
+			Delay:          9,
+		},
+	}
+	result, err := lightning.SendPayPart(&glightning.SendPayRequest{
+		Route:         route,
+		PaymentHash:   "3d8705ad509bb52ee01047a4ced0cd4099da92507674e5452d19271f29df2993",
+		PaymentSecret: "2ef3fd1fe2cbe4ac3f5ff6c0b8d4a8cb10cb0ae9d6f1d7bb5f6e2fa9a1a8fbcc",
+		PartId:        2,
+		GroupId:       3,
+		TotalMsat:     "20000msat",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(2), result.PartId)
+	assert.Equal(t, uint64(3), result.GroupId)
+	assert.Equal(t, "pending", result.Status)
+
+	_, err = lightning.SendPayPart(&glightning.SendPayRequest{
+		Route:       route,
+		PaymentHash: "3d8705ad509bb52ee01047a4ced0cd4099da92507674e5452d19271f29df2993",
+		PartId:      1,
+	})
+	assert.Error(t, err)
+
+	// the same param under its old and new names
+	msat := uint64(20000)
+	_, err = lightning.SendPayPart(&glightning.SendPayRequest{
+		Route:         route,
+		PaymentHash:   "3d8705ad509bb52ee01047a4ced0cd4099da92507674e5452d19271f29df2993",
+		PartId:        1,
+		TotalMsat:     "20000msat",
+		MilliSatoshis: &msat,
+	})
+	assert.EqualError(t, err, "Must specify only one of TotalMsat and MilliSatoshis")
+}
+
 func TestWaitAnyInvoice(t *testing.T) {
 	req := `{"jsonrpc":"2.0","method":"waitanyinvoice","params":{"lastpay_index":1,"timeout":0},"id":1}`
 	resp := wrapResult(1, `{    
@@ -793,6 +1200,38 @@ func TestDeleteInvoice(t *testing.T) {
 	}, invoices)
 }
 
+func TestDeleteInvoiceDescription(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"delinvoice","params":{"desconly":true,"label":"uniq","status":"paid"},"id":1}`
+	resp := wrapResult(1, `{
+  "label": "uniq",
+  "bolt11": "lnbcrt10p1pwz6k92pp5qgfu5fzu5g77enmz5e9znz5c3wly94huwcsywyffx2xzl23uedaqdq8v3jhxccxqzxgcqp28685h6tlq0lnz3yueqxhtdhqqq7mrwr6mv9j94zdhxpxfg3cd6y4pum736hwve4wq2pmgswkj7apnxcnu8yn89ve0vrhmt6g0jsxfkcqa5uxfj",
+  "payment_hash": "0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a",
+  "amount_msat": "1000msat",
+  "status": "paid",
+  "pay_index": 1,
+  "amount_received_msat": "1000msat",
+  "paid_at": 1546475500,
+  "expires_at": 1546475890
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	invoice, err := lightning.DeleteInvoiceDescription("uniq", "paid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.Invoice{
+		Label:                "uniq",
+		Bolt11:               "lnbcrt10p1pwz6k92pp5qgfu5fzu5g77enmz5e9znz5c3wly94huwcsywyffx2xzl23uedaqdq8v3jhxccxqzxgcqp28685h6tlq0lnz3yueqxhtdhqqq7mrwr6mv9j94zdhxpxfg3cd6y4pum736hwve4wq2pmgswkj7apnxcnu8yn89ve0vrhmt6g0jsxfkcqa5uxfj",
+		PaymentHash:          "0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a",
+		AmountMilliSatoshi:   "1000msat",
+		Status:               "paid",
+		PayIndex:             1,
+		MilliSatoshiReceived: "1000msat",
+		PaidAt:               1546475500,
+		ExpiresAt:            1546475890,
+	}, invoice)
+}
+
 func TestListInvoices(t *testing.T) {
 	req := `{"jsonrpc":"2.0","method":"listinvoices","params":{},"id":1}`
 	resp := wrapResult(1, `{
@@ -875,6 +1314,45 @@ func TestGetInvoice(t *testing.T) {
 	}, invoice)
 }
 
+func TestGetInvoiceByHash(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"listinvoices","params":{"payment_hash":"0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a"},"id":1}`
+	resp := wrapResult(1, `{
+  "invoices": [
+    {
+      "label": "uniq",
+      "payment_hash": "0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a",
+      "msatoshi": 1,
+      "status": "unpaid",
+      "description": "desc",
+      "expires_at": 1546475890
+    }
+  ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	invoice, err := lightning.GetInvoiceByHash("0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.Invoice{
+		Label:                 "uniq",
+		PaymentHash:           "0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a",
+		Status:                "unpaid",
+		Description:           "desc",
+		ExpiresAt:             1546475890,
+		AmountMilliSatoshiRaw: uint64(1),
+	}, invoice)
+}
+
+func TestGetInvoiceByHashNotFound(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"listinvoices","params":{"payment_hash":"aa"},"id":1}`
+	resp := wrapResult(1, `{"invoices": []}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err := lightning.GetInvoiceByHash("aa")
+	assert.Error(t, err)
+}
+
 func TestInvoice(t *testing.T) {
 	req := `{"jsonrpc":"2.0","method":"invoice","params":{"description":"desc","expiry":200,"exposeprivatechannels":true,"label":"uniq","msatoshi":"1"},"id":1}`
 	resp := wrapResult(1, `{
@@ -898,6 +1376,26 @@ func TestInvoice(t *testing.T) {
 	}, invoice)
 }
 
+func TestInvoiceDescHashOnly(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"invoice","params":{"deschashonly":true,"description":"[[\"text/plain\",\"coffee\"]]","label":"lnurl","msatoshi":"5000"},"id":1}`
+	resp := wrapResult(1, `{
+  "payment_hash": "0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a",
+  "expires_at": 1546475890,
+  "bolt11": "lnbcrt50n1"
+} `)
+
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	invoice, err := lightning.CreateInvoiceDescHashOnly(5000, "lnurl", `[["text/plain","coffee"]]`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "lnbcrt50n1", invoice.Bolt11)
+
+	_, err = lightning.CreateInvoiceDescHashOnly(5000, "", "desc", 0)
+	assert.Error(t, err)
+}
+
 func TestInvoiceWithChannelExposure(t *testing.T) {
 	req := `{"jsonrpc":"2.0","method":"invoice","params":{"description":"desc","expiry":200,"exposeprivatechannels":["111x1x0","123x0x0"],"label":"uniq","msatoshi":"1"},"id":1}`
 	resp := wrapResult(1, `{
@@ -1073,6 +1571,30 @@ func TestWithdrawAll(t *testing.T) {
 	}, result)
 }
 
+func TestWithdrawParsedAmount(t *testing.T) {
+	addr := "2MzpEvkwrYfuUFiPQdWHDBSFCw8zipNkYBz"
+	req := `{"jsonrpc":"2.0","method":"withdraw","params":{"destination":"2MzpEvkwrYfuUFiPQdWHDBSFCw8zipNkYBz","feerate":"300perkw","satoshi":"150000"},"id":1}`
+	resp := wrapResult(1, `{
+  "tx": "02000000",
+  "txid": "f80423d5daed70d31585e597d8e1c0d191a5f2d8050a11dee730f7727c5abd9c"
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	amount, err := glightning.ParseSat("0.0015btc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	feerate, err := glightning.ParseFeeRate("300perkw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := lightning.Withdraw(addr, amount, feerate, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "f80423d5daed70d31585e597d8e1c0d191a5f2d8050a11dee730f7727c5abd9c", result.TxId)
+}
+
 func TestTxPrepare(t *testing.T) {
 	destination := "bcrt1qeyyk6sl5pr49ycpqyckvmttus5ttj25pd0zpvg"
 	amount := 100000
@@ -1406,24 +1928,76 @@ func TestFundChannel(t *testing.T) {
 
 }
 
-func TestStartFundChannel(t *testing.T) {
-	id := "0334b7c8e723c00aedb6aaab0988619a6929f0039275ac195185efbadad1a343f9"
-	sats := uint64(100000)
-	feeRate := glightning.NewFeeRateByDirective(glightning.PerKb, glightning.Urgent)
-	req := fmt.Sprintf(`{"jsonrpc":"2.0","method":"fundchannel_start","params":{"amount":%d,"announce":true,"close_to":"2NEgepweLjk6b6KyCRhgNgeLXeDaCUTfRsh","feerate":"urgent","id":"%s"},"id":%d}`, sats, id, 1)
-	resp := wrapResult(1, `{"funding_address" : "bcrt1qc4p5fwkgznrrlml5z4hy0xwauys8nlsxsca2zn2ew2wez27hlyequp6sff","scriptpubkey":"00206f8318ef548f36abf6b34a9e64b6d10fd1943698a9f9eab3a11a4642a4f19918"}
-`)
-
-	lightning, requestQ, replyQ := startupServer(t)
-	go runServerSide(t, req, resp, replyQ, requestQ)
-	result, err := lightning.StartFundChannel(id, sats, true, feeRate, "2NEgepweLjk6b6KyCRhgNgeLXeDaCUTfRsh")
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestMultiFundChannel(t *testing.T) {
+	id1 := "03fb0b8a395a60084946eaf98cfb5a81ea010e0307eaf368ba21e7d6bcf0e4dc41"
+	id2 := "02c0114aac5ea2bce7759eb48d5aa75129700c1eb7fe6cc8743968a202f26505d6"
 
-	expected := &glightning.StartResponse{
-		Address:      "bcrt1qc4p5fwkgznrrlml5z4hy0xwauys8nlsxsca2zn2ew2wez27hlyequp6sff",
-		ScriptPubkey: "00206f8318ef548f36abf6b34a9e64b6d10fd1943698a9f9eab3a11a4642a4f19918",
+	req := fmt.Sprintf(`{"jsonrpc":"2.0","method":"multifundchannel","params":{"destinations":[{"id":"%s@127.0.0.1:9735","amount":"100000","announce":true},{"id":"%s","amount":"200000","announce":false}],"feerate":"slow","minchannels":1},"id":1}`, id1, id2)
+	resp := wrapResult(1, `{
+  "tx": "0200",
+  "txid": "7c158044dd655057ea344924e135f8c5e5cffa8f583ccd81650f2b82057f0b5c",
+  "channel_ids": [
+    {
+      "id": "03fb0b8a395a60084946eaf98cfb5a81ea010e0307eaf368ba21e7d6bcf0e4dc41",
+      "channel_id": "5c0b7f05822b0f6581cd3c588ffacfe5c5f835e1244934ea575065dd4480157c",
+      "outnum": 0
+    }
+  ],
+  "failed": [
+    {
+      "id": "02c0114aac5ea2bce7759eb48d5aa75129700c1eb7fe6cc8743968a202f26505d6",
+      "method": "connect",
+      "error": {
+        "code": 401,
+        "message": "All addresses failed"
+      }
+    }
+  ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	result, err := lightning.MultiFundChannel([]*glightning.FundDestination{
+		{Id: id1 + "@127.0.0.1:9735", Amount: "100000", Announce: true},
+		{Id: id2, Amount: "200000"},
+	}, glightning.NewFeeRateByDirective(glightning.PerKw, glightning.Slow), nil, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.MultiFundChannelResult{
+		FundingTx:   "0200",
+		FundingTxId: "7c158044dd655057ea344924e135f8c5e5cffa8f583ccd81650f2b82057f0b5c",
+		ChannelIds: []*glightning.MultiFundChannelId{
+			{Id: id1, ChannelId: "5c0b7f05822b0f6581cd3c588ffacfe5c5f835e1244934ea575065dd4480157c"},
+		},
+		Failed: []*glightning.MultiFundChannelError{
+			{Id: id2, Method: "connect", Error: &jrpc2.RpcError{Code: 401, Message: "All addresses failed"}},
+		},
+	}, result)
+
+	_, err = lightning.MultiFundChannel(nil, nil, nil, nil, 0)
+	assert.EqualError(t, err, "Must specify at least one destination")
+	_, err = lightning.MultiFundChannel([]*glightning.FundDestination{{Id: id1}}, nil, nil, nil, 0)
+	assert.EqualError(t, err, "Must set satoshi amount to send")
+}
+
+func TestStartFundChannel(t *testing.T) {
+	id := "0334b7c8e723c00aedb6aaab0988619a6929f0039275ac195185efbadad1a343f9"
+	sats := uint64(100000)
+	feeRate := glightning.NewFeeRateByDirective(glightning.PerKb, glightning.Urgent)
+	req := fmt.Sprintf(`{"jsonrpc":"2.0","method":"fundchannel_start","params":{"amount":%d,"announce":true,"close_to":"2NEgepweLjk6b6KyCRhgNgeLXeDaCUTfRsh","feerate":"urgent","id":"%s"},"id":%d}`, sats, id, 1)
+	resp := wrapResult(1, `{"funding_address" : "bcrt1qc4p5fwkgznrrlml5z4hy0xwauys8nlsxsca2zn2ew2wez27hlyequp6sff","scriptpubkey":"00206f8318ef548f36abf6b34a9e64b6d10fd1943698a9f9eab3a11a4642a4f19918"}
+`)
+
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	result, err := lightning.StartFundChannel(id, sats, true, feeRate, "2NEgepweLjk6b6KyCRhgNgeLXeDaCUTfRsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &glightning.StartResponse{
+		Address:      "bcrt1qc4p5fwkgznrrlml5z4hy0xwauys8nlsxsca2zn2ew2wez27hlyequp6sff",
+		ScriptPubkey: "00206f8318ef548f36abf6b34a9e64b6d10fd1943698a9f9eab3a11a4642a4f19918",
 	}
 
 	assert.Equal(t, expected, result)
@@ -1449,6 +2023,22 @@ func TestCompleteFundChannel(t *testing.T) {
 	assert.Equal(t, "5c0b7f05822b0f6581cd3c588ffacfe5c5f835e1244934ea575065dd4480157c", result)
 }
 
+func TestCompleteFundChannelPsbt(t *testing.T) {
+	id := "0334b7c8e723c00aedb6aaab0988619a6929f0039275ac195185efbadad1a343f9"
+	req := fmt.Sprintf(`{"jsonrpc":"2.0","method":"fundchannel_complete","params":{"id":"%s","psbt":"cHNidP8B"},"id":1}`, id)
+	resp := wrapResult(1, `{"channel_id": "5c0b", "commitments_secured": true}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	result, err := lightning.CompleteFundChannelPsbt(id, "cHNidP8B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "5c0b", result)
+
+	_, err = lightning.CompleteFundChannelPsbt(id, "")
+	assert.Error(t, err)
+}
+
 func TestCancelFundChannel(t *testing.T) {
 	id := "0334b7c8e723c00aedb6aaab0988619a6929f0039275ac195185efbadad1a343f9"
 	req := fmt.Sprintf(`{"jsonrpc":"2.0","method":"fundchannel_cancel","params":{"id":"%s"},"id":%d}`, id, 1)
@@ -1466,6 +2056,110 @@ func TestCancelFundChannel(t *testing.T) {
 	assert.Equal(t, true, result)
 }
 
+func TestOpenChannelBump(t *testing.T) {
+	cid := "1c6b4e3fd1e8f8cb4a4e4a7dce2d3fc9f9b31e0c4a6dea1e0f55ae23f1e7bc10"
+	lightning, requestQ, replyQ := startupServer(t)
+
+	req := `{"jsonrpc":"2.0","method":"openchannel_bump","params":{"amount":"500000","channel_id":"` + cid + `","funding_feerate":"5000perkw","initialpsbt":"cHNidP8BAA"},"id":1}`
+	resp := wrapResult(1, `{"channel_id": "`+cid+`", "psbt": "cHNidP8BAB", "commitments_secured": false, "funding_serial": 17, "requires_confirmed_inputs": true}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	result, err := lightning.OpenChannelBump(cid, glightning.NewSat(500000), "cHNidP8BAA", glightning.NewFeeRate(glightning.PerKw, 5000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.OpenChannelV2Result{
+		ChannelId:               cid,
+		Psbt:                    "cHNidP8BAB",
+		FundingSerial:           17,
+		RequiresConfirmedInputs: true,
+	}, result)
+
+	req = `{"jsonrpc":"2.0","method":"openchannel_update","params":{"channel_id":"` + cid + `","psbt":"cHNidP8BAB"},"id":2}`
+	resp = wrapResult(2, `{"channel_id": "`+cid+`", "psbt": "cHNidP8BAC", "commitments_secured": true, "funding_outnum": 1}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	result, err = lightning.OpenChannelUpdate(cid, "cHNidP8BAB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, result.CommitmentsSecured)
+	assert.Equal(t, uint32(1), result.FundingOutnum)
+
+	req = `{"jsonrpc":"2.0","method":"openchannel_signed","params":{"channel_id":"` + cid + `","signed_psbt":"cHNidP8BAD"},"id":3}`
+	resp = wrapResult(3, `{"channel_id": "`+cid+`", "tx": "0200", "txid": "f00d"}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	signed, err := lightning.OpenChannelSigned(cid, "cHNidP8BAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "f00d", signed.TxId)
+
+	req = `{"jsonrpc":"2.0","method":"openchannel_abort","params":{"channel_id":"` + cid + `"},"id":4}`
+	resp = wrapResult(4, `{"channel_id": "`+cid+`", "channel_canceled": false, "reason": "Abort requested"}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	aborted, err := lightning.OpenChannelAbort(cid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, aborted.ChannelCanceled)
+	assert.Equal(t, "Abort requested", aborted.Reason)
+
+	_, err = lightning.OpenChannelBump(cid, glightning.NewSat(500000), "", nil)
+	assert.EqualError(t, err, "Must provide an initial psbt")
+}
+
+func TestSplice(t *testing.T) {
+	cid := "1c6b4e3fd1e8f8cb4a4e4a7dce2d3fc9f9b31e0c4a6dea1e0f55ae23f1e7bc10"
+	lightning, requestQ, replyQ := startupServer(t)
+
+	req := `{"jsonrpc":"2.0","method":"splice_init","params":{"channel_id":"` + cid + `","feerate_per_kw":2500,"initialpsbt":"cHNidP8BAA","relative_amount":-100000},"id":1}`
+	resp := wrapResult(1, `{"psbt": "cHNidP8BAB"}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	update, err := lightning.SpliceInit(cid, -100000, "cHNidP8BAA", 2500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.SpliceUpdateResult{Psbt: "cHNidP8BAB"}, update)
+
+	req = `{"jsonrpc":"2.0","method":"splice_update","params":{"channel_id":"` + cid + `","psbt":"cHNidP8BAB"},"id":2}`
+	resp = wrapResult(2, `{"psbt": "cHNidP8BAC", "commitments_secured": true, "signatures_secured": false}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	update, err = lightning.SpliceUpdate(cid, "cHNidP8BAB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, update.CommitmentsSecured)
+
+	req = `{"jsonrpc":"2.0","method":"splice_signed","params":{"channel_id":"` + cid + `","psbt":"cHNidP8BAC"},"id":3}`
+	resp = wrapResult(3, `{"tx": "0200", "txid": "f00d", "outnum": 1}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	signed, err := lightning.SpliceSigned(cid, "cHNidP8BAC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.SpliceSignedResult{Tx: "0200", TxId: "f00d", Outnum: 1}, signed)
+
+	req = `{"jsonrpc":"2.0","method":"addpsbtoutput","params":{"destination":"bcrt1qcold","satoshi":"100000"},"id":4}`
+	resp = wrapResult(4, `{"psbt": "cHNidP8BAA", "estimated_added_weight": 172, "outnum": 0}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	output, err := lightning.AddPsbtOutput(glightning.NewSat(100000), "", "bcrt1qcold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(172), output.EstimatedAddedWeight)
+
+	req = `{"jsonrpc":"2.0","method":"unreserveinputs","params":{"psbt":"cHNidP8BAA"},"id":5}`
+	resp = wrapResult(5, `{"reservations": [{"txid": "beef", "vout": 1, "was_reserved": true, "reserved": false, "reserved_to_block": 0}]}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	reservations, err := lightning.UnreserveInputs("cHNidP8BAA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*glightning.PsbtReservation{{TxId: "beef", Vout: 1, WasReserved: true}}, reservations)
+
+	_, err = lightning.SpliceUpdate(cid, "")
+	assert.EqualError(t, err, "Must provide a channel id and psbt to update")
+}
+
 func TestStop(t *testing.T) {
 	req := `{"jsonrpc":"2.0","method":"stop","params":{},"id":1}`
 	resp := wrapResult(1, `"Shutting down"`)
@@ -1528,6 +2222,40 @@ func TestListChannelsBySource(t *testing.T) {
 	}
 }
 
+func TestListChannelsByDestination(t *testing.T) {
+	lightning, requestQ, replyQ := startupServer(t)
+	destination := "02308c54b63e2c1375a52ce6ca27b171188f99e7c274eaf14be396289d93fb6003"
+	req := "{\"jsonrpc\":\"2.0\",\"method\":\"listchannels\",\"params\":{\"destination\":\"02308c54b63e2c1375a52ce6ca27b171188f99e7c274eaf14be396289d93fb6003\"},\"id\":1}"
+	resp := wrapResult(1, `{
+  "channels": [
+    {
+      "source": "034143d1a45cb9bcb912eab97facf4a971098385c4701753d6bc40e52192d0c04f",
+      "destination": "02308c54b63e2c1375a52ce6ca27b171188f99e7c274eaf14be396289d93fb6003",
+      "short_channel_id": "556297x2967x0",
+      "public": true,
+      "satoshis": 500000,
+      "amount_msat": "500000000msat",
+      "message_flags": 0,
+      "channel_flags": 1,
+      "active": true,
+      "last_update": 1546213449,
+      "base_fee_millisatoshi": 1000,
+      "fee_per_millionth": 1,
+      "delay": 144,
+      "htlc_minimum_msat": "0msat",
+      "htlc_maximum_msat": "4294967295msat"
+    }
+  ]
+}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	channels, err := lightning.ListChannelsByDestination(destination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(channels))
+	assert.Equal(t, destination, channels[0].Destination)
+}
+
 func TestListChannels(t *testing.T) {
 	lightning, requestQ, replyQ := startupServer(t)
 	scid := "556297x2967x0"
@@ -1656,6 +2384,74 @@ func TestListNodes(t *testing.T) {
 	}, nodes)
 }
 
+func TestListNodesWillFund(t *testing.T) {
+	lightning, requestQ, replyQ := startupServer(t)
+	req := `{"jsonrpc":"2.0","method":"listnodes","params":{},"id":1}`
+	resp := wrapResult(1, `{"nodes": [
+    {
+      "nodeid": "02befaace6e8970aaca34eafe85f30f988e374628ec279d94e7eca8b574b738eb4",
+      "alias": "SILENTARTIST",
+      "color": "022d22",
+      "last_timestamp": 1629390236,
+      "features": "88",
+      "addresses": [],
+      "option_will_fund": {
+        "lease_fee_base_msat": "2000000msat",
+        "lease_fee_basis": 65,
+        "funding_weight": 666,
+        "channel_fee_max_base_msat": "5000msat",
+        "channel_fee_max_proportional_thousandths": 100,
+        "compact_lease": "029a0041000a0064000007d0"
+      }
+    }
+  ]}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	nodes, err := lightning.ListNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	featureHex, _ := glightning.NewHex("88")
+	assert.Equal(t, []*glightning.Node{
+		&glightning.Node{
+			Id:            "02befaace6e8970aaca34eafe85f30f988e374628ec279d94e7eca8b574b738eb4",
+			Alias:         "SILENTARTIST",
+			Color:         "022d22",
+			LastTimestamp: uint(1629390236),
+			Features:      featureHex,
+			Addresses:     []glightning.Address{},
+			WillFund: &glightning.WillFund{
+				LeaseFeeBaseMsat:                     "2000000msat",
+				LeaseFeeBasis:                        65,
+				FundingWeight:                        666,
+				ChannelFeeMaxBaseMsat:                "5000msat",
+				ChannelFeeMaxProportionalThousandths: 100,
+				CompactLease:                         "029a0041000a0064000007d0",
+			},
+		},
+	}, nodes)
+}
+
+func TestNetAddressType(t *testing.T) {
+	addrType, err := glightning.ParseNetAddressType("TORV3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, glightning.AddrTorV3, addrType)
+	assert.True(t, addrType.IsTor())
+	assert.False(t, addrType.IsClearnet())
+
+	_, err = glightning.ParseNetAddressType("carrier-pigeon")
+	assert.Error(t, err)
+
+	addr := &glightning.Address{Type: glightning.AddrIPv6, Addr: "::1", Port: 9735}
+	assert.True(t, addr.IsClearnet())
+	assert.Equal(t, "[::1]:9735", addr.String())
+
+	dns := &glightning.Address{Type: glightning.AddrDNS, Addr: "ln.example.com", Port: 9735}
+	assert.False(t, dns.IsTor())
+	assert.Equal(t, "ln.example.com:9735", dns.String())
+}
+
 func TestGetInfo(t *testing.T) {
 	lightning, requestQ, replyQ := startupServer(t)
 	req := "{\"jsonrpc\":\"2.0\",\"method\":\"getinfo\",\"params\":{},\"id\":1}"
@@ -1714,6 +2510,33 @@ func TestGetInfo(t *testing.T) {
 	}, info)
 }
 
+func TestReplayFixtures(t *testing.T) {
+	fixtures, err := jrpc2.ReadFixtures(strings.NewReader(`
+{"method":"getinfo","params":{},"result":{"id":"02a5","alias":"replayed","num_peers":3,"blockheight":812000,"network":"bitcoin"}}
+{"method":"listfunds","error":{"code":-32601,"message":"Unknown command 'listfunds'"}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := jrpc2.NewReplayServer(fixtures...)
+	lightning := glightning.NewLightning()
+	lightning.StartConn(server.Pipe())
+	defer lightning.Shutdown()
+
+	info, err := lightning.GetInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "replayed", info.Alias)
+	assert.Equal(t, 3, info.PeerCount)
+	assert.Equal(t, uint(812000), info.Blockheight)
+
+	_, err = lightning.ListFunds()
+	assert.True(t, errors.Is(err, glightning.ErrUnknownCommand))
+	assert.Equal(t, 0, server.Remaining())
+	assert.Empty(t, server.Unmatched())
+}
+
 func TestGetLog(t *testing.T) {
 	lightning, requestQ, replyQ := startupServer(t)
 	req := "{\"jsonrpc\":\"2.0\",\"method\":\"getlog\",\"params\":{\"level\":\"info\"},\"id\":1}"
@@ -1847,6 +2670,32 @@ func TestHelpFor(t *testing.T) {
 	}, cmd)
 }
 
+func TestHelpForParams(t *testing.T) {
+	lightning, requestQ, replyQ := startupServer(t)
+	resp := wrapResult(1, `{"help": [{"command": "connect id [host] [port]", "description": "Connect to {id} at {host} (which can end in ':port' if not default). {id} can also be of the form id@host", "category":"network", "verbose": "HELP! Please contribute a description for this json_command!"}]}`)
+	req := "{\"jsonrpc\":\"2.0\",\"method\":\"help\",\"params\":{\"command\":\"connect\"},\"id\":1}"
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	cmd, err := lightning.HelpFor("connect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "connect", cmd.Name())
+	assert.Equal(t, "id [host] [port]", cmd.Usage())
+	assert.Equal(t, []*glightning.CommandParam{
+		&glightning.CommandParam{Name: "id"},
+		&glightning.CommandParam{Name: "host", Optional: true},
+		&glightning.CommandParam{Name: "port", Optional: true},
+	}, cmd.Params())
+
+	nested := &glightning.Command{NameAndUsage: "pay bolt11 [msatoshi [label]] riskfactor"}
+	assert.Equal(t, []*glightning.CommandParam{
+		&glightning.CommandParam{Name: "bolt11"},
+		&glightning.CommandParam{Name: "msatoshi", Optional: true},
+		&glightning.CommandParam{Name: "label", Optional: true},
+		&glightning.CommandParam{Name: "riskfactor"},
+	}, nested.Params())
+}
+
 func TestDecodePay(t *testing.T) {
 	lightning, requestQ, replyQ := startupServer(t)
 
@@ -2386,6 +3235,31 @@ func TestSetChannelFee(t *testing.T) {
 	assert.Equal(t, exp, result)
 }
 
+func TestSetChannel(t *testing.T) {
+	request := "{\"jsonrpc\":\"2.0\",\"method\":\"setchannel\",\"params\":{\"feebase\":1000,\"feeppm\":400,\"id\":\"1442x1x0\"},\"id\":1}"
+	reply := wrapResult(1, `{"channels":[{"peer_id":"02502091854ba31bddef5be51584c4014c3edd7d65936b6841fa9a9f6366313a54","channel_id":"04a59bdc9f8708ff5457726725c10d161d8b4ad1330b6d92d1d5196994a2478e","short_channel_id":"1442x1x0","fee_base_msat":"1000msat","fee_proportional_millionths":400,"minimum_htlc_out_msat":"0msat","maximum_htlc_out_msat":"990000000msat","ignore_fee_limits":false}]}`)
+
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, request, reply, replyQ, requestQ)
+	result, err := lightning.SetChannel("1442x1x0", 1000, 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.SetChannelResult{
+		Channels: []*glightning.ChannelSettings{{
+			PeerId:                    "02502091854ba31bddef5be51584c4014c3edd7d65936b6841fa9a9f6366313a54",
+			ChannelId:                 "04a59bdc9f8708ff5457726725c10d161d8b4ad1330b6d92d1d5196994a2478e",
+			ShortChannelId:            "1442x1x0",
+			FeeBaseMsat:               "1000msat",
+			FeeProportionalMillionths: 400,
+			MinimumHtlcOutMsat:        "0msat",
+			MaximumHtlcOutMsat:        "990000000msat",
+		}},
+	}, result)
+
+	_, err = lightning.SetChannelWithOptions(&glightning.SetChannelRequest{})
+	assert.EqualError(t, err, "Must specify a channel to set")
+}
 func TestLimitedFeeRates(t *testing.T) {
 	request := "{\"jsonrpc\":\"2.0\",\"method\":\"feerates\",\"params\":{\"style\":\"perkw\"},\"id\":1}"
 	reply := wrapResult(1, `{ "perkw": { "min_acceptable": 253, "max_acceptable": 4294967295 }, "warning": "Some fee estimates unavailable: bitcoind startup?" } `)
@@ -2435,6 +3309,381 @@ func TestDevSendCustomMessage(t *testing.T) {
 	assert.Equal(t, expect, result)
 }
 
+func TestDevSignLastTx(t *testing.T) {
+	peer := "02e3cd7849f177a46f137ae3bfc1a08fc6a90bf4026c74f83c1ecc8430c282fe96"
+	req := fmt.Sprintf(`{"jsonrpc":"2.0","method":"dev-sign-last-tx","params":{"id":"%s"},"id":1}`, peer)
+	resp := wrapResult(1, `{"tx": "0200000001c0ffee"}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	tx, err := lightning.DevSignLastTx(peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "0200000001c0ffee", tx)
+}
+
+func TestDevFail(t *testing.T) {
+	peer := "02e3cd7849f177a46f137ae3bfc1a08fc6a90bf4026c74f83c1ecc8430c282fe96"
+	req := fmt.Sprintf(`{"jsonrpc":"2.0","method":"dev-fail","params":{"id":"%s"},"id":1}`, peer)
+	resp := wrapResult(1, `{}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	err := lightning.DevFail(peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDevMemLeak(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"dev-memleak","params":{},"id":1}`
+	resp := wrapResult(1, `{"leaks": [
+      {
+         "value": "0x55f1b7a3c2d8",
+         "label": "lightningd/peer_control.c:123:struct peer",
+         "backtrace": [
+            "ccan/ccan/tal/tal.c:442 (tal_alloc_)",
+            "lightningd/peer_control.c:123 (new_peer)"
+         ],
+         "parents": [
+            "lightningd/lightningd.c:97:struct lightningd"
+         ]
+      }
+   ]}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	leaks, err := lightning.DevMemLeak()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*glightning.MemLeak{
+		&glightning.MemLeak{
+			PointerValue: "0x55f1b7a3c2d8",
+			Label:        "lightningd/peer_control.c:123:struct peer",
+			Backtrace: []string{
+				"ccan/ccan/tal/tal.c:442 (tal_alloc_)",
+				"lightningd/peer_control.c:123 (new_peer)",
+			},
+			Parents: []string{
+				"lightningd/lightningd.c:97:struct lightningd",
+			},
+		},
+	}, leaks)
+}
+
+func TestDevRescanOutputs(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"dev-rescan-outputs","params":{},"id":1}`
+	resp := wrapResult(1, `{"outputs": [
+      {
+         "txid": "7eaa9fffc33115389e83816d94f7b14efc6a04c3b33672c3b347b815f8362c88",
+         "output": 1,
+         "oldstate": 0,
+         "newstate": 2
+      }
+   ]}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	outputs, err := lightning.DevRescanOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []glightning.Output{
+		glightning.Output{
+			TxId:     "7eaa9fffc33115389e83816d94f7b14efc6a04c3b33672c3b347b815f8362c88",
+			Output:   1,
+			OldState: glightning.OutputAvailable,
+			NewState: glightning.OutputSpent,
+		},
+	}, outputs)
+	assert.Equal(t, "spent", outputs[0].NewState.String())
+}
+
+func TestFunderUpdate(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"funderupdate","params":{"lease_fee_base_msat":"2000msat","lease_fee_basis":50,"policy":"match","policy_mod":100},"id":1}`
+	resp := wrapResult(1, `{
+   "summary": "match (100%)",
+   "policy": "match",
+   "policy_mod": 100,
+   "leases_only": false,
+   "min_their_funding_msat": "10000000msat",
+   "max_their_funding_msat": "4294967295000msat",
+   "per_channel_min_msat": "10000000msat",
+   "per_channel_max_msat": "4294967295000msat",
+   "reserve_tank_msat": "0msat",
+   "fuzz_percent": 0,
+   "fund_probability": 100,
+   "lease_fee_base_msat": "2000msat",
+   "lease_fee_basis": 50,
+   "funding_weight": 666,
+   "channel_fee_max_base_msat": "5000msat",
+   "channel_fee_max_proportional_thousandths": 100,
+   "compact_lease": "029a00320064000007d0"
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+
+	policyMod := uint64(100)
+	basis := uint32(50)
+	result, err := lightning.FunderUpdate(&glightning.FunderUpdateRequest{
+		Policy:           glightning.FunderMatch.String(),
+		PolicyMod:        &policyMod,
+		LeaseFeeBaseMsat: "2000msat",
+		LeaseFeeBasis:    &basis,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.FunderPolicyResult{
+		Summary:                              "match (100%)",
+		Policy:                               "match",
+		PolicyMod:                            100,
+		MinTheirFundingMsat:                  "10000000msat",
+		MaxTheirFundingMsat:                  "4294967295000msat",
+		PerChannelMinMsat:                    "10000000msat",
+		PerChannelMaxMsat:                    "4294967295000msat",
+		ReserveTankMsat:                      "0msat",
+		FundProbability:                      100,
+		LeaseFeeBaseMsat:                     "2000msat",
+		LeaseFeeBasis:                        50,
+		FundingWeight:                        666,
+		ChannelFeeMaxBaseMsat:                "5000msat",
+		ChannelFeeMaxProportionalThousandths: 100,
+		CompactLease:                         "029a00320064000007d0",
+	}, result)
+}
+
+func TestDevForgetChannelByShortChannelId(t *testing.T) {
+	peer := "02e3cd7849f177a46f137ae3bfc1a08fc6a90bf4026c74f83c1ecc8430c282fe96"
+	req := fmt.Sprintf(`{"jsonrpc":"2.0","method":"dev-forget-channel","params":{"force":true,"id":"%s","short_channel_id":"103x1x0"},"id":1}`, peer)
+	resp := wrapResult(1, `{
+   "forced": true,
+   "funding_unspent": false,
+   "funding_txid": "38154ece6c99e26c9be161039fe38ee744479ac568592c22302ff17c34f11554"
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	result, err := lightning.DevForgetChannelByShortChannelId(peer, "103x1x0", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.ForgetChannelResult{
+		WasForced:        true,
+		IsFundingUnspent: false,
+		FundingTxId:      "38154ece6c99e26c9be161039fe38ee744479ac568592c22302ff17c34f11554",
+	}, result)
+}
+
+func TestSendOnionMessage(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"sendonionmessage","params":{"blinding":"03cc0e3bd6e2ae1f4d0ec1c8c3ce1b5c23cd1e4c4e1a5a8b0c3c1d2e3f4a5b6c7d","first_id":"022d223620a359a47ff7f7ac447c85c46c923da53389221a0054c11c1e3ca31d59","hops":[{"node":"022d223620a359a47ff7f7ac447c85c46c923da53389221a0054c11c1e3ca31d59","tlv":"0401aa"}]},"id":1}`
+	resp := wrapResult(1, `{}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	err := lightning.SendOnionMessage(
+		"022d223620a359a47ff7f7ac447c85c46c923da53389221a0054c11c1e3ca31d59",
+		"03cc0e3bd6e2ae1f4d0ec1c8c3ce1b5c23cd1e4c4e1a5a8b0c3c1d2e3f4a5b6c7d",
+		[]*glightning.OnionMessageHop{
+			&glightning.OnionMessageHop{
+				Node: "022d223620a359a47ff7f7ac447c85c46c923da53389221a0054c11c1e3ca31d59",
+				Tlv:  "0401aa",
+			},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = lightning.SendOnionMessage("022d223620a359a47ff7f7ac447c85c46c923da53389221a0054c11c1e3ca31d59", "03cc", nil)
+	assert.Error(t, err)
+}
+
+func TestInjectOnionMessage(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"injectonionmessage","params":{"message":"0002aabb","path_key":"03cc0e3bd6e2ae1f4d0ec1c8c3ce1b5c23cd1e4c4e1a5a8b0c3c1d2e3f4a5b6c7d"},"id":1}`
+	resp := wrapResult(1, `{}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	err := lightning.InjectOnionMessage("03cc0e3bd6e2ae1f4d0ec1c8c3ce1b5c23cd1e4c4e1a5a8b0c3c1d2e3f4a5b6c7d", "0002aabb")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeprecations(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"deprecations","params":{"enable":false},"id":1}`
+	resp := wrapResult(1, `{}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	err := lightning.Deprecations(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetPsbtVersion(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"setpsbtversion","params":{"psbt":"cHNidP8BAgQCAAAAAQMEAAAAAAEEAQABBQEAAQYBAwH7BAIAAAAA","version":0},"id":1}`
+	resp := wrapResult(1, `{
+  "psbt": "cHNidP8BAAoCAAAAAAAAAAAAAA=="
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	psbt, err := lightning.SetPsbtVersion("cHNidP8BAgQCAAAAAQMEAAAAAAEEAQABBQEAAQYBAwH7BAIAAAAA", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "cHNidP8BAAoCAAAAAAAAAAAAAA==", psbt)
+
+	_, err = lightning.SetPsbtVersion("cHNidP8BAAoCAAAAAAAAAAAAAA==", 1)
+	assert.Error(t, err)
+}
+
+func TestFundPsbt(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"fundpsbt","params":{"feerate":"urgent","reserve":0,"satoshi":"100000","startweight":1000},"id":1}`
+	resp := wrapResult(1, `{
+  "psbt": "cHNidP8BAF4CAAAAAQ==",
+  "feerate_per_kw": 7500,
+  "estimated_final_weight": 1276,
+  "excess_msat": "14571000msat",
+  "reservations": [
+    {
+      "txid": "8e2a1b8a3e6d4b1f6e9c0f1f2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e",
+      "vout": 1,
+      "was_reserved": false,
+      "reserved": false,
+      "reserved_to_block": 0
+    }
+  ]
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	noReserve := uint32(0)
+	result, err := lightning.FundPsbt(glightning.NewSat(100000), glightning.NewFeeRateByDirective(glightning.PerKw, glightning.Urgent), 1000, &noReserve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.FundPsbtResult{
+		Psbt:                 "cHNidP8BAF4CAAAAAQ==",
+		FeeRatePerKw:         7500,
+		EstimatedFinalWeight: 1276,
+		ExcessMsat:           "14571000msat",
+		Reservations: []*glightning.PsbtReservation{
+			{
+				TxId: "8e2a1b8a3e6d4b1f6e9c0f1f2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e",
+				Vout: 1,
+			},
+		},
+	}, result)
+
+	_, err = lightning.FundPsbt(nil, nil, 0, nil)
+	assert.Error(t, err)
+}
+
+func TestSignPsbt(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"signpsbt","params":{"psbt":"cHNidP8BAF4CAAAAAQ==","signonly":[0]},"id":1}`
+	resp := wrapResult(1, `{"signed_psbt": "cHNidP8BAF4CAAAAAg=="}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	signed, err := lightning.SignPsbt("cHNidP8BAF4CAAAAAQ==", []uint32{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "cHNidP8BAF4CAAAAAg==", signed)
+}
+
+func TestSendPsbt(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"sendpsbt","params":{"psbt":"cHNidP8BAF4CAAAAAg=="},"id":1}`
+	resp := wrapResult(1, `{
+  "tx": "02000000000101",
+  "txid": "5e4d3c2b1a09f8e7d6c5b4a3928170f6e5d4c3b2f1f0c9e6f1b4d6e3a8b1a2e8"
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	result, err := lightning.SendPsbt("cHNidP8BAF4CAAAAAg==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "5e4d3c2b1a09f8e7d6c5b4a3928170f6e5d4c3b2f1f0c9e6f1b4d6e3a8b1a2e8", result.TxId)
+}
+
+func TestCall(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"listoffers","params":{"active_only":true},"id":1}`
+	resp := wrapResult(1, `{"offers": []}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	result, err := lightning.Call("listoffers", map[string]interface{}{"active_only": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, `{"offers": []}`, string(result))
+}
+
+func TestCallNoParams(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"getinfo","params":{},"id":1}`
+	resp := wrapResult(1, `{}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err := lightning.Call("getinfo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCallInto(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"summary","params":{"exclude_dead":true,"limit":5},"id":1}`
+	resp := wrapResult(1, `{"network": "regtest", "num_channels": 2}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	type summaryParams struct {
+		ExcludeDead bool `json:"exclude_dead"`
+		Limit       int  `json:"limit"`
+		Offset      int  `json:"offset,omitempty"`
+	}
+	var summary struct {
+		Network     string `json:"network"`
+		NumChannels int    `json:"num_channels"`
+	}
+	err := lightning.CallInto("summary", &summaryParams{ExcludeDead: true, Limit: 5}, &summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "regtest", summary.Network)
+	assert.Equal(t, 2, summary.NumChannels)
+
+	err = lightning.CallInto("summary", []string{"positional"}, &summary)
+	assert.Error(t, err)
+}
+
+func TestCallTyped(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"summary","params":{"limit":5},"id":1}`
+	resp := wrapResult(1, `{"network": "regtest", "num_channels": 2}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	type summaryResult struct {
+		Network     string `json:"network"`
+		NumChannels int    `json:"num_channels"`
+	}
+	summary, err := glightning.Call[summaryResult](lightning, "summary", map[string]interface{}{"limit": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &summaryResult{Network: "regtest", NumChannels: 2}, summary)
+
+	summary, err = glightning.Call[summaryResult](lightning, "", nil)
+	assert.Error(t, err)
+	assert.Nil(t, summary)
+}
+
+func TestRequestRaw(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"getinfo","params":{},"id":1}`
+	resp := wrapResult(1, `{"id": "02aa", "alias": "audit", "num_peers": 3}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	var info glightning.NodeInfo
+	raw, err := lightning.RequestRaw(&glightning.GetInfoRequest{}, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "audit", info.Alias)
+	assert.Equal(t, 3, info.PeerCount)
+	assert.Equal(t, `{"id": "02aa", "alias": "audit", "num_peers": 3}`, string(raw))
+}
+
 func runServerSide(t *testing.T, expectedRequest, reply string, replyQ, requestQ chan []byte) {
 	// take the request off the requestQ
 	request := <-requestQ
@@ -2518,3 +3767,84 @@ func wrapError(id, code int, message, data string) string {
 func wrapResult(id int, result string) string {
 	return fmt.Sprintf("{\"jsonrpc\":\"2.0\",\"id\":%d,\"result\":%s}", id, result)
 }
+
+func TestKeySend(t *testing.T) {
+	dest := "02e3cd7849f177a46f137ae3bfc1a08fc6a90bf4026c74f83c1ecc8430c282fe96"
+	req := `{"jsonrpc":"2.0","method":"keysend","params":{"amount_msat":10000,"destination":"` + dest + `","extratlvs":{"133773310":"6869","7629169":"7061796d656e74"},"label":"boost"},"id":1}`
+	resp := wrapResult(1, `{
+  "destination": "`+dest+`",
+  "payment_hash": "0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a",
+  "created_at": 1661789472.373,
+  "parts": 1,
+  "amount_msat": "10000msat",
+  "amount_sent_msat": "10001msat",
+  "payment_preimage": "25a22f2da5e27c3ea2b0b8e60a7b4d1b2e7a0fa6fef4f9a4d3be44e3bcf50b5d",
+  "status": "complete"
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	result, err := lightning.KeySend(dest, 10000, "boost", []*tlv.Record{
+		{Type: 133773310, Value: []byte("hi")},
+		{Type: 7629169, Value: []byte("payment")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "complete", result.Status)
+	assert.Equal(t, "10001msat", result.MilliSatoshiSent)
+
+	_, err = lightning.KeySend(dest, 10000, "", []*tlv.Record{{Type: 1}, {Type: 1}})
+	assert.EqualError(t, err, "Duplicate extra tlv type 1")
+	_, err = lightning.KeySend(dest, 0, "", nil)
+	assert.Error(t, err)
+}
+
+func TestOnionPayload(t *testing.T) {
+	forward := &glightning.OnionPayload{
+		AmountMsat:     1000,
+		OutgoingCltv:   144,
+		ShortChannelId: glightning.NewShortChannelId(103, 1, 0),
+	}
+	payload, err := forward.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "11"+"020203e8"+"040190"+"06080000670000010000", payload)
+
+	secret := strings.Repeat("11", 32)
+	final := &glightning.OnionPayload{
+		AmountMsat:    1000,
+		OutgoingCltv:  144,
+		PaymentSecret: secret,
+		TotalMsat:     5000,
+		Extra:         []*tlv.Record{{Type: 5482373484, Value: []byte{0xaa}}},
+	}
+	payload, err = final.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "36"+"020203e8"+"040190"+"0822"+secret+"1388"+"ff0000000146c6616c01aa", payload)
+
+	final.PaymentSecret = "11"
+	_, err = final.Encode()
+	assert.Error(t, err)
+}
+
+func TestSendCustomTlvMessage(t *testing.T) {
+	peer := "02e3cd7849f177a46f137ae3bfc1a08fc6a90bf4026c74f83c1ecc8430c282fe96"
+	req := `{"jsonrpc":"2.0","method":"sendcustommsg","params":{"msg":"80010103616263","node_id":"` + peer + `"},"id":1}`
+	resp := wrapResult(1, `{"status": "Message sent to connectd for delivery"}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err := lightning.SendCustomTlvMessage(peer, 32769, []*tlv.Record{{Type: 1, Value: []byte("abc")}})
+	assert.NoError(t, err)
+
+	_, err = lightning.SendCustomTlvMessage(peer, 32768, nil)
+	assert.EqualError(t, err, "Custom message type 32768 must be odd")
+
+	event := &glightning.CustomMsgReceivedEvent{PeerId: peer, Payload: "80010103616263"}
+	msgType, records, err := event.TlvMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(32769), msgType)
+	assert.Equal(t, []*tlv.Record{{Type: 1, Value: []byte("abc")}}, records)
+}
@@ -0,0 +1,167 @@
+package glightning
+
+import (
+	"fmt"
+	"sort"
+)
+
+// How long a liquidity ad lease lasts, in blocks (about four weeks).
+// The peer can't close the channel, or raise its fees above the ad's
+// maximums, until it's up.
+const LeaseBlocks = 4032
+
+// What liquidity to look for with FindLeaseOffers
+type LeaseQuery struct {
+	// Liquidity to lease, in satoshi
+	AmountSat uint64
+	// The feerate the channel will be opened at, for the weight the
+	// peer charges us for adding its funds
+	FeeRatePerKw uint
+	// How long the liquidity is needed for; the cost of the leases
+	// needed to cover it, at today's rates, is reported. Defaults to
+	// one lease.
+	Blocks uint32
+	// Skip offers costing more than this in total, in satoshi. Zero
+	// means no limit.
+	MaxCostSat uint64
+	// Skip offers which let the peer charge more than these for
+	// routing over the channel during the lease. Zero means no limit.
+	MaxChannelFeeBaseMsat uint64
+	MaxChannelFeePPT      uint32
+}
+
+// A node's liquidity ad, costed for a LeaseQuery
+type LeaseOffer struct {
+	NodeId   string
+	Alias    string
+	WillFund *WillFund
+	// The peer's fee for each lease: its base fee plus its basis
+	// points of the amount
+	LeaseFeeSat uint64
+	// What we pay towards the peer's share of the funding transaction
+	// for each lease
+	FundingFeeSat uint64
+	// Leases needed to cover the query's Blocks
+	Leases uint64
+	// (LeaseFeeSat + FundingFeeSat) * Leases
+	TotalCostSat uint64
+	// The most the peer may charge to route over the channel during a
+	// lease
+	ChannelFeeMaxBaseMsat uint64
+	ChannelFeeMaxPPT      uint32
+}
+
+// The lease's cost as a fraction of the amount leased, per year
+func (o *LeaseOffer) AnnualRate(amountSat uint64) float64 {
+	if amountSat == 0 || o.Leases == 0 {
+		return 0
+	}
+	perLease := float64(o.LeaseFeeSat+o.FundingFeeSat) / float64(amountSat)
+	// about 52596 blocks a year
+	return perLease * 52596 / LeaseBlocks
+}
+
+// Build the fundchannel request leasing {query}'s amount from the
+// node, contributing {amount} of our own. We must already be
+// connected to the node.
+func (o *LeaseOffer) FundChannelRequest(amount *Sat, query *LeaseQuery) (*FundChannelRequest, error) {
+	b := NewFundChannelBuilder().
+		NodeId(o.NodeId).
+		Amount(amount).
+		Lease(NewSat64(query.AmountSat), o.WillFund.CompactLease)
+	if query.FeeRatePerKw != 0 {
+		b.FeeRate(NewFeeRate(PerKw, query.FeeRatePerKw))
+	}
+	return b.Build()
+}
+
+// Cost {node}'s liquidity ad for {query}; nil if it doesn't advertise
+// one or the ad can't be read
+func costLease(node *Node, query *LeaseQuery) *LeaseOffer {
+	ad := node.WillFund
+	if ad == nil || ad.CompactLease == "" {
+		return nil
+	}
+	base, err := ParseMSat(ad.LeaseFeeBaseMsat)
+	if err != nil {
+		return nil
+	}
+	maxBase, err := ParseMSat(ad.ChannelFeeMaxBaseMsat)
+	if err != nil {
+		return nil
+	}
+
+	offer := &LeaseOffer{
+		NodeId:                node.Id,
+		Alias:                 node.Alias,
+		WillFund:              ad,
+		LeaseFeeSat:           base.Value/1000 + query.AmountSat*uint64(ad.LeaseFeeBasis)/10000,
+		FundingFeeSat:         uint64(ad.FundingWeight) * uint64(query.FeeRatePerKw) / 1000,
+		Leases:                1,
+		ChannelFeeMaxBaseMsat: maxBase.Value,
+		ChannelFeeMaxPPT:      ad.ChannelFeeMaxProportionalThousandths,
+	}
+	if query.Blocks > LeaseBlocks {
+		offer.Leases = (uint64(query.Blocks) + LeaseBlocks - 1) / LeaseBlocks
+	}
+	offer.TotalCostSat = (offer.LeaseFeeSat + offer.FundingFeeSat) * offer.Leases
+	return offer
+}
+
+// The liquidity ads among {nodes} which meet {query}, cheapest first.
+// Offers costing the same are ranked by the channel fees they allow
+// the peer, lowest first.
+func FindLeaseOffers(nodes []*Node, query *LeaseQuery) ([]*LeaseOffer, error) {
+	if query == nil || query.AmountSat == 0 {
+		return nil, fmt.Errorf("Must set an amount to lease")
+	}
+	var offers []*LeaseOffer
+	for _, node := range nodes {
+		offer := costLease(node, query)
+		if offer == nil {
+			continue
+		}
+		if query.MaxCostSat != 0 && offer.TotalCostSat > query.MaxCostSat {
+			continue
+		}
+		if query.MaxChannelFeeBaseMsat != 0 && offer.ChannelFeeMaxBaseMsat > query.MaxChannelFeeBaseMsat {
+			continue
+		}
+		if query.MaxChannelFeePPT != 0 && offer.ChannelFeeMaxPPT > query.MaxChannelFeePPT {
+			continue
+		}
+		offers = append(offers, offer)
+	}
+	sort.SliceStable(offers, func(i, j int) bool {
+		a, b := offers[i], offers[j]
+		if a.TotalCostSat != b.TotalCostSat {
+			return a.TotalCostSat < b.TotalCostSat
+		}
+		if a.ChannelFeeMaxPPT != b.ChannelFeeMaxPPT {
+			return a.ChannelFeeMaxPPT < b.ChannelFeeMaxPPT
+		}
+		return a.ChannelFeeMaxBaseMsat < b.ChannelFeeMaxBaseMsat
+	})
+	return offers, nil
+}
+
+// FindLeaseOffers over the nodes in lightningd's gossip. Leaving the
+// query's FeeRatePerKw unset uses the current opening estimate.
+func ScanLeaseOffers(client LightningClient, query *LeaseQuery) ([]*LeaseOffer, error) {
+	if query != nil && query.FeeRatePerKw == 0 {
+		estimate, err := client.FeeRates(PerKw)
+		if err != nil {
+			return nil, err
+		}
+		if estimate.Details != nil {
+			q := *query
+			q.FeeRatePerKw = estimate.Details.Opening
+			query = &q
+		}
+	}
+	nodes, err := client.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	return FindLeaseOffers(nodes, query)
+}
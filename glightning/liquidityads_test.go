@@ -0,0 +1,110 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func leaseNode(id string, baseMsat string, basis, weight, ppt uint32) *glightning.Node {
+	return &glightning.Node{
+		Id: id,
+		WillFund: &glightning.WillFund{
+			LeaseFeeBaseMsat:                     baseMsat,
+			LeaseFeeBasis:                        basis,
+			FundingWeight:                        weight,
+			ChannelFeeMaxBaseMsat:                "5000msat",
+			ChannelFeeMaxProportionalThousandths: ppt,
+			CompactLease:                         "lease-" + id,
+		},
+	}
+}
+
+func leaseNodes() []*glightning.Node {
+	return []*glightning.Node{
+		leaseNode("02aa", "2000000msat", 65, 666, 100),
+		leaseNode("02bb", "1000000msat", 100, 500, 50),
+		leaseNode("02cc", "0msat", 50, 1000, 300),
+		{Id: "02dd"},
+	}
+}
+
+func offerIds(offers []*glightning.LeaseOffer) []string {
+	var ids []string
+	for _, o := range offers {
+		ids = append(ids, o.NodeId)
+	}
+	return ids
+}
+
+func TestFindLeaseOffers(t *testing.T) {
+	query := &glightning.LeaseQuery{AmountSat: 1000000, FeeRatePerKw: 7500}
+	offers, err := glightning.FindLeaseOffers(leaseNodes(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"02cc", "02aa", "02bb"}, offerIds(offers))
+	aa := offers[1]
+	assert.Equal(t, uint64(8500), aa.LeaseFeeSat)
+	assert.Equal(t, uint64(4995), aa.FundingFeeSat)
+	assert.Equal(t, uint64(1), aa.Leases)
+	assert.Equal(t, uint64(13495), aa.TotalCostSat)
+	assert.Equal(t, uint64(5000), aa.ChannelFeeMaxBaseMsat)
+	assert.InDelta(t, 0.176, aa.AnnualRate(query.AmountSat), 0.001)
+
+	query.MaxChannelFeePPT = 200
+	offers, _ = glightning.FindLeaseOffers(leaseNodes(), query)
+	assert.Equal(t, []string{"02aa", "02bb"}, offerIds(offers))
+
+	query.MaxChannelFeePPT = 0
+	query.MaxCostSat = 14000
+	offers, _ = glightning.FindLeaseOffers(leaseNodes(), query)
+	assert.Equal(t, []string{"02cc", "02aa"}, offerIds(offers))
+
+	// three leases cover 10000 blocks
+	query.MaxCostSat = 0
+	query.Blocks = 10000
+	offers, _ = glightning.FindLeaseOffers(leaseNodes(), query)
+	assert.Equal(t, uint64(3), offers[1].Leases)
+	assert.Equal(t, uint64(40485), offers[1].TotalCostSat)
+
+	_, err = glightning.FindLeaseOffers(leaseNodes(), &glightning.LeaseQuery{})
+	assert.EqualError(t, err, "Must set an amount to lease")
+}
+
+func TestScanLeaseOffers(t *testing.T) {
+	peer := "02befaace6e8970aaca34eafe85f30f988e374628ec279d94e7eca8b574b738eb4"
+	ln := mock.New()
+	ln.FeeRatesFunc = func(style glightning.FeeRateStyle) (*glightning.FeeRateEstimate, error) {
+		return &glightning.FeeRateEstimate{Style: style, Details: &glightning.FeeRateDetails{Opening: 2000}}, nil
+	}
+	ln.ListNodesFunc = func() ([]*glightning.Node, error) {
+		return []*glightning.Node{leaseNode(peer, "2000000msat", 65, 666, 100)}, nil
+	}
+
+	query := &glightning.LeaseQuery{AmountSat: 500000}
+	offers, err := glightning.ScanLeaseOffers(ln, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, offers, 1) {
+		// 666 weight at 2000perkw
+		assert.Equal(t, uint64(1332), offers[0].FundingFeeSat)
+	}
+	assert.Zero(t, query.FeeRatePerKw)
+
+	req, err := offers[0].FundChannelRequest(glightning.NewSat(100000), &glightning.LeaseQuery{AmountSat: 500000, FeeRatePerKw: 2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.FundChannelRequest{
+		Id:           peer,
+		Amount:       "100000",
+		FeeRate:      "2000perkw",
+		Announce:     true,
+		RequestAmt:   "500000",
+		CompactLease: "lease-" + peer,
+	}, req)
+}
@@ -0,0 +1,230 @@
+// Package lnrpc serves a gRPC mirror of part of lightningd's API
+// (lightning.proto), backed by a glightning.LightningClient, so
+// services in any language with gRPC support can use a node through
+// generated, typed stubs:
+//
+//	s := grpc.NewServer()
+//	lnrpc.RegisterLightningServer(s, lnrpc.NewServer(lightning))
+//	s.Serve(listener)
+//
+// Go clients can use the generated NewLightningClient. Backed by a
+// *glightning.Lightning, each call to lightningd is scoped to its gRPC
+// call's context, so it's abandoned if the caller cancels or its
+// deadline passes.
+package lnrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative lightning.proto
+
+import (
+	"context"
+	"errors"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/jrpc2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Server struct {
+	UnimplementedLightningServer
+	client glightning.LightningClient
+}
+
+func NewServer(client glightning.LightningClient) *Server {
+	return &Server{client: client}
+}
+
+// The client for a call, scoped to its {ctx}, so cancellation and
+// deadlines reach lightningd's calls
+func (s *Server) with(ctx context.Context) glightning.LightningClient {
+	return glightning.ClientWithContext(ctx, s.client)
+}
+
+// lightningd refusing a call is the caller's problem (bad params, no
+// route, ...), so its errors keep their code and message as
+// FailedPrecondition
+func toStatus(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	var rpcErr *jrpc2.RpcError
+	if errors.As(err, &rpcErr) {
+		if rpcErr.Code == -32602 {
+			return status.Error(codes.InvalidArgument, rpcErr.Message)
+		}
+		return status.Error(codes.FailedPrecondition, rpcErr.Error())
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// lightningd gives amounts as "1000msat" strings, with a plain
+// number in older fields
+func msat(amount string, raw uint64) uint64 {
+	if amount == "" {
+		return raw
+	}
+	m, err := glightning.ParseMSat(amount)
+	if err != nil {
+		return raw
+	}
+	return m.Value
+}
+
+func (s *Server) GetInfo(ctx context.Context, req *GetInfoRequest) (*GetInfoResponse, error) {
+	info, err := s.with(ctx).GetInfo()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &GetInfoResponse{
+		Id:                  info.Id,
+		Alias:               info.Alias,
+		Color:               info.Color,
+		NumPeers:            uint32(info.PeerCount),
+		NumPendingChannels:  uint32(info.PendingChannelCount),
+		NumActiveChannels:   uint32(info.ActiveChannelCount),
+		NumInactiveChannels: uint32(info.InactiveChannelCount),
+		Version:             info.Version,
+		Blockheight:         uint32(info.Blockheight),
+		Network:             info.Network,
+		FeesCollectedMsat:   msat(info.FeesCollected, info.FeesCollectedMilliSatoshis),
+	}, nil
+}
+
+func toInvoice(inv *glightning.Invoice) *Invoice {
+	return &Invoice{
+		Label:              inv.Label,
+		Bolt11:             inv.Bolt11,
+		PaymentHash:        inv.PaymentHash,
+		AmountMsat:         msat(inv.AmountMilliSatoshi, inv.AmountMilliSatoshiRaw),
+		Status:             inv.Status,
+		Description:        inv.Description,
+		ExpiresAt:          inv.ExpiresAt,
+		PayIndex:           inv.PayIndex,
+		AmountReceivedMsat: msat(inv.MilliSatoshiReceived, inv.MilliSatoshiReceivedRaw),
+		PaidAt:             inv.PaidAt,
+		PaymentPreimage:    inv.PaymentPreImage,
+	}
+}
+
+func (s *Server) CreateInvoice(ctx context.Context, req *CreateInvoiceRequest) (*Invoice, error) {
+	if req.Label == "" || req.Description == "" {
+		return nil, status.Error(codes.InvalidArgument, "Must set a label and description")
+	}
+	var inv *glightning.Invoice
+	var err error
+	if req.AmountMsat == 0 {
+		inv, err = s.with(ctx).CreateInvoiceAny(req.Label, req.Description, req.Expiry, req.Fallbacks, req.Preimage, req.ExposePrivateChannels)
+	} else {
+		inv, err = s.with(ctx).CreateInvoice(req.AmountMsat, req.Label, req.Description, req.Expiry, req.Fallbacks, req.Preimage, req.ExposePrivateChannels)
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toInvoice(inv), nil
+}
+
+func (s *Server) GetInvoice(ctx context.Context, req *GetInvoiceRequest) (*Invoice, error) {
+	inv, err := s.with(ctx).GetInvoice(req.Label)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toInvoice(inv), nil
+}
+
+func (s *Server) ListInvoices(ctx context.Context, req *ListInvoicesRequest) (*ListInvoicesResponse, error) {
+	invoices, err := s.with(ctx).ListInvoices()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &ListInvoicesResponse{Invoices: make([]*Invoice, len(invoices))}
+	for i, inv := range invoices {
+		resp.Invoices[i] = toInvoice(inv)
+	}
+	return resp, nil
+}
+
+func (s *Server) Pay(ctx context.Context, req *PayRequest) (*Payment, error) {
+	if req.Bolt11 == "" {
+		return nil, status.Error(codes.InvalidArgument, "Must provide a bolt11 invoice")
+	}
+	payment, err := s.with(ctx).Pay(&glightning.PayRequest{
+		Bolt11:        req.Bolt11,
+		MilliSatoshi:  req.AmountMsat,
+		Label:         req.Label,
+		MaxFeePercent: req.MaxFeePercent,
+		RetryFor:      uint(req.RetryFor),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &Payment{
+		PaymentHash:     payment.PaymentHash,
+		PaymentPreimage: payment.PaymentPreimage,
+		Destination:     payment.Destination,
+		AmountMsat:      msat(payment.AmountMilliSatoshi, payment.AmountMilliSatoshiRaw),
+		AmountSentMsat:  msat(payment.MilliSatoshiSent, payment.MilliSatoshiSentRaw),
+		Status:          payment.Status,
+		Parts:           payment.Parts,
+	}, nil
+}
+
+func (s *Server) ListChannels(ctx context.Context, req *ListChannelsRequest) (*ListChannelsResponse, error) {
+	peers, err := s.with(ctx).ListPeers()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &ListChannelsResponse{}
+	for _, peer := range peers {
+		for _, ch := range peer.Channels {
+			resp.Channels = append(resp.Channels, &Channel{
+				PeerId:         peer.Id,
+				Connected:      peer.Connected,
+				State:          ch.State,
+				ShortChannelId: string(ch.ShortChannelId),
+				ChannelId:      ch.ChannelId,
+				FundingTxid:    ch.FundingTxId,
+				Private:        ch.Private,
+				ToUsMsat:       msat(ch.ToUsMsat, ch.MilliSatoshiToUs),
+				TotalMsat:      msat(ch.TotalMsat, ch.MilliSatoshiTotal),
+				SpendableMsat:  msat(ch.SpendableMsat, ch.SpendableMilliSatoshi),
+				ReceivableMsat: msat(ch.ReceivableMsat, ch.ReceivableMilliSatoshi),
+			})
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) FundChannel(ctx context.Context, req *FundChannelRequest) (*FundChannelResponse, error) {
+	if req.AmountSat == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Must fund with a non-zero amount")
+	}
+	var feerate *glightning.FeeRate
+	if req.Feerate != "" {
+		var err error
+		feerate, err = glightning.ParseFeeRate(req.Feerate)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	result, err := s.with(ctx).FundChannelExt(req.NodeId, glightning.NewSat64(req.AmountSat), feerate, !req.Private, nil, nil)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &FundChannelResponse{
+		Tx:        result.FundingTx,
+		Txid:      result.FundingTxId,
+		ChannelId: result.ChannelId,
+	}, nil
+}
+
+func (s *Server) CloseChannel(ctx context.Context, req *CloseChannelRequest) (*CloseChannelResponse, error) {
+	result, err := s.with(ctx).Close(req.Id, uint(req.UnilateralTimeout), req.Destination)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &CloseChannelResponse{
+		Tx:   result.Tx,
+		Txid: result.TxId,
+		Type: result.Type,
+	}, nil
+}
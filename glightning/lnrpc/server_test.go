@@ -0,0 +1,182 @@
+package lnrpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/lnrpc"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func dial(t *testing.T, ln glightning.LightningClient) lnrpc.LightningClient {
+	listener := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	lnrpc.RegisterLightningServer(s, lnrpc.NewServer(ln))
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return lnrpc.NewLightningClient(conn)
+}
+
+func TestGetInfo(t *testing.T) {
+	ln := mock.New()
+	ln.GetInfoFunc = func() (*glightning.NodeInfo, error) {
+		return &glightning.NodeInfo{
+			Id:                 "02aa",
+			Alias:              "grpc",
+			ActiveChannelCount: 3,
+			Blockheight:        800000,
+			FeesCollected:      "1500msat",
+		}, nil
+	}
+	client := dial(t, ln)
+
+	info, err := client.GetInfo(context.Background(), &lnrpc.GetInfoRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "grpc", info.Alias)
+	assert.Equal(t, uint32(3), info.NumActiveChannels)
+	assert.Equal(t, uint32(800000), info.Blockheight)
+	assert.Equal(t, uint64(1500), info.FeesCollectedMsat)
+}
+
+func TestInvoices(t *testing.T) {
+	ln := mock.New()
+	ln.CreateInvoiceFunc = func(msat uint64, label, description string, expiry uint32, fallbacks []string, preimage string, exposePrivate bool) (*glightning.Invoice, error) {
+		assert.Equal(t, uint64(5000), msat)
+		assert.Equal(t, uint32(600), expiry)
+		return &glightning.Invoice{Label: label, Bolt11: "lnbcrt50n1", Status: "unpaid", AmountMilliSatoshi: "5000msat"}, nil
+	}
+	ln.CreateInvoiceAnyFunc = func(label, description string, expiry uint32, fallbacks []string, preimage string, exposePrivate bool) (*glightning.Invoice, error) {
+		return &glightning.Invoice{Label: label, Bolt11: "lnbcrt1"}, nil
+	}
+	ln.ListInvoicesFunc = func() ([]*glightning.Invoice, error) {
+		return []*glightning.Invoice{
+			{Label: "a", Status: "paid", MilliSatoshiReceived: "7000msat"},
+			{Label: "b", Status: "expired"},
+		}, nil
+	}
+	client := dial(t, ln)
+	ctx := context.Background()
+
+	inv, err := client.CreateInvoice(ctx, &lnrpc.CreateInvoiceRequest{AmountMsat: 5000, Label: "order-7", Description: "coffee", Expiry: 600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "lnbcrt50n1", inv.Bolt11)
+	assert.Equal(t, uint64(5000), inv.AmountMsat)
+
+	inv, err = client.CreateInvoice(ctx, &lnrpc.CreateInvoiceRequest{Label: "tip", Description: "any"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "lnbcrt1", inv.Bolt11)
+	assert.Equal(t, 1, ln.CallCount("CreateInvoiceAny"))
+
+	_, err = client.CreateInvoice(ctx, &lnrpc.CreateInvoiceRequest{AmountMsat: 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	list, err := client.ListInvoices(ctx, &lnrpc.ListInvoicesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, list.Invoices, 2)
+	assert.Equal(t, uint64(7000), list.Invoices[0].AmountReceivedMsat)
+	assert.Equal(t, "expired", list.Invoices[1].Status)
+}
+
+func TestPayError(t *testing.T) {
+	ln := mock.New()
+	ln.PayFunc = func(req *glightning.PayRequest) (*glightning.PaymentSuccess, error) {
+		assert.Equal(t, "lnbcrt1", req.Bolt11)
+		assert.Equal(t, float32(0.5), req.MaxFeePercent)
+		return nil, &jrpc2.RpcError{Code: 205, Message: "Could not find a route"}
+	}
+	client := dial(t, ln)
+
+	_, err := client.Pay(context.Background(), &lnrpc.PayRequest{Bolt11: "lnbcrt1", MaxFeePercent: 0.5})
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.FailedPrecondition, st.Code())
+	assert.Equal(t, "205:Could not find a route", st.Message())
+}
+
+func TestChannels(t *testing.T) {
+	ln := mock.New()
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return []*glightning.Peer{
+			{Id: "02aa", Connected: true, Channels: []*glightning.PeerChannel{
+				{State: "CHANNELD_NORMAL", ShortChannelId: "1x2x3", ToUsMsat: "400000msat", TotalMsat: "1000000msat"},
+			}},
+			{Id: "02bb"},
+		}, nil
+	}
+	ln.FundChannelExtFunc = func(id string, amount *glightning.Sat, feerate *glightning.FeeRate, announce bool, minConf *uint16, pushMSat *glightning.MSat) (*glightning.FundChannelResult, error) {
+		assert.Equal(t, uint64(100000), amount.Value)
+		assert.Equal(t, "urgent", feerate.String())
+		assert.False(t, announce)
+		return &glightning.FundChannelResult{FundingTxId: "cc", ChannelId: "dd"}, nil
+	}
+	client := dial(t, ln)
+	ctx := context.Background()
+
+	channels, err := client.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, channels.Channels, 1)
+	assert.Equal(t, "02aa", channels.Channels[0].PeerId)
+	assert.Equal(t, "1x2x3", channels.Channels[0].ShortChannelId)
+	assert.Equal(t, uint64(400000), channels.Channels[0].ToUsMsat)
+
+	funded, err := client.FundChannel(ctx, &lnrpc.FundChannelRequest{NodeId: "02bb", AmountSat: 100000, Feerate: "urgent", Private: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "cc", funded.Txid)
+
+	_, err = client.FundChannel(ctx, &lnrpc.FundChannelRequest{NodeId: "02bb", AmountSat: 1, Feerate: "fast"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPayCancelled(t *testing.T) {
+	lightning := glightning.NewLightning()
+	conn, node := net.Pipe()
+	lightning.StartConn(conn)
+	t.Cleanup(lightning.Shutdown)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// lightningd never answers; the caller gives up once it's sent
+		node.Read(make([]byte, 4096))
+		cancel()
+	}()
+	errs := make(chan error, 1)
+	go func() {
+		_, err := lnrpc.NewServer(lightning).Pay(ctx, &lnrpc.PayRequest{Bolt11: "lnbcrt1"})
+		errs <- err
+	}()
+	select {
+	case err := <-errs:
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.Canceled, st.Code())
+	case <-time.After(5 * time.Second):
+		t.Fatal("Pay still waiting on lightningd after being cancelled")
+	}
+}
@@ -0,0 +1,120 @@
+// Package lnurl bridges LNURL flows (https://github.com/lnurl/luds) to
+// a node: serving LNURL-pay for it, and paying or withdrawing from
+// other services' LNURLs with its invoices and payments.
+package lnurl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+)
+
+const hrp = "lnurl"
+
+// Used for every request to an LNURL service
+var HTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// An LNURL service's {"status": "ERROR", "reason": ...} reply
+type Error struct {
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("LNURL service error: %s", e.Reason)
+}
+
+// Encode {rawurl} as a bech32 "lnurl1..." string
+func Encode(rawurl string) (string, error) {
+	data, err := bech32.ConvertBits([]byte(rawurl), 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32.Encode(hrp, data)
+}
+
+// The URL an LNURL refers to. Accepts bech32 LNURLs, with or without
+// a "lightning:" prefix, LUD-17 lnurlp:// and lnurlw:// URLs, and
+// plain https URLs.
+func Decode(lnurl string) (string, error) {
+	lnurl = strings.TrimSpace(lnurl)
+	if strings.HasPrefix(strings.ToLower(lnurl), "lightning:") {
+		lnurl = lnurl[len("lightning:"):]
+	}
+	lower := strings.ToLower(lnurl)
+	for _, scheme := range []string{"lnurlp://", "lnurlw://"} {
+		if strings.HasPrefix(lower, scheme) {
+			rest := lnurl[len(scheme):]
+			host := strings.SplitN(rest, "/", 2)[0]
+			if strings.HasSuffix(strings.SplitN(host, ":", 2)[0], ".onion") {
+				return "http://" + rest, nil
+			}
+			return "https://" + rest, nil
+		}
+	}
+	if strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://") {
+		return lnurl, nil
+	}
+
+	prefix, data, err := bech32.DecodeNoLimit(lnurl)
+	if err != nil {
+		return "", err
+	}
+	if prefix != hrp {
+		return "", fmt.Errorf("Not an LNURL: prefix is %s", prefix)
+	}
+	raw, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// GET {rawurl} with {query} added, into {result}
+func fetch(rawurl string, query url.Values, result interface{}) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	for k, v := range query {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	resp, err := HTTPClient.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var status struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &status); err == nil && strings.ToUpper(status.Status) == "ERROR" {
+		return &Error{status.Reason}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("LNURL service returned %s", resp.Status)
+	}
+	return json.Unmarshal(body, result)
+}
+
+func writeError(w http.ResponseWriter, reason string) {
+	writeJSON(w, map[string]string{"status": "ERROR", "reason": reason})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
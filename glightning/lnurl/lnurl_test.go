@@ -0,0 +1,173 @@
+package lnurl_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/lnurl"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	// LUD-01's example
+	u, err := lnurl.Decode("lightning:LNURL1DP68GURN8GHJ7UM9WFMXJCM99E3K7MF0V9CXJ0M385EKVCENXC6R2C35XVUKXEFCV5MKVV34X5EKZD3EV56NYD3HXQURZEPEXEJXXEPNXSCRVWFNV9NXZCN9XQ6XYEFHVGCXXCMYXYMNSERXFQ5FNS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "https://service.com/api?q=3fc3645b439ce8e7f2553a69e5267081d96dcd340693afabe04be7b0ccd178df", u)
+
+	encoded, err := lnurl.Encode(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "lnurl1dp68gurn8ghj7um9wfmxjcm99e3k7mf0v9cxj0m385ekvcenxc6r2c35xvukxefcv5mkvv34x5ekzd3ev56nyd3hxqurzepexejxxepnxscrvwfnv9nxzcn9xq6xyefhvgcxxcmyxymnserxfq5fns", encoded)
+
+	u, err = lnurl.Decode("lnurlp://service.com/pay/alice")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://service.com/pay/alice", u)
+	u, err = lnurl.Decode("lnurlw://abcdef.onion/withdraw")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://abcdef.onion/withdraw", u)
+
+	_, err = lnurl.Decode("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+	assert.Error(t, err)
+}
+
+func TestPayServer(t *testing.T) {
+	metadata := lnurl.TextMetadata("coffee")
+	ln := mock.New()
+	ln.CreateInvoiceDescHashOnlyFunc = func(msat uint64, label, description string, expiry uint32) (*glightning.Invoice, error) {
+		assert.Equal(t, uint64(5000), msat)
+		assert.Contains(t, label, "lnurlpay-")
+		assert.Equal(t, metadata, description)
+		return &glightning.Invoice{Bolt11: "lnbcrt50n1"}, nil
+	}
+	server := httptest.NewServer(nil)
+	defer server.Close()
+	pay := lnurl.NewPayServer(ln, server.URL, metadata, 1000, 100000)
+	server.Config.Handler = pay
+
+	var params lnurl.PayParams
+	get(t, server.URL, &params)
+	assert.Equal(t, &lnurl.PayParams{
+		Callback:    server.URL,
+		MinSendable: 1000,
+		MaxSendable: 100000,
+		Metadata:    `[["text/plain","coffee"]]`,
+		Tag:         "payRequest",
+	}, &params)
+
+	var values map[string]interface{}
+	get(t, server.URL+"?amount=5000", &values)
+	assert.Equal(t, "lnbcrt50n1", values["pr"])
+
+	get(t, server.URL+"?amount=5", &values)
+	assert.Equal(t, "ERROR", values["status"])
+	assert.Equal(t, 1, ln.CallCount("CreateInvoiceDescHashOnly"))
+}
+
+func TestPay(t *testing.T) {
+	metadata := lnurl.TextMetadata("coffee")
+	hash := sha256.Sum256([]byte(metadata))
+
+	service := mock.New()
+	service.CreateInvoiceDescHashOnlyFunc = func(msat uint64, label, description string, expiry uint32) (*glightning.Invoice, error) {
+		return &glightning.Invoice{Bolt11: "lnbcrt50n1"}, nil
+	}
+	server := httptest.NewServer(nil)
+	defer server.Close()
+	server.Config.Handler = lnurl.NewPayServer(service, server.URL, metadata, 1000, 100000)
+
+	ln := mock.New()
+	descHash := hex.EncodeToString(hash[:])
+	ln.DecodeBolt11Func = func(bolt11 string) (*glightning.DecodedBolt11, error) {
+		assert.Equal(t, "lnbcrt50n1", bolt11)
+		return &glightning.DecodedBolt11{AmountMsat: "5000msat", DescriptionHash: descHash}, nil
+	}
+	ln.PayBoltFunc = func(bolt11 string) (*glightning.PaymentSuccess, error) {
+		return &glightning.PaymentSuccess{SendPayFields: glightning.SendPayFields{Status: "complete"}}, nil
+	}
+	lnurlPay, _ := lnurl.Encode(server.URL)
+
+	payment, err := lnurl.Pay(ln, lnurlPay, 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "complete", payment.Status)
+
+	_, err = lnurl.Pay(ln, lnurlPay, 100001)
+	assert.EqualError(t, err, "Amount 100001 msat is outside of 1000-100000 msat")
+
+	// an invoice for other metadata isn't paid
+	descHash = "00"
+	_, err = lnurl.Pay(ln, lnurlPay, 5000)
+	assert.EqualError(t, err, "Invoice description_hash does not match metadata")
+	assert.Equal(t, 1, ln.CallCount("PayBolt"))
+}
+
+func TestWithdraw(t *testing.T) {
+	var submitted string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/withdraw", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&lnurl.WithdrawParams{
+			Callback:           server.URL + "/callback?session=9",
+			K1:                 "k1value",
+			DefaultDescription: "faucet",
+			MinWithdrawable:    1000,
+			MaxWithdrawable:    20000,
+			Tag:                "withdrawRequest",
+		})
+	})
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "9", q.Get("session"))
+		if q.Get("k1") != "k1value" {
+			w.Write([]byte(`{"status": "ERROR", "reason": "Bad k1"}`))
+			return
+		}
+		submitted = q.Get("pr")
+		w.Write([]byte(`{"status": "OK"}`))
+	})
+
+	ln := mock.New()
+	ln.CreateInvoiceFunc = func(msat uint64, label, description string, expiry uint32, fallbacks []string, preimage string, exposePrivate bool) (*glightning.Invoice, error) {
+		assert.Equal(t, uint64(20000), msat)
+		assert.Equal(t, "faucet", description)
+		return &glightning.Invoice{Label: label, Bolt11: "lnbcrt200n1"}, nil
+	}
+
+	invoice, err := lnurl.Withdraw(ln, server.URL+"/withdraw", "faucet-1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "faucet-1", invoice.Label)
+	assert.Equal(t, "lnbcrt200n1", submitted)
+
+	_, err = lnurl.Withdraw(ln, server.URL+"/withdraw", "faucet-2", 50000)
+	assert.Error(t, err)
+
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "ERROR", "reason": "Already claimed"}`))
+	})
+	_, err = lnurl.Withdraw(ln, server.URL+"/broken", "faucet-3", 0)
+	assert.EqualError(t, err, "LNURL service error: Already claimed")
+}
+
+func get(t *testing.T, u string, result interface{}) {
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		t.Fatal(err)
+	}
+}
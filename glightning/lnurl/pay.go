@@ -0,0 +1,147 @@
+package lnurl
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/elementsproject/glightning/glightning"
+)
+
+const payTag = "payRequest"
+
+// The first reply to an LNURL-pay, describing what may be paid
+type PayParams struct {
+	Callback    string `json:"callback"`
+	MinSendable uint64 `json:"minSendable"`
+	MaxSendable uint64 `json:"maxSendable"`
+	// JSON array of [mime type, content] pairs; the invoice's
+	// description_hash is its sha256
+	Metadata string `json:"metadata"`
+	Tag      string `json:"tag"`
+}
+
+type payValues struct {
+	Invoice string        `json:"pr"`
+	Routes  []interface{} `json:"routes"`
+}
+
+// LNURL-pay metadata describing a payment with {text}
+func TextMetadata(text string) string {
+	metadata, _ := json.Marshal([][]string{{"text/plain", text}})
+	return string(metadata)
+}
+
+// Pay {msat} to the LNURL-pay {lnurl}. The service's invoice is
+// checked against the amount and metadata before it's paid.
+func Pay(client glightning.LightningClient, lnurl string, msat uint64) (*glightning.PaymentSuccess, error) {
+	u, err := Decode(lnurl)
+	if err != nil {
+		return nil, err
+	}
+	var params PayParams
+	if err := fetch(u, nil, &params); err != nil {
+		return nil, err
+	}
+	if params.Tag != payTag {
+		return nil, fmt.Errorf("Not an LNURL-pay: tag is %s", params.Tag)
+	}
+	if msat < params.MinSendable || msat > params.MaxSendable {
+		return nil, fmt.Errorf("Amount %d msat is outside of %d-%d msat", msat, params.MinSendable, params.MaxSendable)
+	}
+
+	var values payValues
+	if err := fetch(params.Callback, url.Values{"amount": {strconv.FormatUint(msat, 10)}}, &values); err != nil {
+		return nil, err
+	}
+	decoded, err := client.DecodeBolt11(values.Invoice)
+	if err != nil {
+		return nil, err
+	}
+	amount := decoded.MilliSatoshis
+	if decoded.AmountMsat != "" {
+		m, err := glightning.ParseMSat(decoded.AmountMsat)
+		if err != nil {
+			return nil, err
+		}
+		amount = m.Value
+	}
+	if amount != msat {
+		return nil, fmt.Errorf("Invoice is for %d msat, not %d msat", amount, msat)
+	}
+	hash := sha256.Sum256([]byte(params.Metadata))
+	if decoded.DescriptionHash != hex.EncodeToString(hash[:]) {
+		return nil, fmt.Errorf("Invoice description_hash does not match metadata")
+	}
+	return client.PayBolt(values.Invoice)
+}
+
+// Serves LNURL-pay for our node, at the URL {Callback}: the first
+// request gets the PayParams, and requests with an amount get an
+// invoice for it, committing to {Metadata}.
+type PayServer struct {
+	client      glightning.LightningClient
+	Callback    string
+	Metadata    string
+	MinSendable uint64
+	MaxSendable uint64
+	// Invoice expiry in seconds; lightningd's default if zero
+	Expiry uint32
+	// Invoices are labelled with this and a random suffix
+	LabelPrefix string
+}
+
+func NewPayServer(client glightning.LightningClient, callback, metadata string, minSendable, maxSendable uint64) *PayServer {
+	return &PayServer{
+		client:      client,
+		Callback:    callback,
+		Metadata:    metadata,
+		MinSendable: minSendable,
+		MaxSendable: maxSendable,
+		LabelPrefix: "lnurlpay-",
+	}
+}
+
+func (s *PayServer) Params() *PayParams {
+	return &PayParams{
+		Callback:    s.Callback,
+		MinSendable: s.MinSendable,
+		MaxSendable: s.MaxSendable,
+		Metadata:    s.Metadata,
+		Tag:         payTag,
+	}
+}
+
+func (s *PayServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	amount := r.URL.Query().Get("amount")
+	if amount == "" {
+		writeJSON(w, s.Params())
+		return
+	}
+	msat, err := strconv.ParseUint(amount, 10, 64)
+	if err != nil {
+		writeError(w, "Invalid amount")
+		return
+	}
+	if msat < s.MinSendable || msat > s.MaxSendable {
+		writeError(w, fmt.Sprintf("Amount must be between %d and %d msat", s.MinSendable, s.MaxSendable))
+		return
+	}
+	invoice, err := s.client.CreateInvoiceDescHashOnly(msat, s.LabelPrefix+randomSuffix(), s.Metadata, s.Expiry)
+	if err != nil {
+		writeError(w, err.Error())
+		return
+	}
+	writeJSON(w, &payValues{Invoice: invoice.Bolt11, Routes: []interface{}{}})
+}
+
+func randomSuffix() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,66 @@
+package lnurl
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/elementsproject/glightning/glightning"
+)
+
+const withdrawTag = "withdrawRequest"
+
+// The first reply to an LNURL-withdraw, describing what may be
+// withdrawn
+type WithdrawParams struct {
+	Callback           string `json:"callback"`
+	K1                 string `json:"k1"`
+	DefaultDescription string `json:"defaultDescription"`
+	MinWithdrawable    uint64 `json:"minWithdrawable"`
+	MaxWithdrawable    uint64 `json:"maxWithdrawable"`
+	Tag                string `json:"tag"`
+}
+
+// Withdraw {msat} (or as much as allowed, if zero) from the
+// LNURL-withdraw {lnurl}, by creating an invoice labelled {label} and
+// handing it to the service. The service pays it asynchronously; wait
+// on the returned invoice to know when the funds arrive.
+func Withdraw(client glightning.LightningClient, lnurl, label string, msat uint64) (*glightning.Invoice, error) {
+	u, err := Decode(lnurl)
+	if err != nil {
+		return nil, err
+	}
+	var params WithdrawParams
+	if err := fetch(u, nil, &params); err != nil {
+		return nil, err
+	}
+	if params.Tag != withdrawTag {
+		return nil, fmt.Errorf("Not an LNURL-withdraw: tag is %s", params.Tag)
+	}
+	if msat == 0 {
+		msat = params.MaxWithdrawable
+	}
+	if msat < params.MinWithdrawable || msat > params.MaxWithdrawable {
+		return nil, fmt.Errorf("Amount %d msat is outside of %d-%d msat", msat, params.MinWithdrawable, params.MaxWithdrawable)
+	}
+
+	description := params.DefaultDescription
+	if description == "" {
+		description = "LNURL-withdraw"
+	}
+	invoice, err := client.CreateInvoice(msat, label, description, 0, nil, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Status string `json:"status"`
+	}
+	err = fetch(params.Callback, url.Values{"k1": {params.K1}, "pr": {invoice.Bolt11}}, &result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status != "OK" {
+		return nil, fmt.Errorf("LNURL-withdraw not accepted: status %s", result.Status)
+	}
+	return invoice, nil
+}
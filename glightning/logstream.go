@@ -0,0 +1,172 @@
+package glightning
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How often a LogStream from Lightning.StreamLogs polls getlog
+var LogPollInterval = time.Second
+
+// Log entries as lightningd writes them, at or above the level the
+// stream was opened with. Read them from C until Stop.
+//
+// A plugin's stream follows log notifications:
+//
+//	logs := plugin.StreamLogs(glightning.Unusual)
+//	...
+//	for entry := range logs.C {
+//
+// while a plain client's polls getlog, delivering the entries added
+// since its last look:
+//
+//	logs := ln.StreamLogs(glightning.Info)
+type LogStream struct {
+	C <-chan *LogEntry
+
+	c        chan *LogEntry
+	minRank  int
+	done     chan struct{}
+	exited   chan struct{}
+	stopOnce sync.Once
+	// held while sending on, or closing, c
+	sending sync.Mutex
+
+	mu  sync.Mutex
+	err error
+}
+
+func newLogStream(level LogLevel) *LogStream {
+	c := make(chan *LogEntry, 64)
+	return &LogStream{
+		C:       c,
+		c:       c,
+		minRank: logLevelRank(level.String()),
+		done:    make(chan struct{}),
+		exited:  make(chan struct{}),
+	}
+}
+
+// Stream the node's log from its notifications. Like the other
+// Subscribe* methods, call before the plugin is started, at most once.
+func (p *Plugin) StreamLogs(level LogLevel) *LogStream {
+	s := newLogStream(level)
+	close(s.exited)
+	p.SubscribeLog(func(entry *LogEntry) {
+		s.deliver(entry)
+	})
+	return s
+}
+
+// Stream the node's log by polling getlog every LogPollInterval.
+// Only entries logged after the stream is opened are delivered.
+func (l *Lightning) StreamLogs(level LogLevel) *LogStream {
+	s := newLogStream(level)
+	go s.poll(l, level)
+	return s
+}
+
+// Stop delivering entries, and close C
+func (s *LogStream) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		<-s.exited
+		s.sending.Lock()
+		close(s.c)
+		s.sending.Unlock()
+	})
+}
+
+// The error from the last attempt to poll getlog, if it failed
+func (s *LogStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Send {entry} on, if it's at the stream's level. Blocks while C is
+// full, until Stop.
+func (s *LogStream) deliver(entry *LogEntry) {
+	if logLevelRank(entry.Level) < s.minRank {
+		return
+	}
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	select {
+	case s.c <- entry:
+	case <-s.done:
+	}
+}
+
+func (s *LogStream) poll(l *Lightning, level LogLevel) {
+	defer close(s.exited)
+	// the time of the last entry delivered, and how many entries
+	// logged at that time have been
+	var last float64
+	var atLast int
+	first := true
+	for {
+		logs, err := l.GetLog(level)
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+		if err == nil {
+			seen := 0
+			for i := range logs.Logs {
+				log := &logs.Logs[i]
+				if log.Type == "SKIPPED" {
+					continue
+				}
+				at, err := strconv.ParseFloat(log.Time, 64)
+				if err != nil || at < last {
+					continue
+				}
+				if at > last {
+					last, atLast, seen = at, 0, 0
+				}
+				if seen++; seen <= atLast {
+					continue
+				}
+				atLast++
+				if !first {
+					s.deliver(&LogEntry{
+						Level:   strings.ToLower(log.Type),
+						Time:    log.Time,
+						Source:  log.Source,
+						Message: log.Message,
+					})
+				}
+			}
+			first = false
+		}
+
+		select {
+		case <-time.After(LogPollInterval):
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Orders log levels by severity; unknown levels rank with info
+func logLevelRank(level string) int {
+	switch strings.ToLower(level) {
+	case "io", "io_in", "io_out":
+		return 0
+	case "debug":
+		return 1
+	case "unusual":
+		return 3
+	case "broken":
+		return 4
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,97 @@
+package glightning_test
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func receiveLog(t *testing.T, logs *glightning.LogStream) *glightning.LogEntry {
+	select {
+	case entry := <-logs.C:
+		return entry
+	case <-time.After(1 * time.Second):
+		t.Fatal("timed out waiting for log entry")
+	}
+	return nil
+}
+
+func TestPluginStreamLogs(t *testing.T) {
+	plugin := glightning.NewPlugin(nullInitFunc)
+	logs := plugin.StreamLogs(glightning.Unusual)
+	defer logs.Stop()
+
+	notification := `{"jsonrpc":"2.0","method":"log","params":{"log":{"level":"%s","time":"1714564800.123","source":"lightningd","log":"%s"}}}` + "\n\n"
+	msgs := fmt.Sprintf(notification, "debug", "Adding block 850000") +
+		fmt.Sprintf(notification, "unusual", "Peer transient failure") +
+		fmt.Sprintf(notification, "broken", "Database corrupt")
+	runTest(t, plugin, msgs, "")
+
+	var got []string
+	for i := 0; i < 2; i++ {
+		entry := receiveLog(t, logs)
+		assert.Equal(t, "lightningd", entry.Source)
+		got = append(got, entry.Level+": "+entry.Message)
+	}
+	sort.Strings(got)
+	assert.Equal(t, []string{"broken: Database corrupt", "unusual: Peer transient failure"}, got)
+}
+
+func TestStreamLogs(t *testing.T) {
+	glightning.LogPollInterval = time.Millisecond
+	defer func() { glightning.LogPollInterval = time.Second }()
+
+	logs := []string{
+		`{"type":"INFO","time":"7.25","source":"lightningd","log":"Server started"}`,
+		`{"type":"SKIPPED","num_skipped":12}`,
+		`{"type":"UNUSUAL","time":"9.5","source":"chan#1","log":"Peer permanent failure"}`,
+		`{"type":"INFO","time":"9.5","source":"chan#2","log":"Peer reconnected"}`,
+		`{"type":"INFO","time":"11.0","source":"gossipd","log":"Pruned 3 channels"}`,
+	}
+	getlog := func(n int) string {
+		list := logs[0]
+		for _, log := range logs[1:n] {
+			list += "," + log
+		}
+		return `{"created_at":"1714564800.0","bytes_used":1024,"bytes_max":10485760,"log":[` + list + `]}`
+	}
+	// what each poll sees: the second adds the first entry at 9.5,
+	// the third the other at 9.5 and one later
+	replies := []string{getlog(2), getlog(3), getlog(5)}
+
+	lightning, requestQ, replyQ := startupServer(t)
+	go func() {
+		for id := 1; ; id++ {
+			request := <-requestQ
+			if id == 1 {
+				assert.Equal(t, `{"jsonrpc":"2.0","method":"getlog","params":{"level":"info"},"id":1}`, string(request))
+			}
+			reply := replies[len(replies)-1]
+			if id <= len(replies) {
+				reply = replies[id-1]
+			}
+			replyQ <- []byte(wrapResult(id, reply) + "\n\n")
+		}
+	}()
+
+	stream := lightning.StreamLogs(glightning.Info)
+	var got []string
+	for i := 0; i < 3; i++ {
+		entry := receiveLog(t, stream)
+		got = append(got, entry.Time+" "+entry.Level+" "+entry.Source+": "+entry.Message)
+	}
+	assert.Equal(t, []string{
+		"9.5 unusual chan#1: Peer permanent failure",
+		"9.5 info chan#2: Peer reconnected",
+		"11.0 info gossipd: Pruned 3 channels",
+	}, got)
+
+	stream.Stop()
+	_, open := <-stream.C
+	assert.False(t, open)
+	assert.Nil(t, stream.Err())
+}
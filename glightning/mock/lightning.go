@@ -0,0 +1,1854 @@
+// Package mock provides a programmable fake of glightning's
+// LightningClient, for unit testing code without a running node.
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/network"
+	"github.com/elementsproject/glightning/glightning/tlv"
+	"github.com/elementsproject/glightning/jrpc2"
+)
+
+// Returned by any call whose XxxFunc hasn't been set
+var ErrNotMocked = errors.New("method not mocked")
+
+// Lightning is a fake glightning.LightningClient. Set the XxxFunc
+// field for each call the code under test is expected to make;
+// calling a method whose func is unset returns ErrNotMocked (or the
+// zero value, for methods that don't return an error).
+//
+// Every call is recorded by method name, see Calls.
+type Lightning struct {
+	mu    sync.Mutex
+	calls []string
+
+	IsUpFunc                             func() bool
+	RequestFunc                          func(m jrpc2.Method, resp interface{}) error
+	RequestRawFunc                       func(m jrpc2.Method, resp interface{}) (json.RawMessage, error)
+	CallFunc                             func(method string, params map[string]interface{}) (json.RawMessage, error)
+	CallIntoFunc                         func(method string, params interface{}, result interface{}) error
+	ListConfigsFunc                      func() (map[string]interface{}, error)
+	GetConfigFunc                        func(config string) (interface{}, error)
+	GetPeerFunc                          func(peerId string) (*glightning.Peer, error)
+	GetPeerWithLogsFunc                  func(peerId string, level glightning.LogLevel) (*glightning.Peer, error)
+	ListPeersWithLogsFunc                func(level glightning.LogLevel) ([]*glightning.Peer, error)
+	ListPeersFunc                        func() ([]*glightning.Peer, error)
+	GetNodeFunc                          func(nodeId string) (*glightning.Node, error)
+	ListNodesFunc                        func() ([]*glightning.Node, error)
+	GetRouteSimpleFunc                   func(peerId string, msats uint64, riskfactor float32) ([]glightning.RouteHop, error)
+	GetRouteFunc                         func(peerId string, msats uint64, riskfactor float32, cltv uint, fromId string, fuzzpercent float32, exclude []string, maxHops int32) ([]glightning.RouteHop, error)
+	SendOnionFunc                        func(onion string, hop glightning.FirstHop, paymentHash string) (*glightning.SendPayFields, error)
+	SendOnionWithDetailsFunc             func(onion string, hop glightning.FirstHop, paymentHash string, label string, secrets []string, partId *uint64) (*glightning.SendPayFields, error)
+	CreateOnionFunc                      func(hops []glightning.Hop, paymentHash, sessionKey string) (*glightning.CreateOnionResponse, error)
+	SendOnionMessageFunc                 func(firstId, blinding string, hops []*glightning.OnionMessageHop) error
+	InjectOnionMessageFunc               func(pathKey, message string) error
+	GetChannelFunc                       func(shortChanId string) ([]*glightning.Channel, error)
+	ListChannelsBySourceFunc             func(nodeId string) ([]*glightning.Channel, error)
+	ListChannelsByDestinationFunc        func(nodeId string) ([]*glightning.Channel, error)
+	ListChannelsFunc                     func() ([]*glightning.Channel, error)
+	CreateInvoiceAnyFunc                 func(label, description string, expirySeconds uint32, fallbacks []string, preimage string, exposePrivateChans bool) (*glightning.Invoice, error)
+	CreateInvoiceFunc                    func(msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, willExposePrivateChans bool) (*glightning.Invoice, error)
+	CreateInvoiceExposingFunc            func(msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, exposePrivChans []string) (*glightning.Invoice, error)
+	CreateInvoiceWithCltvExpiryFunc      func(msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, willExposePrivateChans bool, cltv uint32) (*glightning.Invoice, error)
+	InvoiceFunc                          func(msat uint64, label, description string) (*glightning.Invoice, error)
+	CreateInvoiceDescHashOnlyFunc        func(msat uint64, label, description string, expirySeconds uint32) (*glightning.Invoice, error)
+	CreateInvoiceWithOptionsFunc         func(req *glightning.InvoiceRequest) (*glightning.Invoice, error)
+	ListInvoicesFunc                     func() ([]*glightning.Invoice, error)
+	GetInvoiceFunc                       func(label string) (*glightning.Invoice, error)
+	GetInvoiceByHashFunc                 func(paymentHash string) (*glightning.Invoice, error)
+	ListInvoicesFilteredFunc             func(req *glightning.ListInvoiceRequest) ([]*glightning.Invoice, error)
+	DeleteInvoiceFunc                    func(label, status string) (*glightning.Invoice, error)
+	DeleteInvoiceDescriptionFunc         func(label, status string) (*glightning.Invoice, error)
+	WaitAnyInvoiceFunc                   func(lastPayIndex uint) (*glightning.Invoice, error)
+	WaitAnyInvoiceTimeoutFunc            func(lastPayIndex uint, timeout uint) (*glightning.Invoice, error)
+	WaitInvoiceFunc                      func(label string) (*glightning.Invoice, error)
+	DeleteExpiredInvoicesSinceFunc       func(unixTime uint64) error
+	DisableInvoiceAutocleanFunc          func() error
+	SetInvoiceAutocleanFunc              func(intervalSeconds, expiredBySeconds uint32) error
+	DecodeBolt11Func                     func(bolt11 string) (*glightning.DecodedBolt11, error)
+	DecodePayFunc                        func(bolt11, desc string) (*glightning.DecodedBolt11, error)
+	ListPayStatusesFunc                  func() ([]glightning.PayStatus, error)
+	GetPayStatusFunc                     func(bolt11 string) (*glightning.PayStatus, error)
+	HelpFunc                             func() ([]*glightning.Command, error)
+	HelpForFunc                          func(command string) (*glightning.Command, error)
+	StopFunc                             func() (string, error)
+	DeprecationsFunc                     func(enable bool) error
+	GetLogFunc                           func(level glightning.LogLevel) (*glightning.LogResponse, error)
+	DevHashFunc                          func(secret string) (string, error)
+	DevCrashFunc                         func() (interface{}, error)
+	DevQueryShortChanIdsFunc             func(peerId string, shortChanIds []string) (*glightning.QueryShortChannelIdsResponse, error)
+	GetInfoFunc                          func() (*glightning.NodeInfo, error)
+	SignMessageFunc                      func(message string) (*glightning.SignedMessage, error)
+	CheckMessageFunc                     func(message, zbase string) (bool, string, error)
+	CheckMessageVerifyFunc               func(message, zbase, pubkey string) (bool, error)
+	SendPayLiteFunc                      func(route []glightning.RouteHop, paymentHash string) (*glightning.SendPayResult, error)
+	SendPayFunc                          func(route []glightning.RouteHop, paymentHash, label string, msat *uint64, bolt11 string, paymentSecret string, partId uint64) (*glightning.SendPayResult, error)
+	SendPayPartFunc                      func(req *glightning.SendPayRequest) (*glightning.SendPayResult, error)
+	WaitSendPayFunc                      func(paymentHash string, timeout uint) (*glightning.SendPayFields, error)
+	WaitSendPayPartFunc                  func(paymentHash string, timeout uint, partId uint64) (*glightning.SendPayFields, error)
+	WaitSendPayPartInGroupFunc           func(paymentHash string, timeout uint, partId, groupId uint64) (*glightning.SendPayFields, error)
+	PayBoltFunc                          func(bolt11 string) (*glightning.PaymentSuccess, error)
+	PayPartialFunc                       func(bolt11 string, partial *glightning.MSat) (*glightning.PaymentSuccess, error)
+	PayFunc                              func(req *glightning.PayRequest) (*glightning.PaymentSuccess, error)
+	KeySendFunc                          func(destination string, msat uint64, label string, extraTlvs []*tlv.Record) (*glightning.PaymentSuccess, error)
+	ListPaysFunc                         func() ([]glightning.PaymentFields, error)
+	ListPaysToBolt11Func                 func(bolt11 string) ([]glightning.PaymentFields, error)
+	ListSendPaysAllFunc                  func() ([]glightning.SendPayFields, error)
+	ListSendPaysFunc                     func(bolt11 string) ([]glightning.SendPayFields, error)
+	ListSendPaysByHashFunc               func(paymentHash string) ([]glightning.SendPayFields, error)
+	ListTransactionsFunc                 func() ([]glightning.Transaction, error)
+	BkprListAccountEventsFunc            func(account string) ([]*glightning.BkprAccountEvent, error)
+	StaticBackupFunc                     func() ([]string, error)
+	EmergencyRecoverFunc                 func() ([]string, error)
+	RecoverChannelFunc                   func(scbs []string) ([]string, error)
+	ConnectPeerFunc                      func(peerId, host string, port uint) (*glightning.ConnectResult, error)
+	ConnectFunc                          func(peerId, host string, port uint) (string, error)
+	FundChannelFunc                      func(id string, amount *glightning.Sat) (*glightning.FundChannelResult, error)
+	FundPrivateChannelFunc               func(id string, amount *glightning.Sat) (*glightning.FundChannelResult, error)
+	FundChannelAtFeeFunc                 func(id string, amount *glightning.Sat, feerate *glightning.FeeRate) (*glightning.FundChannelResult, error)
+	FundPrivateChannelAtFeeFunc          func(id string, amount *glightning.Sat, feerate *glightning.FeeRate) (*glightning.FundChannelResult, error)
+	FundChannelExtFunc                   func(id string, amount *glightning.Sat, feerate *glightning.FeeRate, announce bool, minConf *uint16, pushMSat *glightning.MSat) (*glightning.FundChannelResult, error)
+	FundChannelWithOptionsFunc           func(req *glightning.FundChannelRequest) (*glightning.FundChannelResult, error)
+	MultiFundChannelFunc                 func(destinations []*glightning.FundDestination, feerate *glightning.FeeRate, minConf *uint16, utxos []*glightning.Utxo, minChannels uint) (*glightning.MultiFundChannelResult, error)
+	StartFundChannelFunc                 func(id string, amount uint64, announce bool, feerate *glightning.FeeRate, closeTo string) (*glightning.StartResponse, error)
+	CompleteFundChannelFunc              func(peerId, txId string, txout uint32) (string, error)
+	CompleteFundChannelPsbtFunc          func(peerId, psbt string) (string, error)
+	CancelFundChannelFunc                func(peerId string) (bool, error)
+	OpenChannelBumpFunc                  func(channelId string, amount *glightning.Sat, initialPsbt string, feerate *glightning.FeeRate) (*glightning.OpenChannelV2Result, error)
+	OpenChannelUpdateFunc                func(channelId, psbt string) (*glightning.OpenChannelV2Result, error)
+	OpenChannelSignedFunc                func(channelId, signedPsbt string) (*glightning.OpenChannelSignedResult, error)
+	OpenChannelAbortFunc                 func(channelId string) (*glightning.OpenChannelAbortResult, error)
+	SpliceInitFunc                       func(channelId string, relativeAmount int64, initialPsbt string, feeRatePerKw uint32) (*glightning.SpliceUpdateResult, error)
+	SpliceUpdateFunc                     func(channelId, psbt string) (*glightning.SpliceUpdateResult, error)
+	SpliceSignedFunc                     func(channelId, psbt string) (*glightning.SpliceSignedResult, error)
+	CloseNormalFunc                      func(id string) (*glightning.CloseResult, error)
+	CloseToFunc                          func(id, destination string) (*glightning.CloseResult, error)
+	CloseWithStepFunc                    func(id, step string) (*glightning.CloseResult, error)
+	CloseToWithStepFunc                  func(id, destination, step string) (*glightning.CloseResult, error)
+	CloseToTimeoutWithStepFunc           func(id string, timeout uint, destination, step string) (*glightning.CloseResult, error)
+	CloseFunc                            func(id string, timeout uint, destination string) (*glightning.CloseResult, error)
+	DevSignLastTxFunc                    func(peerId string) (string, error)
+	DevFailFunc                          func(peerId string) error
+	DevReenableCommitFunc                func(id string) error
+	PingFunc                             func(peerId string) (*glightning.Pong, error)
+	PingWithLenFunc                      func(peerId string, pingLen, pongByteLen uint) (*glightning.Pong, error)
+	DevMemDumpFunc                       func() ([]*glightning.MemDumpEntry, error)
+	DevMemLeakFunc                       func() ([]*glightning.MemLeak, error)
+	WithdrawFunc                         func(destination string, amount *glightning.Sat, feerate *glightning.FeeRate, minConf *uint16) (*glightning.WithdrawResult, error)
+	WithdrawWithUtxosFunc                func(destination string, amount *glightning.Sat, feerate *glightning.FeeRate, minConf *uint16, utxos []*glightning.Utxo) (*glightning.WithdrawResult, error)
+	NewAddrFunc                          func() (string, error)
+	NewAddressFunc                       func(addrType glightning.AddressType) (*glightning.NewAddrResult, error)
+	PrepareTxFunc                        func(outputs []*glightning.Outputs, feerate *glightning.FeeRate, minConf *uint16) (*glightning.TxResult, error)
+	PrepareTxWithUtxosFunc               func(outputs []*glightning.Outputs, feerate *glightning.FeeRate, minConf *uint16, utxos []*glightning.Utxo) (*glightning.TxResult, error)
+	DiscardTxFunc                        func(txid string) (*glightning.TxResult, error)
+	SendTxFunc                           func(txid string) (*glightning.TxResult, error)
+	SetPsbtVersionFunc                   func(psbt string, version uint8) (string, error)
+	FundPsbtFunc                         func(amount *glightning.Sat, feerate *glightning.FeeRate, startWeight uint, reserve *uint32) (*glightning.FundPsbtResult, error)
+	FundPsbtWithOptionsFunc              func(req *glightning.FundPsbtRequest) (*glightning.FundPsbtResult, error)
+	SignPsbtFunc                         func(psbt string, signOnly []uint32) (string, error)
+	UnreserveInputsFunc                  func(psbt string) ([]*glightning.PsbtReservation, error)
+	AddPsbtOutputFunc                    func(amount *glightning.Sat, initialPsbt, destination string) (*glightning.AddPsbtOutputResult, error)
+	SendPsbtFunc                         func(psbt string) (*glightning.WithdrawResult, error)
+	ListFundsFunc                        func() (*glightning.FundsResult, error)
+	ListForwardsFunc                     func() ([]glightning.Forwarding, error)
+	ListForwardsFilteredFunc             func(req *glightning.ListForwardsRequest) ([]glightning.Forwarding, error)
+	DevRescanOutputsFunc                 func() ([]glightning.Output, error)
+	DevForgetChannelFunc                 func(peerId string, force bool) (*glightning.ForgetChannelResult, error)
+	DevForgetChannelByShortChannelIdFunc func(peerId, shortChannelId string, force bool) (*glightning.ForgetChannelResult, error)
+	DevForgetChannelByChannelIdFunc      func(peerId, channelId string, force bool) (*glightning.ForgetChannelResult, error)
+	SendCustomMessageFunc                func(nodeId, message string) (*glightning.CustomMessageResult, error)
+	SendCustomTlvMessageFunc             func(nodeId string, msgType uint16, records []*tlv.Record) (*glightning.CustomMessageResult, error)
+	DisconnectFunc                       func(peerId string, force bool) error
+	FeeRatesFunc                         func(style glightning.FeeRateStyle) (*glightning.FeeRateEstimate, error)
+	SetChannelFeeFunc                    func(id string, baseMsat string, ppm uint32) (*glightning.ChannelFeeResult, error)
+	SetChannelFunc                       func(id string, baseMsat uint64, ppm uint32) (*glightning.SetChannelResult, error)
+	SetChannelWithOptionsFunc            func(req *glightning.SetChannelRequest) (*glightning.SetChannelResult, error)
+	ListPluginsFunc                      func() ([]glightning.PluginInfo, error)
+	RescanPluginsFunc                    func() ([]glightning.PluginInfo, error)
+	SetPluginStartDirFunc                func(directory string) ([]glightning.PluginInfo, error)
+	StartPluginFunc                      func(pluginName string) ([]glightning.PluginInfo, error)
+	StopPluginFunc                       func(pluginName string) (string, error)
+	GetSharedSecretFunc                  func(point string) (string, error)
+	GetFunderPolicyFunc                  func() (*glightning.FunderPolicyResult, error)
+	FunderUpdateFunc                     func(req *glightning.FunderUpdateRequest) (*glightning.FunderPolicyResult, error)
+	SqlFunc                              func(query string) ([][]json.RawMessage, error)
+
+	GetRoutesFunc            func(source, destination string, amount *glightning.MSat, layers []string, maxFee *glightning.MSat, finalCltv uint32) (*glightning.GetRoutesResult, error)
+	AskReneCreateLayerFunc   func(layer string, persistent bool) (*glightning.AskReneLayer, error)
+	AskReneRemoveLayerFunc   func(layer string) error
+	AskReneListLayersFunc    func() ([]*glightning.AskReneLayer, error)
+	AskReneGetLayerFunc      func(layer string) (*glightning.AskReneLayer, error)
+	AskReneInformChannelFunc func(layer, scidDir string, amount *glightning.MSat, inform glightning.AskReneInform) ([]*glightning.AskReneConstraint, error)
+	AskReneDisableNodeFunc   func(layer, node string) error
+	AskReneBiasChannelFunc   func(layer, scidDir string, bias int, description string, relative bool) ([]*glightning.AskReneBias, error)
+	AskReneCreateChannelFunc func(layer, source, destination, shortChannelId string, capacity *glightning.MSat) error
+	AskReneReserveFunc       func(path []*glightning.AskReneReservation) error
+	AskReneUnreserveFunc     func(path []*glightning.AskReneReservation) error
+	AskReneAgeFunc           func(layer string, cutoff uint64) (*glightning.AskReneAgeResult, error)
+
+	CommandoFunc   func(peerId, rune, method string, params map[string]interface{}, resp interface{}) error
+	CreateRuneFunc func(restrictions [][]string) (*glightning.Rune, error)
+	CheckRuneFunc  func(rune, nodeId, method string, params map[string]interface{}) (bool, error)
+
+	DetectVersionFunc func() (*glightning.Version, error)
+	VersionFunc       func() *glightning.Version
+
+	ConnectBestEffortFunc func(nodeId string) (*glightning.ConnectBestEffortResult, error)
+	ConnectAddressesFunc  func(nodeId string, addresses []glightning.Address) (*glightning.ConnectBestEffortResult, error)
+
+	SetDatastoreFunc  func(key []string, value string, mode glightning.DatastoreMode) (*glightning.DatastoreEntry, error)
+	ListDatastoreFunc func(key []string) ([]*glightning.DatastoreEntry, error)
+	GetDatastoreFunc  func(key []string) (*glightning.DatastoreEntry, error)
+	DelDatastoreFunc  func(key []string) (*glightning.DatastoreEntry, error)
+
+	WithdrawWithStrategyFunc    func(destination string, amount *glightning.Sat, strategy glightning.FeeStrategy, minConf *uint16) (*glightning.WithdrawResult, error)
+	FundChannelWithStrategyFunc func(id string, amount *glightning.Sat, strategy glightning.FeeStrategy, announce bool, minConf *uint16) (*glightning.FundChannelResult, error)
+	PrepareTxWithStrategyFunc   func(outputs []*glightning.Outputs, strategy glightning.FeeStrategy, minConf *uint16) (*glightning.TxResult, error)
+
+	ListChannelsIterFunc func(batchSize int) *glightning.ChannelIterator
+	ListForwardsIterFunc func(batchSize int) *glightning.ForwardIterator
+	ListInvoicesIterFunc func(batchSize int) *glightning.InvoiceIterator
+
+	DetectNetworkFunc func() (network.Network, error)
+}
+
+var _ glightning.LightningClient = (*Lightning)(nil)
+
+func New() *Lightning {
+	return &Lightning{}
+}
+
+// The names of the methods called so far, in order
+func (fake *Lightning) Calls() []string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	calls := make([]string, len(fake.calls))
+	copy(calls, fake.calls)
+	return calls
+}
+
+// How many times {method} has been called
+func (fake *Lightning) CallCount(method string) int {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	count := 0
+	for _, call := range fake.calls {
+		if call == method {
+			count++
+		}
+	}
+	return count
+}
+
+func (fake *Lightning) record(method string) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.calls = append(fake.calls, method)
+}
+
+func notMocked(method string) error {
+	return fmt.Errorf("%s: %w", method, ErrNotMocked)
+}
+
+func (fake *Lightning) IsUp() (result bool) {
+	fake.record("IsUp")
+	if fake.IsUpFunc == nil {
+		return
+	}
+	return fake.IsUpFunc()
+}
+
+func (fake *Lightning) Request(m jrpc2.Method, resp interface{}) error {
+	fake.record("Request")
+	if fake.RequestFunc == nil {
+		return notMocked("Request")
+	}
+	return fake.RequestFunc(m, resp)
+}
+
+func (fake *Lightning) RequestRaw(m jrpc2.Method, resp interface{}) (result json.RawMessage, err error) {
+	fake.record("RequestRaw")
+	if fake.RequestRawFunc == nil {
+		err = notMocked("RequestRaw")
+		return
+	}
+	return fake.RequestRawFunc(m, resp)
+}
+
+func (fake *Lightning) Call(method string, params map[string]interface{}) (result json.RawMessage, err error) {
+	fake.record("Call")
+	if fake.CallFunc == nil {
+		err = notMocked("Call")
+		return
+	}
+	return fake.CallFunc(method, params)
+}
+
+func (fake *Lightning) CallInto(method string, params interface{}, result interface{}) error {
+	fake.record("CallInto")
+	if fake.CallIntoFunc == nil {
+		return notMocked("CallInto")
+	}
+	return fake.CallIntoFunc(method, params, result)
+}
+
+func (fake *Lightning) ListConfigs() (result map[string]interface{}, err error) {
+	fake.record("ListConfigs")
+	if fake.ListConfigsFunc == nil {
+		err = notMocked("ListConfigs")
+		return
+	}
+	return fake.ListConfigsFunc()
+}
+
+func (fake *Lightning) GetConfig(config string) (result interface{}, err error) {
+	fake.record("GetConfig")
+	if fake.GetConfigFunc == nil {
+		err = notMocked("GetConfig")
+		return
+	}
+	return fake.GetConfigFunc(config)
+}
+
+func (fake *Lightning) GetPeer(peerId string) (result *glightning.Peer, err error) {
+	fake.record("GetPeer")
+	if fake.GetPeerFunc == nil {
+		err = notMocked("GetPeer")
+		return
+	}
+	return fake.GetPeerFunc(peerId)
+}
+
+func (fake *Lightning) GetPeerWithLogs(peerId string, level glightning.LogLevel) (result *glightning.Peer, err error) {
+	fake.record("GetPeerWithLogs")
+	if fake.GetPeerWithLogsFunc == nil {
+		err = notMocked("GetPeerWithLogs")
+		return
+	}
+	return fake.GetPeerWithLogsFunc(peerId, level)
+}
+
+func (fake *Lightning) ListPeersWithLogs(level glightning.LogLevel) (result []*glightning.Peer, err error) {
+	fake.record("ListPeersWithLogs")
+	if fake.ListPeersWithLogsFunc == nil {
+		err = notMocked("ListPeersWithLogs")
+		return
+	}
+	return fake.ListPeersWithLogsFunc(level)
+}
+
+func (fake *Lightning) ListPeers() (result []*glightning.Peer, err error) {
+	fake.record("ListPeers")
+	if fake.ListPeersFunc == nil {
+		err = notMocked("ListPeers")
+		return
+	}
+	return fake.ListPeersFunc()
+}
+
+func (fake *Lightning) GetNode(nodeId string) (result *glightning.Node, err error) {
+	fake.record("GetNode")
+	if fake.GetNodeFunc == nil {
+		err = notMocked("GetNode")
+		return
+	}
+	return fake.GetNodeFunc(nodeId)
+}
+
+func (fake *Lightning) ListNodes() (result []*glightning.Node, err error) {
+	fake.record("ListNodes")
+	if fake.ListNodesFunc == nil {
+		err = notMocked("ListNodes")
+		return
+	}
+	return fake.ListNodesFunc()
+}
+
+func (fake *Lightning) GetRouteSimple(peerId string, msats uint64, riskfactor float32) (result []glightning.RouteHop, err error) {
+	fake.record("GetRouteSimple")
+	if fake.GetRouteSimpleFunc == nil {
+		err = notMocked("GetRouteSimple")
+		return
+	}
+	return fake.GetRouteSimpleFunc(peerId, msats, riskfactor)
+}
+
+func (fake *Lightning) GetRoute(peerId string, msats uint64, riskfactor float32, cltv uint, fromId string, fuzzpercent float32, exclude []string, maxHops int32) (result []glightning.RouteHop, err error) {
+	fake.record("GetRoute")
+	if fake.GetRouteFunc == nil {
+		err = notMocked("GetRoute")
+		return
+	}
+	return fake.GetRouteFunc(peerId, msats, riskfactor, cltv, fromId, fuzzpercent, exclude, maxHops)
+}
+
+func (fake *Lightning) SendOnion(onion string, hop glightning.FirstHop, paymentHash string) (result *glightning.SendPayFields, err error) {
+	fake.record("SendOnion")
+	if fake.SendOnionFunc == nil {
+		err = notMocked("SendOnion")
+		return
+	}
+	return fake.SendOnionFunc(onion, hop, paymentHash)
+}
+
+func (fake *Lightning) SendOnionWithDetails(onion string, hop glightning.FirstHop, paymentHash string, label string, secrets []string, partId *uint64) (result *glightning.SendPayFields, err error) {
+	fake.record("SendOnionWithDetails")
+	if fake.SendOnionWithDetailsFunc == nil {
+		err = notMocked("SendOnionWithDetails")
+		return
+	}
+	return fake.SendOnionWithDetailsFunc(onion, hop, paymentHash, label, secrets, partId)
+}
+
+func (fake *Lightning) CreateOnion(hops []glightning.Hop, paymentHash, sessionKey string) (result *glightning.CreateOnionResponse, err error) {
+	fake.record("CreateOnion")
+	if fake.CreateOnionFunc == nil {
+		err = notMocked("CreateOnion")
+		return
+	}
+	return fake.CreateOnionFunc(hops, paymentHash, sessionKey)
+}
+
+func (fake *Lightning) SendOnionMessage(firstId, blinding string, hops []*glightning.OnionMessageHop) error {
+	fake.record("SendOnionMessage")
+	if fake.SendOnionMessageFunc == nil {
+		return notMocked("SendOnionMessage")
+	}
+	return fake.SendOnionMessageFunc(firstId, blinding, hops)
+}
+
+func (fake *Lightning) InjectOnionMessage(pathKey, message string) error {
+	fake.record("InjectOnionMessage")
+	if fake.InjectOnionMessageFunc == nil {
+		return notMocked("InjectOnionMessage")
+	}
+	return fake.InjectOnionMessageFunc(pathKey, message)
+}
+
+func (fake *Lightning) GetChannel(shortChanId string) (result []*glightning.Channel, err error) {
+	fake.record("GetChannel")
+	if fake.GetChannelFunc == nil {
+		err = notMocked("GetChannel")
+		return
+	}
+	return fake.GetChannelFunc(shortChanId)
+}
+
+func (fake *Lightning) ListChannelsBySource(nodeId string) (result []*glightning.Channel, err error) {
+	fake.record("ListChannelsBySource")
+	if fake.ListChannelsBySourceFunc == nil {
+		err = notMocked("ListChannelsBySource")
+		return
+	}
+	return fake.ListChannelsBySourceFunc(nodeId)
+}
+
+func (fake *Lightning) ListChannelsByDestination(nodeId string) (result []*glightning.Channel, err error) {
+	fake.record("ListChannelsByDestination")
+	if fake.ListChannelsByDestinationFunc == nil {
+		err = notMocked("ListChannelsByDestination")
+		return
+	}
+	return fake.ListChannelsByDestinationFunc(nodeId)
+}
+
+func (fake *Lightning) ListChannels() (result []*glightning.Channel, err error) {
+	fake.record("ListChannels")
+	if fake.ListChannelsFunc == nil {
+		err = notMocked("ListChannels")
+		return
+	}
+	return fake.ListChannelsFunc()
+}
+
+func (fake *Lightning) CreateInvoiceAny(label, description string, expirySeconds uint32, fallbacks []string, preimage string, exposePrivateChans bool) (result *glightning.Invoice, err error) {
+	fake.record("CreateInvoiceAny")
+	if fake.CreateInvoiceAnyFunc == nil {
+		err = notMocked("CreateInvoiceAny")
+		return
+	}
+	return fake.CreateInvoiceAnyFunc(label, description, expirySeconds, fallbacks, preimage, exposePrivateChans)
+}
+
+func (fake *Lightning) CreateInvoice(msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, willExposePrivateChans bool) (result *glightning.Invoice, err error) {
+	fake.record("CreateInvoice")
+	if fake.CreateInvoiceFunc == nil {
+		err = notMocked("CreateInvoice")
+		return
+	}
+	return fake.CreateInvoiceFunc(msat, label, description, expirySeconds, fallbacks, preimage, willExposePrivateChans)
+}
+
+func (fake *Lightning) CreateInvoiceExposing(msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, exposePrivChans []string) (result *glightning.Invoice, err error) {
+	fake.record("CreateInvoiceExposing")
+	if fake.CreateInvoiceExposingFunc == nil {
+		err = notMocked("CreateInvoiceExposing")
+		return
+	}
+	return fake.CreateInvoiceExposingFunc(msat, label, description, expirySeconds, fallbacks, preimage, exposePrivChans)
+}
+
+func (fake *Lightning) CreateInvoiceWithCltvExpiry(msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, willExposePrivateChans bool, cltv uint32) (result *glightning.Invoice, err error) {
+	fake.record("CreateInvoiceWithCltvExpiry")
+	if fake.CreateInvoiceWithCltvExpiryFunc == nil {
+		err = notMocked("CreateInvoiceWithCltvExpiry")
+		return
+	}
+	return fake.CreateInvoiceWithCltvExpiryFunc(msat, label, description, expirySeconds, fallbacks, preimage, willExposePrivateChans, cltv)
+}
+
+func (fake *Lightning) Invoice(msat uint64, label, description string) (result *glightning.Invoice, err error) {
+	fake.record("Invoice")
+	if fake.InvoiceFunc == nil {
+		err = notMocked("Invoice")
+		return
+	}
+	return fake.InvoiceFunc(msat, label, description)
+}
+
+func (fake *Lightning) CreateInvoiceDescHashOnly(msat uint64, label, description string, expirySeconds uint32) (result *glightning.Invoice, err error) {
+	fake.record("CreateInvoiceDescHashOnly")
+	if fake.CreateInvoiceDescHashOnlyFunc == nil {
+		err = notMocked("CreateInvoiceDescHashOnly")
+		return
+	}
+	return fake.CreateInvoiceDescHashOnlyFunc(msat, label, description, expirySeconds)
+}
+
+func (fake *Lightning) CreateInvoiceWithOptions(req *glightning.InvoiceRequest) (result *glightning.Invoice, err error) {
+	fake.record("CreateInvoiceWithOptions")
+	if fake.CreateInvoiceWithOptionsFunc == nil {
+		err = notMocked("CreateInvoiceWithOptions")
+		return
+	}
+	return fake.CreateInvoiceWithOptionsFunc(req)
+}
+
+func (fake *Lightning) ListInvoices() (result []*glightning.Invoice, err error) {
+	fake.record("ListInvoices")
+	if fake.ListInvoicesFunc == nil {
+		err = notMocked("ListInvoices")
+		return
+	}
+	return fake.ListInvoicesFunc()
+}
+
+func (fake *Lightning) GetInvoice(label string) (result *glightning.Invoice, err error) {
+	fake.record("GetInvoice")
+	if fake.GetInvoiceFunc == nil {
+		err = notMocked("GetInvoice")
+		return
+	}
+	return fake.GetInvoiceFunc(label)
+}
+
+func (fake *Lightning) GetInvoiceByHash(paymentHash string) (result *glightning.Invoice, err error) {
+	fake.record("GetInvoiceByHash")
+	if fake.GetInvoiceByHashFunc == nil {
+		err = notMocked("GetInvoiceByHash")
+		return
+	}
+	return fake.GetInvoiceByHashFunc(paymentHash)
+}
+
+func (fake *Lightning) ListInvoicesFiltered(req *glightning.ListInvoiceRequest) (result []*glightning.Invoice, err error) {
+	fake.record("ListInvoicesFiltered")
+	if fake.ListInvoicesFilteredFunc == nil {
+		err = notMocked("ListInvoicesFiltered")
+		return
+	}
+	return fake.ListInvoicesFilteredFunc(req)
+}
+
+func (fake *Lightning) DeleteInvoice(label, status string) (result *glightning.Invoice, err error) {
+	fake.record("DeleteInvoice")
+	if fake.DeleteInvoiceFunc == nil {
+		err = notMocked("DeleteInvoice")
+		return
+	}
+	return fake.DeleteInvoiceFunc(label, status)
+}
+
+func (fake *Lightning) DeleteInvoiceDescription(label, status string) (result *glightning.Invoice, err error) {
+	fake.record("DeleteInvoiceDescription")
+	if fake.DeleteInvoiceDescriptionFunc == nil {
+		err = notMocked("DeleteInvoiceDescription")
+		return
+	}
+	return fake.DeleteInvoiceDescriptionFunc(label, status)
+}
+
+func (fake *Lightning) WaitAnyInvoice(lastPayIndex uint) (result *glightning.Invoice, err error) {
+	fake.record("WaitAnyInvoice")
+	if fake.WaitAnyInvoiceFunc == nil {
+		err = notMocked("WaitAnyInvoice")
+		return
+	}
+	return fake.WaitAnyInvoiceFunc(lastPayIndex)
+}
+
+func (fake *Lightning) WaitAnyInvoiceTimeout(lastPayIndex uint, timeout uint) (result *glightning.Invoice, err error) {
+	fake.record("WaitAnyInvoiceTimeout")
+	if fake.WaitAnyInvoiceTimeoutFunc == nil {
+		err = notMocked("WaitAnyInvoiceTimeout")
+		return
+	}
+	return fake.WaitAnyInvoiceTimeoutFunc(lastPayIndex, timeout)
+}
+
+func (fake *Lightning) WaitInvoice(label string) (result *glightning.Invoice, err error) {
+	fake.record("WaitInvoice")
+	if fake.WaitInvoiceFunc == nil {
+		err = notMocked("WaitInvoice")
+		return
+	}
+	return fake.WaitInvoiceFunc(label)
+}
+
+func (fake *Lightning) DeleteExpiredInvoicesSince(unixTime uint64) error {
+	fake.record("DeleteExpiredInvoicesSince")
+	if fake.DeleteExpiredInvoicesSinceFunc == nil {
+		return notMocked("DeleteExpiredInvoicesSince")
+	}
+	return fake.DeleteExpiredInvoicesSinceFunc(unixTime)
+}
+
+func (fake *Lightning) DisableInvoiceAutoclean() error {
+	fake.record("DisableInvoiceAutoclean")
+	if fake.DisableInvoiceAutocleanFunc == nil {
+		return notMocked("DisableInvoiceAutoclean")
+	}
+	return fake.DisableInvoiceAutocleanFunc()
+}
+
+func (fake *Lightning) SetInvoiceAutoclean(intervalSeconds, expiredBySeconds uint32) error {
+	fake.record("SetInvoiceAutoclean")
+	if fake.SetInvoiceAutocleanFunc == nil {
+		return notMocked("SetInvoiceAutoclean")
+	}
+	return fake.SetInvoiceAutocleanFunc(intervalSeconds, expiredBySeconds)
+}
+
+func (fake *Lightning) DecodeBolt11(bolt11 string) (result *glightning.DecodedBolt11, err error) {
+	fake.record("DecodeBolt11")
+	if fake.DecodeBolt11Func == nil {
+		err = notMocked("DecodeBolt11")
+		return
+	}
+	return fake.DecodeBolt11Func(bolt11)
+}
+
+func (fake *Lightning) DecodePay(bolt11, desc string) (result *glightning.DecodedBolt11, err error) {
+	fake.record("DecodePay")
+	if fake.DecodePayFunc == nil {
+		err = notMocked("DecodePay")
+		return
+	}
+	return fake.DecodePayFunc(bolt11, desc)
+}
+
+func (fake *Lightning) ListPayStatuses() (result []glightning.PayStatus, err error) {
+	fake.record("ListPayStatuses")
+	if fake.ListPayStatusesFunc == nil {
+		err = notMocked("ListPayStatuses")
+		return
+	}
+	return fake.ListPayStatusesFunc()
+}
+
+func (fake *Lightning) GetPayStatus(bolt11 string) (result *glightning.PayStatus, err error) {
+	fake.record("GetPayStatus")
+	if fake.GetPayStatusFunc == nil {
+		err = notMocked("GetPayStatus")
+		return
+	}
+	return fake.GetPayStatusFunc(bolt11)
+}
+
+func (fake *Lightning) Help() (result []*glightning.Command, err error) {
+	fake.record("Help")
+	if fake.HelpFunc == nil {
+		err = notMocked("Help")
+		return
+	}
+	return fake.HelpFunc()
+}
+
+func (fake *Lightning) HelpFor(command string) (result *glightning.Command, err error) {
+	fake.record("HelpFor")
+	if fake.HelpForFunc == nil {
+		err = notMocked("HelpFor")
+		return
+	}
+	return fake.HelpForFunc(command)
+}
+
+func (fake *Lightning) Stop() (result string, err error) {
+	fake.record("Stop")
+	if fake.StopFunc == nil {
+		err = notMocked("Stop")
+		return
+	}
+	return fake.StopFunc()
+}
+
+func (fake *Lightning) Deprecations(enable bool) error {
+	fake.record("Deprecations")
+	if fake.DeprecationsFunc == nil {
+		return notMocked("Deprecations")
+	}
+	return fake.DeprecationsFunc(enable)
+}
+
+func (fake *Lightning) GetLog(level glightning.LogLevel) (result *glightning.LogResponse, err error) {
+	fake.record("GetLog")
+	if fake.GetLogFunc == nil {
+		err = notMocked("GetLog")
+		return
+	}
+	return fake.GetLogFunc(level)
+}
+
+func (fake *Lightning) DevHash(secret string) (result string, err error) {
+	fake.record("DevHash")
+	if fake.DevHashFunc == nil {
+		err = notMocked("DevHash")
+		return
+	}
+	return fake.DevHashFunc(secret)
+}
+
+func (fake *Lightning) DevCrash() (result interface{}, err error) {
+	fake.record("DevCrash")
+	if fake.DevCrashFunc == nil {
+		err = notMocked("DevCrash")
+		return
+	}
+	return fake.DevCrashFunc()
+}
+
+func (fake *Lightning) DevQueryShortChanIds(peerId string, shortChanIds []string) (result *glightning.QueryShortChannelIdsResponse, err error) {
+	fake.record("DevQueryShortChanIds")
+	if fake.DevQueryShortChanIdsFunc == nil {
+		err = notMocked("DevQueryShortChanIds")
+		return
+	}
+	return fake.DevQueryShortChanIdsFunc(peerId, shortChanIds)
+}
+
+func (fake *Lightning) GetInfo() (result *glightning.NodeInfo, err error) {
+	fake.record("GetInfo")
+	if fake.GetInfoFunc == nil {
+		err = notMocked("GetInfo")
+		return
+	}
+	return fake.GetInfoFunc()
+}
+
+func (fake *Lightning) SignMessage(message string) (result *glightning.SignedMessage, err error) {
+	fake.record("SignMessage")
+	if fake.SignMessageFunc == nil {
+		err = notMocked("SignMessage")
+		return
+	}
+	return fake.SignMessageFunc(message)
+}
+
+func (fake *Lightning) CheckMessage(message, zbase string) (r0 bool, r1 string, err error) {
+	fake.record("CheckMessage")
+	if fake.CheckMessageFunc == nil {
+		err = notMocked("CheckMessage")
+		return
+	}
+	return fake.CheckMessageFunc(message, zbase)
+}
+
+func (fake *Lightning) CheckMessageVerify(message, zbase, pubkey string) (result bool, err error) {
+	fake.record("CheckMessageVerify")
+	if fake.CheckMessageVerifyFunc == nil {
+		err = notMocked("CheckMessageVerify")
+		return
+	}
+	return fake.CheckMessageVerifyFunc(message, zbase, pubkey)
+}
+
+func (fake *Lightning) SendPayLite(route []glightning.RouteHop, paymentHash string) (result *glightning.SendPayResult, err error) {
+	fake.record("SendPayLite")
+	if fake.SendPayLiteFunc == nil {
+		err = notMocked("SendPayLite")
+		return
+	}
+	return fake.SendPayLiteFunc(route, paymentHash)
+}
+
+func (fake *Lightning) SendPay(route []glightning.RouteHop, paymentHash, label string, msat *uint64, bolt11 string, paymentSecret string, partId uint64) (result *glightning.SendPayResult, err error) {
+	fake.record("SendPay")
+	if fake.SendPayFunc == nil {
+		err = notMocked("SendPay")
+		return
+	}
+	return fake.SendPayFunc(route, paymentHash, label, msat, bolt11, paymentSecret, partId)
+}
+
+func (fake *Lightning) SendPayPart(req *glightning.SendPayRequest) (result *glightning.SendPayResult, err error) {
+	fake.record("SendPayPart")
+	if fake.SendPayPartFunc == nil {
+		err = notMocked("SendPayPart")
+		return
+	}
+	return fake.SendPayPartFunc(req)
+}
+
+func (fake *Lightning) WaitSendPay(paymentHash string, timeout uint) (result *glightning.SendPayFields, err error) {
+	fake.record("WaitSendPay")
+	if fake.WaitSendPayFunc == nil {
+		err = notMocked("WaitSendPay")
+		return
+	}
+	return fake.WaitSendPayFunc(paymentHash, timeout)
+}
+
+func (fake *Lightning) WaitSendPayPart(paymentHash string, timeout uint, partId uint64) (result *glightning.SendPayFields, err error) {
+	fake.record("WaitSendPayPart")
+	if fake.WaitSendPayPartFunc == nil {
+		err = notMocked("WaitSendPayPart")
+		return
+	}
+	return fake.WaitSendPayPartFunc(paymentHash, timeout, partId)
+}
+
+func (fake *Lightning) WaitSendPayPartInGroup(paymentHash string, timeout uint, partId, groupId uint64) (result *glightning.SendPayFields, err error) {
+	fake.record("WaitSendPayPartInGroup")
+	if fake.WaitSendPayPartInGroupFunc == nil {
+		err = notMocked("WaitSendPayPartInGroup")
+		return
+	}
+	return fake.WaitSendPayPartInGroupFunc(paymentHash, timeout, partId, groupId)
+}
+
+func (fake *Lightning) PayBolt(bolt11 string) (result *glightning.PaymentSuccess, err error) {
+	fake.record("PayBolt")
+	if fake.PayBoltFunc == nil {
+		err = notMocked("PayBolt")
+		return
+	}
+	return fake.PayBoltFunc(bolt11)
+}
+
+func (fake *Lightning) PayPartial(bolt11 string, partial *glightning.MSat) (result *glightning.PaymentSuccess, err error) {
+	fake.record("PayPartial")
+	if fake.PayPartialFunc == nil {
+		err = notMocked("PayPartial")
+		return
+	}
+	return fake.PayPartialFunc(bolt11, partial)
+}
+
+func (fake *Lightning) Pay(req *glightning.PayRequest) (result *glightning.PaymentSuccess, err error) {
+	fake.record("Pay")
+	if fake.PayFunc == nil {
+		err = notMocked("Pay")
+		return
+	}
+	return fake.PayFunc(req)
+}
+
+func (fake *Lightning) KeySend(destination string, msat uint64, label string, extraTlvs []*tlv.Record) (result *glightning.PaymentSuccess, err error) {
+	fake.record("KeySend")
+	if fake.KeySendFunc == nil {
+		err = notMocked("KeySend")
+		return
+	}
+	return fake.KeySendFunc(destination, msat, label, extraTlvs)
+}
+
+func (fake *Lightning) ListPays() (result []glightning.PaymentFields, err error) {
+	fake.record("ListPays")
+	if fake.ListPaysFunc == nil {
+		err = notMocked("ListPays")
+		return
+	}
+	return fake.ListPaysFunc()
+}
+
+func (fake *Lightning) ListPaysToBolt11(bolt11 string) (result []glightning.PaymentFields, err error) {
+	fake.record("ListPaysToBolt11")
+	if fake.ListPaysToBolt11Func == nil {
+		err = notMocked("ListPaysToBolt11")
+		return
+	}
+	return fake.ListPaysToBolt11Func(bolt11)
+}
+
+func (fake *Lightning) ListSendPaysAll() (result []glightning.SendPayFields, err error) {
+	fake.record("ListSendPaysAll")
+	if fake.ListSendPaysAllFunc == nil {
+		err = notMocked("ListSendPaysAll")
+		return
+	}
+	return fake.ListSendPaysAllFunc()
+}
+
+func (fake *Lightning) ListSendPays(bolt11 string) (result []glightning.SendPayFields, err error) {
+	fake.record("ListSendPays")
+	if fake.ListSendPaysFunc == nil {
+		err = notMocked("ListSendPays")
+		return
+	}
+	return fake.ListSendPaysFunc(bolt11)
+}
+
+func (fake *Lightning) ListSendPaysByHash(paymentHash string) (result []glightning.SendPayFields, err error) {
+	fake.record("ListSendPaysByHash")
+	if fake.ListSendPaysByHashFunc == nil {
+		err = notMocked("ListSendPaysByHash")
+		return
+	}
+	return fake.ListSendPaysByHashFunc(paymentHash)
+}
+
+func (fake *Lightning) ListTransactions() (result []glightning.Transaction, err error) {
+	fake.record("ListTransactions")
+	if fake.ListTransactionsFunc == nil {
+		err = notMocked("ListTransactions")
+		return
+	}
+	return fake.ListTransactionsFunc()
+}
+
+func (fake *Lightning) BkprListAccountEvents(account string) (result []*glightning.BkprAccountEvent, err error) {
+	fake.record("BkprListAccountEvents")
+	if fake.BkprListAccountEventsFunc == nil {
+		err = notMocked("BkprListAccountEvents")
+		return
+	}
+	return fake.BkprListAccountEventsFunc(account)
+}
+
+func (fake *Lightning) StaticBackup() (result []string, err error) {
+	fake.record("StaticBackup")
+	if fake.StaticBackupFunc == nil {
+		err = notMocked("StaticBackup")
+		return
+	}
+	return fake.StaticBackupFunc()
+}
+
+func (fake *Lightning) EmergencyRecover() (result []string, err error) {
+	fake.record("EmergencyRecover")
+	if fake.EmergencyRecoverFunc == nil {
+		err = notMocked("EmergencyRecover")
+		return
+	}
+	return fake.EmergencyRecoverFunc()
+}
+
+func (fake *Lightning) RecoverChannel(scbs []string) (result []string, err error) {
+	fake.record("RecoverChannel")
+	if fake.RecoverChannelFunc == nil {
+		err = notMocked("RecoverChannel")
+		return
+	}
+	return fake.RecoverChannelFunc(scbs)
+}
+
+func (fake *Lightning) ConnectPeer(peerId, host string, port uint) (result *glightning.ConnectResult, err error) {
+	fake.record("ConnectPeer")
+	if fake.ConnectPeerFunc == nil {
+		err = notMocked("ConnectPeer")
+		return
+	}
+	return fake.ConnectPeerFunc(peerId, host, port)
+}
+
+func (fake *Lightning) Connect(peerId, host string, port uint) (result string, err error) {
+	fake.record("Connect")
+	if fake.ConnectFunc == nil {
+		err = notMocked("Connect")
+		return
+	}
+	return fake.ConnectFunc(peerId, host, port)
+}
+
+func (fake *Lightning) FundChannel(id string, amount *glightning.Sat) (result *glightning.FundChannelResult, err error) {
+	fake.record("FundChannel")
+	if fake.FundChannelFunc == nil {
+		err = notMocked("FundChannel")
+		return
+	}
+	return fake.FundChannelFunc(id, amount)
+}
+
+func (fake *Lightning) FundPrivateChannel(id string, amount *glightning.Sat) (result *glightning.FundChannelResult, err error) {
+	fake.record("FundPrivateChannel")
+	if fake.FundPrivateChannelFunc == nil {
+		err = notMocked("FundPrivateChannel")
+		return
+	}
+	return fake.FundPrivateChannelFunc(id, amount)
+}
+
+func (fake *Lightning) FundChannelAtFee(id string, amount *glightning.Sat, feerate *glightning.FeeRate) (result *glightning.FundChannelResult, err error) {
+	fake.record("FundChannelAtFee")
+	if fake.FundChannelAtFeeFunc == nil {
+		err = notMocked("FundChannelAtFee")
+		return
+	}
+	return fake.FundChannelAtFeeFunc(id, amount, feerate)
+}
+
+func (fake *Lightning) FundPrivateChannelAtFee(id string, amount *glightning.Sat, feerate *glightning.FeeRate) (result *glightning.FundChannelResult, err error) {
+	fake.record("FundPrivateChannelAtFee")
+	if fake.FundPrivateChannelAtFeeFunc == nil {
+		err = notMocked("FundPrivateChannelAtFee")
+		return
+	}
+	return fake.FundPrivateChannelAtFeeFunc(id, amount, feerate)
+}
+
+func (fake *Lightning) FundChannelExt(id string, amount *glightning.Sat, feerate *glightning.FeeRate, announce bool, minConf *uint16, pushMSat *glightning.MSat) (result *glightning.FundChannelResult, err error) {
+	fake.record("FundChannelExt")
+	if fake.FundChannelExtFunc == nil {
+		err = notMocked("FundChannelExt")
+		return
+	}
+	return fake.FundChannelExtFunc(id, amount, feerate, announce, minConf, pushMSat)
+}
+
+func (fake *Lightning) FundChannelWithOptions(req *glightning.FundChannelRequest) (result *glightning.FundChannelResult, err error) {
+	fake.record("FundChannelWithOptions")
+	if fake.FundChannelWithOptionsFunc == nil {
+		err = notMocked("FundChannelWithOptions")
+		return
+	}
+	return fake.FundChannelWithOptionsFunc(req)
+}
+
+func (fake *Lightning) MultiFundChannel(destinations []*glightning.FundDestination, feerate *glightning.FeeRate, minConf *uint16, utxos []*glightning.Utxo, minChannels uint) (result *glightning.MultiFundChannelResult, err error) {
+	fake.record("MultiFundChannel")
+	if fake.MultiFundChannelFunc == nil {
+		err = notMocked("MultiFundChannel")
+		return
+	}
+	return fake.MultiFundChannelFunc(destinations, feerate, minConf, utxos, minChannels)
+}
+
+func (fake *Lightning) StartFundChannel(id string, amount uint64, announce bool, feerate *glightning.FeeRate, closeTo string) (result *glightning.StartResponse, err error) {
+	fake.record("StartFundChannel")
+	if fake.StartFundChannelFunc == nil {
+		err = notMocked("StartFundChannel")
+		return
+	}
+	return fake.StartFundChannelFunc(id, amount, announce, feerate, closeTo)
+}
+
+func (fake *Lightning) CompleteFundChannel(peerId, txId string, txout uint32) (result string, err error) {
+	fake.record("CompleteFundChannel")
+	if fake.CompleteFundChannelFunc == nil {
+		err = notMocked("CompleteFundChannel")
+		return
+	}
+	return fake.CompleteFundChannelFunc(peerId, txId, txout)
+}
+
+func (fake *Lightning) CompleteFundChannelPsbt(peerId, psbt string) (result string, err error) {
+	fake.record("CompleteFundChannelPsbt")
+	if fake.CompleteFundChannelPsbtFunc == nil {
+		err = notMocked("CompleteFundChannelPsbt")
+		return
+	}
+	return fake.CompleteFundChannelPsbtFunc(peerId, psbt)
+}
+
+func (fake *Lightning) CancelFundChannel(peerId string) (result bool, err error) {
+	fake.record("CancelFundChannel")
+	if fake.CancelFundChannelFunc == nil {
+		err = notMocked("CancelFundChannel")
+		return
+	}
+	return fake.CancelFundChannelFunc(peerId)
+}
+
+func (fake *Lightning) OpenChannelBump(channelId string, amount *glightning.Sat, initialPsbt string, feerate *glightning.FeeRate) (result *glightning.OpenChannelV2Result, err error) {
+	fake.record("OpenChannelBump")
+	if fake.OpenChannelBumpFunc == nil {
+		err = notMocked("OpenChannelBump")
+		return
+	}
+	return fake.OpenChannelBumpFunc(channelId, amount, initialPsbt, feerate)
+}
+
+func (fake *Lightning) OpenChannelUpdate(channelId, psbt string) (result *glightning.OpenChannelV2Result, err error) {
+	fake.record("OpenChannelUpdate")
+	if fake.OpenChannelUpdateFunc == nil {
+		err = notMocked("OpenChannelUpdate")
+		return
+	}
+	return fake.OpenChannelUpdateFunc(channelId, psbt)
+}
+
+func (fake *Lightning) OpenChannelSigned(channelId, signedPsbt string) (result *glightning.OpenChannelSignedResult, err error) {
+	fake.record("OpenChannelSigned")
+	if fake.OpenChannelSignedFunc == nil {
+		err = notMocked("OpenChannelSigned")
+		return
+	}
+	return fake.OpenChannelSignedFunc(channelId, signedPsbt)
+}
+
+func (fake *Lightning) OpenChannelAbort(channelId string) (result *glightning.OpenChannelAbortResult, err error) {
+	fake.record("OpenChannelAbort")
+	if fake.OpenChannelAbortFunc == nil {
+		err = notMocked("OpenChannelAbort")
+		return
+	}
+	return fake.OpenChannelAbortFunc(channelId)
+}
+
+func (fake *Lightning) SpliceInit(channelId string, relativeAmount int64, initialPsbt string, feeRatePerKw uint32) (result *glightning.SpliceUpdateResult, err error) {
+	fake.record("SpliceInit")
+	if fake.SpliceInitFunc == nil {
+		err = notMocked("SpliceInit")
+		return
+	}
+	return fake.SpliceInitFunc(channelId, relativeAmount, initialPsbt, feeRatePerKw)
+}
+
+func (fake *Lightning) SpliceUpdate(channelId, psbt string) (result *glightning.SpliceUpdateResult, err error) {
+	fake.record("SpliceUpdate")
+	if fake.SpliceUpdateFunc == nil {
+		err = notMocked("SpliceUpdate")
+		return
+	}
+	return fake.SpliceUpdateFunc(channelId, psbt)
+}
+
+func (fake *Lightning) SpliceSigned(channelId, psbt string) (result *glightning.SpliceSignedResult, err error) {
+	fake.record("SpliceSigned")
+	if fake.SpliceSignedFunc == nil {
+		err = notMocked("SpliceSigned")
+		return
+	}
+	return fake.SpliceSignedFunc(channelId, psbt)
+}
+
+func (fake *Lightning) CloseNormal(id string) (result *glightning.CloseResult, err error) {
+	fake.record("CloseNormal")
+	if fake.CloseNormalFunc == nil {
+		err = notMocked("CloseNormal")
+		return
+	}
+	return fake.CloseNormalFunc(id)
+}
+
+func (fake *Lightning) CloseTo(id, destination string) (result *glightning.CloseResult, err error) {
+	fake.record("CloseTo")
+	if fake.CloseToFunc == nil {
+		err = notMocked("CloseTo")
+		return
+	}
+	return fake.CloseToFunc(id, destination)
+}
+
+func (fake *Lightning) CloseWithStep(id, step string) (result *glightning.CloseResult, err error) {
+	fake.record("CloseWithStep")
+	if fake.CloseWithStepFunc == nil {
+		err = notMocked("CloseWithStep")
+		return
+	}
+	return fake.CloseWithStepFunc(id, step)
+}
+
+func (fake *Lightning) CloseToWithStep(id, destination, step string) (result *glightning.CloseResult, err error) {
+	fake.record("CloseToWithStep")
+	if fake.CloseToWithStepFunc == nil {
+		err = notMocked("CloseToWithStep")
+		return
+	}
+	return fake.CloseToWithStepFunc(id, destination, step)
+}
+
+func (fake *Lightning) CloseToTimeoutWithStep(id string, timeout uint, destination, step string) (result *glightning.CloseResult, err error) {
+	fake.record("CloseToTimeoutWithStep")
+	if fake.CloseToTimeoutWithStepFunc == nil {
+		err = notMocked("CloseToTimeoutWithStep")
+		return
+	}
+	return fake.CloseToTimeoutWithStepFunc(id, timeout, destination, step)
+}
+
+func (fake *Lightning) Close(id string, timeout uint, destination string) (result *glightning.CloseResult, err error) {
+	fake.record("Close")
+	if fake.CloseFunc == nil {
+		err = notMocked("Close")
+		return
+	}
+	return fake.CloseFunc(id, timeout, destination)
+}
+
+func (fake *Lightning) DevSignLastTx(peerId string) (result string, err error) {
+	fake.record("DevSignLastTx")
+	if fake.DevSignLastTxFunc == nil {
+		err = notMocked("DevSignLastTx")
+		return
+	}
+	return fake.DevSignLastTxFunc(peerId)
+}
+
+func (fake *Lightning) DevFail(peerId string) error {
+	fake.record("DevFail")
+	if fake.DevFailFunc == nil {
+		return notMocked("DevFail")
+	}
+	return fake.DevFailFunc(peerId)
+}
+
+func (fake *Lightning) DevReenableCommit(id string) error {
+	fake.record("DevReenableCommit")
+	if fake.DevReenableCommitFunc == nil {
+		return notMocked("DevReenableCommit")
+	}
+	return fake.DevReenableCommitFunc(id)
+}
+
+func (fake *Lightning) Ping(peerId string) (result *glightning.Pong, err error) {
+	fake.record("Ping")
+	if fake.PingFunc == nil {
+		err = notMocked("Ping")
+		return
+	}
+	return fake.PingFunc(peerId)
+}
+
+func (fake *Lightning) PingWithLen(peerId string, pingLen, pongByteLen uint) (result *glightning.Pong, err error) {
+	fake.record("PingWithLen")
+	if fake.PingWithLenFunc == nil {
+		err = notMocked("PingWithLen")
+		return
+	}
+	return fake.PingWithLenFunc(peerId, pingLen, pongByteLen)
+}
+
+func (fake *Lightning) DevMemDump() (result []*glightning.MemDumpEntry, err error) {
+	fake.record("DevMemDump")
+	if fake.DevMemDumpFunc == nil {
+		err = notMocked("DevMemDump")
+		return
+	}
+	return fake.DevMemDumpFunc()
+}
+
+func (fake *Lightning) DevMemLeak() (result []*glightning.MemLeak, err error) {
+	fake.record("DevMemLeak")
+	if fake.DevMemLeakFunc == nil {
+		err = notMocked("DevMemLeak")
+		return
+	}
+	return fake.DevMemLeakFunc()
+}
+
+func (fake *Lightning) Withdraw(destination string, amount *glightning.Sat, feerate *glightning.FeeRate, minConf *uint16) (result *glightning.WithdrawResult, err error) {
+	fake.record("Withdraw")
+	if fake.WithdrawFunc == nil {
+		err = notMocked("Withdraw")
+		return
+	}
+	return fake.WithdrawFunc(destination, amount, feerate, minConf)
+}
+
+func (fake *Lightning) WithdrawWithUtxos(destination string, amount *glightning.Sat, feerate *glightning.FeeRate, minConf *uint16, utxos []*glightning.Utxo) (result *glightning.WithdrawResult, err error) {
+	fake.record("WithdrawWithUtxos")
+	if fake.WithdrawWithUtxosFunc == nil {
+		err = notMocked("WithdrawWithUtxos")
+		return
+	}
+	return fake.WithdrawWithUtxosFunc(destination, amount, feerate, minConf, utxos)
+}
+
+func (fake *Lightning) NewAddr() (result string, err error) {
+	fake.record("NewAddr")
+	if fake.NewAddrFunc == nil {
+		err = notMocked("NewAddr")
+		return
+	}
+	return fake.NewAddrFunc()
+}
+
+func (fake *Lightning) NewAddress(addrType glightning.AddressType) (result *glightning.NewAddrResult, err error) {
+	fake.record("NewAddress")
+	if fake.NewAddressFunc == nil {
+		err = notMocked("NewAddress")
+		return
+	}
+	return fake.NewAddressFunc(addrType)
+}
+
+func (fake *Lightning) PrepareTx(outputs []*glightning.Outputs, feerate *glightning.FeeRate, minConf *uint16) (result *glightning.TxResult, err error) {
+	fake.record("PrepareTx")
+	if fake.PrepareTxFunc == nil {
+		err = notMocked("PrepareTx")
+		return
+	}
+	return fake.PrepareTxFunc(outputs, feerate, minConf)
+}
+
+func (fake *Lightning) PrepareTxWithUtxos(outputs []*glightning.Outputs, feerate *glightning.FeeRate, minConf *uint16, utxos []*glightning.Utxo) (result *glightning.TxResult, err error) {
+	fake.record("PrepareTxWithUtxos")
+	if fake.PrepareTxWithUtxosFunc == nil {
+		err = notMocked("PrepareTxWithUtxos")
+		return
+	}
+	return fake.PrepareTxWithUtxosFunc(outputs, feerate, minConf, utxos)
+}
+
+func (fake *Lightning) DiscardTx(txid string) (result *glightning.TxResult, err error) {
+	fake.record("DiscardTx")
+	if fake.DiscardTxFunc == nil {
+		err = notMocked("DiscardTx")
+		return
+	}
+	return fake.DiscardTxFunc(txid)
+}
+
+func (fake *Lightning) SendTx(txid string) (result *glightning.TxResult, err error) {
+	fake.record("SendTx")
+	if fake.SendTxFunc == nil {
+		err = notMocked("SendTx")
+		return
+	}
+	return fake.SendTxFunc(txid)
+}
+
+func (fake *Lightning) SetPsbtVersion(psbt string, version uint8) (result string, err error) {
+	fake.record("SetPsbtVersion")
+	if fake.SetPsbtVersionFunc == nil {
+		err = notMocked("SetPsbtVersion")
+		return
+	}
+	return fake.SetPsbtVersionFunc(psbt, version)
+}
+
+func (fake *Lightning) FundPsbt(amount *glightning.Sat, feerate *glightning.FeeRate, startWeight uint, reserve *uint32) (result *glightning.FundPsbtResult, err error) {
+	fake.record("FundPsbt")
+	if fake.FundPsbtFunc == nil {
+		err = notMocked("FundPsbt")
+		return
+	}
+	return fake.FundPsbtFunc(amount, feerate, startWeight, reserve)
+}
+
+func (fake *Lightning) FundPsbtWithOptions(req *glightning.FundPsbtRequest) (result *glightning.FundPsbtResult, err error) {
+	fake.record("FundPsbtWithOptions")
+	if fake.FundPsbtWithOptionsFunc == nil {
+		err = notMocked("FundPsbtWithOptions")
+		return
+	}
+	return fake.FundPsbtWithOptionsFunc(req)
+}
+
+func (fake *Lightning) SignPsbt(psbt string, signOnly []uint32) (result string, err error) {
+	fake.record("SignPsbt")
+	if fake.SignPsbtFunc == nil {
+		err = notMocked("SignPsbt")
+		return
+	}
+	return fake.SignPsbtFunc(psbt, signOnly)
+}
+
+func (fake *Lightning) UnreserveInputs(psbt string) (result []*glightning.PsbtReservation, err error) {
+	fake.record("UnreserveInputs")
+	if fake.UnreserveInputsFunc == nil {
+		err = notMocked("UnreserveInputs")
+		return
+	}
+	return fake.UnreserveInputsFunc(psbt)
+}
+
+func (fake *Lightning) AddPsbtOutput(amount *glightning.Sat, initialPsbt, destination string) (result *glightning.AddPsbtOutputResult, err error) {
+	fake.record("AddPsbtOutput")
+	if fake.AddPsbtOutputFunc == nil {
+		err = notMocked("AddPsbtOutput")
+		return
+	}
+	return fake.AddPsbtOutputFunc(amount, initialPsbt, destination)
+}
+
+func (fake *Lightning) SendPsbt(psbt string) (result *glightning.WithdrawResult, err error) {
+	fake.record("SendPsbt")
+	if fake.SendPsbtFunc == nil {
+		err = notMocked("SendPsbt")
+		return
+	}
+	return fake.SendPsbtFunc(psbt)
+}
+
+func (fake *Lightning) ListFunds() (result *glightning.FundsResult, err error) {
+	fake.record("ListFunds")
+	if fake.ListFundsFunc == nil {
+		err = notMocked("ListFunds")
+		return
+	}
+	return fake.ListFundsFunc()
+}
+
+func (fake *Lightning) ListForwards() (result []glightning.Forwarding, err error) {
+	fake.record("ListForwards")
+	if fake.ListForwardsFunc == nil {
+		err = notMocked("ListForwards")
+		return
+	}
+	return fake.ListForwardsFunc()
+}
+
+func (fake *Lightning) ListForwardsFiltered(req *glightning.ListForwardsRequest) (result []glightning.Forwarding, err error) {
+	fake.record("ListForwardsFiltered")
+	if fake.ListForwardsFilteredFunc == nil {
+		err = notMocked("ListForwardsFiltered")
+		return
+	}
+	return fake.ListForwardsFilteredFunc(req)
+}
+
+func (fake *Lightning) DevRescanOutputs() (result []glightning.Output, err error) {
+	fake.record("DevRescanOutputs")
+	if fake.DevRescanOutputsFunc == nil {
+		err = notMocked("DevRescanOutputs")
+		return
+	}
+	return fake.DevRescanOutputsFunc()
+}
+
+func (fake *Lightning) DevForgetChannel(peerId string, force bool) (result *glightning.ForgetChannelResult, err error) {
+	fake.record("DevForgetChannel")
+	if fake.DevForgetChannelFunc == nil {
+		err = notMocked("DevForgetChannel")
+		return
+	}
+	return fake.DevForgetChannelFunc(peerId, force)
+}
+
+func (fake *Lightning) DevForgetChannelByShortChannelId(peerId, shortChannelId string, force bool) (result *glightning.ForgetChannelResult, err error) {
+	fake.record("DevForgetChannelByShortChannelId")
+	if fake.DevForgetChannelByShortChannelIdFunc == nil {
+		err = notMocked("DevForgetChannelByShortChannelId")
+		return
+	}
+	return fake.DevForgetChannelByShortChannelIdFunc(peerId, shortChannelId, force)
+}
+
+func (fake *Lightning) DevForgetChannelByChannelId(peerId, channelId string, force bool) (result *glightning.ForgetChannelResult, err error) {
+	fake.record("DevForgetChannelByChannelId")
+	if fake.DevForgetChannelByChannelIdFunc == nil {
+		err = notMocked("DevForgetChannelByChannelId")
+		return
+	}
+	return fake.DevForgetChannelByChannelIdFunc(peerId, channelId, force)
+}
+
+func (fake *Lightning) SendCustomMessage(nodeId, message string) (result *glightning.CustomMessageResult, err error) {
+	fake.record("SendCustomMessage")
+	if fake.SendCustomMessageFunc == nil {
+		err = notMocked("SendCustomMessage")
+		return
+	}
+	return fake.SendCustomMessageFunc(nodeId, message)
+}
+
+func (fake *Lightning) SendCustomTlvMessage(nodeId string, msgType uint16, records []*tlv.Record) (result *glightning.CustomMessageResult, err error) {
+	fake.record("SendCustomTlvMessage")
+	if fake.SendCustomTlvMessageFunc == nil {
+		err = notMocked("SendCustomTlvMessage")
+		return
+	}
+	return fake.SendCustomTlvMessageFunc(nodeId, msgType, records)
+}
+
+func (fake *Lightning) Disconnect(peerId string, force bool) error {
+	fake.record("Disconnect")
+	if fake.DisconnectFunc == nil {
+		return notMocked("Disconnect")
+	}
+	return fake.DisconnectFunc(peerId, force)
+}
+
+func (fake *Lightning) FeeRates(style glightning.FeeRateStyle) (result *glightning.FeeRateEstimate, err error) {
+	fake.record("FeeRates")
+	if fake.FeeRatesFunc == nil {
+		err = notMocked("FeeRates")
+		return
+	}
+	return fake.FeeRatesFunc(style)
+}
+
+func (fake *Lightning) SetChannelFee(id string, baseMsat string, ppm uint32) (result *glightning.ChannelFeeResult, err error) {
+	fake.record("SetChannelFee")
+	if fake.SetChannelFeeFunc == nil {
+		err = notMocked("SetChannelFee")
+		return
+	}
+	return fake.SetChannelFeeFunc(id, baseMsat, ppm)
+}
+
+func (fake *Lightning) SetChannel(id string, baseMsat uint64, ppm uint32) (result *glightning.SetChannelResult, err error) {
+	fake.record("SetChannel")
+	if fake.SetChannelFunc == nil {
+		err = notMocked("SetChannel")
+		return
+	}
+	return fake.SetChannelFunc(id, baseMsat, ppm)
+}
+
+func (fake *Lightning) SetChannelWithOptions(req *glightning.SetChannelRequest) (result *glightning.SetChannelResult, err error) {
+	fake.record("SetChannelWithOptions")
+	if fake.SetChannelWithOptionsFunc == nil {
+		err = notMocked("SetChannelWithOptions")
+		return
+	}
+	return fake.SetChannelWithOptionsFunc(req)
+}
+
+func (fake *Lightning) ListPlugins() (result []glightning.PluginInfo, err error) {
+	fake.record("ListPlugins")
+	if fake.ListPluginsFunc == nil {
+		err = notMocked("ListPlugins")
+		return
+	}
+	return fake.ListPluginsFunc()
+}
+
+func (fake *Lightning) RescanPlugins() (result []glightning.PluginInfo, err error) {
+	fake.record("RescanPlugins")
+	if fake.RescanPluginsFunc == nil {
+		err = notMocked("RescanPlugins")
+		return
+	}
+	return fake.RescanPluginsFunc()
+}
+
+func (fake *Lightning) SetPluginStartDir(directory string) (result []glightning.PluginInfo, err error) {
+	fake.record("SetPluginStartDir")
+	if fake.SetPluginStartDirFunc == nil {
+		err = notMocked("SetPluginStartDir")
+		return
+	}
+	return fake.SetPluginStartDirFunc(directory)
+}
+
+func (fake *Lightning) StartPlugin(pluginName string) (result []glightning.PluginInfo, err error) {
+	fake.record("StartPlugin")
+	if fake.StartPluginFunc == nil {
+		err = notMocked("StartPlugin")
+		return
+	}
+	return fake.StartPluginFunc(pluginName)
+}
+
+func (fake *Lightning) StopPlugin(pluginName string) (result string, err error) {
+	fake.record("StopPlugin")
+	if fake.StopPluginFunc == nil {
+		err = notMocked("StopPlugin")
+		return
+	}
+	return fake.StopPluginFunc(pluginName)
+}
+
+func (fake *Lightning) GetSharedSecret(point string) (result string, err error) {
+	fake.record("GetSharedSecret")
+	if fake.GetSharedSecretFunc == nil {
+		err = notMocked("GetSharedSecret")
+		return
+	}
+	return fake.GetSharedSecretFunc(point)
+}
+
+func (fake *Lightning) GetFunderPolicy() (result *glightning.FunderPolicyResult, err error) {
+	fake.record("GetFunderPolicy")
+	if fake.GetFunderPolicyFunc == nil {
+		err = notMocked("GetFunderPolicy")
+		return
+	}
+	return fake.GetFunderPolicyFunc()
+}
+
+func (fake *Lightning) FunderUpdate(req *glightning.FunderUpdateRequest) (result *glightning.FunderPolicyResult, err error) {
+	fake.record("FunderUpdate")
+	if fake.FunderUpdateFunc == nil {
+		err = notMocked("FunderUpdate")
+		return
+	}
+	return fake.FunderUpdateFunc(req)
+}
+
+func (fake *Lightning) Sql(query string) (result [][]json.RawMessage, err error) {
+	fake.record("Sql")
+	if fake.SqlFunc == nil {
+		err = notMocked("Sql")
+		return
+	}
+	return fake.SqlFunc(query)
+}
+
+func (fake *Lightning) GetRoutes(source, destination string, amount *glightning.MSat, layers []string, maxFee *glightning.MSat, finalCltv uint32) (result *glightning.GetRoutesResult, err error) {
+	fake.record("GetRoutes")
+	if fake.GetRoutesFunc == nil {
+		err = notMocked("GetRoutes")
+		return
+	}
+	return fake.GetRoutesFunc(source, destination, amount, layers, maxFee, finalCltv)
+}
+
+func (fake *Lightning) AskReneCreateLayer(layer string, persistent bool) (result *glightning.AskReneLayer, err error) {
+	fake.record("AskReneCreateLayer")
+	if fake.AskReneCreateLayerFunc == nil {
+		err = notMocked("AskReneCreateLayer")
+		return
+	}
+	return fake.AskReneCreateLayerFunc(layer, persistent)
+}
+
+func (fake *Lightning) AskReneRemoveLayer(layer string) error {
+	fake.record("AskReneRemoveLayer")
+	if fake.AskReneRemoveLayerFunc == nil {
+		return notMocked("AskReneRemoveLayer")
+	}
+	return fake.AskReneRemoveLayerFunc(layer)
+}
+
+func (fake *Lightning) AskReneListLayers() (result []*glightning.AskReneLayer, err error) {
+	fake.record("AskReneListLayers")
+	if fake.AskReneListLayersFunc == nil {
+		err = notMocked("AskReneListLayers")
+		return
+	}
+	return fake.AskReneListLayersFunc()
+}
+
+func (fake *Lightning) AskReneGetLayer(layer string) (result *glightning.AskReneLayer, err error) {
+	fake.record("AskReneGetLayer")
+	if fake.AskReneGetLayerFunc == nil {
+		err = notMocked("AskReneGetLayer")
+		return
+	}
+	return fake.AskReneGetLayerFunc(layer)
+}
+
+func (fake *Lightning) AskReneInformChannel(layer, scidDir string, amount *glightning.MSat, inform glightning.AskReneInform) (result []*glightning.AskReneConstraint, err error) {
+	fake.record("AskReneInformChannel")
+	if fake.AskReneInformChannelFunc == nil {
+		err = notMocked("AskReneInformChannel")
+		return
+	}
+	return fake.AskReneInformChannelFunc(layer, scidDir, amount, inform)
+}
+
+func (fake *Lightning) AskReneDisableNode(layer, node string) error {
+	fake.record("AskReneDisableNode")
+	if fake.AskReneDisableNodeFunc == nil {
+		return notMocked("AskReneDisableNode")
+	}
+	return fake.AskReneDisableNodeFunc(layer, node)
+}
+
+func (fake *Lightning) AskReneBiasChannel(layer, scidDir string, bias int, description string, relative bool) (result []*glightning.AskReneBias, err error) {
+	fake.record("AskReneBiasChannel")
+	if fake.AskReneBiasChannelFunc == nil {
+		err = notMocked("AskReneBiasChannel")
+		return
+	}
+	return fake.AskReneBiasChannelFunc(layer, scidDir, bias, description, relative)
+}
+
+func (fake *Lightning) AskReneCreateChannel(layer, source, destination, shortChannelId string, capacity *glightning.MSat) error {
+	fake.record("AskReneCreateChannel")
+	if fake.AskReneCreateChannelFunc == nil {
+		return notMocked("AskReneCreateChannel")
+	}
+	return fake.AskReneCreateChannelFunc(layer, source, destination, shortChannelId, capacity)
+}
+
+func (fake *Lightning) AskReneReserve(path []*glightning.AskReneReservation) error {
+	fake.record("AskReneReserve")
+	if fake.AskReneReserveFunc == nil {
+		return notMocked("AskReneReserve")
+	}
+	return fake.AskReneReserveFunc(path)
+}
+
+func (fake *Lightning) AskReneUnreserve(path []*glightning.AskReneReservation) error {
+	fake.record("AskReneUnreserve")
+	if fake.AskReneUnreserveFunc == nil {
+		return notMocked("AskReneUnreserve")
+	}
+	return fake.AskReneUnreserveFunc(path)
+}
+
+func (fake *Lightning) AskReneAge(layer string, cutoff uint64) (result *glightning.AskReneAgeResult, err error) {
+	fake.record("AskReneAge")
+	if fake.AskReneAgeFunc == nil {
+		err = notMocked("AskReneAge")
+		return
+	}
+	return fake.AskReneAgeFunc(layer, cutoff)
+}
+
+func (fake *Lightning) Commando(peerId, rune, method string, params map[string]interface{}, resp interface{}) error {
+	fake.record("Commando")
+	if fake.CommandoFunc == nil {
+		return notMocked("Commando")
+	}
+	return fake.CommandoFunc(peerId, rune, method, params, resp)
+}
+
+func (fake *Lightning) CreateRune(restrictions [][]string) (result *glightning.Rune, err error) {
+	fake.record("CreateRune")
+	if fake.CreateRuneFunc == nil {
+		err = notMocked("CreateRune")
+		return
+	}
+	return fake.CreateRuneFunc(restrictions)
+}
+
+func (fake *Lightning) CheckRune(rune, nodeId, method string, params map[string]interface{}) (result bool, err error) {
+	fake.record("CheckRune")
+	if fake.CheckRuneFunc == nil {
+		err = notMocked("CheckRune")
+		return
+	}
+	return fake.CheckRuneFunc(rune, nodeId, method, params)
+}
+
+func (fake *Lightning) DetectVersion() (result *glightning.Version, err error) {
+	fake.record("DetectVersion")
+	if fake.DetectVersionFunc == nil {
+		err = notMocked("DetectVersion")
+		return
+	}
+	return fake.DetectVersionFunc()
+}
+
+func (fake *Lightning) Version() (result *glightning.Version) {
+	fake.record("Version")
+	if fake.VersionFunc == nil {
+		return
+	}
+	return fake.VersionFunc()
+}
+
+func (fake *Lightning) ConnectBestEffort(nodeId string) (result *glightning.ConnectBestEffortResult, err error) {
+	fake.record("ConnectBestEffort")
+	if fake.ConnectBestEffortFunc == nil {
+		err = notMocked("ConnectBestEffort")
+		return
+	}
+	return fake.ConnectBestEffortFunc(nodeId)
+}
+
+func (fake *Lightning) ConnectAddresses(nodeId string, addresses []glightning.Address) (result *glightning.ConnectBestEffortResult, err error) {
+	fake.record("ConnectAddresses")
+	if fake.ConnectAddressesFunc == nil {
+		err = notMocked("ConnectAddresses")
+		return
+	}
+	return fake.ConnectAddressesFunc(nodeId, addresses)
+}
+
+func (fake *Lightning) SetDatastore(key []string, value string, mode glightning.DatastoreMode) (result *glightning.DatastoreEntry, err error) {
+	fake.record("SetDatastore")
+	if fake.SetDatastoreFunc == nil {
+		err = notMocked("SetDatastore")
+		return
+	}
+	return fake.SetDatastoreFunc(key, value, mode)
+}
+
+func (fake *Lightning) ListDatastore(key []string) (result []*glightning.DatastoreEntry, err error) {
+	fake.record("ListDatastore")
+	if fake.ListDatastoreFunc == nil {
+		err = notMocked("ListDatastore")
+		return
+	}
+	return fake.ListDatastoreFunc(key)
+}
+
+func (fake *Lightning) GetDatastore(key []string) (result *glightning.DatastoreEntry, err error) {
+	fake.record("GetDatastore")
+	if fake.GetDatastoreFunc == nil {
+		err = notMocked("GetDatastore")
+		return
+	}
+	return fake.GetDatastoreFunc(key)
+}
+
+func (fake *Lightning) DelDatastore(key []string) (result *glightning.DatastoreEntry, err error) {
+	fake.record("DelDatastore")
+	if fake.DelDatastoreFunc == nil {
+		err = notMocked("DelDatastore")
+		return
+	}
+	return fake.DelDatastoreFunc(key)
+}
+
+func (fake *Lightning) WithdrawWithStrategy(destination string, amount *glightning.Sat, strategy glightning.FeeStrategy, minConf *uint16) (result *glightning.WithdrawResult, err error) {
+	fake.record("WithdrawWithStrategy")
+	if fake.WithdrawWithStrategyFunc == nil {
+		err = notMocked("WithdrawWithStrategy")
+		return
+	}
+	return fake.WithdrawWithStrategyFunc(destination, amount, strategy, minConf)
+}
+
+func (fake *Lightning) FundChannelWithStrategy(id string, amount *glightning.Sat, strategy glightning.FeeStrategy, announce bool, minConf *uint16) (result *glightning.FundChannelResult, err error) {
+	fake.record("FundChannelWithStrategy")
+	if fake.FundChannelWithStrategyFunc == nil {
+		err = notMocked("FundChannelWithStrategy")
+		return
+	}
+	return fake.FundChannelWithStrategyFunc(id, amount, strategy, announce, minConf)
+}
+
+func (fake *Lightning) PrepareTxWithStrategy(outputs []*glightning.Outputs, strategy glightning.FeeStrategy, minConf *uint16) (result *glightning.TxResult, err error) {
+	fake.record("PrepareTxWithStrategy")
+	if fake.PrepareTxWithStrategyFunc == nil {
+		err = notMocked("PrepareTxWithStrategy")
+		return
+	}
+	return fake.PrepareTxWithStrategyFunc(outputs, strategy, minConf)
+}
+
+func (fake *Lightning) ListChannelsIter(batchSize int) (result *glightning.ChannelIterator) {
+	fake.record("ListChannelsIter")
+	if fake.ListChannelsIterFunc == nil {
+		return
+	}
+	return fake.ListChannelsIterFunc(batchSize)
+}
+
+func (fake *Lightning) ListForwardsIter(batchSize int) (result *glightning.ForwardIterator) {
+	fake.record("ListForwardsIter")
+	if fake.ListForwardsIterFunc == nil {
+		return
+	}
+	return fake.ListForwardsIterFunc(batchSize)
+}
+
+func (fake *Lightning) ListInvoicesIter(batchSize int) (result *glightning.InvoiceIterator) {
+	fake.record("ListInvoicesIter")
+	if fake.ListInvoicesIterFunc == nil {
+		return
+	}
+	return fake.ListInvoicesIterFunc(batchSize)
+}
+
+func (fake *Lightning) DetectNetwork() (result network.Network, err error) {
+	fake.record("DetectNetwork")
+	if fake.DetectNetworkFunc == nil {
+		err = notMocked("DetectNetwork")
+		return
+	}
+	return fake.DetectNetworkFunc()
+}
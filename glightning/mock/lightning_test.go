@@ -0,0 +1,49 @@
+package mock_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// code under test only needs the interface
+func payAndReport(ln glightning.LightningClient, bolt11 string) (string, error) {
+	paid, err := ln.PayBolt(bolt11)
+	if err != nil {
+		return "", err
+	}
+	return paid.PaymentPreimage, nil
+}
+
+func TestMockPay(t *testing.T) {
+	ln := mock.New()
+	ln.PayBoltFunc = func(bolt11 string) (*glightning.PaymentSuccess, error) {
+		assert.Equal(t, "lnbcrt1", bolt11)
+		return &glightning.PaymentSuccess{
+			SendPayFields: glightning.SendPayFields{PaymentPreimage: "aa"},
+		}, nil
+	}
+
+	preimage, err := payAndReport(ln, "lnbcrt1")
+	assert.NoError(t, err)
+	assert.Equal(t, "aa", preimage)
+	assert.Equal(t, []string{"PayBolt"}, ln.Calls())
+	assert.Equal(t, 1, ln.CallCount("PayBolt"))
+}
+
+func TestMockNotMocked(t *testing.T) {
+	ln := mock.New()
+	_, err := ln.GetInfo()
+	assert.True(t, errors.Is(err, mock.ErrNotMocked))
+
+	ok, pubkey, err := ln.CheckMessage("msg", "zbase")
+	assert.False(t, ok)
+	assert.Equal(t, "", pubkey)
+	assert.Error(t, err)
+
+	assert.False(t, ln.IsUp())
+	assert.Equal(t, []string{"GetInfo", "CheckMessage", "IsUp"}, ln.Calls())
+}
@@ -0,0 +1,297 @@
+package glightning
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// The states of a Monitor's circuit breaker
+type BreakerState int
+
+const (
+	// lightningd is healthy; calls go through
+	BreakerClosed BreakerState = iota
+	// lightningd is unresponsive; calls fail fast with ErrCircuitOpen
+	BreakerOpen
+	// the cooldown has passed; the next call or ping is let through
+	// to test whether lightningd has recovered
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Returned by Monitor.Call while the circuit breaker is open
+var ErrCircuitOpen = errors.New("Circuit breaker is open: lightningd is unresponsive")
+
+// A call which took longer than Monitor.Timeout
+var ErrCallTimeout = errors.New("Call to lightningd timed out")
+
+// Error rate and latency over a Monitor's recent calls
+type MonitorStats struct {
+	State BreakerState
+	// The number of calls in the window, and how many of them failed
+	Calls     int
+	Errors    int
+	ErrorRate float64
+	// Of the calls in the window
+	AvgLatency time.Duration
+	MaxLatency time.Duration
+	// Failures since the last success
+	ConsecutiveFailures int
+	LastError           error
+	LastSuccess         time.Time
+}
+
+type callSample struct {
+	latency time.Duration
+	failed  bool
+}
+
+// Monitor tracks the error rate and latency of calls to lightningd,
+// and trips a circuit breaker once it looks unresponsive, so services
+// depending on it can degrade gracefully instead of piling up calls
+// which are never going to return.
+//
+// Calls are recorded by running them through Call, or with Record.
+// Once started, the monitor also pings lightningd with getinfo every
+// Interval, so the breaker trips (and recovers) even when nothing
+// else is calling.
+type Monitor struct {
+	// How often to ping lightningd. Defaults to 10 seconds.
+	Interval time.Duration
+	// Calls taking longer than this count as failed. Defaults to 30
+	// seconds.
+	Timeout time.Duration
+	// Consecutive failures which trip the breaker. Defaults to 3.
+	FailureThreshold int
+	// How long the breaker stays open before letting a call through
+	// to test lightningd again. Defaults to 30 seconds.
+	Cooldown time.Duration
+	// The number of recent calls stats are kept over. Defaults to 100.
+	Window int
+	// Called whenever the breaker changes state, from the goroutine
+	// whose call caused the change
+	OnStateChange func(from, to BreakerState)
+
+	client LightningClient
+
+	mu          sync.Mutex
+	state       BreakerState
+	openedAt    time.Time
+	probing     bool
+	samples     []callSample
+	next        int
+	failures    int
+	lastErr     error
+	lastSuccess time.Time
+	changes     []BreakerState
+
+	done     chan struct{}
+	exited   chan struct{}
+	stopOnce sync.Once
+}
+
+func NewMonitor(client LightningClient) *Monitor {
+	return &Monitor{
+		Interval:         10 * time.Second,
+		Timeout:          30 * time.Second,
+		FailureThreshold: 3,
+		Cooldown:         30 * time.Second,
+		Window:           100,
+		client:           client,
+	}
+}
+
+// Start pinging lightningd in the background
+func (m *Monitor) Start() {
+	m.done = make(chan struct{})
+	m.exited = make(chan struct{})
+	go m.run()
+}
+
+// Stop pinging, waiting for any ping in progress to return
+func (m *Monitor) Stop() {
+	if m.done == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+	<-m.exited
+}
+
+func (m *Monitor) run() {
+	defer close(m.exited)
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.Ping()
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// Ping lightningd with getinfo, recording the result. Skipped,
+// returning ErrCircuitOpen, while the breaker is open.
+func (m *Monitor) Ping() error {
+	return m.Call(func() error {
+		_, err := m.client.GetInfo()
+		return err
+	})
+}
+
+// Run {call} unless the breaker is open, recording its latency and
+// whether it failed. A call taking longer than Timeout fails with
+// ErrCallTimeout; it's left to finish in the background.
+func (m *Monitor) Call(call func() error) error {
+	if !m.allow() {
+		return ErrCircuitOpen
+	}
+
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		result <- call()
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-time.After(m.Timeout):
+		err = ErrCallTimeout
+	}
+	m.Record(time.Since(start), err)
+	return err
+}
+
+// Whether a call may go through now. While half-open only one call
+// at a time is let through.
+func (m *Monitor) allow() bool {
+	m.mu.Lock()
+	defer m.unlock()
+
+	switch m.state {
+	case BreakerOpen:
+		if time.Since(m.openedAt) < m.Cooldown {
+			return false
+		}
+		m.setState(BreakerHalfOpen)
+		m.probing = true
+		return true
+	case BreakerHalfOpen:
+		if m.probing {
+			return false
+		}
+		m.probing = true
+		return true
+	}
+	return true
+}
+
+// Record a call made to lightningd outside of Call
+func (m *Monitor) Record(latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	window := m.Window
+	if window <= 0 {
+		window = 1
+	}
+	sample := callSample{latency: latency, failed: err != nil}
+	if len(m.samples) < window {
+		m.samples = append(m.samples, sample)
+	} else {
+		m.samples[m.next%len(m.samples)] = sample
+		m.next++
+	}
+
+	m.probing = false
+	if err == nil {
+		m.failures = 0
+		m.lastSuccess = time.Now()
+		if m.state != BreakerClosed {
+			m.setState(BreakerClosed)
+		}
+		return
+	}
+
+	m.failures++
+	m.lastErr = err
+	if m.state == BreakerHalfOpen || (m.state == BreakerClosed && m.failures >= m.FailureThreshold) {
+		m.openedAt = time.Now()
+		m.setState(BreakerOpen)
+	}
+}
+
+// Must hold mu. OnStateChange is called once it's released.
+func (m *Monitor) setState(state BreakerState) {
+	m.changes = append(m.changes, m.state, state)
+	m.state = state
+}
+
+// Release mu, then report any state changes made while holding it
+func (m *Monitor) unlock() {
+	changes := m.changes
+	m.changes = nil
+	m.mu.Unlock()
+	if m.OnStateChange == nil {
+		return
+	}
+	for i := 0; i+1 < len(changes); i += 2 {
+		m.OnStateChange(changes[i], changes[i+1])
+	}
+}
+
+func (m *Monitor) State() BreakerState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Whether the breaker is closed
+func (m *Monitor) Healthy() bool {
+	return m.State() == BreakerClosed
+}
+
+func (m *Monitor) Stats() MonitorStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := MonitorStats{
+		State:               m.state,
+		Calls:               len(m.samples),
+		ConsecutiveFailures: m.failures,
+		LastError:           m.lastErr,
+		LastSuccess:         m.lastSuccess,
+	}
+	var total time.Duration
+	for _, sample := range m.samples {
+		if sample.failed {
+			stats.Errors++
+		}
+		total += sample.latency
+		if sample.latency > stats.MaxLatency {
+			stats.MaxLatency = sample.latency
+		}
+	}
+	if stats.Calls > 0 {
+		stats.ErrorRate = float64(stats.Errors) / float64(stats.Calls)
+		stats.AvgLatency = total / time.Duration(stats.Calls)
+	}
+	return stats
+}
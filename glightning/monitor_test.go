@@ -0,0 +1,119 @@
+package glightning_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitorBreaker(t *testing.T) {
+	ln := mock.New()
+	down := errors.New("connection refused")
+	var pingErr error
+	ln.GetInfoFunc = func() (*glightning.NodeInfo, error) {
+		return &glightning.NodeInfo{}, pingErr
+	}
+
+	monitor := glightning.NewMonitor(ln)
+	monitor.Cooldown = 20 * time.Millisecond
+	var changes []string
+	monitor.OnStateChange = func(from, to glightning.BreakerState) {
+		// callbacks may query the monitor
+		assert.Equal(t, to, monitor.State())
+		changes = append(changes, from.String()+"->"+to.String())
+	}
+
+	assert.NoError(t, monitor.Ping())
+	assert.True(t, monitor.Healthy())
+
+	pingErr = down
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, down, monitor.Ping())
+	}
+	assert.Equal(t, glightning.BreakerOpen, monitor.State())
+
+	// fails fast while open
+	assert.Equal(t, glightning.ErrCircuitOpen, monitor.Ping())
+	assert.Equal(t, 4, ln.CallCount("GetInfo"))
+
+	// a failed test call re-opens it
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, down, monitor.Ping())
+	assert.Equal(t, glightning.BreakerOpen, monitor.State())
+
+	// and a successful one closes it
+	pingErr = nil
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, monitor.Ping())
+	assert.True(t, monitor.Healthy())
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+	}, changes)
+
+	stats := monitor.Stats()
+	assert.Equal(t, 6, stats.Calls)
+	assert.Equal(t, 4, stats.Errors)
+	assert.InDelta(t, 4.0/6, stats.ErrorRate, 0.001)
+	assert.Equal(t, 0, stats.ConsecutiveFailures)
+	assert.Equal(t, down, stats.LastError)
+}
+
+func TestMonitorTimeout(t *testing.T) {
+	monitor := glightning.NewMonitor(mock.New())
+	monitor.Timeout = 10 * time.Millisecond
+	monitor.FailureThreshold = 1
+
+	release := make(chan struct{})
+	defer close(release)
+	err := monitor.Call(func() error {
+		<-release
+		return nil
+	})
+	assert.Equal(t, glightning.ErrCallTimeout, err)
+	assert.Equal(t, glightning.BreakerOpen, monitor.State())
+	assert.True(t, monitor.Stats().MaxLatency >= 10*time.Millisecond)
+}
+
+func TestMonitorWindow(t *testing.T) {
+	monitor := glightning.NewMonitor(mock.New())
+	monitor.Window = 4
+	monitor.FailureThreshold = 100
+
+	for i := 0; i < 4; i++ {
+		monitor.Record(time.Millisecond, errors.New("fail"))
+	}
+	for i := 0; i < 3; i++ {
+		monitor.Record(5*time.Millisecond, nil)
+	}
+	stats := monitor.Stats()
+	assert.Equal(t, 4, stats.Calls)
+	assert.Equal(t, 1, stats.Errors)
+	assert.Equal(t, 4*time.Millisecond, stats.AvgLatency)
+}
+
+func TestMonitorPings(t *testing.T) {
+	ln := mock.New()
+	ln.GetInfoFunc = func() (*glightning.NodeInfo, error) {
+		return &glightning.NodeInfo{}, nil
+	}
+	monitor := glightning.NewMonitor(ln)
+	monitor.Interval = 5 * time.Millisecond
+	monitor.Start()
+	time.Sleep(30 * time.Millisecond)
+	monitor.Stop()
+	monitor.Stop()
+
+	pings := ln.CallCount("GetInfo")
+	assert.True(t, pings >= 2)
+	time.Sleep(15 * time.Millisecond)
+	assert.Equal(t, pings, ln.CallCount("GetInfo"))
+}
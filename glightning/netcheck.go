@@ -0,0 +1,34 @@
+package glightning
+
+import (
+	"github.com/elementsproject/glightning/glightning/network"
+)
+
+// Have Withdraw and PrepareTx check their destinations are addresses
+// for {net} before sending them to lightningd, failing with a
+// *network.AddressError if not. See DetectNetwork.
+func (l *Lightning) SetNetwork(net network.Network) {
+	l.network = &net
+}
+
+// Ask lightningd (via getinfo) which network it's on, and check
+// destinations against it from now on, as with SetNetwork
+func (l *Lightning) DetectNetwork() (network.Network, error) {
+	info, err := l.GetInfo()
+	if err != nil {
+		return 0, err
+	}
+	net, err := network.Parse(info.Network)
+	if err != nil {
+		return 0, err
+	}
+	l.SetNetwork(net)
+	return net, nil
+}
+
+func (l *Lightning) checkAddress(address string) error {
+	if l.network == nil {
+		return nil
+	}
+	return l.network.ValidateAddress(address)
+}
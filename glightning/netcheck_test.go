@@ -0,0 +1,40 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectNetwork(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"getinfo","params":{},"id":1}`
+	resp := wrapResult(1, `{"id": "02aa", "network": "regtest"}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	net, err := lightning.DetectNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, network.Regtest, net)
+
+	// a mainnet address is refused without asking lightningd
+	mainnet := "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	_, err = lightning.Withdraw(mainnet, glightning.NewSat(500000), nil, nil)
+	assert.EqualError(t, err, `Address "`+mainnet+`" is not for regtest`)
+	if assert.IsType(t, &network.AddressError{}, err) {
+		assert.Equal(t, network.AddressWrongNetwork, err.(*network.AddressError).Kind)
+	}
+	_, err = lightning.PrepareTx([]*glightning.Outputs{{Address: mainnet, Satoshi: 1000}}, nil, nil)
+	assert.Error(t, err)
+
+	addr := "bcrt1qx5yjs8y4vm929ykzpmm8r7yxwakyvjwmyc5mkm"
+	req = `{"jsonrpc":"2.0","method":"withdraw","params":{"destination":"` + addr + `","satoshi":"500000"},"id":2}`
+	go runServerSide(t, req, wrapResult(2, `{"txid": "f804"}`), replyQ, requestQ)
+	result, err := lightning.Withdraw(addr, glightning.NewSat(500000), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "f804", result.TxId)
+}
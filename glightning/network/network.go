@@ -0,0 +1,135 @@
+// Package network names the bitcoin networks lightningd runs on, as
+// reported by getinfo, and checks addresses belong to one, so that a
+// mainnet node isn't asked to send to a testnet address (or the
+// reverse):
+//
+//	info, _ := ln.GetInfo()
+//	net, _ := network.Parse(info.Network)
+//	if err := net.ValidateAddress(destination); err != nil {
+//		...
+//	}
+//
+// Lightning.SetNetwork has Withdraw and PrepareTx do this check.
+package network
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+type Network int
+
+const (
+	Bitcoin Network = iota
+	Testnet
+	Signet
+	Regtest
+)
+
+var names = []string{"bitcoin", "testnet", "signet", "regtest"}
+
+// The network's name, as in getinfo
+func (n Network) String() string {
+	if n < 0 || int(n) >= len(names) {
+		return fmt.Sprintf("Network(%d)", int(n))
+	}
+	return names[n]
+}
+
+// Parse getinfo's 'network'
+func Parse(name string) (Network, error) {
+	for i, n := range names {
+		if name == n {
+			return Network(i), nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown network %q", name)
+}
+
+// The network's parameters, for btcutil
+func (n Network) Params() *chaincfg.Params {
+	switch n {
+	case Testnet:
+		return &chaincfg.TestNet3Params
+	case Signet:
+		return &chaincfg.SigNetParams
+	case Regtest:
+		return &chaincfg.RegressionNetParams
+	default:
+		return &chaincfg.MainNetParams
+	}
+}
+
+// Why an address was refused
+type AddressErrorKind int
+
+const (
+	// Not an address on any network: a typo, a bad checksum, or a
+	// segwit v1+ address using bech32 rather than bech32m
+	AddressInvalid AddressErrorKind = iota
+	// A valid address, for another network
+	AddressWrongNetwork
+)
+
+// The error DecodeAddress and ValidateAddress return, so callers can
+// tell a mistyped address from one for the wrong network
+type AddressError struct {
+	Kind    AddressErrorKind
+	Address string
+	// The network the address was checked against
+	Network Network
+	// For AddressWrongNetwork, the network the address is for.
+	// Testnet and signet addresses are indistinguishable; both are
+	// reported as Testnet.
+	AddressNetwork Network
+	// For AddressInvalid, btcutil's reason
+	Err error
+}
+
+func (e *AddressError) Error() string {
+	if e.Kind == AddressWrongNetwork {
+		return fmt.Sprintf("Address %q is not for %s", e.Address, e.Network)
+	}
+	return fmt.Sprintf("Invalid %s address %q: %s", e.Network, e.Address, e.Err)
+}
+
+// Decode {address}, failing with an *AddressError unless it's valid
+// and for this network. Testnet and signet share address formats, so
+// each accepts the other's, and regtest accepts their legacy (base58)
+// addresses.
+func (n Network) DecodeAddress(address string) (btcutil.Address, error) {
+	params := n.Params()
+	addr, err := btcutil.DecodeAddress(address, params)
+	if err == nil && addr.IsForNet(params) {
+		return addr, nil
+	}
+	for _, other := range []Network{Bitcoin, Testnet, Regtest} {
+		if other == n {
+			continue
+		}
+		if a, err := btcutil.DecodeAddress(address, other.Params()); err == nil && a.IsForNet(other.Params()) {
+			return nil, &AddressError{
+				Kind:           AddressWrongNetwork,
+				Address:        address,
+				Network:        n,
+				AddressNetwork: other,
+			}
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("unknown address type")
+	}
+	return nil, &AddressError{
+		Kind:    AddressInvalid,
+		Address: address,
+		Network: n,
+		Err:     err,
+	}
+}
+
+func (n Network) ValidateAddress(address string) error {
+	_, err := n.DecodeAddress(address)
+	return err
+}
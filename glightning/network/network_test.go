@@ -0,0 +1,68 @@
+package network_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, name := range []string{"bitcoin", "testnet", "signet", "regtest"} {
+		net, err := network.Parse(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, name, net.String())
+	}
+	_, err := network.Parse("liquid")
+	assert.EqualError(t, err, `Unknown network "liquid"`)
+}
+
+func TestValidateAddress(t *testing.T) {
+	mainnet := "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	testnet := "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+	regtest := "bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080"
+
+	assert.NoError(t, network.Bitcoin.ValidateAddress(mainnet))
+	assert.NoError(t, network.Bitcoin.ValidateAddress("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"))
+	assert.EqualError(t, network.Bitcoin.ValidateAddress(testnet), `Address "`+testnet+`" is not for bitcoin`)
+
+	assert.NoError(t, network.Testnet.ValidateAddress(testnet))
+	assert.NoError(t, network.Signet.ValidateAddress(testnet))
+	assert.Error(t, network.Signet.ValidateAddress(mainnet))
+
+	assert.NoError(t, network.Regtest.ValidateAddress(regtest))
+	assert.Error(t, network.Regtest.ValidateAddress(testnet))
+
+	assert.Error(t, network.Bitcoin.ValidateAddress("bc1qnotanaddress"))
+}
+
+func TestAddressError(t *testing.T) {
+	// BIP350's taproot address, and the same with a bech32 checksum
+	taproot := "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0"
+	bech32 := "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd"
+	assert.NoError(t, network.Bitcoin.ValidateAddress(taproot))
+
+	err := network.Bitcoin.ValidateAddress(bech32)
+	if assert.IsType(t, &network.AddressError{}, err) {
+		addrErr := err.(*network.AddressError)
+		assert.Equal(t, network.AddressInvalid, addrErr.Kind)
+		assert.Equal(t, bech32, addrErr.Address)
+		assert.Error(t, addrErr.Err)
+	}
+
+	err = network.Regtest.ValidateAddress(taproot)
+	if assert.IsType(t, &network.AddressError{}, err) {
+		addrErr := err.(*network.AddressError)
+		assert.Equal(t, network.AddressWrongNetwork, addrErr.Kind)
+		assert.Equal(t, network.Regtest, addrErr.Network)
+		assert.Equal(t, network.Bitcoin, addrErr.AddressNetwork)
+	}
+	assert.EqualError(t, err, `Address "`+taproot+`" is not for regtest`)
+
+	err = network.Bitcoin.ValidateAddress("tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx")
+	assert.Equal(t, network.Testnet, err.(*network.AddressError).AddressNetwork)
+	err = network.Bitcoin.ValidateAddress("bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080")
+	assert.Equal(t, network.Regtest, err.(*network.AddressError).AddressNetwork)
+}
@@ -0,0 +1,22 @@
+package glightning
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// Check {id} is a node id: the hex encoding of a 33-byte compressed
+// pubkey
+func checkNodeId(id string) error {
+	if len(id) != 66 {
+		return fmt.Errorf("Invalid node id %q: must be 33 bytes of hex", id)
+	}
+	b, err := hex.DecodeString(id)
+	if err != nil {
+		return fmt.Errorf("Invalid node id %q: %s", id, err)
+	}
+	if b[0] != 0x02 && b[0] != 0x03 {
+		return fmt.Errorf("Invalid node id %q: not a compressed pubkey", id)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMalformedNodeIdRejected(t *testing.T) {
+	// no server: these must fail before anything is sent
+	lightning := glightning.NewLightning()
+	for _, bad := range []string{
+		"",
+		"02cc",
+		"02cca6c5c966fcf61d121e3a70e03a1cd9eeeea024b26ea666ce974d43b242e6",
+		"04cca6c5c966fcf61d121e3a70e03a1cd9eeeea024b26ea666ce974d43b242e636",
+		"02cca6c5c966fcf61d121e3a70e03a1cd9eeeea024b26ea666ce974d43b242e6zz",
+	} {
+		_, err := lightning.Connect(bad, "localhost", 9735)
+		assert.Error(t, err, bad)
+	}
+	_, err := lightning.ConnectPeer("02cc@localhost:9735", "", 0)
+	assert.Error(t, err)
+	_, err = lightning.FundChannel("02cc", glightning.NewSat(100000))
+	assert.Error(t, err)
+	_, err = lightning.GetRouteSimple("02cc", 1000, 10)
+	assert.Error(t, err)
+	_, err = lightning.Ping("02cc")
+	assert.Error(t, err)
+	assert.Error(t, lightning.Disconnect("02cc", false))
+}
@@ -0,0 +1,269 @@
+package glightning
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/elementsproject/glightning/glightning/tlv"
+)
+
+// BOLT#4 failure code flags
+const (
+	FailFlagBadOnion = 0x8000
+	FailFlagPerm     = 0x4000
+	FailFlagNode     = 0x2000
+	FailFlagUpdate   = 0x1000
+)
+
+// BOLT#4 failure codes
+const (
+	FailCodeInvalidRealm                     = FailFlagPerm | 1
+	FailCodeTemporaryNodeFailure             = FailFlagNode | 2
+	FailCodePermanentNodeFailure             = FailFlagPerm | FailFlagNode | 2
+	FailCodeRequiredNodeFeatureMissing       = FailFlagPerm | FailFlagNode | 3
+	FailCodeInvalidOnionVersion              = FailFlagBadOnion | FailFlagPerm | 4
+	FailCodeInvalidOnionHmac                 = FailFlagBadOnion | FailFlagPerm | 5
+	FailCodeInvalidOnionKey                  = FailFlagBadOnion | FailFlagPerm | 6
+	FailCodeTemporaryChannelFailure          = FailFlagUpdate | 7
+	FailCodePermanentChannelFailure          = FailFlagPerm | 8
+	FailCodeRequiredChannelFeatureMissing    = FailFlagPerm | 9
+	FailCodeUnknownNextPeer                  = FailFlagPerm | 10
+	FailCodeAmountBelowMinimum               = FailFlagUpdate | 11
+	FailCodeFeeInsufficient                  = FailFlagUpdate | 12
+	FailCodeIncorrectCltvExpiry              = FailFlagUpdate | 13
+	FailCodeExpiryTooSoon                    = FailFlagUpdate | 14
+	FailCodeIncorrectOrUnknownPaymentDetails = FailFlagPerm | 15
+	FailCodeFinalIncorrectCltvExpiry         = 18
+	FailCodeFinalIncorrectHtlcAmount         = 19
+	FailCodeChannelDisabled                  = FailFlagUpdate | 20
+	FailCodeExpiryTooFar                     = 21
+	FailCodeInvalidOnionPayload              = FailFlagPerm | 22
+	FailCodeMppTimeout                       = 23
+	FailCodeInvalidOnionBlinding             = FailFlagBadOnion | FailFlagPerm | 24
+)
+
+var failCodeNames = map[int]string{
+	FailCodeInvalidRealm:                     "WIRE_INVALID_REALM",
+	FailCodeTemporaryNodeFailure:             "WIRE_TEMPORARY_NODE_FAILURE",
+	FailCodePermanentNodeFailure:             "WIRE_PERMANENT_NODE_FAILURE",
+	FailCodeRequiredNodeFeatureMissing:       "WIRE_REQUIRED_NODE_FEATURE_MISSING",
+	FailCodeInvalidOnionVersion:              "WIRE_INVALID_ONION_VERSION",
+	FailCodeInvalidOnionHmac:                 "WIRE_INVALID_ONION_HMAC",
+	FailCodeInvalidOnionKey:                  "WIRE_INVALID_ONION_KEY",
+	FailCodeTemporaryChannelFailure:          "WIRE_TEMPORARY_CHANNEL_FAILURE",
+	FailCodePermanentChannelFailure:          "WIRE_PERMANENT_CHANNEL_FAILURE",
+	FailCodeRequiredChannelFeatureMissing:    "WIRE_REQUIRED_CHANNEL_FEATURE_MISSING",
+	FailCodeUnknownNextPeer:                  "WIRE_UNKNOWN_NEXT_PEER",
+	FailCodeAmountBelowMinimum:               "WIRE_AMOUNT_BELOW_MINIMUM",
+	FailCodeFeeInsufficient:                  "WIRE_FEE_INSUFFICIENT",
+	FailCodeIncorrectCltvExpiry:              "WIRE_INCORRECT_CLTV_EXPIRY",
+	FailCodeExpiryTooSoon:                    "WIRE_EXPIRY_TOO_SOON",
+	FailCodeIncorrectOrUnknownPaymentDetails: "WIRE_INCORRECT_OR_UNKNOWN_PAYMENT_DETAILS",
+	FailCodeFinalIncorrectCltvExpiry:         "WIRE_FINAL_INCORRECT_CLTV_EXPIRY",
+	FailCodeFinalIncorrectHtlcAmount:         "WIRE_FINAL_INCORRECT_HTLC_AMOUNT",
+	FailCodeChannelDisabled:                  "WIRE_CHANNEL_DISABLED",
+	FailCodeExpiryTooFar:                     "WIRE_EXPIRY_TOO_FAR",
+	FailCodeInvalidOnionPayload:              "WIRE_INVALID_ONION_PAYLOAD",
+	FailCodeMppTimeout:                       "WIRE_MPP_TIMEOUT",
+	FailCodeInvalidOnionBlinding:             "WIRE_INVALID_ONION_BLINDING",
+}
+
+// The name lightningd gives {failCode}, e.g. "WIRE_FEE_INSUFFICIENT"
+func FailCodeName(failCode int) string {
+	if name, ok := failCodeNames[failCode]; ok {
+		return name
+	}
+	return fmt.Sprintf("WIRE_UNKNOWN_%#04x", failCode)
+}
+
+// BOLT#7 channel_update, as sent in gossip and with some onion failures
+type ChannelUpdate struct {
+	Signature       string
+	ChainHash       string
+	ShortChannelId  ShortChannelId
+	Timestamp       uint32
+	MessageFlags    uint8
+	ChannelFlags    uint8
+	CltvExpiryDelta uint16
+	HtlcMinimumMsat uint64
+	FeeBaseMsat     uint32
+	FeePPM          uint32
+	HtlcMaximumMsat uint64
+}
+
+// 0 or 1, the side of the channel the update is from
+func (u *ChannelUpdate) Direction() uint8 {
+	return u.ChannelFlags & 1
+}
+
+func (u *ChannelUpdate) Disabled() bool {
+	return u.ChannelFlags&2 != 0
+}
+
+const channelUpdateLen = 136
+
+// Decode a channel_update, without its message type
+func DecodeChannelUpdate(data []byte) (*ChannelUpdate, error) {
+	if len(data) < channelUpdateLen {
+		return nil, fmt.Errorf("message truncated")
+	}
+	return &ChannelUpdate{
+		Signature:       hex.EncodeToString(data[0:64]),
+		ChainHash:       hex.EncodeToString(data[64:96]),
+		ShortChannelId:  ShortChannelIdFromUint64(binary.BigEndian.Uint64(data[96:104])),
+		Timestamp:       binary.BigEndian.Uint32(data[104:108]),
+		MessageFlags:    data[108],
+		ChannelFlags:    data[109],
+		CltvExpiryDelta: binary.BigEndian.Uint16(data[110:112]),
+		HtlcMinimumMsat: binary.BigEndian.Uint64(data[112:120]),
+		FeeBaseMsat:     binary.BigEndian.Uint32(data[120:124]),
+		FeePPM:          binary.BigEndian.Uint32(data[124:128]),
+		HtlcMaximumMsat: binary.BigEndian.Uint64(data[128:136]),
+	}, nil
+}
+
+// A decoded BOLT#4 failure message: why a payment failed, from the
+// node which failed it. Only the fields its code carries are set.
+type OnionFailure struct {
+	Code int
+	// The onion the node couldn't parse (bad onion failures)
+	Sha256OfOnion string
+	// The amount of the HTLC the node was sent
+	HtlcMsat uint64
+	// The cltv_expiry of the HTLC the node was sent
+	CltvExpiry uint32
+	// The block height the final node saw
+	Height uint32
+	// channel_disabled's flags
+	DisabledFlags uint16
+	// invalid_onion_payload: the bad TLV type, and its offset
+	PayloadType   uint64
+	PayloadOffset uint16
+	// The failing channel's latest update, for the payer to apply
+	// before retrying (update failures)
+	ChannelUpdate *ChannelUpdate
+}
+
+func (f *OnionFailure) Name() string {
+	return FailCodeName(f.Code)
+}
+
+// The failure will recur: don't retry the same way
+func (f *OnionFailure) IsPermanent() bool {
+	return f.Code&FailFlagPerm != 0
+}
+
+// The erring node failed, rather than its channel
+func (f *OnionFailure) IsNodeFailure() bool {
+	return f.Code&FailFlagNode != 0
+}
+
+// The node couldn't parse the onion, so couldn't encrypt its reply:
+// the node before it reported the failure
+func (f *OnionFailure) IsBadOnion() bool {
+	return f.Code&FailFlagBadOnion != 0
+}
+
+func (f *OnionFailure) String() string {
+	return f.Name()
+}
+
+// Decode a BOLT#4 failure message (waitsendpay's raw_message): a u16
+// failure code and whatever data that code carries
+func DecodeOnionFailure(msg []byte) (*OnionFailure, error) {
+	if len(msg) < 2 {
+		return nil, fmt.Errorf("Failure message too short")
+	}
+	f := &OnionFailure{Code: int(binary.BigEndian.Uint16(msg))}
+	data := msg[2:]
+	need := func(n int) error {
+		if len(data) < n {
+			return fmt.Errorf("%s: failure data truncated", f.Name())
+		}
+		return nil
+	}
+
+	if f.Code&FailFlagBadOnion != 0 {
+		if err := need(32); err != nil {
+			return nil, err
+		}
+		f.Sha256OfOnion = hex.EncodeToString(data[:32])
+		return f, nil
+	}
+
+	switch f.Code {
+	case FailCodeAmountBelowMinimum, FailCodeFeeInsufficient:
+		if err := need(8); err != nil {
+			return nil, err
+		}
+		f.HtlcMsat = binary.BigEndian.Uint64(data)
+		data = data[8:]
+	case FailCodeIncorrectCltvExpiry:
+		if err := need(4); err != nil {
+			return nil, err
+		}
+		f.CltvExpiry = binary.BigEndian.Uint32(data)
+		data = data[4:]
+	case FailCodeChannelDisabled:
+		if err := need(2); err != nil {
+			return nil, err
+		}
+		f.DisabledFlags = binary.BigEndian.Uint16(data)
+		data = data[2:]
+	case FailCodeIncorrectOrUnknownPaymentDetails:
+		// both were added later, so may be missing
+		if len(data) >= 8 {
+			f.HtlcMsat = binary.BigEndian.Uint64(data)
+		}
+		if len(data) >= 12 {
+			f.Height = binary.BigEndian.Uint32(data[8:])
+		}
+		return f, nil
+	case FailCodeFinalIncorrectCltvExpiry:
+		if err := need(4); err != nil {
+			return nil, err
+		}
+		f.CltvExpiry = binary.BigEndian.Uint32(data)
+		return f, nil
+	case FailCodeFinalIncorrectHtlcAmount:
+		if err := need(8); err != nil {
+			return nil, err
+		}
+		f.HtlcMsat = binary.BigEndian.Uint64(data)
+		return f, nil
+	case FailCodeInvalidOnionPayload:
+		typ, err := tlv.ReadBigSize(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		data = data[tlv.BigSizeLen(typ):]
+		if err := need(2); err != nil {
+			return nil, err
+		}
+		f.PayloadType = typ
+		f.PayloadOffset = binary.BigEndian.Uint16(data)
+		return f, nil
+	}
+
+	if f.Code&FailFlagUpdate != 0 {
+		if err := need(2); err != nil {
+			return nil, err
+		}
+		update := data[2:]
+		if err := need(2 + int(binary.BigEndian.Uint16(data))); err != nil {
+			return nil, err
+		}
+		update = update[:binary.BigEndian.Uint16(data)]
+		// nodes may include the update's message type (258)
+		if len(update) >= channelUpdateLen+2 && binary.BigEndian.Uint16(update) == 258 {
+			update = update[2:]
+		}
+		var err error
+		if f.ChannelUpdate, err = DecodeChannelUpdate(update); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,143 @@
+package glightning_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+const testChannelUpdate = "" +
+	"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" +
+	"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" +
+	"06226e46111a0b59caaf126043eb5bbf28c34f3a5e332a1fc7b2b73cf188910f" +
+	"0000670000010000" + // 103x1x0
+	"5f5e1000" + // timestamp
+	"01" + "03" + // message flags, channel flags (direction 1, disabled)
+	"0028" + // cltv_expiry_delta
+	"00000000000003e8" + // htlc_minimum_msat
+	"000003e8" + // fee_base_msat
+	"0000000a" + // fee_proportional_millionths
+	"000000003b023380" // htlc_maximum_msat
+
+func decodeFailure(t *testing.T, msg string) *glightning.OnionFailure {
+	raw, err := hex.DecodeString(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure, err := glightning.DecodeOnionFailure(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return failure
+}
+
+func TestDecodeOnionFailureUpdate(t *testing.T) {
+	expected := &glightning.ChannelUpdate{
+		Signature:       strings.Repeat("aa", 64),
+		ChainHash:       "06226e46111a0b59caaf126043eb5bbf28c34f3a5e332a1fc7b2b73cf188910f",
+		ShortChannelId:  "103x1x0",
+		Timestamp:       0x5f5e1000,
+		MessageFlags:    1,
+		ChannelFlags:    3,
+		CltvExpiryDelta: 40,
+		HtlcMinimumMsat: 1000,
+		FeeBaseMsat:     1000,
+		FeePPM:          10,
+		HtlcMaximumMsat: 990000000,
+	}
+
+	// fee_insufficient: htlc_msat, then the update
+	failure := decodeFailure(t, "100c"+"0000000000989680"+"0088"+testChannelUpdate)
+	assert.Equal(t, glightning.FailCodeFeeInsufficient, failure.Code)
+	assert.Equal(t, "WIRE_FEE_INSUFFICIENT", failure.Name())
+	assert.Equal(t, uint64(10000000), failure.HtlcMsat)
+	assert.Equal(t, expected, failure.ChannelUpdate)
+	assert.False(t, failure.IsPermanent())
+	assert.Equal(t, uint8(1), failure.ChannelUpdate.Direction())
+	assert.True(t, failure.ChannelUpdate.Disabled())
+
+	// temporary_channel_failure, with the update's message type
+	failure = decodeFailure(t, "1007"+"008a"+"0102"+testChannelUpdate)
+	assert.Equal(t, glightning.FailCodeTemporaryChannelFailure, failure.Code)
+	assert.Equal(t, expected, failure.ChannelUpdate)
+
+	// channel_disabled: flags, then the update
+	failure = decodeFailure(t, "1014"+"0000"+"0088"+testChannelUpdate)
+	assert.Equal(t, glightning.FailCodeChannelDisabled, failure.Code)
+	assert.Equal(t, expected, failure.ChannelUpdate)
+
+	_, err := glightning.DecodeOnionFailure([]byte{0x10, 0x07, 0x00, 0x88, 0xaa})
+	assert.Error(t, err)
+}
+
+func TestDecodeOnionFailure(t *testing.T) {
+	failure := decodeFailure(t, "400f"+"0000000000002710"+"000000c8")
+	assert.Equal(t, &glightning.OnionFailure{
+		Code:     glightning.FailCodeIncorrectOrUnknownPaymentDetails,
+		HtlcMsat: 10000,
+		Height:   200,
+	}, failure)
+	assert.True(t, failure.IsPermanent())
+	assert.False(t, failure.IsNodeFailure())
+
+	// older nodes sent it with no data
+	failure = decodeFailure(t, "400f")
+	assert.Equal(t, glightning.FailCodeIncorrectOrUnknownPaymentDetails, failure.Code)
+
+	failure = decodeFailure(t, "2002")
+	assert.Equal(t, "WIRE_TEMPORARY_NODE_FAILURE", failure.Name())
+	assert.True(t, failure.IsNodeFailure())
+	assert.False(t, failure.IsPermanent())
+
+	failure = decodeFailure(t, "c005"+strings.Repeat("11", 32))
+	assert.True(t, failure.IsBadOnion())
+	assert.Equal(t, strings.Repeat("11", 32), failure.Sha256OfOnion)
+
+	failure = decodeFailure(t, "4016"+"fd0100"+"0005")
+	assert.Equal(t, uint64(256), failure.PayloadType)
+	assert.Equal(t, uint16(5), failure.PayloadOffset)
+
+	failure = decodeFailure(t, "0012"+"00000090")
+	assert.Equal(t, uint32(144), failure.CltvExpiry)
+
+	failure = decodeFailure(t, "0abc")
+	assert.Equal(t, "WIRE_UNKNOWN_0x0abc", failure.Name())
+
+	_, err := glightning.DecodeOnionFailure([]byte{0x10})
+	assert.Error(t, err)
+	_, err = glightning.DecodeOnionFailure([]byte{0x00, 0x13, 0x01})
+	assert.EqualError(t, err, "WIRE_FINAL_INCORRECT_HTLC_AMOUNT: failure data truncated")
+}
+
+func TestWaitSendPayFailure(t *testing.T) {
+	paymentHash := "37ef7c6ff62d5a2fbce1940ab2f4de2785045b922f93944b73f7bc5123ed698f"
+	req := `{"jsonrpc":"2.0","method":"waitsendpay","params":{"payment_hash":"` + paymentHash + `"},"id":1}`
+	resp := `{"jsonrpc":"2.0","id":1,"error":{"code":204,"message":"failed: WIRE_TEMPORARY_CHANNEL_FAILURE (reply from remote)","data":{
+  "id": 4,
+  "payment_hash": "` + paymentHash + `",
+  "status": "failed",
+  "erring_index": 1,
+  "failcode": 4103,
+  "failcodename": "WIRE_TEMPORARY_CHANNEL_FAILURE",
+  "erring_node": "022d223620a359a47ff7f7ac447c85c46c923da53389221a0054c11c1e3ca31d59",
+  "erring_channel": "103x1x0",
+  "erring_direction": 1,
+  "raw_message": "1007008a0102` + testChannelUpdate + `"
+}}}`
+	lightning, requestQ, replyQ := startupServer(t)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err := lightning.WaitSendPay(paymentHash, 0)
+	payErr, ok := err.(*glightning.PaymentError)
+	if !ok {
+		t.Fatalf("expected a PaymentError, got %v", err)
+	}
+	failure := payErr.Data.Failure
+	if failure == nil {
+		t.Fatal("raw_message not decoded")
+	}
+	assert.Equal(t, glightning.FailCodeTemporaryChannelFailure, failure.Code)
+	assert.Equal(t, uint32(10), failure.ChannelUpdate.FeePPM)
+}
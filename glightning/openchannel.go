@@ -0,0 +1,124 @@
+package glightning
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// The output an OpenChannelFlow's funding transaction must create
+type FundingOutput struct {
+	PeerId       string
+	Address      string
+	ScriptPubkey string
+	// In satoshi
+	Amount uint64
+}
+
+// Builds a PSBT paying {output}, e.g. from an external wallet, and
+// returns it (base64). It must not be broadcast yet.
+type FundFunc func(ctx context.Context, output *FundingOutput) (psbt string, err error)
+
+type OpenChannelFlowResult struct {
+	ChannelId string
+	// The funding PSBT, which can now be signed and broadcast
+	Psbt string
+}
+
+// Opens a channel funded from outside lightningd's wallet, chaining
+// connect, fundchannel_start, the caller's funding and
+// fundchannel_complete. If any step fails, or the flow runs out of
+// time, the open is cancelled with fundchannel_cancel.
+//
+//	flow := glightning.NewOpenChannelFlow(ln, peerId, 1000000)
+//	flow.Host = "10.0.0.2"
+//	flow.Timeout = 5 * time.Minute
+//	result, err := flow.Run(ctx, func(ctx context.Context, out *glightning.FundingOutput) (string, error) {
+//		return wallet.FundPsbt(out.Address, out.Amount)
+//	})
+//	... sign and broadcast result.Psbt ...
+type OpenChannelFlow struct {
+	client LightningClient
+	PeerId string
+	// Where to connect to the peer, if set; otherwise it must already
+	// be connected
+	Host string
+	Port uint
+	// In satoshi
+	Amount   uint64
+	Announce bool
+	FeeRate  *FeeRate
+	// Where our funds go when the channel is closed
+	CloseTo string
+	// How long the whole flow may take, funding included; 0 for no
+	// limit beyond Run's context
+	Timeout time.Duration
+}
+
+// A flow opening a public channel of {amount} sat with {peerId}
+func NewOpenChannelFlow(client LightningClient, peerId string, amount uint64) *OpenChannelFlow {
+	return &OpenChannelFlow{
+		client:   client,
+		PeerId:   peerId,
+		Amount:   amount,
+		Announce: true,
+	}
+}
+
+// Run the flow, calling {fund} for the funding PSBT. Only broadcast
+// the funding transaction once Run has succeeded.
+func (f *OpenChannelFlow) Run(ctx context.Context, fund FundFunc) (*OpenChannelFlowResult, error) {
+	if f.Amount == 0 {
+		return nil, fmt.Errorf("Must set satoshi amount to fund")
+	}
+	if fund == nil {
+		return nil, fmt.Errorf("Must provide a FundFunc")
+	}
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+
+	if f.Host != "" {
+		if _, err := f.client.ConnectPeer(f.PeerId, f.Host, f.Port); err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	start, err := f.client.StartFundChannel(f.PeerId, f.Amount, f.Announce, f.FeeRate, f.CloseTo)
+	if err != nil {
+		return nil, err
+	}
+
+	psbt, err := fund(ctx, &FundingOutput{
+		PeerId:       f.PeerId,
+		Address:      start.Address,
+		ScriptPubkey: start.ScriptPubkey,
+		Amount:       f.Amount,
+	})
+	if err == nil {
+		// a late answer is no good either; the peer may have given up
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, f.cancel(err)
+	}
+
+	channelId, err := f.client.CompleteFundChannelPsbt(f.PeerId, psbt)
+	if err != nil {
+		return nil, f.cancel(err)
+	}
+	return &OpenChannelFlowResult{ChannelId: channelId, Psbt: psbt}, nil
+}
+
+// Cancel the open, because of {err}
+func (f *OpenChannelFlow) cancel(err error) error {
+	if _, cancelErr := f.client.CancelFundChannel(f.PeerId); cancelErr != nil {
+		return fmt.Errorf("%s (and cancelling the open failed: %s)", err, cancelErr)
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package glightning_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const openPeer = "02e3cd7849f177a46f137ae3bfc1a08fc6a90bf4026c74f83c1ecc8430c282fe96"
+
+func openChannelMock() *mock.Lightning {
+	ln := mock.New()
+	ln.ConnectPeerFunc = func(peerId, host string, port uint) (*glightning.ConnectResult, error) {
+		return &glightning.ConnectResult{Id: peerId}, nil
+	}
+	ln.StartFundChannelFunc = func(id string, amount uint64, announce bool, feerate *glightning.FeeRate, closeTo string) (*glightning.StartResponse, error) {
+		return &glightning.StartResponse{Address: "bcrt1qfunding", ScriptPubkey: "0020aa"}, nil
+	}
+	ln.CompleteFundChannelPsbtFunc = func(peerId, psbt string) (string, error) {
+		return "channel-1", nil
+	}
+	ln.CancelFundChannelFunc = func(peerId string) (bool, error) {
+		return true, nil
+	}
+	return ln
+}
+
+func TestOpenChannelFlow(t *testing.T) {
+	ln := openChannelMock()
+	flow := glightning.NewOpenChannelFlow(ln, openPeer, 1000000)
+	flow.Host = "10.0.0.2"
+	result, err := flow.Run(context.Background(), func(ctx context.Context, out *glightning.FundingOutput) (string, error) {
+		assert.Equal(t, &glightning.FundingOutput{
+			PeerId:       openPeer,
+			Address:      "bcrt1qfunding",
+			ScriptPubkey: "0020aa",
+			Amount:       1000000,
+		}, out)
+		return "cHNidP8B", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &glightning.OpenChannelFlowResult{ChannelId: "channel-1", Psbt: "cHNidP8B"}, result)
+	assert.Equal(t, []string{"ConnectPeer", "StartFundChannel", "CompleteFundChannelPsbt"}, ln.Calls())
+}
+
+func TestOpenChannelFlowFundingFails(t *testing.T) {
+	ln := openChannelMock()
+	flow := glightning.NewOpenChannelFlow(ln, openPeer, 1000000)
+	_, err := flow.Run(context.Background(), func(ctx context.Context, out *glightning.FundingOutput) (string, error) {
+		return "", errors.New("Insufficient funds")
+	})
+	assert.EqualError(t, err, "Insufficient funds")
+	assert.Equal(t, []string{"StartFundChannel", "CancelFundChannel"}, ln.Calls())
+
+	// complete failing cancels too, and says if that failed
+	ln = openChannelMock()
+	ln.CompleteFundChannelPsbtFunc = func(peerId, psbt string) (string, error) {
+		return "", errors.New("Peer disconnected")
+	}
+	ln.CancelFundChannelFunc = func(peerId string) (bool, error) {
+		return false, errors.New("No channel funding in progress")
+	}
+	flow = glightning.NewOpenChannelFlow(ln, openPeer, 1000000)
+	_, err = flow.Run(context.Background(), func(ctx context.Context, out *glightning.FundingOutput) (string, error) {
+		return "cHNidP8B", nil
+	})
+	assert.EqualError(t, err, "Peer disconnected (and cancelling the open failed: No channel funding in progress)")
+}
+
+func TestOpenChannelFlowTimeout(t *testing.T) {
+	ln := openChannelMock()
+	flow := glightning.NewOpenChannelFlow(ln, openPeer, 1000000)
+	flow.Timeout = 20 * time.Millisecond
+	_, err := flow.Run(context.Background(), func(ctx context.Context, out *glightning.FundingOutput) (string, error) {
+		<-ctx.Done()
+		return "cHNidP8B", nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, ln.CallCount("CancelFundChannel"))
+	assert.Equal(t, 0, ln.CallCount("CompleteFundChannelPsbt"))
+}
@@ -0,0 +1,275 @@
+package glightning
+
+import (
+	"fmt"
+)
+
+// Divides {amount} msat into the amounts to send as separate parts.
+// Called with the whole payment, then again with the amount of any
+// parts that failed and need resending.
+type SplitFunc func(amount uint64) []uint64
+
+// Send everything as a single part
+func NoSplit(amount uint64) []uint64 {
+	return []uint64{amount}
+}
+
+// Split into {parts} parts of (nearly) equal size
+func SplitEvenly(parts int) SplitFunc {
+	return func(amount uint64) []uint64 {
+		n := uint64(parts)
+		if n < 1 {
+			n = 1
+		}
+		if n > amount {
+			n = amount
+		}
+		amounts := make([]uint64, 0, n)
+		for i := uint64(0); i < n; i++ {
+			part := amount / n
+			if i < amount%n {
+				part++
+			}
+			amounts = append(amounts, part)
+		}
+		return amounts
+	}
+}
+
+// Split into parts no larger than {maxPart}
+func SplitByMax(maxPart uint64) SplitFunc {
+	return func(amount uint64) []uint64 {
+		if maxPart == 0 || amount <= maxPart {
+			return []uint64{amount}
+		}
+		return SplitEvenly(int((amount + maxPart - 1) / maxPart))(amount)
+	}
+}
+
+// One attempt at sending part of a payment
+type PaymentPart struct {
+	PartId uint64
+	// Amount delivered to the destination by this part, in msat
+	Amount uint64
+	Route  []RouteHop
+	// Set once the part has concluded, successfully or not
+	Result *SendPayFields
+	Err    error
+}
+
+// Fee paid along this part's route, in msat
+func (pp *PaymentPart) Fee() uint64 {
+	if len(pp.Route) == 0 || pp.Route[0].MilliSatoshi < pp.Amount {
+		return 0
+	}
+	return pp.Route[0].MilliSatoshi - pp.Amount
+}
+
+type PayerResult struct {
+	PaymentHash     string
+	PaymentPreimage string
+	// Amount delivered to the destination, in msat
+	AmountMsat uint64
+	// Total fees paid by the successful parts, in msat
+	FeeMsat uint64
+	// Every part attempted, in the order they concluded
+	Parts []*PaymentPart
+}
+
+// Payer is a Go-side payment engine. It splits a payment into
+// parts (see SplitFunc), routes and sends each with sendpay and
+// its own partid, waits on each part and resends the amount of
+// any which fail, routing around the channel that failed them.
+//
+// Use Pay for a bolt11 invoice or PayTo to pay a known hash.
+type Payer struct {
+	client LightningClient
+
+	// How to divide the payment into parts; defaults to NoSplit
+	Split SplitFunc
+	// Passed to getroute; defaults to 10
+	RiskFactor float32
+	// Give up after sending this many parts in total; defaults to 10
+	MaxParts int
+	// Upper limit on the fees paid across all parts, in msat.
+	// Zero means no limit.
+	MaxFeeMsat uint64
+	// Called as each part concludes, successfully or not
+	OnPart func(*PaymentPart)
+}
+
+func NewPayer(client LightningClient) *Payer {
+	return &Payer{
+		client:     client,
+		Split:      NoSplit,
+		RiskFactor: 10,
+		MaxParts:   10,
+	}
+}
+
+// Pay a {bolt11} invoice, which must include an amount
+func (p *Payer) Pay(bolt11 string) (*PayerResult, error) {
+	decoded, err := p.client.DecodePay(bolt11, "")
+	if err != nil {
+		return nil, err
+	}
+	amount := decoded.MilliSatoshis
+	if amount == 0 && decoded.AmountMsat != "" {
+		msat, err := ParseMSat(decoded.AmountMsat)
+		if err != nil {
+			return nil, err
+		}
+		amount = msat.Value
+	}
+	if amount == 0 {
+		return nil, fmt.Errorf("Must use an invoice with an amount")
+	}
+	return p.pay(&payment{
+		destination:   decoded.Payee,
+		paymentHash:   decoded.PaymentHash,
+		paymentSecret: decoded.PaymentSecret,
+		bolt11:        bolt11,
+		amount:        amount,
+		cltv:          uint(decoded.MinFinalCltvExpiry),
+	})
+}
+
+// Pay {amount} msat to {destination}, in return for the preimage of
+// {paymentHash}. {paymentSecret} is required if the payment is split;
+// {cltv} is the final hop's cltv delta, 0 for the default.
+func (p *Payer) PayTo(destination, paymentHash, paymentSecret string, amount uint64, cltv uint) (*PayerResult, error) {
+	if destination == "" {
+		return nil, fmt.Errorf("Must provide a destination to pay")
+	}
+	if paymentHash == "" {
+		return nil, fmt.Errorf("Must provide a payment hash to pay")
+	}
+	if amount == 0 {
+		return nil, fmt.Errorf("Must provide an amount to pay")
+	}
+	return p.pay(&payment{
+		destination:   destination,
+		paymentHash:   paymentHash,
+		paymentSecret: paymentSecret,
+		amount:        amount,
+		cltv:          cltv,
+	})
+}
+
+type payment struct {
+	destination   string
+	paymentHash   string
+	paymentSecret string
+	bolt11        string
+	amount        uint64
+	cltv          uint
+}
+
+func (p *Payer) pay(pmt *payment) (*PayerResult, error) {
+	split := p.Split
+	if split == nil {
+		split = NoSplit
+	}
+
+	result := &PayerResult{PaymentHash: pmt.paymentHash}
+	done := make(chan *PaymentPart)
+	var exclude []string
+	var partId uint64
+	var inflight int
+	var feesCommitted uint64
+	var lastErr error
+
+	unsent := pmt.amount
+	for {
+		if unsent > 0 && lastErr == nil {
+			for _, amount := range split(unsent) {
+				if int(partId) >= p.MaxParts {
+					lastErr = fmt.Errorf("Gave up after sending %d parts", partId)
+					break
+				}
+				partId++
+				part, err := p.sendPart(pmt, partId, amount, exclude, feesCommitted)
+				if err != nil {
+					lastErr = err
+					break
+				}
+				unsent -= amount
+				feesCommitted += part.Fee()
+				inflight++
+				go p.waitPart(pmt, part, done)
+			}
+		}
+
+		if inflight == 0 {
+			break
+		}
+
+		part := <-done
+		inflight--
+		result.Parts = append(result.Parts, part)
+		if p.OnPart != nil {
+			p.OnPart(part)
+		}
+		if part.Err == nil {
+			result.AmountMsat += part.Amount
+			result.FeeMsat += part.Fee()
+			result.PaymentPreimage = part.Result.PaymentPreimage
+			continue
+		}
+
+		unsent += part.Amount
+		feesCommitted -= part.Fee()
+		if payErr, ok := part.Err.(*PaymentError); ok && payErr.Data != nil {
+			data := payErr.Data
+			if data.ErringNode == pmt.destination {
+				// the destination refused it; retrying won't help
+				lastErr = part.Err
+			} else if data.ErringChannel != "" {
+				exclude = append(exclude, fmt.Sprintf("%s/%d", data.ErringChannel, data.ErringDirection))
+			}
+		} else if lastErr == nil {
+			lastErr = part.Err
+		}
+	}
+
+	if result.AmountMsat >= pmt.amount {
+		return result, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("Payment failed")
+	}
+	return result, lastErr
+}
+
+func (p *Payer) sendPart(pmt *payment, partId, amount uint64, exclude []string, feesCommitted uint64) (*PaymentPart, error) {
+	route, err := p.client.GetRoute(pmt.destination, amount, p.RiskFactor, pmt.cltv, "", 0, exclude, 0)
+	if err != nil {
+		return nil, err
+	}
+	part := &PaymentPart{
+		PartId: partId,
+		Amount: amount,
+		Route:  route,
+	}
+	if p.MaxFeeMsat != 0 && feesCommitted+part.Fee() > p.MaxFeeMsat {
+		return nil, fmt.Errorf("Fee of %dmsat for part %d exceeds the limit of %dmsat", part.Fee(), partId, p.MaxFeeMsat)
+	}
+
+	_, err = p.client.SendPayPart(&SendPayRequest{
+		Route:         route,
+		PaymentHash:   pmt.paymentHash,
+		Bolt11:        pmt.bolt11,
+		PaymentSecret: pmt.paymentSecret,
+		PartId:        partId,
+		TotalMsat:     NewMsat(pmt.amount).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return part, nil
+}
+
+func (p *Payer) waitPart(pmt *payment, part *PaymentPart, done chan<- *PaymentPart) {
+	part.Result, part.Err = p.client.WaitSendPayPart(pmt.paymentHash, 0, part.PartId)
+	done <- part
+}
@@ -0,0 +1,154 @@
+package glightning_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+const payerDest = "02c0114aac5ea2bce7759eb48d5aa75129700c1eb7fe6cc8743968a202f26505d6"
+
+func TestSplitters(t *testing.T) {
+	assert.Equal(t, []uint64{1000}, glightning.NoSplit(1000))
+	assert.Equal(t, []uint64{334, 333, 333}, glightning.SplitEvenly(3)(1000))
+	assert.Equal(t, []uint64{1, 1}, glightning.SplitEvenly(3)(2))
+	assert.Equal(t, []uint64{250, 250, 250, 250}, glightning.SplitByMax(300)(1000))
+	assert.Equal(t, []uint64{100}, glightning.SplitByMax(300)(100))
+}
+
+// a fake node whose routes all charge a 1000ppm fee; {fail}
+// decides the outcome of each part
+func payerMock(fail func(partId uint64, route []glightning.RouteHop) error) (*mock.Lightning, *[][]string) {
+	ln := mock.New()
+	var mu sync.Mutex
+	var excludes [][]string
+	ln.GetRouteFunc = func(peerId string, msats uint64, riskfactor float32, cltv uint, fromId string, fuzzpercent float32, exclude []string, maxHops int32) ([]glightning.RouteHop, error) {
+		mu.Lock()
+		excludes = append(excludes, exclude)
+		mu.Unlock()
+		scid := glightning.ShortChannelId("103x1x0")
+		if len(exclude) > 0 {
+			scid = "104x1x0"
+		}
+		return []glightning.RouteHop{
+			{Id: "03aa", ShortChannelId: scid, MilliSatoshi: msats + msats/1000, Delay: 15},
+			{Id: peerId, ShortChannelId: "200x1x1", MilliSatoshi: msats, Delay: 9},
+		}, nil
+	}
+	routes := make(map[uint64][]glightning.RouteHop)
+	ln.SendPayPartFunc = func(req *glightning.SendPayRequest) (*glightning.SendPayResult, error) {
+		mu.Lock()
+		routes[req.PartId] = req.Route
+		mu.Unlock()
+		return &glightning.SendPayResult{}, nil
+	}
+	ln.WaitSendPayPartFunc = func(paymentHash string, timeout uint, partId uint64) (*glightning.SendPayFields, error) {
+		mu.Lock()
+		route := routes[partId]
+		mu.Unlock()
+		if err := fail(partId, route); err != nil {
+			return &glightning.SendPayFields{}, err
+		}
+		return &glightning.SendPayFields{
+			PaymentHash:     paymentHash,
+			PartId:          partId,
+			Status:          "complete",
+			PaymentPreimage: "bb",
+		}, nil
+	}
+	return ln, &excludes
+}
+
+func channelFailure(scid string, node string) error {
+	return &glightning.PaymentError{
+		RpcError: &jrpc2.RpcError{Code: 204, Message: "failed: WIRE_TEMPORARY_CHANNEL_FAILURE"},
+		Data: &glightning.PaymentErrorData{
+			ErringNode:      node,
+			ErringChannel:   scid,
+			ErringDirection: 1,
+			FailCodeName:    "WIRE_TEMPORARY_CHANNEL_FAILURE",
+		},
+	}
+}
+
+func TestPayerSplitsAndRetries(t *testing.T) {
+	ln, excludes := payerMock(func(partId uint64, route []glightning.RouteHop) error {
+		if route[0].ShortChannelId == "103x1x0" && partId == 2 {
+			return channelFailure("103x1x0", "03aa")
+		}
+		return nil
+	})
+
+	payer := glightning.NewPayer(ln)
+	payer.Split = glightning.SplitEvenly(2)
+	var parts []uint64
+	payer.OnPart = func(part *glightning.PaymentPart) {
+		parts = append(parts, part.PartId)
+	}
+
+	result, err := payer.PayTo(payerDest, "aa", "cc", 2000000, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "bb", result.PaymentPreimage)
+	assert.Equal(t, uint64(2000000), result.AmountMsat)
+	assert.Equal(t, uint64(2000), result.FeeMsat)
+	assert.Len(t, result.Parts, 4)
+	assert.ElementsMatch(t, []uint64{1, 2, 3, 4}, parts)
+
+	// the failed part's amount was resent, split again, around the bad channel
+	assert.Equal(t, []string{"103x1x0/1"}, (*excludes)[len(*excludes)-1])
+	assert.Equal(t, 4, ln.CallCount("SendPayPart"))
+}
+
+func TestPayerDestinationFailure(t *testing.T) {
+	ln, _ := payerMock(func(partId uint64, route []glightning.RouteHop) error {
+		return channelFailure("", payerDest)
+	})
+
+	payer := glightning.NewPayer(ln)
+	result, err := payer.PayTo(payerDest, "aa", "cc", 1000, 0)
+	assert.Error(t, err)
+	assert.Equal(t, uint64(0), result.AmountMsat)
+	assert.Equal(t, 1, ln.CallCount("SendPayPart"))
+}
+
+func TestPayerLimits(t *testing.T) {
+	ln, _ := payerMock(func(partId uint64, route []glightning.RouteHop) error {
+		return channelFailure(fmt.Sprintf("%dx1x0", 100+partId), "03aa")
+	})
+
+	payer := glightning.NewPayer(ln)
+	payer.MaxParts = 3
+	_, err := payer.PayTo(payerDest, "aa", "cc", 1000, 0)
+	assert.EqualError(t, err, "Gave up after sending 3 parts")
+	assert.Equal(t, 3, ln.CallCount("SendPayPart"))
+
+	payer = glightning.NewPayer(ln)
+	payer.MaxFeeMsat = 500
+	_, err = payer.PayTo(payerDest, "aa", "cc", 1000000, 0)
+	assert.EqualError(t, err, "Fee of 1000msat for part 1 exceeds the limit of 500msat")
+}
+
+func TestPayerBolt11(t *testing.T) {
+	ln, _ := payerMock(func(partId uint64, route []glightning.RouteHop) error {
+		return nil
+	})
+	ln.DecodePayFunc = func(bolt11, desc string) (*glightning.DecodedBolt11, error) {
+		return &glightning.DecodedBolt11{
+			Payee:              payerDest,
+			AmountMsat:         "5000msat",
+			PaymentHash:        "aa",
+			PaymentSecret:      "cc",
+			MinFinalCltvExpiry: 18,
+		}, nil
+	}
+
+	result, err := glightning.NewPayer(ln).Pay("lnbcrt50n1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5000), result.AmountMsat)
+	assert.Equal(t, uint64(5), result.FeeMsat)
+}
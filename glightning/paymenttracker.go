@@ -0,0 +1,276 @@
+package glightning
+
+import (
+	"context"
+	"time"
+)
+
+type PaymentEventKind string
+
+const (
+	// The first part of the payment has been seen
+	PaymentPending PaymentEventKind = "pending"
+	// A part has been sent, including the first
+	PaymentPartSent      PaymentEventKind = "part_sent"
+	PaymentPartSucceeded PaymentEventKind = "part_succeeded"
+	PaymentPartFailed    PaymentEventKind = "part_failed"
+	// Every part sent has concluded and at least one succeeded. The
+	// last event.
+	PaymentSucceeded PaymentEventKind = "succeeded"
+	// Every part sent has failed, and no more were sent for a poll
+	// interval. The last event.
+	PaymentFailed PaymentEventKind = "failed"
+)
+
+// A step in a payment's progress, as delivered by TrackPayment
+type PaymentEvent struct {
+	Kind        PaymentEventKind
+	PaymentHash string
+	// The part, for the part events
+	PartId  uint64
+	GroupId uint64
+	// Delivered to, and sent towards, the destination in msat: by the
+	// part for part events, and by the successful parts in total for
+	// PaymentSucceeded
+	AmountMsat uint64
+	SentMsat   uint64
+	// Set once a part has succeeded
+	Preimage string
+	// Why a part failed, if lightningd said; for PaymentFailed, the
+	// last part's reason
+	Message string
+	Time    time.Time
+}
+
+// PaymentTracker follows payments sent by any means (pay, xpay,
+// sendpay or a Payer) and reports their progress as a single ordered
+// stream of events, for progress displays.
+//
+// Parts are found by polling listsendpays. With an Events bus, parts
+// are also reported as their sendpay_success and sendpay_failure
+// notifications arrive, without waiting for the next poll.
+type PaymentTracker struct {
+	// How often to poll listsendpays. Defaults to 2 seconds.
+	PollInterval time.Duration
+
+	client LightningClient
+	events *Events
+}
+
+// A tracker polling {client}; {events} may be nil
+func NewPaymentTracker(client LightningClient, events *Events) *PaymentTracker {
+	return &PaymentTracker{
+		PollInterval: 2 * time.Second,
+		client:       client,
+		events:       events,
+	}
+}
+
+// TrackPaymentCtx, until the payment concludes
+func (t *PaymentTracker) TrackPayment(paymentHash string) <-chan PaymentEvent {
+	return t.TrackPaymentCtx(context.Background(), paymentHash)
+}
+
+// Follow the payment of {paymentHash}. Events are sent on the
+// returned channel, which is closed after PaymentSucceeded or
+// PaymentFailed, or when {ctx} is done. Keep reading until it's
+// closed: the tracker waits for each event to be read.
+func (t *PaymentTracker) TrackPaymentCtx(ctx context.Context, paymentHash string) <-chan PaymentEvent {
+	out := make(chan PaymentEvent)
+	var sub *EventSubscription
+	if t.events != nil {
+		sub = t.events.Subscribe(16, func(e *Event) bool {
+			switch p := e.Payload.(type) {
+			case *SendPaySuccess:
+				return p.PaymentHash == paymentHash
+			case *SendPayFailure:
+				return p.Data.PaymentHash == paymentHash
+			}
+			return false
+		})
+	}
+
+	tracked := &trackedPayment{
+		ctx:   ctx,
+		hash:  paymentHash,
+		out:   out,
+		parts: make(map[[2]uint64]*PaymentEvent),
+	}
+	go func() {
+		defer close(out)
+		if sub != nil {
+			defer sub.Close()
+		}
+		t.run(tracked, sub)
+	}()
+	return out
+}
+
+func (t *PaymentTracker) run(p *trackedPayment, sub *EventSubscription) {
+	var notifications <-chan *Event
+	if sub != nil {
+		notifications = sub.C
+	}
+	ticker := time.NewTicker(t.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if sendpays, err := t.client.ListSendPaysByHash(p.hash); err == nil {
+			newParts := false
+			for i := range sendpays {
+				s := &sendpays[i]
+				sent := msatOr(s.MilliSatoshiSent, s.MilliSatoshiSentRaw)
+				if p.update(s.PartId, s.GroupId, s.Status, msatOr(s.AmountMilliSatoshi, s.AmountMilliSatoshiRaw), sent, s.PaymentPreimage, "") {
+					newParts = true
+				}
+			}
+			if newParts {
+				p.failedPolls = 0
+			} else if p.allFailed() {
+				p.failedPolls++
+			}
+			if p.done() {
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case e, ok := <-notifications:
+			if !ok {
+				notifications = nil
+				continue
+			}
+			switch n := e.Payload.(type) {
+			case *SendPaySuccess:
+				amount := msatOr(n.AmountMilliSatoshi, n.MilliSatoshi)
+				sent := msatOr(n.AmountSentMilliSatoshi, n.AmountSent)
+				p.update(n.PartId, n.GroupId, "complete", amount, sent, n.PaymentPreimage, "")
+			case *SendPayFailure:
+				d := &n.Data
+				amount := msatOr(d.AmountMilliSatoshi, d.MilliSatoshi)
+				sent := msatOr(d.AmountSentMilliSatoshi, d.AmountSent)
+				p.update(d.PartId, d.GroupId, "failed", amount, sent, "", n.Message)
+			}
+			if p.done() {
+				return
+			}
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+type trackedPayment struct {
+	ctx  context.Context
+	hash string
+	out  chan<- PaymentEvent
+	// by groupid and partid; Kind is the part's latest event
+	parts       map[[2]uint64]*PaymentEvent
+	failedPolls int
+	finished    bool
+}
+
+func (p *trackedPayment) emit(e PaymentEvent) {
+	e.PaymentHash = p.hash
+	e.Time = time.Now()
+	select {
+	case p.out <- e:
+	case <-p.ctx.Done():
+	}
+}
+
+// Record part {partId} of group {groupId} as having {status},
+// emitting the events for whatever changed. Returns whether the part
+// is new.
+func (p *trackedPayment) update(partId, groupId uint64, status string, amount, sent uint64, preimage, message string) bool {
+	key := [2]uint64{groupId, partId}
+	part, known := p.parts[key]
+	if !known {
+		if len(p.parts) == 0 {
+			p.emit(PaymentEvent{Kind: PaymentPending})
+		}
+		part = &PaymentEvent{
+			Kind:       PaymentPartSent,
+			PartId:     partId,
+			GroupId:    groupId,
+			AmountMsat: amount,
+			SentMsat:   sent,
+		}
+		p.parts[key] = part
+		p.emit(*part)
+	}
+
+	var kind PaymentEventKind
+	switch status {
+	case "complete":
+		kind = PaymentPartSucceeded
+	case "failed":
+		kind = PaymentPartFailed
+	default:
+		return !known
+	}
+	if part.Kind != PaymentPartSent {
+		// already concluded; a notification and a poll both saw it
+		return !known
+	}
+	part.Kind = kind
+	part.Preimage = preimage
+	if message != "" {
+		part.Message = message
+	}
+	p.emit(*part)
+	if kind == PaymentPartFailed {
+		p.failedPolls = 0
+	}
+	return !known
+}
+
+func (p *trackedPayment) allFailed() bool {
+	if len(p.parts) == 0 {
+		return false
+	}
+	for _, part := range p.parts {
+		if part.Kind != PaymentPartFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// Emit the final event, if the payment has concluded
+func (p *trackedPayment) done() bool {
+	if p.finished || len(p.parts) == 0 {
+		return p.finished
+	}
+	final := PaymentEvent{Kind: PaymentSucceeded}
+	succeeded := false
+	var lastFailure string
+	for _, part := range p.parts {
+		switch part.Kind {
+		case PaymentPartSent:
+			return false
+		case PaymentPartSucceeded:
+			succeeded = true
+			final.AmountMsat += part.AmountMsat
+			final.SentMsat += part.SentMsat
+			if part.Preimage != "" {
+				final.Preimage = part.Preimage
+			}
+		case PaymentPartFailed:
+			if part.Message != "" {
+				lastFailure = part.Message
+			}
+		}
+	}
+	if !succeeded {
+		// wait out a poll in case the payer retries
+		if p.failedPolls < 2 {
+			return false
+		}
+		final = PaymentEvent{Kind: PaymentFailed, Message: lastFailure}
+	}
+	p.finished = true
+	p.emit(final)
+	return true
+}
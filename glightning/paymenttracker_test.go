@@ -0,0 +1,108 @@
+package glightning_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const trackedHash = "a8f8a6fa0e1c3b0b7d5b4a4ae7c1e0f1f0a3c7ee8b5e9b3de2f7c3a5d4b1e0c9"
+
+func kinds(events []glightning.PaymentEvent) []glightning.PaymentEventKind {
+	var ks []glightning.PaymentEventKind
+	for _, e := range events {
+		ks = append(ks, e.Kind)
+	}
+	return ks
+}
+
+func collect(ch <-chan glightning.PaymentEvent) []glightning.PaymentEvent {
+	var events []glightning.PaymentEvent
+	for e := range ch {
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestTrackPayment(t *testing.T) {
+	var mu sync.Mutex
+	sendpays := []glightning.SendPayFields{
+		{PaymentHash: trackedHash, PartId: 1, GroupId: 1, AmountMilliSatoshi: "60000msat", MilliSatoshiSent: "60100msat", Status: "pending"},
+		{PaymentHash: trackedHash, PartId: 2, GroupId: 1, AmountMilliSatoshi: "40000msat", MilliSatoshiSent: "40050msat", Status: "pending"},
+	}
+	ln := mock.New()
+	ln.ListSendPaysByHashFunc = func(paymentHash string) ([]glightning.SendPayFields, error) {
+		assert.Equal(t, trackedHash, paymentHash)
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]glightning.SendPayFields(nil), sendpays...), nil
+	}
+	events := glightning.NewEvents(glightning.NewPlugin(nullInitFunc))
+	tracker := glightning.NewPaymentTracker(ln, events)
+	tracker.PollInterval = 5 * time.Millisecond
+
+	ch := tracker.TrackPayment(trackedHash)
+	assert.Equal(t, glightning.PaymentPending, (<-ch).Kind)
+	assert.Equal(t, glightning.PaymentPartSent, (<-ch).Kind)
+	assert.Equal(t, glightning.PaymentPartSent, (<-ch).Kind)
+
+	// a notification for another payment is ignored
+	events.Publish(glightning.EventSendPaySuccess, &glightning.SendPaySuccess{PaymentHash: "00", PartId: 1, GroupId: 1})
+	// part 1 fails by notification, then part 2 succeeds in listsendpays
+	events.Publish(glightning.EventSendPayFailure, &glightning.SendPayFailure{
+		Message: "WIRE_TEMPORARY_CHANNEL_FAILURE",
+		Data:    glightning.SendPayFailureData{PaymentHash: trackedHash, PartId: 1, GroupId: 1, Status: "failed"},
+	})
+	failed := <-ch
+	assert.Equal(t, glightning.PaymentPartFailed, failed.Kind)
+	assert.Equal(t, uint64(1), failed.PartId)
+	assert.Equal(t, "WIRE_TEMPORARY_CHANNEL_FAILURE", failed.Message)
+
+	mu.Lock()
+	sendpays[0].Status = "failed"
+	sendpays[1].Status = "complete"
+	sendpays[1].PaymentPreimage = "ff"
+	mu.Unlock()
+
+	rest := collect(ch)
+	assert.Equal(t, []glightning.PaymentEventKind{glightning.PaymentPartSucceeded, glightning.PaymentSucceeded}, kinds(rest))
+	if len(rest) == 2 {
+		assert.Equal(t, uint64(2), rest[0].PartId)
+		assert.Equal(t, "ff", rest[1].Preimage)
+		assert.Equal(t, uint64(40000), rest[1].AmountMsat)
+		assert.Equal(t, uint64(40050), rest[1].SentMsat)
+		assert.Equal(t, trackedHash, rest[1].PaymentHash)
+	}
+}
+
+func TestTrackPaymentFails(t *testing.T) {
+	ln := mock.New()
+	ln.ListSendPaysByHashFunc = func(paymentHash string) ([]glightning.SendPayFields, error) {
+		return []glightning.SendPayFields{
+			{PaymentHash: trackedHash, PartId: 0, GroupId: 1, AmountMilliSatoshi: "1000msat", Status: "failed"},
+		}, nil
+	}
+	tracker := glightning.NewPaymentTracker(ln, nil)
+	tracker.PollInterval = time.Millisecond
+
+	events := collect(tracker.TrackPayment(trackedHash))
+	assert.Equal(t, []glightning.PaymentEventKind{
+		glightning.PaymentPending,
+		glightning.PaymentPartSent,
+		glightning.PaymentPartFailed,
+		glightning.PaymentFailed,
+	}, kinds(events))
+
+	// nothing sent yet: the stream ends with the context
+	ln.ListSendPaysByHashFunc = func(paymentHash string) ([]glightning.SendPayFields, error) {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Empty(t, collect(tracker.TrackPaymentCtx(ctx, trackedHash)))
+}
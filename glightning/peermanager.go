@@ -0,0 +1,322 @@
+package glightning
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// How a peer kept connected by a PeerManager has fared
+type PeerConnectivity struct {
+	NodeId    string
+	Connected bool
+	// When the current connection was seen to start, if connected
+	ConnectedSince time.Time
+	LastDisconnect time.Time
+	Disconnects    uint64
+	// Connection attempts made by the manager, and how many failed
+	Attempts uint64
+	Failures uint64
+	// The last attempt's error, if it failed
+	LastError error
+	// The address the manager last connected on
+	LastAddress *Address
+	// When the manager will next try to connect, if disconnected
+	NextAttempt time.Time
+}
+
+type managedPeer struct {
+	stats   PeerConnectivity
+	backoff time.Duration
+}
+
+// PeerManager keeps a set of important peers connected. Whenever one
+// disconnects it's reconnected with ConnectBestEffort, across its
+// gossiped addresses, backing off from MinBackoff to MaxBackoff while
+// attempts keep failing.
+//
+//	peers := glightning.NewPeerManager(ln, events)
+//	peers.Add(lspNodeId, exchangeNodeId)
+//	err := peers.Start()
+//	defer peers.Stop()
+//
+// Disconnects are seen as they happen with an Events bus, and in any
+// case by checking listpeers every CheckInterval.
+type PeerManager struct {
+	// How often to check listpeers. Defaults to a minute.
+	CheckInterval time.Duration
+	// How long to wait after a failed attempt, doubling with each
+	// failure up to MaxBackoff. Default to 5 seconds and 10 minutes.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Called after each successful reconnection
+	OnConnect func(nodeId string, result *ConnectBestEffortResult)
+	// Called with each failed attempt's error, and with an empty
+	// {nodeId} if listpeers fails
+	OnError func(nodeId string, err error)
+
+	client LightningClient
+	events *Events
+	wake   chan struct{}
+	done   chan struct{}
+	exited chan struct{}
+
+	mu       sync.Mutex
+	peers    map[string]*managedPeer
+	stopOnce sync.Once
+}
+
+// A manager connecting through {client}; {events} may be nil
+func NewPeerManager(client LightningClient, events *Events) *PeerManager {
+	return &PeerManager{
+		CheckInterval: time.Minute,
+		MinBackoff:    5 * time.Second,
+		MaxBackoff:    10 * time.Minute,
+		client:        client,
+		events:        events,
+		wake:          make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		exited:        make(chan struct{}),
+		peers:         make(map[string]*managedPeer),
+	}
+}
+
+// Keep {nodeIds} connected. Peers may be added while running.
+func (m *PeerManager) Add(nodeIds ...string) {
+	m.mu.Lock()
+	for _, id := range nodeIds {
+		if _, ok := m.peers[id]; !ok {
+			m.peers[id] = &managedPeer{stats: PeerConnectivity{NodeId: id}}
+		}
+	}
+	m.mu.Unlock()
+	m.poke()
+}
+
+// Stop looking after {nodeIds}. They're left connected.
+func (m *PeerManager) Remove(nodeIds ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range nodeIds {
+		delete(m.peers, id)
+	}
+}
+
+// The connectivity of every peer managed, by node id
+func (m *PeerManager) Stats() []PeerConnectivity {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make([]PeerConnectivity, 0, len(m.peers))
+	for _, peer := range m.peers {
+		stats = append(stats, peer.stats)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].NodeId < stats[j].NodeId
+	})
+	return stats
+}
+
+// The connectivity of {nodeId}; false if it isn't managed
+func (m *PeerManager) PeerStats(nodeId string) (PeerConnectivity, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	peer, ok := m.peers[nodeId]
+	if !ok {
+		return PeerConnectivity{}, false
+	}
+	return peer.stats, true
+}
+
+// Check which peers are connected and start keeping them so
+func (m *PeerManager) Start() error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	var sub *EventSubscription
+	if m.events != nil {
+		sub = m.events.Subscribe(16, OnlyKinds(EventConnect, EventDisconnect))
+	}
+	go m.run(sub)
+	return nil
+}
+
+// Stop reconnecting, waiting for any attempt in progress to finish
+func (m *PeerManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+	<-m.exited
+}
+
+func (m *PeerManager) poke() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Note peers whose connectedness has changed, according to listpeers
+func (m *PeerManager) check() error {
+	peers, err := m.client.ListPeers()
+	if err != nil {
+		return err
+	}
+	connected := make(map[string]bool, len(peers))
+	for _, peer := range peers {
+		connected[peer.Id] = peer.Connected
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for id, peer := range m.peers {
+		m.setConnected(peer, connected[id], now)
+	}
+	return nil
+}
+
+// Call with mu held
+func (m *PeerManager) setConnected(peer *managedPeer, connected bool, now time.Time) {
+	s := &peer.stats
+	if connected == s.Connected {
+		return
+	}
+	s.Connected = connected
+	if connected {
+		s.ConnectedSince = now
+		s.NextAttempt = time.Time{}
+		peer.backoff = 0
+		return
+	}
+	s.ConnectedSince = time.Time{}
+	s.Disconnects++
+	s.LastDisconnect = now
+	s.NextAttempt = now
+}
+
+func (m *PeerManager) run(sub *EventSubscription) {
+	defer close(m.exited)
+	var notifications <-chan *Event
+	if sub != nil {
+		defer sub.Close()
+		notifications = sub.C
+	}
+	lastCheck := time.Now()
+
+	for {
+		m.reconnect()
+
+		m.mu.Lock()
+		wait := m.CheckInterval - time.Since(lastCheck)
+		for _, peer := range m.peers {
+			if !peer.stats.Connected {
+				if until := time.Until(peer.stats.NextAttempt); until < wait {
+					wait = until
+				}
+			}
+		}
+		m.mu.Unlock()
+		if wait < 0 {
+			wait = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-m.wake:
+		case e, ok := <-notifications:
+			if !ok {
+				notifications = nil
+				break
+			}
+			m.notified(e)
+		case <-m.done:
+			timer.Stop()
+			return
+		}
+		timer.Stop()
+
+		if time.Since(lastCheck) >= m.CheckInterval {
+			lastCheck = time.Now()
+			if err := m.check(); err != nil && m.OnError != nil {
+				m.OnError("", err)
+			}
+		}
+	}
+}
+
+func (m *PeerManager) notified(e *Event) {
+	var id string
+	connected := e.Kind == EventConnect
+	switch p := e.Payload.(type) {
+	case *ConnectEvent:
+		id = p.PeerId
+	case *DisconnectEvent:
+		id = p.PeerId
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if peer, ok := m.peers[id]; ok {
+		m.setConnected(peer, connected, time.Now())
+	}
+}
+
+// Try connecting each disconnected peer which is due an attempt
+func (m *PeerManager) reconnect() {
+	m.mu.Lock()
+	now := time.Now()
+	var due []string
+	for id, peer := range m.peers {
+		if !peer.stats.Connected && !now.Before(peer.stats.NextAttempt) {
+			due = append(due, id)
+		}
+	}
+	m.mu.Unlock()
+	sort.Strings(due)
+
+	for _, id := range due {
+		select {
+		case <-m.done:
+			return
+		default:
+		}
+		result, err := m.client.ConnectBestEffort(id)
+
+		m.mu.Lock()
+		peer, ok := m.peers[id]
+		if ok {
+			s := &peer.stats
+			s.Attempts++
+			if err != nil {
+				s.Failures++
+				s.LastError = err
+				peer.backoff *= 2
+				if peer.backoff < m.MinBackoff {
+					peer.backoff = m.MinBackoff
+				}
+				if peer.backoff > m.MaxBackoff {
+					peer.backoff = m.MaxBackoff
+				}
+				s.NextAttempt = time.Now().Add(peer.backoff)
+			} else {
+				s.LastError = nil
+				addr := result.Address
+				s.LastAddress = &addr
+				m.setConnected(peer, true, time.Now())
+			}
+		}
+		m.mu.Unlock()
+
+		if !ok {
+			continue
+		}
+		if err != nil {
+			if m.OnError != nil {
+				m.OnError(id, err)
+			}
+		} else if m.OnConnect != nil {
+			m.OnConnect(id, result)
+		}
+	}
+}
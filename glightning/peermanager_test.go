@@ -0,0 +1,124 @@
+package glightning_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerManager(t *testing.T) {
+	var mu sync.Mutex
+	connected := map[string]bool{"02aa": true}
+	failures := 2
+	ln := mock.New()
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		var peers []*glightning.Peer
+		for id, c := range connected {
+			peers = append(peers, &glightning.Peer{Id: id, Connected: c})
+		}
+		return peers, nil
+	}
+	ln.ConnectBestEffortFunc = func(nodeId string) (*glightning.ConnectBestEffortResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if nodeId == "02bb" && failures > 0 {
+			failures--
+			return nil, errors.New("Connection refused")
+		}
+		connected[nodeId] = true
+		addr := glightning.Address{Type: "ipv4", Addr: "10.0.0.2", Port: 9735}
+		return &glightning.ConnectBestEffortResult{Address: addr}, nil
+	}
+
+	events := glightning.NewEvents(glightning.NewPlugin(nullInitFunc))
+	peers := glightning.NewPeerManager(ln, events)
+	peers.MinBackoff = time.Millisecond
+	peers.MaxBackoff = 2 * time.Millisecond
+	reconnected := make(chan string, 4)
+	peers.OnConnect = func(nodeId string, result *glightning.ConnectBestEffortResult) {
+		reconnected <- nodeId
+	}
+	var errs []error
+	peers.OnError = func(nodeId string, err error) {
+		errs = append(errs, err)
+	}
+	peers.Add("02aa", "02bb")
+	if err := peers.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer peers.Stop()
+
+	// 02bb connects on the third attempt
+	assert.Equal(t, "02bb", <-reconnected)
+
+	mu.Lock()
+	connected["02aa"] = false
+	mu.Unlock()
+	events.Publish(glightning.EventDisconnect, &glightning.DisconnectEvent{PeerId: "02aa"})
+	assert.Equal(t, "02aa", <-reconnected)
+	peers.Stop()
+
+	stats := peers.Stats()
+	if assert.Len(t, stats, 2) {
+		aa, bb := stats[0], stats[1]
+		assert.Equal(t, "02aa", aa.NodeId)
+		assert.True(t, aa.Connected)
+		assert.Equal(t, uint64(1), aa.Disconnects)
+		assert.Equal(t, uint64(1), aa.Attempts)
+		assert.False(t, aa.LastDisconnect.IsZero())
+
+		assert.True(t, bb.Connected)
+		assert.Equal(t, uint64(3), bb.Attempts)
+		assert.Equal(t, uint64(2), bb.Failures)
+		assert.Nil(t, bb.LastError)
+		assert.Equal(t, "10.0.0.2", bb.LastAddress.Addr)
+		assert.True(t, bb.NextAttempt.IsZero())
+	}
+	assert.Len(t, errs, 2)
+
+	peers.Remove("02bb")
+	_, ok := peers.PeerStats("02bb")
+	assert.False(t, ok)
+}
+
+func TestPeerManagerPolls(t *testing.T) {
+	var mu sync.Mutex
+	up := true
+	ln := mock.New()
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []*glightning.Peer{{Id: "02aa", Connected: up}}, nil
+	}
+	ln.ConnectBestEffortFunc = func(nodeId string) (*glightning.ConnectBestEffortResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		up = true
+		return &glightning.ConnectBestEffortResult{}, nil
+	}
+
+	// without an Events bus, the disconnect is found by polling
+	peers := glightning.NewPeerManager(ln, nil)
+	peers.CheckInterval = time.Millisecond
+	reconnected := make(chan string, 1)
+	peers.OnConnect = func(nodeId string, result *glightning.ConnectBestEffortResult) {
+		reconnected <- nodeId
+	}
+	peers.Add("02aa")
+	if err := peers.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer peers.Stop()
+
+	mu.Lock()
+	up = false
+	mu.Unlock()
+	assert.Equal(t, "02aa", <-reconnected)
+}
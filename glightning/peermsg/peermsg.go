@@ -0,0 +1,380 @@
+// Package peermsg frames app-level peer protocols over lightningd's
+// custom messages (sendcustommsg and the custommsg hook): handlers are
+// registered per message type, payloads too big for one message are
+// split into chunks and reassembled, and requests are matched up with
+// their replies.
+//
+//	m := peermsg.New(lightning)
+//	m.Handle(0x8a01, func(peerId string, payload []byte) ([]byte, error) {
+//		return []byte("pong"), nil
+//	})
+//	m.Register(plugin)
+//	...
+//	reply, err := m.Request(peerId, 0x8a01, []byte("ping"), 10*time.Second)
+//
+// Each custom message's body is a TLV stream carrying a message id,
+// the id being replied to, if any, chunk numbering and the payload.
+// Both peers need to be using this framing.
+package peermsg
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/tlv"
+)
+
+// How much payload goes in each custom message, leaving room for the
+// framing within lightningd's 65535 byte message limit
+const ChunkSize = 65000
+
+var ErrTimeout = errors.New("Timed out waiting for a reply")
+
+// The error a peer's handler returned for our request
+type RemoteError struct {
+	PeerId  string
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("Peer %s: %s", e.PeerId, e.Message)
+}
+
+// Handles a message's payload from {peerId}. If the message is a
+// request, the returned payload (or error) is sent back as the reply.
+type Handler func(peerId string, payload []byte) ([]byte, error)
+
+const (
+	fieldId        = 0
+	fieldReplyTo   = 2
+	fieldWantReply = 4
+	fieldChunk     = 6
+	fieldChunks    = 8
+	fieldError     = 10
+	fieldPayload   = 12
+)
+
+var schema = tlv.NewSchema().
+	Register(fieldId, "id", tlv.Tu64Decoder).
+	Register(fieldReplyTo, "reply_to", tlv.Tu64Decoder).
+	Register(fieldWantReply, "want_reply", tlv.BytesDecoder).
+	Register(fieldChunk, "chunk", tlv.Tu32Decoder).
+	Register(fieldChunks, "chunks", tlv.Tu32Decoder).
+	Register(fieldError, "error", tlv.StringDecoder).
+	Register(fieldPayload, "payload", tlv.BytesDecoder)
+
+type frame struct {
+	id        uint64
+	replyTo   uint64
+	wantReply bool
+	chunk     uint32
+	chunks    uint32
+	err       string
+	payload   []byte
+}
+
+func (f *frame) encode(msgType uint16) ([]byte, error) {
+	records := []*tlv.Record{tlv.NewTu64Record(fieldId, f.id)}
+	if f.replyTo != 0 {
+		records = append(records, tlv.NewTu64Record(fieldReplyTo, f.replyTo))
+	}
+	if f.wantReply {
+		records = append(records, &tlv.Record{Type: fieldWantReply, Value: []byte{}})
+	}
+	if f.chunks > 1 {
+		records = append(records,
+			tlv.NewTu64Record(fieldChunk, uint64(f.chunk)),
+			tlv.NewTu64Record(fieldChunks, uint64(f.chunks)))
+	}
+	if f.err != "" {
+		records = append(records, &tlv.Record{Type: fieldError, Value: []byte(f.err)})
+	}
+	records = append(records, &tlv.Record{Type: fieldPayload, Value: f.payload})
+	return tlv.EncodeMessage(msgType, records)
+}
+
+func decodeFrame(records []*tlv.Record) (*frame, error) {
+	values, err := schema.DecodeRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	f := &frame{chunks: 1}
+	id, ok := values[fieldId].(uint64)
+	if !ok {
+		return nil, fmt.Errorf("Missing message id")
+	}
+	f.id = id
+	if v, ok := values[fieldReplyTo].(uint64); ok {
+		f.replyTo = v
+	}
+	_, f.wantReply = values[fieldWantReply]
+	if v, ok := values[fieldChunks].(uint32); ok {
+		f.chunks = v
+		f.chunk, _ = values[fieldChunk].(uint32)
+	}
+	if f.chunks == 0 || f.chunk >= f.chunks {
+		return nil, fmt.Errorf("Bad chunk %d of %d", f.chunk, f.chunks)
+	}
+	if v, ok := values[fieldError].(string); ok {
+		f.err = v
+	}
+	f.payload, _ = values[fieldPayload].([]byte)
+	return f, nil
+}
+
+// A message being reassembled from its chunks
+type partial struct {
+	first    *frame
+	chunks   [][]byte
+	received int
+	size     int
+	started  time.Time
+}
+
+type reply struct {
+	payload []byte
+	err     error
+}
+
+type pendingRequest struct {
+	peerId string
+	ch     chan *reply
+}
+
+type Messenger struct {
+	// Messages bigger than this are dropped
+	MaxMessageSize int
+	// Partly received messages are dropped after this long
+	ReassemblyTimeout time.Duration
+
+	client   glightning.LightningClient
+	mu       sync.Mutex
+	nextId   uint64
+	handlers map[uint16]Handler
+	// message types we've made requests with, so take replies for
+	types    map[uint16]bool
+	partials map[string]*partial
+	pending  map[uint64]*pendingRequest
+}
+
+func New(client glightning.LightningClient) *Messenger {
+	return &Messenger{
+		MaxMessageSize:    4 << 20,
+		ReassemblyTimeout: time.Minute,
+		client:            client,
+		handlers:          make(map[uint16]Handler),
+		types:             make(map[uint16]bool),
+		partials:          make(map[string]*partial),
+		pending:           make(map[uint64]*pendingRequest),
+	}
+}
+
+// Register the custommsg hook, through which messages arrive. If your
+// plugin registers that hook itself, call OnCustomMsg from it instead.
+func (m *Messenger) Register(plugin *glightning.Plugin) error {
+	return plugin.RegisterHooks(&glightning.Hooks{
+		CustomMsgReceived: m.OnCustomMsg,
+	})
+}
+
+func checkType(msgType uint16) error {
+	if msgType%2 == 0 {
+		return fmt.Errorf("Custom message type %d must be odd", msgType)
+	}
+	return nil
+}
+
+// Call {handler} with messages of {msgType}
+func (m *Messenger) Handle(msgType uint16, handler Handler) error {
+	if err := checkType(msgType); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[msgType] = handler
+	m.types[msgType] = true
+	return nil
+}
+
+// Send {payload} to {peerId} as a {msgType} message, expecting no reply
+func (m *Messenger) Send(peerId string, msgType uint16, payload []byte) error {
+	if err := checkType(msgType); err != nil {
+		return err
+	}
+	return m.send(peerId, msgType, &frame{id: m.newId(), payload: payload})
+}
+
+// Send {payload} to {peerId} as a {msgType} message, and wait up to
+// {timeout} for their handler's reply
+func (m *Messenger) Request(peerId string, msgType uint16, payload []byte, timeout time.Duration) ([]byte, error) {
+	if err := checkType(msgType); err != nil {
+		return nil, err
+	}
+	id := m.newId()
+	ch := make(chan *reply, 1)
+	m.mu.Lock()
+	m.types[msgType] = true
+	m.pending[id] = &pendingRequest{peerId: peerId, ch: ch}
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.pending, id)
+		m.mu.Unlock()
+	}()
+
+	if err := m.send(peerId, msgType, &frame{id: id, wantReply: true, payload: payload}); err != nil {
+		return nil, err
+	}
+	select {
+	case r := <-ch:
+		return r.payload, r.err
+	case <-time.After(timeout):
+		return nil, ErrTimeout
+	}
+}
+
+func (m *Messenger) newId() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextId++
+	return m.nextId
+}
+
+// Send {f}, split into as many chunks as its payload needs
+func (m *Messenger) send(peerId string, msgType uint16, f *frame) error {
+	payload := f.payload
+	f.chunks = uint32((len(payload) + ChunkSize - 1) / ChunkSize)
+	if f.chunks == 0 {
+		f.chunks = 1
+	}
+	for f.chunk = 0; f.chunk < f.chunks; f.chunk++ {
+		end := len(payload)
+		if end > ChunkSize {
+			end = ChunkSize
+		}
+		f.payload, payload = payload[:end], payload[end:]
+		msg, err := f.encode(msgType)
+		if err != nil {
+			return err
+		}
+		if _, err := m.client.SendCustomMessage(peerId, hex.EncodeToString(msg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The custommsg hook. Messages of types we don't handle are left for
+// other plugins.
+func (m *Messenger) OnCustomMsg(event *glightning.CustomMsgReceivedEvent) (*glightning.CustomMsgReceivedResponse, error) {
+	msgType, records, err := event.TlvMessage()
+	if err != nil {
+		return event.Continue(), nil
+	}
+	m.mu.Lock()
+	ours := m.types[msgType]
+	m.mu.Unlock()
+	if !ours {
+		return event.Continue(), nil
+	}
+
+	f, err := decodeFrame(records)
+	if err != nil {
+		return event.Continue(), nil
+	}
+	payload, complete := m.reassemble(event.PeerId, msgType, f)
+	if complete {
+		f.payload = payload
+		m.dispatch(event.PeerId, msgType, f)
+	}
+	return event.Continue(), nil
+}
+
+// Add {f} to its message, returning the whole payload once all its
+// chunks are in
+func (m *Messenger) reassemble(peerId string, msgType uint16, f *frame) ([]byte, bool) {
+	if f.chunks == 1 {
+		return f.payload, true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for key, p := range m.partials {
+		if now.Sub(p.started) > m.ReassemblyTimeout {
+			delete(m.partials, key)
+		}
+	}
+
+	key := fmt.Sprintf("%s/%d/%d", peerId, msgType, f.id)
+	p, ok := m.partials[key]
+	if !ok {
+		// all but the last chunk are full, so we know it's too big
+		if int(f.chunks-1)*ChunkSize > m.MaxMessageSize {
+			return nil, false
+		}
+		p = &partial{first: f, chunks: make([][]byte, f.chunks), started: now}
+		m.partials[key] = p
+	}
+	if f.chunks != p.first.chunks || p.chunks[f.chunk] != nil {
+		return nil, false
+	}
+	p.chunks[f.chunk] = f.payload
+	p.received++
+	p.size += len(f.payload)
+	if p.size > m.MaxMessageSize {
+		delete(m.partials, key)
+		return nil, false
+	}
+	if p.received < len(p.chunks) {
+		return nil, false
+	}
+
+	delete(m.partials, key)
+	payload := make([]byte, 0, p.size)
+	for _, chunk := range p.chunks {
+		payload = append(payload, chunk...)
+	}
+	return payload, true
+}
+
+func (m *Messenger) dispatch(peerId string, msgType uint16, f *frame) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if f.replyTo != 0 {
+		req, ok := m.pending[f.replyTo]
+		if !ok || req.peerId != peerId {
+			return
+		}
+		r := &reply{payload: f.payload}
+		if f.err != "" {
+			r.err = &RemoteError{PeerId: peerId, Message: f.err}
+		}
+		req.ch <- r
+		delete(m.pending, f.replyTo)
+		return
+	}
+
+	handler, ok := m.handlers[msgType]
+	if !ok {
+		return
+	}
+	// lightningd waits on the hook, so handle (and reply) elsewhere
+	go func() {
+		payload, err := handler(peerId, f.payload)
+		if !f.wantReply {
+			return
+		}
+		r := &frame{id: m.newId(), replyTo: f.id, payload: payload}
+		if err != nil {
+			r.err = err.Error()
+			r.payload = nil
+		}
+		m.send(peerId, msgType, r)
+	}()
+}
@@ -0,0 +1,120 @@
+package peermsg_test
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/elementsproject/glightning/glightning/peermsg"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	alice = "02aa"
+	bob   = "02bb"
+	ping  = 0x8a01
+)
+
+// Two messengers whose custom messages are delivered to each other
+func pair() (*peermsg.Messenger, *peermsg.Messenger, *[]string) {
+	var mu sync.Mutex
+	var sent []string
+	aliceLn, bobLn := mock.New(), mock.New()
+	a, b := peermsg.New(aliceLn), peermsg.New(bobLn)
+	deliver := func(to *peermsg.Messenger, from string) func(string, string) (*glightning.CustomMessageResult, error) {
+		return func(nodeId, msg string) (*glightning.CustomMessageResult, error) {
+			mu.Lock()
+			sent = append(sent, msg)
+			mu.Unlock()
+			to.OnCustomMsg(&glightning.CustomMsgReceivedEvent{PeerId: from, Payload: msg})
+			return &glightning.CustomMessageResult{}, nil
+		}
+	}
+	aliceLn.SendCustomMessageFunc = deliver(b, alice)
+	bobLn.SendCustomMessageFunc = deliver(a, bob)
+	return a, b, &sent
+}
+
+func TestRequest(t *testing.T) {
+	a, b, sent := pair()
+	b.Handle(ping, func(peerId string, payload []byte) ([]byte, error) {
+		assert.Equal(t, alice, peerId)
+		return append([]byte("pong:"), payload...), nil
+	})
+
+	reply, err := a.Request(bob, ping, []byte("hi"), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "pong:hi", string(reply))
+	// id 1, want_reply, "hi"; then bob's reply to 1
+	assert.Equal(t, []string{"8a0100010104000c026869", "8a010001010201010c07706f6e673a6869"}, *sent)
+}
+
+func TestChunking(t *testing.T) {
+	a, b, sent := pair()
+	big := bytes.Repeat([]byte("0123456789"), 20000)
+	received := make(chan []byte, 1)
+	b.Handle(ping, func(peerId string, payload []byte) ([]byte, error) {
+		received <- payload
+		return nil, nil
+	})
+
+	assert.NoError(t, a.Send(bob, ping, big))
+	select {
+	case got := <-received:
+		assert.Equal(t, big, got)
+	case <-time.After(time.Second):
+		t.Fatal("message not reassembled")
+	}
+	assert.Len(t, *sent, 4)
+	for _, msg := range *sent {
+		assert.True(t, len(msg)/2 <= 65535)
+	}
+
+	// too big to accept
+	b.MaxMessageSize = 100000
+	assert.NoError(t, a.Send(bob, ping, big))
+	select {
+	case <-received:
+		t.Fatal("oversized message delivered")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRemoteError(t *testing.T) {
+	a, b, _ := pair()
+	b.Handle(ping, func(peerId string, payload []byte) ([]byte, error) {
+		return nil, errors.New("Not now")
+	})
+
+	_, err := a.Request(bob, ping, nil, time.Second)
+	assert.Equal(t, &peermsg.RemoteError{PeerId: bob, Message: "Not now"}, err)
+
+	// nobody handling it at the other end
+	_, err = b.Request(alice, 0x8a03, nil, 20*time.Millisecond)
+	assert.Equal(t, peermsg.ErrTimeout, err)
+
+	assert.Error(t, a.Send(bob, 0x8a02, nil))
+	assert.Error(t, a.Handle(0x8a02, nil))
+}
+
+func TestOtherMessages(t *testing.T) {
+	ln := mock.New()
+	m := peermsg.New(ln)
+	m.Handle(ping, func(peerId string, payload []byte) ([]byte, error) {
+		t.Fatal("handled a message which wasn't ours")
+		return nil, nil
+	})
+
+	for _, payload := range []string{"8a03000101", "8a01", "8a01ff", "8a01020101", "zz"} {
+		resp, err := m.OnCustomMsg(&glightning.CustomMsgReceivedEvent{PeerId: alice, Payload: payload})
+		assert.NoError(t, err)
+		assert.Equal(t, (&glightning.CustomMsgReceivedEvent{}).Continue(), resp)
+	}
+	time.Sleep(20 * time.Millisecond)
+}
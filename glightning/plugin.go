@@ -2,6 +2,7 @@ package glightning
 
 import (
 	"bufio"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/elementsproject/glightning/glightning/tlv"
 	"github.com/elementsproject/glightning/jrpc2"
 )
 
@@ -26,10 +28,14 @@ const (
 	_Forward        Subscription = "forward_event"
 	_SendPaySuccess Subscription = "sendpay_success"
 	_SendPayFailure Subscription = "sendpay_failure"
+	_ChannelState   Subscription = "channel_state_changed"
+	_BlockAdded     Subscription = "block_added"
+	_Log            Subscription = "log"
 	_PeerConnected  Hook         = "peer_connected"
 	_DbWrite        Hook         = "db_write"
 	_InvoicePayment Hook         = "invoice_payment"
 	_OpenChannel    Hook         = "openchannel"
+	_OpenChannel2   Hook         = "openchannel2"
 	_HtlcAccepted   Hook         = "htlc_accepted"
 	_RpcCommand     Hook         = "rpc_command"
 	_CustomMsg      Hook         = "custommsg"
@@ -54,6 +60,16 @@ type CustomMsgReceivedResponse struct {
 	Result _CustomMsgReceivedResult `json:"result"`
 }
 
+// The message's type and TLV stream body, for messages sent with
+// SendCustomTlvMessage
+func (pc *CustomMsgReceivedEvent) TlvMessage() (uint16, []*tlv.Record, error) {
+	msg, err := hex.DecodeString(pc.Payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	return tlv.DecodeMessage(msg)
+}
+
 func (pc *CustomMsgReceivedEvent) New() interface{} {
 	return &CustomMsgReceivedEvent{
 		hook: pc.hook,
@@ -230,18 +246,38 @@ type OpenChannelEvent struct {
 }
 
 type OpenChannel struct {
-	PeerId                            string `json:"id"`
-	FundingSatoshis                   string `json:"funding_satoshis"`
-	PushMilliSatoshis                 string `json:"push_msat"`
-	DustLimitSatoshis                 string `json:"dust_limit_satoshis"`
-	MaxHtlcValueInFlightMilliSatoshis string `json:"max_htlc_value_in_flight_msat"`
-	ChannelReserveSatoshis            string `json:"channel_reserve_satoshis"`
-	HtlcMinimumMillisatoshis          string `json:"htlc_minimum_msat"`
-	FeeratePerKw                      int    `json:"feerate_per_kw"`
-	ToSelfDelay                       int    `json:"to_self_delay"`
-	MaxAcceptedHtlcs                  int    `json:"max_accepted_htlcs"`
-	ChannelFlags                      int    `json:"channel_flags"`
-	ShutdownScriptPubkey              string `json:"shutdown_scriptpubkey"`
+	PeerId                            string       `json:"id"`
+	FundingSatoshis                   string       `json:"funding_satoshis"`
+	PushMilliSatoshis                 string       `json:"push_msat"`
+	DustLimitSatoshis                 string       `json:"dust_limit_satoshis"`
+	MaxHtlcValueInFlightMilliSatoshis string       `json:"max_htlc_value_in_flight_msat"`
+	ChannelReserveSatoshis            string       `json:"channel_reserve_satoshis"`
+	HtlcMinimumMillisatoshis          string       `json:"htlc_minimum_msat"`
+	FeeratePerKw                      int          `json:"feerate_per_kw"`
+	ToSelfDelay                       int          `json:"to_self_delay"`
+	MaxAcceptedHtlcs                  int          `json:"max_accepted_htlcs"`
+	ChannelFlags                      int          `json:"channel_flags"`
+	ShutdownScriptPubkey              string       `json:"shutdown_scriptpubkey"`
+	ChannelType                       *ChannelType `json:"channel_type,omitempty"`
+}
+
+// The channel type a peer proposes when opening a channel
+type ChannelType struct {
+	Bits  []FeatureBit `json:"bits"`
+	Names []string     `json:"names"`
+}
+
+// Whether the channel type includes feature {bit}
+func (t *ChannelType) Has(bit FeatureBit) bool {
+	if t == nil {
+		return false
+	}
+	for _, b := range t.Bits {
+		if b == bit {
+			return true
+		}
+	}
+	return false
 }
 
 type OpenChannelResult string
@@ -255,6 +291,9 @@ type OpenChannelResponse struct {
 	// Sent back to peer.
 	Message        string `json:"error_message,omitempty"`
 	CloseToAddress string `json:"close_to,omitempty"`
+	// Confirmations before the channel is usable, overriding
+	// lightningd's; 0 accepts the channel as zero-conf
+	MinDepth *uint32 `json:"mindepth,omitempty"`
 }
 
 func (oc *OpenChannelEvent) New() interface{} {
@@ -291,6 +330,100 @@ func (oc *OpenChannelEvent) ContinueWithCloseTo(address string) *OpenChannelResp
 	}
 }
 
+// Accept the channel once its funding has {depth} confirmations
+func (oc *OpenChannelEvent) ContinueWithMinDepth(depth uint32) *OpenChannelResponse {
+	return &OpenChannelResponse{
+		Result:   OcContinue,
+		MinDepth: &depth,
+	}
+}
+
+// Accept the channel for use before its funding confirms. Only do
+// this for peers trusted not to double-spend the funding.
+func (oc *OpenChannelEvent) ContinueZeroConf() *OpenChannelResponse {
+	return oc.ContinueWithMinDepth(0)
+}
+
+// The openchannel2 hook is called when a peer proposes a dual-funded
+// (v2) channel
+type OpenChannel2Event struct {
+	OpenChannel2 OpenChannel2 `json:"openchannel2"`
+	hook         func(*OpenChannel2Event) (*OpenChannel2Response, error)
+}
+
+type OpenChannel2 struct {
+	PeerId                            string       `json:"id"`
+	ChannelId                         string       `json:"channel_id"`
+	TheirFundingMilliSatoshis         string       `json:"their_funding_msat"`
+	DustLimitMilliSatoshis            string       `json:"dust_limit_msat"`
+	MaxHtlcValueInFlightMilliSatoshis string       `json:"max_htlc_value_in_flight_msat"`
+	HtlcMinimumMilliSatoshis          string       `json:"htlc_minimum_msat"`
+	FundingFeeratePerKw               int          `json:"funding_feerate_per_kw"`
+	CommitmentFeeratePerKw            int          `json:"commitment_feerate_per_kw"`
+	FeerateOurMax                     int          `json:"feerate_our_max"`
+	FeerateOurMin                     int          `json:"feerate_our_min"`
+	ToSelfDelay                       int          `json:"to_self_delay"`
+	MaxAcceptedHtlcs                  int          `json:"max_accepted_htlcs"`
+	ChannelFlags                      int          `json:"channel_flags"`
+	Locktime                          uint32       `json:"locktime"`
+	ShutdownScriptPubkey              string       `json:"shutdown_scriptpubkey,omitempty"`
+	ChannelMaxMilliSatoshis           string       `json:"channel_max_msat,omitempty"`
+	RequestedLeaseMilliSatoshis       string       `json:"requested_lease_msat,omitempty"`
+	LeaseBlockheightStart             uint32       `json:"lease_blockheight_start,omitempty"`
+	NodeBlockheight                   uint32       `json:"node_blockheight,omitempty"`
+	RequireConfirmedInputs            bool         `json:"require_confirmed_inputs,omitempty"`
+	ChannelType                       *ChannelType `json:"channel_type,omitempty"`
+}
+
+type OpenChannel2Response struct {
+	Result OpenChannelResult `json:"result"`
+	// Only allowed if result is "reject"
+	// Sent back to peer.
+	Message        string `json:"error_message,omitempty"`
+	CloseToAddress string `json:"close_to,omitempty"`
+	// Our contribution to the channel, with the PSBT of the inputs
+	// funding it
+	OurFundingMilliSatoshis string `json:"our_funding_msat,omitempty"`
+	Psbt                    string `json:"psbt,omitempty"`
+}
+
+func (oc *OpenChannel2Event) New() interface{} {
+	return &OpenChannel2Event{
+		hook: oc.hook,
+	}
+}
+
+func (oc *OpenChannel2Event) Name() string {
+	return string(_OpenChannel2)
+}
+
+func (oc *OpenChannel2Event) Call() (jrpc2.Result, error) {
+	return oc.hook(oc)
+}
+
+func (oc *OpenChannel2Event) Reject(errorMessage string) *OpenChannel2Response {
+	return &OpenChannel2Response{
+		Result:  OcReject,
+		Message: errorMessage,
+	}
+}
+
+// Accept the channel without contributing funds
+func (oc *OpenChannel2Event) Continue() *OpenChannel2Response {
+	return &OpenChannel2Response{
+		Result: OcContinue,
+	}
+}
+
+// Accept the channel, contributing {amount} from the inputs in {psbt}
+func (oc *OpenChannel2Event) ContinueWithFunding(amount *MSat, psbt string) *OpenChannel2Response {
+	return &OpenChannel2Response{
+		Result:                  OcContinue,
+		OurFundingMilliSatoshis: amount.String(),
+		Psbt:                    psbt,
+	}
+}
+
 type RpcCommandEvent struct {
 	Cmd  RpcCmd `json:"rpc_command"`
 	hook func(*RpcCommandEvent) (*RpcCommandResponse, error)
@@ -670,6 +803,8 @@ type SendPaySuccess struct {
 	CreatedAt              float64 `json:"created_at"`
 	Status                 string  `json:"status"`
 	PaymentPreimage        string  `json:"payment_preimage"`
+	PartId                 uint64  `json:"partid,omitempty"`
+	GroupId                uint64  `json:"groupid,omitempty"`
 }
 
 type SendPaySuccessEvent struct {
@@ -708,6 +843,8 @@ type SendPayFailureData struct {
 	ErringChannel          string `json:"erring_channel"`
 	ErringDirection        int    `json:"erring_direction"`
 	FailCodeName           string `json:"failcodename"`
+	PartId                 uint64 `json:"partid,omitempty"`
+	GroupId                uint64 `json:"groupid,omitempty"`
 }
 
 type SendPayFailure struct {
@@ -763,6 +900,94 @@ func (e *WarnEvent) Call() (jrpc2.Result, error) {
 	return nil, nil
 }
 
+type ChannelStateChangedEvent struct {
+	ChannelStateChanged ChannelStateChanged `json:"channel_state_changed"`
+	cb                  func(*ChannelStateChanged)
+}
+
+type ChannelStateChanged struct {
+	PeerId         string         `json:"peer_id"`
+	ChannelId      string         `json:"channel_id"`
+	ShortChannelId ShortChannelId `json:"short_channel_id"`
+	Timestamp      string         `json:"timestamp"`
+	OldState       string         `json:"old_state"`
+	NewState       string         `json:"new_state"`
+	Cause          string         `json:"cause"`
+	Message        string         `json:"message"`
+}
+
+func (e *ChannelStateChangedEvent) Name() string {
+	return string(_ChannelState)
+}
+
+func (e *ChannelStateChangedEvent) New() interface{} {
+	return &ChannelStateChangedEvent{
+		cb: e.cb,
+	}
+}
+
+func (e *ChannelStateChangedEvent) Call() (jrpc2.Result, error) {
+	e.cb(&e.ChannelStateChanged)
+	return nil, nil
+}
+
+type BlockAddedEvent struct {
+	Block BlockAdded `json:"block_added"`
+	cb    func(*BlockAdded)
+}
+
+type BlockAdded struct {
+	Hash   string `json:"hash"`
+	Height uint32 `json:"height"`
+}
+
+func (e *BlockAddedEvent) Name() string {
+	return string(_BlockAdded)
+}
+
+func (e *BlockAddedEvent) New() interface{} {
+	return &BlockAddedEvent{
+		cb: e.cb,
+	}
+}
+
+func (e *BlockAddedEvent) Call() (jrpc2.Result, error) {
+	e.cb(&e.Block)
+	return nil, nil
+}
+
+type LogEvent struct {
+	Entry LogEntry `json:"log"`
+	cb    func(*LogEntry)
+}
+
+// A line of lightningd's log, from a log notification or getlog
+type LogEntry struct {
+	// broken, unusual, info, debug, io_in or io_out
+	Level string `json:"level"`
+	// A timestamp in a notification; seconds since lightningd started
+	// from getlog
+	Time    string `json:"time"`
+	Source  string `json:"source"`
+	NodeId  string `json:"node_id,omitempty"`
+	Message string `json:"log"`
+}
+
+func (e *LogEvent) Name() string {
+	return string(_Log)
+}
+
+func (e *LogEvent) New() interface{} {
+	return &LogEvent{
+		cb: e.cb,
+	}
+}
+
+func (e *LogEvent) Call() (jrpc2.Result, error) {
+	e.cb(&e.Entry)
+	return nil, nil
+}
+
 type OptionType string
 
 const _String OptionType = "string"
@@ -1052,7 +1277,13 @@ type Manifest struct {
 	Dynamic       bool         `json:"dynamic"`
 	Subscriptions []string     `json:"subscriptions,omitempty"`
 	Hooks         []Hook       `json:"hooks,omitempty"`
-	FeatureBits   *FeatureBits `json:"featurebits,omitempty"`
+	// Custom notification topics the plugin may emit
+	Notifications []NotificationTopic `json:"notifications,omitempty"`
+	FeatureBits   *FeatureBits        `json:"featurebits,omitempty"`
+}
+
+type NotificationTopic struct {
+	Method string `json:"method"`
 }
 
 func (gm GetManifestMethod) Name() string {
@@ -1091,6 +1322,10 @@ func (gm GetManifestMethod) Call() (jrpc2.Result, error) {
 		m.Hooks[i] = hook
 	}
 
+	for _, topic := range gm.plugin.notifications {
+		m.Notifications = append(m.Notifications, NotificationTopic{topic})
+	}
+
 	m.Dynamic = gm.plugin.dynamic
 
 	if gm.plugin.features.AreSet() {
@@ -1181,6 +1416,47 @@ func (p *Plugin) Log(message string, level LogLevel) {
 	}
 }
 
+// A custom notification, sent to plugins subscribed to its topic
+type customNotification struct {
+	topic  string
+	params map[string]interface{}
+}
+
+func (n *customNotification) Name() string {
+	return n.topic
+}
+
+func (n *customNotification) NamedParams() map[string]interface{} {
+	return n.params
+}
+
+// Declare a custom notification {topic}, which the plugin can then
+// Notify and other plugins subscribe to. Like the Subscribe* methods,
+// this must be called before the plugin is started.
+func (p *Plugin) RegisterNotification(topic string) error {
+	if topic == "" {
+		return fmt.Errorf("Must provide a notification topic")
+	}
+	for _, t := range p.notifications {
+		if t == topic {
+			return fmt.Errorf("Notification topic `%s` already registered", topic)
+		}
+	}
+	p.notifications = append(p.notifications, topic)
+	return nil
+}
+
+// Send a custom notification on {topic}, which must have been
+// registered, with {params} as its payload
+func (p *Plugin) Notify(topic string, params map[string]interface{}) error {
+	for _, t := range p.notifications {
+		if t == topic {
+			return p.server.Notify(&customNotification{topic, params})
+		}
+	}
+	return fmt.Errorf("Notification topic `%s` not registered", topic)
+}
+
 // Map for registering hooks. Not the *most* elegant but
 //   it'll do for now.
 type Hooks struct {
@@ -1188,6 +1464,7 @@ type Hooks struct {
 	DbWrite           func(*DbWriteEvent) (*DbWriteResponse, error)
 	InvoicePayment    func(*InvoicePaymentEvent) (*InvoicePaymentResponse, error)
 	OpenChannel       func(*OpenChannelEvent) (*OpenChannelResponse, error)
+	OpenChannel2      func(*OpenChannel2Event) (*OpenChannel2Response, error)
 	HtlcAccepted      func(*HtlcAcceptedEvent) (*HtlcAcceptedResponse, error)
 	RpcCommand        func(*RpcCommandEvent) (*RpcCommandResponse, error)
 	CustomMsgReceived func(*CustomMsgReceivedEvent) (*CustomMsgReceivedResponse, error)
@@ -1230,6 +1507,15 @@ func (p *Plugin) RegisterHooks(hooks *Hooks) error {
 		}
 		p.hooks = append(p.hooks, _OpenChannel)
 	}
+	if hooks.OpenChannel2 != nil {
+		err := p.server.Register(&OpenChannel2Event{
+			hook: hooks.OpenChannel2,
+		})
+		if err != nil {
+			return err
+		}
+		p.hooks = append(p.hooks, _OpenChannel2)
+	}
 	if hooks.HtlcAccepted != nil {
 		err := p.server.Register(&HtlcAcceptedEvent{
 			hook: hooks.HtlcAccepted,
@@ -1266,6 +1552,7 @@ type Plugin struct {
 	methods       map[string]*RpcMethod
 	hooks         []Hook
 	subscriptions []string
+	notifications []string
 	initialized   bool
 	initFn        func(plugin *Plugin, options map[string]Option, c *Config)
 	Config        *Config
@@ -1559,6 +1846,25 @@ func (p *Plugin) SubscribeForwardings(cb func(c *Forwarding)) {
 	})
 }
 
+func (p *Plugin) SubscribeChannelStateChanged(cb func(c *ChannelStateChanged)) {
+	p.subscribe(&ChannelStateChangedEvent{
+		cb: cb,
+	})
+}
+
+func (p *Plugin) SubscribeBlockAdded(cb func(c *BlockAdded)) {
+	p.subscribe(&BlockAddedEvent{
+		cb: cb,
+	})
+}
+
+// Every line lightningd logs. Busy; see StreamLogs for one level up.
+func (p *Plugin) SubscribeLog(cb func(c *LogEntry)) {
+	p.subscribe(&LogEvent{
+		cb: cb,
+	})
+}
+
 func (p *Plugin) subscribe(subscription jrpc2.ServerMethod) {
 	p.server.Register(subscription)
 	p.subscriptions = append(p.subscriptions, subscription.Name())
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -118,6 +119,43 @@ func TestLogsGeneralInfra(t *testing.T) {
 	assert.Equal(t, "{\"jsonrpc\":\"2.0\",\"method\":\"log\",\"params\":{\"level\":\"info\",\"message\":\"this is a log line\"}}", string(bytesRead))
 }
 
+func TestCustomNotification(t *testing.T) {
+	plugin := glightning.NewPlugin(nullInitFunc)
+	assert.Nil(t, plugin.RegisterNotification("rebalanced"))
+	assert.EqualError(t, plugin.RegisterNotification("rebalanced"), "Notification topic `rebalanced` already registered")
+	assert.EqualError(t, plugin.RegisterNotification(""), "Must provide a notification topic")
+
+	progIn, _, _ := os.Pipe()
+	testIn, progOut, _ := os.Pipe()
+	startErr := make(chan error, 1)
+	go func(in, out *os.File) {
+		startErr <- plugin.Start(in, out)
+	}(progIn, progOut)
+
+	assert.EqualError(t, plugin.Notify("unregistered", nil), "Notification topic `unregistered` not registered")
+	go plugin.Notify("rebalanced", map[string]interface{}{"amount_msat": 100000, "scid": "103x1x0"})
+
+	reader := bufio.NewReader(testIn)
+	line, err := reader.ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","method":"rebalanced","params":{"amount_msat":100000,"scid":"103x1x0"}}`, strings.TrimSpace(line))
+	select {
+	case err := <-startErr:
+		t.Fatal(err)
+	default:
+	}
+}
+
+func TestManifestWithNotifications(t *testing.T) {
+	plugin := glightning.NewPlugin(nullInitFunc)
+	plugin.RegisterNotification("rebalanced")
+	plugin.RegisterNotification("rebalance_failed")
+
+	msg := "{\"jsonrpc\":\"2.0\",\"method\":\"getmanifest\",\"id\":\"aloha\"}\n\n"
+	resp := `{"jsonrpc":"2.0","result":{"options":[],"rpcmethods":[],"dynamic":true,"notifications":[{"method":"rebalanced"},{"method":"rebalance_failed"}],"featurebits":{}},"id":"aloha"}`
+	runTest(t, plugin, msg, resp)
+}
+
 // test the plugin's handling of init
 func TestInit(t *testing.T) {
 
@@ -0,0 +1,114 @@
+package glightning
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// The outcome of sending a probe along one route
+type ProbeResult struct {
+	Route []RouteHop
+	// The probe made it to the destination: every channel on the
+	// route had at least the probed amount available
+	Reachable bool
+	// How long the probe took to conclude
+	Latency time.Duration
+	// If not reachable, the channel which couldn't forward the
+	// probe ("scid/direction") and the reason given
+	ErringChannel string
+	FailCodeName  string
+	// Set when the probe failed for reasons other than routing,
+	// e.g. sendpay itself was refused
+	Err error
+}
+
+// Probe checks whether {amount} msat can be delivered to {destination}
+// without paying anything. Up to {routes} candidate routes are tried
+// (3 if zero), each with a payment hash nobody holds the preimage
+// for; reaching the destination shows up as an
+// incorrect_or_unknown_payment_details failure, which is reported as
+// Reachable. Each further route avoids the first hop of the previous
+// one, or the channel that failed it.
+func (p *Payer) Probe(destination string, amount uint64, routes int) ([]*ProbeResult, error) {
+	if destination == "" {
+		return nil, fmt.Errorf("Must provide a destination to probe")
+	}
+	if amount == 0 {
+		return nil, fmt.Errorf("Must provide an amount to probe with")
+	}
+	if routes == 0 {
+		routes = 3
+	}
+
+	var results []*ProbeResult
+	var exclude []string
+	for len(results) < routes {
+		route, err := p.client.GetRoute(destination, amount, p.RiskFactor, 0, "", 0, exclude, 0)
+		if err != nil {
+			if len(results) == 0 {
+				return nil, err
+			}
+			// no more candidate routes
+			break
+		}
+
+		result := p.probeRoute(route)
+		results = append(results, result)
+		if result.Err != nil {
+			break
+		}
+		if result.ErringChannel != "" {
+			exclude = append(exclude, result.ErringChannel)
+		} else {
+			exclude = append(exclude, fmt.Sprintf("%s/%d", route[0].ShortChannelId, route[0].Direction))
+		}
+	}
+	return results, nil
+}
+
+func (p *Payer) probeRoute(route []RouteHop) *ProbeResult {
+	result := &ProbeResult{Route: route}
+	paymentHash, err := randomHash()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	start := time.Now()
+	_, err = p.client.SendPay(route, paymentHash, "", nil, "", "", 0)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	_, err = p.client.WaitSendPay(paymentHash, 0)
+	result.Latency = time.Since(start)
+
+	payErr, ok := err.(*PaymentError)
+	if !ok || payErr.Data == nil {
+		if err == nil {
+			err = fmt.Errorf("Probe with payment hash %s unexpectedly succeeded", paymentHash)
+		}
+		result.Err = err
+		return result
+	}
+	data := payErr.Data
+	result.FailCodeName = data.FailCodeName
+	if data.FailCode == FailCodeIncorrectOrUnknownPaymentDetails && int(data.ErringIndex) == len(route) {
+		result.Reachable = true
+		return result
+	}
+	if data.ErringChannel != "" {
+		result.ErringChannel = fmt.Sprintf("%s/%d", data.ErringChannel, data.ErringDirection)
+	}
+	return result
+}
+
+func randomHash() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
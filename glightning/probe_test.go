@@ -0,0 +1,82 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbe(t *testing.T) {
+	ln := mock.New()
+	var excludes [][]string
+	ln.GetRouteFunc = func(peerId string, msats uint64, riskfactor float32, cltv uint, fromId string, fuzzpercent float32, exclude []string, maxHops int32) ([]glightning.RouteHop, error) {
+		excludes = append(excludes, exclude)
+		firstHops := []glightning.ShortChannelId{"103x1x0", "104x1x0"}
+		if len(exclude) >= len(firstHops) {
+			return nil, &jrpc2.RpcError{Code: 205, Message: "Could not find a route"}
+		}
+		return []glightning.RouteHop{
+			{Id: "03aa", ShortChannelId: firstHops[len(exclude)], MilliSatoshi: msats + 1, Direction: 1},
+			{Id: peerId, ShortChannelId: "200x1x1", MilliSatoshi: msats},
+		}, nil
+	}
+	var hashes []string
+	ln.SendPayFunc = func(route []glightning.RouteHop, paymentHash, label string, msat *uint64, bolt11 string, paymentSecret string, partId uint64) (*glightning.SendPayResult, error) {
+		assert.Len(t, paymentHash, 64)
+		hashes = append(hashes, paymentHash)
+		return &glightning.SendPayResult{}, nil
+	}
+	ln.WaitSendPayFunc = func(paymentHash string, timeout uint) (*glightning.SendPayFields, error) {
+		if len(hashes) == 1 {
+			return &glightning.SendPayFields{}, &glightning.PaymentError{
+				RpcError: &jrpc2.RpcError{Code: 204},
+				Data: &glightning.PaymentErrorData{
+					ErringIndex:     1,
+					ErringChannel:   "200x1x1",
+					ErringDirection: 0,
+					FailCode:        0x1007,
+					FailCodeName:    "WIRE_TEMPORARY_CHANNEL_FAILURE",
+				},
+			}
+		}
+		return &glightning.SendPayFields{}, &glightning.PaymentError{
+			RpcError: &jrpc2.RpcError{Code: 203},
+			Data: &glightning.PaymentErrorData{
+				ErringIndex:  2,
+				ErringNode:   payerDest,
+				FailCode:     glightning.FailCodeIncorrectOrUnknownPaymentDetails,
+				FailCodeName: "WIRE_INCORRECT_OR_UNKNOWN_PAYMENT_DETAILS",
+			},
+		}
+	}
+
+	results, err := glightning.NewPayer(ln).Probe(payerDest, 50000, 0)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.NotEqual(t, hashes[0], hashes[1])
+
+	assert.False(t, results[0].Reachable)
+	assert.Equal(t, "200x1x1/0", results[0].ErringChannel)
+	assert.NoError(t, results[0].Err)
+
+	assert.True(t, results[1].Reachable)
+	assert.Equal(t, glightning.ShortChannelId("104x1x0"), results[1].Route[0].ShortChannelId)
+	assert.Equal(t, "WIRE_INCORRECT_OR_UNKNOWN_PAYMENT_DETAILS", results[1].FailCodeName)
+
+	assert.Equal(t, []string{"200x1x1/0", "104x1x0/1"}, excludes[2])
+}
+
+func TestProbeNoRoute(t *testing.T) {
+	ln := mock.New()
+	ln.GetRouteFunc = func(peerId string, msats uint64, riskfactor float32, cltv uint, fromId string, fuzzpercent float32, exclude []string, maxHops int32) ([]glightning.RouteHop, error) {
+		return nil, &jrpc2.RpcError{Code: 205, Message: "Could not find a route"}
+	}
+	_, err := glightning.NewPayer(ln).Probe(payerDest, 50000, 1)
+	assert.Error(t, err)
+
+	_, err = glightning.NewPayer(ln).Probe(payerDest, 0, 1)
+	assert.EqualError(t, err, "Must provide an amount to probe with")
+}
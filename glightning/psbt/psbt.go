@@ -0,0 +1,167 @@
+// Package psbt converts the base64 PSBT strings passed to and returned
+// by lightningd (fundpsbt, utxopsbt, signpsbt, sendpsbt, ...) to and
+// from btcutil/psbt packets, and summarises their inputs and outputs,
+// so external-wallet signing flows don't have to pick blobs apart by
+// hand:
+//
+//	funded, _ := ln.FundPsbt(glightning.NewSat(100000), nil, 1000, nil)
+//	packet, _ := psbt.Decode(funded.Psbt)
+//	... add outputs, have the external wallet sign its inputs ...
+//	signed, _ := psbt.Encode(packet)
+//	signed, _ = ln.SignPsbt(signed, nil)
+//	ln.SendPsbt(signed)
+//
+// btcutil/psbt only handles version 0 PSBTs; convert others with
+// Lightning.SetPsbtVersion first.
+package psbt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	btcpsbt "github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Parse a base64 encoded psbt, as lightningd returns them
+func Decode(psbt string) (*btcpsbt.Packet, error) {
+	if psbt == "" {
+		return nil, fmt.Errorf("Must provide a psbt")
+	}
+	packet, err := btcpsbt.NewFromRawBytes(strings.NewReader(psbt), true)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode psbt: %s", err)
+	}
+	return packet, nil
+}
+
+// Base64 encode {packet}, to pass to lightningd
+func Encode(packet *btcpsbt.Packet) (string, error) {
+	return packet.B64Encode()
+}
+
+type Input struct {
+	// The output being spent
+	TxId string
+	Vout uint32
+
+	Sequence uint32
+	// Whether the psbt has the output being spent. If not, Satoshis,
+	// Script and Address aren't known.
+	HasUtxo  bool
+	Satoshis uint64
+	Script   []byte
+	// Empty if the script isn't a standard one
+	Address string
+	// The number of partial signatures
+	Signatures int
+	// Whether the input has its final scriptSig or witness
+	Finalized bool
+}
+
+// The "txid:vout" of the output being spent, as listfunds and
+// reserveinputs name them
+func (i *Input) Outpoint() string {
+	return fmt.Sprintf("%s:%d", i.TxId, i.Vout)
+}
+
+type Output struct {
+	Satoshis uint64
+	Script   []byte
+	// Empty if the script isn't a standard one
+	Address string
+}
+
+// Summarise each of {packet}'s inputs. {params} decides how addresses
+// are encoded, e.g. &chaincfg.MainNetParams.
+func Inputs(packet *btcpsbt.Packet, params *chaincfg.Params) []*Input {
+	inputs := make([]*Input, len(packet.UnsignedTx.TxIn))
+	for i, txIn := range packet.UnsignedTx.TxIn {
+		input := &Input{
+			TxId:     txIn.PreviousOutPoint.Hash.String(),
+			Vout:     txIn.PreviousOutPoint.Index,
+			Sequence: txIn.Sequence,
+		}
+		if i < len(packet.Inputs) {
+			pInput := &packet.Inputs[i]
+			if utxo := spentOutput(pInput, txIn); utxo != nil {
+				input.HasUtxo = true
+				input.Satoshis = uint64(utxo.Value)
+				input.Script = utxo.PkScript
+				input.Address = address(utxo.PkScript, params)
+			}
+			input.Signatures = len(pInput.PartialSigs)
+			if pInput.TaprootKeySpendSig != nil {
+				input.Signatures++
+			}
+			input.Finalized = pInput.FinalScriptSig != nil || pInput.FinalScriptWitness != nil
+		}
+		inputs[i] = input
+	}
+	return inputs
+}
+
+func spentOutput(pInput *btcpsbt.PInput, txIn *wire.TxIn) *wire.TxOut {
+	if pInput.WitnessUtxo != nil {
+		return pInput.WitnessUtxo
+	}
+	if pInput.NonWitnessUtxo != nil {
+		outs := pInput.NonWitnessUtxo.TxOut
+		if int(txIn.PreviousOutPoint.Index) < len(outs) {
+			return outs[txIn.PreviousOutPoint.Index]
+		}
+	}
+	return nil
+}
+
+// Summarise each of {packet}'s outputs
+func Outputs(packet *btcpsbt.Packet, params *chaincfg.Params) []*Output {
+	outputs := make([]*Output, len(packet.UnsignedTx.TxOut))
+	for i, txOut := range packet.UnsignedTx.TxOut {
+		outputs[i] = &Output{
+			Satoshis: uint64(txOut.Value),
+			Script:   txOut.PkScript,
+			Address:  address(txOut.PkScript, params),
+		}
+	}
+	return outputs
+}
+
+func address(script []byte, params *chaincfg.Params) string {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(script, params)
+	if err != nil || len(addrs) != 1 {
+		return ""
+	}
+	return addrs[0].EncodeAddress()
+}
+
+// The fee {packet} pays, in satoshis. Fails if any input's utxo is
+// missing.
+func Fee(packet *btcpsbt.Packet) (uint64, error) {
+	fee, err := packet.GetTxFee()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(fee), nil
+}
+
+// Finalize every input of the fully signed {packet} and extract the
+// transaction, hex encoded as sendrawtransaction takes it
+func Extract(packet *btcpsbt.Packet) (string, error) {
+	if err := btcpsbt.MaybeFinalizeAll(packet); err != nil {
+		return "", err
+	}
+	tx, err := btcpsbt.Extract(packet)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
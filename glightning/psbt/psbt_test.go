@@ -0,0 +1,109 @@
+package psbt_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	btcpsbt "github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/elementsproject/glightning/glightning/psbt"
+	"github.com/stretchr/testify/assert"
+)
+
+// p2wpkh scripts for the keyhashes 0x01.. and 0x02..
+var (
+	inScript  = append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0x01}, 20)...)
+	outScript = append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0x02}, 20)...)
+)
+
+const prevTxId = "8e2a1b8a3e6d4b1f6e9c0f1f2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e"
+
+func testPacket(t *testing.T) *btcpsbt.Packet {
+	hash, err := chainhash.NewHashFromStr(prevTxId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet, err := btcpsbt.New(
+		[]*wire.OutPoint{wire.NewOutPoint(hash, 1)},
+		[]*wire.TxOut{wire.NewTxOut(90000, outScript)},
+		2, 0, []uint32{0xfffffffd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet.Inputs[0].WitnessUtxo = wire.NewTxOut(100000, inScript)
+	return packet
+}
+
+func TestRoundTrip(t *testing.T) {
+	encoded, err := psbt.Encode(testPacket(t))
+	assert.NoError(t, err)
+
+	packet, err := psbt.Decode(encoded)
+	assert.NoError(t, err)
+	reencoded, err := psbt.Encode(packet)
+	assert.NoError(t, err)
+	assert.Equal(t, encoded, reencoded)
+
+	_, err = psbt.Decode("")
+	assert.Error(t, err)
+	_, err = psbt.Decode("bm90IGEgcHNidA==")
+	assert.Error(t, err)
+}
+
+func TestInspect(t *testing.T) {
+	packet := testPacket(t)
+
+	inputs := psbt.Inputs(packet, &chaincfg.MainNetParams)
+	assert.Len(t, inputs, 1)
+	assert.Equal(t, &psbt.Input{
+		TxId:     prevTxId,
+		Vout:     1,
+		Sequence: 0xfffffffd,
+		HasUtxo:  true,
+		Satoshis: 100000,
+		Script:   inScript,
+		Address:  "bc1qqyqszqgpqyqszqgpqyqszqgpqyqszqgpyfl4f3",
+	}, inputs[0])
+	assert.Equal(t, prevTxId+":1", inputs[0].Outpoint())
+
+	outputs := psbt.Outputs(packet, &chaincfg.RegressionNetParams)
+	assert.Equal(t, []*psbt.Output{{
+		Satoshis: 90000,
+		Script:   outScript,
+		Address:  "bcrt1qqgpqyqszqgpqyqszqgpqyqszqgpqyqszazmwwa",
+	}}, outputs)
+
+	fee, err := psbt.Fee(packet)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10000), fee)
+
+	// without the utxo, the amount (and so the fee) isn't known
+	packet.Inputs[0].WitnessUtxo = nil
+	assert.False(t, psbt.Inputs(packet, &chaincfg.MainNetParams)[0].HasUtxo)
+	_, err = psbt.Fee(packet)
+	assert.Error(t, err)
+}
+
+func TestExtract(t *testing.T) {
+	packet := testPacket(t)
+
+	// not signed
+	_, err := psbt.Extract(packet)
+	assert.Error(t, err)
+
+	witness := bytes.NewBuffer(nil)
+	assert.NoError(t, btcpsbt.WriteTxWitness(witness, [][]byte{{0x30}, {0x02}}))
+	packet.Inputs[0].FinalScriptWitness = witness.Bytes()
+	assert.True(t, psbt.Inputs(packet, &chaincfg.MainNetParams)[0].Finalized)
+
+	tx, err := psbt.Extract(packet)
+	assert.NoError(t, err)
+	raw, err := hex.DecodeString(tx)
+	assert.NoError(t, err)
+	var msgTx wire.MsgTx
+	assert.NoError(t, msgTx.Deserialize(bytes.NewReader(raw)))
+	assert.Equal(t, wire.TxWitness{{0x30}, {0x02}}, msgTx.TxIn[0].Witness)
+}
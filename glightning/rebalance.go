@@ -0,0 +1,146 @@
+package glightning
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type RebalanceResult struct {
+	// The circular route taken, starting and ending at this node
+	Route           []RouteHop
+	PaymentHash     string
+	PaymentPreimage string
+	// Amount moved from the outgoing to the incoming channel, in msat
+	AmountMsat uint64
+	FeeMsat    uint64
+}
+
+// Move {amount} msat of outbound liquidity from channel {outScid} to
+// channel {inScid} by paying ourselves along a circular route: out
+// through {outScid}, across the network, and back in through {inScid}.
+// Fails without sending anything if the route would cost more than
+// {maxFeeMsat} (0 for no limit).
+func (p *Payer) Rebalance(outScid, inScid string, amount, maxFeeMsat uint64) (*RebalanceResult, error) {
+	if outScid == "" || inScid == "" {
+		return nil, fmt.Errorf("Must provide both an outgoing and an incoming channel")
+	}
+	if outScid == inScid {
+		return nil, fmt.Errorf("Must rebalance between two different channels")
+	}
+	if amount == 0 {
+		return nil, fmt.Errorf("Must provide an amount to rebalance")
+	}
+
+	info, err := p.client.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	route, err := p.circularRoute(info.Id, outScid, inScid, amount)
+	if err != nil {
+		return nil, err
+	}
+	fee := route[0].MilliSatoshi - amount
+	if maxFeeMsat != 0 && fee > maxFeeMsat {
+		return nil, fmt.Errorf("Rebalance fee of %dmsat exceeds the limit of %dmsat", fee, maxFeeMsat)
+	}
+
+	label := "rebalance-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	invoice, err := p.client.CreateInvoice(amount, label, fmt.Sprintf("Rebalance %s to %s", outScid, inScid), 3600, nil, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.client.SendPay(route, invoice.PaymentHash, label, &amount, invoice.Bolt11, invoice.PaymentSecret, 0)
+	if err != nil {
+		return nil, err
+	}
+	paid, err := p.client.WaitSendPay(invoice.PaymentHash, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RebalanceResult{
+		Route:           route,
+		PaymentHash:     invoice.PaymentHash,
+		PaymentPreimage: paid.PaymentPreimage,
+		AmountMsat:      amount,
+		FeeMsat:         fee,
+	}, nil
+}
+
+// Build a route from {self} out through {outScid} and back in via
+// {inScid}, delivering {amount} msat. Amounts and delays are
+// worked out backwards from the final hop, as for any route.
+func (p *Payer) circularRoute(self, outScid, inScid string, amount uint64) ([]RouteHop, error) {
+	out, err := channelHalf(p.client, outScid, self, "")
+	if err != nil {
+		return nil, err
+	}
+	in, err := channelHalf(p.client, inScid, "", self)
+	if err != nil {
+		return nil, err
+	}
+
+	// the final hop, back to us; paid for by the peer on {inScid}
+	const finalCltv = 9
+	last := RouteHop{
+		Id:             self,
+		ShortChannelId: in.ShortChannelId,
+		MilliSatoshi:   amount,
+		Delay:          finalCltv,
+		Direction:      uint8(in.ChannelFlags & 1),
+	}
+	toIn := amount + channelFee(in, amount)
+	toInDelay := finalCltv + in.Delay
+
+	// from the {outScid} peer across to the {inScid} peer
+	var middle []RouteHop
+	if out.Destination != in.Source {
+		exclude := []string{
+			outScid + "/0", outScid + "/1",
+			inScid + "/0", inScid + "/1",
+		}
+		middle, err = p.client.GetRoute(in.Source, toIn, p.RiskFactor, toInDelay, out.Destination, 0, exclude, 0)
+		if err != nil {
+			return nil, err
+		}
+		first, err := channelHalf(p.client, string(middle[0].ShortChannelId), out.Destination, "")
+		if err != nil {
+			return nil, err
+		}
+		toIn = middle[0].MilliSatoshi + channelFee(first, middle[0].MilliSatoshi)
+		toInDelay = middle[0].Delay + first.Delay
+	}
+
+	route := make([]RouteHop, 0, len(middle)+2)
+	route = append(route, RouteHop{
+		Id:             out.Destination,
+		ShortChannelId: out.ShortChannelId,
+		MilliSatoshi:   toIn,
+		Delay:          toInDelay,
+		Direction:      uint8(out.ChannelFlags & 1),
+	})
+	route = append(route, middle...)
+	return append(route, last), nil
+}
+
+// Find the half of channel {scid} going from {source} or to
+// {destination}, whichever is given
+func channelHalf(client LightningClient, scid, source, destination string) (*Channel, error) {
+	halves, err := client.GetChannel(scid)
+	if err != nil {
+		return nil, err
+	}
+	for _, half := range halves {
+		if (source != "" && half.Source == source) || (destination != "" && half.Destination == destination) {
+			return half, nil
+		}
+	}
+	return nil, fmt.Errorf("Channel %s has no direction usable for rebalancing", scid)
+}
+
+// Fee charged to forward {amount} msat over a channel
+func channelFee(c *Channel, amount uint64) uint64 {
+	return c.BaseFeeMillisatoshi + amount*c.FeePerMillionth/1000000
+}
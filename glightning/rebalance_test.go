@@ -0,0 +1,92 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	rebalSelf = "02aaaa"
+	rebalOut  = "03bbbb"
+	rebalIn   = "03cccc"
+)
+
+func rebalanceMock(t *testing.T) *mock.Lightning {
+	ln := mock.New()
+	ln.GetInfoFunc = func() (*glightning.NodeInfo, error) {
+		return &glightning.NodeInfo{Id: rebalSelf}, nil
+	}
+	channels := map[string][]*glightning.Channel{
+		"103x1x0": {
+			{Source: rebalSelf, Destination: rebalOut, ShortChannelId: "103x1x0", ChannelFlags: 0, BaseFeeMillisatoshi: 1000, FeePerMillionth: 10, Delay: 6},
+			{Source: rebalOut, Destination: rebalSelf, ShortChannelId: "103x1x0", ChannelFlags: 1, BaseFeeMillisatoshi: 1000, FeePerMillionth: 10, Delay: 6},
+		},
+		"104x1x0": {
+			{Source: rebalOut, Destination: rebalIn, ShortChannelId: "104x1x0", ChannelFlags: 0, BaseFeeMillisatoshi: 1000, FeePerMillionth: 100, Delay: 14},
+		},
+		"105x1x0": {
+			{Source: rebalIn, Destination: rebalSelf, ShortChannelId: "105x1x0", ChannelFlags: 1, BaseFeeMillisatoshi: 500, FeePerMillionth: 1000, Delay: 40},
+		},
+	}
+	ln.GetChannelFunc = func(shortChanId string) ([]*glightning.Channel, error) {
+		return channels[shortChanId], nil
+	}
+	ln.GetRouteFunc = func(peerId string, msats uint64, riskfactor float32, cltv uint, fromId string, fuzzpercent float32, exclude []string, maxHops int32) ([]glightning.RouteHop, error) {
+		assert.Equal(t, rebalIn, peerId)
+		assert.Equal(t, rebalOut, fromId)
+		assert.Equal(t, uint(49), cltv)
+		assert.Contains(t, exclude, "103x1x0/1")
+		assert.Contains(t, exclude, "105x1x0/0")
+		return []glightning.RouteHop{
+			{Id: rebalIn, ShortChannelId: "104x1x0", MilliSatoshi: msats, Delay: cltv},
+		}, nil
+	}
+	return ln
+}
+
+func TestRebalance(t *testing.T) {
+	ln := rebalanceMock(t)
+	ln.CreateInvoiceFunc = func(msat uint64, label, description string, expirySeconds uint32, fallbacks []string, preimage string, willExposePrivateChans bool) (*glightning.Invoice, error) {
+		assert.Equal(t, uint64(1000000), msat)
+		return &glightning.Invoice{Label: label, PaymentHash: "aa", PaymentSecret: "cc", Bolt11: "lnbcrt10u1"}, nil
+	}
+	var sent []glightning.RouteHop
+	ln.SendPayFunc = func(route []glightning.RouteHop, paymentHash, label string, msat *uint64, bolt11 string, paymentSecret string, partId uint64) (*glightning.SendPayResult, error) {
+		sent = route
+		assert.Equal(t, "aa", paymentHash)
+		assert.Equal(t, "cc", paymentSecret)
+		assert.Equal(t, uint64(1000000), *msat)
+		return &glightning.SendPayResult{}, nil
+	}
+	ln.WaitSendPayFunc = func(paymentHash string, timeout uint) (*glightning.SendPayFields, error) {
+		return &glightning.SendPayFields{PaymentPreimage: "bb", Status: "complete"}, nil
+	}
+
+	result, err := glightning.NewPayer(ln).Rebalance("103x1x0", "105x1x0", 1000000, 5000)
+	assert.NoError(t, err)
+
+	// 105x1x0 charges 500 + 1000ppm, 104x1x0 1000 + 100ppm
+	expected := []glightning.RouteHop{
+		{Id: rebalOut, ShortChannelId: "103x1x0", MilliSatoshi: 1002600, Delay: 63, Direction: 0},
+		{Id: rebalIn, ShortChannelId: "104x1x0", MilliSatoshi: 1001500, Delay: 49},
+		{Id: rebalSelf, ShortChannelId: "105x1x0", MilliSatoshi: 1000000, Delay: 9, Direction: 1},
+	}
+	assert.Equal(t, expected, sent)
+	assert.Equal(t, expected, result.Route)
+	assert.Equal(t, uint64(2600), result.FeeMsat)
+	assert.Equal(t, "bb", result.PaymentPreimage)
+}
+
+func TestRebalanceFeeLimit(t *testing.T) {
+	ln := rebalanceMock(t)
+	_, err := glightning.NewPayer(ln).Rebalance("103x1x0", "105x1x0", 1000000, 2000)
+	assert.EqualError(t, err, "Rebalance fee of 2600msat exceeds the limit of 2000msat")
+	assert.Equal(t, 0, ln.CallCount("CreateInvoice"))
+	assert.Equal(t, 0, ln.CallCount("SendPay"))
+
+	_, err = glightning.NewPayer(ln).Rebalance("103x1x0", "103x1x0", 1000000, 0)
+	assert.EqualError(t, err, "Must rebalance between two different channels")
+}
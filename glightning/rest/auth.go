@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/elementsproject/glightning/glightning"
+)
+
+// Authenticator decides whether a request may call lightningd's
+// {method} with {params}. A nil Authenticator allows everything; only
+// use that behind something else which authenticates.
+type Authenticator interface {
+	Authorize(r *http.Request, method string, params map[string]interface{}) error
+}
+
+// RuneAuth checks the rune in each request's "Rune" header with
+// lightningd (checkrune), so frontends get exactly the access their
+// rune grants, just as over commando.
+type RuneAuth struct {
+	client glightning.LightningClient
+}
+
+func NewRuneAuth(client glightning.LightningClient) *RuneAuth {
+	return &RuneAuth{client: client}
+}
+
+func (a *RuneAuth) Authorize(r *http.Request, method string, params map[string]interface{}) error {
+	runeHeader := r.Header.Get("Rune")
+	if runeHeader == "" {
+		return fmt.Errorf("Missing Rune header")
+	}
+	valid, err := glightning.ClientWithContext(r.Context(), a.client).CheckRune(runeHeader, "", method, params)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("Rune does not allow %s", method)
+	}
+	return nil
+}
+
+// TokenAuth allows the bearer of each token (in an "Authorization:
+// Bearer <token>" header) to call the methods listed for it, or any
+// method if "*" is listed.
+type TokenAuth struct {
+	tokens map[string]map[string]bool
+}
+
+func NewTokenAuth() *TokenAuth {
+	return &TokenAuth{tokens: make(map[string]map[string]bool)}
+}
+
+// Allow {token} to call {methods}, as well as any allowed already
+func (a *TokenAuth) Allow(token string, methods ...string) {
+	allowed, ok := a.tokens[token]
+	if !ok {
+		allowed = make(map[string]bool)
+		a.tokens[token] = allowed
+	}
+	for _, method := range methods {
+		allowed[method] = true
+	}
+}
+
+func (a *TokenAuth) Authorize(r *http.Request, method string, params map[string]interface{}) error {
+	token := []byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	// compared against every token, so the time taken doesn't tell
+	// how much of one was guessed
+	var allowed map[string]bool
+	for known, methods := range a.tokens {
+		if subtle.ConstantTimeCompare(token, []byte(known)) == 1 {
+			allowed = methods
+		}
+	}
+	if len(token) == 0 || allowed == nil {
+		return fmt.Errorf("Missing or unknown bearer token")
+	}
+	if !allowed[method] && !allowed["*"] {
+		return fmt.Errorf("Token does not allow %s", method)
+	}
+	return nil
+}
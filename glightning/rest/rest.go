@@ -0,0 +1,203 @@
+// Package rest is an HTTP/JSON gateway to lightningd, so web frontends
+// can use a node without speaking JSON-RPC over its socket. Each
+// endpoint sends one of glightning's typed requests:
+//
+//	GET  /v1/getinfo
+//	GET  /v1/peers
+//	GET  /v1/channels
+//	GET  /v1/nodes             GET /v1/nodes/{id}
+//	GET  /v1/funds
+//	GET  /v1/forwards
+//	GET  /v1/invoices          GET /v1/invoices/{label}
+//	POST /v1/invoices          {"amount_msat", "label", "description", "expiry"}
+//	GET  /v1/decode/{bolt11}
+//	POST /v1/pay               {"bolt11"}
+//	GET  /v1/pays
+//	POST /v1/newaddr
+//	POST /v1/withdraw          {"destination", "satoshi", "feerate"}
+//
+// Calls to lightningd are scoped to their HTTP request, so wait for
+// as long as it does; a client giving up abandons the call. Results
+// are returned as lightningd returns them. Errors are returned as
+// {"error": {"code": ..., "message": ...}}, with the JSON-RPC error
+// code if lightningd refused the call.
+//
+// Every request is authorised by an Authenticator, against the
+// lightningd request the endpoint sends, method and params exactly as
+// they go to lightningd: with RuneAuth, a rune restricted to
+// "method=listinvoices" only gets GET /v1/invoices, and one restricted
+// to "pnamelabel=order-7" only GET /v1/invoices/order-7.
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/jrpc2"
+)
+
+// Builds the request an endpoint sends to lightningd. {arg} is the
+// last path segment for endpoints which take one; {params} are the
+// decoded body.
+type RequestFunc func(arg string, params map[string]interface{}) (jrpc2.Method, error)
+
+type route struct {
+	httpMethod string
+	path       string
+	// takes the path segment after {path}
+	hasArg  bool
+	request RequestFunc
+}
+
+type Gateway struct {
+	client glightning.LightningClient
+	auth   Authenticator
+	routes []*route
+}
+
+// A gateway calling {client}, authorising requests with {auth}
+func New(client glightning.LightningClient, auth Authenticator) *Gateway {
+	g := &Gateway{client: client, auth: auth}
+	g.registerRoutes()
+	return g
+}
+
+// Add an endpoint, at {path} (with a trailing "/{}" if it takes an
+// argument), which sends lightningd the request {request} builds
+func (g *Gateway) Handle(httpMethod, path string, request RequestFunc) {
+	r := &route{httpMethod: httpMethod, request: request}
+	if strings.HasSuffix(path, "/{}") {
+		r.hasArg = true
+		path = strings.TrimSuffix(path, "{}")
+	}
+	r.path = path
+	g.routes = append(g.routes, r)
+}
+
+// Find the route for {path}, returning its argument, if any
+func (g *Gateway) match(path string) ([]*route, string) {
+	var matches []*route
+	var arg string
+	for _, r := range g.routes {
+		if !r.hasArg && path == r.path {
+			matches = append(matches, r)
+		} else if r.hasArg && strings.HasPrefix(path, r.path) && len(path) > len(r.path) && !strings.Contains(path[len(r.path):], "/") {
+			matches = append(matches, r)
+			arg = path[len(r.path):]
+		}
+	}
+	return matches, arg
+}
+
+type errorBody struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	var body errorBody
+	body.Error.Code = status
+	body.Error.Message = err.Error()
+	var rpcErr *jrpc2.RpcError
+	if errors.As(err, &rpcErr) {
+		body.Error.Code = rpcErr.Code
+		body.Error.Message = rpcErr.Message
+	}
+	writeJSON(w, status, &body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	routes, arg := g.match(req.URL.Path)
+	if len(routes) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("No such endpoint %s", req.URL.Path))
+		return
+	}
+	var r *route
+	for _, candidate := range routes {
+		if candidate.httpMethod == req.Method {
+			r = candidate
+		}
+	}
+	if r == nil {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s not allowed on %s", req.Method, req.URL.Path))
+		return
+	}
+
+	params := make(map[string]interface{})
+	if req.Method == http.MethodPost && req.Body != nil {
+		err := json.NewDecoder(req.Body).Decode(&params)
+		if err != nil && err != io.EOF {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid JSON body: %s", err))
+			return
+		}
+	}
+
+	request, err := r.request(arg, params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// authorised against just what's sent
+	if g.auth != nil {
+		if err := g.auth.Authorize(req, request.Name(), jrpc2.GetNamedParams(request)); err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+	}
+
+	// lightningd's calls last as long as the HTTP request, not the
+	// client's timeout: a slow pay mustn't fail here while it carries
+	// on in lightningd, inviting a retry
+	var result json.RawMessage
+	if err := glightning.ClientWithContext(req.Context(), g.client).Request(request, &result); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(result)
+}
+
+func stringParam(params map[string]interface{}, name string, required bool) (string, error) {
+	v, ok := params[name]
+	if !ok {
+		if required {
+			return "", fmt.Errorf("Missing %s", name)
+		}
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", name)
+	}
+	return s, nil
+}
+
+func uintParam(params map[string]interface{}, name string, required bool) (uint64, error) {
+	v, ok := params[name]
+	if !ok {
+		if required {
+			return 0, fmt.Errorf("Missing %s", name)
+		}
+		return 0, nil
+	}
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != float64(uint64(f)) {
+		return 0, fmt.Errorf("%s must be a whole number", name)
+	}
+	return uint64(f), nil
+}
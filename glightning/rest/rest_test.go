@@ -0,0 +1,174 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/elementsproject/glightning/glightning/rest"
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+func do(g http.Handler, method, path, body string, headers map[string]string) (int, map[string]interface{}) {
+	var req *http.Request
+	if body != "" {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, req)
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	return rec.Code, result
+}
+
+// Answers each lightningd method with its raw result in {results},
+// recording the requests sent
+func answer(sent *[]jrpc2.Method, results map[string]string) func(jrpc2.Method, interface{}) error {
+	return func(m jrpc2.Method, resp interface{}) error {
+		*sent = append(*sent, m)
+		result, ok := results[m.Name()]
+		if !ok {
+			return &jrpc2.RpcError{Code: 205, Message: "Could not find a route"}
+		}
+		return json.Unmarshal([]byte(result), resp)
+	}
+}
+
+func TestGateway(t *testing.T) {
+	var sent []jrpc2.Method
+	ln := mock.New()
+	ln.RequestFunc = answer(&sent, map[string]string{
+		"getinfo":      `{"id": "02aa", "alias": "gateway"}`,
+		"listinvoices": `{"invoices": [{"label": "order-7", "status": "paid"}]}`,
+		"invoice":      `{"bolt11": "lnbcrt50n1"}`,
+		"withdraw":     `{"txid": "bb"}`,
+	})
+	g := rest.New(ln, nil)
+
+	code, body := do(g, "GET", "/v1/getinfo", "", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "gateway", body["alias"])
+
+	code, body = do(g, "GET", "/v1/invoices/order-7", "", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "order-7", body["invoices"].([]interface{})[0].(map[string]interface{})["label"])
+	assert.Equal(t, &glightning.ListInvoiceRequest{Label: "order-7"}, sent[1])
+
+	code, body = do(g, "POST", "/v1/invoices", `{"amount_msat": 5000, "label": "order-7", "description": "coffee"}`, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "lnbcrt50n1", body["bolt11"])
+	assert.Equal(t, &glightning.InvoiceRequest{MilliSatoshis: "5000", Label: "order-7", Description: "coffee"}, sent[2])
+
+	code, body = do(g, "POST", "/v1/withdraw", `{"destination": "bcrt1q", "satoshi": "all", "feerate": "slow"}`, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "bb", body["txid"])
+	assert.Equal(t, &glightning.WithdrawRequest{Destination: "bcrt1q", Satoshi: "all", FeeRate: "slow"}, sent[3])
+
+	// lightningd's errors keep their code
+	code, body = do(g, "POST", "/v1/pay", `{"bolt11": "lnbcrt1"}`, nil)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, map[string]interface{}{"code": 205.0, "message": "Could not find a route"}, body["error"])
+
+	code, _ = do(g, "POST", "/v1/invoices", `{"amount_msat": "lots", "label": "x", "description": "y"}`, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(g, "POST", "/v1/withdraw", `{"destination": "bcrt1q", "satoshi": "0"}`, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(g, "POST", "/v1/pay", `{`, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(g, "POST", "/v1/getinfo", "", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+	code, _ = do(g, "GET", "/v1/nope", "", nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = do(g, "GET", "/v1/invoices/a/b", "", nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Len(t, sent, 5)
+}
+
+func TestTokenAuth(t *testing.T) {
+	var sent []jrpc2.Method
+	ln := mock.New()
+	ln.RequestFunc = answer(&sent, map[string]string{
+		"getinfo":      `{}`,
+		"listinvoices": `{"invoices": []}`,
+	})
+	auth := rest.NewTokenAuth()
+	auth.Allow("readonly", "getinfo")
+	auth.Allow("admin", "*")
+	g := rest.New(ln, auth)
+
+	code, _ := do(g, "GET", "/v1/getinfo", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = do(g, "GET", "/v1/getinfo", "", map[string]string{"Authorization": "Bearer readonly"})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = do(g, "GET", "/v1/invoices", "", map[string]string{"Authorization": "Bearer readonly"})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = do(g, "GET", "/v1/invoices", "", map[string]string{"Authorization": "Bearer admin"})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = do(g, "GET", "/v1/invoices", "", map[string]string{"Authorization": "Bearer readonl"})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = do(g, "GET", "/v1/invoices", "", map[string]string{"Authorization": "Bearer nobody"})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Len(t, sent, 2)
+}
+
+func TestRuneAuth(t *testing.T) {
+	var sent []jrpc2.Method
+	ln := mock.New()
+	ln.CheckRuneFunc = func(rune, nodeId, method string, params map[string]interface{}) (bool, error) {
+		// checked against the params lightningd gets
+		if rune == "order-7-only" && method == "listinvoices" && params["label"] == "order-7" {
+			return true, nil
+		}
+		return false, errors.New("Not authorized: pnamelabel is not equal to order-7")
+	}
+	ln.RequestFunc = answer(&sent, map[string]string{
+		"listinvoices": `{"invoices": [{"label": "order-7"}]}`,
+	})
+	g := rest.New(ln, rest.NewRuneAuth(ln))
+
+	code, _ := do(g, "GET", "/v1/invoices/order-7", "", map[string]string{"Rune": "order-7-only"})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = do(g, "GET", "/v1/invoices", "", map[string]string{"Rune": "order-7-only"})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, body := do(g, "GET", "/v1/getinfo", "", map[string]string{"Rune": "order-7-only"})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "Not authorized: pnamelabel is not equal to order-7", body["error"].(map[string]interface{})["message"])
+	code, _ = do(g, "GET", "/v1/getinfo", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, []jrpc2.Method{&glightning.ListInvoiceRequest{Label: "order-7"}}, sent)
+}
+
+func TestSlowPay(t *testing.T) {
+	lightning := glightning.NewLightning()
+	lightning.SetTimeout(1)
+	conn, node := net.Pipe()
+	lightning.StartConn(conn)
+	t.Cleanup(lightning.Shutdown)
+	go func() {
+		var req struct {
+			Id json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(node).Decode(&req)
+		// the payment takes longer than the client's timeout
+		time.Sleep(1500 * time.Millisecond)
+		fmt.Fprintf(node, `{"jsonrpc":"2.0","id":%s,"result":{"status":"complete"}}`, req.Id)
+	}()
+
+	code, body := do(rest.New(lightning, nil), "POST", "/v1/pay", `{"bolt11": "lnbcrt1"}`, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "complete", body["status"])
+}
@@ -0,0 +1,118 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/jrpc2"
+)
+
+func (g *Gateway) registerRoutes() {
+	g.Handle(http.MethodGet, "/v1/getinfo", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.GetInfoRequest{}, nil
+	})
+	g.Handle(http.MethodGet, "/v1/peers", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.ListPeersRequest{}, nil
+	})
+	g.Handle(http.MethodGet, "/v1/channels", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.ListChannelRequest{}, nil
+	})
+	g.Handle(http.MethodGet, "/v1/nodes", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.ListNodeRequest{}, nil
+	})
+	g.Handle(http.MethodGet, "/v1/nodes/{}", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.ListNodeRequest{NodeId: arg}, nil
+	})
+	g.Handle(http.MethodGet, "/v1/funds", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.ListFundsRequest{}, nil
+	})
+	g.Handle(http.MethodGet, "/v1/forwards", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.ListForwardsRequest{}, nil
+	})
+	g.Handle(http.MethodGet, "/v1/invoices", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.ListInvoiceRequest{}, nil
+	})
+	g.Handle(http.MethodGet, "/v1/invoices/{}", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.ListInvoiceRequest{Label: arg}, nil
+	})
+	g.Handle(http.MethodPost, "/v1/invoices", createInvoice)
+	g.Handle(http.MethodGet, "/v1/decode/{}", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.DecodePayRequest{Bolt11: arg}, nil
+	})
+	g.Handle(http.MethodPost, "/v1/pay", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		bolt11, err := stringParam(params, "bolt11", true)
+		if err != nil {
+			return nil, err
+		}
+		return &glightning.PayRequest{Bolt11: bolt11}, nil
+	})
+	g.Handle(http.MethodGet, "/v1/pays", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.ListPaysRequest{}, nil
+	})
+	g.Handle(http.MethodPost, "/v1/newaddr", func(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+		return &glightning.NewAddrRequest{AddressType: glightning.Bech32.String()}, nil
+	})
+	g.Handle(http.MethodPost, "/v1/withdraw", withdraw)
+}
+
+func createInvoice(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+	msat, err := uintParam(params, "amount_msat", true)
+	if err != nil {
+		return nil, err
+	}
+	if msat == 0 {
+		return nil, errors.New("amount_msat must be more than zero")
+	}
+	label, err := stringParam(params, "label", true)
+	if err != nil {
+		return nil, err
+	}
+	description, err := stringParam(params, "description", true)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := uintParam(params, "expiry", false)
+	if err != nil {
+		return nil, err
+	}
+	return &glightning.InvoiceRequest{
+		MilliSatoshis: fmt.Sprint(msat),
+		Label:         label,
+		Description:   description,
+		ExpirySeconds: uint32(expiry),
+	}, nil
+}
+
+func withdraw(arg string, params map[string]interface{}) (jrpc2.Method, error) {
+	destination, err := stringParam(params, "destination", true)
+	if err != nil {
+		return nil, err
+	}
+	satoshi, err := stringParam(params, "satoshi", true)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := glightning.ParseSat(satoshi)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Value == 0 && !amount.SendAll {
+		return nil, errors.New("satoshi must be more than zero")
+	}
+	request := &glightning.WithdrawRequest{
+		Destination: destination,
+		Satoshi:     amount.RawString(),
+	}
+	if rate, err := stringParam(params, "feerate", false); err != nil {
+		return nil, err
+	} else if rate != "" {
+		feerate, err := glightning.ParseFeeRate(rate)
+		if err != nil {
+			return nil, err
+		}
+		request.FeeRate = feerate.String()
+	}
+	return request, nil
+}
@@ -0,0 +1,133 @@
+package glightning
+
+import (
+	"sort"
+)
+
+// The most route hints SelectRouteHints picks by default. Each hint
+// lengthens the invoice, and payers rarely try more than a few.
+const DefaultMaxRouteHints = 3
+
+// Pick the private channels worth hinting at in an invoice for
+// {amountMsat} (0 for an invoice without an amount): those in normal
+// operation with a connected peer and room to receive the amount.
+// Up to {max} are chosen, those with the most inbound liquidity
+// first; a {max} of 0 means DefaultMaxRouteHints.
+//
+// Returns their short channel ids, for the invoice's
+// exposeprivatechannels (see InvoiceBuilder.ExposeChannels). An empty
+// result means no private channel can take the payment.
+func SelectRouteHints(peers []*Peer, amountMsat uint64, max int) []string {
+	var scids []string
+	for _, c := range hintCandidates(peers, amountMsat, max, false) {
+		scids = append(scids, string(c.channel.ShortChannelId))
+	}
+	return scids
+}
+
+// The short channel id to give payers for reaching us over {c}: the
+// alias the peer gave the channel, if it has one, otherwise its real
+// scid. The alias works before the channel confirms (zero-conf) and
+// doesn't reveal the funding transaction. Empty if the channel has
+// neither yet.
+func (c *PeerChannel) HintScid() ShortChannelId {
+	if c.Alias != nil && c.Alias.Remote != "" {
+		return ShortChannelId(c.Alias.Remote)
+	}
+	return c.ShortChannelId
+}
+
+// Assumed for the peer's side of a channel when lightningd doesn't
+// report its channel_update: lightningd's own defaults
+const (
+	defaultHintFeeBaseMsat = 1000
+	defaultHintFeePPM      = 10
+	defaultHintCltvDelta   = 34
+)
+
+// As SelectRouteHints, but returns complete route hints, each a single
+// hop from the peer to us over the channel's HintScid, for invoices
+// made outside lightningd (e.g. by an LSP for its client). Zero-conf
+// channels without a real scid yet are included by their alias.
+//
+// The fees and CLTV delta are those of the peer's channel_update, as
+// listpeerchannels reports in 'updates'; lightningd's defaults are
+// assumed if it doesn't.
+func SelectBoltRoutes(peers []*Peer, amountMsat uint64, max int) [][]BoltRoute {
+	var routes [][]BoltRoute
+	for _, c := range hintCandidates(peers, amountMsat, max, true) {
+		hop := BoltRoute{
+			Pubkey:                    c.peer.Id,
+			ShortChannelId:            string(c.channel.HintScid()),
+			FeeBaseMilliSatoshis:      defaultHintFeeBaseMsat,
+			FeeProportionalMillionths: defaultHintFeePPM,
+			CltvExpiryDelta:           defaultHintCltvDelta,
+		}
+		if u := c.channel.Updates; u != nil && u.Remote != nil {
+			hop.FeeBaseMilliSatoshis = msatOr(u.Remote.FeeBaseMsat, 0)
+			hop.FeeProportionalMillionths = u.Remote.FeeProportionalMillionths
+			hop.CltvExpiryDelta = u.Remote.CltvExpiryDelta
+		}
+		routes = append(routes, []BoltRoute{hop})
+	}
+	return routes
+}
+
+type hintCandidate struct {
+	peer    *Peer
+	channel *PeerChannel
+	inbound uint64
+}
+
+// The channels to hint at, best first. With {aliases}, channels known
+// only by an alias qualify.
+func hintCandidates(peers []*Peer, amountMsat uint64, max int, aliases bool) []hintCandidate {
+	if max <= 0 {
+		max = DefaultMaxRouteHints
+	}
+
+	var candidates []hintCandidate
+	for _, peer := range peers {
+		if !peer.Connected {
+			continue
+		}
+		for _, channel := range peer.Channels {
+			if !channel.Private || channel.State != "CHANNELD_NORMAL" {
+				continue
+			}
+			if channel.ShortChannelId == "" && (!aliases || channel.HintScid() == "") {
+				continue
+			}
+			inbound := msatOr(channel.ReceivableMsat, channel.ReceivableMilliSatoshi)
+			if inbound == 0 || inbound < amountMsat {
+				continue
+			}
+			candidates = append(candidates, hintCandidate{peer, channel, inbound})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].inbound > candidates[j].inbound
+	})
+	if len(candidates) > max {
+		candidates = candidates[:max]
+	}
+	return candidates
+}
+
+// SelectRouteHints, over the node's current peers
+func RouteHints(client LightningClient, amountMsat uint64, max int) ([]string, error) {
+	peers, err := client.ListPeers()
+	if err != nil {
+		return nil, err
+	}
+	return SelectRouteHints(peers, amountMsat, max), nil
+}
+
+// SelectBoltRoutes, over the node's current peers
+func BoltRouteHints(client LightningClient, amountMsat uint64, max int) ([][]BoltRoute, error) {
+	peers, err := client.ListPeers()
+	if err != nil {
+		return nil, err
+	}
+	return SelectBoltRoutes(peers, amountMsat, max), nil
+}
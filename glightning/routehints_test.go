@@ -0,0 +1,98 @@
+package glightning_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func hintChannel(scid string, private bool, state string, receivable uint64) *glightning.PeerChannel {
+	return &glightning.PeerChannel{
+		ShortChannelId: glightning.ShortChannelId(scid),
+		Private:        private,
+		State:          state,
+		ReceivableMsat: fmt.Sprintf("%dmsat", receivable),
+	}
+}
+
+func hintPeers() []*glightning.Peer {
+	return []*glightning.Peer{
+		{
+			Id:        "02aa",
+			Connected: true,
+			Channels: []*glightning.PeerChannel{
+				hintChannel("100x1x0", true, "CHANNELD_NORMAL", 50000),
+				hintChannel("101x1x0", false, "CHANNELD_NORMAL", 900000),
+				hintChannel("102x1x0", true, "CHANNELD_AWAITING_LOCKIN", 900000),
+			},
+		},
+		{
+			Id:        "02bb",
+			Connected: false,
+			Channels: []*glightning.PeerChannel{
+				hintChannel("103x1x0", true, "CHANNELD_NORMAL", 900000),
+			},
+		},
+		{
+			Id:        "02cc",
+			Connected: true,
+			Channels: []*glightning.PeerChannel{
+				hintChannel("104x1x0", true, "CHANNELD_NORMAL", 200000),
+				hintChannel("105x1x0", true, "CHANNELD_NORMAL", 10000),
+				hintChannel("106x1x0", true, "CHANNELD_NORMAL", 0),
+			},
+		},
+	}
+}
+
+func TestSelectRouteHints(t *testing.T) {
+	peers := hintPeers()
+	assert.Equal(t, []string{"104x1x0", "100x1x0", "105x1x0"}, glightning.SelectRouteHints(peers, 0, 0))
+	assert.Equal(t, []string{"104x1x0", "100x1x0"}, glightning.SelectRouteHints(peers, 20000, 0))
+	assert.Equal(t, []string{"104x1x0"}, glightning.SelectRouteHints(peers, 0, 1))
+	assert.Empty(t, glightning.SelectRouteHints(peers, 300000, 0))
+}
+
+func TestRouteHints(t *testing.T) {
+	ln := mock.New()
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return hintPeers(), nil
+	}
+	scids, err := glightning.RouteHints(ln, 100000, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"104x1x0"}, scids)
+
+	req, err := glightning.NewInvoiceBuilder().
+		Msat(100000).
+		Label("order-8").
+		Description("tea").
+		ExposeChannels(scids...).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"104x1x0"}, req.ExposeTheseChannels)
+}
+
+func TestSelectBoltRoutes(t *testing.T) {
+	peers := hintPeers()
+	// a zero-conf channel, known only by its alias
+	zeroConf := hintChannel("", true, "CHANNELD_NORMAL", 400000)
+	zeroConf.Alias = &glightning.ChannelAlias{Local: "2x2x2", Remote: "8x8x8"}
+	zeroConf.Updates = &glightning.ChannelUpdates{
+		Remote: &glightning.ChannelForwardingPolicy{FeeBaseMsat: "0msat", FeeProportionalMillionths: 100, CltvExpiryDelta: 80},
+	}
+	peers[0].Channels = append(peers[0].Channels, zeroConf)
+	peers[2].Channels[0].Alias = &glightning.ChannelAlias{Remote: "9x9x9"}
+	assert.Equal(t, glightning.ShortChannelId("9x9x9"), peers[2].Channels[0].HintScid())
+	assert.Equal(t, glightning.ShortChannelId("100x1x0"), peers[0].Channels[0].HintScid())
+
+	assert.Equal(t, [][]glightning.BoltRoute{
+		{{Pubkey: "02aa", ShortChannelId: "8x8x8", FeeBaseMilliSatoshis: 0, FeeProportionalMillionths: 100, CltvExpiryDelta: 80}},
+		{{Pubkey: "02cc", ShortChannelId: "9x9x9", FeeBaseMilliSatoshis: 1000, FeeProportionalMillionths: 10, CltvExpiryDelta: 34}},
+	}, glightning.SelectBoltRoutes(peers, 0, 2))
+
+	// exposeprivatechannels takes real scids only
+	assert.Equal(t, []string{"104x1x0", "100x1x0"}, glightning.SelectRouteHints(peers, 0, 2))
+}
@@ -2,6 +2,8 @@ package glightning
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 type Sat struct {
@@ -38,7 +40,7 @@ func (s *Sat) ConvertMsat() *MSat {
 }
 
 func (m *MSat) String() string {
-	return fmt.Sprintf("%dmsat", m.Value)
+	return strconv.FormatUint(m.Value, 10) + "msat"
 }
 
 func ConvertBtc(btc float64) *Sat {
@@ -53,14 +55,23 @@ func (s *Sat) RawString() string {
 	if s.SendAll {
 		return "all"
 	}
-	return fmt.Sprintf("%d", s.Value)
+	return strconv.FormatUint(s.Value, 10)
 }
 
 func (s *Sat) String() string {
 	if s.SendAll {
 		return "all"
 	}
-	return fmt.Sprintf("%dsat", s.Value)
+	return strconv.FormatUint(s.Value, 10) + "sat"
+}
+
+// Formats the amount in bitcoin, with all 8 decimal places,
+// e.g. "0.00100000btc"
+func (s *Sat) BtcString() string {
+	if s.SendAll {
+		return "all"
+	}
+	return fmt.Sprintf("%d.%08dbtc", s.Value/100000000, s.Value%100000000)
 }
 
 func NewSat64(amount uint64) *Sat {
@@ -80,3 +91,85 @@ func AllSats() *Sat {
 		SendAll: true,
 	}
 }
+
+// Parse a satoshi amount, as accepted by lightningd: a bare number
+// of satoshis, or a number suffixed with 'sat', 'msat' (must be a whole
+// number of satoshis) or 'btc' (up to 8 decimal places). "all" parses
+// to AllSats().
+func ParseSat(amount string) (*Sat, error) {
+	amount = strings.TrimSpace(amount)
+	switch {
+	case amount == "all":
+		return AllSats(), nil
+	case strings.HasSuffix(amount, "msat"):
+		msat, err := parseAmount(strings.TrimSuffix(amount, "msat"), 0)
+		if err != nil {
+			return nil, err
+		}
+		if msat%1000 != 0 {
+			return nil, fmt.Errorf("%s is not a whole number of satoshis", amount)
+		}
+		return NewSat64(msat / 1000), nil
+	case strings.HasSuffix(amount, "btc"):
+		sat, err := parseAmount(strings.TrimSuffix(amount, "btc"), 8)
+		if err != nil {
+			return nil, err
+		}
+		return NewSat64(sat), nil
+	default:
+		sat, err := parseAmount(strings.TrimSuffix(amount, "sat"), 0)
+		if err != nil {
+			return nil, err
+		}
+		return NewSat64(sat), nil
+	}
+}
+
+// Parse a millisatoshi amount: a bare number of millisatoshis, or a
+// number suffixed with 'msat', 'sat' or 'btc' (up to 11 decimal places).
+func ParseMSat(amount string) (*MSat, error) {
+	amount = strings.TrimSpace(amount)
+	var msat uint64
+	var err error
+	switch {
+	case strings.HasSuffix(amount, "msat"):
+		msat, err = parseAmount(strings.TrimSuffix(amount, "msat"), 0)
+	case strings.HasSuffix(amount, "sat"):
+		msat, err = parseAmount(strings.TrimSuffix(amount, "sat"), 3)
+	case strings.HasSuffix(amount, "btc"):
+		msat, err = parseAmount(strings.TrimSuffix(amount, "btc"), 11)
+	default:
+		msat, err = parseAmount(amount, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewMsat(msat), nil
+}
+
+// Parses a decimal string into an integer number of base units,
+// where {places} is the number of decimal places one unit has.
+// Avoids floats so no precision is lost.
+func parseAmount(amount string, places int) (uint64, error) {
+	whole, frac := amount, ""
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		whole, frac = amount[:i], amount[i+1:]
+		if frac == "" {
+			return 0, fmt.Errorf("Invalid amount %q", amount)
+		}
+	}
+	if whole == "" || len(frac) > places {
+		return 0, fmt.Errorf("Invalid amount %q", amount)
+	}
+	digits := whole + frac + strings.Repeat("0", places-len(frac))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("Invalid amount %q", amount)
+		}
+	}
+	v, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid amount %q: %s", amount, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,83 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSatFormatting(t *testing.T) {
+	assert.Equal(t, "500000sat", glightning.NewSat(500000).String())
+	assert.Equal(t, "500000", glightning.NewSat(500000).RawString())
+	assert.Equal(t, "0.00500000btc", glightning.NewSat(500000).BtcString())
+	assert.Equal(t, "21.00000001btc", glightning.NewSat64(2100000001).BtcString())
+	assert.Equal(t, "all", glightning.AllSats().String())
+	assert.Equal(t, "all", glightning.AllSats().RawString())
+	assert.Equal(t, "18446744073709551615msat", glightning.NewMsat(18446744073709551615).String())
+}
+
+func TestParseSat(t *testing.T) {
+	cases := map[string]*glightning.Sat{
+		"1000":        glightning.NewSat(1000),
+		"1000sat":     glightning.NewSat(1000),
+		"1000000msat": glightning.NewSat(1000),
+		"0.001btc":    glightning.NewSat(100000),
+		"1btc":        glightning.NewSat(100000000),
+		"all":         glightning.AllSats(),
+	}
+	for in, expected := range cases {
+		sat, err := glightning.ParseSat(in)
+		if assert.NoError(t, err, in) {
+			assert.Equal(t, expected, sat, in)
+		}
+	}
+
+	for _, in := range []string{"", "sat", "1001msat", "0.000000001btc", "1.btc", "-5", "12x", "99999999999999999999"} {
+		_, err := glightning.ParseSat(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestParseMSat(t *testing.T) {
+	cases := map[string]uint64{
+		"1":                1,
+		"1msat":            1,
+		"1sat":             1000,
+		"0.5sat":           500,
+		"0.00000000001btc": 1,
+		"2btc":             200000000000,
+	}
+	for in, expected := range cases {
+		msat, err := glightning.ParseMSat(in)
+		if assert.NoError(t, err, in) {
+			assert.Equal(t, expected, msat.Value, in)
+		}
+	}
+
+	_, err := glightning.ParseMSat("0.0001sat")
+	assert.Error(t, err)
+}
+
+func TestFeeRateString(t *testing.T) {
+	assert.Equal(t, "253perkw", glightning.NewFeeRate(glightning.PerKw, 253).String())
+	assert.Equal(t, "1000perkb", glightning.NewFeeRate(glightning.PerKb, 1000).String())
+	assert.Equal(t, "urgent", glightning.NewFeeRateByDirective(glightning.PerKb, glightning.Urgent).String())
+}
+
+func TestParseFeeRate(t *testing.T) {
+	rate, err := glightning.ParseFeeRate("253perkw")
+	assert.NoError(t, err)
+	assert.Equal(t, glightning.NewFeeRate(glightning.PerKw, 253), rate)
+
+	rate, err = glightning.ParseFeeRate("1000")
+	assert.NoError(t, err)
+	assert.Equal(t, glightning.NewFeeRate(glightning.PerKb, 1000), rate)
+
+	rate, err = glightning.ParseFeeRate("slow")
+	assert.NoError(t, err)
+	assert.Equal(t, glightning.NewFeeRateByDirective(glightning.PerKb, glightning.Slow), rate)
+
+	_, err = glightning.ParseFeeRate("fast")
+	assert.Error(t, err)
+}
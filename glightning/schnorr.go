@@ -0,0 +1,18 @@
+package glightning
+
+import (
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// Verify BIP-340 signature {sig} of {msg} by x-only public key {pubkey}
+func schnorrVerify(pubkey, msg, sig []byte) bool {
+	pk, err := schnorr.ParsePubKey(pubkey)
+	if err != nil {
+		return false
+	}
+	signature, err := schnorr.ParseSignature(sig)
+	if err != nil {
+		return false
+	}
+	return signature.Verify(msg, pk)
+}
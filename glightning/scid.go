@@ -0,0 +1,132 @@
+package glightning
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A short channel id, in lightningd's "{block}x{txindex}x{output}"
+// form, e.g. "845x12x0". Older versions of c-lightning used ':' as
+// the separator; both are accepted when parsing, and normalized
+// to the 'x' form.
+type ShortChannelId string
+
+func NewShortChannelId(block, txIndex uint32, output uint16) ShortChannelId {
+	return ShortChannelId(fmt.Sprintf("%dx%dx%d", block, txIndex, output))
+}
+
+// Decode a short channel id from its BOLT#7 8-byte integer encoding
+func ShortChannelIdFromUint64(scid uint64) ShortChannelId {
+	return NewShortChannelId(uint32(scid>>40), uint32(scid>>16)&0xFFFFFF, uint16(scid))
+}
+
+func ParseShortChannelId(scid string) (ShortChannelId, error) {
+	block, txIndex, output, err := splitShortChannelId(scid)
+	if err != nil {
+		return "", err
+	}
+	return NewShortChannelId(block, txIndex, output), nil
+}
+
+func splitShortChannelId(scid string) (uint32, uint32, uint16, error) {
+	sep := "x"
+	if strings.Contains(scid, ":") {
+		sep = ":"
+	}
+	parts := strings.Split(scid, sep)
+	if len(parts) != 3 {
+		return 0, 0, 0, fmt.Errorf("Invalid short_channel_id %q", scid)
+	}
+	block, err := strconv.ParseUint(parts[0], 10, 24)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("Invalid short_channel_id %q: bad block height", scid)
+	}
+	txIndex, err := strconv.ParseUint(parts[1], 10, 24)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("Invalid short_channel_id %q: bad tx index", scid)
+	}
+	output, err := strconv.ParseUint(parts[2], 10, 16)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("Invalid short_channel_id %q: bad output index", scid)
+	}
+	return uint32(block), uint32(txIndex), uint16(output), nil
+}
+
+func (s ShortChannelId) String() string {
+	return string(s)
+}
+
+func (s ShortChannelId) Valid() bool {
+	return s.Err() == nil
+}
+
+// Why the id is malformed, or nil if it's not. An id lightningd sent
+// that can't be parsed is kept as it came, rather than failing the
+// whole result it's in; check here before relying on its parts.
+func (s ShortChannelId) Err() error {
+	_, _, _, err := splitShortChannelId(string(s))
+	return err
+}
+
+// Block height of the funding transaction. Zero if the id is malformed.
+func (s ShortChannelId) Block() uint32 {
+	block, _, _, _ := splitShortChannelId(string(s))
+	return block
+}
+
+// Index of the funding transaction within its block. Zero if the
+// id is malformed.
+func (s ShortChannelId) TxIndex() uint32 {
+	_, txIndex, _, _ := splitShortChannelId(string(s))
+	return txIndex
+}
+
+// Funding output index. Zero if the id is malformed.
+func (s ShortChannelId) Output() uint16 {
+	_, _, output, _ := splitShortChannelId(string(s))
+	return output
+}
+
+// The BOLT#7 8-byte integer encoding of the short channel id
+func (s ShortChannelId) Uint64() uint64 {
+	block, txIndex, output, _ := splitShortChannelId(string(s))
+	return uint64(block)<<40 | uint64(txIndex)<<16 | uint64(output)
+}
+
+// Compare orders short channel ids by block, then tx index, then
+// output. Returns -1, 0 or 1.
+func (s ShortChannelId) Compare(other ShortChannelId) int {
+	a, b := s.Uint64(), other.Uint64()
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (s ShortChannelId) Less(other ShortChannelId) bool {
+	return s.Compare(other) < 0
+}
+
+func (s ShortChannelId) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(s))
+}
+
+func (s *ShortChannelId) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	scid, err := ParseShortChannelId(raw)
+	if err != nil {
+		// kept as it came; Err says what's wrong with it
+		*s = ShortChannelId(raw)
+		return nil
+	}
+	*s = scid
+	return nil
+}
@@ -0,0 +1,61 @@
+package glightning_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseShortChannelId(t *testing.T) {
+	scid, err := glightning.ParseShortChannelId("845x12x0")
+	assert.NoError(t, err)
+	assert.Equal(t, glightning.ShortChannelId("845x12x0"), scid)
+	assert.Equal(t, uint32(845), scid.Block())
+	assert.Equal(t, uint32(12), scid.TxIndex())
+	assert.Equal(t, uint16(0), scid.Output())
+
+	legacy, err := glightning.ParseShortChannelId("103:1:1")
+	assert.NoError(t, err)
+	assert.Equal(t, glightning.ShortChannelId("103x1x1"), legacy)
+
+	for _, bad := range []string{"", "845x12", "845x12x0x1", "axbxc", "16777216x0x0", "1x1x65536"} {
+		_, err := glightning.ParseShortChannelId(bad)
+		assert.Error(t, err, bad)
+	}
+	assert.False(t, glightning.ShortChannelId("nope").Valid())
+}
+
+func TestShortChannelIdUint64(t *testing.T) {
+	scid := glightning.NewShortChannelId(539268, 845, 1)
+	assert.Equal(t, uint64(592931436542885889), scid.Uint64())
+	assert.Equal(t, scid, glightning.ShortChannelIdFromUint64(592931436542885889))
+}
+
+func TestShortChannelIdCompare(t *testing.T) {
+	a := glightning.ShortChannelId("100x2x0")
+	b := glightning.ShortChannelId("100x10x0")
+	assert.True(t, a.Less(b))
+	assert.False(t, b.Less(a))
+	assert.Equal(t, 0, a.Compare("100:2:0"))
+}
+
+func TestShortChannelIdJSON(t *testing.T) {
+	var hop glightning.RouteHop
+	err := json.Unmarshal([]byte(`{"id":"02aa","channel":"103:1:1","direction":1}`), &hop)
+	assert.NoError(t, err)
+	assert.Equal(t, glightning.ShortChannelId("103x1x1"), hop.ShortChannelId)
+
+	out, err := json.Marshal(hop.ShortChannelId)
+	assert.NoError(t, err)
+	assert.Equal(t, `"103x1x1"`, string(out))
+
+	// an unparsable id doesn't fail the rest of the result
+	err = json.Unmarshal([]byte(`{"id":"02bb","channel":"garbage"}`), &hop)
+	assert.NoError(t, err)
+	assert.Equal(t, "02bb", hop.Id)
+	assert.Equal(t, glightning.ShortChannelId("garbage"), hop.ShortChannelId)
+	assert.EqualError(t, hop.ShortChannelId.Err(), `Invalid short_channel_id "garbage"`)
+	assert.NoError(t, glightning.ShortChannelId("845x12x0").Err())
+}
@@ -0,0 +1,169 @@
+package glightning
+
+import (
+	"sync"
+)
+
+// The short channel ids a channel is known by
+type ScidMapping struct {
+	ChannelId string
+	PeerId    string
+	// The real scid, from the funding transaction's position in the
+	// chain; empty until it confirms
+	Scid ShortChannelId
+	// The alias we gave the channel, which the peer uses, and the one
+	// the peer gave it, which we use (e.g. in route hints)
+	LocalAlias  ShortChannelId
+	RemoteAlias ShortChannelId
+}
+
+// ScidMap maps the alias short channel ids of our channels to their
+// real ones, so data keyed by alias (forwards and HTLCs over
+// unannounced or zero-conf channels) can be joined with data keyed by
+// real scid, such as the gossip graph.
+//
+//	scids := glightning.NewScidMap(ln)
+//	err := scids.Follow(events)
+//	...
+//	scid := scids.Resolve(glightning.ShortChannelId(forward.InChannel))
+//
+// The map is loaded from listpeerchannels by Refresh, and kept up to
+// date from channel_state_changed notifications by Follow, which
+// learns each channel's real scid once it confirms.
+type ScidMap struct {
+	client LightningClient
+
+	mu        sync.RWMutex
+	byChannel map[string]*ScidMapping
+	byScid    map[ShortChannelId]*ScidMapping
+
+	sub    *EventSubscription
+	exited chan struct{}
+}
+
+func NewScidMap(client LightningClient) *ScidMap {
+	return &ScidMap{
+		client:    client,
+		byChannel: make(map[string]*ScidMapping),
+		byScid:    make(map[ShortChannelId]*ScidMapping),
+	}
+}
+
+// Reload the map from the node's channels
+func (m *ScidMap) Refresh() error {
+	peers, err := m.client.ListPeers()
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byChannel = make(map[string]*ScidMapping)
+	m.byScid = make(map[ShortChannelId]*ScidMapping)
+	for _, peer := range peers {
+		for _, channel := range peer.Channels {
+			mapping := &ScidMapping{
+				ChannelId: channel.ChannelId,
+				PeerId:    peer.Id,
+				Scid:      channel.ShortChannelId,
+			}
+			if channel.Alias != nil {
+				mapping.LocalAlias = ShortChannelId(channel.Alias.Local)
+				mapping.RemoteAlias = ShortChannelId(channel.Alias.Remote)
+			}
+			m.add(mapping)
+		}
+	}
+	return nil
+}
+
+// Call with mu held
+func (m *ScidMap) add(mapping *ScidMapping) {
+	if mapping.ChannelId != "" {
+		m.byChannel[mapping.ChannelId] = mapping
+	}
+	for _, scid := range []ShortChannelId{mapping.Scid, mapping.LocalAlias, mapping.RemoteAlias} {
+		if scid != "" {
+			m.byScid[scid] = mapping
+		}
+	}
+}
+
+// Note the real scid in {change}, if it has one. Returns false if the
+// channel isn't in the map, in which case Refresh to learn its
+// aliases.
+func (m *ScidMap) Apply(change *ChannelStateChanged) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mapping, ok := m.byChannel[change.ChannelId]
+	if !ok {
+		return false
+	}
+	if change.ShortChannelId != "" && change.ShortChannelId != mapping.Scid {
+		// after a splice the old scid still resolves to the channel
+		mapping.Scid = change.ShortChannelId
+		m.byScid[mapping.Scid] = mapping
+	}
+	return true
+}
+
+// The channel {scid} belongs to, whether it's an alias or real
+func (m *ScidMap) Lookup(scid ShortChannelId) (ScidMapping, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	mapping, ok := m.byScid[scid]
+	if !ok {
+		return ScidMapping{}, false
+	}
+	return *mapping, true
+}
+
+// The real scid of the channel {scid} belongs to. Returns {scid}
+// itself if it's unknown, or if the channel has yet to confirm.
+func (m *ScidMap) Resolve(scid ShortChannelId) ShortChannelId {
+	mapping, ok := m.Lookup(scid)
+	if !ok || mapping.Scid == "" {
+		return scid
+	}
+	return mapping.Scid
+}
+
+// Every channel in the map
+func (m *ScidMap) Mappings() []ScidMapping {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	mappings := make([]ScidMapping, 0, len(m.byChannel))
+	for _, mapping := range m.byChannel {
+		mappings = append(mappings, *mapping)
+	}
+	return mappings
+}
+
+// Refresh, then keep the map up to date from {events}' channel state
+// changes until Stop
+func (m *ScidMap) Follow(events *Events) error {
+	if err := m.Refresh(); err != nil {
+		return err
+	}
+	m.sub = events.Subscribe(16, OnlyKinds(EventChannelStateChanged))
+	m.exited = make(chan struct{})
+	go func() {
+		defer close(m.exited)
+		for e := range m.sub.C {
+			change, ok := e.Payload.(*ChannelStateChanged)
+			if ok && !m.Apply(change) {
+				// a new channel; errors are retried on its next change
+				m.Refresh()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop following events
+func (m *ScidMap) Stop() {
+	if m.sub == nil {
+		return
+	}
+	m.sub.Close()
+	<-m.exited
+}
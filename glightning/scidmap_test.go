@@ -0,0 +1,66 @@
+package glightning_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScidMap(t *testing.T) {
+	channels := []*glightning.PeerChannel{
+		{ChannelId: "c1", ShortChannelId: "700x1x0", Alias: &glightning.ChannelAlias{Local: "1x1x1", Remote: "8x8x8"}},
+		{ChannelId: "c2", Alias: &glightning.ChannelAlias{Local: "2x2x2", Remote: "9x9x9"}},
+	}
+	ln := mock.New()
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return []*glightning.Peer{{Id: "02aa", Channels: channels}}, nil
+	}
+	scids := glightning.NewScidMap(ln)
+	events := glightning.NewEvents(glightning.NewPlugin(nullInitFunc))
+	if err := scids.Follow(events); err != nil {
+		t.Fatal(err)
+	}
+	defer scids.Stop()
+
+	assert.Equal(t, glightning.ShortChannelId("700x1x0"), scids.Resolve("1x1x1"))
+	assert.Equal(t, glightning.ShortChannelId("700x1x0"), scids.Resolve("8x8x8"))
+	assert.Equal(t, glightning.ShortChannelId("700x1x0"), scids.Resolve("700x1x0"))
+	// not confirmed yet, or not ours
+	assert.Equal(t, glightning.ShortChannelId("2x2x2"), scids.Resolve("2x2x2"))
+	assert.Equal(t, glightning.ShortChannelId("5x5x5"), scids.Resolve("5x5x5"))
+
+	// the zero-conf channel confirms
+	events.Publish(glightning.EventChannelStateChanged, &glightning.ChannelStateChanged{
+		PeerId: "02aa", ChannelId: "c2", ShortChannelId: "701x3x1", NewState: "CHANNELD_NORMAL",
+	})
+	assert.Eventually(t, func() bool {
+		return scids.Resolve("9x9x9") == "701x3x1"
+	}, time.Second, time.Millisecond)
+	mapping, ok := scids.Lookup("2x2x2")
+	assert.True(t, ok)
+	assert.Equal(t, glightning.ScidMapping{
+		ChannelId:   "c2",
+		PeerId:      "02aa",
+		Scid:        "701x3x1",
+		LocalAlias:  "2x2x2",
+		RemoteAlias: "9x9x9",
+	}, mapping)
+
+	// a new channel is found by refreshing
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		return []*glightning.Peer{{Id: "02bb", Channels: []*glightning.PeerChannel{
+			{ChannelId: "c3", Alias: &glightning.ChannelAlias{Local: "3x3x3"}},
+		}}}, nil
+	}
+	events.Publish(glightning.EventChannelStateChanged, &glightning.ChannelStateChanged{
+		PeerId: "02bb", ChannelId: "c3", NewState: "CHANNELD_AWAITING_LOCKIN",
+	})
+	assert.Eventually(t, func() bool {
+		_, ok := scids.Lookup("3x3x3")
+		return ok
+	}, time.Second, time.Millisecond)
+	assert.Len(t, scids.Mappings(), 1)
+}
@@ -0,0 +1,182 @@
+package glightning
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// A splice, signed and sent to the peer
+type SpliceResult struct {
+	ChannelId string
+	// In satoshi: positive for a splice in, negative for a splice out
+	RelativeAmount int64
+	TxId           string
+	Tx             string
+	// Set once the splice has confirmed and the channel is using it,
+	// if the Splicer waits for that
+	Locked bool
+}
+
+// Splicer resizes a channel while it stays open, running splice_init,
+// splice_update and splice_signed with the PSBT handling each needs:
+//
+//	splicer := glightning.NewSplicer(ln, channelId)
+//	splicer.WaitLocked = true
+//	result, err := splicer.SpliceIn(ctx, 500000)
+//
+// Splicing must be enabled in lightningd (experimental-splicing).
+type Splicer struct {
+	ChannelId string
+	// The splice's feerate; 0 uses lightningd's estimate
+	FeeRatePerKw uint32
+	// Signs our inputs of a splice in. Defaults to signpsbt, for
+	// inputs from lightningd's wallet.
+	Sign func(ctx context.Context, psbt string) (string, error)
+	// Wait for the splice to confirm and the channel to start using it
+	// before returning
+	WaitLocked bool
+	// How often to check whether the splice has locked. Defaults to a
+	// minute.
+	PollInterval time.Duration
+
+	client LightningClient
+}
+
+func NewSplicer(client LightningClient, channelId string) *Splicer {
+	return &Splicer{
+		ChannelId:    channelId,
+		PollInterval: time.Minute,
+		client:       client,
+	}
+}
+
+// Add {amount} satoshi from lightningd's wallet to the channel. The
+// wallet also pays the splice's fee. If the splice can't be agreed,
+// the wallet's inputs are released again.
+func (s *Splicer) SpliceIn(ctx context.Context, amount uint64) (*SpliceResult, error) {
+	if amount == 0 {
+		return nil, fmt.Errorf("Must set satoshi amount to splice in")
+	}
+	req := &FundPsbtRequest{
+		Satoshi:        NewSat64(amount).RawString(),
+		ExcessAsChange: true,
+	}
+	if s.FeeRatePerKw != 0 {
+		req.FeeRate = NewFeeRate(PerKw, uint(s.FeeRatePerKw)).String()
+	}
+	funded, err := s.client.FundPsbtWithOptions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.splice(ctx, int64(amount), funded.Psbt, true)
+	if err != nil {
+		s.client.UnreserveInputs(funded.Psbt)
+		return nil, err
+	}
+	return s.wait(ctx, result)
+}
+
+// Take {amount} satoshi out of the channel, paying it to
+// {destination}, or to lightningd's wallet if that's empty. The
+// splice's fee is paid from the channel too.
+func (s *Splicer) SpliceOut(ctx context.Context, amount uint64, destination string) (*SpliceResult, error) {
+	if amount == 0 {
+		return nil, fmt.Errorf("Must set satoshi amount to splice out")
+	}
+	output, err := s.client.AddPsbtOutput(NewSat64(amount), "", destination)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.splice(ctx, -int64(amount), output.Psbt, false)
+	if err != nil {
+		return nil, err
+	}
+	return s.wait(ctx, result)
+}
+
+func (s *Splicer) splice(ctx context.Context, relativeAmount int64, psbt string, sign bool) (*SpliceResult, error) {
+	if s.ChannelId == "" {
+		return nil, fmt.Errorf("Must provide a channel id to splice")
+	}
+	update, err := s.client.SpliceInit(s.ChannelId, relativeAmount, psbt, s.FeeRatePerKw)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; !update.CommitmentsSecured; i++ {
+		if i == maxUpdateRounds {
+			return nil, fmt.Errorf("Commitments not secured after %d updates", maxUpdateRounds)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if update, err = s.client.SpliceUpdate(s.ChannelId, update.Psbt); err != nil {
+			return nil, err
+		}
+	}
+
+	psbt = update.Psbt
+	if sign {
+		if s.Sign != nil {
+			psbt, err = s.Sign(ctx, psbt)
+		} else {
+			psbt, err = s.client.SignPsbt(psbt, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	signed, err := s.client.SpliceSigned(s.ChannelId, psbt)
+	if err != nil {
+		return nil, err
+	}
+	return &SpliceResult{
+		ChannelId:      s.ChannelId,
+		RelativeAmount: relativeAmount,
+		TxId:           signed.TxId,
+		Tx:             signed.Tx,
+	}, nil
+}
+
+func (s *Splicer) wait(ctx context.Context, result *SpliceResult) (*SpliceResult, error) {
+	if !s.WaitLocked {
+		return result, nil
+	}
+	return result, s.WaitForLock(ctx, result)
+}
+
+// Wait until {result}'s splice has confirmed and the channel is using
+// it as its funding, or {ctx} is done
+func (s *Splicer) WaitForLock(ctx context.Context, result *SpliceResult) error {
+	for {
+		locked, err := s.locked(result.TxId)
+		if err != nil {
+			return err
+		}
+		if locked {
+			result.Locked = true
+			return nil
+		}
+		select {
+		case <-time.After(s.PollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Splicer) locked(txid string) (bool, error) {
+	peers, err := s.client.ListPeers()
+	if err != nil {
+		return false, err
+	}
+	for _, peer := range peers {
+		for _, channel := range peer.Channels {
+			if channel.ChannelId == s.ChannelId {
+				return channel.FundingTxId == txid && channel.State == "CHANNELD_NORMAL", nil
+			}
+		}
+	}
+	return false, fmt.Errorf("Channel %s not found", s.ChannelId)
+}
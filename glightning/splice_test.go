@@ -0,0 +1,113 @@
+package glightning_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/glightning/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const spliceChannelId = "252d1b0a1e57895e84137f28cf19ab2c35847e284c112fefdecc7afeaa5c1de7"
+
+// a node splicing {spliceChannelId}, whose peer secures the
+// commitments on the second update
+func spliceMock(t *testing.T) *mock.Lightning {
+	ln := mock.New()
+	ln.SpliceInitFunc = func(channelId string, relativeAmount int64, initialPsbt string, feeRatePerKw uint32) (*glightning.SpliceUpdateResult, error) {
+		assert.Equal(t, spliceChannelId, channelId)
+		return &glightning.SpliceUpdateResult{Psbt: initialPsbt + "+init"}, nil
+	}
+	updates := 0
+	ln.SpliceUpdateFunc = func(channelId, psbt string) (*glightning.SpliceUpdateResult, error) {
+		updates++
+		return &glightning.SpliceUpdateResult{Psbt: psbt + "+update", CommitmentsSecured: updates == 2}, nil
+	}
+	ln.SpliceSignedFunc = func(channelId, psbt string) (*glightning.SpliceSignedResult, error) {
+		return &glightning.SpliceSignedResult{Tx: psbt, TxId: "5p1ce"}, nil
+	}
+	return ln
+}
+
+func TestSpliceIn(t *testing.T) {
+	ln := spliceMock(t)
+	ln.FundPsbtWithOptionsFunc = func(req *glightning.FundPsbtRequest) (*glightning.FundPsbtResult, error) {
+		assert.Equal(t, &glightning.FundPsbtRequest{Satoshi: "500000", FeeRate: "3000perkw", ExcessAsChange: true}, req)
+		return &glightning.FundPsbtResult{Psbt: "funded"}, nil
+	}
+	ln.SignPsbtFunc = func(psbt string, signOnly []uint32) (string, error) {
+		return psbt + "+signed", nil
+	}
+	checks := 0
+	ln.ListPeersFunc = func() ([]*glightning.Peer, error) {
+		checks++
+		channel := &glightning.PeerChannel{ChannelId: spliceChannelId, State: "CHANNELD_AWAITING_SPLICE", FundingTxId: "0pen"}
+		if checks == 3 {
+			channel.State, channel.FundingTxId = "CHANNELD_NORMAL", "5p1ce"
+		}
+		return []*glightning.Peer{{Id: "02aa", Channels: []*glightning.PeerChannel{channel}}}, nil
+	}
+
+	splicer := glightning.NewSplicer(ln, spliceChannelId)
+	splicer.FeeRatePerKw = 3000
+	splicer.WaitLocked = true
+	splicer.PollInterval = time.Millisecond
+	result, err := splicer.SpliceIn(context.Background(), 500000)
+	assert.NoError(t, err)
+	assert.Equal(t, &glightning.SpliceResult{
+		ChannelId:      spliceChannelId,
+		RelativeAmount: 500000,
+		TxId:           "5p1ce",
+		Tx:             "funded+init+update+update+signed",
+		Locked:         true,
+	}, result)
+	assert.Equal(t, 3, checks)
+}
+
+func TestSpliceInReleasesInputs(t *testing.T) {
+	ln := spliceMock(t)
+	ln.FundPsbtWithOptionsFunc = func(req *glightning.FundPsbtRequest) (*glightning.FundPsbtResult, error) {
+		return &glightning.FundPsbtResult{Psbt: "funded"}, nil
+	}
+	ln.SpliceUpdateFunc = func(channelId, psbt string) (*glightning.SpliceUpdateResult, error) {
+		return nil, errors.New("peer disconnected")
+	}
+	var released string
+	ln.UnreserveInputsFunc = func(psbt string) ([]*glightning.PsbtReservation, error) {
+		released = psbt
+		return nil, nil
+	}
+
+	_, err := glightning.NewSplicer(ln, spliceChannelId).SpliceIn(context.Background(), 500000)
+	assert.EqualError(t, err, "peer disconnected")
+	assert.Equal(t, "funded", released)
+}
+
+func TestSpliceOut(t *testing.T) {
+	ln := spliceMock(t)
+	ln.AddPsbtOutputFunc = func(amount *glightning.Sat, initialPsbt, destination string) (*glightning.AddPsbtOutputResult, error) {
+		assert.Equal(t, uint64(100000), amount.Value)
+		assert.Equal(t, "bc1qcold", destination)
+		return &glightning.AddPsbtOutputResult{Psbt: "output"}, nil
+	}
+	var relative int64
+	init := ln.SpliceInitFunc
+	ln.SpliceInitFunc = func(channelId string, relativeAmount int64, initialPsbt string, feeRatePerKw uint32) (*glightning.SpliceUpdateResult, error) {
+		relative = relativeAmount
+		return init(channelId, relativeAmount, initialPsbt, feeRatePerKw)
+	}
+
+	splicer := glightning.NewSplicer(ln, spliceChannelId)
+	result, err := splicer.SpliceOut(context.Background(), 100000, "bc1qcold")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-100000), relative)
+	// nothing of ours to sign
+	assert.Equal(t, "output+init+update+update", result.Tx)
+	assert.False(t, result.Locked)
+
+	_, err = splicer.SpliceOut(context.Background(), 0, "")
+	assert.EqualError(t, err, "Must set satoshi amount to splice out")
+}
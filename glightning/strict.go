@@ -0,0 +1,61 @@
+package glightning
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A result had a field its Go type has nothing to decode into; see
+// SetStrict. The rest of the result is still decoded.
+type UnknownFieldError struct {
+	// The type the result was decoded into, e.g. "glightning.NodeInfo".
+	// The field may be on a struct nested in it.
+	Type  string
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("%s has no field for %q", e.Type, e.Field)
+}
+
+// Turn strict decoding on or off. When on, a result with a field
+// glightning doesn't map (e.g. one added by a newer lightningd) fails
+// with an *UnknownFieldError, naming the field. Results are still
+// decoded, so this can be used to log what is being missed:
+//
+//	info, err := lightning.GetInfo()
+//	var unknown *glightning.UnknownFieldError
+//	if errors.As(err, &unknown) {
+//		log.Printf("unmapped field: %s", unknown)
+//	}
+//
+// Works alongside SetCompat.
+func (l *Lightning) SetStrict(on bool) {
+	l.strict = on
+	l.setUnmarshaler()
+}
+
+func strictUnmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil {
+		return nil
+	}
+	// encoding/json has no error type for these
+	const prefix = "json: unknown field "
+	if !strings.HasPrefix(err.Error(), prefix) {
+		return err
+	}
+	field, unquoteErr := strconv.Unquote(strings.TrimPrefix(err.Error(), prefix))
+	if unquoteErr != nil {
+		return err
+	}
+	return &UnknownFieldError{
+		Type:  strings.TrimLeft(fmt.Sprintf("%T", v), "*"),
+		Field: field,
+	}
+}
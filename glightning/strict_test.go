@@ -0,0 +1,60 @@
+package glightning_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrict(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"getinfo","params":{},"id":1}`
+	resp := wrapResult(1, `{"id": "02aa", "alias": "strict", "color": "02aa00", "fancy_new_field": 1}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	lightning.SetStrict(true)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	info, err := lightning.GetInfo()
+	var unknown *glightning.UnknownFieldError
+	if !errors.As(err, &unknown) {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "glightning.NodeInfo", unknown.Type)
+	assert.Equal(t, "fancy_new_field", unknown.Field)
+	assert.Equal(t, `glightning.NodeInfo has no field for "fancy_new_field"`, err.Error())
+	// the known fields are still filled in
+	assert.Equal(t, "strict", info.Alias)
+
+	req = `{"jsonrpc":"2.0","method":"getinfo","params":{},"id":2}`
+	resp = wrapResult(2, `{"id": "02aa", "alias": "strict"}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err = lightning.GetInfo()
+	assert.NoError(t, err)
+
+	lightning.SetStrict(false)
+	req = `{"jsonrpc":"2.0","method":"getinfo","params":{},"id":3}`
+	resp = wrapResult(3, `{"id": "02aa", "fancy_new_field": 1}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err = lightning.GetInfo()
+	assert.NoError(t, err)
+}
+
+func TestStrictCompat(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"listinvoices","params":{"label":"uniq"},"id":1}`
+	resp := wrapResult(1, `{"invoices": [{"label": "uniq", "amount_msat": 1000, "status": "paid", "bolt12": "lno1"}]}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	lightning.SetCompat(true)
+	lightning.SetStrict(true)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	var result struct {
+		Invoices []*glightning.Invoice `json:"invoices"`
+	}
+	err := lightning.Request(&glightning.ListInvoiceRequest{Label: "uniq"}, &result)
+	var unknown *glightning.UnknownFieldError
+	if !errors.As(err, &unknown) {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "bolt12", unknown.Field)
+	// compat still converts the amount
+	assert.Equal(t, "1000msat", result.Invoices[0].AmountMilliSatoshi)
+}
@@ -491,7 +491,7 @@ func getShortChannelId(t *testing.T, node1, node2 *Node) string {
 	if peer == nil || len(peer.Channels) == 0 {
 		t.Fatal(fmt.Sprintf("peer %s not found", info.Id))
 	}
-	return peer.Channels[0].ShortChannelId
+	return peer.Channels[0].ShortChannelId.String()
 }
 
 func TestPluginOptions(t *testing.T) {
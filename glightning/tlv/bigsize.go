@@ -0,0 +1,105 @@
+package tlv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Read a BOLT#1 bigsize: a big-endian varint which must be minimally
+// encoded
+func ReadBigSize(r io.Reader) (uint64, error) {
+	var first [1]byte
+	if _, err := io.ReadFull(r, first[:]); err != nil {
+		return 0, fmt.Errorf("Truncated bigsize")
+	}
+	var size int
+	var min uint64
+	switch first[0] {
+	case 0xfd:
+		size, min = 2, 0xfd
+	case 0xfe:
+		size, min = 4, 0x10000
+	case 0xff:
+		size, min = 8, 0x100000000
+	default:
+		return uint64(first[0]), nil
+	}
+	buf := make([]byte, 8)
+	if _, err := io.ReadFull(r, buf[8-size:]); err != nil {
+		return 0, fmt.Errorf("Truncated bigsize")
+	}
+	v := binary.BigEndian.Uint64(buf)
+	if v < min {
+		return 0, fmt.Errorf("Non-minimal bigsize")
+	}
+	return v, nil
+}
+
+func WriteBigSize(w *bytes.Buffer, v uint64) {
+	buf := make([]byte, 9)
+	switch {
+	case v < 0xfd:
+		w.WriteByte(byte(v))
+	case v <= 0xffff:
+		buf[0] = 0xfd
+		binary.BigEndian.PutUint16(buf[1:], uint16(v))
+		w.Write(buf[:3])
+	case v <= 0xffffffff:
+		buf[0] = 0xfe
+		binary.BigEndian.PutUint32(buf[1:], uint32(v))
+		w.Write(buf[:5])
+	default:
+		buf[0] = 0xff
+		binary.BigEndian.PutUint64(buf[1:], v)
+		w.Write(buf)
+	}
+}
+
+// The number of bytes {v} takes as a bigsize
+func BigSizeLen(v uint64) int {
+	switch {
+	case v < 0xfd:
+		return 1
+	case v <= 0xffff:
+		return 3
+	case v <= 0xffffffff:
+		return 5
+	default:
+		return 9
+	}
+}
+
+// A truncated (no leading zeros) big-endian integer, as used for
+// tu16, tu32 and tu64 record values
+func Tu64(v uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, v)
+	for len(buf) > 0 && buf[0] == 0 {
+		buf = buf[1:]
+	}
+	return buf
+}
+
+func ReadTu64(v []byte) (uint64, error) {
+	if len(v) > 8 {
+		return 0, fmt.Errorf("tu64 too long")
+	}
+	if len(v) > 0 && v[0] == 0 {
+		return 0, fmt.Errorf("tu64 not minimal")
+	}
+	var n uint64
+	for _, b := range v {
+		n = n<<8 | uint64(b)
+	}
+	return n, nil
+}
+
+func ReadTu32(v []byte) (uint32, error) {
+	if len(v) > 4 {
+		return 0, fmt.Errorf("tu32 too long")
+	}
+	n, err := ReadTu64(v)
+	return uint32(n), err
+}
@@ -0,0 +1,161 @@
+// Package tlv encodes and decodes BOLT#1 type-length-value streams, as
+// used in onion payloads, BOLT#12 strings, keysend extra records and
+// peer messages.
+//
+// A stream's records are in strictly increasing type order. Readers
+// must understand every even type ("it's OK to be odd"): a Schema
+// registers the types a reader knows, with how to decode each.
+package tlv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+type Record struct {
+	Type  uint64
+	Value []byte
+}
+
+// A record holding {v} as a tu64
+func NewTu64Record(typ, v uint64) *Record {
+	return &Record{Type: typ, Value: Tu64(v)}
+}
+
+// Decode a TLV stream into its records
+func Decode(data []byte) ([]*Record, error) {
+	var records []*Record
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		typ, err := ReadBigSize(r)
+		if err != nil {
+			return nil, err
+		}
+		length, err := ReadBigSize(r)
+		if err != nil {
+			return nil, err
+		}
+		if length > uint64(r.Len()) {
+			return nil, fmt.Errorf("Field %d: length %d exceeds remaining data", typ, length)
+		}
+		if len(records) > 0 && typ <= records[len(records)-1].Type {
+			return nil, fmt.Errorf("Fields out of order at %d", typ)
+		}
+		value := make([]byte, length)
+		r.Read(value)
+		records = append(records, &Record{typ, value})
+	}
+	return records, nil
+}
+
+// Encode {records} as a TLV stream, sorting them by type. Types must
+// be unique.
+func Encode(records []*Record) ([]byte, error) {
+	sorted := make([]*Record, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Type < sorted[j].Type
+	})
+
+	var buf bytes.Buffer
+	for i, rec := range sorted {
+		if i > 0 && rec.Type == sorted[i-1].Type {
+			return nil, fmt.Errorf("Duplicate field %d", rec.Type)
+		}
+		WriteBigSize(&buf, rec.Type)
+		WriteBigSize(&buf, uint64(len(rec.Value)))
+		buf.Write(rec.Value)
+	}
+	return buf.Bytes(), nil
+}
+
+// A peer message: a big-endian u16 type followed by a TLV stream
+func EncodeMessage(msgType uint16, records []*Record) ([]byte, error) {
+	stream, err := Encode(records)
+	if err != nil {
+		return nil, err
+	}
+	msg := make([]byte, 2, 2+len(stream))
+	binary.BigEndian.PutUint16(msg, msgType)
+	return append(msg, stream...), nil
+}
+
+func DecodeMessage(msg []byte) (uint16, []*Record, error) {
+	if len(msg) < 2 {
+		return 0, nil, fmt.Errorf("Message too short for a type")
+	}
+	records, err := Decode(msg[2:])
+	return binary.BigEndian.Uint16(msg), records, err
+}
+
+// Turns a record's value into a typed Go value
+type Decoder func(value []byte) (interface{}, error)
+
+var (
+	Tu64Decoder Decoder = func(v []byte) (interface{}, error) {
+		return ReadTu64(v)
+	}
+	Tu32Decoder Decoder = func(v []byte) (interface{}, error) {
+		return ReadTu32(v)
+	}
+	BytesDecoder Decoder = func(v []byte) (interface{}, error) {
+		return v, nil
+	}
+	StringDecoder Decoder = func(v []byte) (interface{}, error) {
+		return string(v), nil
+	}
+)
+
+// The record types a reader understands
+type Schema struct {
+	names    map[uint64]string
+	decoders map[uint64]Decoder
+}
+
+func NewSchema() *Schema {
+	return &Schema{
+		names:    make(map[uint64]string),
+		decoders: make(map[uint64]Decoder),
+	}
+}
+
+// Register record type {typ}, called {name} in errors, decoded with
+// {decoder}. Returns the schema, so registrations can be chained.
+func (s *Schema) Register(typ uint64, name string, decoder Decoder) *Schema {
+	s.names[typ] = name
+	s.decoders[typ] = decoder
+	return s
+}
+
+// Decode a TLV stream, returning the decoded value of each registered
+// type present. Unregistered odd types are returned as their raw
+// bytes; unregistered even types are an error.
+func (s *Schema) Decode(data []byte) (map[uint64]interface{}, error) {
+	records, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.DecodeRecords(records)
+}
+
+func (s *Schema) DecodeRecords(records []*Record) (map[uint64]interface{}, error) {
+	values := make(map[uint64]interface{}, len(records))
+	for _, rec := range records {
+		decoder, ok := s.decoders[rec.Type]
+		if !ok {
+			if rec.Type%2 == 0 {
+				return nil, fmt.Errorf("Unknown even field %d", rec.Type)
+			}
+			values[rec.Type] = rec.Value
+			continue
+		}
+		v, err := decoder(rec.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", s.names[rec.Type], err)
+		}
+		values[rec.Type] = v
+	}
+	return values, nil
+}
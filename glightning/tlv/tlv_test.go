@@ -0,0 +1,134 @@
+package tlv_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning/tlv"
+	"github.com/stretchr/testify/assert"
+)
+
+// BOLT#1's bigsize test vectors
+func TestBigSize(t *testing.T) {
+	vectors := []struct {
+		value   uint64
+		encoded string
+	}{
+		{0, "00"},
+		{252, "fc"},
+		{253, "fd00fd"},
+		{65535, "fdffff"},
+		{65536, "fe00010000"},
+		{4294967295, "feffffffff"},
+		{4294967296, "ff0000000100000000"},
+		{18446744073709551615, "ffffffffffffffffff"},
+	}
+	for _, v := range vectors {
+		var buf bytes.Buffer
+		tlv.WriteBigSize(&buf, v.value)
+		assert.Equal(t, v.encoded, hex.EncodeToString(buf.Bytes()))
+		assert.Equal(t, len(v.encoded)/2, tlv.BigSizeLen(v.value))
+
+		raw, _ := hex.DecodeString(v.encoded)
+		decoded, err := tlv.ReadBigSize(bytes.NewReader(raw))
+		assert.NoError(t, err)
+		assert.Equal(t, v.value, decoded)
+	}
+
+	for _, bad := range []string{"fd00fc", "fe0000ffff", "ff00000000ffffffff", "fd00", "ff00000000", ""} {
+		raw, _ := hex.DecodeString(bad)
+		_, err := tlv.ReadBigSize(bytes.NewReader(raw))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestTu64(t *testing.T) {
+	assert.Equal(t, []byte{}, tlv.Tu64(0))
+	assert.Equal(t, []byte{0x01, 0x00}, tlv.Tu64(256))
+	n, err := tlv.ReadTu64([]byte{0x01, 0x00})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(256), n)
+	_, err = tlv.ReadTu64([]byte{0x00, 0x01})
+	assert.EqualError(t, err, "tu64 not minimal")
+	_, err = tlv.ReadTu32([]byte{1, 0, 0, 0, 0})
+	assert.EqualError(t, err, "tu32 too long")
+}
+
+func TestEncodeDecode(t *testing.T) {
+	records := []*tlv.Record{
+		{Type: 65537, Value: []byte("hi")},
+		tlv.NewTu64Record(2, 1000),
+		{Type: 4, Value: []byte{}},
+	}
+	data, err := tlv.Encode(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "020203e80400fe00010001026869", hex.EncodeToString(data))
+
+	decoded, err := tlv.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*tlv.Record{records[1], records[2], records[0]}, decoded)
+
+	_, err = tlv.Encode([]*tlv.Record{{Type: 1}, {Type: 1}})
+	assert.EqualError(t, err, "Duplicate field 1")
+
+	raw, _ := hex.DecodeString("04000200")
+	_, err = tlv.Decode(raw)
+	assert.EqualError(t, err, "Fields out of order at 2")
+	raw, _ = hex.DecodeString("0205aa")
+	_, err = tlv.Decode(raw)
+	assert.EqualError(t, err, "Field 2: length 5 exceeds remaining data")
+}
+
+func TestSchema(t *testing.T) {
+	schema := tlv.NewSchema().
+		Register(2, "amt_to_forward", tlv.Tu64Decoder).
+		Register(4, "outgoing_cltv_value", tlv.Tu32Decoder).
+		Register(7, "note", tlv.StringDecoder)
+
+	data, _ := tlv.Encode([]*tlv.Record{
+		tlv.NewTu64Record(2, 5000),
+		tlv.NewTu64Record(4, 144),
+		{Type: 7, Value: []byte("coffee")},
+		{Type: 9, Value: []byte{0xab}},
+	})
+	values, err := schema.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[uint64]interface{}{
+		2: uint64(5000),
+		4: uint32(144),
+		7: "coffee",
+		9: []byte{0xab},
+	}, values)
+
+	// it's OK to be odd, but not even
+	data, _ = tlv.Encode([]*tlv.Record{{Type: 10, Value: []byte{1}}})
+	_, err = schema.Decode(data)
+	assert.EqualError(t, err, "Unknown even field 10")
+
+	data, _ = tlv.Encode([]*tlv.Record{{Type: 2, Value: []byte{0, 1}}})
+	_, err = schema.Decode(data)
+	assert.EqualError(t, err, "amt_to_forward: tu64 not minimal")
+}
+
+func TestMessage(t *testing.T) {
+	msg, err := tlv.EncodeMessage(32769, []*tlv.Record{{Type: 1, Value: []byte{0xff}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "80010101ff", hex.EncodeToString(msg))
+
+	msgType, records, err := tlv.DecodeMessage(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(32769), msgType)
+	assert.Equal(t, []*tlv.Record{{Type: 1, Value: []byte{0xff}}}, records)
+
+	_, _, err = tlv.DecodeMessage([]byte{0x80})
+	assert.Error(t, err)
+}
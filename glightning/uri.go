@@ -0,0 +1,104 @@
+package glightning
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type PaymentCodeKind string
+
+const (
+	PaymentCodeBolt11 PaymentCodeKind = "bolt11"
+	PaymentCodeOffer  PaymentCodeKind = "offer"
+	PaymentCodeLnurl  PaymentCodeKind = "lnurl"
+)
+
+// Something a wallet can pay: a bolt11 invoice, a bolt12 offer or an
+// LNURL, as shared in a "lightning:" URI or QR code
+type PaymentCode struct {
+	Kind PaymentCodeKind
+	// The code itself, in lower case and with any '+' joins removed
+	Code string
+}
+
+// Classify {code}, a bare bolt11 invoice, offer or LNURL
+func NewPaymentCode(code string) (*PaymentCode, error) {
+	code = strings.ToLower(strings.Join(strings.Fields(code), ""))
+	if strings.HasPrefix(code, "lno1") {
+		// offers may be split with '+' joins; see DecodeOffer
+		code = strings.Replace(code, "+", "", -1)
+	}
+
+	var kind PaymentCodeKind
+	switch {
+	case strings.HasPrefix(code, "lno1"):
+		kind = PaymentCodeOffer
+	case strings.HasPrefix(code, "lnurl1"):
+		kind = PaymentCodeLnurl
+	case strings.HasPrefix(code, "lni1"), strings.HasPrefix(code, "lnr1"):
+		return nil, fmt.Errorf("Bolt12 invoices and invoice requests aren't shared for payment")
+	case strings.HasPrefix(code, "ln") && strings.Contains(code, "1"):
+		kind = PaymentCodeBolt11
+	default:
+		return nil, fmt.Errorf("Not a lightning payment code: %q", code)
+	}
+	return &PaymentCode{Kind: kind, Code: code}, nil
+}
+
+// Parse a "lightning:" URI, a BIP21 "bitcoin:" URI with a "lightning"
+// or "lno" parameter (preferring the offer if it has both), or a bare
+// code. The scheme is case insensitive, as uppercase QR payloads use
+// "LIGHTNING:".
+func ParsePaymentURI(uri string) (*PaymentCode, error) {
+	uri = strings.TrimSpace(uri)
+	lower := strings.ToLower(uri)
+	switch {
+	case strings.HasPrefix(lower, "lightning:"):
+		uri = uri[len("lightning:"):]
+		// some wallets write "lightning://"
+		uri = strings.TrimPrefix(uri, "//")
+	case strings.HasPrefix(lower, "bitcoin:"):
+		parts := strings.SplitN(uri, "?", 2)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("Bitcoin URI has no lightning payment: %q", uri)
+		}
+		query, err := url.ParseQuery(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		params := make(map[string]string)
+		for key, values := range query {
+			params[strings.ToLower(key)] = values[0]
+		}
+		if offer, ok := params["lno"]; ok {
+			return NewPaymentCode(offer)
+		}
+		if invoice, ok := params["lightning"]; ok {
+			return NewPaymentCode(invoice)
+		}
+		return nil, fmt.Errorf("Bitcoin URI has no lightning payment: %q", uri)
+	}
+	return NewPaymentCode(uri)
+}
+
+// The code as a "lightning:" URI, for links
+func (c *PaymentCode) URI() string {
+	return "lightning:" + c.Code
+}
+
+// The URI in upper case, for rendering as a QR code. Upper case
+// bech32 fits QR's alphanumeric mode, which makes for a noticeably
+// smaller, easier to scan code than the byte mode lower case needs.
+func (c *PaymentCode) QRPayload() string {
+	return strings.ToUpper(c.URI())
+}
+
+// The QR payload for {code}, a bolt11 invoice, offer or LNURL
+func QRPayload(code string) (string, error) {
+	pc, err := NewPaymentCode(code)
+	if err != nil {
+		return "", err
+	}
+	return pc.QRPayload(), nil
+}
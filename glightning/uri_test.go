@@ -0,0 +1,67 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+const uriInvoice = "lnbcrt100n1pj9xcfxpp5lz2x3"
+
+func TestParsePaymentURI(t *testing.T) {
+	for _, uri := range []string{
+		uriInvoice,
+		"lightning:" + uriInvoice,
+		"LIGHTNING:LNBCRT100N1PJ9XCFXPP5LZ2X3",
+		"lightning://" + uriInvoice,
+		"  lightning:" + uriInvoice + "\n",
+		"bitcoin:bcrt1qxyz?amount=0.00001&lightning=" + uriInvoice,
+		"BITCOIN:BCRT1QXYZ?LIGHTNING=LNBCRT100N1PJ9XCFXPP5LZ2X3",
+	} {
+		code, err := glightning.ParsePaymentURI(uri)
+		if assert.NoError(t, err, uri) {
+			assert.Equal(t, glightning.PaymentCodeBolt11, code.Kind, uri)
+			assert.Equal(t, uriInvoice, code.Code, uri)
+		}
+	}
+
+	code, err := glightning.ParsePaymentURI("bitcoin:bcrt1qxyz?lightning=" + uriInvoice + "&lno=" + testOffer)
+	assert.NoError(t, err)
+	assert.Equal(t, glightning.PaymentCodeOffer, code.Kind)
+	assert.Equal(t, testOffer, code.Code)
+
+	code, err = glightning.ParsePaymentURI("lightning:" + testOffer[:40] + "+\n  " + testOffer[40:])
+	assert.NoError(t, err)
+	assert.Equal(t, glightning.PaymentCodeOffer, code.Kind)
+	assert.Equal(t, testOffer, code.Code)
+
+	code, err = glightning.ParsePaymentURI("LIGHTNING:LNURL1DP68GURN8GHJ7UM9WFMXJCM99E3K7MF0V9CXJ0M385EKVCENXC6R2C35XVUKXEFCV5MKVV34X5EKZD3EV56NYD3HXQURZEPEXEJXXEPNXSCRVWFNV9NXZCN9XQ6XYEFHVGCXXCMYXYMNSERXFQ5FNS")
+	assert.NoError(t, err)
+	assert.Equal(t, glightning.PaymentCodeLnurl, code.Kind)
+
+	_, err = glightning.ParsePaymentURI("bitcoin:bcrt1qxyz?amount=1")
+	assert.EqualError(t, err, `Bitcoin URI has no lightning payment: "bitcoin:bcrt1qxyz?amount=1"`)
+	_, err = glightning.ParsePaymentURI("lightning:lni1qqg")
+	assert.Error(t, err)
+	_, err = glightning.ParsePaymentURI("https://example.com")
+	assert.Error(t, err)
+}
+
+func TestPaymentCodeQRPayload(t *testing.T) {
+	code, err := glightning.NewPaymentCode(uriInvoice)
+	assert.NoError(t, err)
+	assert.Equal(t, "lightning:"+uriInvoice, code.URI())
+	assert.Equal(t, "LIGHTNING:LNBCRT100N1PJ9XCFXPP5LZ2X3", code.QRPayload())
+
+	payload, err := glightning.QRPayload(testOffer)
+	assert.NoError(t, err)
+	// QR alphanumeric mode's characters only
+	for _, c := range payload {
+		assert.Contains(t, "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:", string(c))
+	}
+
+	parsed, err := glightning.ParsePaymentURI(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, testOffer, parsed.Code)
+}
@@ -0,0 +1,128 @@
+package glightning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elementsproject/glightning/jrpc2"
+)
+
+// A `warning_*` field in a result, e.g. an invoice's
+// warning_capacity, or getinfo's warning_bitcoind_sync
+type RpcWarning struct {
+	// The command whose result it was in
+	Method string
+	// Where in the result, e.g. "invoices[0].warning_capacity"
+	Field   string
+	Message string
+}
+
+func (w *RpcWarning) String() string {
+	return fmt.Sprintf("%s: %s: %s", w.Method, w.Field, w.Message)
+}
+
+// Call {cb} with each warning in the results of calls made from now
+// on, wherever in the result it is, so they're noticed even where
+// glightning has no field for them (a new warning, often ahead of a
+// deprecation, in a newer lightningd). A nil {cb} stops this.
+//
+//	lightning.OnWarning(func(w *glightning.RpcWarning) {
+//		log.Printf("lightningd warned: %s", w)
+//	})
+func (l *Lightning) OnWarning(cb func(*RpcWarning)) {
+	rpc := l.rpc
+	if w, ok := rpc.(*warningRequester); ok {
+		rpc = w.rpc
+	}
+	if cb == nil {
+		l.rpc = rpc
+		return
+	}
+	l.rpc = &warningRequester{rpc, cb}
+}
+
+// Looks for warnings in each raw result
+type warningRequester struct {
+	rpc requester
+	cb  func(*RpcWarning)
+}
+
+func (w *warningRequester) Request(m jrpc2.Method, resp interface{}) error {
+	_, err := w.RequestRaw(m, resp)
+	return err
+}
+
+func (w *warningRequester) RequestNoTimeout(m jrpc2.Method, resp interface{}) error {
+	_, err := w.RequestRawNoTimeout(m, resp)
+	return err
+}
+
+func (w *warningRequester) RequestCtx(ctx context.Context, m jrpc2.Method, resp interface{}) error {
+	_, err := w.RequestRawCtx(ctx, m, resp)
+	return err
+}
+
+func (w *warningRequester) RequestRaw(m jrpc2.Method, resp interface{}) (json.RawMessage, error) {
+	raw, err := w.rpc.RequestRaw(m, resp)
+	w.check(m, raw)
+	return raw, err
+}
+
+func (w *warningRequester) RequestRawNoTimeout(m jrpc2.Method, resp interface{}) (json.RawMessage, error) {
+	raw, err := w.rpc.RequestRawNoTimeout(m, resp)
+	w.check(m, raw)
+	return raw, err
+}
+
+func (w *warningRequester) RequestRawCtx(ctx context.Context, m jrpc2.Method, resp interface{}) (json.RawMessage, error) {
+	raw, err := w.rpc.RequestRawCtx(ctx, m, resp)
+	w.check(m, raw)
+	return raw, err
+}
+
+func (w *warningRequester) check(m jrpc2.Method, raw json.RawMessage) {
+	// cheap test first, as most results have none
+	if !bytes.Contains(raw, []byte(`"warning_`)) {
+		return
+	}
+	var result interface{}
+	if json.Unmarshal(raw, &result) != nil {
+		return
+	}
+	for _, warning := range findWarnings(result, "") {
+		warning.Method = m.Name()
+		w.cb(warning)
+	}
+}
+
+func findWarnings(val interface{}, path string) []*RpcWarning {
+	var warnings []*RpcWarning
+	switch v := val.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+			if msg, ok := v[key].(string); ok && strings.HasPrefix(key, "warning_") {
+				warnings = append(warnings, &RpcWarning{Field: field, Message: msg})
+				continue
+			}
+			warnings = append(warnings, findWarnings(v[key], field)...)
+		}
+	case []interface{}:
+		for i, item := range v {
+			warnings = append(warnings, findWarnings(item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return warnings
+}
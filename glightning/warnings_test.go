@@ -0,0 +1,53 @@
+package glightning_test
+
+import (
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnWarning(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"invoice","params":{"description":"desc","exposeprivatechannels":false,"label":"uniq","msatoshi":"1000"},"id":1}`
+	resp := wrapResult(1, `{
+  "payment_hash": "0213ca245ca23deccf62a64a298a988bbe42d6fc7620471129328c2faa3ccb7a",
+  "expires_at": 1546475890,
+  "bolt11": "lnbcrt10n1",
+  "warning_capacity": "Insufficient incoming channel capacity to pay invoice",
+  "warning_newfangled": "Something new"
+}`)
+	lightning, requestQ, replyQ := startupServer(t)
+	var warnings []*glightning.RpcWarning
+	lightning.OnWarning(func(w *glightning.RpcWarning) {
+		warnings = append(warnings, w)
+	})
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	invoice, err := lightning.Invoice(1000, "uniq", "desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Insufficient incoming channel capacity to pay invoice", invoice.WarningCapacity)
+	assert.Equal(t, []*glightning.RpcWarning{
+		{Method: "invoice", Field: "warning_capacity", Message: "Insufficient incoming channel capacity to pay invoice"},
+		{Method: "invoice", Field: "warning_newfangled", Message: "Something new"},
+	}, warnings)
+
+	req = `{"jsonrpc":"2.0","method":"listinvoices","params":{},"id":2}`
+	resp = wrapResult(2, `{"invoices": [{"label": "a"}, {"label": "b", "warning_offline": "Peer offline"}]}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err = lightning.ListInvoices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, warnings, 3)
+	assert.Equal(t, "invoices[1].warning_offline", warnings[2].Field)
+	assert.Equal(t, "listinvoices: invoices[1].warning_offline: Peer offline", warnings[2].String())
+
+	lightning.OnWarning(nil)
+	req = `{"jsonrpc":"2.0","method":"listinvoices","params":{},"id":3}`
+	resp = wrapResult(3, `{"invoices": [{"label": "b", "warning_offline": "Peer offline"}]}`)
+	go runServerSide(t, req, resp, replyQ, requestQ)
+	_, err = lightning.ListInvoices()
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 3)
+}
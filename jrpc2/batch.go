@@ -0,0 +1,114 @@
+package jrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Several requests, sent together as a JSON array
+type batchRequest []*Request
+
+// The outcome of one call in a batch
+type BatchResult struct {
+	// The call's result, as the server sent it, if it succeeded
+	Result json.RawMessage
+	// Why it failed: an *RpcError from the server, or ctx's error if
+	// no response came for it in time
+	Err error
+}
+
+// Decode a successful call's result into {resp}
+func (r *BatchResult) Decode(resp interface{}) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	return json.Unmarshal(r.Result, resp)
+}
+
+// Call every one of {methods} in a single batch. Results come back in
+// the same order as {methods}, however the server ordered its
+// responses. Times out after the client's timeout, like Request.
+func (c *Client) Batch(methods []Method) ([]*BatchResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout*time.Second)
+	defer cancel()
+	results, err := c.BatchCtx(ctx, methods)
+	if err == context.DeadlineExceeded {
+		return results, fmt.Errorf("Request timed out")
+	}
+	return results, err
+}
+
+// Batch, waiting for as long as {ctx} allows. If it's done before
+// every response has come, the calls still waiting have ctx.Err() as
+// their error, which is also returned.
+func (c *Client) BatchCtx(ctx context.Context, methods []Method) ([]*BatchResult, error) {
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("Must provide at least one method to batch")
+	}
+	batch := make(batchRequest, len(methods))
+	replyChans := make([]chan *RawResponse, len(methods))
+	spans := make([]Span, len(methods))
+	for i, m := range methods {
+		batch[i] = &Request{c.NextId(), m}
+		replyChans[i] = make(chan *RawResponse, 1)
+		c.pending.Store(batch[i].Id.Val(), replyChans[i])
+		c.callStarted()
+		spans[i] = c.startSpan(ctx, batch[i])
+	}
+	forget := func() {
+		for _, req := range batch {
+			c.pending.Delete(req.Id.Val())
+		}
+	}
+
+	if err := c.send(ctx, batch); err != nil {
+		forget()
+		for i, req := range batch {
+			c.callDone(req, time.Now(), nil, err)
+			spans[i].End(err)
+		}
+		return nil, err
+	}
+	sent := time.Now()
+
+	results := make([]*BatchResult, len(methods))
+	for i, replyChan := range replyChans {
+		select {
+		case rawResp := <-replyChan:
+			results[i] = batchResult(rawResp)
+			c.callDone(batch[i], sent, rawResp, results[i].Err)
+			spans[i].End(results[i].Err)
+		case <-ctx.Done():
+			forget()
+			for j := i; j < len(results); j++ {
+				select {
+				case rawResp := <-replyChans[j]:
+					results[j] = batchResult(rawResp)
+					c.callDone(batch[j], sent, rawResp, results[j].Err)
+				default:
+					results[j] = &BatchResult{Err: ctx.Err()}
+					c.callDone(batch[j], sent, nil, ctx.Err())
+				}
+				spans[j].End(results[j].Err)
+			}
+			return results, ctx.Err()
+		}
+	}
+	return results, nil
+}
+
+func batchResult(rawResp *RawResponse) *BatchResult {
+	if rawResp == nil {
+		return &BatchResult{Err: fmt.Errorf("Pipe closed unexpectedly, nil result")}
+	}
+	if rawResp.Error != nil {
+		// the error is decoded apart from the buffer
+		rawResp.release()
+		return &BatchResult{Err: rawResp.Error}
+	}
+	// the result is kept, so its buffer can't go back to the pool
+	rawResp.buf = nil
+	return &BatchResult{Result: rawResp.Raw}
+}
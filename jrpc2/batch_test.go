@@ -0,0 +1,58 @@
+package jrpc2_test
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientBatch(t *testing.T) {
+	s, in, out := setupServer(t)
+	s.Register(&Subtract{})
+	client := jrpc2.NewClient()
+	go client.StartUp(in, out)
+
+	results, err := client.Batch([]jrpc2.Method{
+		&ClientSubtract{8, 2},
+		&ClientAdd{},
+		&ClientSubtract{8, 5},
+	})
+	assert.Nil(t, err)
+	if assert.Len(t, results, 3) {
+		var answer int
+		assert.Nil(t, results[0].Decode(&answer))
+		assert.Equal(t, 6, answer)
+		assert.EqualError(t, results[1].Err, "-32601:Method not found")
+		assert.EqualError(t, results[1].Decode(&answer), "-32601:Method not found")
+		assert.Equal(t, "3", string(results[2].Result))
+	}
+
+	_, err = client.Batch(nil)
+	assert.EqualError(t, err, "Must provide at least one method to batch")
+
+	// single requests still work alongside
+	answer, err := subtract(client, 3, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, answer)
+}
+
+func TestServerBatch(t *testing.T) {
+	s, in, out := setupServer(t)
+	s.Register(&Subtract{})
+	reader := bufio.NewReader(in)
+
+	// the notification gets no response
+	out.Write([]byte(`[{"jsonrpc":"2.0","method":"subtract","params":[1,1]},{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":5},{"jsonrpc":"2.0","method":"foobar","id":6}]` + "\n\n"))
+	line, err := reader.ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"jsonrpc":"2.0","result":19,"id":5},{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":6}]`, strings.TrimSpace(line))
+	reader.ReadString('\n')
+
+	out.Write([]byte("[]\n\n"))
+	line, err = reader.ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`, strings.TrimSpace(line))
+}
@@ -0,0 +1,148 @@
+package jrpc2
+
+import (
+	"bufio"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Chaos describes the misbehaviour of a flaky server, for testing how
+// a client (and the application using it) copes. Wrap a connection to
+// a server, such as a ReplayServer's Pipe, and the frames read back
+// from it are delayed, reordered, dropped or cut short:
+//
+//	conn := jrpc2.Chaos{DropRate: 0.1, MaxLatency: time.Second}.Wrap(server.Pipe())
+//	go client.ConnStart(conn, nil)
+//
+// Rates are probabilities, from 0 to 1, applied to each frame.
+type Chaos struct {
+	// Each frame is delayed by a random time between these
+	MinLatency time.Duration
+	MaxLatency time.Duration
+	// Frames never delivered
+	DropRate float64
+	// Frames held back and delivered after the next one
+	ReorderRate float64
+	// Frames cut off partway, as if the connection failed mid-write;
+	// a client can't make sense of anything after one
+	TruncateRate float64
+	// Seeds the random choices, so a failing run can be repeated
+	Seed int64
+}
+
+// What a ChaosConn has done to the frames read through it
+type ChaosStats struct {
+	Frames    uint64
+	Delayed   uint64
+	Dropped   uint64
+	Reordered uint64
+	Truncated uint64
+}
+
+// A connection whose reads suffer the chaos it was wrapped with.
+// Writes go straight through.
+type ChaosConn struct {
+	chaos Chaos
+	conn  io.ReadWriteCloser
+	rand  *rand.Rand
+	in    *io.PipeReader
+	out   *io.PipeWriter
+
+	mu    sync.Mutex
+	stats ChaosStats
+}
+
+func (c Chaos) Wrap(conn io.ReadWriteCloser) *ChaosConn {
+	in, out := io.Pipe()
+	cc := &ChaosConn{
+		chaos: c,
+		conn:  conn,
+		rand:  rand.New(rand.NewSource(c.Seed)),
+		in:    in,
+		out:   out,
+	}
+	go cc.relay()
+	return cc
+}
+
+func (cc *ChaosConn) Read(p []byte) (int, error) {
+	return cc.in.Read(p)
+}
+
+func (cc *ChaosConn) Write(p []byte) (int, error) {
+	return cc.conn.Write(p)
+}
+
+func (cc *ChaosConn) Close() error {
+	cc.in.Close()
+	return cc.conn.Close()
+}
+
+func (cc *ChaosConn) Stats() ChaosStats {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.stats
+}
+
+func (cc *ChaosConn) count(stat *uint64) {
+	cc.mu.Lock()
+	*stat++
+	cc.mu.Unlock()
+}
+
+func (cc *ChaosConn) chance(rate float64) bool {
+	return rate > 0 && cc.rand.Float64() < rate
+}
+
+// Read frames off the wrapped connection, passing them on to Read
+// once they've been messed with
+func (cc *ChaosConn) relay() {
+	scanner := bufio.NewScanner(cc.conn)
+	scanner.Buffer(make([]byte, 1024), MaxIntakeBuffer)
+	scanner.Split(scanDoubleNewline)
+	var held []byte
+	for scanner.Scan() {
+		frame := append(append([]byte(nil), scanner.Bytes()...), '\n', '\n')
+		cc.count(&cc.stats.Frames)
+		if cc.chance(cc.chaos.DropRate) {
+			cc.count(&cc.stats.Dropped)
+			continue
+		}
+		if len(frame) > 2 && cc.chance(cc.chaos.TruncateRate) {
+			frame = frame[:cc.rand.Intn(len(frame)-2)]
+			cc.count(&cc.stats.Truncated)
+		}
+		if delay := cc.latency(); delay > 0 {
+			time.Sleep(delay)
+			cc.count(&cc.stats.Delayed)
+		}
+		if held == nil && cc.chance(cc.chaos.ReorderRate) {
+			held = frame
+			cc.count(&cc.stats.Reordered)
+			continue
+		}
+		if _, err := cc.out.Write(frame); err != nil {
+			return
+		}
+		if held != nil {
+			if _, err := cc.out.Write(held); err != nil {
+				return
+			}
+			held = nil
+		}
+	}
+	if held != nil {
+		cc.out.Write(held)
+	}
+	cc.out.CloseWithError(scanner.Err())
+}
+
+func (cc *ChaosConn) latency() time.Duration {
+	delay := cc.chaos.MinLatency
+	if spread := cc.chaos.MaxLatency - cc.chaos.MinLatency; spread > 0 {
+		delay += time.Duration(cc.rand.Int63n(int64(spread)))
+	}
+	return delay
+}
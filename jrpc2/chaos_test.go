@@ -0,0 +1,85 @@
+package jrpc2_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+func chaosServer(t *testing.T) *jrpc2.ReplayServer {
+	fixtures, err := jrpc2.ReadFixtures(strings.NewReader(`
+{"method":"subtract","params":{"minuend":8,"subtrahend":2},"result":6}
+{"method":"subtract","params":{"minuend":8,"subtrahend":1},"result":7}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jrpc2.NewReplayServer(fixtures...)
+}
+
+func TestChaosLatencyAndReorder(t *testing.T) {
+	conn := jrpc2.Chaos{
+		MinLatency:  5 * time.Millisecond,
+		MaxLatency:  10 * time.Millisecond,
+		ReorderRate: 1,
+	}.Wrap(chaosServer(t).Pipe())
+	client := jrpc2.NewClient()
+	go client.ConnStart(conn, nil)
+	defer client.Shutdown()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	answers := make([]int, 2)
+	errs := make([]error, 2)
+	for i, subtrahend := range []int{2, 1} {
+		wg.Add(1)
+		go func(i, subtrahend int) {
+			defer wg.Done()
+			answers[i], errs[i] = subtract(client, 8, subtrahend)
+		}(i, subtrahend)
+	}
+	wg.Wait()
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, []int{6, 7}, answers)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+
+	stats := conn.Stats()
+	assert.Equal(t, uint64(2), stats.Frames)
+	assert.Equal(t, uint64(2), stats.Delayed)
+	assert.Equal(t, uint64(1), stats.Reordered)
+}
+
+func TestChaosDrop(t *testing.T) {
+	conn := jrpc2.Chaos{DropRate: 1}.Wrap(chaosServer(t).Pipe())
+	client := jrpc2.NewClient()
+	go client.ConnStart(conn, nil)
+	defer client.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var answer int
+	err := client.RequestCtx(ctx, &ClientSubtract{8, 2}, &answer)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, uint64(1), conn.Stats().Dropped)
+}
+
+func TestChaosTruncate(t *testing.T) {
+	conn := jrpc2.Chaos{TruncateRate: 1, Seed: 7}.Wrap(chaosServer(t).Pipe())
+	client := jrpc2.NewClient()
+	go client.ConnStart(conn, nil)
+
+	defer client.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var answer int
+	err := client.RequestCtx(ctx, &ClientSubtract{8, 2}, &answer)
+	// the client waits for the rest of the cut-off frame
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, uint64(1), conn.Stats().Truncated)
+}
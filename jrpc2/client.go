@@ -2,10 +2,11 @@ package jrpc2
 
 import (
 	"bufio"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"os"
 	"sync"
@@ -17,21 +18,45 @@ import (
 // - 'call' a method which is really...
 // - fire off a request
 // - receive a result back (& match that result to outbound request)
-// bonus round:
-//    - send and receive in batches
+// - send and receive in batches
 
+// Many goroutines may make calls on one client at once. Each call
+// registers a reply channel in pending under its request's id, and
+// whoever takes the channel back out of pending (with LoadAndDelete)
+// owns it: the reader delivering the response, Shutdown failing the
+// call, or the caller itself giving up. So exactly one reply, or
+// none, is ever sent on it, and it's never closed.
+//
+// A running connection is a session, ended by closing its done
+// channel; the write loop and callers waiting to send select on it,
+// so requestQueue itself is never closed.
 type Client struct {
-	requestQueue   chan *Request
-	pending        sync.Map // map[string]chan *RawResponse
+	requestQueue   chan interface{} // *Request, or batchRequest
+	pending        sync.Map         // map[string]chan *RawResponse
 	requestCounter int64
-	shutdown       bool
+	waiting        int64 // calls waiting on a response, for metrics
 	timeout        time.Duration
+	// decodes a result into the caller's response object
+	unmarshal func(data []byte, v interface{}) error
+	// handlers for notifications from the server, by method
+	notifyHandlers sync.Map // map[string]func(json.RawMessage)
+	logger         Logger
+	metrics        Metrics
+	tracer         Tracer
+
+	mu       sync.Mutex
+	shutdown bool
+	done     chan struct{}
+	// interceptors, run in the order they were added
+	requestHooks  []func(*Request)
+	responseHooks []func(*RawResponse)
 }
 
 func NewClient() *Client {
 	client := &Client{}
-	client.requestQueue = make(chan *Request)
+	client.requestQueue = make(chan interface{})
 	client.timeout = time.Duration(20)
+	client.done = make(chan struct{})
 	return client
 }
 
@@ -39,66 +64,231 @@ func (c *Client) SetTimeout(secs uint) {
 	c.timeout = time.Duration(secs)
 }
 
+// Replace the function used to decode a call's result into the
+// response object. Passing nil restores the default, json.Unmarshal
+func (c *Client) SetUnmarshaler(fn func(data []byte, v interface{}) error) {
+	c.unmarshal = fn
+}
+
+// Send what the client logs to {logger}, instead of the stdlib's log.
+// Passing nil restores the default. Set it before starting up.
+func (c *Client) SetLogger(logger Logger) {
+	c.logger = logger
+}
+
+func (c *Client) log() Logger {
+	return orStdLogger(c.logger)
+}
+
+// Run {hook} on every request (and notification) before it's sent,
+// e.g. to log it, count it, or rewrite its Method. Hooks run in the
+// order they're added, on the caller's goroutine, and each sees what
+// the one before left.
+func (c *Client) OnRequest(hook func(req *Request)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requestHooks = append(c.requestHooks, hook)
+}
+
+// Run {hook} on every response from the server before it's handed to
+// its caller, e.g. to log it or time it, or to rewrite its result or
+// error. Hooks run in the order they're added. Raw may be backed by
+// a pooled buffer, so a hook mustn't keep it once it returns;
+// replacing it with a fresh slice is fine.
+func (c *Client) OnResponse(hook func(resp *RawResponse)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responseHooks = append(c.responseHooks, hook)
+}
+
+func (c *Client) interceptRequest(req *Request) {
+	c.mu.Lock()
+	hooks := c.requestHooks
+	c.mu.Unlock()
+	for _, hook := range hooks {
+		hook(req)
+	}
+}
+
+func (c *Client) interceptResponse(resp *RawResponse) {
+	c.mu.Lock()
+	hooks := c.responseHooks
+	c.mu.Unlock()
+	for _, hook := range hooks {
+		hook(resp)
+	}
+}
+
+// Begin a new session, returning its done channel
+func (c *Client) start() chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.shutdown {
+		c.shutdown = false
+		c.done = make(chan struct{})
+	}
+	return c.done
+}
+
+// The current session's done channel, and whether it's running
+func (c *Client) session() (chan struct{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done, !c.shutdown
+}
+
 func (c *Client) StartUp(in, out *os.File) {
-	c.shutdown = false
-	go c.setupWriteQueue(out)
-	c.readQueue(in)
+	done := c.start()
+	go c.setupWriteQueue(out, done)
+	c.readQueue(in, done)
 }
 
 // Start up on a socket, instead of using pipes
 // This method blocks. The up channel is an optional
 // channel to receive  notification when the connection is set up
 func (c *Client) SocketStart(socket string, up chan bool) error {
-	c.shutdown = false
 	conn, err := net.Dial("unix", socket)
 	if err != nil {
 		return fmt.Errorf("Unable to dial socket %s:%s", socket, err.Error())
 	}
+	c.ConnStart(conn, up)
+	return nil
+}
+
+// Dial lightningd's unix socket (lightning-rpc, in its network
+// directory) and start talking over it in the background. Unlike
+// SocketStart, returns as soon as the connection is up.
+func (c *Client) StartUpSocket(path string) error {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return fmt.Errorf("Unable to dial socket %s:%s", path, err.Error())
+	}
+	go c.serve(conn, c.start(), nil)
+	return nil
+}
+
+// Dial a JSON-RPC endpoint over TCP, such as lightning-rpc forwarded
+// by socat, and start talking over it in the background. With a
+// {tlsConfig} the connection uses TLS; give it Certificates for
+// mutual TLS.
+func (c *Client) StartUpTCP(addr string, tlsConfig *tls.Config) error {
+	var conn net.Conn
+	var err error
+	if tlsConfig != nil {
+		conn, err = tls.Dial("tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to dial %s:%s", addr, err.Error())
+	}
+	go c.serve(conn, c.start(), nil)
+	return nil
+}
+
+// Start up on an already open connection, such as one end of a pipe
+// to a ReplayServer. Blocks like SocketStart, closing {conn} when the
+// client shuts down.
+func (c *Client) ConnStart(conn io.ReadWriteCloser, up chan bool) {
+	c.serve(conn, c.start(), up)
+}
+
+func (c *Client) serve(conn io.ReadWriteCloser, done chan struct{}, up chan bool) {
 	defer conn.Close()
-	go func(conn net.Conn, up chan bool) {
+	go func(conn io.Reader, up chan bool) {
 		if up != nil {
 			up <- true
 		}
-		c.readQueue(conn)
+		c.readQueue(conn, done)
 	}(conn, up)
-	c.setupWriteQueue(conn)
-	return nil
+	c.setupWriteQueue(conn, done)
 }
 
 func (c *Client) Shutdown() {
+	done, _ := c.session()
+	c.endSession(done)
+}
+
+// End the session {done} belongs to, if it's still the current one,
+// failing every call waiting on a reply
+func (c *Client) endSession(done chan struct{}) {
+	c.mu.Lock()
+	if c.shutdown || c.done != done {
+		c.mu.Unlock()
+		return
+	}
 	c.shutdown = true
-	close(c.requestQueue)
+	close(done)
+	c.mu.Unlock()
+
 	c.pending.Range(func(key, value interface{}) bool {
-		v_chan, ok := value.(chan *RawResponse)
-		if !ok {
-			panic("value not chan *RawResponse")
+		if replyChan, ok := c.pending.LoadAndDelete(key); ok {
+			// a nil response fails the call
+			replyChan.(chan *RawResponse) <- nil
 		}
-		close(v_chan)
-		c.pending.Delete(key)
 		return true
 	})
-	c.requestQueue = make(chan *Request)
 }
 
 func (c *Client) IsUp() bool {
-	return !c.shutdown
+	_, up := c.session()
+	return up
+}
+
+// Send {req} to the write loop, unless the client shuts down or
+// {ctx} is done first
+func (c *Client) send(ctx context.Context, req interface{}) error {
+	done, up := c.session()
+	if !up {
+		return fmt.Errorf("Client is shutdown")
+	}
+	switch r := req.(type) {
+	case *Request:
+		c.interceptRequest(r)
+	case batchRequest:
+		for _, each := range r {
+			c.interceptRequest(each)
+		}
+	}
+	select {
+	case c.requestQueue <- req:
+		switch r := req.(type) {
+		case *Request:
+			c.requestSent(r)
+		case batchRequest:
+			for _, each := range r {
+				c.requestSent(each)
+			}
+		}
+		return nil
+	case <-done:
+		return fmt.Errorf("Client is shutdown")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (c *Client) setupWriteQueue(outW io.Writer) {
+func (c *Client) setupWriteQueue(outW io.Writer, done chan struct{}) {
 	out := bufio.NewWriter(outW)
 	defer out.Flush()
 	twoNewlines := []byte("\n\n")
-	for request := range c.requestQueue {
+	for {
+		var request interface{}
+		select {
+		case request = <-c.requestQueue:
+		case <-done:
+			return
+		}
 		data, err := json.Marshal(request)
 		if err != nil {
 			// todo: send error back to waiting response
 			// iff it's got an id associated with it
-			log.Println(err.Error())
+			c.log().Error(err.Error())
 			continue
 		}
 
 		if debugIO(false) {
-			log.Println(string(data))
+			c.log().Debug(string(data))
 		}
 		data = append(data, twoNewlines...)
 		out.Write(data)
@@ -106,101 +296,207 @@ func (c *Client) setupWriteQueue(outW io.Writer) {
 	}
 }
 
-func (c *Client) readQueue(in io.Reader) {
+// Responses are read whole into a pooled frame buffer, then their
+// result is copied into another pooled buffer, which is handed back
+// once the result has been decoded (see handleReply). The decoder,
+// and its own read buffer, are kept for the life of the connection.
+func (c *Client) readQueue(in io.Reader, done chan struct{}) {
+	// there's a problem with the input, or it's closed: shutdown
+	defer c.endSession(done)
 	decoder := json.NewDecoder(in)
-	for !c.shutdown {
-		var rawResp RawResponse
-		if err := decoder.Decode(&rawResp); err == io.EOF {
-			c.Shutdown()
-			break
+	frame := getBuffer()
+	defer putBuffer(frame)
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if err := decoder.Decode((*json.RawMessage)(frame)); err == io.EOF {
+			return
 		} else if err != nil {
-			log.Print(err.Error())
-			break
+			c.log().Error(err.Error())
+			return
+		}
+		if err := c.readFrame(*frame); err != nil {
+			c.log().Error(err.Error())
+			return
 		}
-		go processResponse(c, &rawResp)
 	}
+}
 
-	// there's a problem with the input, shutdown
-	c.Shutdown()
+// Hand the response, or batch of them, in {frame} to its caller
+func (c *Client) readFrame(frame []byte) error {
+	if len(frame) > 0 && frame[0] == '[' {
+		return c.readBatch(frame)
+	}
+	buf := getBuffer()
+	rawResp := &RawResponse{Raw: *buf, buf: buf}
+	if err := rawResp.UnmarshalJSON(frame); err != nil {
+		rawResp.release()
+		return err
+	}
+	go processResponse(c, rawResp)
+	return nil
+}
+
+// Hand each response of a batch to its caller
+func (c *Client) readBatch(frame []byte) error {
+	var elems []json.RawMessage
+	if err := json.Unmarshal(frame, &elems); err != nil {
+		return err
+	}
+	for _, elem := range elems {
+		rawResp := &RawResponse{}
+		if err := rawResp.UnmarshalJSON(elem); err != nil {
+			return err
+		}
+		go processResponse(c, rawResp)
+	}
+	return nil
+}
+
+// Handle the server's notifications of {method}, by calling
+// {handler} with their params. Notifications are handled as they
+// come, each on its own goroutine, so handlers may run concurrently
+// and out of order. A nil {handler} removes the method's handler.
+// Notifications without a handler are logged and dropped.
+func (c *Client) OnNotification(method string, handler func(params json.RawMessage)) {
+	if handler == nil {
+		c.notifyHandlers.Delete(method)
+		return
+	}
+	c.notifyHandlers.Store(method, handler)
 }
 
 func processResponse(c *Client, resp *RawResponse) {
+	if resp.method != "" && resp.Id == nil {
+		resp.release()
+		handler, exists := c.notifyHandlers.Load(resp.method)
+		if !exists {
+			c.log().Info(fmt.Sprintf("No handler for notification %s", resp.method))
+			return
+		}
+		handler.(func(json.RawMessage))(resp.params)
+		return
+	}
+
 	// the response should have an ID
 	if resp.Id == nil || resp.Id.Val() == "" {
 		// no id means there's no one listening
 		// for this to come back through ...
-		log.Printf("No Id provided %v", resp)
+		c.log().Error(fmt.Sprintf("No Id provided %v", resp))
 		return
 	}
 
+	c.interceptResponse(resp)
 	id := resp.Id.Val()
 	// look up 'reply channel' via the
 	// client (should have a registry of
 	// resonses that are waiting...)
-	respChan, exists := c.pending.Load(id)
+	respChan, exists := c.pending.LoadAndDelete(id)
 	if !exists {
-		log.Printf("No return channel found for response with id %s", id)
+		c.log().Info(fmt.Sprintf("No return channel found for response with id %s", id))
+		resp.release()
 		return
 	}
 	respChan.(chan *RawResponse) <- resp
-	c.pending.Delete(id)
 }
 
 // Sends a notification to the server. No response is expected,
 // and no ID is assigned to the request.
 func (c *Client) Notify(m Method) error {
-	if c.shutdown {
-		return fmt.Errorf("Client is shutdown")
-	}
-	req := &Request{nil, m}
-	c.requestQueue <- req
-	return nil
+	return c.send(context.Background(), &Request{nil, m})
 }
 
 // Isses an RPC call. Is blocking. Times out after {timeout}
 // seconds (set on client).
 func (c *Client) Request(m Method, resp interface{}) error {
-	if c.shutdown {
-		return fmt.Errorf("Client is shutdown")
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout*time.Second)
+	defer cancel()
+	err := c.RequestCtx(ctx, m, resp)
+	if err == context.DeadlineExceeded {
+		return fmt.Errorf("Request timed out")
 	}
+	return err
+}
+
+// Hangs until a response comes. Be aware that this may never
+// terminate.
+func (c *Client) RequestNoTimeout(m Method, resp interface{}) error {
+	return c.RequestCtx(context.Background(), m, resp)
+}
+
+// RequestCtx, by the name other context-aware APIs use
+func (c *Client) RequestWithContext(ctx context.Context, m Method, resp interface{}) error {
+	return c.RequestCtx(ctx, m, resp)
+}
+
+// Like Request, but waits for as long as {ctx} allows instead of
+// the client's timeout, returning ctx.Err() if it's done first;
+// cancelling {ctx} abandons the call. Request and RequestNoTimeout
+// are this with a deadline of the client's timeout, and with none.
+func (c *Client) RequestCtx(ctx context.Context, m Method, resp interface{}) error {
 	id := c.NextId()
 	// set up to get a response back
 	replyChan := make(chan *RawResponse, 1)
 	c.pending.Store(id.Val(), replyChan)
+	c.callStarted()
+	req := &Request{id, m}
+	span := c.startSpan(ctx, req)
 
 	// send the request out
-	req := &Request{id, m}
-	c.requestQueue <- req
+	if err := c.send(ctx, req); err != nil {
+		c.pending.Delete(id.Val())
+		c.callDone(req, time.Now(), nil, err)
+		span.End(err)
+		return err
+	}
+	sent := time.Now()
 
 	select {
 	case rawResp := <-replyChan:
-		return handleReply(rawResp, resp)
-	case <-time.After(c.timeout * time.Second):
+		err := c.handleReply(rawResp, resp)
+		c.callDone(req, sent, rawResp, err)
+		span.End(err)
+		return err
+	case <-ctx.Done():
 		c.pending.Delete(id.Val())
-		return fmt.Errorf("Request timed out")
+		c.callDone(req, sent, nil, ctx.Err())
+		span.End(ctx.Err())
+		return ctx.Err()
 	}
 }
 
-// Hangs until a response comes. Be aware that this may never
-// terminate.
-func (c *Client) RequestNoTimeout(m Method, resp interface{}) error {
-	if c.shutdown {
-		return fmt.Errorf("Client is shutdown")
-	}
-	id := c.NextId()
-	// set up to get a response back
-	replyChan := make(chan *RawResponse, 1)
-	c.pending.Store(id.Val(), replyChan)
+// Like Request, but also returns the result exactly as the server
+// sent it. {resp} may be nil, if only the raw result is wanted.
+func (c *Client) RequestRaw(m Method, resp interface{}) (json.RawMessage, error) {
+	result := &rawResult{resp: resp}
+	err := c.Request(m, result)
+	return result.raw, err
+}
 
-	// send the request out
-	req := &Request{id, m}
-	c.requestQueue <- req
+// RequestRaw without the timeout
+func (c *Client) RequestRawNoTimeout(m Method, resp interface{}) (json.RawMessage, error) {
+	result := &rawResult{resp: resp}
+	err := c.RequestNoTimeout(m, result)
+	return result.raw, err
+}
+
+// RequestRaw, waiting for as long as {ctx} allows
+func (c *Client) RequestRawCtx(ctx context.Context, m Method, resp interface{}) (json.RawMessage, error) {
+	result := &rawResult{resp: resp}
+	err := c.RequestCtx(ctx, m, result)
+	return result.raw, err
+}
 
-	rawResp := <-replyChan
-	return handleReply(rawResp, resp)
+// Asks handleReply to keep the raw result, as well as decoding it
+type rawResult struct {
+	raw  json.RawMessage
+	resp interface{}
 }
 
-func handleReply(rawResp *RawResponse, resp interface{}) error {
+func (c *Client) handleReply(rawResp *RawResponse, resp interface{}) error {
 	if rawResp == nil {
 		return fmt.Errorf("Pipe closed unexpectedly, nil result")
 	}
@@ -209,18 +505,35 @@ func handleReply(rawResp *RawResponse, resp interface{}) error {
 	// that we should parse into an 'error' (depending on the code?)
 	if rawResp.Error != nil {
 		if debugIO(true) {
-			log.Printf("%d:%s", rawResp.Error.Code, rawResp.Error.Message)
-			log.Println(string(rawResp.Error.Data))
+			c.log().Debug(fmt.Sprintf("%d:%s", rawResp.Error.Code, rawResp.Error.Message))
+			c.log().Debug(string(rawResp.Error.Data))
 		}
+		rawResp.release()
 		return rawResp.Error
 	}
 
 	if debugIO(true) {
-		log.Println(string(rawResp.Raw))
+		c.log().Debug(string(rawResp.Raw))
 	}
 
 	// or a raw response, that we should json map into the
 	// provided resp (interface)
+	if result, ok := resp.(*rawResult); ok {
+		// the caller keeps the raw result, so its buffer can't go
+		// back to the pool
+		rawResp.buf = nil
+		result.raw = rawResp.Raw
+		if result.resp == nil {
+			return nil
+		}
+		resp = result.resp
+	}
+	// decoding copies what it needs out of Raw, so its buffer can be
+	// reused once it's done
+	defer rawResp.release()
+	if c.unmarshal != nil {
+		return c.unmarshal(rawResp.Raw, resp)
+	}
 	return json.Unmarshal(rawResp.Raw, resp)
 }
 
@@ -0,0 +1,122 @@
+package jrpc2_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/elementsproject/glightning/glightning"
+	"github.com/elementsproject/glightning/jrpc2"
+)
+
+// A listchannels result of {n} channel halves, around 600 bytes each
+func listChannelsResult(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"channels":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, `{"source":"02%064x","destination":"03%064x","short_channel_id":"%dx%dx0","public":true,"amount_msat":"%dmsat","message_flags":1,"channel_flags":%d,"active":true,"last_update":1700000000,"base_fee_millisatoshi":1000,"fee_per_millionth":%d,"delay":40,"htlc_minimum_msat":"1000msat","htlc_maximum_msat":"990000000msat","features":""}`,
+			i, i+1, 700000+i/10, i%10, 1000000000+i, i%2, i%500)
+	}
+	buf.WriteString(`]}`)
+	return buf.Bytes()
+}
+
+// A listpeers result of {n} peers, each with a channel
+func listPeersResult(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"peers":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, `{"id":"02%064x","connected":true,"netaddr":["127.0.0.1:%d"],"features":"08a0000a0a69a2","channels":[{"state":"CHANNELD_NORMAL","short_channel_id":"%dx1x0","direction":1,"channel_id":"%064x","funding_txid":"%064x","private":false,"to_us_msat":"%dmsat","total_msat":"2000000000msat","spendable_msat":"900000000msat","receivable_msat":"900000000msat","their_to_self_delay":144,"our_to_self_delay":144,"max_accepted_htlcs":483,"htlcs":[],"fee_base_msat":"1000msat","fee_proportional_millionths":10}]}`,
+			i, 9735+i, 700000+i, i, i, 1000000000+i)
+	}
+	buf.WriteString(`]}`)
+	return buf.Bytes()
+}
+
+type listChannelsBench struct{}
+
+func (r *listChannelsBench) Name() string {
+	return "listchannels"
+}
+
+// Answer every request on {serverIn} with {result}
+func serveResult(b *testing.B, serverIn, serverOut *os.File, result []byte) {
+	decoder := json.NewDecoder(serverIn)
+	prefix := []byte(`{"jsonrpc":"2.0","id":`)
+	middle := []byte(`,"result":`)
+	suffix := []byte("}\n\n")
+	var req struct {
+		Id json.RawMessage `json:"id"`
+	}
+	for {
+		if err := decoder.Decode(&req); err != nil {
+			return
+		}
+		serverOut.Write(prefix)
+		serverOut.Write(req.Id)
+		serverOut.Write(middle)
+		serverOut.Write(result)
+		serverOut.Write(suffix)
+	}
+}
+
+func benchmarkRequest(b *testing.B, result []byte, resp func() interface{}) {
+	serverIn, out, err := os.Pipe()
+	if err != nil {
+		b.Fatal(err)
+	}
+	in, serverOut, err := os.Pipe()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer serverOut.Close()
+	defer out.Close()
+	go serveResult(b, serverIn, serverOut, result)
+
+	client := jrpc2.NewClient()
+	go client.StartUp(in, out)
+	defer client.Shutdown()
+
+	b.SetBytes(int64(len(result)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := client.Request(&listChannelsBench{}, resp()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkListChannels(b *testing.B) {
+	result := listChannelsResult(10000)
+	benchmarkRequest(b, result, func() interface{} {
+		return &struct {
+			Channels []*glightning.Channel `json:"channels"`
+		}{}
+	})
+}
+
+func BenchmarkListPeers(b *testing.B) {
+	result := listPeersResult(2000)
+	benchmarkRequest(b, result, func() interface{} {
+		return &struct {
+			Peers []*glightning.Peer `json:"peers"`
+		}{}
+	})
+}
+
+// The read path alone, with the result decoded into nothing
+func BenchmarkListChannelsRaw(b *testing.B) {
+	result := listChannelsResult(10000)
+	benchmarkRequest(b, result, func() interface{} {
+		return &struct{}{}
+	})
+}
@@ -2,9 +2,15 @@ package jrpc2_test
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"net"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -173,6 +179,127 @@ func TestClientNoId(t *testing.T) {
 	}
 }
 
+func TestClientOnNotification(t *testing.T) {
+	in, out, serverIn, serverOut := setupWritePipes(t)
+	client := jrpc2.NewClient()
+	received := make(chan string, 1)
+	client.OnNotification("block_added", func(params json.RawMessage) {
+		received <- string(params)
+	})
+	go client.StartUp(in, out)
+
+	serverOut.Write([]byte(`{"jsonrpc":"2.0","method":"block_added","params":{"height":850000}}` + "\n\n"))
+	select {
+	case params := <-received:
+		assert.Equal(t, `{"height":850000}`, params)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for notification")
+	}
+
+	// the client is still up, and answers requests as before
+	go func() {
+		reader := bufio.NewReader(serverIn)
+		reader.ReadString('\n')
+		serverOut.Write([]byte(`{"jsonrpc":"2.0","result":4,"id":1}` + "\n\n"))
+	}()
+	answer, err := subtract(client, 5, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, answer)
+
+	client.OnNotification("block_added", nil)
+	logs := overrideLogger(t)
+	defer resetLogger()
+	serverOut.Write([]byte(`{"jsonrpc":"2.0","method":"block_added","params":{"height":850001}}` + "\n\n"))
+	buf := make([]byte, 1024)
+	n, _ := logs.Read(buf)
+	assert.Equal(t, "No handler for notification block_added\n", string(buf[20:n]))
+}
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []string
+	logged  chan struct{}
+}
+
+func (l *recordingLogger) record(level, msg string) {
+	l.mu.Lock()
+	l.entries = append(l.entries, level+": "+msg)
+	l.mu.Unlock()
+	l.logged <- struct{}{}
+}
+
+func (l *recordingLogger) Debug(msg string) { l.record("debug", msg) }
+func (l *recordingLogger) Info(msg string)  { l.record("info", msg) }
+func (l *recordingLogger) Error(msg string) { l.record("error", msg) }
+
+func TestClientSetLogger(t *testing.T) {
+	in, out, _, serverOut := setupWritePipes(t)
+	logger := &recordingLogger{logged: make(chan struct{}, 4)}
+	client := jrpc2.NewClient()
+	client.SetLogger(logger)
+	go client.StartUp(in, out)
+
+	wait := func() {
+		select {
+		case <-logger.logged:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for log entry")
+		}
+	}
+	serverOut.Write([]byte(`{"jsonrpc":"2.0","method":"block_added","params":{}}` + "\n\n"))
+	wait()
+	serverOut.Write([]byte(`{"jsonrpc":"2.0","result":4,"id":7}` + "\n\n"))
+	wait()
+	serverOut.Write([]byte(`{"jsonrpc":"2.0","result":}` + "\n\n"))
+	wait()
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	assert.Equal(t, []string{
+		"info: No handler for notification block_added",
+		"info: No return channel found for response with id 7",
+		"error: invalid character '}' looking for beginning of value",
+	}, logger.entries)
+}
+
+func TestClientInterceptors(t *testing.T) {
+	server := jrpc2.NewReplayServer(&jrpc2.Fixture{
+		Method: "subtract",
+		Params: json.RawMessage(`{"minuend":10,"subtrahend":1}`),
+		Result: json.RawMessage(`9`),
+	})
+	client := jrpc2.NewClient()
+	client.SetTimeout(1)
+	var order []string
+	var mu sync.Mutex
+	note := func(s string) {
+		mu.Lock()
+		order = append(order, s)
+		mu.Unlock()
+	}
+	client.OnRequest(func(req *jrpc2.Request) {
+		note("request " + req.Method.Name())
+	})
+	// rewrites the request, so it matches the fixture
+	client.OnRequest(func(req *jrpc2.Request) {
+		if sub, ok := req.Method.(*ClientSubtract); ok {
+			req.Method = &ClientSubtract{sub.Minuend * 2, sub.Subtrahend}
+		}
+	})
+	client.OnResponse(func(resp *jrpc2.RawResponse) {
+		note("response " + string(resp.Raw))
+		resp.Raw = json.RawMessage(`90`)
+	})
+	go client.ConnStart(server.Pipe(), nil)
+	defer client.Shutdown()
+
+	answer, err := subtract(client, 5, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 90, answer)
+	assert.Empty(t, server.Unmatched())
+	assert.Equal(t, []string{"request subtract", "response 9"}, order)
+}
+
 // double check that we're sending out requests with
 // incremented ids
 func TestClientCheckIdIncrement(t *testing.T) {
@@ -245,6 +372,41 @@ func TestClientShutdown(t *testing.T) {
 	}
 }
 
+func TestClientConcurrentRequests(t *testing.T) {
+	s, in, out := setupServer(t)
+	s.Register(&Subtract{})
+	client := jrpc2.NewClient()
+	go client.StartUp(in, out)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			answer, err := subtract(client, 100, i)
+			assert.Nil(t, err)
+			assert.Equal(t, 100-i, answer)
+		}(i)
+	}
+	wg.Wait()
+
+	// calls racing a shutdown fail, rather than hang or panic
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := subtract(client, 100, i); err != nil {
+				assert.Contains(t, []string{"Client is shutdown", "Pipe closed unexpectedly, nil result"}, err.Error())
+			}
+		}(i)
+	}
+	client.Shutdown()
+	wg.Wait()
+	assert.False(t, client.IsUp())
+	_, err := subtract(client, 1, 1)
+	assert.EqualError(t, err, "Client is shutdown")
+}
+
 // a notification should:
 //  - not have an id
 //  - return immediately
@@ -277,6 +439,66 @@ func TestClientNoResponse(t *testing.T) {
 	assert.Equal(t, "Request timed out", err.Error())
 }
 
+func TestClientRequestRaw(t *testing.T) {
+	s, in, out := setupServer(t)
+	s.Register(&Subtract{})
+	client := jrpc2.NewClient()
+	go client.StartUp(in, out)
+
+	var answer int
+	raw, err := client.RequestRaw(&ClientSubtract{8, 2}, &answer)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, answer)
+	assert.Equal(t, "6", string(raw))
+
+	raw, err = client.RequestRawNoTimeout(&ClientSubtract{8, 5}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "3", string(raw))
+}
+
+func TestClientRequestCtx(t *testing.T) {
+	s, in, out := setupServer(t)
+	s.Register(&Subtract{})
+	client := jrpc2.NewClient()
+	go client.StartUp(in, out)
+
+	var answer int
+	err := client.RequestCtx(context.Background(), &ClientSubtract{8, 2}, &answer)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, answer)
+
+	raw, err := client.RequestRawCtx(context.Background(), &ClientSubtract{8, 5}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "3", string(raw))
+}
+
+func TestClientRequestCtxCancelled(t *testing.T) {
+	in, out, _, _ := setupWritePipes(t)
+	client := jrpc2.NewClient()
+	go client.StartUp(in, out)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var answer int
+	err := client.RequestCtx(ctx, &ClientSubtract{5, 1}, &answer)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestClientRequestWithContextCancel(t *testing.T) {
+	in, out, _, _ := setupWritePipes(t)
+	client := jrpc2.NewClient()
+	go client.StartUp(in, out)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	var answer int
+	err := client.RequestWithContext(ctx, &ClientSubtract{5, 1}, &answer)
+	assert.Equal(t, context.Canceled, err)
+}
+
 func subtract(client *jrpc2.Client, minuend, subtrahend int) (int, error) {
 	var response int
 	err := client.Request(&ClientSubtract{minuend, subtrahend}, &response)
@@ -326,3 +548,40 @@ func setupWritePipes(t *testing.T) (in, out, serverIn, serverOut *os.File) {
 
 	return in, out, serverIn, serverOut
 }
+
+func TestClientStartUpSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jrpc2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "lightning-rpc")
+
+	client := jrpc2.NewClient()
+	err = client.StartUpSocket(socket)
+	assert.EqualError(t, err, fmt.Sprintf("Unable to dial socket %s:dial unix %s: connect: no such file or directory", socket, socket))
+
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	server := jrpc2.NewReplayServer(&jrpc2.Fixture{
+		Method: "subtract",
+		Result: json.RawMessage(`4`),
+	})
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		server.Serve(conn)
+	}()
+
+	assert.Nil(t, client.StartUpSocket(socket))
+	defer client.Shutdown()
+	answer, err := subtract(client, 5, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, answer)
+}
@@ -0,0 +1,122 @@
+package jrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// Talk to a JSON-RPC endpoint behind an HTTP gateway, such as a
+// bridge to lightning-rpc: each request (or batch) is POSTed to
+// {endpoint}, and the body of the reply read as its response.
+// {httpClient} may be nil, for http.DefaultClient; give one with its
+// own Transport to add authentication, TLS settings and the like.
+//
+// Requests are posted concurrently, and their responses matched up
+// by id just as on a stream. Returns straight away; calls fail once
+// the client shuts down.
+func (c *Client) StartUpHTTP(endpoint string, httpClient *http.Client) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("Must provide an http(s) url, not %s", endpoint)
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	go c.postQueue(endpoint, httpClient, c.start())
+	return nil
+}
+
+func (c *Client) postQueue(endpoint string, httpClient *http.Client, done chan struct{}) {
+	// in-flight posts are abandoned at shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for {
+		select {
+		case request := <-c.requestQueue:
+			go c.post(ctx, endpoint, httpClient, request)
+		case <-done:
+			return
+		}
+	}
+}
+
+func (c *Client) post(ctx context.Context, endpoint string, httpClient *http.Client, request interface{}) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		c.log().Error(err.Error())
+		return
+	}
+	if debugIO(false) {
+		c.log().Debug(string(data))
+	}
+	body, err := c.doPost(ctx, endpoint, httpClient, data)
+	if err != nil {
+		c.failRequest(request, err)
+		return
+	}
+	// notifications get nothing back
+	if len(bytes.TrimSpace(body)) == 0 {
+		return
+	}
+	if err := c.readFrame(bytes.TrimSpace(body)); err != nil {
+		c.failRequest(request, err)
+	}
+}
+
+func (c *Client) doPost(ctx context.Context, endpoint string, httpClient *http.Client, data []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// gateways commonly send JSON-RPC errors with a failure status,
+	// so a body is read as a response whatever the status
+	if resp.StatusCode/100 != 2 && len(bytes.TrimSpace(body)) == 0 {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return body, nil
+}
+
+// Fail the calls waiting on {request}, which got no response
+func (c *Client) failRequest(request interface{}, err error) {
+	var ids []*Id
+	switch r := request.(type) {
+	case *Request:
+		ids = append(ids, r.Id)
+	case batchRequest:
+		for _, req := range r {
+			ids = append(ids, req.Id)
+		}
+	}
+	for _, id := range ids {
+		if id == nil {
+			continue
+		}
+		if replyChan, ok := c.pending.LoadAndDelete(id.Val()); ok {
+			replyChan.(chan *RawResponse) <- &RawResponse{
+				Id: id,
+				Error: &RpcError{
+					Code:    InternalErr,
+					Message: err.Error(),
+				},
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package jrpc2_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientStartUpHTTP(t *testing.T) {
+	fixtures, err := jrpc2.ReadFixtures(strings.NewReader(`
+{"method":"subtract","params":{"minuend":8,"subtrahend":2},"result":6}
+{"method":"subtract","params":{"minuend":8,"subtrahend":5},"result":3}
+{"method":"subtract","error":{"code":-32602,"message":"Bad subtraction"}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(jrpc2.NewReplayServer(fixtures...))
+	defer server.Close()
+
+	client := jrpc2.NewClient()
+	assert.Nil(t, client.StartUpHTTP(server.URL, nil))
+	defer client.Shutdown()
+
+	answer, err := subtract(client, 8, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, answer)
+	_, err = subtract(client, 1, 1)
+	assert.EqualError(t, err, "-32602:Bad subtraction")
+
+	results, err := client.Batch([]jrpc2.Method{&ClientSubtract{8, 5}, &ClientSubtract{8, 2}})
+	assert.Nil(t, err)
+	var first, second int
+	assert.Nil(t, results[0].Decode(&first))
+	assert.Nil(t, results[1].Decode(&second))
+	assert.Equal(t, []int{3, 6}, []int{first, second})
+
+	assert.Nil(t, client.Notify(&ClientSubtract{8, 2}))
+
+	assert.EqualError(t, jrpc2.NewClient().StartUpHTTP("unix:///tmp/rpc", nil), "Must provide an http(s) url, not unix:///tmp/rpc")
+}
+
+func TestClientStartUpHTTPFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := jrpc2.NewClient()
+	assert.Nil(t, client.StartUpHTTP(server.URL, nil))
+	defer client.Shutdown()
+	var answer json.RawMessage
+	err := client.Request(&ClientSubtract{8, 2}, &answer)
+	assert.EqualError(t, err, "-32603:HTTP 502 Bad Gateway")
+}
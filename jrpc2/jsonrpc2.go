@@ -116,6 +116,25 @@ type RawResponse struct {
 	Id    *Id             `json:"id"`
 	Raw   json.RawMessage `json:"-"`
 	Error *RpcError       `json:"error,omitempty"`
+	// the pooled buffer backing Raw, if any
+	buf *[]byte
+	// set instead, if this is a notification from the server
+	method string
+	params json.RawMessage
+}
+
+// Return the buffer backing Raw to the pool. Raw mustn't be used
+// afterwards.
+func (r *RawResponse) release() {
+	if r.buf == nil {
+		return
+	}
+	if cap(r.Raw) > cap(*r.buf) {
+		*r.buf = r.Raw
+	}
+	putBuffer(r.buf)
+	r.buf = nil
+	r.Raw = nil
 }
 
 type Result interface{}
@@ -131,23 +150,45 @@ func (e *RpcError) ParseData(into interface{}) error {
 	return json.Unmarshal(e.Data, into)
 }
 
+// Whether the error came with a `data` member
+func (e *RpcError) HasData() bool {
+	return len(e.Data) > 0 && string(e.Data) != "null"
+}
+
+// Decode the error's `data` member into {into}, failing if
+// the error didn't have one
+func (e *RpcError) UnmarshalData(into interface{}) error {
+	if !e.HasData() {
+		return fmt.Errorf("Error %d has no data", e.Code)
+	}
+	return json.Unmarshal(e.Data, into)
+}
+
 func (e *RpcError) Error() string {
 	return fmt.Sprintf("%d:%s", e.Code, e.Message)
 }
 
+// Lets errors.Is match an RpcError against a value standing for its
+// code: any error with an RpcErrorCode method, such as the sentinel
+// errors glightning defines for lightningd's codes
+func (e *RpcError) Is(target error) bool {
+	coded, ok := target.(interface{ RpcErrorCode() int })
+	return ok && coded.RpcErrorCode() == e.Code
+}
+
 // What we really want is the parameter values off of
 // the Method object
 // called on the client side
 func (r *Request) MarshalJSON() ([]byte, error) {
 	type Alias Request
 	return json.Marshal(&struct {
-		Version string                 `json:"jsonrpc"`
-		Name    string                 `json:"method"`
-		Params  map[string]interface{} `json:"params"`
+		Version string      `json:"jsonrpc"`
+		Name    string      `json:"method"`
+		Params  interface{} `json:"params"`
 		*Alias
 	}{
 		Alias:   (*Alias)(r),
-		Params:  GetNamedParams(r.Method),
+		Params:  requestParams(r.Method),
 		Version: specVersion,
 		Name:    r.Method.Name(),
 	})
@@ -222,9 +263,14 @@ func (r *RawResponse) UnmarshalJSON(data []byte) error {
 	raw := &struct {
 		Version string          `json:"jsonrpc"`
 		Result  json.RawMessage `json:"result,omitempty"`
+		Method  string          `json:"method,omitempty"`
+		Params  json.RawMessage `json:"params,omitempty"`
 		*Alias
 	}{
-		Alias: (*Alias)(r),
+		// decoding into Raw's existing capacity, if it has any,
+		// saves allocating for the result
+		Result: r.Raw[:0],
+		Alias:  (*Alias)(r),
 	}
 	err := json.Unmarshal(data, &raw)
 	if err != nil {
@@ -232,8 +278,10 @@ func (r *RawResponse) UnmarshalJSON(data []byte) error {
 	}
 	// map these together
 	r.Raw = raw.Result
+	r.method = raw.Method
+	r.params = raw.Params
 
-	if len(r.Raw) == 0 && r.Error == nil {
+	if len(r.Raw) == 0 && r.Error == nil && r.method == "" {
 		return errors.New("Must send either a result or an error in a response")
 	}
 	return nil
@@ -261,6 +309,36 @@ func GetParams(target Method) []interface{} {
 	return params
 }
 
+// {target}'s fields as positional params, in the order they're
+// declared. Unset 'omitempty' fields are sent as null, to keep the
+// place of those after them without giving a value, and left off
+// altogether at the end.
+func GetPositionalParams(target Method) []interface{} {
+	params := make([]interface{}, 0)
+	v := reflect.ValueOf(target)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	typeOf := v.Type()
+	// how many params there are, up to the last one that's set
+	keep := 0
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		fType := typeOf.Field(i)
+		if !field.CanInterface() {
+			continue
+		}
+		tag, _ := fType.Tag.Lookup("json")
+		if _, omit := parseTag(tag); omit && isZero(field.Interface()) {
+			params = append(params, nil)
+			continue
+		}
+		params = append(params, field.Interface())
+		keep = len(params)
+	}
+	return params[:keep]
+}
+
 func parseTag(tag string) (name string, omitempty bool) {
 	omitempty = false
 	name = ""
@@ -278,7 +356,46 @@ func parseTag(tag string) (name string, omitempty bool) {
 	return name, omitempty
 }
 
+// A Method whose parameters are only known at runtime can
+// provide them directly, instead of as struct fields
+type NamedParamsMethod interface {
+	Method
+	NamedParams() map[string]interface{}
+}
+
+// A Method whose params are sent as an array, in order, rather than
+// as an object; for commands, or servers, that only take them that
+// way. A struct can list its fields with GetPositionalParams:
+//
+//	func (r *Request) PositionalParams() []interface{} {
+//		return jrpc2.GetPositionalParams(r)
+//	}
+type PositionalMethod interface {
+	Method
+	PositionalParams() []interface{}
+}
+
+// The params to send for {target}: an array, if it's a
+// PositionalMethod, or else a map of its named params
+func requestParams(target Method) interface{} {
+	if m, ok := target.(PositionalMethod); ok {
+		params := m.PositionalParams()
+		if params == nil {
+			params = make([]interface{}, 0)
+		}
+		return params
+	}
+	return GetNamedParams(target)
+}
+
 func GetNamedParams(target Method) map[string]interface{} {
+	if m, ok := target.(NamedParamsMethod); ok {
+		params := m.NamedParams()
+		if params == nil {
+			params = make(map[string]interface{})
+		}
+		return params
+	}
 	params := make(map[string]interface{})
 	v := reflect.ValueOf(target)
 	if v.Kind() == reflect.Ptr {
@@ -399,7 +516,8 @@ func innerParse(targetValue reflect.Value, fVal reflect.Value, value interface{}
 	if fVal.Kind() == v.Kind() &&
 		fVal.Kind() != reflect.Map &&
 		fVal.Kind() != reflect.Slice {
-		fVal.Set(v)
+		// named types (e.g. `type Foo string`) need converting
+		fVal.Set(v.Convert(fVal.Type()))
 		return nil
 	}
 
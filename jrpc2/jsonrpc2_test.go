@@ -151,6 +151,25 @@ func TestSimpleNamedParamParsing(t *testing.T) {
 	assert.Equal(t, second, hm2.Second, "The named param Second should be three")
 }
 
+type Color string
+
+type Painted struct {
+	Color Color `json:"color"`
+}
+
+func (p Painted) Name() string {
+	return "painted"
+}
+
+func TestNamedTypeParamParsing(t *testing.T) {
+	params := map[string]interface{}{"color": "blue"}
+
+	p := &Painted{}
+	err := jrpc2.ParseNamedParams(p, params)
+	assert.Nil(t, err)
+	assert.Equal(t, Color("blue"), p.Color)
+}
+
 type Outer struct {
 	Method HelloMethod `json:"method"`
 }
@@ -194,6 +213,54 @@ func (o OuterP) Call() (jrpc2.Result, error) {
 	return "", nil
 }
 
+type PositionalPay struct {
+	Bolt11     string  `json:"bolt11"`
+	Msat       uint64  `json:"msat,omitempty"`
+	Label      string  `json:"label,omitempty"`
+	RiskFactor float32 `json:"riskfactor,omitempty"`
+}
+
+func (p *PositionalPay) Name() string {
+	return "pay"
+}
+
+func (p *PositionalPay) New() interface{} {
+	return &PositionalPay{}
+}
+
+func (p *PositionalPay) Call() (jrpc2.Result, error) {
+	return nil, nil
+}
+
+func (p *PositionalPay) PositionalParams() []interface{} {
+	return jrpc2.GetPositionalParams(p)
+}
+
+func TestPositionalParams(t *testing.T) {
+	// an unset field before a set one keeps its place, as null;
+	// unset ones at the end are left off
+	data, err := json.Marshal(&jrpc2.Request{
+		Id:     jrpc2.NewIdAsInt(1),
+		Method: &PositionalPay{Bolt11: "lnbc1", Label: "coffee"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","method":"pay","params":["lnbc1",null,"coffee"],"id":1}`, string(data))
+
+	data, err = json.Marshal(&jrpc2.Request{
+		Id:     jrpc2.NewIdAsInt(2),
+		Method: &PositionalPay{Bolt11: "lnbc1"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","method":"pay","params":["lnbc1"],"id":2}`, string(data))
+
+	// and the server maps them back onto the method's fields
+	s := jrpc2.NewServer()
+	s.Register(&PositionalPay{})
+	var req jrpc2.Request
+	assert.Nil(t, s.Unmarshal(data, &req))
+	assert.Equal(t, &PositionalPay{Bolt11: "lnbc1"}, req.Method)
+}
+
 func TestPtrsNamedParamParsing(t *testing.T) {
 	first := int64(2)
 	second := int64(3)
@@ -639,6 +706,39 @@ func TestErrorMethod(t *testing.T) {
 	assert.Equal(t, "You've got yourself an error", resp.Error.Message)
 }
 
+type DataErroringMethod struct{}
+
+func (e DataErroringMethod) New() interface{} {
+	return &DataErroringMethod{}
+}
+func (e DataErroringMethod) Call() (jrpc2.Result, error) {
+	return nil, &jrpc2.RpcError{Code: 204, Message: "failed", Data: json.RawMessage(`{"erring_index":2}`)}
+}
+
+func (e DataErroringMethod) Name() string {
+	return "dataerror"
+}
+
+func TestErrorData(t *testing.T) {
+	resp := jrpc2.Execute(nil, &DataErroringMethod{})
+	out, err := json.Marshal(resp)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":204,"message":"failed","data":{"erring_index":2}},"id":null}`, string(out))
+
+	var uResp jrpc2.RawResponse
+	assert.Nil(t, json.Unmarshal(out, &uResp))
+	assert.True(t, uResp.Error.HasData())
+	var data struct {
+		ErringIndex int `json:"erring_index"`
+	}
+	assert.Nil(t, uResp.Error.UnmarshalData(&data))
+	assert.Equal(t, 2, data.ErringIndex)
+
+	noData := &jrpc2.RpcError{Code: -32602, Message: "bad params", Data: json.RawMessage(`null`)}
+	assert.False(t, noData.HasData())
+	assert.EqualError(t, noData.UnmarshalData(&data), "Error -32602 has no data")
+}
+
 func TestServerRegistry(t *testing.T) {
 	server := jrpc2.NewServer()
 	method := &ErroringMethod{}
@@ -49,7 +49,7 @@ var vectors = []struct {
 	{`{"jsonrpc":"2.0","method":1,"params":"bar"}\n\n`,
 		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`},
 	{`[{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}]\n\n`,
-		`{"jsonrpc":"2.0","error":{"code":-32603,"message":"This server can't handle batch requests"},"id":null}`},
+		`[{"jsonrpc":"2.0","result":19,"id":1}]`},
 }
 
 func setupFiles(t *testing.T, fileName string) (socket *os.File) {
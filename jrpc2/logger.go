@@ -0,0 +1,40 @@
+package jrpc2
+
+import (
+	"log"
+)
+
+// Where the client and server report what goes wrong as they run:
+// messages they can't parse, responses no one's waiting for, and the
+// like. Implement it to send these into an application's own logging.
+//
+// Debug is only used for the traffic logged when GOLIGHT_DEBUG_IO (or
+// GOLIGHT_DEBUG_IO_IN) is set.
+type Logger interface {
+	Debug(msg string)
+	Info(msg string)
+	Error(msg string)
+}
+
+// The default Logger, which prints every level with the stdlib's log
+type stdLogger struct{}
+
+func (stdLogger) Debug(msg string) {
+	log.Print(msg)
+}
+
+func (stdLogger) Info(msg string) {
+	log.Print(msg)
+}
+
+func (stdLogger) Error(msg string) {
+	log.Print(msg)
+}
+
+// {logger}, or the default if it's nil
+func orStdLogger(logger Logger) Logger {
+	if logger == nil {
+		return stdLogger{}
+	}
+	return logger
+}
@@ -0,0 +1,59 @@
+package jrpc2
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Metrics is told about each call a client makes, so it can be
+// monitored, e.g. by counters and histograms registered with
+// Prometheus (see the README). Its methods are called from many
+// goroutines at once, and should be quick.
+type Metrics interface {
+	// A request for {method} was sent; notifications included
+	RequestSent(method string)
+	// The server answered a call of {method}, {latency} after it was
+	// sent; with {rpcErr}, if it failed
+	ResponseReceived(method string, latency time.Duration, rpcErr *RpcError)
+	// A call of {method} got no answer: it timed out, was cancelled,
+	// or the client shut down
+	CallFailed(method string, err error)
+	// How many calls are waiting on a response, each time it changes
+	QueueDepth(depth int)
+}
+
+// Report each call the client makes to {metrics}. Passing nil stops
+// reporting. Set it before starting up.
+func (c *Client) SetMetrics(metrics Metrics) {
+	c.metrics = metrics
+}
+
+// Another call is waiting on its response
+func (c *Client) callStarted() {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.QueueDepth(int(atomic.AddInt64(&c.waiting, 1)))
+}
+
+// The call of {req}, sent at {sent}, is over: answered by {rawResp},
+// or failed with {err}
+func (c *Client) callDone(req *Request, sent time.Time, rawResp *RawResponse, err error) {
+	if c.metrics == nil {
+		return
+	}
+	method := req.Method.Name()
+	if rawResp != nil {
+		c.metrics.ResponseReceived(method, time.Since(sent), rawResp.Error)
+	} else {
+		c.metrics.CallFailed(method, err)
+	}
+	c.metrics.QueueDepth(int(atomic.AddInt64(&c.waiting, -1)))
+}
+
+func (c *Client) requestSent(req *Request) {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.RequestSent(req.Method.Name())
+}
@@ -0,0 +1,87 @@
+package jrpc2_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingMetrics struct {
+	mu        sync.Mutex
+	sent      []string
+	received  []string
+	failed    []string
+	depths    []int
+	latencies []time.Duration
+}
+
+func (m *recordingMetrics) RequestSent(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, method)
+}
+
+func (m *recordingMetrics) ResponseReceived(method string, latency time.Duration, rpcErr *jrpc2.RpcError) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code := 0
+	if rpcErr != nil {
+		code = rpcErr.Code
+	}
+	m.received = append(m.received, fmt.Sprintf("%s %d", method, code))
+	m.latencies = append(m.latencies, latency)
+}
+
+func (m *recordingMetrics) CallFailed(method string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failed = append(m.failed, fmt.Sprintf("%s %s", method, err))
+}
+
+func (m *recordingMetrics) QueueDepth(depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.depths = append(m.depths, depth)
+}
+
+func TestClientMetrics(t *testing.T) {
+	server := jrpc2.NewReplayServer(
+		&jrpc2.Fixture{Method: "subtract", Result: json.RawMessage(`4`)},
+		&jrpc2.Fixture{Method: "add", Error: &jrpc2.RpcError{Code: 205, Message: "Unable to find a route"}},
+	)
+	metrics := &recordingMetrics{}
+	client := jrpc2.NewClient()
+	client.SetTimeout(1)
+	client.SetMetrics(metrics)
+	go client.ConnStart(server.Pipe(), nil)
+
+	answer, err := subtract(client, 5, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, answer)
+	var sum int
+	assert.NotNil(t, client.Request(&ClientAdd{}, &sum))
+	assert.Nil(t, client.Notify(&ClientSubtract{1, 1}))
+
+	results, err := client.Batch([]jrpc2.Method{&ClientSubtract{5, 1}, &ClientAdd{}})
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+
+	client.Shutdown()
+	_, err = subtract(client, 2, 1)
+	assert.EqualError(t, err, "Client is shutdown")
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	assert.Equal(t, []string{"subtract", "add", "subtract", "subtract", "add"}, metrics.sent)
+	assert.Equal(t, []string{"subtract 0", "add 205", "subtract 0", "add 205"}, metrics.received)
+	assert.Equal(t, []string{"subtract Client is shutdown"}, metrics.failed)
+	assert.Equal(t, []int{1, 0, 1, 0, 1, 2, 1, 0, 1, 0}, metrics.depths)
+	for _, latency := range metrics.latencies {
+		assert.True(t, latency > 0)
+	}
+}
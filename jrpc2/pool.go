@@ -0,0 +1,33 @@
+package jrpc2
+
+import (
+	"sync"
+)
+
+// Responses are read into pooled buffers, so a long-running client
+// isn't allocating, and collecting, a fresh buffer the size of every
+// result it reads; some, like listchannels on mainnet, run to tens
+// of megabytes.
+
+// Buffers which have grown larger than this are left to the garbage
+// collector rather than pinned in the pool
+const maxPooledBuffer = 64 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, 4096)
+		return &buf
+	},
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(buf *[]byte) {
+	if cap(*buf) > maxPooledBuffer {
+		return
+	}
+	*buf = (*buf)[:0]
+	bufferPool.Put(buf)
+}
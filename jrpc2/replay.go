@@ -0,0 +1,284 @@
+package jrpc2
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// A canned exchange for a ReplayServer: a request, and the response
+// the server sent to it. The fields are the request's and response's
+// own members, so a recorded pair of frames maps straight onto one.
+type Fixture struct {
+	Method string `json:"method"`
+	// The request's params; if unset, any params match
+	Params json.RawMessage `json:"params,omitempty"`
+	// Either a result or an error to reply with
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *RpcError       `json:"error,omitempty"`
+}
+
+// Read fixtures from {path}: a sequence of Fixture objects, such as
+// one per line
+func LoadFixtures(path string) ([]*Fixture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadFixtures(f)
+}
+
+// Read a sequence of Fixture objects from {in}
+func ReadFixtures(in io.Reader) ([]*Fixture, error) {
+	var fixtures []*Fixture
+	decoder := json.NewDecoder(in)
+	for {
+		var fixture Fixture
+		err := decoder.Decode(&fixture)
+		if err == io.EOF {
+			return fixtures, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if fixture.Method == "" {
+			return nil, fmt.Errorf("Fixture %d has no method", len(fixtures))
+		}
+		fixtures = append(fixtures, &fixture)
+	}
+}
+
+// ReplayServer answers requests from canned fixtures, so a client can
+// be tested against exactly what a real server once sent:
+//
+//	fixtures, err := jrpc2.LoadFixtures("testdata/getinfo.json")
+//	server := jrpc2.NewReplayServer(fixtures...)
+//	ln := glightning.NewLightning()
+//	ln.StartConn(server.Pipe())
+//
+// A request is answered by a fixture with its method and params, or
+// failing that by one with its method and no params. Several fixtures
+// for the same request are replayed in turn, the last being repeated
+// once the others are used up. Requests without a fixture get a
+// MethodNotFound error, and are noted in Unmatched.
+//
+// It's also an http.Handler, to stand in for an HTTP gateway.
+type ReplayServer struct {
+	mu        sync.Mutex
+	fixtures  []*Fixture
+	used      []bool
+	unmatched []string
+}
+
+func NewReplayServer(fixtures ...*Fixture) *ReplayServer {
+	s := &ReplayServer{}
+	s.Add(fixtures...)
+	return s
+}
+
+// Add more fixtures, after those already loaded
+func (s *ReplayServer) Add(fixtures ...*Fixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fixtures = append(s.fixtures, fixtures...)
+	s.used = append(s.used, make([]bool, len(fixtures))...)
+}
+
+// The methods of requests no fixture matched, in the order they came
+func (s *ReplayServer) Unmatched() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.unmatched...)
+}
+
+// How many fixtures have yet to be replayed
+func (s *ReplayServer) Remaining() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remaining := 0
+	for _, used := range s.used {
+		if !used {
+			remaining++
+		}
+	}
+	return remaining
+}
+
+// Serve one end of an in-memory pipe, returning the other end for a
+// client to use. The pipe closes when the client closes its end.
+func (s *ReplayServer) Pipe() net.Conn {
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		s.Serve(server)
+	}()
+	return client
+}
+
+// Answer requests read from {conn} until it's closed
+func (s *ReplayServer) Serve(conn io.ReadWriter) error {
+	decoder := json.NewDecoder(conn)
+	out := bufio.NewWriter(conn)
+	twoNewlines := []byte("\n\n")
+	for {
+		var msg json.RawMessage
+		err := decoder.Decode(&msg)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp, err := s.answer(msg)
+		if err != nil {
+			return err
+		}
+		// notifications get no reply
+		if resp == nil {
+			continue
+		}
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		out.Write(append(data, twoNewlines...))
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// Answer requests POSTed over HTTP, one (or one batch) per post, so
+// the server can stand in for an HTTP gateway
+func (s *ReplayServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp, err := s.answer(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// The reply to {msg}: a *Response, a slice of them for a batch, or
+// nil if there's nothing to send back
+func (s *ReplayServer) answer(msg json.RawMessage) (interface{}, error) {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) > 0 && msg[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(msg, &batch); err != nil {
+			return nil, err
+		}
+		var replies []*Response
+		for _, m := range batch {
+			resp, err := s.answerOne(m)
+			if err != nil {
+				return nil, err
+			}
+			if resp != nil {
+				replies = append(replies, resp)
+			}
+		}
+		if len(replies) == 0 {
+			return nil, nil
+		}
+		return replies, nil
+	}
+	resp, err := s.answerOne(msg)
+	if resp == nil || err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *ReplayServer) answerOne(msg json.RawMessage) (*Response, error) {
+	var request struct {
+		Id     *Id             `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(msg, &request); err != nil {
+		return nil, err
+	}
+	resp := s.reply(request.Method, request.Params)
+	if request.Id == nil {
+		return nil, nil
+	}
+	resp.Id = request.Id
+	return resp, nil
+}
+
+func (s *ReplayServer) reply(method string, params json.RawMessage) *Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	match := s.match(method, params, false)
+	if match < 0 {
+		match = s.match(method, params, true)
+	}
+	if match < 0 {
+		s.unmatched = append(s.unmatched, method)
+		return &Response{
+			Error: &RpcError{
+				Code:    MethodNotFound,
+				Message: fmt.Sprintf("No fixture for %s", method),
+			},
+		}
+	}
+
+	s.used[match] = true
+	fixture := s.fixtures[match]
+	if fixture.Error != nil {
+		return &Response{Error: fixture.Error}
+	}
+	return &Response{Result: fixture.Result}
+}
+
+// The first unused fixture for the request, or else the last used
+// one; -1 if there's none. Call with mu held.
+func (s *ReplayServer) match(method string, params json.RawMessage, anyParams bool) int {
+	match := -1
+	for i, fixture := range s.fixtures {
+		if fixture.Method != method || (fixture.Params == nil) != anyParams {
+			continue
+		}
+		if !anyParams && !sameParams(fixture.Params, params) {
+			continue
+		}
+		match = i
+		if !s.used[i] {
+			break
+		}
+	}
+	return match
+}
+
+// Whether {params} match a fixture's {want}, ignoring formatting and
+// the order of object members
+func sameParams(want, params json.RawMessage) bool {
+	if bytes.Equal(want, params) {
+		return true
+	}
+	var w, p interface{}
+	if json.Unmarshal(want, &w) != nil || json.Unmarshal(params, &p) != nil {
+		return false
+	}
+	return reflect.DeepEqual(w, p)
+}
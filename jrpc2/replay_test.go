@@ -0,0 +1,55 @@
+package jrpc2_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayServer(t *testing.T) {
+	fixtures, err := jrpc2.ReadFixtures(strings.NewReader(`
+{"method":"subtract","params":{"minuend":8,"subtrahend":2},"result":6}
+{"method":"subtract","params":{"subtrahend":1,"minuend":8},"result":7}
+{"method":"subtract","params":{"subtrahend":1,"minuend":8},"result":70}
+{"method":"subtract","error":{"code":-32602,"message":"Bad subtraction"}}
+`))
+	assert.Nil(t, err)
+	server := jrpc2.NewReplayServer(fixtures...)
+	client := jrpc2.NewClient()
+	go client.ConnStart(server.Pipe(), nil)
+	defer client.Shutdown()
+
+	answer, err := subtract(client, 8, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, answer)
+
+	// replayed in turn, then the last repeated
+	for _, want := range []int{7, 70, 70} {
+		answer, err = subtract(client, 8, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, want, answer)
+	}
+
+	// any params match a fixture without any
+	_, err = subtract(client, 1, 2)
+	assert.EqualError(t, err, "-32602:Bad subtraction")
+	assert.Equal(t, 0, server.Remaining())
+
+	var result interface{}
+	err = client.Request(&ClientAdd{}, &result)
+	assert.EqualError(t, err, "-32601:No fixture for add")
+	assert.Equal(t, []string{"add"}, server.Unmatched())
+}
+
+func TestReadFixturesNoMethod(t *testing.T) {
+	_, err := jrpc2.ReadFixtures(strings.NewReader(`{"result":1}`))
+	assert.EqualError(t, err, "Fixture 0 has no method")
+}
+
+type ClientAdd struct{}
+
+func (a *ClientAdd) Name() string {
+	return "add"
+}
@@ -25,12 +25,12 @@ type ServerMethod interface {
 
 // a server needs to be able to
 // - send back a response (with the right id)
-// bonus round:
-//   - respond to batched requests
+// - respond to batched requests
 type Server struct {
 	registry sync.Map // map[string]ServerMethod
 	outQueue chan interface{}
 	shutdown bool
+	logger   Logger
 }
 
 func NewServer() *Server {
@@ -40,6 +40,16 @@ func NewServer() *Server {
 	return server
 }
 
+// Send what the server logs to {logger}, instead of the stdlib's log.
+// Passing nil restores the default. Set it before starting up.
+func (s *Server) SetLogger(logger Logger) {
+	s.logger = logger
+}
+
+func (s *Server) log() Logger {
+	return orStdLogger(s.logger)
+}
+
 // Listen through a file socket
 func (s *Server) StartUpSingle(in string) {
 	ln, err := net.Listen("unix", in)
@@ -51,7 +61,7 @@ func (s *Server) StartUpSingle(in string) {
 	for !s.shutdown {
 		inConn, err := ln.Accept()
 		if err != nil {
-			log.Print(err.Error())
+			s.log().Error(err.Error())
 			continue
 		}
 		go func() {
@@ -108,7 +118,7 @@ func (s *Server) listen(in io.Reader) error {
 	for scanner.Scan() && !s.shutdown {
 		msg := scanner.Bytes()
 		if debugIO(true) {
-			log.Println(string(msg))
+			s.log().Debug(string(msg))
 		}
 		// pass down a copy so things stay sane
 		msg_buf := make([]byte, len(msg))
@@ -132,11 +142,11 @@ func (s *Server) setupWriteQueue(outWriter io.Writer) {
 	for response := range s.outQueue {
 		data, err := json.Marshal(response)
 		if err != nil {
-			log.Println(err.Error())
+			s.log().Error(err.Error())
 			continue
 		}
 		if debugIO(false) {
-			log.Println(string(data))
+			s.log().Debug(string(data))
 		}
 		// append two newlines to the outgoing message
 		data = append(data, twoNewlines...)
@@ -146,50 +156,93 @@ func (s *Server) setupWriteQueue(outWriter io.Writer) {
 }
 
 func processMsg(s *Server, data []byte) {
-	// read is done. time to figure out what we've gotten
-	if len(data) == 0 {
-		s.outQueue <- (&Response{
+	if len(data) > 0 && data[0] == '[' {
+		processBatch(s, data)
+		return
+	}
+	if resp := handleMsg(s, data); resp != nil {
+		s.outQueue <- resp
+	}
+}
+
+// Run each request of a batch, in parallel, and reply with an array
+// of their responses. Notifications get none; a batch of only those
+// gets no reply at all.
+func processBatch(s *Server, data []byte) {
+	var msgs []json.RawMessage
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		s.outQueue <- &Response{
+			Error: &RpcError{
+				Code:    ParseError,
+				Message: fmt.Sprintf("Parse error:%s", err.Error()),
+			},
+		}
+		return
+	}
+	if len(msgs) == 0 {
+		s.outQueue <- &Response{
 			Error: &RpcError{
 				Code:    InvalidRequest,
 				Message: "Invalid Request",
 			},
-		})
+		}
 		return
 	}
 
-	// right now we don't handle arrays of requests...
-	// todo: infra for batches (ie use wait group)
-	if data[0] == '[' {
-		s.outQueue <- &Response{
+	responses := make([]*Response, len(msgs))
+	var wg sync.WaitGroup
+	for i, msg := range msgs {
+		wg.Add(1)
+		go func(i int, msg []byte) {
+			defer wg.Done()
+			responses[i] = handleMsg(s, msg)
+		}(i, msg)
+	}
+	wg.Wait()
+
+	var replies []*Response
+	for _, resp := range responses {
+		if resp != nil {
+			replies = append(replies, resp)
+		}
+	}
+	if len(replies) > 0 {
+		s.outQueue <- replies
+	}
+}
+
+// The response to a single request; nil for a notification
+func handleMsg(s *Server, data []byte) *Response {
+	// read is done. time to figure out what we've gotten
+	if len(data) == 0 {
+		return &Response{
 			Error: &RpcError{
-				Code:    InternalErr,
-				Message: "This server can't handle batch requests",
+				Code:    InvalidRequest,
+				Message: "Invalid Request",
 			},
 		}
-		return
 	}
 
 	// parse the received buffer into a request object
 	var request Request
 	err := s.Unmarshal(data, &request)
 	if err != nil {
-		s.outQueue <- &Response{
+		return &Response{
 			Id: err.Id,
 			Error: &RpcError{
 				Code:    err.Code,
 				Message: err.Msg,
 			},
 		}
-		return
 	}
 
 	// this is a subscription. we won't call you back.
 	if request.Id == nil {
 		request.Method.(ServerMethod).Call()
-		return
+		return nil
 	}
 	// ok we've successfully gotten the method call out..
-	s.outQueue <- Execute(request.Id, request.Method.(ServerMethod))
+	return Execute(request.Id, request.Method.(ServerMethod))
 }
 
 func Execute(id *Id, method ServerMethod) *Response {
@@ -198,7 +251,6 @@ func Execute(id *Id, method ServerMethod) *Response {
 		Id: id,
 	}
 	if err != nil {
-		// todo: data object for errors?
 		resp.Error = constructError(err)
 	} else {
 		resp.Result = result
@@ -248,8 +300,13 @@ func (s *Server) Unregister(method ServerMethod) error {
 	return s.UnregisterByName(method.Name())
 }
 
+// Methods can return an *RpcError to pick the code and
+// attach `data`; any other error is sent as code -1
 func constructError(err error) *RpcError {
-	// todo: specify return data?
+	var rpcErr *RpcError
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
 	return &RpcError{
 		Code:    -1,
 		Message: err.Error(),
@@ -0,0 +1,119 @@
+package jrpc2_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+// Answer subtract calls on every connection {ln} accepts
+func serveReplay(ln net.Listener) {
+	server := jrpc2.NewReplayServer(&jrpc2.Fixture{
+		Method: "subtract",
+		Result: json.RawMessage(`4`),
+	})
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			server.Serve(conn)
+		}()
+	}
+}
+
+func TestClientStartUpTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go serveReplay(ln)
+
+	client := jrpc2.NewClient()
+	assert.Nil(t, client.StartUpTCP(ln.Addr().String(), nil))
+	defer client.Shutdown()
+	answer, err := subtract(client, 5, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, answer)
+}
+
+// A certificate for localhost signed by {ca}, or self-signed if nil
+func testCert(t *testing.T, ca *tls.Certificate, isCA bool) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	parent, signer := template, interface{}(key)
+	if ca != nil {
+		parent = ca.Leaf
+		signer = ca.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestClientStartUpTCPMutualTLS(t *testing.T) {
+	ca := testCert(t, nil, true)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	serverCert := testCert(t, &ca, false)
+	clientCert := testCert(t, &ca, false)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go serveReplay(ln)
+
+	client := jrpc2.NewClient()
+	err = client.StartUpTCP(ln.Addr().String(), &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{clientCert},
+	})
+	assert.Nil(t, err)
+	defer client.Shutdown()
+	answer, err := subtract(client, 5, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, answer)
+
+	// the server's certificate isn't trusted without the CA
+	err = jrpc2.NewClient().StartUpTCP(ln.Addr().String(), &tls.Config{})
+	assert.Error(t, err)
+}
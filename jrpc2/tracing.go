@@ -0,0 +1,39 @@
+package jrpc2
+
+import (
+	"context"
+)
+
+// Tracer starts a span for each call a client makes, so the calls
+// show up in an application's distributed traces, e.g. through
+// OpenTelemetry (see the README). It's called from many goroutines
+// at once.
+type Tracer interface {
+	// Start a span for the call of {method} with {id}, as a child of
+	// any span in {ctx}, the context the call was made with
+	Start(ctx context.Context, method string, id *Id) Span
+}
+
+// The span of a single call
+type Span interface {
+	// The call is over: {err} is nil if it succeeded, an *RpcError if
+	// the server failed it, or else why no answer came
+	End(err error)
+}
+
+// Trace each call the client makes with {tracer}. Passing nil stops
+// tracing. Set it before starting up.
+func (c *Client) SetTracer(tracer Tracer) {
+	c.tracer = tracer
+}
+
+type noopSpan struct{}
+
+func (noopSpan) End(err error) {}
+
+func (c *Client) startSpan(ctx context.Context, req *Request) Span {
+	if c.tracer == nil {
+		return noopSpan{}
+	}
+	return c.tracer.Start(ctx, req.Method.Name(), req.Id)
+}
@@ -0,0 +1,68 @@
+package jrpc2_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/elementsproject/glightning/jrpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+type traceKey struct{}
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []string
+}
+
+type recordingSpan struct {
+	tracer *recordingTracer
+	name   string
+}
+
+func (t *recordingTracer) Start(ctx context.Context, method string, id *jrpc2.Id) jrpc2.Span {
+	parent, _ := ctx.Value(traceKey{}).(string)
+	return &recordingSpan{t, fmt.Sprintf("%s/%s#%s", parent, method, id.Val())}
+}
+
+func (s *recordingSpan) End(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, fmt.Sprintf("%s: %v", s.name, err))
+}
+
+func TestClientTracer(t *testing.T) {
+	server := jrpc2.NewReplayServer(
+		&jrpc2.Fixture{Method: "subtract", Result: json.RawMessage(`4`)},
+		&jrpc2.Fixture{Method: "add", Error: &jrpc2.RpcError{Code: 205, Message: "Unable to find a route"}},
+	)
+	tracer := &recordingTracer{}
+	client := jrpc2.NewClient()
+	client.SetTimeout(1)
+	client.SetTracer(tracer)
+	go client.ConnStart(server.Pipe(), nil)
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "handler")
+	var answer int
+	assert.Nil(t, client.RequestCtx(ctx, &ClientSubtract{5, 1}, &answer))
+	assert.Equal(t, 4, answer)
+	_, err := client.BatchCtx(ctx, []jrpc2.Method{&ClientAdd{}})
+	assert.Nil(t, err)
+	// calls without a context get spans without a parent
+	client.Request(&ClientAdd{}, &answer)
+
+	client.Shutdown()
+	assert.NotNil(t, client.RequestCtx(ctx, &ClientSubtract{2, 1}, &answer))
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	assert.Equal(t, []string{
+		"handler/subtract#1: <nil>",
+		"handler/add#2: 205:Unable to find a route",
+		"/add#3: 205:Unable to find a route",
+		"handler/subtract#4: Client is shutdown",
+	}, tracer.spans)
+}